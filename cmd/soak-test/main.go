@@ -0,0 +1,151 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Soak Test Harness
+//
+// Drives thousands of simulated certificates through the certificate manager
+// against an in-process fake Vault for an extended period, reporting
+// throughput, goroutine counts, and heap usage to catch leaks and races
+// before production. Build with -race to enable the race detector.
+// -------------------------------------------------------------------------------
+
+// Package main provides the soak-test harness for vault-cert-manager.
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"cert-manager/pkg/vaulttest"
+
+	"github.com/spf13/pflag"
+)
+
+// -------------------------------------------------------------------------
+// MAIN
+// -------------------------------------------------------------------------
+
+func main() {
+	// --- Parse command line flags ---
+	var certCount int
+	var duration time.Duration
+	var interval time.Duration
+	var ttl time.Duration
+	var failureRate float64
+	var goroutineSlack int
+	var parallel int
+	var workDir string
+
+	pflag.IntVar(&certCount, "certs", 1000, "Number of simulated certificates")
+	pflag.DurationVar(&duration, "duration", 5*time.Minute, "Total soak duration")
+	pflag.DurationVar(&interval, "interval", 5*time.Second, "Delay between processing cycles")
+	pflag.DurationVar(&ttl, "ttl", 2*time.Minute, "TTL requested for each certificate")
+	pflag.Float64Var(&failureRate, "failure-rate", 0.05, "Probability the fake Vault fails an issue request")
+	pflag.IntVar(&goroutineSlack, "goroutine-slack", 20, "Allowed goroutine growth before the run is considered leaking")
+	pflag.IntVar(&parallel, "parallel", 8, "Certificates processed at once")
+	pflag.StringVar(&workDir, "dir", "", "Directory for certificate files (default: temporary directory)")
+	pflag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError})))
+
+	if err := run(certCount, duration, interval, ttl, failureRate, goroutineSlack, parallel, workDir); err != nil {
+		fmt.Fprintf(os.Stderr, "soak test failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("soak test passed")
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// run executes the soak test and returns an error if a leak is detected.
+func run(certCount int, duration, interval, ttl time.Duration, failureRate float64, goroutineSlack, parallel int, workDir string) error {
+	if workDir == "" {
+		dir, err := os.MkdirTemp("", "vault-cert-manager-soak-")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(dir) }()
+		workDir = dir
+	}
+
+	fake, err := vaulttest.NewServer()
+	if err != nil {
+		return err
+	}
+	defer fake.Close()
+	fake.FailureRate = failureRate
+
+	client, err := vault.NewClient(&config.VaultConfig{
+		Address: fake.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "soak-token"}},
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	manager := cert.NewManager(client)
+	manager.SetMaxParallel(parallel)
+	for i := 0; i < certCount; i++ {
+		name := fmt.Sprintf("soak-%05d", i)
+		certConfig := &config.CertificateConfig{
+			Name:        name,
+			Role:        "soak",
+			CommonName:  name + ".soak.test",
+			Certificate: filepath.Join(workDir, name+".crt"),
+			Key:         filepath.Join(workDir, name+".key"),
+			TTL:         ttl,
+		}
+		if err := manager.AddCertificate(certConfig); err != nil {
+			return err
+		}
+	}
+
+	runtime.GC()
+	baseGoroutines := runtime.NumGoroutine()
+	var baseMem runtime.MemStats
+	runtime.ReadMemStats(&baseMem)
+
+	fmt.Printf("soaking %d certificates for %s (ttl %s, failure rate %.2f)\n", certCount, duration, ttl, failureRate)
+
+	deadline := time.Now().Add(duration)
+	for cycle := 1; time.Now().Before(deadline); cycle++ {
+		start := time.Now()
+		if err := manager.ProcessCertificates(); err != nil {
+			return fmt.Errorf("cycle %d: %w", cycle, err)
+		}
+
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		summary := manager.LastSummary()
+		fmt.Printf("cycle=%d took=%s renewed=%d failed=%d issued=%d vault_failures=%d goroutines=%d heap_alloc=%dKiB\n",
+			cycle, time.Since(start).Round(time.Millisecond), summary.Renewed, summary.Failed, fake.Issued(), fake.Failed(),
+			runtime.NumGoroutine(), mem.HeapAlloc/1024)
+
+		time.Sleep(interval)
+	}
+
+	runtime.GC()
+	var endMem runtime.MemStats
+	runtime.ReadMemStats(&endMem)
+	endGoroutines := runtime.NumGoroutine()
+
+	fmt.Printf("goroutines: start=%d end=%d, heap: start=%dKiB end=%dKiB\n",
+		baseGoroutines, endGoroutines, baseMem.HeapAlloc/1024, endMem.HeapAlloc/1024)
+
+	if endGoroutines > baseGoroutines+goroutineSlack {
+		return fmt.Errorf("goroutine leak: %d at start, %d at end", baseGoroutines, endGoroutines)
+	}
+	if fake.Issued() == 0 {
+		return fmt.Errorf("no certificates were issued")
+	}
+
+	return nil
+}
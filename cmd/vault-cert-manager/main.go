@@ -10,15 +10,26 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"cert-manager/pkg/app"
+	"cert-manager/pkg/cert"
 	"cert-manager/pkg/config"
+	"cert-manager/pkg/report"
+	"cert-manager/pkg/sandbox"
 	"cert-manager/pkg/web"
 
 	"github.com/spf13/pflag"
@@ -39,24 +50,96 @@ var (
 // -------------------------------------------------------------------------
 
 func main() {
+	// --- Sandboxed hook helper (re-executed by the cert manager) ---
+	sandbox.RunHelper()
+
+	// --- Migration from certbot/acme.sh ---
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
 	// --- Parse command line flags ---
 	var configPath string
 	var showVersion bool
 	var rotateNow bool
+	var dryRun bool
 	var aggregatorMode bool
 	var consulAddr string
+	var staticNodes []string
+	var nodesFile string
+	var consulDiscovery web.ConsulDiscovery
+	var consulClient web.ConsulClientConfig
+	var consulTokenFile string
+	var srvName string
+	var srvRefresh time.Duration
+	var nodeTLS bool
+	var nodeTLSConfig web.NodeTLSConfig
 	var serviceName string
 	var aggregatorPort int
 	var rotateTimeout int
+	var rateLimit float64
+	var rateBurst int
+	var slo web.SLOConfig
+	var reportPath string
+	var reportPeriod time.Duration
+	var reportKey string
+	var chaosMode bool
+	var trustKey string
+	var strictTrust bool
+	var authUser string
+	var authPasswordFile string
+	var authTokenFile string
+	var authProtectStatus bool
+	var nodeTokenFile string
+	var oidcConfig web.OIDCConfig
+	var oidcSecretFile string
 
 	pflag.StringVarP(&configPath, "config", "c", "", "Path to config file or directory")
 	pflag.BoolVarP(&showVersion, "version", "v", false, "Show version information")
 	pflag.BoolVarP(&rotateNow, "rotate", "r", false, "Force rotate all certificates and exit")
+	pflag.BoolVar(&dryRun, "dry-run", false, "Print which certificates would be rotated and why, then exit without issuing or writing anything (with --rotate, plan a forced rotation)")
 	pflag.BoolVarP(&aggregatorMode, "aggregator", "a", false, "Run in aggregator mode (centralized dashboard)")
 	pflag.StringVar(&consulAddr, "consul-addr", "http://localhost:8500", "Consul HTTP address for service discovery")
 	pflag.StringVar(&serviceName, "service-name", "vault-cert-manager", "Consul service name to discover")
+	pflag.BoolVar(&consulDiscovery.PassingOnly, "consul-passing-only", true, "Only query instances passing their Consul health checks")
+	pflag.StringVar(&consulDiscovery.Datacenter, "consul-datacenter", "", "Consul datacenter to discover nodes in (default the agent's datacenter)")
+	pflag.StringSliceVar(&consulDiscovery.Tags, "consul-tag", nil, "Only query instances with this service tag; repeat or comma-separate for several")
+	pflag.StringVar(&consulTokenFile, "consul-token-file", "", "File holding the Consul ACL token for discovery queries (default $CONSUL_HTTP_TOKEN)")
+	pflag.StringVar(&consulClient.CAFile, "consul-ca-file", "", "CA bundle verifying Consul's HTTPS certificate (default $CONSUL_CACERT)")
+	pflag.StringVar(&consulClient.CertFile, "consul-client-cert", "", "Client certificate presented to Consul (default $CONSUL_CLIENT_CERT)")
+	pflag.StringVar(&consulClient.KeyFile, "consul-client-key", "", "Key of the Consul client certificate (default $CONSUL_CLIENT_KEY)")
+	pflag.StringVar(&consulClient.TLSServerName, "consul-tls-server-name", "", "Server name verified in Consul's certificate (default $CONSUL_TLS_SERVER_NAME)")
+	pflag.StringSliceVar(&staticNodes, "nodes", nil, "Node addresses as [name=]host:port, comma-separated, queried instead of Consul (aggregator mode)")
+	pflag.StringVar(&nodesFile, "nodes-file", "", "File of node addresses, one [name=]host:port per line, queried instead of Consul and re-read on every refresh (aggregator mode)")
+	pflag.StringVar(&srvName, "srv-name", "", "DNS SRV record to discover nodes from instead of Consul, e.g. _vault-cert-manager._tcp.example.com (aggregator mode)")
+	pflag.DurationVar(&srvRefresh, "srv-refresh", web.DefaultSRVRefresh, "How long SRV lookups are cached (aggregator mode)")
 	pflag.IntVarP(&aggregatorPort, "port", "p", 9102, "Port for aggregator dashboard")
 	pflag.IntVar(&rotateTimeout, "timeout", 120, "Timeout in seconds for rotate operations (aggregator mode)")
+	pflag.Float64Var(&rateLimit, "rate-limit", 10, "Rotate requests per minute allowed per client, 0 to disable (aggregator mode)")
+	pflag.IntVar(&rateBurst, "rate-burst", 5, "Rotate request burst allowed per client (aggregator mode)")
+	pflag.DurationVar(&slo.Lead, "slo-lead", web.DefaultSLOLead, "Renewals must happen at least this long before expiry to meet the renewal SLO (aggregator mode)")
+	pflag.DurationVar(&slo.Window, "slo-window", web.DefaultSLOWindow, "Rolling window the renewal SLO is computed over (aggregator mode)")
+	pflag.Float64Var(&slo.Target, "slo-target", web.DefaultSLOTarget, "Renewal SLO target percentage (aggregator mode)")
+	pflag.StringVar(&reportPath, "report", "", "Write a compliance report to this path (PDF for .pdf, otherwise HTML) and exit")
+	pflag.DurationVar(&reportPeriod, "report-period", report.DefaultPeriod, "Rotation history window covered by compliance reports")
+	pflag.StringVar(&reportKey, "report-key", "", "PEM private key used to sign compliance reports")
+	pflag.BoolVar(&chaosMode, "chaos", false, "Enable fault injection using the chaos config section (testing only)")
+	pflag.StringVar(&trustKey, "trust-key", "", "Public key (PEM or minisign) used to verify config file signatures")
+	pflag.BoolVar(&strictTrust, "strict-trust", false, "Refuse config files and central source documents without a valid signature (requires --trust-key)")
+	pflag.StringVar(&authUser, "auth-user", "", "Basic auth user name required to rotate, with --auth-password-file (aggregator mode)")
+	pflag.StringVar(&authPasswordFile, "auth-password-file", "", "File holding the basic auth password (aggregator mode)")
+	pflag.StringVar(&authTokenFile, "auth-token-file", "", "File of bearer tokens accepted to rotate, one per line (aggregator mode)")
+	pflag.BoolVar(&authProtectStatus, "auth-protect-status", false, "Also require credentials for the dashboard, status, and report (aggregator mode)")
+	pflag.BoolVar(&nodeTLS, "node-tls", false, "Query and rotate nodes over HTTPS, implied by --node-ca-file and --node-client-cert (aggregator mode)")
+	pflag.StringVar(&nodeTLSConfig.CAFile, "node-ca-file", "", "CA bundle verifying the nodes' HTTPS certificates (aggregator mode)")
+	pflag.StringVar(&nodeTLSConfig.CertFile, "node-client-cert", "", "Client certificate presented to nodes, for nodes with web.tls_client_ca_file (aggregator mode)")
+	pflag.StringVar(&nodeTLSConfig.KeyFile, "node-client-key", "", "Key of the node client certificate (aggregator mode)")
+	pflag.StringVar(&nodeTokenFile, "node-token-file", "", "File holding the bearer token sent to nodes that require authentication (aggregator mode)")
+	pflag.StringVar(&oidcConfig.IssuerURL, "oidc-issuer", "", "OpenID Connect issuer URL for dashboard single sign-on (aggregator mode)")
+	pflag.StringVar(&oidcConfig.ClientID, "oidc-client-id", "", "OpenID Connect client ID (aggregator mode)")
+	pflag.StringVar(&oidcSecretFile, "oidc-client-secret-file", "", "File holding the OpenID Connect client secret (aggregator mode)")
+	pflag.StringVar(&oidcConfig.RedirectURL, "oidc-redirect-url", "", "The aggregator's /auth/callback URL as registered with the provider (aggregator mode)")
+	pflag.StringSliceVar(&oidcConfig.AllowedGroups, "oidc-allowed-groups", nil, "Groups allowed to log in, comma-separated; default any authenticated user (aggregator mode)")
 	pflag.Parse()
 
 	if showVersion {
@@ -64,8 +147,21 @@ func main() {
 		os.Exit(0)
 	}
 
+	var reportSigner crypto.Signer
+	if reportKey != "" {
+		signer, err := report.LoadSigner(reportKey)
+		if err != nil {
+			slog.Error("Failed to load report signing key", "error", err)
+			os.Exit(1)
+		}
+		reportSigner = signer
+	}
+
 	// --- Aggregator mode ---
 	if aggregatorMode {
+		if addr := web.ConsulAddrFromEnv(); addr != "" && !pflag.CommandLine.Changed("consul-addr") {
+			consulAddr = addr
+		}
 		slog.Info("Starting aggregator mode",
 			"version", version,
 			"commit", commit,
@@ -75,10 +171,90 @@ func main() {
 			"timeout", rotateTimeout,
 		)
 		aggregator := web.NewAggregator(consulAddr, serviceName, time.Duration(rotateTimeout)*time.Second)
-		if err := aggregator.StartServer(aggregatorPort); err != nil {
-			slog.Error("Aggregator server failed", "error", err)
+		aggregator.SetConsulDiscovery(consulDiscovery)
+		if err := loadConsulClient(aggregator, consulClient, consulTokenFile); err != nil {
+			slog.Error("Failed to configure the Consul client", "error", err)
 			os.Exit(1)
 		}
+		if srvName != "" && (len(staticNodes) > 0 || nodesFile != "") {
+			slog.Error("--srv-name cannot be combined with --nodes or --nodes-file")
+			os.Exit(1)
+		}
+		if srvName != "" {
+			aggregator.SetSRVDiscovery(srvName, srvRefresh)
+		}
+		if len(staticNodes) > 0 || nodesFile != "" {
+			var nodes []web.ConsulService
+			for _, address := range staticNodes {
+				node, err := web.ParseNodeAddress(address)
+				if err != nil {
+					slog.Error("Invalid --nodes address", "error", err)
+					os.Exit(1)
+				}
+				nodes = append(nodes, node)
+			}
+			if err := aggregator.SetStaticNodes(nodes, nodesFile); err != nil {
+				slog.Error("Failed to load static nodes", "error", err)
+				os.Exit(1)
+			}
+		}
+		if reportSigner != nil {
+			aggregator.SetReportSigner(reportSigner)
+		}
+		aggregator.SetSLO(slo)
+		if rateLimit > 0 {
+			aggregator.SetRateLimiter(web.NewRateLimiter(rateLimit, rateBurst))
+		}
+		oidc, err := loadAggregatorOIDC(oidcConfig, oidcSecretFile)
+		if err != nil {
+			slog.Error("Failed to configure single sign-on", "error", err)
+			os.Exit(1)
+		}
+		auth, err := loadAggregatorAuth(authUser, authPasswordFile, authTokenFile, authProtectStatus, oidc)
+		if err != nil {
+			slog.Error("Failed to load aggregator credentials", "error", err)
+			os.Exit(1)
+		}
+		aggregator.SetAuth(auth)
+		if nodeTLS || nodeTLSConfig != (web.NodeTLSConfig{}) {
+			if err := aggregator.SetNodeTLS(nodeTLSConfig); err != nil {
+				slog.Error("Failed to configure node TLS", "error", err)
+				os.Exit(1)
+			}
+		}
+		if nodeTokenFile != "" {
+			tokens, err := readSecretLines(nodeTokenFile)
+			if err == nil && len(tokens) != 1 {
+				err = fmt.Errorf("token file %s must hold one token", nodeTokenFile)
+			}
+			if err != nil {
+				slog.Error("Failed to load node token", "error", err)
+				os.Exit(1)
+			}
+			aggregator.SetNodeToken(tokens[0])
+		}
+		serverErr := make(chan error, 1)
+		go func() { serverErr <- aggregator.StartServer(aggregatorPort) }()
+
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case err := <-serverErr:
+			if err != nil {
+				slog.Error("Aggregator server failed", "error", err)
+				os.Exit(1)
+			}
+		case <-sigChan:
+			// Let proxied rotations finish, waiting at most as long as one
+			// may take.
+			slog.Info("Shutdown signal received, stopping aggregator...")
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rotateTimeout)*time.Second)
+			defer cancel()
+			if err := aggregator.Shutdown(ctx); err != nil {
+				slog.Warn("Aggregator server did not shut down cleanly", "error", err)
+			}
+			slog.Info("Aggregator stopped")
+		}
 		return
 	}
 
@@ -87,8 +263,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if strictTrust && trustKey == "" {
+		slog.Error("--strict-trust requires --trust-key")
+		os.Exit(1)
+	}
+
 	// --- Load configuration ---
-	cfg, err := config.LoadConfig(configPath)
+	cfg, err := loadConfig(configPath, trustKey, strictTrust, chaosMode)
 	if err != nil {
 		slog.Error("Failed to load config", "error", err)
 		os.Exit(1)
@@ -100,6 +281,26 @@ func main() {
 		slog.Error("Failed to create application", "error", err)
 		os.Exit(1)
 	}
+	application.SetBuildInfo(version, commit)
+
+	// --- Compliance report mode ---
+	if reportPath != "" {
+		if err := writeReport(application, reportPath, reportPeriod, reportSigner); err != nil {
+			slog.Error("Failed to write compliance report", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Compliance report written", "path", reportPath)
+		os.Exit(0)
+	}
+
+	// --- Dry-run mode ---
+	if dryRun {
+		if err := printPlan(os.Stdout, application.Plan(rotateNow)); err != nil {
+			slog.Error("Failed to print rotation plan", "error", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
 	// --- One-shot rotation mode ---
 	if rotateNow {
@@ -128,7 +329,7 @@ func main() {
 
 	// --- Signal handling ---
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, reloadSignal)
 
 	for {
 		sig := <-sigChan
@@ -140,6 +341,15 @@ func main() {
 			} else {
 				slog.Info("Force rotation completed")
 			}
+		case reloadSignal:
+			slog.Info("SIGUSR1 received, reloading certificates from config...")
+			cfg, err := loadConfig(configPath, trustKey, strictTrust, chaosMode)
+			if err == nil {
+				err = application.ReloadConfig(cfg)
+			}
+			if err != nil {
+				slog.Error("Config reload failed, keeping current certificates", "error", err)
+			}
 		case syscall.SIGINT, syscall.SIGTERM:
 			slog.Info("Shutdown signal received, stopping application...")
 			application.Stop()
@@ -148,3 +358,203 @@ func main() {
 		}
 	}
 }
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// loadConfig loads the config, verifying its signatures when a trust key
+// is given. The key also verifies documents from the central source.
+func loadConfig(path, trustKey string, strictTrust, chaosMode bool) (*config.Config, error) {
+	var cfg *config.Config
+	if trustKey != "" {
+		key, err := config.LoadTrustKey(trustKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trust key: %w", err)
+		}
+		var warnings []string
+		cfg, warnings, err = config.LoadTrustedConfig(path, key, strictTrust)
+		if err != nil {
+			return nil, err
+		}
+		for _, warning := range warnings {
+			slog.Warn("Config file is not trusted", "problem", warning)
+		}
+		cfg.Trust = config.SourceTrust{Key: key, Strict: strictTrust}
+	} else {
+		var err error
+		if cfg, err = config.LoadConfig(path); err != nil {
+			return nil, err
+		}
+	}
+	if chaosMode {
+		cfg.Chaos.Enabled = true
+	}
+	return cfg, nil
+}
+
+// loadAggregatorAuth builds the aggregator's authentication from the
+// --auth-* flags and single sign-on, or returns nil when none were given.
+func loadAggregatorAuth(user, passwordFile, tokenFile string, protectStatus bool, oidc *web.OIDC) (*web.Auth, error) {
+	if user == "" && passwordFile == "" && tokenFile == "" && oidc == nil {
+		if protectStatus {
+			return nil, fmt.Errorf("--auth-protect-status requires --auth-user, --auth-token-file, or --oidc-issuer")
+		}
+		return nil, nil
+	}
+	if (user == "") != (passwordFile == "") {
+		return nil, fmt.Errorf("--auth-user and --auth-password-file must be set together")
+	}
+
+	var password string
+	if passwordFile != "" {
+		lines, err := readSecretLines(passwordFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) != 1 {
+			return nil, fmt.Errorf("password file %s must hold one password", passwordFile)
+		}
+		password = lines[0]
+	}
+
+	var tokens []string
+	if tokenFile != "" {
+		var err error
+		if tokens, err = readSecretLines(tokenFile); err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			return nil, fmt.Errorf("token file %s holds no tokens", tokenFile)
+		}
+	}
+	auth := web.NewAuth(user, password, tokens, protectStatus)
+	if oidc != nil {
+		auth.SetOIDC(oidc)
+	}
+	return auth, nil
+}
+
+// loadAggregatorOIDC builds single sign-on from the --oidc-* flags, or
+// returns nil when no issuer was given.
+func loadAggregatorOIDC(cfg web.OIDCConfig, secretFile string) (*web.OIDC, error) {
+	if cfg.IssuerURL == "" {
+		return nil, nil
+	}
+	if cfg.ClientID == "" || secretFile == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("--oidc-issuer requires --oidc-client-id, --oidc-client-secret-file, and --oidc-redirect-url")
+	}
+
+	lines, err := readSecretLines(secretFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) != 1 {
+		return nil, fmt.Errorf("client secret file %s must hold one secret", secretFile)
+	}
+	cfg.ClientSecret = lines[0]
+	return web.NewOIDC(cfg)
+}
+
+// loadConsulClient configures the aggregator's Consul client from the
+// --consul-* flags, falling back to the CONSUL_* environment variables for
+// settings not given as flags.
+func loadConsulClient(aggregator *web.Aggregator, flags web.ConsulClientConfig, tokenFile string) error {
+	cfg, err := web.ConsulClientConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if tokenFile != "" {
+		lines, err := readSecretLines(tokenFile)
+		if err != nil {
+			return err
+		}
+		if len(lines) != 1 {
+			return fmt.Errorf("token file %s must hold one token", tokenFile)
+		}
+		cfg.Token = lines[0]
+	}
+	if flags.CAFile != "" {
+		cfg.CAFile = flags.CAFile
+	}
+	if flags.CertFile != "" || flags.KeyFile != "" {
+		cfg.CertFile, cfg.KeyFile = flags.CertFile, flags.KeyFile
+	}
+	if flags.TLSServerName != "" {
+		cfg.TLSServerName = flags.TLSServerName
+	}
+	return aggregator.SetConsulClient(cfg)
+}
+
+// readSecretLines reads the non-empty lines of a secret file, trimmed of
+// surrounding whitespace.
+func readSecretLines(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// writeReport renders the node compliance report to path, as PDF when path
+// ends in .pdf and HTML otherwise, writing a base64 detached signature
+// alongside it when a signer is configured.
+func writeReport(application *app.App, path string, period time.Duration, signer crypto.Signer) error {
+	format := report.FormatHTML
+	if strings.EqualFold(filepath.Ext(path), ".pdf") {
+		format = report.FormatPDF
+	}
+
+	var buf bytes.Buffer
+	if err := application.WriteReport(&buf, period, format); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write report %s: %w", path, err)
+	}
+
+	if signer == nil {
+		return nil
+	}
+
+	sig, err := report.Sign(signer, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to sign report: %w", err)
+	}
+
+	sigPath := path + ".sig"
+	if err := os.WriteFile(sigPath, []byte(base64.StdEncoding.EncodeToString(sig)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write report signature %s: %w", sigPath, err)
+	}
+	return nil
+}
+
+// printPlan writes a rotation plan as a table.
+func printPlan(w io.Writer, plan []cert.PlannedRotation) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CERTIFICATE\tACTION\tREASON\tNOT AFTER\tRENEW AT")
+	for _, entry := range plan {
+		action := "skip"
+		if entry.Rotate {
+			action = "rotate"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			entry.Name, action, entry.Reason, formatPlanTime(entry.NotAfter), formatPlanTime(entry.RenewAt))
+	}
+	return tw.Flush()
+}
+
+// formatPlanTime formats t for printPlan, "-" when unset.
+func formatPlanTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
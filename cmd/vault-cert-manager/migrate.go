@@ -0,0 +1,100 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Migrate Command
+//
+// `vault-cert-manager migrate` turns certbot and acme.sh state on a host
+// into certificate definitions issued from a Vault PKI role.
+// -------------------------------------------------------------------------------
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cert-manager/pkg/migrate"
+
+	"github.com/spf13/pflag"
+)
+
+// -------------------------------------------------------------------------
+// MIGRATE
+// -------------------------------------------------------------------------
+
+// runMigrate scans ACME client directories, writes the generated config,
+// optionally copies current certificates, and prints a migration report.
+// It returns the process exit code.
+func runMigrate(args []string) int {
+	home, _ := os.UserHomeDir()
+
+	var opts migrate.Options
+	var output string
+	var copyCerts bool
+
+	flags := pflag.NewFlagSet("migrate", pflag.ContinueOnError)
+	flags.StringVar(&opts.CertbotDir, "certbot-dir", "/etc/letsencrypt", "certbot config directory to scan")
+	flags.StringVar(&opts.AcmeShDir, "acme-sh-dir", filepath.Join(home, ".acme.sh"), "acme.sh home directory to scan")
+	flags.StringVar(&opts.Role, "role", "", "Vault PKI role for the generated certificates (required)")
+	flags.StringVar(&opts.CertDir, "cert-dir", "", "Directory for managed certificate files (default: keep current paths)")
+	flags.DurationVar(&opts.TTL, "ttl", 24*time.Hour, "TTL to request for the generated certificates")
+	flags.StringVarP(&output, "output", "o", "", "Write the generated config to this file instead of stdout")
+	flags.BoolVar(&copyCerts, "copy", false, "Copy current certificates and keys into the managed paths (requires --cert-dir)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	// Default directories are only scanned when present.
+	if !flags.Changed("certbot-dir") && !dirExists(opts.CertbotDir) {
+		opts.CertbotDir = ""
+	}
+	if !flags.Changed("acme-sh-dir") && !dirExists(opts.AcmeShDir) {
+		opts.AcmeShDir = ""
+	}
+	if copyCerts && opts.CertDir == "" {
+		fmt.Fprintln(os.Stderr, "--copy requires --cert-dir")
+		return 2
+	}
+
+	plan, err := migrate.Scan(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Migration scan failed: %v\n", err)
+		return 1
+	}
+
+	if copyCerts {
+		if err := plan.Copy(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to copy certificates: %v\n", err)
+			return 1
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+			return 1
+		}
+		defer func() { _ = file.Close() }()
+		w = file
+	}
+	if err := plan.WriteConfig(w); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write config: %v\n", err)
+		return 1
+	}
+
+	plan.WriteReport(os.Stderr)
+	return 0
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// dirExists reports whether path is an existing directory.
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,17 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Reload Signal (Unix)
+//
+// SIGUSR1 reloads the certificates from the config.
+// -------------------------------------------------------------------------------
+
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignal triggers a config reload.
+var reloadSignal os.Signal = syscall.SIGUSR1
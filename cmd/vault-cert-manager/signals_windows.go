@@ -0,0 +1,16 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Reload Signal (Windows)
+//
+// Windows has no SIGUSR1 and cannot deliver SIGHUP, so config reloads need
+// a restart and forced rotations go through --rotate or the REST API. Ctrl+C
+// and service shutdown arrive as SIGINT and SIGTERM as on Unix.
+// -------------------------------------------------------------------------------
+
+//go:build windows
+
+package main
+
+import "os"
+
+// reloadSignal is nil: signal.Notify ignores it and it matches no signal.
+var reloadSignal os.Signal
@@ -0,0 +1,407 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - ACME Client
+//
+// Alternative issuance backend for certificates with source "acme". Orders
+// certificates from an RFC 8555 directory (Let's Encrypt, Vault's ACME
+// endpoint, ...), solves http-01 or dns-01 challenges, and returns the
+// result in the same shape as Vault PKI issuance so the manager can deploy
+// it unchanged.
+// -------------------------------------------------------------------------------
+
+// Package acme provides an ACME issuance backend implementing vault.Client.
+package acme
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+
+	acmeapi "golang.org/x/crypto/acme"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// orderTimeout bounds a single order, including challenge validation.
+const orderTimeout = 10 * time.Minute
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Client issues certificates over ACME.
+type Client struct {
+	config  *config.ACMEConfig
+	client  *acmeapi.Client
+	solvers map[string]solver
+
+	mu         sync.Mutex
+	registered bool
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewClient loads or creates the ACME account key and prepares the
+// configured challenge solvers. The account is registered on first issue.
+func NewClient(cfg *config.ACMEConfig) (*Client, error) {
+	key, err := loadAccountKey(cfg.AccountKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &acmeapi.Client{
+		Key:          key,
+		DirectoryURL: cfg.DirectoryURL,
+		UserAgent:    "vault-cert-manager",
+	}
+
+	solvers := make(map[string]solver)
+	if cfg.HTTP01 != nil {
+		solvers["http-01"] = newHTTPSolver(cfg.HTTP01)
+	}
+	if cfg.DNS01 != nil {
+		solvers["dns-01"] = newDNSSolver(cfg.DNS01)
+	}
+
+	return &Client{
+		config:  cfg,
+		client:  client,
+		solvers: solvers,
+	}, nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// IssueCertificate orders a certificate for the common name and alt names,
+// solving each pending authorization with the certificate's challenge type.
+func (c *Client) IssueCertificate(certConfig *config.CertificateConfig) (*vault.CertificateData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), orderTimeout)
+	defer cancel()
+
+	if err := c.register(ctx); err != nil {
+		return nil, err
+	}
+
+	challengeType := "http-01"
+	if certConfig.ACME != nil && certConfig.ACME.Challenge != "" {
+		challengeType = certConfig.ACME.Challenge
+	}
+	solver, ok := c.solvers[challengeType]
+	if !ok {
+		return nil, fmt.Errorf("no %s solver configured", challengeType)
+	}
+
+	names := orderNames(certConfig)
+	slog.Info("Requesting certificate from ACME",
+		"certificate", certConfig.Name,
+		"directory", c.config.DirectoryURL,
+		"challenge", challengeType,
+		"names", names)
+
+	order, err := c.client.AuthorizeOrder(ctx, acmeapi.DomainIDs(names...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create acme order: %w", err)
+	}
+
+	for _, authzURL := range order.AuthzURLs {
+		if err := c.authorize(ctx, authzURL, challengeType, solver); err != nil {
+			return nil, err
+		}
+	}
+
+	order, err = c.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, fmt.Errorf("acme order did not become ready: %w", err)
+	}
+
+	key, err := generateKey(certConfig)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: certConfig.CommonName},
+		DNSNames: names,
+	}, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+
+	chain, _, err := c.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to finalize acme order: %w", err)
+	}
+
+	return buildCertificateData(chain, key, certConfig.PrivateKeyFormat)
+}
+
+// register creates the ACME account on first use. An existing account for
+// the key is reused.
+func (c *Client) register(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.registered {
+		return nil
+	}
+
+	account := &acmeapi.Account{}
+	if c.config.Email != "" {
+		account.Contact = []string{"mailto:" + c.config.Email}
+	}
+	if eab := c.config.EAB; eab != nil {
+		hmacKey, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(eab.HMACKey, "="))
+		if err != nil {
+			return fmt.Errorf("invalid acme eab hmac_key: %w", err)
+		}
+		account.ExternalAccountBinding = &acmeapi.ExternalAccountBinding{KID: eab.KeyID, Key: hmacKey}
+	}
+
+	_, err := c.client.Register(ctx, account, acmeapi.AcceptTOS)
+	if err != nil && !errors.Is(err, acmeapi.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register acme account: %w", err)
+	}
+
+	c.registered = true
+	return nil
+}
+
+// authorize solves one authorization with the chosen challenge type,
+// cleaning up the challenge response once the server has validated it.
+func (c *Client) authorize(ctx context.Context, authzURL, challengeType string, solver solver) error {
+	authz, err := c.client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return fmt.Errorf("failed to get acme authorization: %w", err)
+	}
+	if authz.Status == acmeapi.StatusValid {
+		return nil
+	}
+
+	var challenge *acmeapi.Challenge
+	for _, candidate := range authz.Challenges {
+		if candidate.Type == challengeType {
+			challenge = candidate
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("acme server offered no %s challenge for %s", challengeType, authz.Identifier.Value)
+	}
+
+	domain := authz.Identifier.Value
+	if err := solver.Present(ctx, c.client, domain, challenge.Token); err != nil {
+		return fmt.Errorf("failed to present %s challenge for %s: %w", challengeType, domain, err)
+	}
+	defer func() {
+		if err := solver.CleanUp(c.client, domain, challenge.Token); err != nil {
+			slog.Warn("Failed to clean up ACME challenge", "domain", domain, "challenge", challengeType, "error", err)
+		}
+	}()
+
+	if _, err := c.client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("failed to accept %s challenge for %s: %w", challengeType, domain, err)
+	}
+	if _, err := c.client.WaitAuthorization(ctx, authzURL); err != nil {
+		return fmt.Errorf("acme authorization failed for %s: %w", domain, err)
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// orderNames returns the common name followed by the distinct alt names.
+func orderNames(certConfig *config.CertificateConfig) []string {
+	names := []string{certConfig.CommonName}
+	seen := map[string]bool{certConfig.CommonName: true}
+	for _, name := range certConfig.AltNames {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// loadAccountKey reads the PEM account key, generating and saving an ECDSA
+// P-256 key if the file does not exist yet.
+func loadAccountKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("acme account key %s is not PEM encoded", path)
+		}
+		key, err := parsePrivateKey(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse acme account key %s: %w", path, err)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read acme account key %s: %w", path, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate acme account key: %w", err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode acme account key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create directory for acme account key: %w", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write acme account key %s: %w", path, err)
+	}
+
+	slog.Info("Created ACME account key", "path", path)
+	return key, nil
+}
+
+// parsePrivateKey decodes a PKCS#1, SEC 1, or PKCS#8 private key.
+func parsePrivateKey(block *pem.Block) (crypto.Signer, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return signer, nil
+}
+
+// generateKey creates the certificate key from key_type and key_bits,
+// defaulting to ECDSA P-256.
+func generateKey(certConfig *config.CertificateConfig) (crypto.Signer, error) {
+	var key crypto.Signer
+	var err error
+
+	switch certConfig.KeyType {
+	case "rsa":
+		bits := certConfig.KeyBits
+		if bits == 0 {
+			bits = 2048
+		}
+		key, err = rsa.GenerateKey(rand.Reader, bits)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		curve := elliptic.P256()
+		switch certConfig.KeyBits {
+		case 224:
+			curve = elliptic.P224()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		}
+		key, err = ecdsa.GenerateKey(curve, rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	return key, nil
+}
+
+// encodePrivateKey PEM-encodes key as PKCS#8 or, for the "pem" format, the
+// key type's traditional encoding.
+func encodePrivateKey(key crypto.Signer, format string) (string, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if format != "pkcs8" {
+			block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+		}
+	case *ecdsa.PrivateKey:
+		if format != "pkcs8" {
+			der, err := x509.MarshalECPrivateKey(k)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode private key: %w", err)
+			}
+			block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+		}
+	}
+
+	if block == nil {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode private key: %w", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// buildCertificateData converts an issued DER chain, leaf first, into the
+// PEM bundle the manager deploys.
+func buildCertificateData(chain [][]byte, key crypto.Signer, format string) (*vault.CertificateData, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("acme server returned an empty certificate chain")
+	}
+
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse issued certificate: %w", err)
+	}
+
+	privateKey, err := encodePrivateKey(key, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var intermediates []string
+	for _, der := range chain[1:] {
+		intermediates = append(intermediates, strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))))
+	}
+
+	return &vault.CertificateData{
+		Certificate:      strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: chain[0]}))),
+		PrivateKey:       strings.TrimSpace(privateKey),
+		CertificateChain: strings.Join(intermediates, "\n"),
+		SerialNumber:     vault.FormatSerial(leaf.SerialNumber.Bytes()),
+		Expiration:       leaf.NotAfter,
+	}, nil
+}
@@ -0,0 +1,222 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - ACME Challenge Solvers
+//
+// http-01 responses are served from a temporary listener or written beneath
+// an existing web server's root; dns-01 records are published and removed
+// by an operator-supplied hook command.
+// -------------------------------------------------------------------------------
+
+package acme
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"cert-manager/pkg/config"
+
+	acmeapi "golang.org/x/crypto/acme"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// challengePathPrefix is where http-01 responses are served from.
+const challengePathPrefix = "/.well-known/acme-challenge/"
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// solver publishes and withdraws a challenge response for a domain.
+type solver interface {
+	Present(ctx context.Context, client *acmeapi.Client, domain, token string) error
+	CleanUp(client *acmeapi.Client, domain, token string) error
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// httpSolver answers http-01 challenges. With a listen address, a server
+// runs only while challenges are outstanding.
+type httpSolver struct {
+	config *config.HTTP01Config
+
+	mu        sync.Mutex
+	responses map[string]string // token -> key authorization
+	server    *http.Server
+}
+
+// dnsSolver answers dns-01 challenges through a hook command.
+type dnsSolver struct {
+	config *config.DNS01Config
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTORS
+// -------------------------------------------------------------------------
+
+// newHTTPSolver creates an http-01 solver.
+func newHTTPSolver(cfg *config.HTTP01Config) *httpSolver {
+	return &httpSolver{
+		config:    cfg,
+		responses: make(map[string]string),
+	}
+}
+
+// newDNSSolver creates a dns-01 solver.
+func newDNSSolver(cfg *config.DNS01Config) *dnsSolver {
+	return &dnsSolver{config: cfg}
+}
+
+// -------------------------------------------------------------------------
+// HTTP-01
+// -------------------------------------------------------------------------
+
+// Present makes the key authorization for token available over HTTP.
+func (s *httpSolver) Present(_ context.Context, client *acmeapi.Client, _, token string) error {
+	response, err := client.HTTP01ChallengeResponse(token)
+	if err != nil {
+		return err
+	}
+
+	if s.config.Webroot != "" {
+		path := filepath.Join(s.config.Webroot, client.HTTP01ChallengePath(token))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("failed to create challenge directory: %w", err)
+		}
+		return os.WriteFile(path, []byte(response), 0o644)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[token] = response
+	if s.server != nil {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.Listen)
+	if err != nil {
+		delete(s.responses, token)
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Listen, err)
+	}
+
+	s.server = &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("ACME http-01 server error", "error", err)
+		}
+	}(s.server)
+
+	return nil
+}
+
+// CleanUp withdraws the response for token, stopping the listener once no
+// challenges remain.
+func (s *httpSolver) CleanUp(client *acmeapi.Client, _, token string) error {
+	if s.config.Webroot != "" {
+		err := os.Remove(filepath.Join(s.config.Webroot, client.HTTP01ChallengePath(token)))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.responses, token)
+	if len(s.responses) > 0 || s.server == nil {
+		return nil
+	}
+
+	server := s.server
+	s.server = nil
+	return server.Close()
+}
+
+// ServeHTTP serves outstanding key authorizations.
+func (s *httpSolver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.URL.Path, challengePathPrefix)
+	if !ok || r.Method != http.MethodGet {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	response, ok := s.responses[token]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(response))
+}
+
+// -------------------------------------------------------------------------
+// DNS-01
+// -------------------------------------------------------------------------
+
+// Present publishes the TXT record for domain and waits for it to
+// propagate.
+func (s *dnsSolver) Present(ctx context.Context, client *acmeapi.Client, domain, token string) error {
+	value, err := client.DNS01ChallengeRecord(token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.run(ctx, "present", domain, value); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(s.config.PropagationDelay):
+		return nil
+	}
+}
+
+// CleanUp removes the TXT record for domain.
+func (s *dnsSolver) CleanUp(client *acmeapi.Client, domain, token string) error {
+	value, err := client.DNS01ChallengeRecord(token)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	return s.run(ctx, "cleanup", domain, value)
+}
+
+// run invokes the hook command with the action, record name, and value.
+func (s *dnsSolver) run(ctx context.Context, action, domain, value string) error {
+	fqdn := "_acme-challenge." + strings.TrimPrefix(domain, "*.") + "."
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", s.config.Command+` "$@"`, "sh", action, fqdn, value)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("dns01 command failed (%s %s): %w: %s", action, fqdn, err, strings.TrimSpace(string(output)))
+	}
+
+	slog.Debug("Ran ACME dns-01 command", "action", action, "record", fqdn)
+	return nil
+}
@@ -15,18 +15,40 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
+	"net"
+	"reflect"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"cert-manager/pkg/acme"
 	"cert-manager/pkg/cert"
+	"cert-manager/pkg/chaos"
+	"cert-manager/pkg/cloud"
 	"cert-manager/pkg/config"
 	"cert-manager/pkg/health"
 	"cert-manager/pkg/logging"
 	"cert-manager/pkg/metrics"
+	"cert-manager/pkg/notify"
+	"cert-manager/pkg/source"
+	"cert-manager/pkg/tracing"
 	"cert-manager/pkg/vault"
+	"cert-manager/pkg/watch"
+	"cert-manager/pkg/web"
 )
 
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// serverShutdownTimeout bounds the wait for in-flight HTTP requests when
+// stopping, long enough for a rotation requested just before to finish.
+const serverShutdownTimeout = 30 * time.Second
+
 // -------------------------------------------------------------------------
 // TYPES
 // -------------------------------------------------------------------------
@@ -35,11 +57,26 @@ import (
 type App struct {
 	config        *config.Config
 	certManager   *cert.Manager
+	vaultRouter   *vault.Router
 	healthChecker health.Checker
 	collector     *metrics.Collector
+	source        source.Source
+	sourceDoc     []byte // last applied source document
+	routedVault   map[string]*config.VaultConfig
+	acmeClient    vault.Client
+	routedACME    map[string]bool
+	watcher       *watch.Scanner
+	tracer        *tracing.Tracer // nil unless tracing is configured
 	ctx           context.Context
 	cancel        context.CancelFunc
 	wg            sync.WaitGroup
+
+	// syncMu serializes changes to the managed set from the config and
+	// the source.
+	syncMu sync.Mutex
+
+	// initialPassDone is set once the first processing pass has finished.
+	initialPassDone atomic.Bool
 }
 
 // -------------------------------------------------------------------------
@@ -47,17 +84,154 @@ type App struct {
 // -------------------------------------------------------------------------
 
 // New creates a new App instance with the given configuration.
-func New(cfg *config.Config) (*App, error) {
+func New(cfg *config.Config) (_ *App, err error) {
 	logging.SetupLogger(&cfg.Logging)
 
-	vaultClient, err := vault.NewClient(&cfg.Vault)
-	if err != nil {
+	// A watch-only agent has no Vault; the router then has no fallback.
+	var fallback vault.Client
+	var vaultClient *vault.VaultClient
+	if cfg.IsWatchOnly() {
+		slog.Info("No Vault configured, only watching certificates",
+			"files", len(cfg.Watch.Files),
+			"endpoints", len(cfg.Watch.Endpoints))
+	} else {
+		client, err := vault.NewClient(&cfg.Vault)
+		if err != nil {
+			return nil, err
+		}
+		vaultClient, fallback = client, client
+	}
+
+	router := vault.NewRouter(fallback)
+	defer func() {
+		if err != nil {
+			router.Close()
+		}
+	}()
+	routedVault := make(map[string]*config.VaultConfig)
+	routedACME := make(map[string]bool)
+	for _, certConfig := range cfg.Certificates {
+		if certConfig.Vault == nil {
+			continue
+		}
+		client, err := vault.NewClient(certConfig.Vault)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %s: %w", certConfig.Name, err)
+		}
+		slog.Info("Using dedicated Vault cluster for certificate",
+			"certificate", certConfig.Name,
+			"address", certConfig.Vault.Address)
+		router.Route(certConfig.Name, client)
+		routedVault[certConfig.Name] = certConfig.Vault
+	}
+
+	var acmeClient vault.Client
+	if cfg.ACME != nil {
+		client, err := acme.NewClient(cfg.ACME)
+		if err != nil {
+			return nil, err
+		}
+		acmeClient = client
+		for _, certConfig := range cfg.Certificates {
+			if certConfig.IsACMESource() {
+				router.Route(certConfig.Name, acmeClient)
+				routedACME[certConfig.Name] = true
+			}
+		}
+	}
+
+	var issuer vault.Client = router
+	var healthChecker health.Checker = health.NewTCPChecker()
+	var injector *chaos.Injector
+	if cfg.Chaos.Enabled {
+		slog.Warn("Chaos mode enabled: issuance, writes, and health checks will randomly fail",
+			"issue_failure_rate", cfg.Chaos.IssueFailureRate,
+			"write_failure_rate", cfg.Chaos.WriteFailureRate,
+			"health_check_failure_rate", cfg.Chaos.HealthCheckFailureRate,
+			"delay_rate", cfg.Chaos.DelayRate,
+			"max_delay", cfg.Chaos.MaxDelay)
+		injector = chaos.New(&cfg.Chaos)
+		issuer = injector.WrapClient(issuer)
+		healthChecker = injector.WrapChecker(healthChecker)
+	}
+
+	if err := cert.UpgradeBackupArchives(cfg.Certificates); err != nil {
 		return nil, err
 	}
 
-	certManager := cert.NewManager(vaultClient)
-	healthChecker := health.NewTCPChecker()
+	certManager := cert.NewManager(issuer)
+	certManager.SetDeploymentVerifier(health.NewVerifier(healthChecker))
+	certManager.SetMaxParallel(cfg.Renewal.MaxParallel)
+	if cfg.Renewal.StateFile != "" {
+		if err := certManager.SetStateFile(cfg.Renewal.StateFile); err != nil {
+			return nil, err
+		}
+	}
+	if injector != nil {
+		certManager.SetFaultInjector(injector)
+	}
+	if cfg.Notifications.Default != nil || len(cfg.Notifications.Routes) > 0 {
+		certManager.SetNotifier(notify.New(&cfg.Notifications))
+	}
+	var tracer *tracing.Tracer
+	if cfg.Tracing.Endpoint != "" {
+		tracer = tracing.New(&cfg.Tracing)
+		certManager.SetTracer(tracer)
+	}
 	collector := metrics.NewCollector(certManager, healthChecker)
+	certManager.SetRenewalObserver(collector)
+	if cfg.StatsD != nil {
+		address := net.JoinHostPort(cfg.StatsD.Host, strconv.Itoa(cfg.StatsD.Port))
+		sink, err := metrics.NewStatsDSink(address, cfg.StatsD.Prefix, cfg.StatsD.Tags)
+		if err != nil {
+			return nil, err
+		}
+		collector.AddSink(sink)
+	}
+	if !cfg.IsWatchOnly() {
+		collector.SetVaultStats(router)
+	}
+	if cfg.Renewal.Adaptive != nil {
+		throttle := cert.NewRenewalThrottle(router, cfg.Renewal.Adaptive)
+		certManager.SetRenewalThrottle(throttle)
+		collector.SetRenewalThrottle(throttle)
+	}
+	if len(cfg.Prometheus.MetadataLabels) > 0 {
+		collector.SetMetadataLabels(cfg.Prometheus.MetadataLabels)
+	}
+	if cfg.Node.CloudMetadata != "" {
+		if identity := detectCloudIdentity(&cfg.Node); identity != nil {
+			collector.SetNodeIdentity(identity)
+		}
+	}
+	if auth := cfg.API.Auth; auth != nil {
+		apiAuth := web.NewAuth(auth.Username, auth.Password, auth.Tokens, auth.ProtectStatus)
+		if o := auth.OIDC; o != nil {
+			oidc, err := web.NewOIDC(web.OIDCConfig{
+				IssuerURL:     o.IssuerURL,
+				ClientID:      o.ClientID,
+				ClientSecret:  o.ClientSecret,
+				RedirectURL:   o.RedirectURL,
+				Scopes:        o.Scopes,
+				AllowedGroups: o.AllowedGroups,
+				GroupsClaim:   o.GroupsClaim,
+				SessionTTL:    o.SessionTTL,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("api.auth.oidc: %w", err)
+			}
+			apiAuth.SetOIDC(oidc)
+		}
+		collector.SetAuth(apiAuth)
+	}
+	if !cfg.API.RateLimit.Disabled {
+		collector.SetRateLimiter(web.NewRateLimiter(cfg.API.RateLimit.RequestsPerMinute, cfg.API.RateLimit.Burst))
+	}
+	var watcher *watch.Scanner
+	if cfg.Watch.HasTargets() {
+		watcher = watch.NewScanner(&cfg.Watch)
+		collector.SetWatchScanner(watcher)
+	}
 
 	for _, certConfig := range cfg.Certificates {
 		if err := certManager.AddCertificate(&certConfig); err != nil {
@@ -67,14 +241,49 @@ func New(cfg *config.Config) (*App, error) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &App{
+	app := &App{
 		config:        cfg,
 		certManager:   certManager,
+		vaultRouter:   router,
 		healthChecker: healthChecker,
 		collector:     collector,
+		routedVault:   routedVault,
+		acmeClient:    acmeClient,
+		routedACME:    routedACME,
+		watcher:       watcher,
+		tracer:        tracer,
 		ctx:           ctx,
 		cancel:        cancel,
-	}, nil
+	}
+
+	switch {
+	case cfg.Source.VaultKV != nil:
+		app.source = source.NewVaultKV(vaultClient, cfg.Source.VaultKV)
+	case cfg.Source.ConsulKV != nil:
+		app.source = source.NewConsulKV(cfg.Source.ConsulKV)
+	}
+
+	if app.source != nil {
+		doc, err := app.source.Load(ctx)
+		if err == nil {
+			err = app.applySource(doc)
+		}
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to load certificates from %s: %w", app.source.Name(), err)
+		}
+	}
+	collector.SetReadiness(app.ready)
+	collector.SetConfigHash(cfg.Hash())
+	collector.SetServerOptions(metrics.ServerOptions{
+		CertFile:         cfg.Web.TLSCertFile,
+		KeyFile:          cfg.Web.TLSKeyFile,
+		ClientCAFile:     cfg.Web.TLSClientCAFile,
+		DisableDashboard: !cfg.Web.DashboardEnabled(),
+		DisableMetrics:   !cfg.Web.MetricsEnabled(),
+	})
+
+	return app, nil
 }
 
 // -------------------------------------------------------------------------
@@ -86,7 +295,7 @@ func (a *App) Run() error {
 	slog.Info("Starting cert-manager application")
 
 	a.wg.Go(func() {
-		if err := a.collector.StartServer(a.config.Prometheus.Port); err != nil {
+		if err := a.collector.StartServer(a.config.Web.Port); err != nil {
 			slog.Error("Metrics server error", "error", err)
 		}
 	})
@@ -99,14 +308,58 @@ func (a *App) Run() error {
 		a.runMetricsUpdater()
 	})
 
+	a.wg.Go(func() {
+		a.runCAChainChecker()
+	})
+
+	if a.watcher != nil {
+		a.wg.Go(func() {
+			a.watcher.Run(a.ctx)
+		})
+	}
+
+	if a.tracer != nil {
+		a.wg.Go(func() {
+			a.tracer.Run(a.ctx)
+		})
+	}
+
+	if integrity := a.config.Integrity; integrity.Watch {
+		a.wg.Go(func() {
+			if err := a.certManager.WatchFiles(a.ctx, integrity.Reissue, integrity.Debounce); err != nil {
+				slog.Error("File integrity watcher error", "error", err)
+			}
+		})
+	}
+
+	if a.source != nil {
+		a.wg.Go(func() {
+			a.source.Watch(a.ctx, func(doc source.Document) {
+				if err := a.applySource(doc); err != nil {
+					slog.Error("Ignoring invalid certificates from source", "source", a.source.Name(), "error", err)
+				}
+			})
+		})
+	}
+
 	return nil
 }
 
 // Stop gracefully shuts down the application and waits for workers to finish.
 func (a *App) Stop() {
 	slog.Info("Stopping cert-manager application")
+
+	// Drain the HTTP server first, so rotations requested through the API
+	// finish before the workers stop.
+	ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+	defer cancel()
+	if err := a.collector.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP server did not shut down cleanly", "error", err)
+	}
+
 	a.cancel()
 	a.wg.Wait()
+	a.vaultRouter.Close()
 }
 
 // ForceRotate triggers immediate rotation of all certificates.
@@ -114,34 +367,146 @@ func (a *App) ForceRotate() error {
 	return a.certManager.ForceRotateAll()
 }
 
+// ReloadConfig reconciles the managed certificates with cfg, a freshly
+// loaded configuration: new certificates are added, changed ones updated,
+// and ones no longer listed retired, without a restart. Certificates from
+// the source are kept. Other settings only take effect on restart.
+func (a *App) ReloadConfig(cfg *config.Config) error {
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+
+	if a.acmeClient == nil {
+		for _, certConfig := range cfg.Certificates {
+			if certConfig.IsACMESource() {
+				return fmt.Errorf("certificate %s: acme was not configured at startup, restart to enable it", certConfig.Name)
+			}
+		}
+	}
+
+	current, reloaded := *a.config, *cfg
+	current.Certificates, reloaded.Certificates = nil, nil
+	if !reflect.DeepEqual(current, reloaded) {
+		slog.Warn("Settings other than certificates changed, restart to apply them")
+	}
+
+	if err := cert.UpgradeBackupArchives(cfg.Certificates); err != nil {
+		return err
+	}
+	if err := a.syncCertificates(cfg, a.sourceDoc, "config"); err != nil {
+		return err
+	}
+	a.collector.SetConfigHash(a.config.Hash())
+	return nil
+}
+
+// SetBuildInfo reports the running binary's version and commit in the
+// build info metric and on the dashboard.
+func (a *App) SetBuildInfo(version, commit string) {
+	a.collector.SetBuildInfo(version, commit)
+}
+
 // RunOnce processes certificates once and returns (for --rotate mode).
+// With a Pushgateway or StatsD configured, the results are sent before
+// returning; a failed send is logged and does not fail the run.
 func (a *App) RunOnce() error {
 	slog.Info("Running one-time certificate rotation")
-	return a.certManager.ForceRotateAll()
+	if a.tracer != nil {
+		defer a.tracer.Flush()
+	}
+	err := a.certManager.ForceRotateAll()
+
+	push := a.config.Prometheus.Pushgateway
+	if push != nil || a.config.StatsD != nil {
+		a.collector.UpdateMetrics()
+		a.collector.EmitToSinks()
+	}
+	if push != nil {
+		if pushErr := a.collector.Push(metrics.PushOptions{
+			URL:      push.URL,
+			Job:      push.Job,
+			Instance: push.Instance,
+			Timeout:  push.Timeout,
+		}); pushErr != nil {
+			slog.Error("Failed to push metrics", "error", pushErr)
+		} else {
+			slog.Info("Pushed metrics to Pushgateway", "url", push.URL, "job", push.Job, "instance", push.Instance)
+		}
+	}
+	return err
+}
+
+// Plan returns what the next processing pass would do, or with force what
+// ForceRotate would do, without issuing certificates or writing files.
+func (a *App) Plan(force bool) []cert.PlannedRotation {
+	if force {
+		return a.certManager.PlanForceRotateAll()
+	}
+	return a.certManager.Plan()
+}
+
+// WriteReport renders a compliance report for this node covering the given
+// period in format, report.FormatHTML or report.FormatPDF. Rotation history
+// before this process started comes from renewal.state_file.
+func (a *App) WriteReport(w io.Writer, period time.Duration, format string) error {
+	dashboard := web.NewDashboard(a.certManager, a.healthChecker)
+	return dashboard.BuildReport(period).Render(w, format)
 }
 
 // -------------------------------------------------------------------------
 // BACKGROUND WORKERS
 // -------------------------------------------------------------------------
 
-// runCertificateProcessor periodically checks and renews certificates.
+// runCertificateProcessor processes certificates once at startup, then
+// when the earliest one is due, and at least every renewal.check_interval
+// so certificate files removed from disk are reissued.
 func (a *App) runCertificateProcessor() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
+	if err := a.certManager.ProcessCertificates(); err != nil {
+		slog.Error("Error processing certificates", "error", err)
+	}
+	a.initialPassDone.Store(true)
+
+	timer := time.NewTimer(a.nextPassIn())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-a.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-a.certManager.ScheduleChanged():
+		case <-timer.C:
 			if err := a.certManager.ProcessCertificates(); err != nil {
 				slog.Error("Error processing certificates", "error", err)
 			}
 		}
+		timer.Reset(a.nextPassIn())
+	}
+}
+
+// ready reports why the agent is not ready to serve yet: until the first
+// processing pass has finished and, unless watch-only, Vault has accepted
+// a login.
+func (a *App) ready() error {
+	if !a.initialPassDone.Load() {
+		return fmt.Errorf("initial certificate processing has not completed")
+	}
+	if !a.config.IsWatchOnly() && a.vaultRouter.Stats().AuthSuccesses == 0 {
+		return fmt.Errorf("not authenticated with vault")
 	}
+	return nil
 }
 
-// runMetricsUpdater periodically updates Prometheus metrics.
+// nextPassIn returns how long to wait before the next processing pass.
+func (a *App) nextPassIn() time.Duration {
+	wait := a.config.Renewal.CheckInterval
+	if due, ok := a.certManager.NextDue(); ok {
+		wait = min(wait, time.Until(due))
+	}
+	slog.Debug("Scheduled next certificate check", "in", wait.Round(time.Second))
+	return max(wait, 0)
+}
+
+// runMetricsUpdater periodically re-verifies deployed files, remediates
+// services serving an old certificate, and updates Prometheus metrics.
 func (a *App) runMetricsUpdater() {
 	ticker := time.NewTicker(a.config.Prometheus.RefreshInterval)
 	defer ticker.Stop()
@@ -151,7 +516,177 @@ func (a *App) runMetricsUpdater() {
 		case <-a.ctx.Done():
 			return
 		case <-ticker.C:
+			a.certManager.VerifyDestinations()
+			a.certManager.RemediateOutOfSync()
 			a.collector.UpdateMetrics()
+			a.collector.EmitToSinks()
+		}
+	}
+}
+
+// runCAChainChecker periodically reissues certificates whose issuing CA
+// has rotated on the PKI mount.
+func (a *App) runCAChainChecker() {
+	ticker := time.NewTicker(a.config.Vault.CACheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.certManager.CheckCAChains(); err != nil {
+				slog.Error("Error checking CA chains", "error", err)
+			}
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// CERTIFICATE SOURCES
+// -------------------------------------------------------------------------
+
+// applySource verifies and validates a document from the central source
+// and syncs the managed set to the local certificates plus the remote
+// definitions. With a trust key the signature is checked over the exact
+// bytes that are parsed; in strict mode an unsigned or invalid document is
+// refused, since its definitions choose key paths and on_change commands.
+// A refused or invalid document leaves the current set untouched.
+func (a *App) applySource(doc source.Document) error {
+	a.syncMu.Lock()
+	defer a.syncMu.Unlock()
+
+	if trust := a.config.Trust; trust.Key != nil {
+		if err := trust.Key.Verify(doc.Data, doc.Signature); err != nil {
+			if trust.Strict {
+				return fmt.Errorf("refusing untrusted document from %s: %w", a.source.Name(), err)
+			}
+			slog.Warn("Certificate source document is not trusted", "source", a.source.Name(), "problem", err)
+		}
+	}
+
+	return a.syncCertificates(a.config, doc.Data, a.source.Name())
+}
+
+// syncCertificates makes the managed set the certificates of cfg plus the
+// definitions in doc, the source document, if any. On success cfg's
+// certificates and doc become the current ones. Callers hold syncMu.
+func (a *App) syncCertificates(cfg *config.Config, doc []byte, origin string) error {
+	var remote []config.CertificateConfig
+	if doc != nil {
+		var err error
+		if remote, err = config.ParseCertificates(doc, cfg); err != nil {
+			return err
+		}
+	}
+
+	all := make([]config.CertificateConfig, 0, len(cfg.Certificates)+len(remote))
+	all = append(all, cfg.Certificates...)
+	all = append(all, remote...)
+	if err := a.routeCertificates(all); err != nil {
+		return err
+	}
+
+	desired := make([]*config.CertificateConfig, len(all))
+	for i := range all {
+		desired[i] = &all[i]
+	}
+
+	added, updated, removed := a.certManager.SyncCertificates(desired)
+	for _, name := range removed {
+		a.collector.RemoveCertificate(name)
+	}
+	a.config.Certificates = cfg.Certificates
+	a.sourceDoc = doc
+	if len(added) > 0 || len(updated) > 0 || len(removed) > 0 {
+		slog.Info("Applied certificate changes",
+			"from", origin,
+			"added", added,
+			"updated", updated,
+			"removed", removed)
+	}
+
+	return nil
+}
+
+// routeCertificates creates, replaces, or removes dedicated Vault clients
+// for certificates with a vault override, and routes ACME certificates to
+// the ACME client.
+func (a *App) routeCertificates(certs []config.CertificateConfig) error {
+	wanted := make(map[string]*config.VaultConfig)
+	wantedACME := make(map[string]bool)
+	for _, certConfig := range certs {
+		if certConfig.Vault != nil {
+			wanted[certConfig.Name] = certConfig.Vault
+		}
+		if certConfig.IsACMESource() {
+			wantedACME[certConfig.Name] = true
+		}
+	}
+
+	for name, vaultConfig := range wanted {
+		if reflect.DeepEqual(a.routedVault[name], vaultConfig) {
+			continue
+		}
+		client, err := vault.NewClient(vaultConfig)
+		if err != nil {
+			return fmt.Errorf("certificate %s: %w", name, err)
+		}
+		slog.Info("Using dedicated Vault cluster for certificate",
+			"certificate", name,
+			"address", vaultConfig.Address)
+		a.vaultRouter.Route(name, client)
+		a.routedVault[name] = vaultConfig
+	}
+
+	for name := range a.routedVault {
+		if _, ok := wanted[name]; !ok {
+			a.vaultRouter.Remove(name)
+			delete(a.routedVault, name)
 		}
 	}
+
+	for name := range wantedACME {
+		if !a.routedACME[name] {
+			a.vaultRouter.Route(name, a.acmeClient)
+			a.routedACME[name] = true
+		}
+	}
+	for name := range a.routedACME {
+		if wantedACME[name] {
+			continue
+		}
+		if _, ok := wanted[name]; !ok {
+			a.vaultRouter.Remove(name)
+		}
+		delete(a.routedACME, name)
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// detectCloudIdentity looks up the node's cloud identity, returning nil when
+// no metadata service answers so non-cloud hosts start normally.
+func detectCloudIdentity(cfg *config.NodeConfig) *cloud.Identity {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.CloudMetadataTimeout)
+	defer cancel()
+
+	identity, err := cloud.Detect(ctx, cfg.CloudMetadata)
+	if err != nil {
+		slog.Warn("Cloud metadata not available, continuing without node identity",
+			"provider", cfg.CloudMetadata,
+			"error", err)
+		return nil
+	}
+
+	slog.Info("Detected cloud instance",
+		"provider", identity.Provider,
+		"instance_id", identity.InstanceID,
+		"zone", identity.Zone,
+		"account", identity.Account)
+	return identity
 }
@@ -12,10 +12,43 @@ package app
 
 import (
 	"cert-manager/pkg/config"
+	"cert-manager/pkg/source"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// staticSource is a central source whose documents are applied directly.
+type staticSource struct{}
+
+// Name describes the source for logging.
+func (staticSource) Name() string { return "static" }
+
+// Load returns an empty document.
+func (staticSource) Load(ctx context.Context) (source.Document, error) {
+	return source.Document{}, nil
+}
+
+// Watch returns when ctx is cancelled.
+func (staticSource) Watch(ctx context.Context, onChange func(source.Document)) {
+	<-ctx.Done()
+}
+
 // -------------------------------------------------------------------------
 // TESTS
 // -------------------------------------------------------------------------
@@ -76,7 +109,195 @@ func TestNew(t *testing.T) {
 	app.Stop()
 }
 
-// TestApp_Stop verifies that the application shuts down cleanly.
+// TestNew_WatchOnly verifies an agent with only watch targets starts
+// without a Vault client and exports watched certificates.
+func TestNew_WatchOnly(t *testing.T) {
+	cfg := &config.Config{
+		Watch: config.WatchConfig{
+			Files:    []string{"/nonexistent/*.pem"},
+			Interval: time.Minute,
+			Timeout:  time.Second,
+		},
+	}
+
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if app.watcher == nil {
+		t.Error("watcher is nil")
+	}
+	if stats := app.vaultRouter.Stats(); len(stats.Requests) != 0 {
+		t.Errorf("expected no Vault requests, got %v", stats.Requests)
+	}
+
+	app.Stop()
+}
+
+// TestNew_ClosesVaultOnError verifies the Vault clients created by New are
+// closed when a later step fails.
+func TestNew_ClosesVaultOnError(t *testing.T) {
+	newConfig := func() *config.Config {
+		certConfig := config.CertificateConfig{
+			Name:        "test-cert",
+			Role:        "test-role",
+			CommonName:  "test.example.com",
+			Certificate: "/tmp/test.crt",
+			Key:         "/tmp/test.key",
+			TTL:         24 * time.Hour,
+		}
+		return &config.Config{
+			Vault: config.VaultConfig{
+				Address: "https://vault.example.com",
+				Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+			},
+			Certificates: []config.CertificateConfig{certConfig},
+		}
+	}
+
+	duplicate := newConfig()
+	duplicate.Certificates = append(duplicate.Certificates, duplicate.Certificates[0])
+	oidc := newConfig()
+	oidc.API.Auth = &config.APIAuthConfig{OIDC: &config.OIDCConfig{RedirectURL: "https://node1:9101/callback"}}
+
+	for name, cfg := range map[string]*config.Config{"duplicate certificate": duplicate, "oidc": oidc} {
+		before := runtime.NumGoroutine()
+		if _, err := New(cfg); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if after := runtime.NumGoroutine(); after > before {
+			t.Errorf("%s: expected the Vault client to be closed, %d goroutines left running", name, after-before)
+		}
+	}
+}
+
+// TestApp_ReloadConfig verifies a reloaded config adds, updates, and
+// retires certificates, and ACME certificates are refused without an ACME
+// client.
+func TestApp_ReloadConfig(t *testing.T) {
+	newConfig := func(names ...string) *config.Config {
+		cfg := &config.Config{
+			Vault: config.VaultConfig{
+				Address: "https://vault.example.com",
+				Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+			},
+			Prometheus: config.PrometheusConfig{Port: 9092, RefreshInterval: 10 * time.Second},
+		}
+		for _, name := range names {
+			cfg.Certificates = append(cfg.Certificates, config.CertificateConfig{
+				Name:        name,
+				Role:        "test-role",
+				CommonName:  name + ".example.com",
+				Certificate: "/tmp/" + name + ".crt",
+				Key:         "/tmp/" + name + ".key",
+				TTL:         24 * time.Hour,
+			})
+		}
+		return cfg
+	}
+
+	app, err := New(newConfig("keep", "drop"))
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	defer app.Stop()
+
+	reloaded := newConfig("keep", "new")
+	reloaded.Certificates[0].TTL = 48 * time.Hour
+	if err := app.ReloadConfig(reloaded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	certs := app.certManager.Snapshot()
+	if len(certs) != 2 || certs[0].Config.Name != "keep" || certs[1].Config.Name != "new" {
+		t.Fatalf("unexpected certificates after reload: %v", certs)
+	}
+	if certs[0].Config.TTL != 48*time.Hour {
+		t.Errorf("expected keep to be updated, got ttl %s", certs[0].Config.TTL)
+	}
+	if len(app.config.Certificates) != 2 {
+		t.Errorf("expected reloaded certificates to become current, got %d", len(app.config.Certificates))
+	}
+
+	acme := newConfig("keep")
+	acme.Certificates[0].Source = "acme"
+	if err := app.ReloadConfig(acme); err == nil {
+		t.Error("expected error for acme certificate without acme client")
+	}
+	if len(app.certManager.Snapshot()) != 2 {
+		t.Error("expected failed reload to leave certificates untouched")
+	}
+}
+
+// TestApp_ApplySource_StrictTrust verifies source documents are verified
+// over the bytes applied, and unsigned or tampered ones are refused in
+// strict mode.
+func TestApp_ApplySource_StrictTrust(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "trust.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatalf("failed to write trust key: %v", err)
+	}
+	key, err := config.LoadTrustKey(keyFile)
+	if err != nil {
+		t.Fatalf("failed to load trust key: %v", err)
+	}
+
+	app, err := New(&config.Config{
+		Vault: config.VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+		},
+		Prometheus: config.PrometheusConfig{Port: 9093, RefreshInterval: 10 * time.Second},
+		Trust:      config.SourceTrust{Key: key, Strict: true},
+	})
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	defer app.Stop()
+	app.source = staticSource{}
+
+	data := []byte("certificates:\n  - name: remote\n    role: test-role\n    common_name: remote.example.com\n" +
+		"    certificate: /tmp/remote.crt\n    key: /tmp/remote.key\n")
+	signature := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)))
+
+	if err := app.applySource(source.Document{Data: data}); err == nil {
+		t.Error("expected an unsigned document to be refused in strict mode")
+	}
+	tampered := append(append([]byte{}, data...), "    on_change: touch /tmp/pwned\n"...)
+	if err := app.applySource(source.Document{Data: tampered, Signature: signature}); err == nil {
+		t.Error("expected a tampered document to be refused in strict mode")
+	}
+	if len(app.certManager.Snapshot()) != 0 {
+		t.Fatal("expected refused documents to leave certificates untouched")
+	}
+
+	if err := app.applySource(source.Document{Data: data, Signature: signature}); err != nil {
+		t.Fatalf("expected a signed document to be applied: %v", err)
+	}
+	if snapshot := app.certManager.Snapshot(); len(snapshot) != 1 {
+		t.Errorf("expected the remote certificate to be managed, got %d certificates", len(snapshot))
+	}
+
+	app.config.Trust.Strict = false
+	if err := app.applySource(source.Document{Data: data}); err != nil {
+		t.Errorf("expected an unsigned document to be applied with a warning outside strict mode: %v", err)
+	}
+}
+
+// TestApp_Stop verifies that the application shuts down cleanly, including
+// the metrics server.
 func TestApp_Stop(t *testing.T) {
 	cfg := &config.Config{
 		Vault: config.VaultConfig{
@@ -86,11 +307,13 @@ func TestApp_Stop(t *testing.T) {
 					Value: "test-token",
 				},
 			},
+			CACheckInterval: time.Hour,
 		},
 		Prometheus: config.PrometheusConfig{
-			Port:            9091,
+			Port:            0,
 			RefreshInterval: 10 * time.Second,
 		},
+		Renewal:      config.RenewalConfig{CheckInterval: time.Hour},
 		Certificates: []config.CertificateConfig{},
 	}
 
@@ -98,6 +321,130 @@ func TestApp_Stop(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to create app: %v", err)
 	}
+	if err := app.Run(); err != nil {
+		t.Fatalf("failed to run app: %v", err)
+	}
 
-	app.Stop()
+	stopped := make(chan struct{})
+	go func() {
+		app.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Stop did not return with the metrics server running")
+	}
+}
+
+// TestApp_RunOnce_Pushgateway verifies one-shot runs push their metrics
+// before returning.
+func TestApp_RunOnce_Pushgateway(t *testing.T) {
+	var pushes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushes.Add(1)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		Vault: config.VaultConfig{
+			Address: "https://vault.example.com",
+			Auth: config.AuthConfig{
+				Token: &config.TokenAuth{
+					Value: "test-token",
+				},
+			},
+		},
+		Prometheus: config.PrometheusConfig{
+			Pushgateway: &config.PushgatewayConfig{URL: server.URL, Job: "vault-cert-manager", Instance: "node1", Timeout: time.Second},
+		},
+	}
+
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	defer app.Stop()
+
+	if err := app.RunOnce(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pushes.Load() != 1 {
+		t.Errorf("expected 1 push, got %d", pushes.Load())
+	}
+}
+
+// TestApp_RunOnce_Failure verifies one-shot runs fail when a certificate
+// cannot be rotated.
+func TestApp_RunOnce_Failure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"errors":["vault sealed"]}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Vault: config.VaultConfig{
+			Address: server.URL,
+			Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+			Retry:   config.RetryConfig{MaxAttempts: 1},
+		},
+		Certificates: []config.CertificateConfig{{
+			Name:        "test-cert",
+			Role:        "test-role",
+			CommonName:  "test.example.com",
+			Certificate: filepath.Join(dir, "test.crt"),
+			Key:         filepath.Join(dir, "test.key"),
+			TTL:         24 * time.Hour,
+		}},
+	}
+
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	defer app.Stop()
+
+	if err := app.RunOnce(); err == nil {
+		t.Fatal("expected an error when the rotation fails")
+	}
+	if summary := app.certManager.LastSummary(); summary.Certificates != 1 || summary.Failed != 1 {
+		t.Errorf("expected one failed certificate in the summary, got %+v", summary)
+	}
+}
+
+// TestApp_Ready verifies the agent only reports ready once the first
+// processing pass has finished.
+func TestApp_Ready(t *testing.T) {
+	cfg := &config.Config{
+		Vault: config.VaultConfig{
+			Address: "https://vault.example.com",
+			Auth: config.AuthConfig{
+				Token: &config.TokenAuth{
+					Value: "test-token",
+				},
+			},
+		},
+		Renewal: config.RenewalConfig{CheckInterval: time.Hour},
+	}
+
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("failed to create app: %v", err)
+	}
+	defer app.Stop()
+
+	if err := app.ready(); err == nil {
+		t.Fatal("expected not ready before the first processing pass")
+	}
+
+	app.wg.Go(app.runCertificateProcessor)
+	deadline := time.Now().Add(5 * time.Second)
+	for app.ready() != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected ready after the first processing pass: %v", app.ready())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
 }
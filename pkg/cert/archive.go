@@ -0,0 +1,158 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Backup Archive Layout
+//
+// Versions the layout of backup archive directories. Each archive version
+// holds the saved files, named by destination, and a manifest recording
+// where each was deployed and with which mode, so a version can be
+// restored by hand after the config has changed. Archives written before
+// manifests are upgraded when the agent starts.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/state"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// manifestFile is the manifest in each archive version.
+const manifestFile = "manifest.json"
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// archiveSchema is the layout of a backup.dir archive.
+var archiveSchema = state.Schema{
+	Name: "backup-archive",
+	Migrations: []state.Migration{
+		{Version: 1, Description: "add manifests to archive versions", Apply: addArchiveManifests},
+	},
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// archiveManifest describes one archive version.
+type archiveManifest struct {
+	Certificate string                `json:"certificate"`
+	Created     time.Time             `json:"created,omitzero"`
+	Files       []archiveManifestFile `json:"files"`
+}
+
+// archiveManifestFile is one saved file. Path and mode are unknown for
+// files archived before manifests.
+type archiveManifestFile struct {
+	Role string `json:"role"`
+	Path string `json:"path,omitempty"`
+	Mode string `json:"mode,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// FUNCTIONS
+// -------------------------------------------------------------------------
+
+// UpgradeBackupArchives migrates the backup archives of certs to the
+// current layout. It fails without changing anything in an archive written
+// by a newer release.
+func UpgradeBackupArchives(certs []config.CertificateConfig) error {
+	seen := make(map[string]bool)
+	var errs []error
+	for _, cfg := range certs {
+		if cfg.Backup == nil || cfg.Backup.Dir == "" || seen[cfg.Backup.Dir] {
+			continue
+		}
+		seen[cfg.Backup.Dir] = true
+		if err := archiveSchema.Upgrade(cfg.Backup.Dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// writeArchiveManifest records the files saved in an archive version.
+func writeArchiveManifest(version, name string, created time.Time, saved []backupFile) error {
+	manifest := archiveManifest{Certificate: name, Created: created.UTC()}
+	for _, file := range saved {
+		manifest.Files = append(manifest.Files, archiveManifestFile{
+			Role: file.role,
+			Path: file.path,
+			Mode: fmt.Sprintf("%04o", file.mode.Perm()),
+		})
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool { return manifest.Files[i].Role < manifest.Files[j].Role })
+	return saveManifest(version, manifest)
+}
+
+// addArchiveManifests writes a manifest, listing the saved files by role,
+// into every archive version that has none.
+func addArchiveManifests(dir string) error {
+	certs, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, certEntry := range certs {
+		if !certEntry.IsDir() {
+			continue
+		}
+		certDir := filepath.Join(dir, certEntry.Name())
+		versions, err := os.ReadDir(certDir)
+		if err != nil {
+			return err
+		}
+		for _, versionEntry := range versions {
+			version := filepath.Join(certDir, versionEntry.Name())
+			if !versionEntry.IsDir() || fileExists(filepath.Join(version, manifestFile)) {
+				continue
+			}
+			files, err := os.ReadDir(version)
+			if err != nil {
+				return err
+			}
+			manifest := archiveManifest{Certificate: certEntry.Name(), Files: []archiveManifestFile{}}
+			if created, err := time.Parse(archiveTimeFormat, versionEntry.Name()); err == nil {
+				manifest.Created = created
+			}
+			for _, file := range files {
+				manifest.Files = append(manifest.Files, archiveManifestFile{Role: file.Name()})
+			}
+			if err := saveManifest(version, manifest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveManifest writes manifest into an archive version.
+func saveManifest(version string, manifest archiveManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(version, manifestFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write backup manifest %s: %w", path, err)
+	}
+	return nil
+}
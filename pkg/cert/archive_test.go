@@ -0,0 +1,79 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Backup Archive Layout Tests
+//
+// Unit tests for archive manifests and upgrading archives written before
+// them.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/state"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestUpgradeBackupArchives verifies legacy archive versions gain manifests
+// and the archive is stamped with the current version.
+func TestUpgradeBackupArchives(t *testing.T) {
+	archive := t.TempDir()
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	version := filepath.Join(archive, "web", created.Format(archiveTimeFormat))
+	_ = os.MkdirAll(version, 0700)
+	_ = os.WriteFile(filepath.Join(version, "certificate"), []byte("cert"), 0600)
+	_ = os.WriteFile(filepath.Join(version, "key"), []byte("key"), 0600)
+
+	certs := []config.CertificateConfig{
+		{Name: "web", Backup: &config.BackupConfig{Dir: archive}},
+		{Name: "api", Backup: &config.BackupConfig{Dir: archive}},
+		{Name: "db"},
+	}
+	if err := UpgradeBackupArchives(certs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(version, manifestFile))
+	if err != nil {
+		t.Fatalf("expected manifest to be written: %v", err)
+	}
+	var manifest archiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	if manifest.Certificate != "web" || !manifest.Created.Equal(created) {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+	if len(manifest.Files) != 2 || manifest.Files[0].Role != "certificate" || manifest.Files[1].Role != "key" {
+		t.Errorf("unexpected manifest files %+v", manifest.Files)
+	}
+
+	if v, _ := archiveSchema.Version(archive); v != archiveSchema.Current() {
+		t.Errorf("expected archive at version %d, got %d", archiveSchema.Current(), v)
+	}
+}
+
+// TestUpgradeBackupArchives_Newer verifies an archive from a newer release
+// is refused.
+func TestUpgradeBackupArchives_Newer(t *testing.T) {
+	archive := t.TempDir()
+	marker := `{"schema":"backup-archive","version":99}`
+	_ = os.WriteFile(filepath.Join(archive, state.VersionFile), []byte(marker), 0600)
+
+	err := UpgradeBackupArchives([]config.CertificateConfig{{Name: "web", Backup: &config.BackupConfig{Dir: archive}}})
+	if !errors.Is(err, state.ErrNewerVersion) {
+		t.Errorf("expected newer version error, got %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Deployment Backup and Rollback
+//
+// Saves the certificate files being replaced, either as .bak files next to
+// them or in a versioned archive directory, and restores them when the new
+// certificate could not be put into service.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// archiveTimeFormat names archive versions so they sort chronologically.
+const archiveTimeFormat = "20060102T150405.000000000Z"
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// errRolledBack marks deployments that were undone by restoring backups.
+var errRolledBack = errors.New("deployment rolled back")
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// backupFile is one saved file and the mode and ownership to restore it
+// with.
+type backupFile struct {
+	role   string
+	path   string
+	backup string
+	mode   os.FileMode
+	owner  string
+	group  string
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// backupFiles copies the certificate files about to be replaced.
+func (m *Manager) backupFiles(managed *ManagedCertificate) ([]backupFile, error) {
+	files := deployedFiles(managed)
+
+	archive := ""
+	now := m.clock.Now()
+	if dir := managed.Config.Backup.Dir; dir != "" {
+		if err := archiveSchema.Upgrade(dir); err != nil {
+			return nil, err
+		}
+		archive = filepath.Join(dir, managed.Config.Name, now.UTC().Format(archiveTimeFormat))
+		if err := os.MkdirAll(archive, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create backup directory %s: %w", archive, err)
+		}
+	}
+
+	var saved []backupFile
+	for role, file := range files {
+		if !fileExists(file.path) {
+			continue
+		}
+		if archive != "" {
+			file.backup = filepath.Join(archive, role)
+		} else {
+			file.backup = file.path + ".bak"
+		}
+
+		data, err := os.ReadFile(file.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file.path, err)
+		}
+		if err := os.WriteFile(file.backup, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write backup %s: %w", file.backup, err)
+		}
+		saved = append(saved, file)
+	}
+
+	if archive != "" {
+		if err := writeArchiveManifest(archive, managed.Config.Name, now, saved); err != nil {
+			return nil, err
+		}
+		pruneArchive(filepath.Dir(archive), managed.Config.Backup.Keep)
+	}
+
+	slog.Debug("Backed up certificate files", "certificate", managed.Config.Name, "files", len(saved))
+	return saved, nil
+}
+
+// rollback restores saved files after a failed deployment, reloads them, and
+// re-runs on_change so the service returns to the previous certificate. The
+// returned error wraps cause and errRolledBack.
+func (m *Manager) rollback(managed *ManagedCertificate, saved []backupFile, cause error) error {
+	name := managed.Config.Name
+	slog.Error("Deployment failed, rolling back to previous certificate",
+		"certificate", name,
+		"error", cause)
+
+	for _, file := range saved {
+		data, err := os.ReadFile(file.backup)
+		if err == nil {
+			err = m.writeDestination(managed, file.role, file.path, string(data), file.mode, file.owner, file.group)
+		}
+		if err != nil {
+			return fmt.Errorf("%w; failed to restore %s: %v", cause, file.path, err)
+		}
+	}
+
+	m.mu.Lock()
+	err := m.loadExistingCertificate(managed)
+	if err == nil {
+		if managed.Config.Refreshes() {
+			managed.NextRenewal = m.clock.Now().Add(managed.Config.RefreshInterval())
+		} else {
+			managed.NextRenewal = renewalThreshold(managed)
+		}
+	}
+	m.unlock()
+	if err != nil {
+		return fmt.Errorf("%w; failed to load restored certificate: %v", cause, err)
+	}
+	m.restoreDeployment(managed, saved)
+	if managed.Config.Versioned != nil && !managed.Config.Shadow {
+		m.restoreVersion(managed)
+	}
+
+	if managed.Config.HasOnChange() && !managed.Config.Shadow {
+		if err := m.runOnChangeScript(managed); err != nil {
+			slog.Warn("Failed to run on_change script after rollback",
+				"certificate", name,
+				"error", err)
+		}
+	}
+
+	slog.Warn("Rolled back to previous certificate",
+		"certificate", name,
+		"fingerprint", managed.Fingerprint)
+	return fmt.Errorf("%w: %w", errRolledBack, cause)
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// deployedFiles returns the files written for a certificate, keyed by the
+// name used for them in an archive version.
+func deployedFiles(managed *ManagedCertificate) map[string]backupFile {
+	cfg := managed.Config
+	files := make(map[string]backupFile)
+	add := func(role, path string, mode os.FileMode) {
+		files[role] = backupFile{role: role, path: path, mode: mode, owner: cfg.Owner, group: cfg.Group}
+	}
+
+	switch {
+	case cfg.IsCombinedFile():
+		add("certificate", cfg.CertificatePath(), cfg.KeyFileMode())
+	case cfg.IsCABundleSource():
+		add("certificate", cfg.CertificatePath(), cfg.CertFileMode())
+	default:
+		add("certificate", cfg.CertificatePath(), cfg.CertFileMode())
+		add("key", cfg.KeyPath(), cfg.KeyFileMode())
+	}
+	if cfg.CAFile != "" {
+		add("ca_file", cfg.CAFilePath(), cfg.CertFileMode())
+	}
+	if cfg.Keystore != nil {
+		add("keystore", cfg.KeystorePath(), cfg.KeyFileMode())
+		if cfg.Keystore.Truststore != "" {
+			add("truststore", cfg.TruststorePath(), cfg.CertFileMode())
+		}
+	}
+	for i, output := range cfg.Outputs {
+		role := fmt.Sprintf("output-%d", i)
+		files[role] = backupFile{
+			role:  role,
+			path:  cfg.OutputPath(output),
+			mode:  output.FileMode(),
+			owner: output.Owner,
+			group: output.Group,
+		}
+	}
+
+	return files
+}
+
+// pruneArchive removes all but the newest keep versions in dir.
+func pruneArchive(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		slog.Warn("Failed to list backup archive", "dir", dir, "error", err)
+		return
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions = append(versions, entry.Name())
+		}
+	}
+	if len(versions) <= keep {
+		return
+	}
+
+	sort.Strings(versions)
+	for _, version := range versions[:len(versions)-keep] {
+		if err := os.RemoveAll(filepath.Join(dir, version)); err != nil {
+			slog.Warn("Failed to prune backup version", "dir", dir, "version", version, "error", err)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Deployment Backup and Rollback Tests
+//
+// Unit tests for saving replaced certificate files and restoring them after
+// failed deployments.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_Rollback_OnChangeFailure verifies a failing on_change restores
+// the previous files and is recorded as a rolled back rotation.
+func TestManager_Rollback_OnChangeFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	marker := filepath.Join(tmpDir, "fail")
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		OnChange:    "test ! -e " + marker,
+		Backup:      &config.BackupConfig{Keep: 5},
+	}
+
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(newSelfSignedCertificateData(t), nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(newSelfSignedCertificateData(t), nil),
+	)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	original, err := os.ReadFile(certConfig.Certificate)
+	if err != nil {
+		t.Fatalf("failed to read certificate: %v", err)
+	}
+	originalFingerprint := manager.certificates["web"].Fingerprint
+
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatalf("failed to create marker: %v", err)
+	}
+	err = manager.ForceRotate("web")
+	if !errors.Is(err, errRolledBack) {
+		t.Fatalf("expected rolled back error, got %v", err)
+	}
+
+	restored, err := os.ReadFile(certConfig.Certificate)
+	if err != nil {
+		t.Fatalf("failed to read certificate: %v", err)
+	}
+	if string(restored) != string(original) {
+		t.Error("expected previous certificate to be restored")
+	}
+	if !fileExists(certConfig.Certificate+".bak") || !fileExists(certConfig.Key+".bak") {
+		t.Error("expected .bak files next to the certificate and key")
+	}
+
+	managed := manager.certificates["web"]
+	if managed.Fingerprint != originalFingerprint {
+		t.Error("expected restored certificate to be reloaded")
+	}
+	last := managed.History[len(managed.History)-1]
+	if last.Success || !last.RolledBack {
+		t.Errorf("expected failed, rolled back event, got %+v", last)
+	}
+}
+
+// TestManager_Rollback_HealthCheck verifies a failed deployment check rolls
+// back, and that versions are archived and pruned in the backup dir.
+func TestManager_Rollback_HealthCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+	verifier := &fakeVerifier{}
+	manager.SetDeploymentVerifier(verifier)
+
+	archive := filepath.Join(tmpDir, "archive")
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		HealthCheck: &config.HealthCheck{TCP: "localhost:443"},
+		Backup:      &config.BackupConfig{Dir: archive, Keep: 2, VerifyTimeout: time.Second},
+	}
+
+	mockClient.EXPECT().IssueCertificate(certConfig).DoAndReturn(
+		func(*config.CertificateConfig) (*vault.CertificateData, error) {
+			return newSelfSignedCertificateData(t), nil
+		}).Times(4)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := manager.ForceRotate("web"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	deployed := manager.certificates["web"].Fingerprint
+
+	verifier.err = errors.New("still serving the old certificate")
+	if err := manager.ForceRotate("web"); !errors.Is(err, errRolledBack) {
+		t.Fatalf("expected rolled back error, got %v", err)
+	}
+	if manager.certificates["web"].Fingerprint != deployed {
+		t.Error("expected previous certificate to be restored")
+	}
+	if verifier.timeout != time.Second {
+		t.Errorf("expected verify_timeout to be passed, got %s", verifier.timeout)
+	}
+
+	versions, err := os.ReadDir(filepath.Join(archive, "web"))
+	if err != nil {
+		t.Fatalf("failed to read archive: %v", err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("expected 2 archived versions, got %d", len(versions))
+	}
+	for _, name := range []string{"certificate", "key", manifestFile} {
+		if !fileExists(filepath.Join(archive, "web", versions[len(versions)-1].Name(), name)) {
+			t.Errorf("expected %s in newest archive version", name)
+		}
+	}
+}
+
+// TestManager_ConfirmDeployment verifies rotations without backup are
+// confirmed only with confirm_timeout, and an unconfirmed one fails and is
+// counted while the new files stay in place.
+func TestManager_ConfirmDeployment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+	verifier := &fakeVerifier{err: errors.New("still serving the old certificate")}
+	manager.SetDeploymentVerifier(verifier)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		HealthCheck: &config.HealthCheck{TCP: "localhost:443"},
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).DoAndReturn(
+		func(*config.CertificateConfig) (*vault.CertificateData, error) {
+			return newSelfSignedCertificateData(t), nil
+		}).Times(2)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if verifier.timeout != 0 {
+		t.Fatal("expected no confirmation without confirm_timeout or backup")
+	}
+
+	certConfig.HealthCheck.ConfirmTimeout = 2 * time.Second
+	err := manager.ForceRotate("web")
+	if !errors.Is(err, errUnconfirmed) || errors.Is(err, errRolledBack) {
+		t.Fatalf("expected unconfirmed error without rollback, got %v", err)
+	}
+	if verifier.timeout != 2*time.Second {
+		t.Errorf("expected confirm_timeout to be passed, got %s", verifier.timeout)
+	}
+
+	managed := manager.certificates["web"]
+	last := managed.History[len(managed.History)-1]
+	if last.Success || !last.Unconfirmed || last.RolledBack {
+		t.Errorf("expected an unconfirmed failed rotation, got %+v", last)
+	}
+	if managed.UnconfirmedRotations != 1 {
+		t.Errorf("expected 1 unconfirmed rotation, got %d", managed.UnconfirmedRotations)
+	}
+	data, _ := os.ReadFile(certConfig.Certificate)
+	if manager.calculateFingerprint(data) != managed.Fingerprint {
+		t.Error("expected the new certificate to stay deployed")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeVerifier is a DeploymentVerifier returning a fixed result.
+type fakeVerifier struct {
+	err     error
+	timeout time.Duration
+}
+
+// VerifyDeployment implements DeploymentVerifier.
+func (f *fakeVerifier) VerifyDeployment(_ *ManagedCertificate, timeout time.Duration) error {
+	f.timeout = timeout
+	return f.err
+}
@@ -0,0 +1,93 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - CA Bundle Distribution
+//
+// Certificate entries with source "ca_bundle" hold no key: they sync the
+// PKI mount's CA chain to the certificate file for hosts that only need to
+// trust the CA. The chain is re-read every refresh_interval and deployed,
+// running on_change, only when it differs from the file on disk.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/vault"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// refreshCABundle re-reads the CA chain from Vault and deploys it only when
+// it differs from the bundle on disk. It reports whether it deployed.
+func (m *Manager) refreshCABundle(managed *ManagedCertificate) (changed bool, err error) {
+	var certData *vault.CertificateData
+	changed = true
+	started, previous := time.Now(), m.notAfter(managed)
+	endSpan := m.startRotationSpan(managed, "certificate.refresh")
+	endRenewal := m.startRenewal(managed)
+	defer func() {
+		if changed {
+			m.recordRotation(managed, certData, started, previous, err)
+		}
+		endSpan(err)
+		endRenewal()
+	}()
+
+	span := m.startSpan(managed, "vault.issue")
+	certData, err = m.vaultClient.IssueCertificate(managed.Config)
+	span.End(err)
+	m.recordVaultContact(managed, err)
+	if err != nil {
+		return changed, failedIn(reasonIssue, fmt.Errorf("failed to read CA chain from vault: %w", err))
+	}
+
+	onDisk, readErr := os.ReadFile(managed.Config.CertificatePath())
+	if readErr == nil && string(onDisk) == certData.Certificate {
+		changed = false
+		m.mu.Lock()
+		managed.NextRenewal = m.clock.Now().Add(managed.Config.RefreshInterval())
+		m.unlock()
+		return changed, nil
+	}
+
+	slog.Info("CA chain in Vault differs from disk, deploying",
+		"certificate", managed.Config.Name,
+		"path", managed.Config.CertificatePath())
+	return changed, m.deployCertificate(managed, certData)
+}
+
+// writeCABundle writes the CA chain of a CA bundle entry to its
+// certificate file.
+func (m *Manager) writeCABundle(managed *ManagedCertificate, certData *vault.CertificateData) error {
+	cfg := managed.Config
+	if err := m.writeDestination(managed, "certificate", cfg.CertificatePath(), certData.Certificate, cfg.CertFileMode(), cfg.Owner, cfg.Group); err != nil {
+		return fmt.Errorf("failed to write CA bundle file: %w", err)
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// validateCABundle checks that a CA bundle holds only CA certificates.
+func validateCABundle(certData *vault.CertificateData) error {
+	certs := parseCertificateBlocks([]byte(certData.Certificate))
+	if len(certs) == 0 {
+		return fmt.Errorf("no CA certificate found")
+	}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return fmt.Errorf("certificate %s in CA bundle is not a CA", cert.Subject.CommonName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - CA Bundle Distribution Tests
+//
+// Unit tests for syncing the CA chain to a CA bundle entry's file only
+// when it changes.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_ProcessCertificates_CABundle verifies the CA chain is written
+// without a key, redeployed with on_change only when it changes, and that a
+// chain holding a non-CA certificate is refused.
+func TestManager_ProcessCertificates_CABundle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	marker := filepath.Join(tmpDir, "changed")
+	certConfig := &config.CertificateConfig{
+		Name:        "internal-ca",
+		Certificate: filepath.Join(tmpDir, "internal-ca.pem"),
+		OnChange:    "echo x >> " + marker,
+		Source:      "ca_bundle",
+		CABundle:    &config.CertCABundleSource{RefreshInterval: time.Hour},
+	}
+
+	first := &vault.CertificateData{Certificate: vault.CreateTestCertificateData().CertificateChain}
+	second := &vault.CertificateData{Certificate: first.Certificate + vault.CreateTestCertificateData().CertificateChain}
+	leaf := &vault.CertificateData{Certificate: vault.CreateTestCertificateData().Certificate}
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(first, nil).Times(2),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(second, nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(leaf, nil),
+	)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := manager.ProcessCertificates(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Make the next cycle re-read the chain.
+		manager.certificates["internal-ca"].NextRenewal = time.Time{}
+	}
+
+	bundle, err := os.ReadFile(certConfig.Certificate)
+	if err != nil {
+		t.Fatalf("failed to read CA bundle: %v", err)
+	}
+	if string(bundle) != second.Certificate {
+		t.Error("expected the CA bundle to hold the changed chain")
+	}
+	if n := len(manager.certificates["internal-ca"].History); n != 2 {
+		t.Errorf("expected 2 deployments, got %d", n)
+	}
+	runs, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("failed to read on_change marker: %v", err)
+	}
+	if n := strings.Count(string(runs), "x"); n != 2 {
+		t.Errorf("expected on_change to run twice, got %d", n)
+	}
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	history := manager.certificates["internal-ca"].History
+	if last := history[len(history)-1]; last.Success {
+		t.Error("expected a bundle holding a leaf certificate to fail")
+	}
+	if bundle, _ := os.ReadFile(certConfig.Certificate); string(bundle) != second.Certificate {
+		t.Error("expected the rejected bundle to leave the file untouched")
+	}
+}
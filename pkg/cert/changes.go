@@ -0,0 +1,71 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Change Notifications
+//
+// Lets the dashboard push status changes instead of waiting for the next
+// poll. Subscribers are signalled whenever an issuance attempt starts or
+// finishes or the managed set changes, and read the new state from
+// Snapshot. Signals coalesce, so a slow subscriber sees one pending signal
+// rather than blocking rotations.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Subscribe returns a channel signalled whenever an issuance attempt
+// starts or finishes or certificates are synced or removed, and a function
+// that stops the signals.
+func (m *Manager) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	m.subsMu.Lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[chan struct{}]struct{})
+	}
+	m.subscribers[ch] = struct{}{}
+	m.subsMu.Unlock()
+
+	return ch, func() {
+		m.subsMu.Lock()
+		delete(m.subscribers, ch)
+		m.subsMu.Unlock()
+	}
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// startRenewal marks managed as renewing until the returned function is
+// called, signalling subscribers on both changes.
+func (m *Manager) startRenewal(managed *ManagedCertificate) func() {
+	m.setRenewing(managed, true)
+	return func() { m.setRenewing(managed, false) }
+}
+
+// setRenewing sets whether an issuance attempt of managed is in progress.
+func (m *Manager) setRenewing(managed *ManagedCertificate, renewing bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	managed.Renewing = renewing
+	m.notifyChange()
+}
+
+// notifyChange publishes the certificates and signals every subscriber
+// without blocking. Callers hold m.mu, so subscribers woken by the signal
+// see the change in Snapshot.
+func (m *Manager) notifyChange() {
+	m.publish()
+
+	m.subsMu.Lock()
+	defer m.subsMu.Unlock()
+
+	for ch := range m.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
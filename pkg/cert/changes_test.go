@@ -0,0 +1,84 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Change Notification Tests
+//
+// Unit tests for signalling subscribers when renewals start and finish.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_Subscribe verifies subscribers are signalled when a renewal
+// starts and finishes, and the certificate shows as renewing in between.
+func TestManager_Subscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "test-cert",
+		Role:        "test-role",
+		CommonName:  "test.example.com",
+		Certificate: filepath.Join(tmpDir, "test.crt"),
+		Key:         filepath.Join(tmpDir, "test.key"),
+		TTL:         24 * time.Hour,
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	changes, unsubscribe := manager.Subscribe()
+	defer unsubscribe()
+
+	mockClient.EXPECT().IssueCertificate(certConfig).DoAndReturn(func(*config.CertificateConfig) (*vault.CertificateData, error) {
+		select {
+		case <-changes:
+		default:
+			t.Error("expected a signal when the renewal started")
+		}
+		if !manager.Snapshot()[0].Renewing {
+			t.Error("expected the certificate to be renewing")
+		}
+		return vault.CreateTestCertificateData(), nil
+	})
+
+	if err := manager.ForceRotate("test-cert"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-changes:
+	default:
+		t.Error("expected a signal when the renewal finished")
+	}
+	if manager.Snapshot()[0].Renewing {
+		t.Error("expected the renewal to be finished")
+	}
+
+	unsubscribe()
+	manager.SyncCertificates(nil)
+	select {
+	case <-changes:
+		t.Error("expected no signal after unsubscribing")
+	default:
+	}
+}
@@ -0,0 +1,190 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Destination Verification
+//
+// Every file a deployment writes (certificate, key, CA file, outputs, and
+// keystores) is read back and hashed, and a destination whose contents
+// differ from what was written fails the deployment. The checksums are kept
+// so later checks detect files changed or removed behind the manager's
+// back, and each destination remembers which certificate it was written
+// for, so files left behind by a partially failed deployment show up as
+// out of sync.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// DestinationStatus is the last verification of one deployed file.
+type DestinationStatus struct {
+	Path        string
+	Checksum    string // SHA-256 of the contents written
+	Fingerprint string // certificate the contents were written for
+	InSync      bool
+	Error       string
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// VerifyDestinations re-reads every destination written since startup and
+// records whether it still holds what was written. Verification is skipped
+// while a processing pass or rotation is writing files.
+func (m *Manager) VerifyDestinations() {
+	if !m.opMu.TryLock() {
+		return
+	}
+	defer m.opMu.Unlock()
+
+	for _, managed := range m.managedList() {
+		m.mu.RLock()
+		destinations := maps.Clone(managed.Destinations)
+		m.mu.RUnlock()
+
+		for role, status := range destinations {
+			m.recordDestination(managed, role, verifyDestination(status.Path, status.Checksum, status.Fingerprint))
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// writeDestination writes a deployed file and verifies it by reading it
+// back. role names the destination as in deployedFiles. While staging, the
+// file is written to its staged path and verified when committed.
+func (m *Manager) writeDestination(managed *ManagedCertificate, role, filename, content string, mode os.FileMode, owner, group string) error {
+	m.mu.RLock()
+	staging := managed.staging
+	m.mu.RUnlock()
+	if staging {
+		staged := filename + stagedSuffix
+		if err := m.writeFileWithPermissions(staged, content, mode, owner, group); err != nil {
+			return err
+		}
+		m.stageDestination(managed, role, filename, staged, content)
+		return nil
+	}
+
+	if err := m.writeFileWithPermissions(filename, content, mode, owner, group); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	deployment := managed.deployment
+	m.mu.RUnlock()
+
+	sum := sha256.Sum256([]byte(content))
+	status := verifyDestination(filename, hex.EncodeToString(sum[:]), deployment)
+	m.recordDestination(managed, role, status)
+	if !status.InSync {
+		return fmt.Errorf("verification of %s failed: %s", filename, status.Error)
+	}
+	return nil
+}
+
+// pruneDestinations forgets destinations the certificate's configuration
+// no longer writes.
+func (m *Manager) pruneDestinations(managed *ManagedCertificate) {
+	files := deployedFiles(managed)
+
+	m.mu.Lock()
+	defer m.unlock()
+	for role := range managed.Destinations {
+		if _, ok := files[role]; !ok {
+			delete(managed.Destinations, role)
+		}
+	}
+}
+
+// startDeployment marks fingerprint as the certificate every destination
+// should hold.
+func (m *Manager) startDeployment(managed *ManagedCertificate, fingerprint string) {
+	m.mu.Lock()
+	managed.deployment = fingerprint
+	m.unlock()
+}
+
+// restoreDeployment marks the certificate loaded after a rollback as the
+// one the restored files hold.
+func (m *Manager) restoreDeployment(managed *ManagedCertificate, restored []backupFile) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	managed.deployment = managed.Fingerprint
+	for _, file := range restored {
+		if status, ok := managed.Destinations[file.role]; ok {
+			status.Fingerprint = managed.Fingerprint
+			managed.Destinations[file.role] = status
+		}
+	}
+}
+
+// recordDestination stores a destination's status, logging when it goes
+// out of sync or recovers. A destination last written for another
+// certificate than the latest deployment is out of sync.
+func (m *Manager) recordDestination(managed *ManagedCertificate, role string, status DestinationStatus) {
+	m.mu.Lock()
+	if status.InSync && status.Fingerprint != managed.deployment {
+		status.InSync = false
+		status.Error = "not updated by the latest deployment"
+	}
+	previous, known := managed.Destinations[role]
+	if managed.Destinations == nil {
+		managed.Destinations = make(map[string]DestinationStatus)
+	}
+	managed.Destinations[role] = status
+	m.unlock()
+
+	switch {
+	case !status.InSync && (!known || previous.InSync):
+		slog.Error("Deployed file does not match what was written",
+			"certificate", managed.Config.Name,
+			"destination", role,
+			"path", status.Path,
+			"error", status.Error)
+	case status.InSync && known && !previous.InSync:
+		slog.Info("Deployed file back in sync",
+			"certificate", managed.Config.Name,
+			"destination", role,
+			"path", status.Path)
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// verifyDestination compares the SHA-256 of the file at path with checksum.
+func verifyDestination(path, checksum, fingerprint string) DestinationStatus {
+	status := DestinationStatus{Path: path, Checksum: checksum, Fingerprint: fingerprint}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != checksum {
+		status.Error = "checksum mismatch"
+		return status
+	}
+	status.InSync = true
+	return status
+}
@@ -0,0 +1,113 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Destination Verification Tests
+//
+// Unit tests for per-destination verification of deployed files.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_VerifyDestinations verifies deployed files are tracked per
+// destination, tampering is detected, and destinations a failed deployment
+// did not reach are reported out of sync.
+func TestManager_VerifyDestinations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "db",
+		Role:        "test-role",
+		CommonName:  "db.example.com",
+		Certificate: filepath.Join(tmpDir, "db.crt"),
+		Key:         filepath.Join(tmpDir, "db.key"),
+		TTL:         24 * time.Hour,
+		Outputs: []config.OutputConfig{
+			{Type: "leaf", Path: filepath.Join(tmpDir, "out", "leaf.pem"), Mode: "0644"},
+			{Type: "key", Path: filepath.Join(tmpDir, "out", "key.pem"), Mode: "0600"},
+		},
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(newSelfSignedCertificateData(t), nil)
+	if err := manager.ForceRotate("db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inSync := func() map[string]bool {
+		manager.VerifyDestinations()
+		result := make(map[string]bool)
+		for role, status := range manager.Snapshot()[0].Destinations {
+			result[role] = status.InSync
+		}
+		return result
+	}
+
+	got := inSync()
+	for _, role := range []string{"certificate", "key", "output-0", "output-1"} {
+		if !got[role] {
+			t.Errorf("expected %s in sync after deployment, got %v", role, got)
+		}
+	}
+
+	// A file changed behind the manager's back is detected and recovers
+	// once restored.
+	original, _ := os.ReadFile(certConfig.Key)
+	if err := os.WriteFile(certConfig.Key, []byte("tampered"), 0600); err != nil {
+		t.Fatalf("failed to tamper with key: %v", err)
+	}
+	if inSync()["key"] {
+		t.Error("expected tampered key to be out of sync")
+	}
+	if err := os.WriteFile(certConfig.Key, original, 0600); err != nil {
+		t.Fatalf("failed to restore key: %v", err)
+	}
+	if !inSync()["key"] {
+		t.Error("expected restored key to be back in sync")
+	}
+
+	// The next deployment fails writing output-0, so output-1 still holds
+	// the previous certificate.
+	_ = os.Remove(certConfig.Outputs[0].Path)
+	if err := os.Mkdir(certConfig.Outputs[0].Path, 0755); err != nil {
+		t.Fatalf("failed to block output: %v", err)
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(newSelfSignedCertificateData(t), nil)
+	if err := manager.ForceRotate("db"); err == nil {
+		t.Fatal("expected deployment to fail")
+	}
+
+	got = inSync()
+	if !got["certificate"] || !got["key"] {
+		t.Errorf("expected rewritten files in sync, got %v", got)
+	}
+	if got["output-0"] || got["output-1"] {
+		t.Errorf("expected outputs the deployment did not update to be out of sync, got %v", got)
+	}
+	if status := manager.Snapshot()[0].Destinations["output-1"]; status.Error != "not updated by the latest deployment" {
+		t.Errorf("unexpected output-1 error: %q", status.Error)
+	}
+}
@@ -0,0 +1,173 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Output Encoding
+//
+// Converts issued PEM material to the file formats a certificate is
+// configured for: PKCS#8 private keys regardless of what the source
+// returned, and DER files for appliances that cannot read PEM. DER files
+// are converted back to PEM when read so the rest of the manager only
+// deals with PEM.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// PUBLIC FUNCTIONS
+// -------------------------------------------------------------------------
+
+// LoadKeyPair reads the deployed certificate and key as a TLS key pair,
+// from DER or an encrypted key as configured.
+func LoadKeyPair(cfg *config.CertificateConfig) (tls.Certificate, error) {
+	certPEM, err := readPEMFile(cfg, cfg.CertificatePath())
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	keyPEM, err := existingKeyPEM(cfg)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read private key: %w", err)
+	}
+	return tls.X509KeyPair(certPEM, []byte(keyPEM))
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// certificateFile returns the certificate file contents for cfg. PEM
+// certificate files carry the chain unless exclude_chain is set; DER
+// certificate files hold only the leaf.
+func certificateFile(cfg *config.CertificateConfig, leaf, chain string) string {
+	if cfg.IsDER() || cfg.ExcludeChain {
+		return leaf
+	}
+	return encodeOutput(cfg, "fullchain", leaf, "", chain)
+}
+
+// encodeOutput returns the contents of an additional output file built from
+// the encoded leaf, key, and chain.
+func encodeOutput(cfg *config.CertificateConfig, outputType, leaf, key, chain string) string {
+	sep := "\n"
+	if cfg.IsDER() {
+		sep = ""
+	}
+	fullchain := leaf
+	if chain != "" {
+		fullchain += sep + chain
+	}
+
+	switch outputType {
+	case "leaf":
+		return leaf
+	case "chain":
+		return chain
+	case "key":
+		return key
+	case "combined":
+		return fullchain + sep + key
+	default: // fullchain
+		return fullchain
+	}
+}
+
+// encodeParts encodes the leaf certificate, private key, and CA chain
+// separately. PEM parts are returned as issued, apart from the PKCS#8
+// conversion and key_encryption; a DER chain holds the certificates back to back, as written
+// to ca_file.
+func encodeParts(cfg *config.CertificateConfig, certData *vault.CertificateData) (leaf, key, chain string, err error) {
+	key = certData.PrivateKey
+	if cfg.PrivateKeyFormat == "pkcs8" {
+		if key, err = toPKCS8PEM(key); err != nil {
+			return "", "", "", err
+		}
+	}
+	if cfg.KeyEncryption != nil {
+		passphrase, err := keyPassphrase(cfg.KeyEncryption)
+		if err != nil {
+			return "", "", "", err
+		}
+		if key, err = encryptKeyPEM(key, passphrase); err != nil {
+			return "", "", "", err
+		}
+	}
+
+	if !cfg.IsDER() {
+		return certData.Certificate, key, certData.CertificateChain, nil
+	}
+
+	leafDER := firstCertificateDER([]byte(certData.Certificate))
+	if leafDER == nil {
+		return "", "", "", fmt.Errorf("no certificate to encode as DER")
+	}
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return "", "", "", fmt.Errorf("no private key to encode as DER")
+	}
+	var chainDER []byte
+	for _, cert := range parseCertificateBlocks([]byte(certData.CertificateChain)) {
+		chainDER = append(chainDER, cert.Raw...)
+	}
+	return string(leafDER), string(block.Bytes), string(chainDER), nil
+}
+
+// readPEMFile reads a deployed certificate, key, or CA file, converting DER
+// files back to PEM.
+func readPEMFile(cfg *config.CertificateConfig, path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !cfg.IsDER() {
+		return data, err
+	}
+
+	if certs, err := x509.ParseCertificates(data); err == nil {
+		var out []byte
+		for _, cert := range certs {
+			out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+		return out, nil
+	}
+
+	if blockType := privateKeyType(data); blockType != "" {
+		return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), nil
+	}
+	return nil, fmt.Errorf("%s is not a DER certificate or private key", path)
+}
+
+// toPKCS8PEM re-encodes a PEM private key as PKCS#8.
+func toPKCS8PEM(keyPEM string) (string, error) {
+	key, err := parsePrivateKeyPEM([]byte(keyPEM))
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode private key as PKCS#8: %w", err)
+	}
+	return strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))), nil
+}
+
+// privateKeyType returns the PEM block type of a DER private key, or "".
+func privateKeyType(der []byte) string {
+	if _, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return "PRIVATE KEY"
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return "RSA PRIVATE KEY"
+	}
+	if _, err := x509.ParseECPrivateKey(der); err == nil {
+		return "EC PRIVATE KEY"
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Renewal Failure Reasons
+//
+// Tags errors with the stage of an issuance attempt that failed, so the
+// rotation history and managed_cert_renewal_failures_total can tell Vault
+// outages from failed writes, rejected deployments, and reloads that never
+// took effect without parsing error messages.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import "errors"
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// Stages an issuance attempt can fail in, reported as RotationEvent.Reason.
+const (
+	reasonIssue       = "issue"       // Vault, ACME, or KV request failed
+	reasonValidation  = "validation"  // issued material failed validation
+	reasonWrite       = "write"       // files could not be written or loaded
+	reasonPreChange   = "pre_change"  // pre_change rejected the deployment
+	reasonOnChange    = "on_change"   // on_change failed and was rolled back
+	reasonUnconfirmed = "unconfirmed" // the target never served the new certificate
+	reasonOther       = "other"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// stageError is an error tagged with the stage that failed.
+type stageError struct {
+	reason string
+	err    error
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Error returns the underlying error's message unchanged.
+func (e *stageError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *stageError) Unwrap() error {
+	return e.err
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// failedIn tags err with the stage that failed.
+func failedIn(reason string, err error) error {
+	return &stageError{reason: reason, err: err}
+}
+
+// failureReason returns the stage err was tagged with, or "other".
+func failureReason(err error) string {
+	if errors.Is(err, errUnconfirmed) {
+		return reasonUnconfirmed
+	}
+	var stage *stageError
+	if errors.As(err, &stage) {
+		return stage.reason
+	}
+	return reasonOther
+}
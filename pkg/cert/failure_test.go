@@ -0,0 +1,127 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Renewal Failure Reason Tests
+//
+// Unit tests for tagging failed issuance attempts with the stage that
+// failed and reporting them to the renewal observer.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_RenewalObserver verifies every attempt is reported with its
+// duration, and failures with the stage that failed and the correlation ID
+// of a failed Vault request.
+func TestManager_RenewalObserver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	observer := &fakeObserver{}
+	manager := NewManager(mockClient)
+	manager.SetRenewalObserver(observer)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		Backup:      &config.BackupConfig{Keep: 1},
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(nil, &vault.RequestError{
+			CorrelationID:  "corr-1",
+			VaultRequestID: "req-1",
+			Err:            errors.New("permission denied"),
+		}),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil),
+	)
+
+	_ = manager.ForceRotate("web")
+	if err := manager.ForceRotate("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certConfig.OnChange = "exit 1"
+	_ = manager.ForceRotate("web")
+
+	want := []string{reasonIssue, "", reasonOnChange}
+	if len(observer.events) != len(want) {
+		t.Fatalf("expected %d observed attempts, got %d", len(want), len(observer.events))
+	}
+	for i, event := range observer.events {
+		if event.Reason != want[i] || event.Success != (want[i] == "") {
+			t.Errorf("attempt %d: expected reason %q, got %+v", i, want[i], event)
+		}
+	}
+	if failed := observer.events[0]; failed.CorrelationID != "corr-1" || failed.VaultRequestID != "req-1" {
+		t.Errorf("expected the failed request's IDs, got %q %q", failed.CorrelationID, failed.VaultRequestID)
+	}
+	history := manager.certificates["web"].History
+	if history[2].Reason != reasonOnChange {
+		t.Errorf("expected the reason in the history, got %q", history[2].Reason)
+	}
+	if history[0].CorrelationID != "corr-1" {
+		t.Errorf("expected the correlation ID in the history, got %q", history[0].CorrelationID)
+	}
+}
+
+// TestFailureReason verifies the tagged stage survives wrapping and
+// unconfirmed rotations are recognized.
+func TestFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{failedIn(reasonWrite, errors.New("disk full")), reasonWrite},
+		{errors.Join(errRolledBack, failedIn(reasonPreChange, errors.New("rejected"))), reasonPreChange},
+		{errUnconfirmed, reasonUnconfirmed},
+		{errors.New("unknown"), reasonOther},
+	}
+	for _, tt := range tests {
+		if got := failureReason(tt.err); got != tt.want {
+			t.Errorf("failureReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeObserver records observed issuance outcomes.
+type fakeObserver struct {
+	events []RotationEvent
+}
+
+// ObserveRenewal implements RenewalObserver.
+func (f *fakeObserver) ObserveRenewal(_ string, event RotationEvent, duration time.Duration) {
+	if duration <= 0 {
+		event.Reason = "missing duration"
+	}
+	f.events = append(f.events, event)
+}
@@ -0,0 +1,217 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - on_change Hooks
+//
+// Runs a certificate's on_change command, or its built-in on_change_action,
+// with a timeout so a hung hook cannot stall renewals. Commands run in
+// their own process group, and on expiry the whole group is killed,
+// including anything the script started. Failed attempts are retried with
+// exponential backoff and counted per certificate. Commands are told which
+// certificate they run for through CERT_* environment variables, so one
+// script can reload many services.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/reload"
+	"cert-manager/pkg/sandbox"
+	"cert-manager/pkg/vault"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// hookWaitDelay bounds the wait for a killed hook's output to be closed by
+// processes that left its process group.
+const hookWaitDelay = 5 * time.Second
+
+// maxHookOutput bounds the on_change output kept for the dashboard. The
+// end of the output is kept, where errors usually are.
+const maxHookOutput = 4096
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// HookFailures counts failed on_change attempts, retries included.
+type HookFailures struct {
+	Timeouts int `json:"timeouts,omitempty"` // killed after the policy timeout
+	Errors   int `json:"errors,omitempty"`   // exited non-zero or could not start
+}
+
+// HookRun is the outcome of an on_change attempt.
+type HookRun struct {
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_seconds"`
+	Command  string    `json:"command"`          // on_change, or the on_change_action performed
+	Output   string    `json:"output,omitempty"` // combined output of on_change, truncated
+	Error    string    `json:"error,omitempty"`
+	TimedOut bool      `json:"timed_out,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// runOnChangeScript executes the certificate's post-renewal action or
+// script, the script inside its sandbox when one is configured, retrying failures as its
+// on_change_policy allows. It returns the last attempt's error.
+func (m *Manager) runOnChangeScript(managed *ManagedCertificate) (err error) {
+	span := m.startSpan(managed, "hook.on_change")
+	defer func() { span.End(err) }()
+
+	policy := managed.Config.OnChangePolicy
+	backoff := policy.Backoff
+
+	for attempt := 0; ; attempt++ {
+		err := m.runHook(managed)
+		if err == nil {
+			return nil
+		}
+		if attempt >= policy.Retries {
+			return err
+		}
+
+		slog.Warn("on_change script failed, retrying",
+			"certificate", managed.Config.Name,
+			"attempt", attempt+1,
+			"retry_in", backoff,
+			"error", err)
+		time.Sleep(backoff)
+		backoff = min(2*backoff, policy.MaxBackoff)
+	}
+}
+
+// runHook runs on_change_action or on_change once, giving up once the
+// policy timeout passes, and counts a failure. A timed out script is
+// killed with its process group. The attempt is kept as LastHook.
+func (m *Manager) runHook(managed *ManagedCertificate) (err error) {
+	run := &HookRun{Time: m.clock.Now(), Command: managed.Config.OnChange}
+	started := time.Now()
+	defer func() {
+		run.Duration = time.Since(started).Seconds()
+		if err != nil {
+			run.Error = err.Error()
+		}
+		m.mu.Lock()
+		managed.LastHook = run
+		m.unlock()
+	}()
+
+	ctx := context.Background()
+	if timeout := managed.Config.OnChangePolicy.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if action := managed.Config.OnChangeAction; action != nil {
+		run.Command = reload.Describe(action)
+		err := reload.Run(ctx, action)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			run.TimedOut = true
+			m.recordHookFailure(managed, true)
+			return fmt.Errorf("%s timed out after %s: %w", reload.Describe(action), managed.Config.OnChangePolicy.Timeout, err)
+		}
+		if err != nil {
+			m.recordHookFailure(managed, false)
+			return fmt.Errorf("%s failed: %w", reload.Describe(action), err)
+		}
+		slog.Debug("On-change action executed successfully",
+			"action", reload.Describe(action))
+		return nil
+	}
+
+	output, err := m.runCommand(ctx, managed, managed.Config.OnChange, nil)
+	run.Output = truncateOutput(output)
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		run.TimedOut = true
+		m.recordHookFailure(managed, true)
+		return fmt.Errorf("script timed out after %s and was killed: %s",
+			managed.Config.OnChangePolicy.Timeout, output)
+	}
+	if err != nil {
+		m.recordHookFailure(managed, false)
+		return fmt.Errorf("script failed with error %v: %s", err, output)
+	}
+	slog.Debug("On-change script executed successfully",
+		"output", output)
+	return nil
+}
+
+// runCommand runs script with sh in the certificate's sandbox and its own
+// process group, which is killed when ctx is done. The CERT_* variables
+// and env are added to its environment. It returns the combined output.
+func (m *Manager) runCommand(ctx context.Context, managed *ManagedCertificate, script string, env []string) (string, error) {
+	cmd, err := sandbox.CommandContext(ctx, script, managed.Config.OnChangeSandbox)
+	if err != nil {
+		return "", fmt.Errorf("failed to prepare script sandbox: %w", err)
+	}
+	m.mu.RLock()
+	env = append(hookEnv(managed), env...)
+	m.mu.RUnlock()
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, env...)
+
+	setProcessGroup(cmd)
+	cmd.WaitDelay = hookWaitDelay
+
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
+// recordHookFailure counts a failed on_change attempt.
+func (m *Manager) recordHookFailure(managed *ManagedCertificate, timedOut bool) {
+	m.mu.Lock()
+	defer m.unlock()
+
+	if timedOut {
+		managed.HookFailures.Timeouts++
+	} else {
+		managed.HookFailures.Errors++
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// hookEnv returns the CERT_* variables describing the deployed certificate.
+// Values not known, e.g. before a certificate could be loaded, are empty.
+// The caller must hold m.mu.
+func hookEnv(managed *ManagedCertificate) []string {
+	var notAfter, serial string
+	if managed.Certificate != nil {
+		notAfter = managed.Certificate.NotAfter.UTC().Format(time.RFC3339)
+		serial = vault.FormatSerial(managed.Certificate.SerialNumber.Bytes())
+	}
+	return []string{
+		"CERT_NAME=" + managed.Config.Name,
+		"CERT_PATH=" + managed.Config.CertificatePath(),
+		"KEY_PATH=" + managed.Config.KeyPath(),
+		"CERT_FINGERPRINT=" + managed.Fingerprint,
+		"CERT_NOT_AFTER=" + notAfter,
+		"CERT_SERIAL=" + serial,
+	}
+}
+
+// truncateOutput keeps the last maxHookOutput bytes of hook output.
+func truncateOutput(output string) string {
+	if len(output) <= maxHookOutput {
+		return output
+	}
+	return "..." + output[len(output)-maxHookOutput:]
+}
@@ -0,0 +1,182 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - on_change Hook Tests
+//
+// Unit tests for hook timeouts, process group kills, and retries.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRunOnChangeScript_Timeout verifies a hung hook is killed with the
+// processes it started once its timeout passes.
+func TestRunOnChangeScript_Timeout(t *testing.T) {
+	manager := NewManager(nil)
+	managed := &ManagedCertificate{Config: &config.CertificateConfig{
+		Name: "web",
+		// The background sleep keeps the output pipe open unless the
+		// whole process group is killed.
+		OnChange:       "sleep 30 & sleep 30",
+		OnChangePolicy: config.HookPolicy{Timeout: 100 * time.Millisecond},
+	}}
+
+	start := time.Now()
+	err := manager.runOnChangeScript(managed)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected hook to be killed promptly, took %s", elapsed)
+	}
+	if managed.HookFailures.Timeouts != 1 || managed.HookFailures.Errors != 0 {
+		t.Errorf("unexpected failure counts %+v", managed.HookFailures)
+	}
+}
+
+// TestRunOnChangeScript_Retries verifies failed attempts are retried up to
+// the policy's limit and each failure is counted.
+func TestRunOnChangeScript_Retries(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "attempted")
+	manager := NewManager(nil)
+	managed := &ManagedCertificate{Config: &config.CertificateConfig{
+		Name:           "web",
+		OnChange:       "test -f " + marker + " || { touch " + marker + "; exit 1; }",
+		OnChangePolicy: config.HookPolicy{Retries: 2, Backoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond},
+	}}
+
+	if err := manager.runOnChangeScript(managed); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if managed.HookFailures.Errors != 1 {
+		t.Errorf("expected 1 counted failure, got %+v", managed.HookFailures)
+	}
+
+	managed.Config.OnChange = "exit 3"
+	if err := manager.runOnChangeScript(managed); err == nil {
+		t.Fatal("expected error after retries are exhausted")
+	}
+	if managed.HookFailures.Errors != 4 {
+		t.Errorf("expected 3 more failures, got %+v", managed.HookFailures)
+	}
+}
+
+// TestRunOnChangeScript_Environment verifies the hook is given the
+// deployed certificate's name, paths, fingerprint, expiry, and serial.
+func TestRunOnChangeScript_Environment(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	envFile := filepath.Join(tmpDir, "env")
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		OnChange:    "env | grep -E '^(CERT|KEY)_' > " + envFile,
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatalf("expected on_change to run: %v", err)
+	}
+	managed := manager.certificates["web"]
+	for _, want := range []string{
+		"CERT_NAME=web",
+		"CERT_PATH=" + certConfig.Certificate,
+		"KEY_PATH=" + certConfig.Key,
+		"CERT_FINGERPRINT=" + managed.Fingerprint,
+		"CERT_NOT_AFTER=" + managed.Certificate.NotAfter.UTC().Format(time.RFC3339),
+		"CERT_SERIAL=" + vault.FormatSerial(managed.Certificate.SerialNumber.Bytes()),
+	} {
+		if !strings.Contains(string(data), want+"\n") {
+			t.Errorf("expected %s in hook environment:\n%s", want, data)
+		}
+	}
+}
+
+// TestRunOnChangeScript_Action verifies a built-in action runs instead of
+// a script and its failures are counted.
+func TestRunOnChangeScript_Action(t *testing.T) {
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	manager := NewManager(nil)
+	managed := &ManagedCertificate{Config: &config.CertificateConfig{
+		Name:           "web",
+		OnChangeAction: &config.ActionConfig{HTTP: &config.HTTPAction{URL: server.URL, Method: "POST"}},
+	}}
+
+	if err := manager.runOnChangeScript(managed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	status = http.StatusInternalServerError
+	if err := manager.runOnChangeScript(managed); err == nil {
+		t.Fatal("expected error")
+	}
+	if managed.HookFailures.Errors != 1 {
+		t.Errorf("expected 1 counted failure, got %+v", managed.HookFailures)
+	}
+}
+
+// TestRunOnChangeScript_LastHook verifies the last attempt's output and
+// error are kept, with long output truncated to its end.
+func TestRunOnChangeScript_LastHook(t *testing.T) {
+	manager := NewManager(nil)
+	managed := &ManagedCertificate{Config: &config.CertificateConfig{
+		Name:     "web",
+		OnChange: "echo reloaded",
+	}}
+
+	if err := manager.runOnChangeScript(managed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if run := managed.LastHook; run == nil || run.Command != "echo reloaded" || run.Output != "reloaded\n" || run.Error != "" {
+		t.Errorf("unexpected last hook %+v", run)
+	}
+
+	managed.Config.OnChange = "head -c 10000 /dev/zero | tr '\\0' x; echo failed; exit 1"
+	if err := manager.runOnChangeScript(managed); err == nil {
+		t.Fatal("expected error")
+	}
+	run := managed.LastHook
+	if run.Error == "" || !strings.HasSuffix(run.Output, "xfailed\n") || len(run.Output) != maxHookOutput+len("...") {
+		t.Errorf("expected the truncated failure to be kept, got error %q and %d bytes of output", run.Error, len(run.Output))
+	}
+}
@@ -0,0 +1,35 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - on_change Process Group (Unix)
+//
+// Runs on_change commands in their own process group and kills the whole
+// group when the hook is cancelled.
+// -------------------------------------------------------------------------------
+
+//go:build !windows
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// setProcessGroup starts cmd in a new process group, killed as a whole on
+// cancellation.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
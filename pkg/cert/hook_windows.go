@@ -0,0 +1,31 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - on_change Process Group (Windows)
+//
+// Runs on_change commands in a new process group. On cancellation only the
+// command itself is killed; processes it started are left running.
+// -------------------------------------------------------------------------------
+
+//go:build windows
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// setProcessGroup starts cmd in a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
@@ -0,0 +1,199 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Integrity Watching
+//
+// Watches every deployed file for changes made outside the manager, e.g. an
+// operator or config management tool overwriting or deleting a certificate.
+// Drift is logged, the certificate is reloaded from disk so its fingerprint
+// reflects what is deployed, and optionally it is reissued to restore the
+// managed state. Otherwise deleted files are reissued by the next pass.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/fswatch"
+	"context"
+	"log/slog"
+	"maps"
+	"os"
+	"sort"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// integrityRefreshInterval is how often the watched files are refreshed,
+// picking up certificates added since and directories created since.
+const integrityRefreshInterval = 30 * time.Second
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// fileDrift is a deployed file found changed outside the manager.
+type fileDrift struct {
+	role   string
+	path   string
+	change string // "modified" or "deleted"
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// WatchFiles watches deployed files until ctx is done, checking a
+// certificate's files once they have been quiet for debounce after a
+// change. With reissue, certificates whose files drifted are reissued.
+func (m *Manager) WatchFiles(ctx context.Context, reissue bool, debounce time.Duration) error {
+	watcher, err := fswatch.New()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = watcher.Close() }()
+
+	refresh := func() {
+		if err := watcher.Set(m.deployedPaths()); err != nil {
+			slog.Warn("Failed to watch deployed files", "error", err)
+		}
+	}
+	refresh()
+
+	ticker := time.NewTicker(integrityRefreshInterval)
+	defer ticker.Stop()
+
+	pending := make(map[string]bool)
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			refresh()
+		case path := <-watcher.Events():
+			pending[path] = true
+			settle = time.After(debounce)
+		case <-settle:
+			m.reconcilePaths(pending, reissue)
+			pending = make(map[string]bool)
+			settle = nil
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// deployedPaths lists every file written for a managed certificate.
+func (m *Manager) deployedPaths() []string {
+	var paths []string
+	for _, managed := range m.managedList() {
+		for _, file := range deployedFiles(managed) {
+			paths = append(paths, file.path)
+		}
+	}
+	return paths
+}
+
+// reconcilePaths checks the certificates owning any of paths.
+func (m *Manager) reconcilePaths(paths map[string]bool, reissue bool) {
+	for _, managed := range m.managedList() {
+		for _, file := range deployedFiles(managed) {
+			if paths[file.path] {
+				m.reconcileCertificate(managed, reissue)
+				break
+			}
+		}
+	}
+}
+
+// reconcileCertificate logs drift in a certificate's files, reloads the
+// certificate from disk, and reissues it when reissue is set. Drift is
+// judged after any rotation in progress, so the manager's own writes do
+// not count.
+func (m *Manager) reconcileCertificate(managed *ManagedCertificate, reissue bool) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	drifts := m.detectDrift(managed)
+	if len(drifts) == 0 {
+		return
+	}
+
+	name := managed.Config.Name
+	for _, drift := range drifts {
+		slog.Warn("Managed file changed outside vault-cert-manager",
+			"certificate", name,
+			"destination", drift.role,
+			"path", drift.path,
+			"change", drift.change)
+	}
+
+	m.mu.Lock()
+	err := m.loadExistingCertificate(managed)
+	m.unlock()
+	if err != nil {
+		slog.Warn("Failed to reload drifted certificate", "certificate", name, "error", err)
+	}
+
+	// Another system owns certificates that are not fully managed, so
+	// their changes are only logged.
+	if !reissue || !managed.Config.AutoRenews() || !managed.Config.IssuesMissing() {
+		// Deleted files make the certificate due now.
+		m.notifyScheduleChanged()
+		return
+	}
+	slog.Info("Reissuing certificate to restore managed files", "certificate", name)
+	if err := m.issueCertificate(managed); err != nil {
+		slog.Error("Failed to reissue drifted certificate", "certificate", name, "error", err)
+	}
+}
+
+// detectDrift compares a certificate's files with what was last written.
+// Destinations written since startup are compared by checksum; otherwise
+// only a deleted certificate or key file, or a certificate file holding
+// another certificate than the one loaded, is drift.
+func (m *Manager) detectDrift(managed *ManagedCertificate) []fileDrift {
+	m.mu.RLock()
+	destinations := maps.Clone(managed.Destinations)
+	fingerprint := managed.Fingerprint
+	m.mu.RUnlock()
+
+	var drifts []fileDrift
+	for role, file := range deployedFiles(managed) {
+		_, err := os.Stat(file.path)
+		change := "modified"
+		if os.IsNotExist(err) {
+			change = "deleted"
+		}
+
+		if status, tracked := destinations[role]; tracked {
+			result := verifyDestination(file.path, status.Checksum, status.Fingerprint)
+			m.recordDestination(managed, role, result)
+			if !result.InSync {
+				drifts = append(drifts, fileDrift{role: role, path: file.path, change: change})
+			}
+			continue
+		}
+
+		switch {
+		case role != "certificate" && role != "key":
+		case change == "deleted":
+			drifts = append(drifts, fileDrift{role: role, path: file.path, change: change})
+		case role == "certificate" && fingerprint != "":
+			data, err := readPEMFile(managed.Config, file.path)
+			if err == nil && m.calculateFingerprint(data) != fingerprint {
+				drifts = append(drifts, fileDrift{role: role, path: file.path, change: change})
+			}
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool { return drifts[i].role < drifts[j].role })
+	return drifts
+}
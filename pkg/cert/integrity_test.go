@@ -0,0 +1,152 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Integrity Watching Tests
+//
+// Unit tests for detecting and reconciling deployed files changed outside
+// the manager.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_ReconcileCertificate verifies an overwritten certificate is
+// reloaded from disk, and a deleted key makes the certificate due without
+// reissue.
+func TestManager_ReconcileCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, certConfig, mockClient := newIntegrityManager(t, ctrl)
+
+	// The manager's own deployment is not drift.
+	if drifts := manager.detectDrift(manager.certificates["web"]); len(drifts) != 0 {
+		t.Fatalf("expected no drift after deployment, got %+v", drifts)
+	}
+
+	foreign := newSelfSignedCertificateData(t)
+	if err := os.WriteFile(certConfig.Certificate, []byte(foreign.Certificate), 0644); err != nil {
+		t.Fatalf("failed to overwrite certificate: %v", err)
+	}
+	managed := manager.certificates["web"]
+	manager.reconcileCertificate(managed, false)
+
+	snapshot := manager.Snapshot()[0]
+	if snapshot.Fingerprint != manager.calculateFingerprint([]byte(foreign.Certificate)) {
+		t.Error("expected fingerprint of the overwritten certificate")
+	}
+	if snapshot.Destinations["certificate"].InSync {
+		t.Error("expected overwritten certificate to be out of sync")
+	}
+
+	if err := os.Remove(certConfig.Key); err != nil {
+		t.Fatalf("failed to remove key: %v", err)
+	}
+	select {
+	case <-manager.ScheduleChanged():
+	default:
+	}
+	manager.reconcileCertificate(managed, false)
+	select {
+	case <-manager.ScheduleChanged():
+	default:
+		t.Error("expected a schedule change for the deleted key")
+	}
+	if due, _ := manager.NextDue(); due.After(time.Now()) {
+		t.Errorf("expected certificate with a deleted key to be due now, got %v", due)
+	}
+
+	// With reissue, the managed files are restored.
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	manager.reconcileCertificate(managed, true)
+	if !fileExists(certConfig.Key) {
+		t.Error("expected reissue to restore the key")
+	}
+	if drifts := manager.detectDrift(managed); len(drifts) != 0 {
+		t.Errorf("expected no drift after reissue, got %+v", drifts)
+	}
+}
+
+// TestManager_WatchFiles verifies a changed file is detected and reissued
+// through the file watcher.
+func TestManager_WatchFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	manager, certConfig, mockClient := newIntegrityManager(t, ctrl)
+	reissued := make(chan struct{})
+	mockClient.EXPECT().IssueCertificate(certConfig).DoAndReturn(func(*config.CertificateConfig) (*vault.CertificateData, error) {
+		close(reissued)
+		return vault.CreateTestCertificateData(), nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = manager.WatchFiles(ctx, true, 10*time.Millisecond)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Give the watcher time to start before changing the file.
+	time.Sleep(100 * time.Millisecond)
+	if err := os.WriteFile(certConfig.Key, []byte("edited by hand"), 0600); err != nil {
+		t.Fatalf("failed to overwrite key: %v", err)
+	}
+
+	select {
+	case <-reissued:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the drifted certificate to be reissued")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// newIntegrityManager returns a manager with one deployed certificate.
+func newIntegrityManager(t *testing.T, ctrl *gomock.Controller) (*Manager, *config.CertificateConfig, *vault.MockClient) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	if err := manager.ForceRotate("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return manager, certConfig, mockClient
+}
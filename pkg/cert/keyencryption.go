@@ -0,0 +1,224 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Encrypted Private Keys
+//
+// Encrypts private keys written to disk as PKCS#8 EncryptedPrivateKeyInfo
+// (PBES2, RFC 8018): AES-256-CBC under a key derived from the configured
+// passphrase with PBKDF2-HMAC-SHA256. OpenSSL, Java, and most appliances
+// read this format as "BEGIN ENCRYPTED PRIVATE KEY". Keys read back from
+// disk, e.g. to rebuild a keystore, are decrypted with the same passphrase.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// encryptedKeyBlock is the PEM block type of an encrypted PKCS#8 key.
+const encryptedKeyBlock = "ENCRYPTED PRIVATE KEY"
+
+// pbkdf2Iterations is the PBKDF2 work factor for keys this release writes.
+const pbkdf2Iterations = 100000
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// Object identifiers of the PBES2 algorithms used.
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// encryptedPrivateKeyInfo is the PKCS#8 EncryptedPrivateKeyInfo structure.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// pbes2Params are the parameters of the PBES2 algorithm.
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// pbkdf2Params are the parameters of the PBKDF2 key derivation function.
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// keyPassphrase returns the configured passphrase for encrypted keys.
+func keyPassphrase(cfg *config.KeyEncryptionConfig) (string, error) {
+	var passphrase string
+	if cfg.PassphraseFile != "" {
+		data, err := os.ReadFile(cfg.PassphraseFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read key passphrase file: %w", err)
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	} else {
+		passphrase = os.Getenv(cfg.PassphraseEnv)
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("key passphrase is empty")
+	}
+	return passphrase, nil
+}
+
+// encryptKeyPEM encrypts a PEM private key as an encrypted PKCS#8 PEM
+// block.
+func encryptKeyPEM(keyPEM, passphrase string) (string, error) {
+	pkcs8, err := toPKCS8PEM(keyPEM)
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode([]byte(pkcs8))
+
+	salt := make([]byte, 16)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate IV: %w", err)
+	}
+
+	key := pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, 32, sha256.New)
+	aesBlock, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	padding := aes.BlockSize - len(block.Bytes)%aes.BlockSize
+	data := append(append([]byte{}, block.Bytes...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	cipher.NewCBCEncrypter(aesBlock, iv).CryptBlocks(data, data)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return "", err
+	}
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return "", err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+	})
+	if err != nil {
+		return "", err
+	}
+	der, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: data,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode encrypted private key: %w", err)
+	}
+	return strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: encryptedKeyBlock, Bytes: der}))), nil
+}
+
+// decryptKeyPEM replaces encrypted PKCS#8 blocks in data with the
+// decrypted keys. Other blocks are kept as they are.
+func decryptKeyPEM(data []byte, passphrase string) ([]byte, error) {
+	var out []byte
+	for {
+		block, rest := pem.Decode(data)
+		if block == nil {
+			return out, nil
+		}
+		data = rest
+		if block.Type == encryptedKeyBlock {
+			der, err := decryptPKCS8(block.Bytes, passphrase)
+			if err != nil {
+				return nil, err
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		}
+		out = append(out, pem.EncodeToMemory(block)...)
+	}
+}
+
+// decryptPKCS8 decrypts a PBES2 EncryptedPrivateKeyInfo with PBKDF2 and
+// AES-256-CBC, returning the PKCS#8 DER.
+func decryptPKCS8(der []byte, passphrase string) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse encrypted private key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported private key encryption %s", info.Algorithm.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse PBES2 parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) || !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, fmt.Errorf("unsupported PBES2 algorithms %s, %s", params.KeyDerivationFunc.Algorithm, params.EncryptionScheme.Algorithm)
+	}
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("failed to parse PBKDF2 parameters: %w", err)
+	}
+	if !kdf.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		return nil, fmt.Errorf("unsupported PBKDF2 PRF %s", kdf.PRF.Algorithm)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid AES-256-CBC IV")
+	}
+
+	data := info.EncryptedData
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid encrypted private key length")
+	}
+	key := pbkdf2.Key([]byte(passphrase), kdf.Salt, kdf.IterationCount, 32, sha256.New)
+	aesBlock, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(aesBlock, iv).CryptBlocks(plain, data)
+
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plain[len(plain)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, fmt.Errorf("failed to decrypt private key, wrong passphrase?")
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -0,0 +1,149 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Private Key Reuse
+//
+// With reuse_private_key set, a certificate whose key is on disk is renewed
+// by having the PKI role sign a CSR for that key instead of issuing a fresh
+// key, so the public key stays the same across renewals for pinning and
+// hardware clients. Without a key on disk, e.g. on first issuance, Vault
+// issues a new key as usual.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// requestCertificate issues a certificate from Vault, or, with
+// reuse_private_key and a key on disk, has Vault sign a CSR for that key.
+func (m *Manager) requestCertificate(managed *ManagedCertificate) (*vault.CertificateData, error) {
+	cfg := managed.Config
+	if !cfg.ReusePrivateKey {
+		return m.vaultClient.IssueCertificate(cfg)
+	}
+
+	keyPEM, err := existingKeyPEM(cfg)
+	if os.IsNotExist(err) {
+		slog.Info("No private key on disk to reuse, issuing with a new key", "certificate", cfg.Name)
+		return m.vaultClient.IssueCertificate(cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key to reuse: %w", err)
+	}
+
+	signer, ok := m.vaultClient.(vault.CSRSigner)
+	if !ok {
+		return nil, fmt.Errorf("vault client cannot sign CSRs")
+	}
+	key, err := parsePrivateKeyPEM([]byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key to reuse: %w", err)
+	}
+	csr, err := createCSR(cfg, key)
+	if err != nil {
+		return nil, err
+	}
+
+	certData, err := signer.SignCertificate(cfg, csr)
+	if err != nil {
+		return nil, err
+	}
+	certData.PrivateKey = keyPEM
+	slog.Debug("Renewed certificate with the existing private key", "certificate", cfg.Name)
+	return certData, nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// existingKeyPEM returns the deployed private key as a PEM block of its
+// own, decrypted and converted from DER as needed.
+func existingKeyPEM(cfg *config.CertificateConfig) (string, error) {
+	path := cfg.KeyPath()
+	if cfg.IsCombinedFile() {
+		path = cfg.CertificatePath()
+	}
+	data, err := readPEMFile(cfg, path)
+	if err != nil {
+		return "", err
+	}
+	if cfg.KeyEncryption != nil {
+		passphrase, err := keyPassphrase(cfg.KeyEncryption)
+		if err != nil {
+			return "", err
+		}
+		if data, err = decryptKeyPEM(data, passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return "", fmt.Errorf("no private key found in %s", path)
+		}
+		if privateKeyType(block.Bytes) != "" || strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			return string(pem.EncodeToMemory(block)), nil
+		}
+	}
+}
+
+// createCSR returns a PEM CSR for key with the certificate's subject and
+// SANs, which Vault uses by default when signing.
+func createCSR(cfg *config.CertificateConfig, key crypto.PrivateKey) (string, error) {
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:         cfg.CommonName,
+			OrganizationalUnit: cfg.OU,
+			Organization:       cfg.Organization,
+			Country:            cfg.Country,
+			Locality:           cfg.Locality,
+			PostalCode:         cfg.PostalCode,
+		},
+	}
+	for _, name := range cfg.AltNames {
+		if strings.Contains(name, "@") {
+			template.EmailAddresses = append(template.EmailAddresses, name)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+	for _, ip := range cfg.IPSans {
+		if parsed := net.ParseIP(ip); parsed != nil {
+			template.IPAddresses = append(template.IPAddresses, parsed)
+		}
+	}
+	for _, uri := range cfg.URISans {
+		if parsed, err := url.Parse(uri); err == nil {
+			template.URIs = append(template.URIs, parsed)
+		}
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to create CSR: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
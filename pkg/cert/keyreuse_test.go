@@ -0,0 +1,115 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Private Key Reuse Tests
+//
+// Unit tests for renewing certificates with a CSR for the key on disk.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"cert-manager/pkg/vaulttest"
+	"crypto"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_ReusePrivateKey verifies the first issuance gets a key from
+// Vault, renewals keep that key with a new certificate, and removing the
+// key file gets a fresh one.
+func TestManager_ReusePrivateKey(t *testing.T) {
+	fake, err := vaulttest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start fake vault: %v", err)
+	}
+	defer fake.Close()
+
+	client, err := vault.NewClient(&config.VaultConfig{
+		Address: fake.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	tmpDir := t.TempDir()
+	manager := NewManager(client)
+	certConfig := &config.CertificateConfig{
+		Name:            "pinned",
+		Role:            "test-role",
+		CommonName:      "pinned.example.com",
+		AltNames:        []string{"www.example.com"},
+		Certificate:     filepath.Join(tmpDir, "pinned.crt"),
+		Key:             filepath.Join(tmpDir, "pinned.key"),
+		TTL:             24 * time.Hour,
+		ReusePrivateKey: true,
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	readFiles := func() (string, string) {
+		cert, err := os.ReadFile(certConfig.Certificate)
+		if err != nil {
+			t.Fatalf("failed to read certificate: %v", err)
+		}
+		key, err := os.ReadFile(certConfig.Key)
+		if err != nil {
+			t.Fatalf("failed to read key: %v", err)
+		}
+		return string(cert), string(key)
+	}
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	firstCert, firstKey := readFiles()
+
+	if err := manager.ForceRotate("pinned"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	renewedCert, renewedKey := readFiles()
+	if renewedCert == firstCert {
+		t.Error("expected a new certificate on renewal")
+	}
+	if renewedKey != firstKey {
+		t.Error("expected the private key to be reused on renewal")
+	}
+
+	leaf := parseCertificateBlocks([]byte(renewedCert))[0]
+	if leaf.Subject.CommonName != "pinned.example.com" {
+		t.Errorf("unexpected common name %s", leaf.Subject.CommonName)
+	}
+	key, err := parsePrivateKeyPEM([]byte(renewedKey))
+	if err != nil {
+		t.Fatalf("failed to parse key: %v", err)
+	}
+	if !key.(crypto.Signer).Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(leaf.PublicKey) {
+		t.Error("expected the renewed certificate to be for the reused key")
+	}
+
+	if err := os.Remove(certConfig.Key); err != nil {
+		t.Fatalf("failed to remove key: %v", err)
+	}
+	if err := manager.ForceRotate("pinned"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, freshKey := readFiles(); freshKey == firstKey {
+		t.Error("expected a fresh key once the key file was removed")
+	}
+	if fake.Issued() != 3 {
+		t.Errorf("expected 3 issuances, got %d", fake.Issued())
+	}
+}
@@ -0,0 +1,237 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Java KeyStore Output
+//
+// Writes a certificate's key and chain as a JKS keystore, and its CA chain
+// as a JKS truststore, for JVM services that cannot read PEM. Keystores are
+// rewritten with every deployment and recreated from the PEM files on disk
+// when missing, running on_change so the service picks them up.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/keystore"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// writeKeystore writes the keystore, and the truststore if configured, from
+// PEM material. certPEM starts with the leaf certificate; issuers from
+// certPEM and chainPEM form the chain, with duplicates dropped.
+func (m *Manager) writeKeystore(managed *ManagedCertificate, certPEM, chainPEM, keyPEM []byte) error {
+	cfg := managed.Config
+
+	certs := parseCertificateBlocks(certPEM)
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate found for keystore")
+	}
+	leaf := certs[0]
+	chain := dedupeCertificates(leaf, append(certs[1:], parseCertificateBlocks(chainPEM)...))
+
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return err
+	}
+
+	password, err := keystorePassword(cfg.Keystore)
+	if err != nil {
+		return err
+	}
+
+	now := m.clock.Now()
+	ks := &keystore.KeyStore{}
+	if err := ks.AddPrivateKey(cfg.Keystore.Alias, key, append([]*x509.Certificate{leaf}, chain...), now); err != nil {
+		return err
+	}
+	data, err := ks.Marshal(password)
+	if err != nil {
+		return err
+	}
+	if err := m.writeDestination(managed, "keystore", cfg.KeystorePath(), string(data), cfg.KeyFileMode(), cfg.Owner, cfg.Group); err != nil {
+		return fmt.Errorf("failed to write keystore: %w", err)
+	}
+
+	if cfg.Keystore.Truststore == "" {
+		return nil
+	}
+	if len(chain) == 0 {
+		slog.Warn("No CA chain available, not writing truststore",
+			"certificate", cfg.Name,
+			"truststore", cfg.TruststorePath())
+		return nil
+	}
+
+	ts := &keystore.KeyStore{}
+	for i, ca := range chain {
+		if err := ts.AddTrustedCert(fmt.Sprintf("ca-%d", i), ca, now); err != nil {
+			return err
+		}
+	}
+	if data, err = ts.Marshal(password); err != nil {
+		return err
+	}
+	if err := m.writeDestination(managed, "truststore", cfg.TruststorePath(), string(data), cfg.CertFileMode(), cfg.Owner, cfg.Group); err != nil {
+		return fmt.Errorf("failed to write truststore: %w", err)
+	}
+	return nil
+}
+
+// ensureKeystore recreates a missing keystore or truststore from the PEM
+// files on disk and runs on_change, e.g. after keystore output is enabled
+// for a certificate that is not yet due for renewal.
+func (m *Manager) ensureKeystore(managed *ManagedCertificate) {
+	cfg := managed.Config
+	if cfg.Keystore == nil || !m.certificateExists(managed) || !keystoreMissing(cfg) {
+		return
+	}
+
+	err := m.rebuildKeystore(managed)
+	if err != nil {
+		slog.Error("Failed to write keystore",
+			"certificate", cfg.Name,
+			"error", err)
+		return
+	}
+	slog.Info("Wrote missing keystore from certificate on disk",
+		"certificate", cfg.Name,
+		"keystore", cfg.KeystorePath())
+
+	if cfg.HasOnChange() && !cfg.Shadow {
+		if err := m.runOnChangeScript(managed); err != nil {
+			slog.Warn("Failed to run on_change script",
+				"certificate", cfg.Name,
+				"error", err)
+		}
+	}
+}
+
+// rebuildKeystore writes the keystore from the deployed certificate files.
+func (m *Manager) rebuildKeystore(managed *ManagedCertificate) error {
+	cfg := managed.Config
+
+	certPEM, err := readPEMFile(cfg, cfg.CertificatePath())
+	if err != nil {
+		return fmt.Errorf("failed to read certificate file: %w", err)
+	}
+	keyPEM := certPEM
+	if !cfg.IsCombinedFile() {
+		if keyPEM, err = readPEMFile(cfg, cfg.KeyPath()); err != nil {
+			return fmt.Errorf("failed to read private key file: %w", err)
+		}
+	}
+	if cfg.KeyEncryption != nil {
+		passphrase, err := keyPassphrase(cfg.KeyEncryption)
+		if err != nil {
+			return err
+		}
+		if keyPEM, err = decryptKeyPEM(keyPEM, passphrase); err != nil {
+			return err
+		}
+	}
+	var chainPEM []byte
+	if cfg.CAFile != "" {
+		// ca_file is not written when Vault returns no chain.
+		chainPEM, _ = readPEMFile(cfg, cfg.CAFilePath())
+	}
+
+	return m.writeKeystore(managed, certPEM, chainPEM, keyPEM)
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// keystoreMissing reports whether a configured keystore or truststore is
+// not on disk.
+func keystoreMissing(cfg *config.CertificateConfig) bool {
+	if !fileExists(cfg.KeystorePath()) {
+		return true
+	}
+	return cfg.Keystore.Truststore != "" && !fileExists(cfg.TruststorePath())
+}
+
+// keystorePassword returns the configured store password.
+func keystorePassword(cfg *config.KeystoreConfig) (string, error) {
+	if cfg.PasswordFile == "" {
+		return cfg.Password, nil
+	}
+	data, err := os.ReadFile(cfg.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read keystore password file: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// parseCertificateBlocks parses every certificate in PEM data, skipping
+// other blocks.
+func parseCertificateBlocks(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// dedupeCertificates drops certificates equal to leaf or repeated in chain.
+func dedupeCertificates(leaf *x509.Certificate, chain []*x509.Certificate) []*x509.Certificate {
+	seen := [][]byte{leaf.Raw}
+	var out []*x509.Certificate
+	for _, cert := range chain {
+		duplicate := false
+		for _, raw := range seen {
+			if bytes.Equal(raw, cert.Raw) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			seen = append(seen, cert.Raw)
+			out = append(out, cert)
+		}
+	}
+	return out
+}
+
+// parsePrivateKeyPEM parses the first PKCS#1, SEC 1, or PKCS#8 private key
+// in PEM data.
+func parsePrivateKeyPEM(data []byte) (crypto.PrivateKey, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no private key found")
+		}
+		switch block.Type {
+		case "RSA PRIVATE KEY":
+			return x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			return x509.ParseECPrivateKey(block.Bytes)
+		case "PRIVATE KEY":
+			return x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Java KeyStore Output Tests
+//
+// Unit tests for writing keystores on issuance and recreating missing
+// keystores from the certificate files on disk.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_IssueCertificate_Keystore verifies issuance writes the
+// keystore and a truststore holding the CA chain.
+func TestManager_IssueCertificate_Keystore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		Keystore: &config.KeystoreConfig{
+			Path:       filepath.Join(tmpDir, "java", "web.jks"),
+			Alias:      "web",
+			Password:   "changeit",
+			Truststore: filepath.Join(tmpDir, "java", "trust.jks"),
+		},
+	}
+
+	certData := vault.CreateTestCertificateData()
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(certData, nil)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ForceRotate("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for path, mode := range map[string]os.FileMode{
+		certConfig.Keystore.Path:       0600,
+		certConfig.Keystore.Truststore: 0644,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", path, err)
+		}
+		if info.Mode().Perm() != mode {
+			t.Errorf("expected %s mode %v, got %v", path, mode, info.Mode().Perm())
+		}
+		data, _ := os.ReadFile(path)
+		if !bytes.HasPrefix(data, []byte{0xFE, 0xED, 0xFE, 0xED}) {
+			t.Errorf("expected %s to be a JKS file", path)
+		}
+	}
+}
+
+// TestManager_EnsureKeystore verifies a missing keystore is recreated from
+// the certificate on disk and on_change runs.
+func TestManager_EnsureKeystore(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(nil)
+
+	passwordFile := filepath.Join(tmpDir, "password")
+	if err := os.WriteFile(passwordFile, []byte("changeit\n"), 0600); err != nil {
+		t.Fatalf("failed to write password file: %v", err)
+	}
+
+	marker := filepath.Join(tmpDir, "changed")
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Certificate: filepath.Join(tmpDir, "web.pem"),
+		Key:         filepath.Join(tmpDir, "web.pem"),
+		OnChange:    "touch " + marker,
+		Keystore: &config.KeystoreConfig{
+			Path:         filepath.Join(tmpDir, "web.jks"),
+			Alias:        "web",
+			PasswordFile: passwordFile,
+		},
+	}
+
+	certData := newSelfSignedCertificateData(t)
+	if err := os.WriteFile(certConfig.Certificate, []byte(certData.Certificate+certData.PrivateKey), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	managed := manager.certificates["web"]
+	manager.ensureKeystore(managed)
+
+	if !fileExists(certConfig.Keystore.Path) {
+		t.Fatal("expected keystore to be written")
+	}
+	if !fileExists(marker) {
+		t.Fatal("expected on_change to run after writing the keystore")
+	}
+
+	// An existing keystore is left alone.
+	_ = os.Remove(marker)
+	manager.ensureKeystore(managed)
+	if fileExists(marker) {
+		t.Error("expected on_change not to run when the keystore exists")
+	}
+}
@@ -14,24 +14,78 @@ package cert
 // -------------------------------------------------------------------------
 
 import (
+	"bytes"
+	"cert-manager/pkg/clock"
 	"cert-manager/pkg/config"
 	"cert-manager/pkg/vault"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"math/rand"
 	"os"
-	"os/exec"
-	"os/user"
 	"path/filepath"
-	"strconv"
-	"syscall"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// maxRotationHistory bounds the number of rotation events kept per certificate.
+const maxRotationHistory = 100
+
+// ttlTolerance absorbs Vault's NotBefore backdating when comparing a
+// certificate's granted lifetime with the requested TTL.
+const ttlTolerance = 5 * time.Minute
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// errUnconfirmed marks rotations the health_check target never served.
+var errUnconfirmed = errors.New("deployment not confirmed")
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// FaultInjector optionally delays or fails operations for chaos testing.
+type FaultInjector interface {
+	Inject(op string) error
+}
+
+// Notifier is told about every issuance outcome, along with the expiry of
+// the certificate currently on disk.
+type Notifier interface {
+	NotifyRotation(certConfig *config.CertificateConfig, event RotationEvent, notAfter time.Time)
+}
+
+// RenewalObserver is told about every issuance outcome and how long the
+// attempt took, from the request to the confirmed deployment.
+type RenewalObserver interface {
+	ObserveRenewal(name string, event RotationEvent, duration time.Duration)
+}
+
+// DeploymentVerifier confirms a certificate's health_check target serves
+// the certificate just deployed, waiting up to timeout for it to reload.
+type DeploymentVerifier interface {
+	VerifyDeployment(managed *ManagedCertificate, timeout time.Duration) error
+}
+
 // -------------------------------------------------------------------------
 // TYPES
 // -------------------------------------------------------------------------
@@ -40,6 +94,32 @@ import (
 type Manager struct {
 	vaultClient  vault.Client
 	certificates map[string]*ManagedCertificate
+	clock        clock.Clock
+	faults       FaultInjector
+	notifier     Notifier
+	observer     RenewalObserver
+	throttle     *RenewalThrottle
+	verifier     DeploymentVerifier
+	tracer       Tracer
+	maxParallel  int
+	lastSummary  ProcessSummary
+	stateFile    *stateFile
+
+	scheduleChanged chan struct{}
+
+	// subscribers are signalled on status changes, see Subscribe.
+	subscribers map[chan struct{}]struct{}
+	subsMu      sync.Mutex
+
+	// opMu serializes lifecycle operations (processing and forced rotation).
+	// mu guards the certificate map and ManagedCertificate state so readers
+	// can take consistent snapshots while operations are in flight.
+	opMu sync.Mutex
+	mu   sync.RWMutex
+
+	// snapshot holds immutable copies of the certificates, published by
+	// writers before they release mu, see Snapshot.
+	snapshot atomic.Pointer[[]*ManagedCertificate]
 }
 
 // ManagedCertificate represents a certificate under management.
@@ -50,6 +130,63 @@ type ManagedCertificate struct {
 	Certificate   *x509.Certificate
 	Fingerprint   string
 	RenewalJitter time.Duration
+	History       []RotationEvent
+	Destinations  map[string]DestinationStatus // keyed by destination, e.g. "key" or "output-0"
+	HookFailures  HookFailures
+	Renewals      RenewalCounts
+	LastFailure   time.Time
+	LastError     string
+
+	// PreChangeFailures counts deployments rejected by pre_change.
+	PreChangeFailures int
+
+	// UnconfirmedRotations counts rotations the health_check target never
+	// served within the confirmation window.
+	UnconfirmedRotations int
+
+	// Remediations counts reloads of a health_check target found serving
+	// an old certificate, see RemediateOutOfSync.
+	Remediations RemediationCounts
+
+	// Renewing is set while an issuance attempt is in progress.
+	Renewing bool
+
+	// LastHook is the last on_change attempt, nil until one ran.
+	LastHook *HookRun
+
+	// LastAttempt is when an issuance attempt last finished, and
+	// ConsecutiveFailures the number of attempts failed since the last
+	// success.
+	LastAttempt         time.Time
+	ConsecutiveFailures int
+
+	// VaultReachable is whether the last Vault request for the certificate
+	// reached Vault, nil until one was made and for ACME certificates.
+	VaultReachable *bool
+
+	retryAt        time.Time    // earliest next attempt, see scheduleRetry
+	deployment     string       // fingerprint of the certificate last deployed, see startDeployment
+	staging        bool         // writes go to staged paths, see stageDestination
+	staged         []stagedFile // files awaiting pre_change
+	outOfSyncSince time.Time    // when the target was first seen out of sync, see RemediateOutOfSync
+	span           Span         // issuance attempt in progress, see startRotationSpan
+}
+
+// RotationEvent records the outcome of a single issuance attempt.
+type RotationEvent struct {
+	Time           time.Time `json:"time"`
+	Success        bool      `json:"success"`
+	Error          string    `json:"error,omitempty"`
+	Serial         string    `json:"serial,omitempty"`
+	CorrelationID  string    `json:"correlation_id,omitempty"`
+	VaultRequestID string    `json:"vault_request_id,omitempty"`
+	RolledBack     bool      `json:"rolled_back,omitempty"`
+	Unconfirmed    bool      `json:"unconfirmed,omitempty"` // the target never served the new certificate
+	Reason         string    `json:"reason,omitempty"`      // stage that failed, e.g. "issue" or "write"
+
+	// PreviousNotAfter is the expiry of the certificate being replaced,
+	// zero for a first issuance.
+	PreviousNotAfter time.Time `json:"previous_not_after,omitzero"`
 }
 
 // -------------------------------------------------------------------------
@@ -59,8 +196,10 @@ type ManagedCertificate struct {
 // NewManager creates a new certificate manager with the given Vault client.
 func NewManager(vaultClient vault.Client) *Manager {
 	return &Manager{
-		vaultClient:  vaultClient,
-		certificates: make(map[string]*ManagedCertificate),
+		vaultClient:     vaultClient,
+		certificates:    make(map[string]*ManagedCertificate),
+		clock:           clock.Real{},
+		scheduleChanged: make(chan struct{}, 1),
 	}
 }
 
@@ -70,72 +209,170 @@ func NewManager(vaultClient vault.Client) *Manager {
 
 // AddCertificate registers a certificate configuration for management.
 func (m *Manager) AddCertificate(certConfig *config.CertificateConfig) error {
+	m.mu.Lock()
+	defer m.unlock()
+
 	if _, exists := m.certificates[certConfig.Name]; exists {
 		return fmt.Errorf("certificate %s already exists", certConfig.Name)
 	}
 
-	managed := &ManagedCertificate{
-		Config: certConfig,
+	m.certificates[certConfig.Name] = m.newManagedCertificate(certConfig)
+	m.notifyScheduleChanged()
+	return nil
+}
+
+// SyncCertificates makes the managed set match desired: new certificates
+// are added, changed definitions replace the old ones while keeping renewal
+// state, and certificates no longer listed are dropped. Files of dropped
+// certificates are left on disk. It returns the affected names.
+func (m *Manager) SyncCertificates(desired []*config.CertificateConfig) (added, updated, removed []string) {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := make(map[string]*config.CertificateConfig, len(desired))
+	for _, certConfig := range desired {
+		wanted[certConfig.Name] = certConfig
 	}
 
-	jitter := time.Duration(rand.Int63n(int64(time.Hour)))
-	managed.RenewalJitter = jitter
+	for name := range m.certificates {
+		if _, ok := wanted[name]; !ok {
+			delete(m.certificates, name)
+			removed = append(removed, name)
+		}
+	}
 
-	if err := m.loadExistingCertificate(managed); err != nil {
-		slog.Debug("No existing certificate found, will issue new one",
-			"certificate", certConfig.Name,
-			"error", err)
+	for name, certConfig := range wanted {
+		managed, exists := m.certificates[name]
+		if !exists {
+			m.certificates[name] = m.newManagedCertificate(certConfig)
+			added = append(added, name)
+			continue
+		}
+
+		if m.replaceConfig(managed, certConfig) {
+			updated = append(updated, name)
+		}
+	}
+
+	if len(added) > 0 || len(updated) > 0 {
+		m.notifyScheduleChanged()
 	}
+	if len(added) > 0 || len(updated) > 0 || len(removed) > 0 {
+		m.notifyChange()
+	}
+
+	sort.Strings(added)
+	sort.Strings(updated)
+	sort.Strings(removed)
+	return added, updated, removed
+}
 
-	m.certificates[certConfig.Name] = managed
+// UpdateCertificate replaces the definition of a managed certificate,
+// keeping its renewal state. The certificate on disk is reloaded if its
+// path changed.
+func (m *Manager) UpdateCertificate(certConfig *config.CertificateConfig) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.Lock()
+	defer m.unlock()
+
+	managed, exists := m.certificates[certConfig.Name]
+	if !exists {
+		return fmt.Errorf("certificate %s not found", certConfig.Name)
+	}
+	if m.replaceConfig(managed, certConfig) {
+		m.notifyScheduleChanged()
+	}
 	return nil
 }
 
-// ProcessCertificates checks all certificates and renews or issues as needed.
-func (m *Manager) ProcessCertificates() error {
-	for name, managed := range m.certificates {
-		if m.needsRenewal(managed) {
-			slog.Info("Certificate needs renewal", "certificate", name)
-			if err := m.renewCertificate(managed); err != nil {
-				slog.Error("Failed to renew certificate",
-					"certificate", name,
-					"error", err)
-				continue
-			}
-		}
+// RemoveCertificate stops managing a certificate, signalling subscribers.
+// Its files are left on disk.
+func (m *Manager) RemoveCertificate(name string) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
 
-		if !m.certificateExists(managed) {
-			slog.Info("Certificate does not exist on disk, issuing new certificate",
-				"certificate", name)
-			if err := m.issueCertificate(managed); err != nil {
-				slog.Error("Failed to issue certificate",
-					"certificate", name,
-					"error", err)
-				continue
-			}
-		}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.certificates[name]; !exists {
+		return fmt.Errorf("certificate %s not found", name)
 	}
+	delete(m.certificates, name)
+	m.notifyChange()
 	return nil
 }
 
-// ForceRotateAll forces immediate renewal of all managed certificates.
+// ProcessCertificates checks all certificates and renews or issues as
+// needed, up to the configured number at once. Failures are logged per
+// certificate and summed in LastSummary; they do not stop the pass.
+func (m *Manager) ProcessCertificates() error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	degraded := m.throttle != nil && m.throttle.Observe()
+
+	summary := m.runParallel(m.renewalOrder(), func(managed *ManagedCertificate) (processOutcome, error) {
+		outcome, err := m.processCertificate(managed, degraded)
+		m.scheduleRetry(managed, outcome, err)
+		return outcome, err
+	})
+	logSummary("Processed certificates", summary)
+
+	m.mu.Lock()
+	m.lastSummary = summary
+	m.unlock()
+	return nil
+}
+
+// ForceRotateAll forces immediate renewal of all managed certificates. The
+// results are summed in LastSummary, and an error joining each failed
+// certificate's is returned when any rotation fails.
 func (m *Manager) ForceRotateAll() error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
 	slog.Info("Force rotating all certificates")
-	for name, managed := range m.certificates {
+	summary := m.runParallel(m.managedList(), func(managed *ManagedCertificate) (processOutcome, error) {
+		name := managed.Config.Name
 		slog.Info("Force rotating certificate", "certificate", name)
 		if err := m.issueCertificate(managed); err != nil {
 			slog.Error("Failed to rotate certificate",
 				"certificate", name,
 				"error", err)
-			continue
+			return outcomeUnchanged, err
 		}
+		return outcomeRenewed, nil
+	})
+	logSummary("Force rotated certificates", summary)
+
+	m.mu.Lock()
+	m.lastSummary = summary
+	m.unlock()
+
+	if summary.Failed == 0 {
+		return nil
 	}
-	return nil
+	errs := make([]error, 0, summary.Failed)
+	for _, name := range slices.Sorted(maps.Keys(summary.Errors)) {
+		errs = append(errs, fmt.Errorf("%s: %w", name, summary.Errors[name]))
+	}
+	return fmt.Errorf("%d of %d certificates failed to rotate: %w",
+		summary.Failed, summary.Certificates, errors.Join(errs...))
 }
 
 // ForceRotate forces immediate renewal of a specific certificate.
 func (m *Manager) ForceRotate(name string) error {
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	m.mu.RLock()
 	managed, exists := m.certificates[name]
+	m.mu.RUnlock()
 	if !exists {
 		return fmt.Errorf("certificate %s not found", name)
 	}
@@ -144,31 +381,398 @@ func (m *Manager) ForceRotate(name string) error {
 	return m.issueCertificate(managed)
 }
 
-// GetManagedCertificates returns all certificates under management.
-func (m *Manager) GetManagedCertificates() map[string]*ManagedCertificate {
-	return m.certificates
+// CheckCAChains compares the issuing CA on disk for each certificate with
+// the PKI mount's current CA chain and reissues certificates whose issuing
+// CA has rotated. Certificates with no chain on disk are skipped. Requires
+// a Vault client implementing vault.CAChainFetcher.
+func (m *Manager) CheckCAChains() error {
+	fetcher, ok := m.vaultClient.(vault.CAChainFetcher)
+	if !ok {
+		return fmt.Errorf("vault client cannot read CA chains")
+	}
+
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	for _, managed := range m.managedList() {
+		name := managed.Config.Name
+		if managed.Certificate == nil || managed.Config.Refreshes() || managed.Config.IsACMESource() || !managed.Config.AutoRenews() {
+			continue
+		}
+
+		onDisk, err := m.issuingCAOnDisk(managed)
+		if err != nil {
+			slog.Warn("Failed to read CA chain on disk", "certificate", name, "error", err)
+			continue
+		}
+		if onDisk == nil {
+			continue
+		}
+
+		chain, err := fetcher.FetchCAChain(managed.Config)
+		if err != nil {
+			slog.Error("Failed to fetch CA chain from Vault", "certificate", name, "error", err)
+			continue
+		}
+		current := firstCertificateDER([]byte(chain))
+		if current == nil {
+			slog.Error("Vault returned an unparseable CA chain", "certificate", name)
+			continue
+		}
+
+		if bytes.Equal(onDisk, current) {
+			continue
+		}
+
+		slog.Info("Issuing CA has rotated, reissuing certificate", "certificate", name)
+		if err := m.issueCertificate(managed); err != nil {
+			slog.Error("Failed to reissue certificate after CA rotation",
+				"certificate", name,
+				"error", err)
+			continue
+		}
+	}
+	return nil
+}
+
+// SetNotifier enables rotation outcome notifications.
+func (m *Manager) SetNotifier(notifier Notifier) {
+	m.notifier = notifier
+}
+
+// SetRenewalObserver reports every issuance outcome and its duration to
+// observer, e.g. for metrics.
+func (m *Manager) SetRenewalObserver(observer RenewalObserver) {
+	m.observer = observer
+}
+
+// SetRenewalThrottle defers non-urgent renewals while the throttle reports
+// Vault as degraded.
+func (m *Manager) SetRenewalThrottle(throttle *RenewalThrottle) {
+	m.throttle = throttle
+}
+
+// RenewalThrottle returns the configured renewal throttle, or nil.
+func (m *Manager) RenewalThrottle() *RenewalThrottle {
+	return m.throttle
+}
+
+// SetDeploymentVerifier checks health_check targets after deployments of
+// certificates with backup enabled, rolling back when the check fails.
+func (m *Manager) SetDeploymentVerifier(verifier DeploymentVerifier) {
+	m.verifier = verifier
+}
+
+// SetClock replaces the time source used for renewal scheduling.
+func (m *Manager) SetClock(c clock.Clock) {
+	m.clock = c
+}
+
+// SetFaultInjector enables fault injection for certificate writes.
+func (m *Manager) SetFaultInjector(faults FaultInjector) {
+	m.faults = faults
+}
+
+// Snapshot returns point-in-time copies of all managed certificates,
+// sorted by name. It does not lock, so metrics and the dashboard never wait
+// on processing; the copies are shared between callers and must not be
+// modified.
+func (m *Manager) Snapshot() []*ManagedCertificate {
+	snapshot := m.snapshot.Load()
+	if snapshot == nil {
+		return nil
+	}
+	return slices.Clone(*snapshot)
+}
+
+// ChainOnDisk returns the CA certificates last written for the named
+// certificate, issuing CA first: ca_file if set, otherwise the blocks
+// after the leaf in the certificate file. It returns nil when no chain is
+// on disk.
+func (m *Manager) ChainOnDisk(name string) ([]*x509.Certificate, error) {
+	m.mu.RLock()
+	managed, ok := m.certificates[name]
+	var certConfig *config.CertificateConfig
+	if ok {
+		certConfig = managed.Config
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("certificate %s not found", name)
+	}
+
+	path, skipLeaf := certConfig.CAFilePath(), false
+	if path == "" {
+		if certConfig.ExcludeChain {
+			return nil, nil
+		}
+		path, skipLeaf = certConfig.CertificatePath(), true
+	}
+
+	data, err := readPEMFile(certConfig, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return chain, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if skipLeaf {
+			skipLeaf = false
+			continue
+		}
+		ca, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chain certificate in %s: %w", path, err)
+		}
+		chain = append(chain, ca)
+	}
 }
 
 // -------------------------------------------------------------------------
 // PRIVATE METHODS
 // -------------------------------------------------------------------------
 
+// newManagedCertificate creates management state for a certificate and
+// loads any existing certificate from disk.
+func (m *Manager) newManagedCertificate(certConfig *config.CertificateConfig) *ManagedCertificate {
+	managed := &ManagedCertificate{
+		Config:        certConfig,
+		RenewalJitter: time.Duration(rand.Int63n(int64(MaxRenewalJitter))),
+	}
+
+	if err := m.loadExistingCertificate(managed); err != nil {
+		slog.Debug("No existing certificate found, will issue new one",
+			"certificate", certConfig.Name,
+			"error", err)
+	}
+	m.restoreState(managed)
+
+	return managed
+}
+
+// replaceConfig gives managed a new definition, reloading the certificate
+// from disk if its path changed. It reports whether the definition
+// differed. Callers hold m.mu.
+func (m *Manager) replaceConfig(managed *ManagedCertificate, certConfig *config.CertificateConfig) bool {
+	if reflect.DeepEqual(managed.Config, certConfig) {
+		return false
+	}
+
+	pathChanged := managed.Config.CertificatePath() != certConfig.CertificatePath()
+	managed.Config = certConfig
+	if pathChanged {
+		managed.Certificate = nil
+		managed.Fingerprint = ""
+		if err := m.loadExistingCertificate(managed); err != nil {
+			slog.Debug("No existing certificate at new path, will issue new one",
+				"certificate", certConfig.Name,
+				"error", err)
+		}
+	}
+	return true
+}
+
+// publish stores copies of every managed certificate for Snapshot.
+// Callers hold m.mu.
+func (m *Manager) publish() {
+	snapshot := make([]*ManagedCertificate, 0, len(m.certificates))
+	for _, managed := range m.certificates {
+		c := *managed
+		c.History = slices.Clone(managed.History)
+		c.Destinations = maps.Clone(managed.Destinations)
+		c.staged = nil
+		c.span = nil
+		snapshot = append(snapshot, &c)
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		return snapshot[i].Config.Name < snapshot[j].Config.Name
+	})
+	m.snapshot.Store(&snapshot)
+}
+
+// unlock publishes the certificates and releases m.mu. Writers use it in
+// place of m.mu.Unlock so Snapshot sees every change.
+func (m *Manager) unlock() {
+	m.publish()
+	m.mu.Unlock()
+}
+
+// managedList returns the live managed certificates sorted by name.
+func (m *Manager) managedList() []*ManagedCertificate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]*ManagedCertificate, 0, len(m.certificates))
+	for _, managed := range m.certificates {
+		list = append(list, managed)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Config.Name < list[j].Config.Name
+	})
+	return list
+}
+
+// processCertificate renews, issues, or refreshes one certificate as
+// needed during a processing pass.
+func (m *Manager) processCertificate(managed *ManagedCertificate, degraded bool) (processOutcome, error) {
+	name := managed.Config.Name
+	outcome := outcomeUnchanged
+
+	missing := !m.certificateExists(managed) || outputsMissing(managed.Config)
+	if !missing && managed.Certificate == nil {
+		// The files may have been fixed or provisioned since they were
+		// last read.
+		m.mu.Lock()
+		_ = m.loadExistingCertificate(managed)
+		m.unlock()
+	}
+	if (missing || managed.Certificate == nil) && !managed.Config.IssuesMissing() {
+		err := fmt.Errorf("certificate files are missing or unreadable and policy %s does not create them", managed.Config.Policy)
+		slog.Error("Not issuing certificate",
+			"certificate", name,
+			"error", err)
+		return outcome, err
+	}
+
+	if managed.Config.IsCABundleSource() {
+		if missing || (managed.Config.AutoRenews() && m.clock.Now().After(managed.NextRenewal)) {
+			changed, err := m.refreshCABundle(managed)
+			if err != nil {
+				slog.Error("Failed to sync CA bundle from Vault",
+					"certificate", name,
+					"error", err)
+				return outcome, err
+			}
+			if changed {
+				outcome = outcomeRenewed
+			}
+		}
+		return outcome, nil
+	}
+
+	if managed.Config.IsKVSource() {
+		if missing || (managed.Config.AutoRenews() && m.clock.Now().After(managed.NextRenewal)) {
+			fingerprint := m.fingerprint(managed)
+			if err := m.refreshKVCertificate(managed); err != nil {
+				slog.Error("Failed to deploy certificate from Vault KV",
+					"certificate", name,
+					"error", err)
+				return outcome, err
+			}
+			if m.fingerprint(managed) != fingerprint {
+				outcome = outcomeRenewed
+			}
+		}
+		m.ensureKeystore(managed)
+		return outcome, nil
+	}
+
+	if m.needsRenewal(managed) {
+		if degraded && !m.isUrgent(managed) {
+			m.throttle.recordDeferred()
+			slog.Info("Deferring renewal while Vault is degraded",
+				"certificate", name,
+				"not_after", managed.Certificate.NotAfter)
+			return outcomeDeferred, nil
+		}
+
+		if m.isExpired(managed) {
+			slog.Warn("Certificate on disk has expired, reissuing",
+				"certificate", name,
+				"not_after", managed.Certificate.NotAfter)
+		} else {
+			slog.Log(context.Background(), renewalLogLevel(managed), "Certificate needs renewal", "certificate", name)
+		}
+		if err := m.renewCertificate(managed); err != nil {
+			slog.Error("Failed to renew certificate",
+				"certificate", name,
+				"error", err)
+			return outcome, err
+		}
+		outcome = outcomeRenewed
+	}
+
+	switch {
+	case !m.certificateExists(managed) || outputsMissing(managed.Config):
+		slog.Info("Certificate does not exist on disk, issuing new certificate",
+			"certificate", name)
+	case managed.Certificate == nil:
+		slog.Warn("Certificate on disk cannot be parsed, reissuing",
+			"certificate", name,
+			"path", managed.Config.CertificatePath())
+	default:
+		m.ensureKeystore(managed)
+		return outcome, nil
+	}
+	if err := m.issueCertificate(managed); err != nil {
+		slog.Error("Failed to issue certificate",
+			"certificate", name,
+			"error", err)
+		return outcome, err
+	}
+	outcome = outcomeRenewed
+
+	m.ensureKeystore(managed)
+	return outcome, nil
+}
+
+// renewalOrder returns the managed certificates soonest to expire first, so
+// the most urgent renewals reach Vault first. Certificates not yet loaded
+// come before all others.
+func (m *Manager) renewalOrder() []*ManagedCertificate {
+	list := m.managedList()
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i].Certificate, list[j].Certificate
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.NotAfter.Before(b.NotAfter)
+	})
+	return list
+}
+
+// isUrgent reports whether a certificate is past the midpoint of its
+// renewal window, by default a sixth of its lifetime before expiry.
+func (m *Manager) isUrgent(managed *ManagedCertificate) bool {
+	if managed.Certificate == nil {
+		return true
+	}
+	return managed.Certificate.NotAfter.Sub(m.clock.Now()) < renewalWindow(managed)/2
+}
+
+// isExpired reports whether the certificate on disk is past its expiry.
+func (m *Manager) isExpired(managed *ManagedCertificate) bool {
+	return managed.Certificate != nil && !m.clock.Now().Before(managed.Certificate.NotAfter)
+}
+
 // needsRenewal checks if a certificate should be renewed based on expiration.
 func (m *Manager) needsRenewal(managed *ManagedCertificate) bool {
-	if managed.Certificate == nil {
+	if managed.Certificate == nil || !managed.Config.AutoRenews() {
 		return false
 	}
 
-	renewalThreshold := managed.Certificate.NotAfter.Add(-managed.Config.TTL/3 - managed.RenewalJitter)
-	return time.Now().After(renewalThreshold)
+	return m.clock.Now().After(renewalThreshold(managed))
 }
 
 // certificateExists checks if certificate files exist on disk.
 func (m *Manager) certificateExists(managed *ManagedCertificate) bool {
-	certExists := fileExists(managed.Config.Certificate)
-	keyExists := fileExists(managed.Config.Key)
+	certExists := fileExists(managed.Config.CertificatePath())
+	keyExists := fileExists(managed.Config.KeyPath())
 
-	if managed.Config.IsCombinedFile() {
+	if managed.Config.IsCombinedFile() || managed.Config.IsCABundleSource() {
 		return certExists
 	}
 
@@ -180,80 +784,391 @@ func (m *Manager) renewCertificate(managed *ManagedCertificate) error {
 	return m.issueCertificate(managed)
 }
 
-// issueCertificate requests a new certificate from Vault and writes it to disk.
-func (m *Manager) issueCertificate(managed *ManagedCertificate) error {
-	certData, err := m.vaultClient.IssueCertificate(managed.Config)
+// issueCertificate requests a new certificate from Vault and writes it to
+// disk. With reuse_private_key, the existing key is kept.
+func (m *Manager) issueCertificate(managed *ManagedCertificate) (err error) {
+	var certData *vault.CertificateData
+	started, previous := time.Now(), m.notAfter(managed)
+	endSpan := m.startRotationSpan(managed, "certificate.rotate")
+	endRenewal := m.startRenewal(managed)
+	defer func() {
+		m.recordRotation(managed, certData, started, previous, err)
+		endSpan(err)
+		endRenewal()
+	}()
+
+	span := m.startSpan(managed, "vault.issue")
+	certData, err = m.requestCertificate(managed)
+	span.End(err)
+	m.recordVaultContact(managed, err)
 	if err != nil {
-		return fmt.Errorf("failed to issue certificate from vault: %w", err)
+		return failedIn(reasonIssue, fmt.Errorf("failed to issue certificate from vault: %w", err))
 	}
 
-	if err := m.writeCertificateToDisk(managed, certData); err != nil {
-		return fmt.Errorf("failed to write certificate to disk: %w", err)
+	return m.deployCertificate(managed, certData)
+}
+
+// refreshKVCertificate re-reads a pre-issued certificate from Vault KV and
+// deploys it only when it differs from the certificate on disk.
+func (m *Manager) refreshKVCertificate(managed *ManagedCertificate) (err error) {
+	var certData *vault.CertificateData
+	changed := true
+	started, previous := time.Now(), m.notAfter(managed)
+	endSpan := m.startRotationSpan(managed, "certificate.refresh")
+	endRenewal := m.startRenewal(managed)
+	defer func() {
+		if changed {
+			m.recordRotation(managed, certData, started, previous, err)
+		}
+		endSpan(err)
+		endRenewal()
+	}()
+
+	span := m.startSpan(managed, "vault.issue")
+	certData, err = m.vaultClient.IssueCertificate(managed.Config)
+	span.End(err)
+	m.recordVaultContact(managed, err)
+	if err != nil {
+		return failedIn(reasonIssue, fmt.Errorf("failed to read certificate from vault kv: %w", err))
 	}
 
-	if err := m.loadExistingCertificate(managed); err != nil {
-		return fmt.Errorf("failed to load newly issued certificate: %w", err)
+	if m.certificateExists(managed) && !outputsMissing(managed.Config) && m.calculateFingerprint([]byte(certData.Certificate)) == managed.Fingerprint {
+		changed = false
+		m.mu.Lock()
+		managed.NextRenewal = m.clock.Now().Add(managed.Config.KV.RefreshInterval)
+		m.unlock()
+		return nil
 	}
 
-	managed.LastRenewed = time.Now()
-	managed.NextRenewal = managed.Certificate.NotAfter.Add(-managed.Config.TTL/3 - managed.RenewalJitter)
+	slog.Info("Certificate in Vault KV differs from disk, deploying",
+		"certificate", managed.Config.Name,
+		"serial", certData.SerialNumber)
+	return m.deployCertificate(managed, certData)
+}
+
+// deployCertificate validates certificate material, writes it to disk,
+// reloads it, and runs the on_change script. With pre_change set, the files
+// are staged and only moved into place once pre_change accepts them. With
+// versioned set, a new version is archived and the live symlinks point at
+// it before on_change runs. The rotation then fails unless the health
+// check confirms the new certificate is served. With backup enabled, the
+// previous files are restored if the new ones cannot be written, on_change
+// fails, or the rotation is not confirmed. Shadow certificates stop once
+// their shadow files are written.
+func (m *Manager) deployCertificate(managed *ManagedCertificate, certData *vault.CertificateData) error {
+	validate := m.validateIssued
+	if managed.Config.IsCABundleSource() {
+		validate = validateCABundle
+	}
+	if err := validate(certData); err != nil {
+		return failedIn(reasonValidation, fmt.Errorf("issued certificate failed validation: %w", err))
+	}
+
+	var saved []backupFile
+	if managed.Config.Backup != nil && m.certificateExists(managed) {
+		var err error
+		if saved, err = m.backupFiles(managed); err != nil {
+			return failedIn(reasonWrite, fmt.Errorf("failed to back up certificate files: %w", err))
+		}
+	}
+
+	if stages(managed) {
+		if err := m.writeStaged(managed, certData); err != nil {
+			return err
+		}
+		span := m.startSpan(managed, "disk.commit")
+		err := m.commitStaged(managed)
+		span.End(err)
+		if err != nil {
+			err = failedIn(reasonWrite, fmt.Errorf("failed to write certificate to disk: %w", err))
+			if saved != nil {
+				return m.rollback(managed, saved, err)
+			}
+			return err
+		}
+	} else if err := m.writeCertificateToDisk(managed, certData); err != nil {
+		err = failedIn(reasonWrite, fmt.Errorf("failed to write certificate to disk: %w", err))
+		if saved != nil {
+			return m.rollback(managed, saved, err)
+		}
+		return err
+	}
 
-	if managed.Config.OnChange != "" {
-		if err := m.runOnChangeScript(managed.Config.OnChange); err != nil {
+	m.mu.Lock()
+	err := m.loadExistingCertificate(managed)
+	if err == nil {
+		managed.LastRenewed = m.clock.Now()
+		if managed.Config.Refreshes() {
+			managed.NextRenewal = managed.LastRenewed.Add(managed.Config.RefreshInterval())
+		} else {
+			managed.NextRenewal = renewalThreshold(managed)
+		}
+	}
+	m.unlock()
+	if err != nil {
+		err = failedIn(reasonWrite, fmt.Errorf("failed to load newly issued certificate: %w", err))
+		if saved != nil {
+			return m.rollback(managed, saved, err)
+		}
+		return err
+	}
+
+	warnTTLMismatch(managed)
+	warnRenewalWindow(managed)
+
+	if managed.Config.Shadow {
+		slog.Info("Issued shadow certificate, production files and on_change left untouched",
+			"certificate", managed.Config.Name,
+			"path", managed.Config.CertificatePath(),
+			"serial", certData.SerialNumber,
+			"correlation_id", certData.CorrelationID)
+		return nil
+	}
+
+	if managed.Config.Versioned != nil {
+		span := m.startSpan(managed, "disk.write_version")
+		err := m.writeVersion(managed, certData)
+		span.End(err)
+		if err != nil {
+			err = failedIn(reasonWrite, fmt.Errorf("failed to write certificate version: %w", err))
+			if saved != nil {
+				return m.rollback(managed, saved, err)
+			}
+			return err
+		}
+	}
+
+	if managed.Config.HasOnChange() {
+		if err := m.runOnChangeScript(managed); err != nil {
+			if saved != nil {
+				return m.rollback(managed, saved, failedIn(reasonOnChange, fmt.Errorf("on_change failed: %w", err)))
+			}
 			slog.Warn("Failed to run on_change script",
 				"certificate", managed.Config.Name,
 				"error", err)
 		}
 	}
 
-	slog.Info("Successfully issued/renewed certificate",
-		"certificate", managed.Config.Name)
+	if err := m.confirmDeployment(managed, saved != nil); err != nil {
+		if saved != nil {
+			return m.rollback(managed, saved, err)
+		}
+		return err
+	}
+
+	slog.Log(context.Background(), renewalLogLevel(managed), "Successfully issued/renewed certificate",
+		"certificate", managed.Config.Name,
+		"serial", certData.SerialNumber,
+		"correlation_id", certData.CorrelationID)
 	return nil
 }
 
+// confirmDeployment polls the health_check target until it serves the
+// deployed certificate, for up to confirm_timeout, or backup's
+// verify_timeout when backed up. Rotations it cannot confirm are counted
+// and fail with errUnconfirmed.
+func (m *Manager) confirmDeployment(managed *ManagedCertificate, backedUp bool) error {
+	check := managed.Config.HealthCheck
+	if m.verifier == nil || check == nil {
+		return nil
+	}
+	timeout := check.ConfirmTimeout
+	if timeout == 0 && backedUp {
+		timeout = managed.Config.Backup.VerifyTimeout
+	}
+	if timeout <= 0 {
+		return nil
+	}
+
+	span := m.startSpan(managed, "health.confirm")
+	err := m.verifier.VerifyDeployment(managed, timeout)
+	span.End(err)
+	if err != nil {
+		m.mu.Lock()
+		managed.UnconfirmedRotations++
+		m.unlock()
+		slog.Error("Service did not serve the new certificate after on_change",
+			"certificate", managed.Config.Name,
+			"target", check.Target(),
+			"timeout", timeout,
+			"error", err)
+		return fmt.Errorf("%w: health check failed: %w", errUnconfirmed, err)
+	}
+	return nil
+}
+
+// recordRotation appends an issuance outcome to the certificate's history.
+// started is when the attempt began, and previous the expiry of the
+// certificate the issuance replaced.
+func (m *Manager) recordRotation(managed *ManagedCertificate, certData *vault.CertificateData, started, previous time.Time, err error) {
+	duration := time.Since(started)
+	event := RotationEvent{
+		Time:             m.clock.Now(),
+		Success:          err == nil,
+		PreviousNotAfter: previous,
+	}
+	if err != nil {
+		event.Error = err.Error()
+		event.Reason = failureReason(err)
+	}
+	var reqErr *vault.RequestError
+	switch {
+	case certData != nil:
+		event.Serial = certData.SerialNumber
+		event.CorrelationID = certData.CorrelationID
+		event.VaultRequestID = certData.VaultRequestID
+	case errors.As(err, &reqErr):
+		event.CorrelationID = reqErr.CorrelationID
+		event.VaultRequestID = reqErr.VaultRequestID
+	}
+	event.RolledBack = errors.Is(err, errRolledBack)
+	event.Unconfirmed = errors.Is(err, errUnconfirmed)
+
+	m.mu.Lock()
+	managed.History = append(managed.History, event)
+	if len(managed.History) > maxRotationHistory {
+		managed.History = managed.History[len(managed.History)-maxRotationHistory:]
+	}
+	managed.LastAttempt = event.Time
+	if event.Success {
+		managed.Renewals.Success++
+		managed.ConsecutiveFailures = 0
+	} else {
+		managed.Renewals.Failure++
+		managed.ConsecutiveFailures++
+		managed.LastFailure = event.Time
+		managed.LastError = event.Error
+	}
+	var notAfter time.Time
+	if managed.Certificate != nil {
+		notAfter = managed.Certificate.NotAfter
+	}
+	m.unlock()
+
+	m.saveState()
+
+	if m.notifier != nil {
+		m.notifier.NotifyRotation(managed.Config, event, notAfter)
+	}
+	if m.observer != nil {
+		m.observer.ObserveRenewal(managed.Config.Name, event, duration)
+	}
+}
+
+// recordVaultContact records whether a Vault request for managed, which
+// failed with err if not nil, reached Vault.
+func (m *Manager) recordVaultContact(managed *ManagedCertificate, err error) {
+	if managed.Config.IsACMESource() {
+		return
+	}
+	reachable := !vault.IsUnreachable(err)
+	m.mu.Lock()
+	managed.VaultReachable = &reachable
+	m.unlock()
+}
+
+// fingerprint returns the fingerprint of the certificate currently
+// deployed.
+func (m *Manager) fingerprint(managed *ManagedCertificate) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return managed.Fingerprint
+}
+
+// notAfter returns the expiry of the certificate currently deployed, or
+// zero if none is.
+func (m *Manager) notAfter(managed *ManagedCertificate) time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if managed.Certificate == nil {
+		return time.Time{}
+	}
+	return managed.Certificate.NotAfter
+}
+
 // writeCertificateToDisk writes certificate and key files to the filesystem.
-func (m *Manager) writeCertificateToDisk(managed *ManagedCertificate, certData *vault.CertificateData) error {
+func (m *Manager) writeCertificateToDisk(managed *ManagedCertificate, certData *vault.CertificateData) (err error) {
+	span := m.startSpan(managed, "disk.write")
+	defer func() { span.End(err) }()
+
+	if m.faults != nil {
+		if err := m.faults.Inject("write"); err != nil {
+			return err
+		}
+	}
+
 	if err := m.ensureDirectories(managed); err != nil {
 		return err
 	}
+	m.pruneDestinations(managed)
+	m.startDeployment(managed, m.calculateFingerprint([]byte(certData.Certificate)))
+
+	if managed.Config.IsCABundleSource() {
+		return m.writeCABundle(managed, certData)
+	}
 
-	fullCert := certData.Certificate
-	if certData.CertificateChain != "" {
-		fullCert += "\n" + certData.CertificateChain
+	leaf, privateKey, caChain, err := encodeParts(managed.Config, certData)
+	if err != nil {
+		return err
 	}
+	fullCert := certificateFile(managed.Config, leaf, caChain)
 
 	if managed.Config.IsCombinedFile() {
-		content := fullCert + "\n" + certData.PrivateKey
-		if err := m.writeFileWithPermissions(managed.Config.Certificate, content, 0600, managed.Config.Owner, managed.Config.Group); err != nil {
+		content := fullCert + "\n" + privateKey
+		if err := m.writeDestination(managed, "certificate", managed.Config.CertificatePath(), content, managed.Config.KeyFileMode(), managed.Config.Owner, managed.Config.Group); err != nil {
 			return fmt.Errorf("failed to write combined certificate file: %w", err)
 		}
 	} else {
-		if err := m.writeFileWithPermissions(managed.Config.Certificate, fullCert, 0644, managed.Config.Owner, managed.Config.Group); err != nil {
+		if err := m.writeDestination(managed, "certificate", managed.Config.CertificatePath(), fullCert, managed.Config.CertFileMode(), managed.Config.Owner, managed.Config.Group); err != nil {
 			return fmt.Errorf("failed to write certificate file: %w", err)
 		}
-		if err := m.writeFileWithPermissions(managed.Config.Key, certData.PrivateKey, 0600, managed.Config.Owner, managed.Config.Group); err != nil {
+		if err := m.writeDestination(managed, "key", managed.Config.KeyPath(), privateKey, managed.Config.KeyFileMode(), managed.Config.Owner, managed.Config.Group); err != nil {
 			return fmt.Errorf("failed to write private key file: %w", err)
 		}
 	}
 
+	if managed.Config.CAFile != "" {
+		if certData.CertificateChain == "" {
+			slog.Warn("Vault returned no CA chain, not writing ca_file",
+				"certificate", managed.Config.Name,
+				"ca_file", managed.Config.CAFilePath())
+		} else if err := m.writeDestination(managed, "ca_file", managed.Config.CAFilePath(), caChain, managed.Config.CertFileMode(), managed.Config.Owner, managed.Config.Group); err != nil {
+			return fmt.Errorf("failed to write CA file: %w", err)
+		}
+	}
+
+	for i, output := range managed.Config.Outputs {
+		if output.Type == "chain" && caChain == "" {
+			slog.Warn("Vault returned no CA chain, not writing chain output",
+				"certificate", managed.Config.Name,
+				"path", managed.Config.OutputPath(output))
+			continue
+		}
+		content := encodeOutput(managed.Config, output.Type, leaf, privateKey, caChain)
+		if err := m.writeDestination(managed, fmt.Sprintf("output-%d", i), managed.Config.OutputPath(output), content, output.FileMode(), output.Owner, output.Group); err != nil {
+			return fmt.Errorf("failed to write %s output: %w", output.Type, err)
+		}
+	}
+
+	if managed.Config.Keystore != nil {
+		if err := m.writeKeystore(managed, []byte(certData.Certificate), []byte(certData.CertificateChain), []byte(certData.PrivateKey)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // loadExistingCertificate reads and parses a certificate from disk.
 func (m *Manager) loadExistingCertificate(managed *ManagedCertificate) error {
-	certData, err := os.ReadFile(managed.Config.Certificate)
+	certData, err := readPEMFile(managed.Config, managed.Config.CertificatePath())
 	if err != nil {
 		return fmt.Errorf("failed to read certificate file: %w", err)
 	}
 
-	block, _ := pem.Decode(certData)
-	if block == nil || block.Type != "CERTIFICATE" {
-		return fmt.Errorf("failed to decode PEM certificate")
-	}
-
-	cert, err := x509.ParseCertificate(block.Bytes)
+	cert, err := parseCertificatePEM(certData)
 	if err != nil {
-		return fmt.Errorf("failed to parse certificate: %w", err)
+		return err
 	}
 
 	managed.Certificate = cert
@@ -262,6 +1177,38 @@ func (m *Manager) loadExistingCertificate(managed *ManagedCertificate) error {
 	return nil
 }
 
+// issuingCAOnDisk returns the DER of the issuing CA last written for the
+// certificate: the first block of ca_file if set, otherwise the first chain
+// block in the certificate file. It returns nil when no chain is on disk.
+func (m *Manager) issuingCAOnDisk(managed *ManagedCertificate) ([]byte, error) {
+	if managed.Config.CAFile != "" {
+		data, err := readPEMFile(managed.Config, managed.Config.CAFilePath())
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		return firstCertificateDER(data), nil
+	}
+
+	if managed.Config.ExcludeChain {
+		return nil, nil
+	}
+
+	data, err := readPEMFile(managed.Config, managed.Config.CertificatePath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate file: %w", err)
+	}
+
+	// Skip the leaf; the next certificate block is the issuing CA.
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return nil, nil
+	}
+	return firstCertificateDER(rest), nil
+}
+
 // calculateFingerprint computes a SHA256 fingerprint of the certificate.
 func (m *Manager) calculateFingerprint(certData []byte) string {
 	block, _ := pem.Decode(certData)
@@ -273,27 +1220,35 @@ func (m *Manager) calculateFingerprint(certData []byte) string {
 }
 
 // ensureDirectories creates parent directories for certificate files.
+// Directories it creates get the certificate's dir_mode; existing ones are
+// left as they are.
 func (m *Manager) ensureDirectories(managed *ManagedCertificate) error {
-	certDir := filepath.Dir(managed.Config.Certificate)
-	if err := os.MkdirAll(certDir, 0755); err != nil {
-		return fmt.Errorf("failed to create certificate directory %s: %w", certDir, err)
-	}
-
-	if !managed.Config.IsCombinedFile() {
-		keyDir := filepath.Dir(managed.Config.Key)
-		if err := os.MkdirAll(keyDir, 0755); err != nil {
-			return fmt.Errorf("failed to create key directory %s: %w", keyDir, err)
+	mode := managed.Config.DirFileMode()
+	for _, file := range deployedFiles(managed) {
+		dir := filepath.Dir(file.path)
+		if _, err := os.Stat(dir); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, mode); err != nil {
+			return fmt.Errorf("failed to create %s directory %s: %w", file.role, dir, err)
+		}
+		if err := os.Chmod(dir, mode); err != nil {
+			return fmt.Errorf("failed to set mode on %s directory %s: %w", file.role, dir, err)
 		}
 	}
-
 	return nil
 }
 
-// writeFileWithPermissions writes a file with the specified mode and ownership.
+// writeFileWithPermissions writes a file with the specified mode and
+// ownership. The mode is applied to existing files too, and regardless of
+// the umask.
 func (m *Manager) writeFileWithPermissions(filename, content string, mode os.FileMode, owner, group string) error {
 	if err := os.WriteFile(filename, []byte(content), mode); err != nil {
 		return err
 	}
+	if err := os.Chmod(filename, mode); err != nil {
+		return err
+	}
 
 	if owner != "" || group != "" {
 		if err := m.changeOwnership(filename, owner, group); err != nil {
@@ -306,48 +1261,146 @@ func (m *Manager) writeFileWithPermissions(filename, content string, mode os.Fil
 	return nil
 }
 
-// changeOwnership sets the owner and group of a file.
-func (m *Manager) changeOwnership(filename, owner, group string) error {
-	uid, gid := -1, -1
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
 
-	if owner != "" {
-		if u, err := user.Lookup(owner); err == nil {
-			if uid, err = strconv.Atoi(u.Uid); err != nil {
-				return fmt.Errorf("invalid uid for user %s: %w", owner, err)
-			}
-		} else {
-			return fmt.Errorf("user %s not found: %w", owner, err)
-		}
+// PublicKeyInfo returns the public key algorithm and size of a certificate.
+func PublicKeyInfo(cert *x509.Certificate) (string, int) {
+	if cert == nil {
+		return "", 0
 	}
 
-	if group != "" {
-		if g, err := user.LookupGroup(group); err == nil {
-			if gid, err = strconv.Atoi(g.Gid); err != nil {
-				return fmt.Errorf("invalid gid for group %s: %w", group, err)
-			}
-		} else {
-			return fmt.Errorf("group %s not found: %w", group, err)
-		}
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return "RSA", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		return "ECDSA", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		return "Ed25519", 256
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0
 	}
+}
+
+// DaysLeft returns the whole days until notAfter, as the dashboard shows
+// and managed_cert_days_left exports.
+func DaysLeft(notAfter time.Time) int {
+	return int(time.Until(notAfter).Hours() / 24)
+}
+
+// certificateLifetime returns the lifetime Vault actually granted, which
+// may be shorter than the requested TTL when it exceeds the role's max_ttl.
+// It falls back to the requested TTL if the validity period is unusable.
+func certificateLifetime(managed *ManagedCertificate) time.Duration {
+	lifetime := managed.Certificate.NotAfter.Sub(managed.Certificate.NotBefore)
+	if lifetime <= 0 {
+		return managed.Config.TTL
+	}
+	return lifetime
+}
+
+// renewalWindow returns how long before expiry a certificate is renewed:
+// renew_before, or the part of the lifetime left at renew_at_percent,
+// whichever is longer. It defaults to a third of the lifetime, which is
+// also used when renew_before covers the whole lifetime, e.g. because the
+// role's max_ttl truncated the certificate.
+func renewalWindow(managed *ManagedCertificate) time.Duration {
+	lifetime := certificateLifetime(managed)
+	cfg := managed.Config
+
+	var window time.Duration
+	if cfg.RenewBefore > 0 {
+		window = cfg.RenewBefore
+	}
+	if cfg.RenewAtPercent > 0 {
+		window = max(window, time.Duration(float64(lifetime)*(1-float64(cfg.RenewAtPercent))))
+	}
+	if window <= 0 || window >= lifetime {
+		return lifetime / 3
+	}
+	return window
+}
 
-	return syscall.Chown(filename, uid, gid)
+// renewalThreshold returns when a certificate becomes due for renewal: the
+// renewal window before expiry, less jitter. Jitter scales with the window
+// so short-lived certificates are not renewed on every pass.
+func renewalThreshold(managed *ManagedCertificate) time.Time {
+	window := renewalWindow(managed)
+	return managed.Certificate.NotAfter.Add(-window - renewalJitter(managed, window))
 }
 
-// runOnChangeScript executes the configured post-renewal script.
-func (m *Manager) runOnChangeScript(script string) error {
-	cmd := exec.Command("sh", "-c", script)
-	output, err := cmd.CombinedOutput()
+// warnRenewalWindow logs when renew_before or renew_at_percent would renew
+// the certificate immediately and the default window is used instead.
+func warnRenewalWindow(managed *ManagedCertificate) {
+	cfg := managed.Config
+	if cfg.RenewBefore == 0 || cfg.RenewBefore < certificateLifetime(managed) {
+		return
+	}
+	slog.Warn("renew_before is longer than the certificate's lifetime, renewing with a third of the lifetime left",
+		"certificate", cfg.Name,
+		"renew_before", cfg.RenewBefore,
+		"lifetime", certificateLifetime(managed).Round(time.Second))
+}
+
+// warnTTLMismatch logs when Vault granted a lifetime that differs from the
+// requested TTL, typically because the role's max_ttl truncated it.
+func warnTTLMismatch(managed *ManagedCertificate) {
+	if managed.Config.Refreshes() || managed.Config.IsACMESource() || managed.Config.TTL == 0 {
+		return
+	}
+
+	granted := managed.Certificate.NotAfter.Sub(managed.Certificate.NotBefore)
+	diff := granted - managed.Config.TTL
+	if diff < -ttlTolerance || diff > ttlTolerance {
+		slog.Warn("Vault granted a different TTL than requested, check the role's max_ttl",
+			"certificate", managed.Config.Name,
+			"requested_ttl", managed.Config.TTL,
+			"granted_ttl", granted.Round(time.Second),
+			"next_renewal", managed.NextRenewal)
+	}
+}
+
+// parseCertificatePEM decodes the first PEM certificate block in data.
+func parseCertificatePEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode PEM certificate")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
-		return fmt.Errorf("script failed with error %v: %s", err, string(output))
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
 	}
-	slog.Debug("On-change script executed successfully",
-		"output", string(output))
-	return nil
+	return cert, nil
 }
 
-// -------------------------------------------------------------------------
-// HELPERS
-// -------------------------------------------------------------------------
+// firstCertificateDER returns the DER bytes of the first PEM certificate
+// block in data, or nil if there is none.
+func firstCertificateDER(data []byte) []byte {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil
+		}
+		if block.Type == "CERTIFICATE" {
+			return block.Bytes
+		}
+	}
+}
+
+// outputsMissing reports whether an additional output file is not on disk.
+// Chain outputs are skipped when Vault returns no chain, so they are not
+// checked.
+func outputsMissing(cfg *config.CertificateConfig) bool {
+	for _, output := range cfg.Outputs {
+		if output.Type != "chain" && !fileExists(cfg.OutputPath(output)) {
+			return true
+		}
+	}
+	return false
+}
 
 // fileExists checks if a file exists at the given path.
 func fileExists(filename string) bool {
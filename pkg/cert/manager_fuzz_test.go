@@ -0,0 +1,41 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - PEM Parsing Fuzz Tests
+//
+// Native Go fuzz tests for certificate PEM parsing and fingerprinting.
+// Run with: go test -fuzz=FuzzParseCertificatePEM ./pkg/cert
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/vault"
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// FUZZ TESTS
+// -------------------------------------------------------------------------
+
+// FuzzParseCertificatePEM verifies arbitrary input never panics the PEM parser.
+func FuzzParseCertificatePEM(f *testing.F) {
+	data := vault.CreateTestCertificateData()
+	f.Add([]byte(data.Certificate))
+	f.Add([]byte(data.Certificate + "\n" + data.CertificateChain))
+	f.Add([]byte(data.PrivateKey))
+	f.Add([]byte("-----BEGIN CERTIFICATE-----\n-----END CERTIFICATE-----\n"))
+	f.Add([]byte{})
+
+	m := &Manager{}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cert, err := parseCertificatePEM(data)
+		if err == nil && cert == nil {
+			t.Fatal("nil certificate without error")
+		}
+		_ = m.calculateFingerprint(data)
+		_, _ = PublicKeyInfo(cert)
+	})
+}
@@ -11,11 +11,25 @@ package cert
 // -------------------------------------------------------------------------
 
 import (
+	"cert-manager/pkg/clock"
 	"cert-manager/pkg/config"
 	"cert-manager/pkg/vault"
+	"cert-manager/pkg/vaulttest"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"fmt"
+	"math/big"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -205,3 +219,889 @@ func TestManager_ProcessCertificates_VaultError(t *testing.T) {
 		t.Error("certificate file should not exist after vault error")
 	}
 }
+
+// TestManager_ProcessCertificates_ConsecutiveFailures verifies failed
+// attempts are counted until a renewal succeeds, and Vault is reported
+// unreachable only when a request did not reach it.
+func TestManager_ProcessCertificates_ConsecutiveFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "test-cert",
+		Role:        "test-role",
+		CommonName:  "test.example.com",
+		Certificate: filepath.Join(tmpDir, "test.crt"),
+		Key:         filepath.Join(tmpDir, "test.key"),
+		TTL:         24 * time.Hour,
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	unreachable := &url.Error{Op: "Put", URL: "https://vault:8200", Err: &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}}
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(nil, unreachable),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(nil, fmt.Errorf("permission denied")),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil),
+	)
+
+	check := func(failures int, reachable bool) {
+		t.Helper()
+		managed := manager.Snapshot()[0]
+		if managed.ConsecutiveFailures != failures {
+			t.Errorf("expected %d consecutive failures, got %d", failures, managed.ConsecutiveFailures)
+		}
+		if managed.VaultReachable == nil || *managed.VaultReachable != reachable {
+			t.Errorf("expected vault reachable %v, got %v", reachable, managed.VaultReachable)
+		}
+		if managed.LastAttempt.IsZero() {
+			t.Error("expected the last attempt to be recorded")
+		}
+	}
+
+	_ = manager.ForceRotate("test-cert")
+	check(1, false)
+	_ = manager.ForceRotate("test-cert")
+	check(2, true)
+	if err := manager.ForceRotate("test-cert"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	check(0, true)
+}
+
+// TestManager_ProcessCertificates_Reissue verifies certificates found on
+// disk at startup that are already expired or cannot be parsed are
+// reissued right away.
+func TestManager_ProcessCertificates_Reissue(t *testing.T) {
+	tests := []struct {
+		name   string
+		write  func(t *testing.T, certConfig *config.CertificateConfig)
+		reason string
+	}{
+		{
+			name: "unparseable",
+			write: func(t *testing.T, certConfig *config.CertificateConfig) {
+				_ = os.WriteFile(certConfig.Certificate, []byte("not a certificate"), 0644)
+			},
+			reason: PlanUnreadable,
+		},
+		{
+			name: "expired",
+			write: func(t *testing.T, certConfig *config.CertificateConfig) {
+				data := selfSignedCertificateData(t, time.Now().Add(-48*time.Hour), time.Now().Add(-time.Hour))
+				_ = os.WriteFile(certConfig.Certificate, []byte(data.Certificate), 0644)
+			},
+			reason: PlanExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tmpDir := t.TempDir()
+			certConfig := &config.CertificateConfig{
+				Name:        "web",
+				Role:        "test-role",
+				CommonName:  "web.example.com",
+				Certificate: filepath.Join(tmpDir, "web.crt"),
+				Key:         filepath.Join(tmpDir, "web.key"),
+				TTL:         24 * time.Hour,
+			}
+			tt.write(t, certConfig)
+			_ = os.WriteFile(certConfig.Key, []byte("key"), 0600)
+
+			mockClient := vault.NewMockClient(ctrl)
+			mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+
+			manager := NewManager(mockClient)
+			if err := manager.AddCertificate(certConfig); err != nil {
+				t.Fatalf("failed to add certificate: %v", err)
+			}
+			if plan := manager.Plan(); !plan[0].Rotate || plan[0].Reason != tt.reason {
+				t.Errorf("expected %s rotation planned, got %+v", tt.reason, plan[0])
+			}
+			if err := manager.ProcessCertificates(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if summary := manager.LastSummary(); summary.Renewed != 1 {
+				t.Errorf("expected certificate to be reissued, got %+v", summary)
+			}
+		})
+	}
+}
+
+// TestManager_ProcessCertificates_Policy verifies issue_if_missing
+// certificates are issued but never renewed, and renew_only certificates
+// are renewed but never created.
+func TestManager_ProcessCertificates_Policy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	newConfig := func(name, policy string) *config.CertificateConfig {
+		return &config.CertificateConfig{
+			Name:        name,
+			Role:        "test-role",
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(tmpDir, name+".crt"),
+			Key:         filepath.Join(tmpDir, name+".key"),
+			TTL:         24 * time.Hour,
+			Policy:      policy,
+		}
+	}
+	issueOnly := newConfig("issue", config.PolicyIssueIfMissing)
+	renewOnly := newConfig("renew", config.PolicyRenewOnly)
+
+	mockClient := vault.NewMockClient(ctrl)
+	fake := clock.NewFake(time.Now())
+	manager := NewManager(mockClient)
+	manager.SetClock(fake)
+	for _, certConfig := range []*config.CertificateConfig{issueOnly, renewOnly} {
+		if err := manager.AddCertificate(certConfig); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+	}
+
+	// Missing files: issue_if_missing issues, renew_only refuses.
+	mockClient.EXPECT().IssueCertificate(issueOnly).Return(vault.CreateTestCertificateData(), nil)
+	_ = manager.ProcessCertificates()
+	if summary := manager.LastSummary(); summary.Renewed != 1 || summary.Errors["renew"] == nil {
+		t.Fatalf("expected issue to be issued and renew to fail, got %+v", summary)
+	}
+	if fileExists(renewOnly.Certificate) {
+		t.Error("expected renew_only certificate not to be created")
+	}
+
+	// Another system provisions the renew_only certificate.
+	data := vault.CreateTestCertificateData()
+	_ = os.WriteFile(renewOnly.Certificate, []byte(data.Certificate), 0644)
+	_ = os.WriteFile(renewOnly.Key, []byte(data.PrivateKey), 0600)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary := manager.LastSummary(); summary.Renewed != 0 || summary.Failed != 0 {
+		t.Errorf("expected nothing to do for provisioned certificate, got %+v", summary)
+	}
+
+	// Past the renewal threshold only renew_only renews.
+	fake.Advance(20 * time.Hour)
+	plan := manager.Plan()
+	for _, p := range plan {
+		if p.Name == "issue" && (p.Rotate || p.Reason != PlanPolicy) {
+			t.Errorf("expected issue_if_missing to be left alone, got %+v", p)
+		}
+	}
+	mockClient.EXPECT().IssueCertificate(renewOnly).Return(vault.CreateTestCertificateData(), nil)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary := manager.LastSummary(); summary.Renewed != 1 {
+		t.Errorf("expected renew_only certificate to be renewed, got %+v", summary)
+	}
+}
+
+// TestManager_Snapshot verifies snapshots are sorted copies safe for concurrent reads.
+func TestManager_Snapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	for _, name := range []string{"b-cert", "a-cert"} {
+		certConfig := &config.CertificateConfig{
+			Name:        name,
+			Role:        "test-role",
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(tmpDir, name+".crt"),
+			Key:         filepath.Join(tmpDir, name+".key"),
+			TTL:         24 * time.Hour,
+		}
+		if err := manager.AddCertificate(certConfig); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+	}
+
+	mockClient.EXPECT().IssueCertificate(gomock.Any()).Return(nil, fmt.Errorf("vault error")).AnyTimes()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			_ = manager.ProcessCertificates()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			for _, managed := range manager.Snapshot() {
+				_ = len(managed.History)
+			}
+		}
+	}()
+	wg.Wait()
+
+	snapshot := manager.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Config.Name != "a-cert" {
+		t.Fatalf("expected sorted snapshot, got %d entries", len(snapshot))
+	}
+
+	snapshot[0].History = nil
+	if len(manager.certificates["a-cert"].History) == 0 {
+		t.Error("modifying a snapshot should not affect managed state")
+	}
+}
+
+// TestManager_SyncCertificates verifies certificates are added, updated in
+// place, and removed to match the desired set.
+func TestManager_SyncCertificates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	manager := NewManager(vault.NewMockClient(ctrl))
+
+	newConfig := func(name, commonName string) *config.CertificateConfig {
+		return &config.CertificateConfig{
+			Name:        name,
+			Role:        "test-role",
+			CommonName:  commonName,
+			Certificate: filepath.Join(tmpDir, name+".crt"),
+			Key:         filepath.Join(tmpDir, name+".key"),
+			TTL:         24 * time.Hour,
+		}
+	}
+
+	if err := manager.AddCertificate(newConfig("keep", "keep.example.com")); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.AddCertificate(newConfig("change", "old.example.com")); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.AddCertificate(newConfig("drop", "drop.example.com")); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	jitter := manager.certificates["change"].RenewalJitter
+
+	added, updated, removed := manager.SyncCertificates([]*config.CertificateConfig{
+		newConfig("keep", "keep.example.com"),
+		newConfig("change", "new.example.com"),
+		newConfig("new", "new.example.com"),
+	})
+
+	if !reflect.DeepEqual(added, []string{"new"}) {
+		t.Errorf("expected [new] added, got %v", added)
+	}
+	if !reflect.DeepEqual(updated, []string{"change"}) {
+		t.Errorf("expected [change] updated, got %v", updated)
+	}
+	if !reflect.DeepEqual(removed, []string{"drop"}) {
+		t.Errorf("expected [drop] removed, got %v", removed)
+	}
+
+	certs := manager.certificates
+	if len(certs) != 3 {
+		t.Fatalf("expected 3 certificates, got %d", len(certs))
+	}
+	if certs["change"].Config.CommonName != "new.example.com" {
+		t.Errorf("expected updated common name, got %s", certs["change"].Config.CommonName)
+	}
+	if certs["change"].RenewalJitter != jitter {
+		t.Error("expected renewal state to be kept across updates")
+	}
+}
+
+// TestManager_UpdateRemoveCertificate verifies single certificates can be
+// updated in place and retired, signalling subscribers, and unknown names
+// are rejected.
+func TestManager_UpdateRemoveCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	manager := NewManager(vault.NewMockClient(ctrl))
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	jitter := manager.certificates["web"].RenewalJitter
+
+	changed := *certConfig
+	changed.CommonName = "www.example.com"
+	if err := manager.UpdateCertificate(&changed); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	managed := manager.certificates["web"]
+	if managed.Config.CommonName != "www.example.com" || managed.RenewalJitter != jitter {
+		t.Errorf("expected definition updated with state kept, got %s", managed.Config.CommonName)
+	}
+
+	missing := changed
+	missing.Name = "missing"
+	if err := manager.UpdateCertificate(&missing); err == nil {
+		t.Error("expected error updating unknown certificate")
+	}
+
+	changes, unsubscribe := manager.Subscribe()
+	defer unsubscribe()
+	if err := manager.RemoveCertificate("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(manager.certificates) != 0 || len(manager.Snapshot()) != 0 {
+		t.Error("expected certificate to be removed")
+	}
+	select {
+	case <-changes:
+	default:
+		t.Error("expected a signal when the certificate was removed")
+	}
+	if err := manager.RemoveCertificate("web"); err == nil {
+		t.Error("expected error removing unknown certificate")
+	}
+}
+
+// TestManager_ProcessCertificates_CAFile verifies the CA chain is written to
+// ca_file and left out of the certificate file when exclude_chain is set.
+func TestManager_ProcessCertificates_CAFile(t *testing.T) {
+	tests := []struct {
+		name         string
+		excludeChain bool
+	}{
+		{name: "chain appended", excludeChain: false},
+		{name: "chain excluded", excludeChain: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tmpDir := t.TempDir()
+			mockClient := vault.NewMockClient(ctrl)
+			manager := NewManager(mockClient)
+
+			certConfig := &config.CertificateConfig{
+				Name:         "test-cert",
+				Role:         "test-role",
+				CommonName:   "test.example.com",
+				Certificate:  filepath.Join(tmpDir, "test.crt"),
+				Key:          filepath.Join(tmpDir, "test.key"),
+				CAFile:       filepath.Join(tmpDir, "ca", "ca.crt"),
+				ExcludeChain: tt.excludeChain,
+				TTL:          24 * time.Hour,
+			}
+
+			certData := vault.CreateTestCertificateData()
+			mockClient.EXPECT().IssueCertificate(certConfig).Return(certData, nil)
+
+			if err := manager.AddCertificate(certConfig); err != nil {
+				t.Fatalf("failed to add certificate: %v", err)
+			}
+			if err := manager.ProcessCertificates(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			ca, err := os.ReadFile(certConfig.CAFile)
+			if err != nil {
+				t.Fatalf("failed to read CA file: %v", err)
+			}
+			if string(ca) != certData.CertificateChain {
+				t.Error("CA file should contain exactly the CA chain")
+			}
+
+			leaf, err := os.ReadFile(certConfig.Certificate)
+			if err != nil {
+				t.Fatalf("failed to read certificate file: %v", err)
+			}
+			if hasChain := strings.Contains(string(leaf), certData.CertificateChain); hasChain == tt.excludeChain {
+				t.Errorf("expected chain in certificate file: %v, got %v", !tt.excludeChain, hasChain)
+			}
+		})
+	}
+}
+
+// TestManager_CheckCAChains verifies certificates are reissued only when the
+// issuing CA on the PKI mount differs from the one on disk.
+func TestManager_CheckCAChains(t *testing.T) {
+	fake, err := vaulttest.NewServer()
+	if err != nil {
+		t.Fatalf("failed to start fake vault: %v", err)
+	}
+	defer fake.Close()
+
+	client, err := vault.NewClient(&config.VaultConfig{
+		Address: fake.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	tmpDir := t.TempDir()
+	manager := NewManager(client)
+	for _, certConfig := range []*config.CertificateConfig{
+		{Name: "ca-file", CAFile: filepath.Join(tmpDir, "ca.crt")},
+		{Name: "appended-chain"},
+		{Name: "no-chain", ExcludeChain: true},
+	} {
+		certConfig.Role = "test-role"
+		certConfig.CommonName = certConfig.Name + ".example.com"
+		certConfig.Certificate = filepath.Join(tmpDir, certConfig.Name+".crt")
+		certConfig.Key = filepath.Join(tmpDir, certConfig.Name+".key")
+		certConfig.TTL = 24 * time.Hour
+		if err := manager.AddCertificate(certConfig); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+	}
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.CheckCAChains(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.Issued() != 3 {
+		t.Fatalf("expected no reissue with an unchanged CA, got %d issuances", fake.Issued())
+	}
+
+	if err := fake.RotateCA(); err != nil {
+		t.Fatalf("failed to rotate CA: %v", err)
+	}
+	if err := manager.CheckCAChains(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fake.Issued() != 5 {
+		t.Errorf("expected the two certificates with a chain on disk to be reissued, got %d issuances", fake.Issued())
+	}
+
+	ca, err := os.ReadFile(filepath.Join(tmpDir, "ca.crt"))
+	if err != nil {
+		t.Fatalf("failed to read CA file: %v", err)
+	}
+	if string(ca) != fake.CAPEM() {
+		t.Error("expected ca_file to hold the rotated CA")
+	}
+}
+
+// TestManager_ProcessCertificates_KVSource verifies pre-issued certificates
+// are redeployed only when the certificate in KV changes.
+func TestManager_ProcessCertificates_KVSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	marker := filepath.Join(tmpDir, "changed")
+	certConfig := &config.CertificateConfig{
+		Name:        "wildcard",
+		CommonName:  "*.example.com",
+		Certificate: filepath.Join(tmpDir, "wildcard.crt"),
+		Key:         filepath.Join(tmpDir, "wildcard.key"),
+		OnChange:    "echo x >> " + marker,
+		Source:      "kv",
+		KV:          &config.CertKVSource{Mount: "secret", Path: "certs/wildcard", RefreshInterval: time.Minute},
+	}
+
+	first := newSelfSignedCertificateData(t)
+	second := newSelfSignedCertificateData(t)
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(first, nil).Times(2),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(second, nil),
+	)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := manager.ProcessCertificates(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		// Make the next cycle re-read KV.
+		manager.certificates["wildcard"].NextRenewal = time.Time{}
+	}
+
+	if n := len(manager.certificates["wildcard"].History); n != 2 {
+		t.Errorf("expected 2 deployments, got %d", n)
+	}
+
+	runs, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("failed to read on_change marker: %v", err)
+	}
+	if n := strings.Count(string(runs), "x"); n != 2 {
+		t.Errorf("expected on_change to run twice, got %d", n)
+	}
+}
+
+// TestManager_ProcessCertificates_Shadow verifies shadow certificates are
+// written to .shadow paths without touching production files or running
+// on_change.
+func TestManager_ProcessCertificates_Shadow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	marker := filepath.Join(tmpDir, "changed")
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		CAFile:      filepath.Join(tmpDir, "ca.crt"),
+		TTL:         24 * time.Hour,
+		OnChange:    "touch " + marker,
+		Shadow:      true,
+	}
+	if err := os.WriteFile(certConfig.Certificate, []byte("production"), 0644); err != nil {
+		t.Fatalf("failed to write production certificate: %v", err)
+	}
+
+	certData := newSelfSignedCertificateData(t)
+	certData.CertificateChain = certData.Certificate
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(certData, nil)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ForceRotate("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{certConfig.Certificate, certConfig.Key, certConfig.CAFile} {
+		if !fileExists(path + config.ShadowSuffix) {
+			t.Errorf("expected shadow file %s", path+config.ShadowSuffix)
+		}
+	}
+	if production, _ := os.ReadFile(certConfig.Certificate); string(production) != "production" {
+		t.Error("expected production certificate to be untouched")
+	}
+	if fileExists(certConfig.Key) {
+		t.Error("expected production key not to be written")
+	}
+	if fileExists(marker) {
+		t.Error("expected on_change not to run in shadow mode")
+	}
+}
+
+// TestManager_ProcessCertificates_FileModes verifies configured file and
+// directory modes are applied, including to files that already exist.
+func TestManager_ProcessCertificates_FileModes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "db",
+		Role:        "test-role",
+		CommonName:  "db.example.com",
+		Certificate: filepath.Join(tmpDir, "tls", "db.crt"),
+		Key:         filepath.Join(tmpDir, "tls", "db.key"),
+		CAFile:      filepath.Join(tmpDir, "ca.crt"),
+		CertMode:    "0640",
+		KeyMode:     "0640",
+		DirMode:     "0750",
+		TTL:         24 * time.Hour,
+	}
+	_ = os.WriteFile(certConfig.CAFile, []byte("stale"), 0600)
+
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, path := range []string{certConfig.Certificate, certConfig.Key, certConfig.CAFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", path, err)
+		}
+		if info.Mode().Perm() != 0640 {
+			t.Errorf("expected %s mode 0640, got %v", filepath.Base(path), info.Mode().Perm())
+		}
+	}
+
+	info, err := os.Stat(filepath.Dir(certConfig.Certificate))
+	if err != nil {
+		t.Fatalf("expected directory to be created: %v", err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("expected directory mode 0750, got %v", info.Mode().Perm())
+	}
+}
+
+// TestManager_ProcessCertificates_Outputs verifies each output gets its
+// own layout and mode, and that a missing output is rewritten.
+func TestManager_ProcessCertificates_Outputs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:         "db",
+		Role:         "test-role",
+		CommonName:   "db.example.com",
+		Certificate:  filepath.Join(tmpDir, "db.crt"),
+		Key:          filepath.Join(tmpDir, "db.key"),
+		ExcludeChain: true,
+		TTL:          24 * time.Hour,
+		Outputs: []config.OutputConfig{
+			{Type: "leaf", Path: filepath.Join(tmpDir, "postgres", "server.crt"), Mode: "0640"},
+			{Type: "key", Path: filepath.Join(tmpDir, "postgres", "server.key"), Mode: "0600"},
+			{Type: "fullchain", Path: filepath.Join(tmpDir, "nginx", "fullchain.pem"), Mode: "0644"},
+			{Type: "chain", Path: filepath.Join(tmpDir, "nginx", "chain.pem"), Mode: "0644"},
+			{Type: "combined", Path: filepath.Join(tmpDir, "haproxy", "db.pem"), Mode: "0600"},
+		},
+	}
+
+	certData := vault.CreateTestCertificateData()
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(certData, nil).Times(2)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fullchain := certData.Certificate + "\n" + certData.CertificateChain
+	expected := []string{
+		certData.Certificate,
+		certData.PrivateKey,
+		fullchain,
+		certData.CertificateChain,
+		fullchain + "\n" + certData.PrivateKey,
+	}
+	for i, output := range certConfig.Outputs {
+		data, err := os.ReadFile(output.Path)
+		if err != nil {
+			t.Fatalf("expected %s output to be written: %v", output.Type, err)
+		}
+		if string(data) != expected[i] {
+			t.Errorf("unexpected %s output:\n%s", output.Type, data)
+		}
+		info, _ := os.Stat(output.Path)
+		if info.Mode().Perm() != output.FileMode() {
+			t.Errorf("expected %s mode %v, got %v", output.Type, output.FileMode(), info.Mode().Perm())
+		}
+	}
+
+	certFile, _ := os.ReadFile(certConfig.Certificate)
+	if string(certFile) != certData.Certificate {
+		t.Error("expected exclude_chain to keep the certificate file leaf-only")
+	}
+
+	// A removed output is rewritten on the next pass.
+	_ = os.Remove(certConfig.Outputs[0].Path)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fileExists(certConfig.Outputs[0].Path) {
+		t.Error("expected missing output to be rewritten")
+	}
+}
+
+// TestManager_IssueCertificate_PreviousNotAfter verifies rotation history
+// records the expiry of the certificate being replaced.
+func TestManager_IssueCertificate_PreviousNotAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+	}
+
+	first := newSelfSignedCertificateData(t)
+	second := newSelfSignedCertificateData(t)
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(first, nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(second, nil),
+	)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := manager.ForceRotate("web"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	history := manager.certificates["web"].History
+	if len(history) != 2 {
+		t.Fatalf("expected 2 rotation events, got %d", len(history))
+	}
+	if !history[0].PreviousNotAfter.IsZero() {
+		t.Error("expected no previous expiry for the first issuance")
+	}
+	firstCert, _ := parseCertificatePEM([]byte(first.Certificate))
+	if !history[1].PreviousNotAfter.Equal(firstCert.NotAfter) {
+		t.Errorf("expected previous expiry %v, got %v", firstCert.NotAfter, history[1].PreviousNotAfter)
+	}
+}
+
+// TestManager_NeedsRenewal_TruncatedTTL verifies renewal timing follows the
+// lifetime Vault granted rather than the requested TTL.
+func TestManager_NeedsRenewal_TruncatedTTL(t *testing.T) {
+	manager := NewManager(nil)
+	managed := &ManagedCertificate{
+		Config:        &config.CertificateConfig{Name: "short", TTL: 24 * time.Hour},
+		RenewalJitter: time.Hour,
+	}
+
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		expected  bool
+	}{
+		{name: "early in lifetime", remaining: 50 * time.Minute, expected: false},
+		{name: "inside renewal window", remaining: 25 * time.Minute, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notAfter := time.Now().Add(tt.remaining)
+			managed.Certificate = &x509.Certificate{NotBefore: notAfter.Add(-time.Hour), NotAfter: notAfter}
+
+			if got := manager.needsRenewal(managed); got != tt.expected {
+				t.Errorf("expected needsRenewal %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestRenewalWindow verifies renew_before and renew_at_percent override the
+// default third of the lifetime, with the longer window winning.
+func TestRenewalWindow(t *testing.T) {
+	start := time.Now()
+	lifetime := 90 * 24 * time.Hour
+
+	tests := []struct {
+		name        string
+		renewBefore time.Duration
+		renewAt     config.Percent
+		expected    time.Duration
+	}{
+		{name: "default", expected: 30 * 24 * time.Hour},
+		{name: "renew_before", renewBefore: 14 * 24 * time.Hour, expected: 14 * 24 * time.Hour},
+		{name: "renew_at_percent", renewAt: 0.75, expected: 540 * time.Hour},
+		{name: "longer window wins", renewBefore: 14 * 24 * time.Hour, renewAt: 0.5, expected: 45 * 24 * time.Hour},
+		{name: "renew_before covers lifetime", renewBefore: 120 * 24 * time.Hour, expected: 30 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managed := &ManagedCertificate{
+				Config:      &config.CertificateConfig{Name: "web", RenewBefore: tt.renewBefore, RenewAtPercent: tt.renewAt},
+				Certificate: &x509.Certificate{NotBefore: start, NotAfter: start.Add(lifetime)},
+			}
+			if got := renewalWindow(managed); got != tt.expected {
+				t.Errorf("expected window %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestManager_NeedsRenewal_Clock verifies renewal timing follows the
+// manager's clock rather than the wall clock.
+func TestManager_NeedsRenewal_Clock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	manager := NewManager(nil)
+	manager.SetClock(fake)
+
+	managed := &ManagedCertificate{
+		Config:      &config.CertificateConfig{Name: "sim", TTL: 30 * 24 * time.Hour},
+		Certificate: &x509.Certificate{NotBefore: start, NotAfter: start.Add(30 * 24 * time.Hour)},
+	}
+
+	if manager.needsRenewal(managed) {
+		t.Fatal("expected a fresh certificate not to need renewal")
+	}
+	fake.Advance(21 * 24 * time.Hour)
+	if !manager.needsRenewal(managed) {
+		t.Fatal("expected renewal once the clock enters the renewal window")
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// newSelfSignedCertificateData returns parseable certificate data with a
+// fresh self-signed certificate.
+func newSelfSignedCertificateData(t *testing.T) *vault.CertificateData {
+	t.Helper()
+	return selfSignedCertificateData(t, time.Now(), time.Now().Add(365*24*time.Hour))
+}
+
+// selfSignedCertificateData returns certificate data with a self-signed
+// certificate valid from notBefore to notAfter.
+func selfSignedCertificateData(t *testing.T, notBefore, notAfter time.Time) *vault.CertificateData {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "*.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	return &vault.CertificateData{
+		Certificate: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+	}
+}
@@ -0,0 +1,81 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Ownership (Unix)
+//
+// Sets the owner and group of written files with chown. Numeric owners and
+// groups are used as IDs directly, so container UIDs without a passwd or
+// group entry work; names are looked up.
+// -------------------------------------------------------------------------------
+
+//go:build !windows
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// changeOwnership sets the owner and group of a file.
+func (m *Manager) changeOwnership(filename, owner, group string) error {
+	uid, gid := -1, -1
+
+	if owner != "" {
+		id, err := lookupID(owner, func(name string) (string, error) {
+			u, err := user.Lookup(name)
+			if err != nil {
+				return "", err
+			}
+			return u.Uid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("user %s not found: %w", owner, err)
+		}
+		uid = id
+	}
+
+	if group != "" {
+		id, err := lookupID(group, func(name string) (string, error) {
+			g, err := user.LookupGroup(name)
+			if err != nil {
+				return "", err
+			}
+			return g.Gid, nil
+		})
+		if err != nil {
+			return fmt.Errorf("group %s not found: %w", group, err)
+		}
+		gid = id
+	}
+
+	return os.Chown(filename, uid, gid)
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// lookupID returns name as a numeric ID, or the ID lookup resolves it to.
+func lookupID(name string, lookup func(string) (string, error)) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return id, nil
+	}
+	idStr, err := lookup(name)
+	if err != nil {
+		return -1, err
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return -1, fmt.Errorf("invalid id %q: %w", idStr, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,93 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Ownership Tests (Unix)
+//
+// Unit tests for resolving owners and groups to numeric IDs and applying
+// them.
+// -------------------------------------------------------------------------------
+
+//go:build !windows
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestLookupID verifies numeric owners and groups are used as IDs without
+// a lookup and names are resolved.
+func TestLookupID(t *testing.T) {
+	lookup := func(name string) (string, error) {
+		if name == "web" {
+			return "1001", nil
+		}
+		return "", fmt.Errorf("unknown name %s", name)
+	}
+
+	tests := []struct {
+		name     string
+		expected int
+		wantErr  bool
+	}{
+		{name: "1001", expected: 1001},
+		{name: "0", expected: 0},
+		{name: "web", expected: 1001},
+		{name: "missing", wantErr: true},
+		{name: "-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := lookupID(tt.name, lookup)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got id %d", id)
+				}
+				return
+			}
+			if err != nil || id != tt.expected {
+				t.Errorf("expected id %d, got %d, %v", tt.expected, id, err)
+			}
+		})
+	}
+}
+
+// TestManager_ChangeOwnership_NumericIDs verifies numeric owners and
+// groups are applied without a passwd or group entry.
+func TestManager_ChangeOwnership_NumericIDs(t *testing.T) {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		// Root can chown to IDs no account has.
+		uid, gid = 54321, 54321
+	}
+
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(path, []byte("cert"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	manager := NewManager(nil)
+	if err := manager.changeOwnership(path, strconv.Itoa(uid), strconv.Itoa(gid)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat file: %v", err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	if int(stat.Uid) != uid || int(stat.Gid) != gid {
+		t.Errorf("expected owner %d:%d, got %d:%d", uid, gid, stat.Uid, stat.Gid)
+	}
+}
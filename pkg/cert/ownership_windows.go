@@ -0,0 +1,68 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Ownership (Windows)
+//
+// Windows has no UIDs or GIDs: owner and group name accounts, e.g. "web" or
+// "DOMAIN\svc-web", or are SID strings such as "S-1-5-32-544", and are set
+// as the owner and primary group in the file's security descriptor. Setting
+// an owner other than the service account needs the restore privilege, as
+// held by services running as LocalSystem or an administrator. File modes
+// are not mapped to ACLs; files inherit the ACL of their directory.
+// -------------------------------------------------------------------------------
+
+//go:build windows
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// changeOwnership sets the owner and primary group of a file.
+func (m *Manager) changeOwnership(filename, owner, group string) error {
+	var info windows.SECURITY_INFORMATION
+	var ownerSID, groupSID *windows.SID
+
+	if owner != "" {
+		sid, err := lookupSID(owner)
+		if err != nil {
+			return fmt.Errorf("user %s not found: %w", owner, err)
+		}
+		ownerSID = sid
+		info |= windows.OWNER_SECURITY_INFORMATION
+	}
+
+	if group != "" {
+		sid, err := lookupSID(group)
+		if err != nil {
+			return fmt.Errorf("group %s not found: %w", group, err)
+		}
+		groupSID = sid
+		info |= windows.GROUP_SECURITY_INFORMATION
+	}
+
+	return windows.SetNamedSecurityInfo(filename, windows.SE_FILE_OBJECT, info, ownerSID, groupSID, nil, nil)
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// lookupSID returns the SID of an account name or SID string.
+func lookupSID(name string) (*windows.SID, error) {
+	if strings.HasPrefix(strings.ToUpper(name), "S-") {
+		return windows.StringToSid(name)
+	}
+	sid, _, _, err := windows.LookupSID("", name)
+	return sid, err
+}
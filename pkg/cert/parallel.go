@@ -0,0 +1,136 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Parallel Processing
+//
+// Bounded worker pool for processing passes and forced rotations. Workers
+// take certificates in renewal order, so the soonest-to-expire are still
+// started first, and each certificate's failure is recorded without
+// affecting the others. Outcomes are summed into a ProcessSummary.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// processOutcome is what a pass did with one certificate.
+type processOutcome int
+
+const (
+	outcomeUnchanged processOutcome = iota
+	outcomeRenewed
+	outcomeDeferred
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// ProcessSummary aggregates the outcome of one processing pass or forced
+// rotation.
+type ProcessSummary struct {
+	Certificates int
+	Renewed      int // issued, renewed, or redeployed from KV
+	ShortLived   int // of Renewed, certificates living less than ShortLivedLifetime
+	Deferred     int // renewal deferred while Vault is degraded
+	Failed       int
+	Errors       map[string]error // keyed by certificate name
+	Duration     time.Duration
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// SetMaxParallel sets how many certificates are processed at once. Values
+// below 1 process certificates one at a time.
+func (m *Manager) SetMaxParallel(n int) {
+	m.maxParallel = n
+}
+
+// LastSummary returns the summary of the most recent processing pass.
+func (m *Manager) LastSummary() ProcessSummary {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSummary
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// runParallel calls fn for every certificate in list on up to maxParallel
+// workers, in list order, and waits for all of them.
+func (m *Manager) runParallel(list []*ManagedCertificate, fn func(*ManagedCertificate) (processOutcome, error)) ProcessSummary {
+	start := time.Now()
+	summary := ProcessSummary{Certificates: len(list), Errors: make(map[string]error)}
+
+	jobs := make(chan *ManagedCertificate, len(list))
+	for _, managed := range list {
+		jobs <- managed
+	}
+	close(jobs)
+
+	workers := min(max(m.maxParallel, 1), len(list))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for managed := range jobs {
+				outcome, err := fn(managed)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					summary.Failed++
+					summary.Errors[managed.Config.Name] = err
+				case outcome == outcomeRenewed:
+					summary.Renewed++
+					if isShortLived(managed) {
+						summary.ShortLived++
+					}
+				case outcome == outcomeDeferred:
+					summary.Deferred++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary.Duration = time.Since(start)
+	return summary
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// logSummary logs a pass's totals, at info level when anything other than
+// routine renewals of short-lived certificates happened.
+func logSummary(msg string, summary ProcessSummary) {
+	level := slog.LevelDebug
+	if summary.Renewed > summary.ShortLived || summary.Deferred > 0 || summary.Failed > 0 {
+		level = slog.LevelInfo
+	}
+	slog.Log(context.Background(), level, msg,
+		"certificates", summary.Certificates,
+		"renewed", summary.Renewed,
+		"deferred", summary.Deferred,
+		"failed", summary.Failed,
+		"duration", summary.Duration.Round(time.Millisecond))
+}
@@ -0,0 +1,137 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Parallel Processing Tests
+//
+// Unit tests for the bounded worker pool: the parallelism limit, per
+// certificate error isolation, and the aggregated pass summary.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_ProcessCertificates_Parallel verifies certificates are issued
+// concurrently up to the limit and one failure does not stop the others.
+func TestManager_ProcessCertificates_Parallel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+	manager.SetMaxParallel(3)
+
+	var inFlight, peak atomic.Int32
+	mockClient.EXPECT().IssueCertificate(gomock.Any()).DoAndReturn(func(cfg *config.CertificateConfig) (*vault.CertificateData, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if cfg.Name == "cert-3" {
+			return nil, errors.New("permission denied")
+		}
+		return newSelfSignedCertificateData(t), nil
+	}).Times(8)
+
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("cert-%d", i)
+		if err := manager.AddCertificate(&config.CertificateConfig{
+			Name:        name,
+			Role:        "test-role",
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(tmpDir, name+".crt"),
+			Key:         filepath.Join(tmpDir, name+".key"),
+			TTL:         24 * time.Hour,
+		}); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+	}
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Errorf("expected between 2 and 3 concurrent issues, got %d", p)
+	}
+
+	summary := manager.LastSummary()
+	if summary.Certificates != 8 || summary.Renewed != 7 || summary.Failed != 1 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+	if _, ok := summary.Errors["cert-3"]; !ok || len(summary.Errors) != 1 {
+		t.Errorf("expected only cert-3 to fail, got %v", summary.Errors)
+	}
+
+	for name, managed := range manager.certificates {
+		if (managed.Certificate == nil) != (name == "cert-3") {
+			t.Errorf("unexpected state for %s after the pass", name)
+		}
+	}
+}
+
+// TestManager_ProcessCertificates_Sequential verifies the default processes
+// one certificate at a time.
+func TestManager_ProcessCertificates_Sequential(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	var inFlight, peak atomic.Int32
+	mockClient.EXPECT().IssueCertificate(gomock.Any()).DoAndReturn(func(cfg *config.CertificateConfig) (*vault.CertificateData, error) {
+		if n := inFlight.Add(1); n > peak.Load() {
+			peak.Store(n)
+		}
+		defer inFlight.Add(-1)
+		time.Sleep(5 * time.Millisecond)
+		return newSelfSignedCertificateData(t), nil
+	}).Times(3)
+
+	for i := 0; i < 3; i++ {
+		name := fmt.Sprintf("cert-%d", i)
+		if err := manager.AddCertificate(&config.CertificateConfig{
+			Name:        name,
+			Role:        "test-role",
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(tmpDir, name+".crt"),
+			Key:         filepath.Join(tmpDir, name+".key"),
+			TTL:         24 * time.Hour,
+		}); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+	}
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p := peak.Load(); p != 1 {
+		t.Errorf("expected sequential processing, got %d concurrent issues", p)
+	}
+}
@@ -0,0 +1,156 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Rotation Planning
+//
+// Dry-run evaluation of what a processing pass or forced rotation would do
+// and why, without contacting the issuer or touching disk.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// Reasons a certificate would or would not be rotated.
+const (
+	PlanMissing       = "missing"        // certificate or key file missing
+	PlanOutputMissing = "output_missing" // an outputs entry is missing
+	PlanExpiring      = "expiring"       // past its renewal threshold
+	PlanExpired       = "expired"        // past its expiry
+	PlanKVRefresh     = "kv_refresh"     // KV-sourced certificate due to be re-read
+	PlanCARefresh     = "ca_refresh"     // CA bundle due to be re-read
+	PlanForced        = "forced"         // forced rotation
+	PlanDeferred      = "deferred"       // due, but deferred while Vault is degraded
+	PlanUnreadable    = "unreadable"     // files exist but cannot be parsed, reissued
+	PlanPolicy        = "policy"         // never renewed under its policy
+	PlanNotDue        = "not_due"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// PlannedRotation is what a pass or forced rotation would do for one
+// certificate.
+type PlannedRotation struct {
+	Name     string    `json:"name"`
+	Rotate   bool      `json:"rotate"`
+	Reason   string    `json:"reason"`
+	NotAfter time.Time `json:"not_after,omitzero"`
+	RenewAt  time.Time `json:"renew_at,omitzero"`
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Plan returns what the next processing pass would do for each
+// certificate, in the order the pass would handle them.
+func (m *Manager) Plan() []PlannedRotation {
+	list := m.renewalOrder()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	degraded := m.throttle != nil && m.throttle.Throttled()
+	plan := make([]PlannedRotation, 0, len(list))
+	for _, managed := range list {
+		plan = append(plan, m.planCertificate(managed, degraded))
+	}
+	return plan
+}
+
+// PlanForceRotateAll returns what ForceRotateAll would do.
+func (m *Manager) PlanForceRotateAll() []PlannedRotation {
+	list := m.managedList()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	plan := make([]PlannedRotation, 0, len(list))
+	for _, managed := range list {
+		plan = append(plan, forcedPlan(managed))
+	}
+	return plan
+}
+
+// PlanForceRotate returns what ForceRotate would do for one certificate.
+func (m *Manager) PlanForceRotate(name string) (PlannedRotation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	managed, exists := m.certificates[name]
+	if !exists {
+		return PlannedRotation{}, fmt.Errorf("certificate %s not found", name)
+	}
+	return forcedPlan(managed), nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// planCertificate mirrors processCertificate's decisions for one
+// certificate. The caller must hold m.mu.
+func (m *Manager) planCertificate(managed *ManagedCertificate, degraded bool) PlannedRotation {
+	plan := PlannedRotation{Name: managed.Config.Name}
+	if managed.Certificate != nil {
+		plan.NotAfter = managed.Certificate.NotAfter
+	}
+
+	switch {
+	case !m.certificateExists(managed):
+		plan.Rotate, plan.Reason = managed.Config.IssuesMissing(), PlanMissing
+	case outputsMissing(managed.Config):
+		plan.Rotate, plan.Reason = managed.Config.IssuesMissing(), PlanOutputMissing
+	case managed.Certificate != nil && !managed.Config.AutoRenews():
+		plan.Reason = PlanPolicy
+	case managed.Config.Refreshes():
+		plan.RenewAt = managed.NextRenewal
+		if m.clock.Now().After(managed.NextRenewal) {
+			plan.Rotate, plan.Reason = true, PlanKVRefresh
+			if managed.Config.IsCABundleSource() {
+				plan.Reason = PlanCARefresh
+			}
+		} else {
+			plan.Reason = PlanNotDue
+		}
+	case managed.Certificate == nil:
+		plan.Rotate, plan.Reason = managed.Config.IssuesMissing(), PlanUnreadable
+	default:
+		plan.RenewAt = renewalThreshold(managed)
+		switch {
+		case !m.needsRenewal(managed):
+			plan.Reason = PlanNotDue
+		case degraded && !m.isUrgent(managed):
+			plan.Reason = PlanDeferred
+		case m.isExpired(managed):
+			plan.Rotate, plan.Reason = true, PlanExpired
+		default:
+			plan.Rotate, plan.Reason = true, PlanExpiring
+		}
+	}
+	return plan
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// forcedPlan is the plan for forcibly rotating managed.
+func forcedPlan(managed *ManagedCertificate) PlannedRotation {
+	plan := PlannedRotation{Name: managed.Config.Name, Rotate: true, Reason: PlanForced}
+	if managed.Certificate != nil {
+		plan.NotAfter = managed.Certificate.NotAfter
+	}
+	return plan
+}
@@ -0,0 +1,123 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Rotation Planning Tests
+//
+// Unit tests for dry-run rotation plans.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/clock"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_Plan verifies the plan reports missing, expiring, and
+// current certificates without issuing or writing anything.
+func TestManager_Plan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+	fake := clock.NewFake(time.Now())
+	manager.SetClock(fake)
+
+	deployed := &config.CertificateConfig{
+		Name:        "deployed",
+		Role:        "test-role",
+		CommonName:  "deployed.example.com",
+		Certificate: filepath.Join(tmpDir, "deployed.crt"),
+		Key:         filepath.Join(tmpDir, "deployed.key"),
+	}
+	missing := &config.CertificateConfig{
+		Name:        "missing",
+		Role:        "test-role",
+		CommonName:  "missing.example.com",
+		Certificate: filepath.Join(tmpDir, "missing.crt"),
+		Key:         filepath.Join(tmpDir, "missing.key"),
+	}
+	if err := manager.AddCertificate(deployed); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	mockClient.EXPECT().IssueCertificate(deployed).Return(newSelfSignedCertificateData(t), nil)
+	if err := manager.ForceRotate("deployed"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := manager.AddCertificate(missing); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	reasons := func() map[string]PlannedRotation {
+		result := make(map[string]PlannedRotation)
+		for _, entry := range manager.Plan() {
+			result[entry.Name] = entry
+		}
+		return result
+	}
+
+	plan := reasons()
+	if entry := plan["missing"]; !entry.Rotate || entry.Reason != PlanMissing {
+		t.Errorf("expected missing certificate to be issued, got %+v", entry)
+	}
+	entry := plan["deployed"]
+	if entry.Rotate || entry.Reason != PlanNotDue || entry.RenewAt.IsZero() {
+		t.Errorf("expected deployed certificate not due, got %+v", entry)
+	}
+
+	fake.Advance(entry.RenewAt.Sub(fake.Now()) + time.Second)
+	if entry := reasons()["deployed"]; !entry.Rotate || entry.Reason != PlanExpiring {
+		t.Errorf("expected deployed certificate to be renewed, got %+v", entry)
+	}
+	if fileExists(missing.Certificate) {
+		t.Error("expected the plan not to write anything")
+	}
+
+	forced, err := manager.PlanForceRotate("deployed")
+	if err != nil || !forced.Rotate || forced.Reason != PlanForced {
+		t.Errorf("expected forced plan, got %+v (%v)", forced, err)
+	}
+	if _, err := manager.PlanForceRotate("unknown"); err == nil {
+		t.Error("expected error for unknown certificate")
+	}
+	if all := manager.PlanForceRotateAll(); len(all) != 2 {
+		t.Errorf("expected both certificates in forced plan, got %d", len(all))
+	}
+}
+
+// TestManager_Plan_Unreadable verifies certificates whose files cannot be
+// parsed are planned for reissue.
+func TestManager_Plan_Unreadable(t *testing.T) {
+	tmpDir := t.TempDir()
+	certConfig := &config.CertificateConfig{
+		Name:        "test-cert",
+		Certificate: filepath.Join(tmpDir, "test.pem"),
+		Key:         filepath.Join(tmpDir, "test.pem"),
+	}
+	if err := os.WriteFile(certConfig.Certificate, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+
+	manager := NewManager(nil)
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if plan := manager.Plan(); len(plan) != 1 || !plan[0].Rotate || plan[0].Reason != PlanUnreadable {
+		t.Errorf("expected unreadable certificate to be reissued, got %+v", plan)
+	}
+}
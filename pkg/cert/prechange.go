@@ -0,0 +1,183 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Pre-Deployment Validation
+//
+// With pre_change set, a deployment first writes every file next to its
+// destination with a .staged suffix and runs pre_change, e.g. nginx -t
+// against a config pointing at the staged files. Only if it succeeds are
+// the staged files renamed into place, each atomically; otherwise they are
+// removed and the certificate in service is kept.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/vault"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// stagedSuffix is appended to a destination's path while it is staged.
+const stagedSuffix = ".staged"
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// stagedFile is a destination written to its staged path.
+type stagedFile struct {
+	role     string
+	path     string
+	staged   string
+	checksum string
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// writeStaged writes the certificate's files to their staged paths and
+// runs pre_change against them. The staged files are removed on failure,
+// leaving the certificate in service untouched.
+func (m *Manager) writeStaged(managed *ManagedCertificate, certData *vault.CertificateData) error {
+	m.mu.Lock()
+	managed.staging = true
+	m.unlock()
+	err := m.writeCertificateToDisk(managed, certData)
+	m.mu.Lock()
+	managed.staging = false
+	m.unlock()
+
+	if err != nil {
+		m.discardStaged(managed)
+		return failedIn(reasonWrite, fmt.Errorf("failed to write certificate to disk: %w", err))
+	}
+	if err := m.runPreChange(managed); err != nil {
+		m.discardStaged(managed)
+		slog.Error("Pre-change validation rejected new certificate, keeping the current one",
+			"certificate", managed.Config.Name,
+			"serial", certData.SerialNumber,
+			"error", err)
+		return failedIn(reasonPreChange, err)
+	}
+	return nil
+}
+
+// stageDestination records a destination written to its staged path.
+func (m *Manager) stageDestination(managed *ManagedCertificate, role, filename, staged, content string) {
+	sum := sha256.Sum256([]byte(content))
+	m.mu.Lock()
+	managed.staged = append(managed.staged, stagedFile{
+		role:     role,
+		path:     filename,
+		staged:   staged,
+		checksum: hex.EncodeToString(sum[:]),
+	})
+	m.unlock()
+}
+
+// runPreChange runs pre_change against the staged files. A failure is
+// counted and leaves the staged files for the caller to discard.
+func (m *Manager) runPreChange(managed *ManagedCertificate) (err error) {
+	span := m.startSpan(managed, "hook.pre_change")
+	defer func() { span.End(err) }()
+
+	ctx := context.Background()
+	if timeout := managed.Config.OnChangePolicy.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var env []string
+	m.mu.RLock()
+	for _, file := range managed.staged {
+		switch file.role {
+		case "certificate":
+			env = append(env, "CERT_STAGED_PATH="+file.staged)
+		case "key":
+			env = append(env, "KEY_STAGED_PATH="+file.staged)
+		case "ca_file":
+			env = append(env, "CA_STAGED_PATH="+file.staged)
+		}
+	}
+	m.mu.RUnlock()
+
+	output, err := m.runCommand(ctx, managed, managed.Config.PreChange, env)
+	if err != nil {
+		m.mu.Lock()
+		managed.PreChangeFailures++
+		m.unlock()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("pre_change timed out after %s and was killed: %s", managed.Config.OnChangePolicy.Timeout, output)
+		}
+		return fmt.Errorf("pre_change failed with error %v: %s", err, output)
+	}
+	slog.Debug("Pre-change validation passed",
+		"certificate", managed.Config.Name,
+		"output", output)
+	return nil
+}
+
+// commitStaged renames the staged files into place and verifies them.
+func (m *Manager) commitStaged(managed *ManagedCertificate) error {
+	m.mu.Lock()
+	staged := managed.staged
+	managed.staged = nil
+	deployment := managed.deployment
+	m.unlock()
+
+	for i, file := range staged {
+		if err := os.Rename(file.staged, file.path); err != nil {
+			for _, rest := range staged[i:] {
+				_ = os.Remove(rest.staged)
+			}
+			return fmt.Errorf("failed to move %s into place: %w", file.staged, err)
+		}
+		status := verifyDestination(file.path, file.checksum, deployment)
+		m.recordDestination(managed, file.role, status)
+		if !status.InSync {
+			for _, rest := range staged[i+1:] {
+				_ = os.Remove(rest.staged)
+			}
+			return fmt.Errorf("verification of %s failed: %s", file.path, status.Error)
+		}
+	}
+	return nil
+}
+
+// discardStaged removes the staged files, leaving the destinations as
+// they were.
+func (m *Manager) discardStaged(managed *ManagedCertificate) {
+	m.mu.Lock()
+	staged := managed.staged
+	managed.staged = nil
+	m.unlock()
+
+	for _, file := range staged {
+		if err := os.Remove(file.staged); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove staged file", "path", file.staged, "error", err)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// stages reports whether deployments of managed are staged for pre_change.
+func stages(managed *ManagedCertificate) bool {
+	return managed.Config.PreChange != "" && !managed.Config.Shadow
+}
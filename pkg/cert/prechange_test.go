@@ -0,0 +1,88 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Pre-Deployment Validation Tests
+//
+// Unit tests for staging files, running pre_change against them, and
+// keeping the current certificate when it fails.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_PreChange verifies pre_change sees the staged files before
+// they replace the destinations, and that a failing pre_change keeps the
+// current certificate and skips on_change.
+func TestManager_PreChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	marker := filepath.Join(tmpDir, "reloaded")
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		PreChange:   `test -s "$CERT_STAGED_PATH" && test -s "$KEY_STAGED_PATH" && ! test -e "$CERT_PATH"`,
+		OnChange:    "touch " + marker,
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil).Times(2)
+
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !fileExists(certConfig.Certificate) || !fileExists(certConfig.Key) {
+		t.Fatal("expected staged files to be moved into place")
+	}
+	if fileExists(certConfig.Certificate+stagedSuffix) || fileExists(certConfig.Key+stagedSuffix) {
+		t.Error("expected no staged files to be left behind")
+	}
+	if status := manager.certificates["web"].Destinations["certificate"]; !status.InSync {
+		t.Errorf("expected certificate destination in sync, got %+v", status)
+	}
+
+	// The destination exists now, so the same check rejects the rotation.
+	_ = os.Remove(marker)
+	deployed, _ := os.ReadFile(certConfig.Certificate)
+	if err := manager.ForceRotate("web"); err == nil {
+		t.Fatal("expected pre_change failure")
+	}
+	current, _ := os.ReadFile(certConfig.Certificate)
+	if string(current) != string(deployed) {
+		t.Error("expected current certificate to be kept")
+	}
+	if fileExists(certConfig.Certificate + stagedSuffix) {
+		t.Error("expected staged files to be removed")
+	}
+	if fileExists(marker) {
+		t.Error("expected on_change not to run")
+	}
+	if n := manager.certificates["web"].PreChangeFailures; n != 1 {
+		t.Errorf("expected 1 pre_change failure, got %d", n)
+	}
+}
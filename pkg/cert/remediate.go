@@ -0,0 +1,156 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Out-of-Sync Remediation
+//
+// Reloads services that keep serving a certificate other than the one on
+// disk, e.g. after a reload that silently failed or a restart from a stale
+// copy. Once a certificate's health_check target has been out of sync for
+// its remediate.after grace period, its remediate.command, or on_change
+// without one, is run again and the outcome counted.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// ServedFingerprinter reports the fingerprint of the certificate a
+// certificate's health_check target currently serves. Deployment
+// verifiers that implement it enable remediation.
+type ServedFingerprinter interface {
+	ServedFingerprint(managed *ManagedCertificate) (string, error)
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// RemediationCounts counts remediations of out-of-sync services.
+type RemediationCounts struct {
+	Success int `json:"success,omitempty"`
+	Failure int `json:"failure,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// RemediateOutOfSync checks the health_check target of every certificate
+// with remediate set and reloads those out of sync for longer than their
+// grace period. Targets that cannot be reached are left to the health
+// check metrics.
+func (m *Manager) RemediateOutOfSync() {
+	served, ok := m.verifier.(ServedFingerprinter)
+	if !ok {
+		return
+	}
+
+	m.opMu.Lock()
+	defer m.opMu.Unlock()
+
+	for _, managed := range m.managedList() {
+		check := managed.Config.HealthCheck
+		if check == nil || check.Remediate == nil || managed.Config.Shadow {
+			continue
+		}
+
+		m.mu.RLock()
+		fingerprint := managed.Fingerprint
+		m.mu.RUnlock()
+		if fingerprint == "" {
+			continue
+		}
+
+		remote, err := served.ServedFingerprint(managed)
+		if err != nil {
+			slog.Debug("Skipping remediation, health check failed",
+				"certificate", managed.Config.Name,
+				"error", err)
+			continue
+		}
+		if remote == "" {
+			continue
+		}
+
+		now := m.clock.Now()
+		m.mu.Lock()
+		if remote == fingerprint {
+			managed.outOfSyncSince = time.Time{}
+		} else if managed.outOfSyncSince.IsZero() {
+			managed.outOfSyncSince = now
+		}
+		since := managed.outOfSyncSince
+		m.unlock()
+
+		if since.IsZero() || now.Sub(since) < check.Remediate.After {
+			continue
+		}
+		m.remediate(managed, remote, fingerprint, now.Sub(since))
+	}
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// remediate runs the remediation for a certificate out of sync for
+// outOfSync, counts the outcome, and restarts its grace period.
+func (m *Manager) remediate(managed *ManagedCertificate, remote, fingerprint string, outOfSync time.Duration) {
+	slog.Warn("Service still serving an old certificate, remediating",
+		"certificate", managed.Config.Name,
+		"target", managed.Config.HealthCheck.Target(),
+		"served", remote,
+		"disk", fingerprint,
+		"out_of_sync", outOfSync.Round(time.Second))
+
+	err := m.runRemediation(managed)
+
+	m.mu.Lock()
+	managed.outOfSyncSince = m.clock.Now()
+	if err != nil {
+		managed.Remediations.Failure++
+	} else {
+		managed.Remediations.Success++
+	}
+	m.unlock()
+
+	if err != nil {
+		slog.Error("Failed to remediate out-of-sync certificate",
+			"certificate", managed.Config.Name,
+			"error", err)
+		return
+	}
+	slog.Info("Remediated out-of-sync certificate", "certificate", managed.Config.Name)
+}
+
+// runRemediation runs remediate.command with the on_change policy's
+// timeout, or on_change with its retries when no command is set.
+func (m *Manager) runRemediation(managed *ManagedCertificate) error {
+	command := managed.Config.HealthCheck.Remediate.Command
+	if command == "" {
+		return m.runOnChangeScript(managed)
+	}
+
+	ctx := context.Background()
+	if timeout := managed.Config.OnChangePolicy.Timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	output, err := m.runCommand(ctx, managed, command, nil)
+	if err != nil {
+		return fmt.Errorf("remediate command failed with error %v: %s", err, output)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Out-of-Sync Remediation Tests
+//
+// Unit tests for reloading services that keep serving an old certificate.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/clock"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_RemediateOutOfSync verifies the remediation runs only once
+// the target has been out of sync for the grace period, restarts the grace
+// period, and is counted.
+func TestManager_RemediateOutOfSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	marker := filepath.Join(tmpDir, "remediated")
+	mockClient := vault.NewMockClient(ctrl)
+	fake := clock.NewFake(time.Now())
+	verifier := &fakeServedVerifier{served: "stale"}
+	manager := NewManager(mockClient)
+	manager.SetClock(fake)
+	manager.SetDeploymentVerifier(verifier)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		HealthCheck: &config.HealthCheck{
+			TCP: "localhost:443",
+			Remediate: &config.RemediateConfig{
+				After:   5 * time.Minute,
+				Command: "echo reload >> " + marker,
+			},
+		},
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	remediations := func() int {
+		data, _ := os.ReadFile(marker)
+		return strings.Count(string(data), "reload")
+	}
+
+	manager.RemediateOutOfSync()
+	fake.Advance(4 * time.Minute)
+	manager.RemediateOutOfSync()
+	if remediations() != 0 {
+		t.Fatal("expected no remediation within the grace period")
+	}
+
+	fake.Advance(2 * time.Minute)
+	manager.RemediateOutOfSync()
+	if remediations() != 1 {
+		t.Fatalf("expected one remediation after the grace period, got %d", remediations())
+	}
+	manager.RemediateOutOfSync()
+	if remediations() != 1 {
+		t.Error("expected the grace period to restart after remediating")
+	}
+
+	// Back in sync, the grace period is reset.
+	verifier.served = manager.certificates["web"].Fingerprint
+	manager.RemediateOutOfSync()
+	fake.Advance(10 * time.Minute)
+	verifier.served = "stale"
+	manager.RemediateOutOfSync()
+	if remediations() != 1 {
+		t.Error("expected no remediation once back in sync")
+	}
+
+	certConfig.HealthCheck.Remediate.Command = "exit 1"
+	fake.Advance(5 * time.Minute)
+	manager.RemediateOutOfSync()
+	if counts := manager.certificates["web"].Remediations; counts.Success != 1 || counts.Failure != 1 {
+		t.Errorf("expected one successful and one failed remediation, got %+v", counts)
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeServedVerifier is a DeploymentVerifier whose target serves a fixed
+// fingerprint.
+type fakeServedVerifier struct {
+	served string
+}
+
+// VerifyDeployment implements DeploymentVerifier.
+func (f *fakeServedVerifier) VerifyDeployment(_ *ManagedCertificate, _ time.Duration) error {
+	return nil
+}
+
+// ServedFingerprint implements ServedFingerprinter.
+func (f *fakeServedVerifier) ServedFingerprint(_ *ManagedCertificate) (string, error) {
+	return f.served, nil
+}
@@ -0,0 +1,113 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Renewal Scheduling
+//
+// Computes when each certificate next needs attention, so the processing
+// loop can sleep until the earliest one is due instead of polling. Missing
+// certificates are due immediately; attempts that fail, are deferred, or
+// renew are not retried for RetryDelay, which bounds the retry rate the
+// same way the old one-minute poll did.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// RetryDelay is how long a certificate waits before it is attempted again
+// after a processing pass failed, deferred, or renewed it. Short-lived
+// certificates wait a quarter of their renewal window if that is shorter.
+const RetryDelay = time.Minute
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// NextDue returns when the earliest certificate needs processing, which is
+// the current time if one is already due. ok is false when no certificate
+// is scheduled.
+func (m *Manager) NextDue() (next time.Time, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := m.clock.Now()
+	for _, managed := range m.certificates {
+		due, scheduled := m.dueAt(managed)
+		if !scheduled {
+			continue
+		}
+		due = maxTime(due, now)
+		if !ok || due.Before(next) {
+			next, ok = due, true
+		}
+	}
+	return next, ok
+}
+
+// ScheduleChanged is signalled when certificates are added or their
+// definitions change, so a caller waiting on NextDue can recompute it.
+func (m *Manager) ScheduleChanged() <-chan struct{} {
+	return m.scheduleChanged
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// dueAt returns when a certificate next needs processing. Certificates
+// whose files are missing or cannot be parsed are due now; ones whose
+// policy never renews them are otherwise not scheduled. The caller must
+// hold m.mu.
+func (m *Manager) dueAt(managed *ManagedCertificate) (time.Time, bool) {
+	var due time.Time
+	switch {
+	case !m.certificateExists(managed) || outputsMissing(managed.Config):
+		// Due now.
+	case managed.Certificate != nil && !managed.Config.AutoRenews():
+		return time.Time{}, false
+	case managed.Config.Refreshes():
+		due = managed.NextRenewal
+	case managed.Certificate != nil:
+		due = renewalThreshold(managed)
+	}
+	return maxTime(due, managed.retryAt), true
+}
+
+// scheduleRetry holds off the next attempt for retryDelay when a pass did
+// anything with the certificate other than leave it unchanged.
+func (m *Manager) scheduleRetry(managed *ManagedCertificate, outcome processOutcome, err error) {
+	if err == nil && outcome == outcomeUnchanged {
+		return
+	}
+	m.mu.Lock()
+	managed.retryAt = m.clock.Now().Add(retryDelay(managed))
+	m.unlock()
+}
+
+// notifyScheduleChanged signals ScheduleChanged without blocking.
+func (m *Manager) notifyScheduleChanged() {
+	select {
+	case m.scheduleChanged <- struct{}{}:
+	default:
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// maxTime returns the later of a and b.
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
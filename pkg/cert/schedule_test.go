@@ -0,0 +1,110 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Renewal Scheduling Tests
+//
+// Unit tests for computing when certificates are next due and the retry
+// delay after failed attempts.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/clock"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_NextDue verifies missing certificates are due immediately,
+// failed attempts wait RetryDelay, and deployed certificates are due at
+// their renewal threshold.
+func TestManager_NextDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+	fake := clock.NewFake(time.Now())
+	manager.SetClock(fake)
+
+	if _, ok := manager.NextDue(); ok {
+		t.Fatal("expected nothing scheduled without certificates")
+	}
+
+	certConfig := &config.CertificateConfig{
+		Name:        "test-cert",
+		Role:        "test-role",
+		CommonName:  "test.example.com",
+		Certificate: filepath.Join(tmpDir, "test.crt"),
+		Key:         filepath.Join(tmpDir, "test.key"),
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	select {
+	case <-manager.ScheduleChanged():
+	default:
+		t.Error("expected AddCertificate to signal a schedule change")
+	}
+
+	due, ok := manager.NextDue()
+	if !ok || !due.Equal(fake.Now()) {
+		t.Fatalf("expected missing certificate to be due now, got %v (scheduled %v)", due, ok)
+	}
+
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(nil, fmt.Errorf("vault error"))
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if due, _ := manager.NextDue(); !due.Equal(fake.Now().Add(RetryDelay)) {
+		t.Errorf("expected retry after %s, got %v", RetryDelay, due.Sub(fake.Now()))
+	}
+
+	fake.Advance(RetryDelay)
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(newSelfSignedCertificateData(t), nil)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	managed := manager.Snapshot()[0]
+	want := renewalThreshold(managed)
+	if due, _ := manager.NextDue(); !due.Equal(want) {
+		t.Errorf("expected certificate due at its renewal threshold %v, got %v", want, due)
+	}
+}
+
+// TestManager_NextDue_Unparseable verifies certificates whose files cannot
+// be parsed are due now, so they are reissued.
+func TestManager_NextDue_Unparseable(t *testing.T) {
+	tmpDir := t.TempDir()
+	certConfig := &config.CertificateConfig{
+		Name:        "test-cert",
+		Certificate: filepath.Join(tmpDir, "test.pem"),
+		Key:         filepath.Join(tmpDir, "test.pem"),
+	}
+	if err := os.WriteFile(certConfig.Certificate, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+
+	manager := NewManager(nil)
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if due, ok := manager.NextDue(); !ok || due.After(time.Now()) {
+		t.Errorf("expected unparseable certificate to be due now, got %v (scheduled %v)", due, ok)
+	}
+}
@@ -0,0 +1,102 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Short-Lived Certificates
+//
+// Certificates with lifetimes of minutes renew many times an hour. Renewal
+// jitter and the retry delay scale down with the renewal window so they
+// cannot push a renewal past expiry, routine renewals of short-lived
+// certificates are logged at debug level, and the interval between
+// renewals is exported so their renewal frequency can be tracked.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"log/slog"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	// ShortLivedLifetime is the lifetime below which a certificate is
+	// short-lived and its routine renewals are logged at debug level.
+	ShortLivedLifetime = time.Hour
+
+	// MaxRenewalJitter is the largest jitter applied to a renewal; it
+	// scales down for renewal windows shorter than four times this.
+	MaxRenewalJitter = time.Hour
+
+	// MinRetryDelay is the shortest wait before a failed certificate is
+	// attempted again, however short its renewal window.
+	MinRetryDelay = 5 * time.Second
+)
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// RenewalInterval returns the time between the certificate's two most
+// recent successful rotations, or zero before it has rotated twice.
+func (managed *ManagedCertificate) RenewalInterval() time.Duration {
+	var last time.Time
+	for i := len(managed.History) - 1; i >= 0; i-- {
+		event := managed.History[i]
+		if !event.Success {
+			continue
+		}
+		if last.IsZero() {
+			last = event.Time
+			continue
+		}
+		return last.Sub(event.Time)
+	}
+	return 0
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// isShortLived reports whether a certificate lives less than
+// ShortLivedLifetime, judged from the requested TTL before it is issued.
+func isShortLived(managed *ManagedCertificate) bool {
+	if managed.Certificate != nil {
+		return certificateLifetime(managed) < ShortLivedLifetime
+	}
+	return managed.Config.TTL > 0 && managed.Config.TTL < ShortLivedLifetime
+}
+
+// renewalLogLevel is the level routine renewals of a certificate are
+// logged at.
+func renewalLogLevel(managed *ManagedCertificate) slog.Level {
+	if isShortLived(managed) {
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// renewalJitter scales the certificate's jitter, drawn from
+// MaxRenewalJitter, to at most a quarter of window.
+func renewalJitter(managed *ManagedCertificate, window time.Duration) time.Duration {
+	limit := window / 4
+	if limit >= MaxRenewalJitter {
+		return managed.RenewalJitter
+	}
+	return time.Duration(float64(managed.RenewalJitter) * float64(limit) / float64(MaxRenewalJitter))
+}
+
+// retryDelay returns how long a certificate waits after a pass to be
+// attempted again: RetryDelay, or a quarter of the renewal window for
+// short-lived certificates, but at least MinRetryDelay.
+func retryDelay(managed *ManagedCertificate) time.Duration {
+	if managed.Certificate == nil {
+		return RetryDelay
+	}
+	return max(min(RetryDelay, renewalWindow(managed)/4), MinRetryDelay)
+}
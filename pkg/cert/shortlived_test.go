@@ -0,0 +1,86 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Short-Lived Certificate Tests
+//
+// Unit tests for jitter, retry delay, logging, and renewal intervals of
+// certificates with lifetimes of minutes.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"crypto/x509"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRenewalThreshold_ShortLived verifies jitter and retry delay scale
+// with the renewal window of a five-minute certificate.
+func TestRenewalThreshold_ShortLived(t *testing.T) {
+	now := time.Now()
+	managed := &ManagedCertificate{
+		Config:        &config.CertificateConfig{Name: "short", TTL: 5 * time.Minute, RenewAtPercent: 0.5},
+		Certificate:   &x509.Certificate{NotBefore: now, NotAfter: now.Add(5 * time.Minute)},
+		RenewalJitter: MaxRenewalJitter - time.Nanosecond,
+	}
+
+	window := renewalWindow(managed)
+	if window != 150*time.Second {
+		t.Fatalf("expected a 150s window, got %v", window)
+	}
+	if jitter := managed.Certificate.NotAfter.Sub(renewalThreshold(managed)) - window; jitter > window/4 {
+		t.Errorf("expected jitter within a quarter of the window, got %v", jitter)
+	}
+	if delay := retryDelay(managed); delay != window/4 {
+		t.Errorf("expected retry delay of a quarter of the window, got %v", delay)
+	}
+	if level := renewalLogLevel(managed); level != slog.LevelDebug {
+		t.Errorf("expected short-lived renewals at debug level, got %v", level)
+	}
+
+	managed.Certificate.NotAfter = now.Add(30 * 24 * time.Hour)
+	if jitter := renewalJitter(managed, renewalWindow(managed)); jitter != managed.RenewalJitter {
+		t.Errorf("expected full jitter for a long-lived certificate, got %v", jitter)
+	}
+	if delay := retryDelay(managed); delay != RetryDelay {
+		t.Errorf("expected RetryDelay for a long-lived certificate, got %v", delay)
+	}
+	if level := renewalLogLevel(managed); level != slog.LevelInfo {
+		t.Errorf("expected long-lived renewals at info level, got %v", level)
+	}
+
+	managed.Certificate.NotAfter = now.Add(10 * time.Second)
+	if delay := retryDelay(managed); delay != MinRetryDelay {
+		t.Errorf("expected MinRetryDelay floor, got %v", delay)
+	}
+}
+
+// TestManagedCertificate_RenewalInterval verifies the interval is taken
+// from the two most recent successful rotations.
+func TestManagedCertificate_RenewalInterval(t *testing.T) {
+	start := time.Now()
+	managed := &ManagedCertificate{}
+	if interval := managed.RenewalInterval(); interval != 0 {
+		t.Errorf("expected no interval without history, got %v", interval)
+	}
+
+	managed.History = []RotationEvent{
+		{Time: start, Success: true},
+		{Time: start.Add(2 * time.Minute), Success: true},
+		{Time: start.Add(3 * time.Minute), Success: false},
+		{Time: start.Add(5 * time.Minute), Success: true},
+		{Time: start.Add(6 * time.Minute), Success: false},
+	}
+	if interval := managed.RenewalInterval(); interval != 3*time.Minute {
+		t.Errorf("expected 3m interval, got %v", interval)
+	}
+}
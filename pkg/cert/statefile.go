@@ -0,0 +1,222 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Renewal State File
+//
+// Keeps per-certificate renewal state (last renewal, the serial it
+// deployed, renewal counts, the last failure, and rotation history) in a
+// JSON file so it survives restarts. The file is rewritten atomically after every issuance
+// attempt and read back when certificates are added.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/state"
+	"cert-manager/pkg/vault"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// stateFileVersion is the state file format this release writes.
+const stateFileVersion = 1
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// RenewalCounts counts a certificate's issuance attempts by outcome.
+type RenewalCounts struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+}
+
+// stateFile is the renewal state file and the entries last read from or
+// written to it. writeMu serializes writes; mu guards entries and is never
+// held while taking the manager's locks.
+type stateFile struct {
+	path    string
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	entries map[string]certificateState
+}
+
+// stateDocument is the content of the state file.
+type stateDocument struct {
+	Version      int                         `json:"version"`
+	Certificates map[string]certificateState `json:"certificates"`
+}
+
+// certificateState is the persisted state of one certificate.
+type certificateState struct {
+	LastRenewed time.Time     `json:"last_renewed,omitzero"`
+	Serial      string        `json:"serial,omitempty"`
+	Renewals    RenewalCounts `json:"renewals"`
+	LastFailure time.Time     `json:"last_failure,omitzero"`
+	LastError   string        `json:"last_error,omitempty"`
+
+	// LastAttempt and ConsecutiveFailures, so a renewal failing for days
+	// is still reported after a restart.
+	LastAttempt         time.Time `json:"last_attempt,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures,omitempty"`
+
+	// History keeps rotation events for compliance reports and the
+	// renewal SLO across restarts.
+	History []RotationEvent `json:"history,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// SetStateFile keeps renewal state in path across restarts, restoring it
+// for certificates added from now on. A missing file starts empty, as does
+// an unreadable one, with a warning; a file written by a newer release is
+// refused.
+func (m *Manager) SetStateFile(path string) error {
+	entries, err := readStateFile(path)
+	if err != nil {
+		return err
+	}
+	m.stateFile = &stateFile{path: path, entries: entries}
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// restoreState applies managed's persisted state. The last renewal time is
+// only restored while the certificate on disk is the one it deployed.
+// Callers hold m.mu.
+func (m *Manager) restoreState(managed *ManagedCertificate) {
+	if m.stateFile == nil {
+		return
+	}
+	m.stateFile.mu.Lock()
+	saved, ok := m.stateFile.entries[managed.Config.Name]
+	m.stateFile.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	managed.Renewals = saved.Renewals
+	managed.LastFailure = saved.LastFailure
+	managed.LastError = saved.LastError
+	managed.LastAttempt = saved.LastAttempt
+	managed.ConsecutiveFailures = saved.ConsecutiveFailures
+	managed.History = append([]RotationEvent(nil), saved.History...)
+	if len(managed.History) > maxRotationHistory {
+		managed.History = managed.History[len(managed.History)-maxRotationHistory:]
+	}
+	if managed.Certificate != nil && vault.FormatSerial(managed.Certificate.SerialNumber.Bytes()) == saved.Serial {
+		managed.LastRenewed = saved.LastRenewed
+	}
+}
+
+// saveState writes the state of every managed certificate to the state
+// file. Failures are logged; the state file is advisory.
+func (m *Manager) saveState() {
+	if m.stateFile == nil {
+		return
+	}
+	m.stateFile.writeMu.Lock()
+	defer m.stateFile.writeMu.Unlock()
+
+	entries := make(map[string]certificateState)
+	m.mu.RLock()
+	for name, managed := range m.certificates {
+		entry := certificateState{
+			LastRenewed: managed.LastRenewed,
+			Renewals:    managed.Renewals,
+			LastFailure: managed.LastFailure,
+			LastError:   managed.LastError,
+
+			LastAttempt:         managed.LastAttempt,
+			ConsecutiveFailures: managed.ConsecutiveFailures,
+			History:             append([]RotationEvent(nil), managed.History...),
+		}
+		if managed.Certificate != nil {
+			entry.Serial = vault.FormatSerial(managed.Certificate.SerialNumber.Bytes())
+		}
+		entries[name] = entry
+	}
+	m.mu.RUnlock()
+
+	m.stateFile.mu.Lock()
+	m.stateFile.entries = entries
+	m.stateFile.mu.Unlock()
+
+	if err := writeStateFile(m.stateFile.path, entries); err != nil {
+		slog.Warn("Failed to save renewal state", "path", m.stateFile.path, "error", err)
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// readStateFile reads the entries of the state file at path.
+func readStateFile(path string) (map[string]certificateState, error) {
+	entries := make(map[string]certificateState)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var doc stateDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		slog.Warn("Ignoring unreadable renewal state file", "path", path, "error", err)
+		return entries, nil
+	}
+	if doc.Version > stateFileVersion {
+		return nil, fmt.Errorf("state file %s has version %d, this release supports %d: %w",
+			path, doc.Version, stateFileVersion, state.ErrNewerVersion)
+	}
+	for name, entry := range doc.Certificates {
+		entries[name] = entry
+	}
+	return entries, nil
+}
+
+// writeStateFile atomically replaces the state file at path.
+func writeStateFile(path string, entries map[string]certificateState) error {
+	data, err := json.MarshalIndent(stateDocument{Version: stateFileVersion, Certificates: entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create state file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
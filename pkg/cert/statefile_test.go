@@ -0,0 +1,125 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Renewal State File Tests
+//
+// Unit tests for persisting renewal state and restoring it in a new
+// manager.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/state"
+	"cert-manager/pkg/vault"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_StateFile verifies renewal counts, the last failure, rotation
+// history, and the last renewal time survive a restart, and the last renewal time is dropped
+// once the certificate on disk was replaced by something else.
+func TestManager_StateFile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	statePath := filepath.Join(tmpDir, "state", "state.json")
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+	}
+
+	mockClient := vault.NewMockClient(ctrl)
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(nil, errors.New("vault sealed")),
+	)
+
+	first := NewManager(mockClient)
+	if err := first.SetStateFile(statePath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := first.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := first.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := first.ForceRotate("web"); err == nil {
+		t.Fatal("expected rotation failure")
+	}
+	lastRenewed := first.certificates["web"].LastRenewed
+
+	restarted := func() *ManagedCertificate {
+		manager := NewManager(mockClient)
+		if err := manager.SetStateFile(statePath); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := manager.AddCertificate(certConfig); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+		return manager.certificates["web"]
+	}
+
+	managed := restarted()
+	if managed.Renewals != (RenewalCounts{Success: 1, Failure: 1}) {
+		t.Errorf("unexpected renewal counts %+v", managed.Renewals)
+	}
+	if !managed.LastRenewed.Equal(lastRenewed) {
+		t.Errorf("expected last renewal %v, got %v", lastRenewed, managed.LastRenewed)
+	}
+	if managed.LastFailure.IsZero() || managed.LastError == "" {
+		t.Errorf("expected last failure to be restored, got %v %q", managed.LastFailure, managed.LastError)
+	}
+	if managed.ConsecutiveFailures != 1 || managed.LastAttempt.IsZero() {
+		t.Errorf("expected one consecutive failure and the last attempt to be restored, got %d %v", managed.ConsecutiveFailures, managed.LastAttempt)
+	}
+	if len(managed.History) != 2 || !managed.History[0].Success || managed.History[1].Success || managed.History[1].Error == "" {
+		t.Errorf("expected the successful and failed rotations to be restored, got %+v", managed.History)
+	}
+
+	data := newSelfSignedCertificateData(t)
+	if err := os.WriteFile(certConfig.Certificate, []byte(data.Certificate), 0644); err != nil {
+		t.Fatalf("failed to replace certificate: %v", err)
+	}
+	managed = restarted()
+	if !managed.LastRenewed.IsZero() {
+		t.Errorf("expected no last renewal for a replaced certificate, got %v", managed.LastRenewed)
+	}
+	if managed.Renewals.Success != 1 {
+		t.Errorf("expected renewal counts to be kept, got %+v", managed.Renewals)
+	}
+}
+
+// TestManager_SetStateFile verifies unreadable state files start empty and
+// those from a newer release are refused.
+func TestManager_SetStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	_ = os.WriteFile(path, []byte("{not json"), 0600)
+	if err := NewManager(nil).SetStateFile(path); err != nil {
+		t.Errorf("expected unreadable state file to be ignored, got %v", err)
+	}
+
+	_ = os.WriteFile(path, []byte(`{"version": 99, "certificates": {}}`), 0600)
+	if err := NewManager(nil).SetStateFile(path); !errors.Is(err, state.ErrNewerVersion) {
+		t.Errorf("expected ErrNewerVersion, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Adaptive Renewal
+//
+// Watches Vault request stats between processing passes and reports when
+// Vault looks degraded (high error rate or latency), so the manager can
+// defer renewals of certificates with plenty of lifetime left and spend
+// Vault's remaining capacity on certificates close to expiry.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// StatsSource provides cumulative Vault request counters.
+type StatsSource interface {
+	Stats() vault.Stats
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// RenewalThrottle decides whether non-urgent renewals should be deferred.
+type RenewalThrottle struct {
+	source StatsSource
+	config *config.AdaptiveRenewalConfig
+	now    func() time.Time
+
+	mu            sync.Mutex
+	lastCount     uint64
+	lastErrors    uint64
+	lastDuration  float64
+	degradedUntil time.Time
+	deferred      uint64
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewRenewalThrottle creates a throttle judging Vault health from source.
+// Requests made before creation are not counted.
+func NewRenewalThrottle(source StatsSource, cfg *config.AdaptiveRenewalConfig) *RenewalThrottle {
+	t := &RenewalThrottle{
+		source: source,
+		config: cfg,
+		now:    time.Now,
+	}
+	t.lastCount, t.lastErrors, t.lastDuration = totals(source.Stats())
+	return t
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Observe samples Vault stats since the previous call and returns true
+// while Vault is considered degraded. A degraded window keeps renewals
+// deferred for the backoff period; windows with too few requests to judge
+// leave the current state unchanged.
+func (t *RenewalThrottle) Observe() bool {
+	count, errors, duration := totals(t.source.Stats())
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	requests := count - t.lastCount
+	failed := errors - t.lastErrors
+	elapsed := duration - t.lastDuration
+	t.lastCount, t.lastErrors, t.lastDuration = count, errors, duration
+
+	now := t.now()
+	if requests > 0 && requests >= uint64(t.config.MinRequests) {
+		errorRate := float64(failed) / float64(requests)
+		latency := time.Duration(elapsed / float64(requests) * float64(time.Second))
+		if errorRate >= t.config.ErrorRate || latency >= t.config.Latency {
+			if !now.Before(t.degradedUntil) {
+				slog.Warn("Vault looks degraded, deferring non-urgent renewals",
+					"error_rate", errorRate,
+					"mean_latency", latency,
+					"requests", requests,
+					"backoff", t.config.Backoff)
+			}
+			t.degradedUntil = now.Add(t.config.Backoff)
+		}
+	}
+
+	return now.Before(t.degradedUntil)
+}
+
+// Throttled reports whether renewals are currently being deferred.
+func (t *RenewalThrottle) Throttled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.now().Before(t.degradedUntil)
+}
+
+// Deferred returns the total number of renewals deferred so far.
+func (t *RenewalThrottle) Deferred() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.deferred
+}
+
+// recordDeferred counts a deferred renewal.
+func (t *RenewalThrottle) recordDeferred() {
+	t.mu.Lock()
+	t.deferred++
+	t.mu.Unlock()
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// totals sums request counts, errors, and latency across all Vault paths.
+func totals(stats vault.Stats) (count, errors uint64, duration float64) {
+	for _, req := range stats.Requests {
+		count += req.Count
+		errors += req.Errors
+		duration += req.DurationSum
+	}
+	return count, errors, duration
+}
@@ -0,0 +1,155 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Adaptive Renewal Tests
+//
+// Unit tests for judging Vault degradation and deferring renewals.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRenewalThrottle_Observe verifies degradation is judged per window and
+// lasts for the backoff period.
+func TestRenewalThrottle_Observe(t *testing.T) {
+	source := &fakeStats{}
+	now := time.Now()
+	throttle := NewRenewalThrottle(source, &config.AdaptiveRenewalConfig{
+		ErrorRate:   0.5,
+		Latency:     time.Second,
+		MinRequests: 2,
+		Backoff:     time.Minute,
+	})
+	throttle.now = func() time.Time { return now }
+
+	source.add(10, 0, 1)
+	if throttle.Observe() {
+		t.Fatal("expected healthy window not to throttle")
+	}
+
+	source.add(1, 1, 0)
+	if throttle.Observe() {
+		t.Fatal("expected window below min_requests not to throttle")
+	}
+
+	source.add(4, 2, 0.4)
+	if !throttle.Observe() {
+		t.Fatal("expected high error rate to throttle")
+	}
+
+	now = now.Add(30 * time.Second)
+	if !throttle.Observe() {
+		t.Error("expected throttle to persist within backoff")
+	}
+
+	now = now.Add(31 * time.Second)
+	if throttle.Observe() {
+		t.Error("expected throttle to lift after backoff")
+	}
+
+	source.add(2, 0, 5)
+	if !throttle.Observe() {
+		t.Error("expected high latency to throttle")
+	}
+	if !throttle.Throttled() {
+		t.Error("expected Throttled to report degradation")
+	}
+}
+
+// TestManager_ProcessCertificates_Degraded verifies non-urgent renewals are
+// deferred while Vault is degraded and urgent ones still go through.
+func TestManager_ProcessCertificates_Degraded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	source := &fakeStats{}
+	throttle := NewRenewalThrottle(source, &config.AdaptiveRenewalConfig{
+		ErrorRate:   0.2,
+		Latency:     time.Second,
+		MinRequests: 1,
+		Backoff:     time.Minute,
+	})
+	manager.SetRenewalThrottle(throttle)
+
+	newConfig := func(name string) *config.CertificateConfig {
+		return &config.CertificateConfig{
+			Name:        name,
+			Role:        "test-role",
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(tmpDir, name+".crt"),
+			Key:         filepath.Join(tmpDir, name+".key"),
+			TTL:         24 * time.Hour,
+		}
+	}
+	urgent := newConfig("urgent")
+	relaxed := newConfig("relaxed")
+
+	for _, cfg := range []*config.CertificateConfig{urgent, relaxed} {
+		if err := manager.AddCertificate(cfg); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+	}
+	manager.certificates["urgent"].Certificate = &x509.Certificate{
+		NotBefore: time.Now().Add(-23 * time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	manager.certificates["relaxed"].Certificate = &x509.Certificate{
+		NotBefore: time.Now().Add(-18 * time.Hour),
+		NotAfter:  time.Now().Add(6 * time.Hour),
+	}
+
+	mockClient.EXPECT().IssueCertificate(urgent).Return(newSelfSignedCertificateData(t), nil)
+
+	source.add(5, 5, 0)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if throttle.Deferred() != 1 {
+		t.Errorf("expected 1 deferred renewal, got %d", throttle.Deferred())
+	}
+	if fileExists(relaxed.Certificate) {
+		t.Error("expected non-urgent renewal to be deferred")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeStats is a StatsSource with adjustable cumulative counters.
+type fakeStats struct {
+	stats vault.RequestStats
+}
+
+// add records count requests, errors failures, and seconds of latency.
+func (f *fakeStats) add(count, errors uint64, seconds float64) {
+	f.stats.Count += count
+	f.stats.Errors += errors
+	f.stats.DurationSum += seconds
+}
+
+// Stats implements StatsSource.
+func (f *fakeStats) Stats() vault.Stats {
+	return vault.Stats{Requests: map[string]vault.RequestStats{"pki/issue": f.stats}}
+}
@@ -0,0 +1,98 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Rotation Tracing
+//
+// Optional spans around the stages of an issuance attempt: the Vault
+// request, disk writes, pre_change and on_change hooks, and the health
+// check confirming the deployment. Stage spans are children of the
+// rotation span of the certificate they belong to, so a slow rotation can
+// be broken down in a tracing backend.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// Tracer starts spans, as children of parent or as new traces when parent
+// is nil.
+type Tracer interface {
+	Start(parent Span, name string, attributes map[string]string) Span
+}
+
+// Span is a timed operation started by a Tracer, ended with its outcome.
+type Span interface {
+	End(err error)
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// noopSpan is returned when no tracer is set.
+type noopSpan struct{}
+
+// End does nothing.
+func (noopSpan) End(error) {}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// SetTracer records spans around issuance attempts with tracer.
+func (m *Manager) SetTracer(tracer Tracer) {
+	m.tracer = tracer
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// startRotationSpan starts the span of an issuance attempt of managed,
+// the parent of its stage spans until the returned function ends it.
+func (m *Manager) startRotationSpan(managed *ManagedCertificate, name string) func(err error) {
+	if m.tracer == nil {
+		return func(error) {}
+	}
+
+	span := m.tracer.Start(nil, name, spanAttributes(managed))
+	m.mu.Lock()
+	managed.span = span
+	m.unlock()
+
+	return func(err error) {
+		m.mu.Lock()
+		managed.span = nil
+		m.unlock()
+		span.End(err)
+	}
+}
+
+// startSpan starts a span for a stage of work on managed, under its
+// rotation span when an issuance attempt is in progress.
+func (m *Manager) startSpan(managed *ManagedCertificate, name string) Span {
+	if m.tracer == nil {
+		return noopSpan{}
+	}
+
+	m.mu.RLock()
+	parent := managed.span
+	m.mu.RUnlock()
+	return m.tracer.Start(parent, name, spanAttributes(managed))
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// spanAttributes returns the attributes identifying managed on its spans.
+func spanAttributes(managed *ManagedCertificate) map[string]string {
+	source := managed.Config.Source
+	if source == "" {
+		source = "pki"
+	}
+	return map[string]string{
+		"certificate.name":   managed.Config.Name,
+		"certificate.source": source,
+	}
+}
@@ -0,0 +1,114 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Rotation Tracing Tests
+//
+// Unit tests for spans around the stages of an issuance attempt.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_Tracing verifies an issuance attempt is traced as a rotation
+// span with its stages as children.
+func TestManager_Tracing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	tracer := &fakeTracer{}
+	manager := NewManager(mockClient)
+	manager.SetTracer(tracer)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		OnChange:    "true",
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	parents := make(map[string]string)
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span %s was not ended", span.name)
+		}
+		parent := ""
+		if span.parent != nil {
+			parent = span.parent.name
+		}
+		parents[span.name] = parent
+	}
+	for name, parent := range map[string]string{
+		"certificate.rotate": "",
+		"vault.issue":        "certificate.rotate",
+		"disk.write":         "certificate.rotate",
+		"hook.on_change":     "certificate.rotate",
+	} {
+		if got, ok := parents[name]; !ok || got != parent {
+			t.Errorf("expected span %s under %q, got %q (recorded: %v)", name, parent, got, ok)
+		}
+	}
+	if span := manager.certificates["web"].span; span != nil {
+		t.Error("expected the rotation span to be cleared")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeTracer records the spans it starts.
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+// fakeSpan is a span recorded by fakeTracer.
+type fakeSpan struct {
+	name   string
+	parent *fakeSpan
+	ended  bool
+}
+
+// Start implements Tracer.
+func (f *fakeTracer) Start(parent Span, name string, _ map[string]string) Span {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	span := &fakeSpan{name: name}
+	span.parent, _ = parent.(*fakeSpan)
+	f.spans = append(f.spans, span)
+	return span
+}
+
+// End implements Span.
+func (f *fakeSpan) End(error) {
+	f.ended = true
+}
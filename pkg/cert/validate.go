@@ -0,0 +1,99 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Issued Certificate Validation
+//
+// Checks issued material before it replaces anything on disk: the private
+// key must belong to the certificate, and when a CA chain is returned the
+// certificate must verify up to it. A failed check fails the rotation with
+// the previous files untouched, guarding against mixed-up responses.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/vault"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// validateIssued checks that certData's private key matches its leaf
+// certificate and that the leaf verifies against the returned chain.
+func (m *Manager) validateIssued(certData *vault.CertificateData) error {
+	certs := parseCertificateBlocks([]byte(certData.Certificate))
+	if len(certs) == 0 {
+		return fmt.Errorf("no certificate found")
+	}
+	leaf := certs[0]
+
+	key, err := parsePrivateKeyPEM([]byte(certData.PrivateKey))
+	if err != nil {
+		return err
+	}
+	if err := keyMatches(leaf, key); err != nil {
+		return err
+	}
+
+	chain := dedupeCertificates(leaf, append(certs[1:], parseCertificateBlocks([]byte(certData.CertificateChain))...))
+	if len(chain) == 0 {
+		return nil
+	}
+	return verifyChain(leaf, chain, maxTime(m.clock.Now(), leaf.NotBefore))
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// keyMatches reports an error unless key is the private key for cert.
+func keyMatches(cert *x509.Certificate, key crypto.PrivateKey) error {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return fmt.Errorf("unsupported private key type %T", key)
+	}
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(cert.PublicKey) {
+		return fmt.Errorf("private key does not match certificate %s", cert.Subject.CommonName)
+	}
+	return nil
+}
+
+// verifyChain verifies leaf up to chain at the given time. Self-signed
+// certificates in chain are the trust anchors; without one, the last
+// certificate in chain is, as when the mount's root is kept elsewhere.
+func verifyChain(leaf *x509.Certificate, chain []*x509.Certificate, at time.Time) error {
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	anchored := false
+	for _, ca := range chain {
+		if bytes.Equal(ca.RawSubject, ca.RawIssuer) && ca.CheckSignatureFrom(ca) == nil {
+			roots.AddCert(ca)
+			anchored = true
+		} else {
+			intermediates.AddCert(ca)
+		}
+	}
+	if !anchored {
+		roots.AddCert(chain[len(chain)-1])
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return fmt.Errorf("certificate does not verify against the CA chain: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Issued Certificate Validation Tests
+//
+// Unit tests for checking issued key, certificate, and chain before they
+// are deployed.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_ValidateIssued verifies matching material passes and a
+// mismatched key or unrelated chain is rejected.
+func TestManager_ValidateIssued(t *testing.T) {
+	manager := NewManager(nil)
+
+	valid := vault.CreateTestCertificateData()
+	if err := manager.validateIssued(valid); err != nil {
+		t.Errorf("expected issued certificate to validate, got %v", err)
+	}
+
+	selfSigned := newSelfSignedCertificateData(t)
+	if err := manager.validateIssued(selfSigned); err != nil {
+		t.Errorf("expected certificate without a chain to validate, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*vault.CertificateData)
+		want   string
+	}{
+		{
+			name:   "mismatched key",
+			modify: func(d *vault.CertificateData) { d.PrivateKey = newSelfSignedCertificateData(t).PrivateKey },
+			want:   "does not match",
+		},
+		{
+			name:   "unrelated chain",
+			modify: func(d *vault.CertificateData) { d.CertificateChain = newSelfSignedCertificateData(t).Certificate },
+			want:   "does not verify",
+		},
+		{
+			name:   "no certificate",
+			modify: func(d *vault.CertificateData) { d.Certificate = "" },
+			want:   "no certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certData := vault.CreateTestCertificateData()
+			tt.modify(certData)
+			err := manager.validateIssued(certData)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestManager_ForceRotate_InvalidIssued verifies a rotation with a
+// mismatched key fails and leaves the deployed files untouched.
+func TestManager_ForceRotate_InvalidIssued(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	if err := manager.ForceRotate("web"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	before, _ := os.ReadFile(certConfig.Certificate)
+
+	mixedUp := vault.CreateTestCertificateData()
+	mixedUp.PrivateKey = newSelfSignedCertificateData(t).PrivateKey
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(mixedUp, nil)
+	if err := manager.ForceRotate("web"); err == nil {
+		t.Fatal("expected rotation with a mismatched key to fail")
+	}
+
+	after, _ := os.ReadFile(certConfig.Certificate)
+	if string(after) != string(before) {
+		t.Error("expected the previous certificate to be kept")
+	}
+}
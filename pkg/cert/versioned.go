@@ -0,0 +1,192 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Versioned Certificate Directory
+//
+// With versioned set, every issuance is also kept in a certbot-style
+// directory: archive/<name>/ holds cert-, chain-, fullchain-, and
+// privkey-<timestamp>.pem for each version, and live/<name>/ holds stable
+// symlinks to the newest. Services can point at the live paths, and the
+// archive gives operators a history of rotations to roll back to by hand.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/vault"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// versionKinds are the files of each version, in the order they are
+// written.
+var versionKinds = []string{"cert", "chain", "fullchain", "privkey"}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// writeVersion writes a new version to the archive, points the live
+// symlinks at it, and prunes old versions.
+func (m *Manager) writeVersion(managed *ManagedCertificate, certData *vault.CertificateData) error {
+	cfg := managed.Config
+	archive, live := versionDirs(managed)
+	for _, dir := range []string{archive, live} {
+		if err := os.MkdirAll(dir, cfg.DirFileMode()); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", dir, err)
+		}
+	}
+
+	leaf, key, chain, err := encodeParts(cfg, certData)
+	if err != nil {
+		return err
+	}
+	contents := map[string]string{
+		"cert":      leaf,
+		"chain":     chain,
+		"fullchain": encodeOutput(cfg, "fullchain", leaf, "", chain),
+		"privkey":   key,
+	}
+
+	version := m.clock.Now().UTC().Format(archiveTimeFormat)
+	for _, kind := range versionKinds {
+		if contents[kind] == "" {
+			continue
+		}
+		mode := cfg.CertFileMode()
+		if kind == "privkey" {
+			mode = cfg.KeyFileMode()
+		}
+		path := filepath.Join(archive, versionFile(kind, version))
+		if err := m.writeFileWithPermissions(path, contents[kind], mode, cfg.Owner, cfg.Group); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	if err := linkVersion(managed, version); err != nil {
+		return err
+	}
+	pruneVersions(archive, cfg.Versioned.Keep)
+
+	slog.Debug("Wrote certificate version",
+		"certificate", cfg.Name,
+		"version", version,
+		"live", live)
+	return nil
+}
+
+// restoreVersion points the live symlinks back at the newest version
+// holding the certificate now deployed after a rollback, and removes the
+// versions written since.
+func (m *Manager) restoreVersion(managed *ManagedCertificate) {
+	archive, _ := versionDirs(managed)
+	versions := listVersions(archive)
+	for i := len(versions) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(filepath.Join(archive, versionFile("cert", versions[i])))
+		if err != nil || m.calculateFingerprint(data) != managed.Fingerprint {
+			continue
+		}
+		if err := linkVersion(managed, versions[i]); err != nil {
+			slog.Warn("Failed to restore live symlinks", "certificate", managed.Config.Name, "error", err)
+			return
+		}
+		for _, version := range versions[i+1:] {
+			removeVersion(archive, version)
+		}
+		return
+	}
+	slog.Warn("No archived version holds the restored certificate, live symlinks left as they are",
+		"certificate", managed.Config.Name)
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// versionDirs returns the certificate's archive and live directories.
+func versionDirs(managed *ManagedCertificate) (archive, live string) {
+	dir, name := managed.Config.Versioned.Dir, managed.Config.Name
+	return filepath.Join(dir, "archive", name), filepath.Join(dir, "live", name)
+}
+
+// versionFile returns the archive file name of kind in version.
+func versionFile(kind, version string) string {
+	return kind + "-" + version + ".pem"
+}
+
+// linkVersion atomically points each live symlink at version, relative to
+// the live directory. Links for files the version lacks, such as a chain,
+// are removed.
+func linkVersion(managed *ManagedCertificate, version string) error {
+	archive, live := versionDirs(managed)
+	for _, kind := range versionKinds {
+		link := filepath.Join(live, kind+".pem")
+		file := versionFile(kind, version)
+		if !fileExists(filepath.Join(archive, file)) {
+			if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", link, err)
+			}
+			continue
+		}
+
+		target := filepath.Join("..", "..", "archive", managed.Config.Name, file)
+		tmp := link + ".tmp"
+		_ = os.Remove(tmp)
+		if err := os.Symlink(target, tmp); err != nil {
+			return fmt.Errorf("failed to link %s: %w", link, err)
+		}
+		if err := os.Rename(tmp, link); err != nil {
+			_ = os.Remove(tmp)
+			return fmt.Errorf("failed to link %s: %w", link, err)
+		}
+	}
+	return nil
+}
+
+// listVersions returns the versions in archive, oldest first.
+func listVersions(archive string) []string {
+	entries, err := os.ReadDir(archive)
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, "cert-") && strings.HasSuffix(name, ".pem") {
+			versions = append(versions, strings.TrimSuffix(strings.TrimPrefix(name, "cert-"), ".pem"))
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// pruneVersions removes all but the newest keep versions in archive.
+func pruneVersions(archive string, keep int) {
+	versions := listVersions(archive)
+	if len(versions) <= keep {
+		return
+	}
+	for _, version := range versions[:len(versions)-keep] {
+		removeVersion(archive, version)
+	}
+}
+
+// removeVersion removes the files of version from archive.
+func removeVersion(archive, version string) {
+	for _, kind := range versionKinds {
+		path := filepath.Join(archive, versionFile(kind, version))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			slog.Warn("Failed to remove certificate version", "path", path, "error", err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Versioned Certificate Directory Tests
+//
+// Unit tests for archiving each issuance, the live symlinks, pruning, and
+// restoring the live symlinks on rollback.
+// -------------------------------------------------------------------------------
+
+package cert
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestManager_Versioned verifies each issuance is archived with relative
+// live symlinks to the newest, old versions are pruned, and a rollback
+// points the live symlinks back at the restored version.
+func TestManager_Versioned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	versionedDir := filepath.Join(tmpDir, "versions")
+	mockClient := vault.NewMockClient(ctrl)
+	manager := NewManager(mockClient)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "example.com",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+		TTL:         24 * time.Hour,
+		Versioned:   &config.VersionedConfig{Dir: versionedDir, Keep: 2},
+		Backup:      &config.BackupConfig{Keep: 1},
+	}
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	issued := make([]*vault.CertificateData, 4)
+	for i := range issued {
+		issued[i] = vault.CreateTestCertificateData()
+	}
+	gomock.InOrder(
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(issued[0], nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(issued[1], nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(issued[2], nil),
+		mockClient.EXPECT().IssueCertificate(certConfig).Return(issued[3], nil),
+	)
+
+	for i := 0; i < 3; i++ {
+		if err := manager.ForceRotate("web"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	live := filepath.Join(versionedDir, "live", "web")
+	archive := filepath.Join(versionedDir, "archive", "web")
+	target, err := os.Readlink(filepath.Join(live, "cert.pem"))
+	if err != nil {
+		t.Fatalf("failed to read live symlink: %v", err)
+	}
+	if !strings.HasPrefix(target, filepath.Join("..", "..", "archive", "web", "cert-")) {
+		t.Errorf("expected a relative link into the archive, got %s", target)
+	}
+	for kind, want := range map[string]string{
+		"cert":      issued[2].Certificate,
+		"chain":     issued[2].CertificateChain,
+		"fullchain": issued[2].Certificate + "\n" + issued[2].CertificateChain,
+		"privkey":   issued[2].PrivateKey,
+	} {
+		data, err := os.ReadFile(filepath.Join(live, kind+".pem"))
+		if err != nil {
+			t.Fatalf("failed to read live %s: %v", kind, err)
+		}
+		if string(data) != want {
+			t.Errorf("expected live %s to hold the newest version", kind)
+		}
+	}
+	if versions := listVersions(archive); len(versions) != 2 {
+		t.Fatalf("expected 2 versions kept, got %v", versions)
+	}
+
+	// A failed on_change rolls back and restores the live symlinks.
+	certConfig.OnChange = "exit 1"
+	if err := manager.ForceRotate("web"); err == nil {
+		t.Fatal("expected rollback error")
+	}
+	data, err := os.ReadFile(filepath.Join(live, "cert.pem"))
+	if err != nil {
+		t.Fatalf("failed to read live certificate: %v", err)
+	}
+	if string(data) != issued[2].Certificate {
+		t.Error("expected live symlinks to point at the restored version")
+	}
+	// The oldest version was pruned before the rollback.
+	if versions := listVersions(archive); len(versions) != 1 {
+		t.Errorf("expected the rolled back version to be removed, got %v", versions)
+	}
+}
@@ -0,0 +1,185 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fault Injection
+//
+// Chaos/testing mode that randomly fails or delays certificate issuance,
+// disk writes, and health checks with configurable probabilities. Used in
+// staging to validate alerting, retry, and rollback behavior without
+// breaking a real Vault cluster.
+// -------------------------------------------------------------------------------
+
+// Package chaos provides fault injection for testing failure handling.
+package chaos
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/health"
+	"cert-manager/pkg/vault"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// Operation names used for fault injection.
+const (
+	OpIssue       = "issue"
+	OpWrite       = "write"
+	OpHealthCheck = "health_check"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Injector decides whether an operation should be delayed or failed.
+type Injector struct {
+	config *config.ChaosConfig
+	mu     sync.Mutex
+	rng    *rand.Rand
+}
+
+// client wraps a Vault client with injected issuance faults.
+type client struct {
+	inner    vault.Client
+	injector *Injector
+}
+
+// checker wraps a health checker with injected check faults.
+type checker struct {
+	inner    health.Checker
+	injector *Injector
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// New creates a fault injector from the chaos configuration.
+func New(cfg *config.ChaosConfig) *Injector {
+	return &Injector{
+		config: cfg,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// WrapClient returns a Vault client that injects issuance faults.
+func (i *Injector) WrapClient(inner vault.Client) vault.Client {
+	return &client{inner: inner, injector: i}
+}
+
+// WrapChecker returns a health checker that injects check faults.
+func (i *Injector) WrapChecker(inner health.Checker) health.Checker {
+	return &checker{inner: inner, injector: i}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Inject applies a random delay and/or failure for the named operation.
+// It satisfies cert.FaultInjector.
+func (i *Injector) Inject(op string) error {
+	if delay := i.delay(); delay > 0 {
+		slog.Warn("Chaos: delaying operation", "operation", op, "delay", delay)
+		time.Sleep(delay)
+	}
+
+	if i.roll(i.failureRate(op)) {
+		slog.Warn("Chaos: injecting failure", "operation", op)
+		return fmt.Errorf("chaos: injected %s failure", op)
+	}
+
+	return nil
+}
+
+// IssueCertificate injects faults before delegating to the wrapped client.
+func (c *client) IssueCertificate(certConfig *config.CertificateConfig) (*vault.CertificateData, error) {
+	if err := c.injector.Inject(OpIssue); err != nil {
+		return nil, err
+	}
+	return c.inner.IssueCertificate(certConfig)
+}
+
+// FetchCAChain injects issuance faults before delegating to the wrapped
+// client, so CA rotation checks see the same Vault failures as issuance.
+func (c *client) FetchCAChain(certConfig *config.CertificateConfig) (string, error) {
+	fetcher, ok := c.inner.(vault.CAChainFetcher)
+	if !ok {
+		return "", fmt.Errorf("wrapped vault client cannot read CA chains")
+	}
+	if err := c.injector.Inject(OpIssue); err != nil {
+		return "", err
+	}
+	return fetcher.FetchCAChain(certConfig)
+}
+
+// SignCertificate injects issuance faults before delegating to the wrapped
+// client.
+func (c *client) SignCertificate(certConfig *config.CertificateConfig, csrPEM string) (*vault.CertificateData, error) {
+	signer, ok := c.inner.(vault.CSRSigner)
+	if !ok {
+		return nil, fmt.Errorf("wrapped vault client cannot sign CSRs")
+	}
+	if err := c.injector.Inject(OpIssue); err != nil {
+		return nil, err
+	}
+	return signer.SignCertificate(certConfig, csrPEM)
+}
+
+// Check injects faults before delegating to the wrapped checker.
+func (c *checker) Check(managed *cert.ManagedCertificate) (*health.CheckResult, error) {
+	if err := c.injector.Inject(OpHealthCheck); err != nil {
+		return &health.CheckResult{Success: false, Error: err}, nil
+	}
+	return c.inner.Check(managed)
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// failureRate returns the configured failure probability for an operation.
+func (i *Injector) failureRate(op string) float64 {
+	switch op {
+	case OpIssue:
+		return i.config.IssueFailureRate
+	case OpWrite:
+		return i.config.WriteFailureRate
+	case OpHealthCheck:
+		return i.config.HealthCheckFailureRate
+	default:
+		return 0
+	}
+}
+
+// delay returns a random delay to apply, or zero.
+func (i *Injector) delay() time.Duration {
+	if i.config.MaxDelay <= 0 || !i.roll(i.config.DelayRate) {
+		return 0
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return time.Duration(i.rng.Int63n(int64(i.config.MaxDelay)))
+}
+
+// roll returns true with the given probability.
+func (i *Injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rng.Float64() < rate
+}
@@ -0,0 +1,92 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fault Injection Tests
+//
+// Unit tests for chaos mode fault injection.
+// -------------------------------------------------------------------------------
+
+package chaos
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/health"
+	"cert-manager/pkg/vault"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestInjector_Inject verifies failure rates of zero and one.
+func TestInjector_Inject(t *testing.T) {
+	injector := New(&config.ChaosConfig{
+		Enabled:          true,
+		IssueFailureRate: 1,
+	})
+
+	if err := injector.Inject(OpIssue); err == nil {
+		t.Error("expected injected issue failure")
+	}
+
+	if err := injector.Inject(OpWrite); err != nil {
+		t.Errorf("unexpected write failure: %v", err)
+	}
+}
+
+// TestInjector_Delay verifies delays stay within the configured maximum.
+func TestInjector_Delay(t *testing.T) {
+	injector := New(&config.ChaosConfig{
+		Enabled:   true,
+		DelayRate: 1,
+		MaxDelay:  20 * time.Millisecond,
+	})
+
+	start := time.Now()
+	if err := injector.Inject(OpIssue); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("delay exceeded max_delay: %v", elapsed)
+	}
+}
+
+// TestWrapClient verifies failures short-circuit the wrapped Vault client.
+func TestWrapClient(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := vault.NewMockClient(ctrl)
+	certConfig := &config.CertificateConfig{Name: "test-cert"}
+
+	failing := New(&config.ChaosConfig{Enabled: true, IssueFailureRate: 1}).WrapClient(mockClient)
+	if _, err := failing.IssueCertificate(certConfig); err == nil {
+		t.Error("expected injected failure")
+	}
+
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	passing := New(&config.ChaosConfig{Enabled: true}).WrapClient(mockClient)
+	if _, err := passing.IssueCertificate(certConfig); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestWrapChecker verifies injected health check failures are reported as results.
+func TestWrapChecker(t *testing.T) {
+	checker := New(&config.ChaosConfig{Enabled: true, HealthCheckFailureRate: 1}).WrapChecker(health.NewTCPChecker())
+
+	result, err := checker.Check(&cert.ManagedCertificate{Config: &config.CertificateConfig{Name: "test-cert"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Error("expected failed health check result")
+	}
+}
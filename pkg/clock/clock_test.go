@@ -0,0 +1,41 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Time Source Tests
+//
+// Unit tests for the manually advanced fake clock.
+// -------------------------------------------------------------------------------
+
+package clock
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestFake verifies the fake clock only moves when advanced or set.
+func TestFake(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	if !fake.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, fake.Now())
+	}
+
+	fake.Advance(time.Hour)
+	if want := start.Add(time.Hour); !fake.Now().Equal(want) {
+		t.Errorf("expected %v after Advance, got %v", want, fake.Now())
+	}
+
+	later := start.Add(48 * time.Hour)
+	fake.Set(later)
+	if !fake.Now().Equal(later) {
+		t.Errorf("expected %v after Set, got %v", later, fake.Now())
+	}
+}
@@ -0,0 +1,242 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Cloud Instance Metadata
+//
+// Looks up the node's instance ID, zone, region, and project or account from
+// the GCE, EC2, or Azure instance metadata service, so status APIs, metrics,
+// and the aggregator can map nodes to real infrastructure.
+// -------------------------------------------------------------------------------
+
+// Package cloud identifies the cloud instance vault-cert-manager runs on.
+package cloud
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// Metadata service endpoints, overridden in tests.
+var (
+	gceEndpoint   = "http://metadata.google.internal/computeMetadata/v1"
+	ec2Endpoint   = "http://169.254.169.254/latest"
+	azureEndpoint = "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Identity describes the cloud instance a node runs on.
+type Identity struct {
+	Provider   string `json:"provider"`
+	InstanceID string `json:"instance_id,omitempty"`
+	Zone       string `json:"zone,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Account    string `json:"account,omitempty"` // GCE project, AWS account, or Azure subscription
+}
+
+// detector queries one provider's metadata service.
+type detector func(ctx context.Context, client *http.Client) (*Identity, error)
+
+// -------------------------------------------------------------------------
+// FUNCTIONS
+// -------------------------------------------------------------------------
+
+// Detect returns the instance identity from provider ("gce", "ec2", or
+// "azure"), or from whichever provider answers first for "auto". ctx bounds
+// the lookup, so non-cloud hosts fail fast.
+func Detect(ctx context.Context, provider string) (*Identity, error) {
+	detectors := map[string]detector{
+		"gce":   detectGCE,
+		"ec2":   detectEC2,
+		"azure": detectAzure,
+	}
+
+	client := &http.Client{}
+	if provider != "auto" {
+		detect, ok := detectors[provider]
+		if !ok {
+			return nil, fmt.Errorf("unknown cloud provider %q", provider)
+		}
+		return detect(ctx, client)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		identity *Identity
+		err      error
+	}
+	results := make(chan result, len(detectors))
+	for _, detect := range detectors {
+		go func(detect detector) {
+			identity, err := detect(ctx, client)
+			results <- result{identity, err}
+		}(detect)
+	}
+
+	var errs []error
+	for range detectors {
+		r := <-results
+		if r.err == nil {
+			return r.identity, nil
+		}
+		errs = append(errs, r.err)
+	}
+	return nil, fmt.Errorf("no cloud metadata service found: %w", errors.Join(errs...))
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Labels returns the identity as cloud_* label pairs, omitting empty values.
+func (i *Identity) Labels() map[string]string {
+	labels := map[string]string{"cloud_provider": i.Provider}
+	for name, value := range map[string]string{
+		"cloud_instance_id": i.InstanceID,
+		"cloud_zone":        i.Zone,
+		"cloud_region":      i.Region,
+		"cloud_account":     i.Account,
+	} {
+		if value != "" {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// -------------------------------------------------------------------------
+// PROVIDERS
+// -------------------------------------------------------------------------
+
+// detectGCE reads the GCE metadata server.
+func detectGCE(ctx context.Context, client *http.Client) (*Identity, error) {
+	header := http.Header{"Metadata-Flavor": []string{"Google"}}
+
+	get := func(path string) (string, error) {
+		body, err := fetch(ctx, client, http.MethodGet, gceEndpoint+path, header)
+		return string(body), err
+	}
+
+	id, err := get("/instance/id")
+	if err != nil {
+		return nil, fmt.Errorf("gce: %w", err)
+	}
+	zone, err := get("/instance/zone") // projects/<number>/zones/<zone>
+	if err != nil {
+		return nil, fmt.Errorf("gce: %w", err)
+	}
+	project, err := get("/project/project-id")
+	if err != nil {
+		return nil, fmt.Errorf("gce: %w", err)
+	}
+
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+
+	return &Identity{Provider: "gce", InstanceID: id, Zone: zone, Region: region, Account: project}, nil
+}
+
+// detectEC2 reads the EC2 instance identity document using IMDSv2.
+func detectEC2(ctx context.Context, client *http.Client) (*Identity, error) {
+	token, err := fetch(ctx, client, http.MethodPut, ec2Endpoint+"/api/token",
+		http.Header{"X-Aws-Ec2-Metadata-Token-Ttl-Seconds": []string{"60"}})
+	if err != nil {
+		return nil, fmt.Errorf("ec2: %w", err)
+	}
+
+	body, err := fetch(ctx, client, http.MethodGet, ec2Endpoint+"/dynamic/instance-identity/document",
+		http.Header{"X-Aws-Ec2-Metadata-Token": []string{string(token)}})
+	if err != nil {
+		return nil, fmt.Errorf("ec2: %w", err)
+	}
+
+	var doc struct {
+		InstanceID       string `json:"instanceId"`
+		AvailabilityZone string `json:"availabilityZone"`
+		Region           string `json:"region"`
+		AccountID        string `json:"accountId"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("ec2: failed to parse identity document: %w", err)
+	}
+
+	return &Identity{
+		Provider:   "ec2",
+		InstanceID: doc.InstanceID,
+		Zone:       doc.AvailabilityZone,
+		Region:     doc.Region,
+		Account:    doc.AccountID,
+	}, nil
+}
+
+// detectAzure reads the Azure Instance Metadata Service.
+func detectAzure(ctx context.Context, client *http.Client) (*Identity, error) {
+	body, err := fetch(ctx, client, http.MethodGet, azureEndpoint, http.Header{"Metadata": []string{"true"}})
+	if err != nil {
+		return nil, fmt.Errorf("azure: %w", err)
+	}
+
+	var compute struct {
+		VMID           string `json:"vmId"`
+		Zone           string `json:"zone"`
+		Location       string `json:"location"`
+		SubscriptionID string `json:"subscriptionId"`
+	}
+	if err := json.Unmarshal(body, &compute); err != nil {
+		return nil, fmt.Errorf("azure: failed to parse compute metadata: %w", err)
+	}
+
+	return &Identity{
+		Provider:   "azure",
+		InstanceID: compute.VMID,
+		Zone:       compute.Zone,
+		Region:     compute.Location,
+		Account:    compute.SubscriptionID,
+	}, nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// fetch performs a metadata request and returns the trimmed body.
+func fetch(ctx context.Context, client *http.Client, method, url string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header = header
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s: status %d", method, url, resp.StatusCode)
+	}
+	return []byte(strings.TrimSpace(string(body))), nil
+}
@@ -0,0 +1,163 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Cloud Instance Metadata Tests
+//
+// Unit tests for metadata service detection against fake GCE, EC2, and
+// Azure endpoints.
+// -------------------------------------------------------------------------------
+
+package cloud
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestDetect_GCE verifies zone and region parsing from the GCE metadata server.
+func TestDetect_GCE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/instance/id":
+			_, _ = w.Write([]byte("1234567890"))
+		case "/instance/zone":
+			_, _ = w.Write([]byte("projects/42/zones/us-central1-a"))
+		case "/project/project-id":
+			_, _ = w.Write([]byte("my-project"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	setEndpoint(t, &gceEndpoint, server.URL)
+
+	identity, err := Detect(context.Background(), "gce")
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	expected := Identity{Provider: "gce", InstanceID: "1234567890", Zone: "us-central1-a", Region: "us-central1", Account: "my-project"}
+	if *identity != expected {
+		t.Errorf("expected %+v, got %+v", expected, *identity)
+	}
+}
+
+// TestDetect_EC2 verifies the IMDSv2 token is used to read the identity document.
+func TestDetect_EC2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/api/token":
+			_, _ = w.Write([]byte("token"))
+		case r.URL.Path == "/dynamic/instance-identity/document" && r.Header.Get("X-Aws-Ec2-Metadata-Token") == "token":
+			_, _ = w.Write([]byte(`{"instanceId":"i-0abc","availabilityZone":"us-east-1b","region":"us-east-1","accountId":"111122223333"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	setEndpoint(t, &ec2Endpoint, server.URL)
+
+	identity, err := Detect(context.Background(), "ec2")
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	expected := Identity{Provider: "ec2", InstanceID: "i-0abc", Zone: "us-east-1b", Region: "us-east-1", Account: "111122223333"}
+	if *identity != expected {
+		t.Errorf("expected %+v, got %+v", expected, *identity)
+	}
+}
+
+// TestDetect_Auto verifies auto mode returns the provider that answers.
+func TestDetect_Auto(t *testing.T) {
+	azure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"vmId":"vm-1","zone":"2","location":"westeurope","subscriptionId":"sub-1"}`))
+	}))
+	defer azure.Close()
+	missing := httptest.NewServer(http.NotFoundHandler())
+	defer missing.Close()
+
+	setEndpoint(t, &azureEndpoint, azure.URL)
+	setEndpoint(t, &gceEndpoint, missing.URL)
+	setEndpoint(t, &ec2Endpoint, missing.URL)
+
+	identity, err := Detect(context.Background(), "auto")
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+	if identity.Provider != "azure" || identity.Region != "westeurope" || identity.Account != "sub-1" {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+}
+
+// TestDetect_NoMetadata verifies detection fails within the context deadline
+// when no metadata service answers.
+func TestDetect_NoMetadata(t *testing.T) {
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer hang.Close()
+
+	setEndpoint(t, &gceEndpoint, hang.URL)
+	setEndpoint(t, &ec2Endpoint, hang.URL)
+	setEndpoint(t, &azureEndpoint, hang.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := Detect(ctx, "auto"); err == nil {
+		t.Fatal("expected error when no metadata service answers")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("detection took %v, expected it to respect the deadline", elapsed)
+	}
+
+	if _, err := Detect(context.Background(), "openstack"); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+// TestIdentity_Labels verifies empty fields are omitted from labels.
+func TestIdentity_Labels(t *testing.T) {
+	labels := (&Identity{Provider: "ec2", InstanceID: "i-0abc", Region: "us-east-1"}).Labels()
+
+	expected := map[string]string{"cloud_provider": "ec2", "cloud_instance_id": "i-0abc", "cloud_region": "us-east-1"}
+	if len(labels) != len(expected) {
+		t.Fatalf("expected labels %v, got %v", expected, labels)
+	}
+	for k, v := range expected {
+		if labels[k] != v {
+			t.Errorf("expected label %s=%q, got %q", k, v, labels[k])
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// setEndpoint points a metadata endpoint at url for the duration of a test.
+func setEndpoint(t *testing.T, endpoint *string, url string) {
+	t.Helper()
+	original := *endpoint
+	*endpoint = url
+	t.Cleanup(func() { *endpoint = original })
+}
@@ -14,32 +14,133 @@ package config
 // -------------------------------------------------------------------------
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// ShadowSuffix is appended to the file paths of shadow certificates.
+const ShadowSuffix = ".shadow"
+
+// Certificate policies, deciding which of issuance and renewal the manager
+// does on its own.
+const (
+	PolicyManage         = "manage"           // issue when missing and renew when due
+	PolicyIssueIfMissing = "issue_if_missing" // issue when missing, never renew
+	PolicyRenewOnly      = "renew_only"       // renew when due, never create the files
+)
+
 // -------------------------------------------------------------------------
 // TYPES
 // -------------------------------------------------------------------------
 
 // Config represents the complete application configuration.
 type Config struct {
-	Vault        VaultConfig         `yaml:"vault"`
-	Prometheus   PrometheusConfig    `yaml:"prometheus"`
-	Logging      LoggingConfig       `yaml:"logging"`
-	Certificates []CertificateConfig `yaml:"certificates"`
+	Vault         VaultConfig         `yaml:"vault"`
+	Prometheus    PrometheusConfig    `yaml:"prometheus"`
+	Logging       LoggingConfig       `yaml:"logging"`
+	Chaos         ChaosConfig         `yaml:"chaos,omitempty"`
+	Notifications NotificationsConfig `yaml:"notifications,omitempty"`
+	Source        SourceConfig        `yaml:"source,omitempty"`
+	ACME          *ACMEConfig         `yaml:"acme,omitempty"`
+	API           APIConfig           `yaml:"api,omitempty"`
+	Web           WebConfig           `yaml:"web,omitempty"`
+	Tracing       TracingConfig       `yaml:"tracing,omitempty"`
+	StatsD        *StatsDConfig       `yaml:"statsd,omitempty"`
+	Renewal       RenewalConfig       `yaml:"renewal,omitempty"`
+	Node          NodeConfig          `yaml:"node,omitempty"`
+	Watch         WatchConfig         `yaml:"watch,omitempty"`
+	Integrity     IntegrityConfig     `yaml:"integrity,omitempty"`
+	Certificates  []CertificateConfig `yaml:"certificates"`
+
+	// Trust verifies documents from the central source. It is set from
+	// --trust-key and --strict-trust rather than the config files.
+	Trust SourceTrust `yaml:"-"`
+}
+
+// ACMEConfig holds the ACME account used by certificates with source
+// "acme", e.g. Let's Encrypt or a Vault PKI mount's ACME directory.
+type ACMEConfig struct {
+	DirectoryURL   string   `yaml:"directory_url,omitempty"` // default: Let's Encrypt production
+	Email          string   `yaml:"email,omitempty"`
+	AccountKeyFile string   `yaml:"account_key_file"` // created on first use
+	EAB            *ACMEEAB `yaml:"eab,omitempty"`
+
+	HTTP01 *HTTP01Config `yaml:"http01,omitempty"`
+	DNS01  *DNS01Config  `yaml:"dns01,omitempty"`
+}
+
+// ACMEEAB holds external account binding credentials, required by some
+// ACME servers (including Vault) to register an account.
+type ACMEEAB struct {
+	KeyID   string `yaml:"key_id"`
+	HMACKey string `yaml:"hmac_key"` // base64url encoded
+}
+
+// HTTP01Config solves http-01 challenges, either by serving them on a
+// listen address or by writing them beneath a web server's root.
+type HTTP01Config struct {
+	Listen  string `yaml:"listen,omitempty"`  // default: ":80" when webroot is unset
+	Webroot string `yaml:"webroot,omitempty"` // tokens written to <webroot>/.well-known/acme-challenge
+}
+
+// DNS01Config solves dns-01 challenges with a hook command invoked as
+// "<command> present|cleanup <fqdn> <value>".
+type DNS01Config struct {
+	Command          string        `yaml:"command"`
+	PropagationDelay time.Duration `yaml:"propagation_delay,omitempty"` // wait after present (default: 30s)
+}
+
+// SourceConfig selects a central store for additional certificate
+// definitions, merged with the locally configured certificates.
+type SourceConfig struct {
+	VaultKV  *VaultKVSource  `yaml:"vault_kv,omitempty"`
+	ConsulKV *ConsulKVSource `yaml:"consul_kv,omitempty"`
+}
+
+// VaultKVSource loads certificate definitions from a Vault KV secret. The
+// secret's "certificates" field holds either a YAML document or a list.
+type VaultKVSource struct {
+	Mount           string        `yaml:"mount,omitempty"` // default: "secret"
+	Path            string        `yaml:"path"`
+	Version         int           `yaml:"version,omitempty"`          // KV engine version, 1 or 2 (default: 2)
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"` // default: 5m
 }
 
 // VaultConfig holds Vault server connection settings.
 type VaultConfig struct {
-	Address  string     `yaml:"address"`
-	PKIMount string     `yaml:"pki_mount,omitempty"`
-	Auth     AuthConfig `yaml:"auth"`
+	Address   string      `yaml:"address"`
+	Addresses []string    `yaml:"addresses,omitempty"` // failover addresses tried after address
+	PKIMount  string      `yaml:"pki_mount,omitempty"`
+	Auth      AuthConfig  `yaml:"auth"`
+	Retry     RetryConfig `yaml:"retry,omitempty"`
+
+	// CACheckInterval is how often the PKI mount's CA chain is compared
+	// against the chain on disk to detect issuing CA rotation.
+	CACheckInterval time.Duration `yaml:"ca_check_interval,omitempty"` // default: 1h
+}
+
+// RetryConfig holds the retry policy for Vault operations.
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts,omitempty"`
+	BaseBackoff time.Duration `yaml:"base_backoff,omitempty"`
+	MaxBackoff  time.Duration `yaml:"max_backoff,omitempty"`
+	Jitter      float64       `yaml:"jitter,omitempty"` // fraction of backoff randomized, 0-1
 }
 
 // AuthConfig holds authentication method configuration.
@@ -79,12 +180,33 @@ type AppRoleAuth struct {
 	RoleID       string `yaml:"role_id"`
 	SecretID     string `yaml:"secret_id,omitempty"`
 	SecretIDFile string `yaml:"secret_id_file,omitempty"`
+
+	// SecretIDWrappedFile holds a response-wrapping token for the secret_id.
+	SecretIDWrappedFile string `yaml:"secret_id_wrapped_file,omitempty"`
+	DeleteWrappedFile   bool   `yaml:"delete_wrapped_file,omitempty"`
 }
 
 // PrometheusConfig holds Prometheus metrics server settings.
 type PrometheusConfig struct {
 	Port            int           `yaml:"port"`
 	RefreshInterval time.Duration `yaml:"refresh_interval"`
+
+	// MetadataLabels lists certificate metadata keys exported as labels
+	// on managed_cert_info. Keys not listed are never exposed as labels.
+	MetadataLabels []string `yaml:"metadata_labels,omitempty"`
+
+	// Pushgateway receives the metrics of one-shot (--rotate) runs, which
+	// exit before they can be scraped.
+	Pushgateway *PushgatewayConfig `yaml:"pushgateway,omitempty"`
+}
+
+// PushgatewayConfig identifies the Prometheus Pushgateway one-shot runs
+// push to.
+type PushgatewayConfig struct {
+	URL      string        `yaml:"url"`
+	Job      string        `yaml:"job,omitempty"`      // default: vault-cert-manager
+	Instance string        `yaml:"instance,omitempty"` // default: hostname
+	Timeout  time.Duration `yaml:"timeout,omitempty"`  // default: 10s
 }
 
 // LoggingConfig holds logging output settings.
@@ -93,26 +215,428 @@ type LoggingConfig struct {
 	Format string `yaml:"format"`
 }
 
+// ChaosConfig holds fault-injection settings for testing alerting and recovery.
+type ChaosConfig struct {
+	Enabled                bool          `yaml:"enabled"`
+	IssueFailureRate       float64       `yaml:"issue_failure_rate,omitempty"`
+	WriteFailureRate       float64       `yaml:"write_failure_rate,omitempty"`
+	HealthCheckFailureRate float64       `yaml:"health_check_failure_rate,omitempty"`
+	DelayRate              float64       `yaml:"delay_rate,omitempty"`
+	MaxDelay               time.Duration `yaml:"max_delay,omitempty"`
+}
+
+// NotificationsConfig routes rotation alerts to the owning team.
+type NotificationsConfig struct {
+	OwnerKey string                       `yaml:"owner_key,omitempty"` // metadata key naming the owner (default: "team")
+	Default  *NotificationRoute           `yaml:"default,omitempty"`   // used when no route matches the owner
+	Routes   map[string]NotificationRoute `yaml:"routes,omitempty"`    // keyed by owner value
+}
+
+// NotificationRoute holds the destinations for one owner.
+type NotificationRoute struct {
+	SlackWebhook        string `yaml:"slack_webhook,omitempty"`
+	PagerDutyRoutingKey string `yaml:"pagerduty_routing_key,omitempty"`
+}
+
+// APIConfig holds settings for the node's REST API.
+type APIConfig struct {
+	RateLimit RateLimitConfig `yaml:"rate_limit,omitempty"`
+	Auth      *APIAuthConfig  `yaml:"auth,omitempty"`
+}
+
+// WebConfig holds settings for the HTTP server that serves /metrics, the
+// dashboard, and its API. /healthz and /readyz are always served.
+type WebConfig struct {
+	Port            int    `yaml:"port,omitempty"`          // default: prometheus.port
+	TLSCertFile     string `yaml:"tls_cert_file,omitempty"` // serve HTTPS; reloaded on every handshake
+	TLSKeyFile      string `yaml:"tls_key_file,omitempty"`
+	EnableDashboard *bool  `yaml:"enable_dashboard,omitempty"` // default: true
+	EnableMetrics   *bool  `yaml:"enable_metrics,omitempty"`   // default: true
+
+	// TLSClientCAFile requires clients of the dashboard and its API, such
+	// as the aggregator, to present a certificate signed by these CAs.
+	TLSClientCAFile string `yaml:"tls_client_ca_file,omitempty"`
+}
+
+// StatsDConfig sends the Prometheus metrics to a StatsD or Datadog agent
+// as well, for fleets that are not scraped.
+type StatsDConfig struct {
+	Host   string   `yaml:"host"`
+	Port   int      `yaml:"port,omitempty"`   // default: 8125
+	Prefix string   `yaml:"prefix,omitempty"` // prepended to every metric name, e.g. "vault_cert_manager."
+	Tags   []string `yaml:"tags,omitempty"`   // added to every sample, e.g. "env:prod"
+}
+
+// TracingConfig exports spans around issuance attempts to an OpenTelemetry
+// collector over OTLP/HTTP. Tracing is disabled unless endpoint is set.
+type TracingConfig struct {
+	Endpoint    string            `yaml:"endpoint,omitempty"`     // e.g. http://otel-collector:4318/v1/traces
+	ServiceName string            `yaml:"service_name,omitempty"` // default: vault-cert-manager
+	Headers     map[string]string `yaml:"headers,omitempty"`      // e.g. an API key for a hosted backend
+	Interval    time.Duration     `yaml:"interval,omitempty"`     // export interval (default: 5s)
+	Timeout     time.Duration     `yaml:"timeout,omitempty"`      // export request timeout (default: 10s)
+}
+
+// RateLimitConfig holds the per-client token bucket applied to mutating
+// API endpoints such as rotation.
+type RateLimitConfig struct {
+	Disabled          bool    `yaml:"disabled,omitempty"`
+	RequestsPerMinute float64 `yaml:"requests_per_minute,omitempty"` // sustained rate (default: 10)
+	Burst             int     `yaml:"burst,omitempty"`               // default: 5
+}
+
+// APIAuthConfig requires basic auth, a bearer token, or a single sign-on
+// session for the rotation endpoints, and for the dashboard and read-only
+// endpoints with ProtectStatus.
+type APIAuthConfig struct {
+	Username      string   `yaml:"username,omitempty"`
+	Password      string   `yaml:"password,omitempty"`
+	Tokens        []string `yaml:"tokens,omitempty"`         // accepted as "Authorization: Bearer <token>"
+	ProtectStatus bool     `yaml:"protect_status,omitempty"` // also protect the dashboard, status, and plan
+
+	// OIDC logs dashboard users in through an OpenID Connect provider.
+	OIDC *OIDCConfig `yaml:"oidc,omitempty"`
+}
+
+// OIDCConfig configures dashboard single sign-on with an OpenID Connect
+// provider, using the authorization code flow.
+type OIDCConfig struct {
+	IssuerURL     string        `yaml:"issuer_url"`
+	ClientID      string        `yaml:"client_id"`
+	ClientSecret  string        `yaml:"client_secret"`
+	RedirectURL   string        `yaml:"redirect_url"`             // this server's /auth/callback, e.g. https://node1:9101/auth/callback
+	AllowedGroups []string      `yaml:"allowed_groups,omitempty"` // default: any user the provider authenticates
+	GroupsClaim   string        `yaml:"groups_claim,omitempty"`   // default: groups
+	Scopes        []string      `yaml:"scopes,omitempty"`         // default: openid, profile, email
+	SessionTTL    time.Duration `yaml:"session_ttl,omitempty"`    // default: 8h
+}
+
+// NodeConfig holds settings describing the node itself.
+type NodeConfig struct {
+	// CloudMetadata enriches node identity from the instance metadata
+	// service: "auto", "gce", "ec2", or "azure" (default: disabled).
+	CloudMetadata        string        `yaml:"cloud_metadata,omitempty"`
+	CloudMetadataTimeout time.Duration `yaml:"cloud_metadata_timeout,omitempty"` // default: 2s
+}
+
+// RenewalConfig holds renewal scheduling settings.
+type RenewalConfig struct {
+	Adaptive      *AdaptiveRenewalConfig `yaml:"adaptive,omitempty"`
+	MaxParallel   int                    `yaml:"max_parallel,omitempty"`   // certificates processed at once (default: 1)
+	CheckInterval time.Duration          `yaml:"check_interval,omitempty"` // longest wait between passes (default: 10m)
+	StateFile     string                 `yaml:"state_file,omitempty"`     // renewal state kept across restarts (default: disabled)
+}
+
+// WatchConfig lists certificates that are only monitored, not managed:
+// PEM or DER files matched by glob patterns and TLS endpoints whose served
+// certificate is read. Their expiry is exported as metrics and shown on the
+// dashboard.
+type WatchConfig struct {
+	Files     []string      `yaml:"files,omitempty"`     // glob patterns
+	Endpoints []string      `yaml:"endpoints,omitempty"` // host:port, SNI is the host
+	Interval  time.Duration `yaml:"interval,omitempty"`  // default: 5m
+	Timeout   time.Duration `yaml:"timeout,omitempty"`   // per endpoint (default: 5s)
+}
+
+// Percent is a fraction written in YAML either as a number (0.66) or as a
+// percentage ("66%").
+type Percent float64
+
+// IntegrityConfig watches deployed certificate files for changes made
+// outside the manager, e.g. by an operator or a config management tool.
+type IntegrityConfig struct {
+	Watch    bool          `yaml:"watch,omitempty"`
+	Reissue  bool          `yaml:"reissue,omitempty"`  // reissue certificates whose files drifted
+	Debounce time.Duration `yaml:"debounce,omitempty"` // quiet period before checking changes (default: 2s)
+}
+
+// AdaptiveRenewalConfig defers non-urgent renewals while Vault is
+// degraded, judged from the error rate and mean latency of Vault requests
+// made since the previous processing pass.
+type AdaptiveRenewalConfig struct {
+	ErrorRate   float64       `yaml:"error_rate,omitempty"`   // fraction of failed requests (default: 0.2)
+	Latency     time.Duration `yaml:"latency,omitempty"`      // mean request latency (default: 2s)
+	MinRequests int           `yaml:"min_requests,omitempty"` // requests needed to judge a pass (default: 3)
+	Backoff     time.Duration `yaml:"backoff,omitempty"`      // how long renewals stay deferred (default: 10m)
+}
+
+// ConsulKVSource loads certificate definitions from a Consul KV key holding
+// a YAML or JSON document, watched with blocking queries.
+type ConsulKVSource struct {
+	Address string        `yaml:"address,omitempty"` // default: http://127.0.0.1:8500
+	Key     string        `yaml:"key"`
+	Token   string        `yaml:"token,omitempty"`
+	Wait    time.Duration `yaml:"wait,omitempty"` // blocking query wait (default: 5m)
+}
+
 // CertificateConfig holds settings for a managed certificate.
 type CertificateConfig struct {
-	Name        string        `yaml:"name"`
-	Role        string        `yaml:"role"`
-	CommonName  string        `yaml:"common_name"`
-	Certificate string        `yaml:"certificate"`
-	Key         string        `yaml:"key"`
-	TTL         time.Duration `yaml:"ttl"`
-	AltNames    []string      `yaml:"alt_names,omitempty"`
-	IPSans      []string      `yaml:"ip_sans,omitempty"`
-	OnChange    string        `yaml:"on_change,omitempty"`
-	HealthCheck *HealthCheck  `yaml:"health_check,omitempty"`
-	Owner       string        `yaml:"owner,omitempty"`
-	Group       string        `yaml:"group,omitempty"`
+	Name           string        `yaml:"name"`
+	Role           string        `yaml:"role"`
+	CommonName     string        `yaml:"common_name"`
+	Certificate    string        `yaml:"certificate"`
+	Key            string        `yaml:"key"`
+	TTL            time.Duration `yaml:"ttl"`
+	RenewBefore    time.Duration `yaml:"renew_before,omitempty"`     // renew this long before expiry
+	RenewAtPercent Percent       `yaml:"renew_at_percent,omitempty"` // renew once this fraction of the lifetime has passed
+	AltNames       []string      `yaml:"alt_names,omitempty"`
+	IPSans         []string      `yaml:"ip_sans,omitempty"`
+	URISans        []string      `yaml:"uri_sans,omitempty"`
+	OtherSans      []string      `yaml:"other_sans,omitempty"`    // "<oid>;UTF8:<value>"
+	CAFile         string        `yaml:"ca_file,omitempty"`       // write the CA chain to its own file
+	ExcludeChain   bool          `yaml:"exclude_chain,omitempty"` // do not append the CA chain to the certificate file
+	OnChange       string        `yaml:"on_change,omitempty"`
+	PreChange      string        `yaml:"pre_change,omitempty"` // must succeed against the staged files before they replace the current ones
+	HealthCheck    *HealthCheck  `yaml:"health_check,omitempty"`
+	Shadow         bool          `yaml:"shadow,omitempty"` // write <path>.shadow files and never run on_change
+	Policy         string        `yaml:"policy,omitempty"` // "manage" (default), "issue_if_missing", or "renew_only"
+	Owner          string        `yaml:"owner,omitempty"`
+	Group          string        `yaml:"group,omitempty"`
+	CertMode       string        `yaml:"cert_mode,omitempty"` // octal mode of certificate, ca_file, and truststore files (default: 0644)
+	KeyMode        string        `yaml:"key_mode,omitempty"`  // octal mode of key, combined, and keystore files (default: 0600)
+	DirMode        string        `yaml:"dir_mode,omitempty"`  // octal mode of directories created for them (default: 0755)
+
+	// Subject fields requested alongside the common name, for roles that
+	// require them to be populated.
+	OU           []string `yaml:"ou,omitempty"`
+	Organization []string `yaml:"organization,omitempty"`
+	Country      []string `yaml:"country,omitempty"`
+	Locality     []string `yaml:"locality,omitempty"`
+	PostalCode   []string `yaml:"postal_code,omitempty"`
+
+	// OnChangeAction reloads the service with a built-in action instead
+	// of an on_change shell command.
+	OnChangeAction *ActionConfig `yaml:"on_change_action,omitempty"`
+
+	// OnChangeSandbox restricts the environment on_change runs in.
+	OnChangeSandbox *SandboxConfig `yaml:"on_change_sandbox,omitempty"`
+
+	// OnChangePolicy bounds how long on_change may run and retries it
+	// when it fails.
+	OnChangePolicy HookPolicy `yaml:"on_change_policy,omitempty"`
+
+	// Backup keeps the previous certificate files and restores them when
+	// on_change fails or health_check never sees the new certificate.
+	Backup *BackupConfig `yaml:"backup,omitempty"`
+
+	// Versioned also keeps every issuance in a certbot-style directory:
+	// archive/<name>/ holds each version's files and live/<name>/ symlinks
+	// to the newest.
+	Versioned *VersionedConfig `yaml:"versioned,omitempty"`
+
+	// Keystore also writes the certificate as a Java KeyStore for JVM
+	// services that cannot read PEM.
+	Keystore *KeystoreConfig `yaml:"keystore,omitempty"`
+
+	// Outputs writes additional files with an explicit layout, each with
+	// its own mode and ownership, e.g. a leaf-only file for one service and
+	// a fullchain file for another.
+	Outputs []OutputConfig `yaml:"outputs,omitempty"`
+
+	// Vault overrides the global Vault connection for this certificate.
+	// Auth and retry settings are inherited from the global config when unset.
+	Vault *VaultConfig `yaml:"vault,omitempty"`
+
+	// IssuerRef pins issuance to one issuer of a multi-issuer PKI mount
+	// (Vault 1.11+), by issuer name or ID.
+	IssuerRef string `yaml:"issuer_ref,omitempty"`
+
+	KeyType          string `yaml:"key_type,omitempty"`           // "rsa", "ec", or "ed25519"
+	KeyBits          int    `yaml:"key_bits,omitempty"`           // e.g. 2048/4096 (rsa), 256/384 (ec)
+	PrivateKeyFormat string `yaml:"private_key_format,omitempty"` // "pem" or "pkcs8"
+	Encoding         string `yaml:"encoding,omitempty"`           // file encoding, "pem" or "der" (default: pem)
+
+	// KeyEncryption writes private keys encrypted with a passphrase, for
+	// applications that only accept encrypted keys.
+	KeyEncryption *KeyEncryptionConfig `yaml:"key_encryption,omitempty"`
+
+	// ReusePrivateKey renews by having Vault sign a CSR for the key on
+	// disk instead of issuing a fresh key, for pinned keys and hardware
+	// clients. The first issuance still gets a key from Vault.
+	ReusePrivateKey bool `yaml:"reuse_private_key,omitempty"`
+
+	// Metadata holds arbitrary ownership details (team, service, ticket)
+	// passed through to status APIs and whitelisted metric labels.
+	Metadata map[string]string `yaml:"metadata,omitempty"`
+
+	// Labels are added to every metric of the certificate and returned in
+	// /api/status, so alerts can be routed by owner.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Source selects where certificate material comes from: "pki" issues
+	// from the PKI mount, "kv" deploys a pre-issued certificate from KV,
+	// "acme" orders from the configured ACME directory, and "ca_bundle"
+	// only syncs the PKI mount's CA chain to the certificate file.
+	Source   string              `yaml:"source,omitempty"` // default: "pki"
+	KV       *CertKVSource       `yaml:"kv,omitempty"`
+	ACME     *CertACMEConfig     `yaml:"acme,omitempty"`
+	CABundle *CertCABundleSource `yaml:"ca_bundle,omitempty"`
+}
+
+// KeyEncryptionConfig encrypts written private keys as PKCS#8 with
+// AES-256-CBC under a key derived from the passphrase with PBKDF2.
+type KeyEncryptionConfig struct {
+	PassphraseFile string `yaml:"passphrase_file,omitempty"` // read at write time, trailing newline trimmed
+	PassphraseEnv  string `yaml:"passphrase_env,omitempty"`  // environment variable holding the passphrase
+}
+
+// CertACMEConfig holds per-certificate ACME settings.
+type CertACMEConfig struct {
+	Challenge string `yaml:"challenge,omitempty"` // "http-01" or "dns-01" (default: http-01 if configured)
+}
+
+// CertKVSource locates a pre-issued certificate in a KV v2 secret with
+// "certificate", "private_key", and optional "ca_chain" fields.
+type CertKVSource struct {
+	Mount           string        `yaml:"mount,omitempty"` // default: "secret"
+	Path            string        `yaml:"path"`
+	Version         int           `yaml:"version,omitempty"`          // pin a secret version (default: latest)
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"` // default: 5m
+}
+
+// CertCABundleSource configures a certificate entry that only distributes
+// the PKI mount's CA chain, for hosts that need to trust the CA but hold no
+// certificate of their own.
+type CertCABundleSource struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"` // default: 1h
+}
+
+// SandboxConfig limits what a hook command can reach. Landlock, seccomp,
+// and no_network are only available on Linux.
+type SandboxConfig struct {
+	MinimalEnv bool            `yaml:"minimal_env,omitempty"` // pass only PATH and env
+	Env        []string        `yaml:"env,omitempty"`         // variable names kept with minimal_env
+	NoNetwork  bool            `yaml:"no_network,omitempty"`  // run in an empty network namespace
+	Seccomp    bool            `yaml:"seccomp,omitempty"`     // deny privileged syscalls (mount, ptrace, module loading, ...)
+	Landlock   *LandlockConfig `yaml:"landlock,omitempty"`
+}
+
+// LandlockConfig lists the only paths a sandboxed hook may access.
+type LandlockConfig struct {
+	ReadOnly  []string `yaml:"read_only,omitempty"`  // read and execute
+	ReadWrite []string `yaml:"read_write,omitempty"` // full access
+}
+
+// ActionConfig is a built-in reload action. Exactly one action is set.
+type ActionConfig struct {
+	Systemd     string        `yaml:"systemd,omitempty"`      // unit reloaded or restarted over D-Bus
+	SystemdMode string        `yaml:"systemd_mode,omitempty"` // "reload" (default), "restart", or "reload-or-restart"
+	Signal      *SignalAction `yaml:"signal,omitempty"`
+	HTTP        *HTTPAction   `yaml:"http,omitempty"`
+	Docker      string        `yaml:"docker,omitempty"` // container restarted through the Docker Engine API
+}
+
+// SignalAction signals the process whose PID is in a pidfile.
+type SignalAction struct {
+	Pidfile string `yaml:"pidfile"`
+	Signal  string `yaml:"signal,omitempty"` // e.g. HUP or SIGUSR1 (default: HUP)
+}
+
+// HTTPAction calls a reload endpoint, succeeding on any 2xx status.
+type HTTPAction struct {
+	URL    string `yaml:"url"`
+	Method string `yaml:"method,omitempty"` // default: POST
+}
+
+// HookPolicy controls the timeout and retries of a hook command.
+type HookPolicy struct {
+	Timeout    time.Duration `yaml:"timeout,omitempty"`     // process group killed after this (default: 5m)
+	Retries    int           `yaml:"retries,omitempty"`     // further attempts after a failure (default: 0)
+	Backoff    time.Duration `yaml:"backoff,omitempty"`     // wait before the first retry, doubling after each (default: 5s)
+	MaxBackoff time.Duration `yaml:"max_backoff,omitempty"` // longest wait between retries (default: 1m)
+}
+
+// BackupConfig controls where previous certificate files are kept.
+type BackupConfig struct {
+	Dir           string        `yaml:"dir,omitempty"`            // versioned archive directory (default: <file>.bak)
+	Keep          int           `yaml:"keep,omitempty"`           // versions kept in dir (default: 5)
+	VerifyTimeout time.Duration `yaml:"verify_timeout,omitempty"` // how long health_check may take to see the new certificate (default: 30s)
+}
+
+// VersionedConfig controls the versioned archive/live directory.
+type VersionedConfig struct {
+	Dir  string `yaml:"dir"`            // holds archive/ and live/
+	Keep int    `yaml:"keep,omitempty"` // versions kept in archive (default: 10)
+}
+
+// KeystoreConfig controls Java KeyStore (JKS) output.
+type KeystoreConfig struct {
+	Path         string `yaml:"path"`
+	Alias        string `yaml:"alias,omitempty"` // private key entry alias (default: certificate name)
+	Password     string `yaml:"password,omitempty"`
+	PasswordFile string `yaml:"password_file,omitempty"` // read at write time, trailing newline trimmed
+	Truststore   string `yaml:"truststore,omitempty"`    // JKS with the CA chain as trusted entries
+}
+
+// OutputConfig is one additional file written for a certificate.
+type OutputConfig struct {
+	Type  string `yaml:"type"` // "leaf", "fullchain", "chain", "key", or "combined"
+	Path  string `yaml:"path"`
+	Mode  string `yaml:"mode,omitempty"`  // octal, e.g. "0640" (default: 0600 for key and combined, 0644 otherwise)
+	Owner string `yaml:"owner,omitempty"` // default: the certificate's owner
+	Group string `yaml:"group,omitempty"` // default: the certificate's group
 }
 
 // HealthCheck holds health check configuration for a certificate.
 type HealthCheck struct {
-	TCP     string        `yaml:"tcp,omitempty"`
-	Timeout time.Duration `yaml:"timeout,omitempty"`
+	TCP      string        `yaml:"tcp,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty"`
+	StartTLS string        `yaml:"starttls,omitempty"` // "smtp", "ldap", or "postgres": upgrade a plaintext connection to TLS
+
+	// Command, instead of tcp, runs a script that exits 0 when healthy
+	// and may print the served certificate as PEM or its fingerprint.
+	Command string `yaml:"command,omitempty"`
+
+	// ConfirmTimeout is how long after on_change the target may take to
+	// serve a new certificate before the rotation is marked failed. Zero
+	// confirms only rotations with backup, within its verify_timeout.
+	ConfirmTimeout time.Duration `yaml:"confirm_timeout,omitempty"`
+
+	// Verify also checks the served chain and hostname, failing the check
+	// as untrusted if they do not verify.
+	Verify     bool   `yaml:"verify,omitempty"`
+	CAFile     string `yaml:"ca_file,omitempty"`     // roots to verify against (default: system roots)
+	ServerName string `yaml:"server_name,omitempty"` // SNI and name to verify (default: host of tcp)
+
+	// ClientAuth presents a client certificate to services that require
+	// mTLS, by default the managed certificate and key.
+	ClientAuth        bool   `yaml:"client_auth,omitempty"`
+	ClientCertificate string `yaml:"client_certificate,omitempty"` // PEM client certificate (default: the managed certificate)
+	ClientKey         string `yaml:"client_key,omitempty"`         // PEM client key (default: the managed key)
+
+	// Remediate reloads the service when it keeps serving a certificate
+	// other than the one on disk.
+	Remediate *RemediateConfig `yaml:"remediate,omitempty"`
+}
+
+// RemediateConfig controls reloading services found out of sync.
+type RemediateConfig struct {
+	After   time.Duration `yaml:"after,omitempty"`   // how long the target may stay out of sync (default: 5m)
+	Command string        `yaml:"command,omitempty"` // run instead of on_change
+}
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// labelNamePattern matches valid Prometheus label names.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// reservedCertLabels are label names per-certificate metrics already use,
+// or that Prometheus sets on every scraped series.
+var reservedCertLabels = map[string]bool{
+	"name": true, "status": true, "reason": true, "result": true,
+	"fingerprint": true, "location": true, "destination": true, "path": true,
+	"job": true, "instance": true,
+}
+
+// otherSANPattern matches Vault's other_sans format, "<oid>;UTF8:<value>".
+var otherSANPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)+;(UTF8|UTF-8):.+$`)
+
+// validSignals are the signal names a signal action may send, without the
+// SIG prefix.
+var validSignals = map[string]bool{
+	"HUP": true, "INT": true, "QUIT": true, "TERM": true, "USR1": true, "USR2": true, "WINCH": true,
 }
 
 // -------------------------------------------------------------------------
@@ -121,6 +645,16 @@ type HealthCheck struct {
 
 // LoadConfig loads and validates configuration from a file or directory.
 func LoadConfig(path string) (*Config, error) {
+	return loadConfig(path, nil)
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE FUNCTIONS
+// -------------------------------------------------------------------------
+
+// loadConfig loads and validates configuration from a file or directory,
+// passing each file's contents to check, when set, before parsing them.
+func loadConfig(path string, check func(file string, content []byte) error) (*Config, error) {
 	stat, err := os.Stat(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat path %s: %w", path, err)
@@ -129,13 +663,13 @@ func LoadConfig(path string) (*Config, error) {
 	var configs []*Config
 
 	if stat.IsDir() {
-		dirConfigs, err := loadConfigFromDirectory(path)
+		dirConfigs, err := loadConfigFromDirectory(path, check)
 		if err != nil {
 			return nil, err
 		}
 		configs = dirConfigs
 	} else {
-		config, err := loadConfigFromFile(path)
+		config, err := loadConfigFromFile(path, check)
 		if err != nil {
 			return nil, err
 		}
@@ -149,6 +683,8 @@ func LoadConfig(path string) (*Config, error) {
 	merged := configs[0]
 	for i := 1; i < len(configs); i++ {
 		merged.Certificates = append(merged.Certificates, configs[i].Certificates...)
+		merged.Watch.Files = append(merged.Watch.Files, configs[i].Watch.Files...)
+		merged.Watch.Endpoints = append(merged.Watch.Endpoints, configs[i].Watch.Endpoints...)
 	}
 
 	if err := validateConfig(merged); err != nil {
@@ -158,47 +694,70 @@ func LoadConfig(path string) (*Config, error) {
 	return merged, nil
 }
 
-// -------------------------------------------------------------------------
-// PRIVATE FUNCTIONS
-// -------------------------------------------------------------------------
+// ParseCertificates decodes a document containing a "certificates" list and
+// validates it together with the locally configured certificates, so remote
+// definitions cannot collide with local ones. Only the remote definitions are
+// returned, with defaults applied.
+func ParseCertificates(data []byte, cfg *Config) ([]CertificateConfig, error) {
+	var doc struct {
+		Certificates []CertificateConfig `yaml:"certificates"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse certificates: %w", err)
+	}
+
+	combined := make([]CertificateConfig, 0, len(cfg.Certificates)+len(doc.Certificates))
+	combined = append(combined, cfg.Certificates...)
+	combined = append(combined, doc.Certificates...)
+
+	if err := validateCertificates(combined, &cfg.Vault); err != nil {
+		return nil, err
+	}
+	if err := validateACMECertificates(combined, cfg.ACME); err != nil {
+		return nil, err
+	}
+
+	return combined[len(cfg.Certificates):], nil
+}
 
-// loadConfigFromFile reads and parses a single YAML config file.
-func loadConfigFromFile(filename string) (*Config, error) {
+// loadConfigFromFile reads and parses a single YAML config file, checking
+// the bytes read with check when set.
+func loadConfigFromFile(filename string, check func(file string, content []byte) error) (*Config, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file %s: %w", filename, err)
 	}
+	if check != nil {
+		if err := check(filename, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return parseConfig(data, filename)
+}
 
+// parseConfig decodes YAML configuration data read from source.
+func parseConfig(data []byte, source string) (*Config, error) {
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", filename, err)
+		return nil, fmt.Errorf("failed to parse config file %s: %w", source, err)
 	}
 
 	return &config, nil
 }
 
 // loadConfigFromDirectory loads all YAML files from a directory.
-func loadConfigFromDirectory(dir string) ([]*Config, error) {
-	entries, err := os.ReadDir(dir)
+func loadConfigFromDirectory(dir string, check func(file string, content []byte) error) ([]*Config, error) {
+	files, err := configFiles(dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+		return nil, err
 	}
 
 	var configs []*Config
 	var primaryConfig *Config
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		filename := entry.Name()
-		if !strings.HasSuffix(filename, ".yml") && !strings.HasSuffix(filename, ".yaml") {
-			continue
-		}
-
-		fullPath := filepath.Join(dir, filename)
-		config, err := loadConfigFromFile(fullPath)
+	for _, fullPath := range files {
+		config, err := loadConfigFromFile(fullPath, check)
 		if err != nil {
 			return nil, err
 		}
@@ -214,21 +773,75 @@ func loadConfigFromDirectory(dir string) ([]*Config, error) {
 		configs = append([]*Config{primaryConfig}, configs...)
 	}
 
-	if len(configs) == 0 {
-		return nil, fmt.Errorf("no .yml or .yaml files found in directory %s", dir)
+	return configs, nil
+}
+
+// configFiles returns the config files at path: the file itself, or the
+// .yml and .yaml files directly inside a directory.
+func configFiles(path string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat path %s: %w", path, err)
+	}
+	if !stat.IsDir() {
+		return []string{path}, nil
 	}
 
-	return configs, nil
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", path, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		filename := entry.Name()
+		if !strings.HasSuffix(filename, ".yml") && !strings.HasSuffix(filename, ".yaml") {
+			continue
+		}
+		files = append(files, filepath.Join(path, filename))
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .yml or .yaml files found in directory %s", path)
+	}
+
+	return files, nil
 }
 
 // validateConfig validates the configuration and sets defaults.
 func validateConfig(config *Config) error {
-	if config.Vault.Address == "" {
+	if err := validateWatchConfig(&config.Watch); err != nil {
+		return fmt.Errorf("watch.%w", err)
+	}
+
+	if config.Vault.Address == "" && !config.IsWatchOnly() {
 		return fmt.Errorf("vault.address is required")
 	}
+	for i, addr := range config.Vault.Addresses {
+		if addr == "" {
+			return fmt.Errorf("vault.addresses[%d] must not be empty", i)
+		}
+	}
 
-	if err := validateAuthConfig(&config.Vault.Auth); err != nil {
-		return fmt.Errorf("vault.auth: %w", err)
+	if config.Vault.Address != "" {
+		if err := validateAuthConfig(&config.Vault.Auth); err != nil {
+			return fmt.Errorf("vault.auth: %w", err)
+		}
+	}
+
+	if err := validateRetryConfig(&config.Vault.Retry); err != nil {
+		return fmt.Errorf("vault.retry: %w", err)
+	}
+
+	if config.Vault.CACheckInterval == 0 {
+		config.Vault.CACheckInterval = time.Hour
+	}
+	if config.Vault.CACheckInterval < 0 {
+		return fmt.Errorf("vault.ca_check_interval must be positive")
 	}
 
 	if config.Prometheus.Port == 0 {
@@ -238,6 +851,68 @@ func validateConfig(config *Config) error {
 		config.Prometheus.RefreshInterval = 10 * time.Second
 	}
 
+	if push := config.Prometheus.Pushgateway; push != nil {
+		if err := validatePushgatewayConfig(push); err != nil {
+			return fmt.Errorf("prometheus.pushgateway.%w", err)
+		}
+	}
+
+	for i, label := range config.Prometheus.MetadataLabels {
+		if !labelNamePattern.MatchString(label) || label == "name" {
+			return fmt.Errorf("prometheus.metadata_labels[%d] is not a valid label name: %q", i, label)
+		}
+		if config.Node.CloudMetadata != "" && strings.HasPrefix(label, "cloud_") {
+			return fmt.Errorf("prometheus.metadata_labels[%d] %q collides with node.cloud_metadata labels", i, label)
+		}
+	}
+
+	if err := validateNodeConfig(&config.Node); err != nil {
+		return fmt.Errorf("node.%w", err)
+	}
+
+	if config.Renewal.MaxParallel < 0 {
+		return fmt.Errorf("renewal.max_parallel must be positive")
+	}
+	if config.Renewal.MaxParallel == 0 {
+		config.Renewal.MaxParallel = 1
+	}
+	if config.Renewal.CheckInterval < 0 {
+		return fmt.Errorf("renewal.check_interval must be positive")
+	}
+	if config.Renewal.CheckInterval == 0 {
+		config.Renewal.CheckInterval = 10 * time.Minute
+	}
+
+	if config.Renewal.Adaptive != nil {
+		if err := validateAdaptiveRenewalConfig(config.Renewal.Adaptive); err != nil {
+			return fmt.Errorf("renewal.adaptive.%w", err)
+		}
+	}
+
+	if config.Integrity.Reissue && !config.Integrity.Watch {
+		return fmt.Errorf("integrity.reissue requires integrity.watch")
+	}
+	if config.Integrity.Debounce < 0 {
+		return fmt.Errorf("integrity.debounce must be positive")
+	}
+	if config.Integrity.Debounce == 0 {
+		config.Integrity.Debounce = 2 * time.Second
+	}
+
+	if err := validateRateLimitConfig(&config.API.RateLimit); err != nil {
+		return fmt.Errorf("api.rate_limit.%w", err)
+	}
+
+	if config.API.Auth != nil {
+		if err := validateAPIAuthConfig(config.API.Auth); err != nil {
+			return fmt.Errorf("api.auth.%w", err)
+		}
+	}
+
+	if err := validateWebConfig(&config.Web, config.Prometheus.Port); err != nil {
+		return fmt.Errorf("web.%w", err)
+	}
+
 	if config.Logging.Level == "" {
 		config.Logging.Level = "info"
 	}
@@ -256,8 +931,46 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("logging.level must be one of 'debug', 'info', 'warn', 'error', got '%s'", config.Logging.Level)
 	}
 
+	if err := validateChaosConfig(&config.Chaos); err != nil {
+		return fmt.Errorf("chaos: %w", err)
+	}
+
+	if err := validateNotificationsConfig(&config.Notifications); err != nil {
+		return fmt.Errorf("notifications.%w", err)
+	}
+
+	if err := validateTracingConfig(&config.Tracing); err != nil {
+		return fmt.Errorf("tracing.%w", err)
+	}
+
+	if config.StatsD != nil {
+		if err := validateStatsDConfig(config.StatsD); err != nil {
+			return fmt.Errorf("statsd.%w", err)
+		}
+	}
+
+	if err := validateSourceConfig(&config.Source); err != nil {
+		return fmt.Errorf("source.%w", err)
+	}
+
+	if config.ACME != nil {
+		if err := validateACMEConfig(config.ACME); err != nil {
+			return fmt.Errorf("acme.%w", err)
+		}
+	}
+
+	if err := validateCertificates(config.Certificates, &config.Vault); err != nil {
+		return err
+	}
+
+	return validateACMECertificates(config.Certificates, config.ACME)
+}
+
+// validateCertificates validates certificate definitions against the global
+// Vault settings and sets per-certificate defaults in place.
+func validateCertificates(certs []CertificateConfig, vault *VaultConfig) error {
 	certNames := make(map[string]bool)
-	for i, cert := range config.Certificates {
+	for i, cert := range certs {
 		if cert.Name == "" {
 			return fmt.Errorf("certificates[%d].name is required", i)
 		}
@@ -266,48 +979,1113 @@ func validateConfig(config *Config) error {
 		}
 		certNames[cert.Name] = true
 
-		if cert.Role == "" {
+		if err := validateCertSource(&certs[i]); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
+		}
+		if cert.Role == "" && certs[i].Source == "pki" {
 			return fmt.Errorf("certificates[%d].role is required for %s", i, cert.Name)
 		}
-		if cert.CommonName == "" {
+		if cert.ReusePrivateKey && certs[i].Source != "pki" {
+			return fmt.Errorf("certificates[%d].reuse_private_key requires source 'pki' for %s", i, cert.Name)
+		}
+		if cert.CommonName == "" && certs[i].Source != "ca_bundle" {
 			return fmt.Errorf("certificates[%d].common_name is required for %s", i, cert.Name)
 		}
 		if cert.Certificate == "" {
 			return fmt.Errorf("certificates[%d].certificate is required for %s", i, cert.Name)
 		}
-		if cert.Key == "" {
+		if cert.Key == "" && certs[i].Source != "ca_bundle" {
 			return fmt.Errorf("certificates[%d].key is required for %s", i, cert.Name)
 		}
 
 		if cert.TTL == 0 {
-			config.Certificates[i].TTL = 24 * time.Hour
+			certs[i].TTL = 24 * time.Hour
 		}
 
-		if cert.HealthCheck != nil {
-			if cert.HealthCheck.TCP == "" {
-				return fmt.Errorf("certificates[%d].health_check.tcp is required when health_check is specified for %s", i, cert.Name)
-			}
-			if cert.HealthCheck.Timeout == 0 {
-				config.Certificates[i].HealthCheck.Timeout = 5 * time.Second
-			}
+		if cert.CAFile != "" && (cert.CAFile == cert.Certificate || cert.CAFile == cert.Key) {
+			return fmt.Errorf("certificates[%d].ca_file must differ from certificate and key for %s", i, cert.Name)
 		}
-	}
 
-	return nil
-}
+		if err := validateRenewalPolicy(&certs[i]); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
+		}
 
-// validateAuthConfig validates the authentication configuration.
-func validateAuthConfig(auth *AuthConfig) error {
-	authMethods := 0
+		if err := validateKeyConfig(&cert); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
+		}
 
-	if auth.Token != nil {
-		authMethods++
-		if auth.Token.Value == "" {
-			return fmt.Errorf("token.value is required")
+		if err := validateSubjectConfig(&cert); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
 		}
-	}
 
-	if auth.GCP != nil {
+		if cert.Vault != nil {
+			if err := validateCertVaultConfig(cert.Vault, vault); err != nil {
+				return fmt.Errorf("certificates[%d].vault.%w for %s", i, err, cert.Name)
+			}
+		}
+
+		if cert.OnChangeAction != nil {
+			if cert.OnChange != "" {
+				return fmt.Errorf("certificates[%d].on_change and on_change_action are mutually exclusive for %s", i, cert.Name)
+			}
+			if cert.OnChangeSandbox != nil {
+				return fmt.Errorf("certificates[%d].on_change_sandbox only applies to on_change for %s", i, cert.Name)
+			}
+			if err := validateActionConfig(cert.OnChangeAction); err != nil {
+				return fmt.Errorf("certificates[%d].on_change_action.%w for %s", i, err, cert.Name)
+			}
+		}
+
+		if cert.OnChangeSandbox != nil {
+			if err := validateSandboxConfig(cert.OnChangeSandbox); err != nil {
+				return fmt.Errorf("certificates[%d].on_change_sandbox.%w for %s", i, err, cert.Name)
+			}
+		}
+
+		if err := validateHookPolicy(&certs[i].OnChangePolicy); err != nil {
+			return fmt.Errorf("certificates[%d].on_change_policy.%w for %s", i, err, cert.Name)
+		}
+
+		if cert.HealthCheck != nil {
+			if cert.HealthCheck.TCP == "" && cert.HealthCheck.Command == "" {
+				return fmt.Errorf("certificates[%d].health_check.tcp or command is required when health_check is specified for %s", i, cert.Name)
+			}
+			if cert.HealthCheck.TCP != "" && cert.HealthCheck.Command != "" {
+				return fmt.Errorf("certificates[%d].health_check.tcp and command are mutually exclusive for %s", i, cert.Name)
+			}
+			if cert.HealthCheck.Command != "" && (cert.HealthCheck.StartTLS != "" || cert.HealthCheck.Verify || cert.HealthCheck.ClientAuth) {
+				return fmt.Errorf("certificates[%d].health_check.starttls, verify, and client_auth require tcp for %s", i, cert.Name)
+			}
+			if cert.HealthCheck.Timeout == 0 {
+				certs[i].HealthCheck.Timeout = 5 * time.Second
+			}
+			switch cert.HealthCheck.StartTLS {
+			case "", "smtp", "ldap", "postgres":
+			default:
+				return fmt.Errorf("certificates[%d].health_check.starttls must be one of 'smtp', 'ldap', 'postgres', got '%s' for %s", i, cert.HealthCheck.StartTLS, cert.Name)
+			}
+			if cert.HealthCheck.ConfirmTimeout < 0 {
+				return fmt.Errorf("certificates[%d].health_check.confirm_timeout must not be negative for %s", i, cert.Name)
+			}
+			if cert.HealthCheck.CAFile != "" && !cert.HealthCheck.Verify {
+				return fmt.Errorf("certificates[%d].health_check.ca_file requires verify for %s", i, cert.Name)
+			}
+			if err := validateHealthCheckClientAuth(&certs[i]); err != nil {
+				return fmt.Errorf("certificates[%d].health_check.%w for %s", i, err, cert.Name)
+			}
+			if remediate := cert.HealthCheck.Remediate; remediate != nil {
+				if remediate.After < 0 {
+					return fmt.Errorf("certificates[%d].health_check.remediate.after must not be negative for %s", i, cert.Name)
+				}
+				if remediate.After == 0 {
+					remediate.After = 5 * time.Minute
+				}
+				if remediate.Command == "" && !cert.HasOnChange() {
+					return fmt.Errorf("certificates[%d].health_check.remediate requires a command or on_change for %s", i, cert.Name)
+				}
+			}
+		}
+
+		if cert.Backup != nil {
+			if err := validateBackupConfig(cert.Backup); err != nil {
+				return fmt.Errorf("certificates[%d].backup.%w for %s", i, err, cert.Name)
+			}
+		}
+
+		if cert.Versioned != nil {
+			if err := validateVersionedConfig(&certs[i]); err != nil {
+				return fmt.Errorf("certificates[%d].versioned.%w for %s", i, err, cert.Name)
+			}
+		}
+
+		if cert.Keystore != nil {
+			if err := validateKeystoreConfig(&certs[i]); err != nil {
+				return fmt.Errorf("certificates[%d].keystore.%w for %s", i, err, cert.Name)
+			}
+		}
+
+		if err := validateFileModes(&certs[i]); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
+		}
+
+		if err := validateOutputs(&certs[i]); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
+		}
+
+		if err := validateCertLabels(cert.Labels); err != nil {
+			return fmt.Errorf("certificates[%d].%w for %s", i, err, cert.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateCertLabels checks certificate labels are valid Prometheus label
+// names that do not collide with the labels metrics already carry.
+func validateCertLabels(labels map[string]string) error {
+	for key := range labels {
+		if !labelNamePattern.MatchString(key) || strings.HasPrefix(key, "__") {
+			return fmt.Errorf("labels key %q is not a valid label name", key)
+		}
+		if reservedCertLabels[key] || strings.HasPrefix(key, "cloud_") {
+			return fmt.Errorf("labels key %q is reserved", key)
+		}
+	}
+	return nil
+}
+
+// validateRenewalPolicy checks renew_before and renew_at_percent leave part
+// of the certificate's lifetime before renewal, and checks policy, which
+// defaults to manage. Certificates setting neither renew_before nor
+// renew_at_percent renew with a third of their lifetime left.
+func validateRenewalPolicy(cert *CertificateConfig) error {
+	if cert.RenewBefore < 0 {
+		return fmt.Errorf("renew_before must be positive")
+	}
+	if cert.RenewBefore > 0 && cert.Source == "pki" && cert.RenewBefore >= cert.TTL {
+		return fmt.Errorf("renew_before %s must be shorter than ttl %s", cert.RenewBefore, cert.TTL)
+	}
+	if cert.RenewAtPercent < 0 || cert.RenewAtPercent >= 1 {
+		return fmt.Errorf("renew_at_percent must be between 0 and 1 (or 0%% and 100%%), got %g", float64(cert.RenewAtPercent))
+	}
+	switch cert.Policy {
+	case "":
+		cert.Policy = PolicyManage
+	case PolicyManage, PolicyIssueIfMissing, PolicyRenewOnly:
+	default:
+		return fmt.Errorf("policy must be '%s', '%s', or '%s', got '%s'", PolicyManage, PolicyIssueIfMissing, PolicyRenewOnly, cert.Policy)
+	}
+	return nil
+}
+
+// validateActionConfig checks that exactly one reload action is set and
+// sets its defaults.
+func validateActionConfig(action *ActionConfig) error {
+	set := 0
+	for _, ok := range []bool{action.Systemd != "", action.Signal != nil, action.HTTP != nil, action.Docker != ""} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("must set exactly one of systemd, signal, http, or docker")
+	}
+
+	switch {
+	case action.Systemd != "":
+		if action.SystemdMode == "" {
+			action.SystemdMode = "reload"
+		}
+		switch action.SystemdMode {
+		case "reload", "restart", "reload-or-restart":
+		default:
+			return fmt.Errorf("systemd_mode must be 'reload', 'restart', or 'reload-or-restart', got '%s'", action.SystemdMode)
+		}
+	case action.Signal != nil:
+		if action.Signal.Pidfile == "" {
+			return fmt.Errorf("signal.pidfile is required")
+		}
+		if action.Signal.Signal == "" {
+			action.Signal.Signal = "HUP"
+		}
+		if !validSignals[strings.TrimPrefix(strings.ToUpper(action.Signal.Signal), "SIG")] {
+			return fmt.Errorf("signal.signal must be one of HUP, INT, QUIT, TERM, USR1, USR2, or WINCH, got '%s'", action.Signal.Signal)
+		}
+	case action.HTTP != nil:
+		u, err := url.Parse(action.HTTP.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("http.url must be an http or https URL, got '%s'", action.HTTP.URL)
+		}
+		if action.HTTP.Method == "" {
+			action.HTTP.Method = "POST"
+		}
+		action.HTTP.Method = strings.ToUpper(action.HTTP.Method)
+	}
+	if action.SystemdMode != "" && action.Systemd == "" {
+		return fmt.Errorf("systemd_mode requires systemd")
+	}
+	return nil
+}
+
+// validateHookPolicy validates a hook's timeout and retries and sets their
+// defaults.
+func validateHookPolicy(policy *HookPolicy) error {
+	if policy.Timeout == 0 {
+		policy.Timeout = 5 * time.Minute
+	}
+	if policy.Backoff == 0 {
+		policy.Backoff = 5 * time.Second
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = max(time.Minute, policy.Backoff)
+	}
+	switch {
+	case policy.Timeout < 0:
+		return fmt.Errorf("timeout must be positive")
+	case policy.Retries < 0:
+		return fmt.Errorf("retries must not be negative")
+	case policy.Backoff < 0:
+		return fmt.Errorf("backoff must be positive")
+	case policy.MaxBackoff < policy.Backoff:
+		return fmt.Errorf("max_backoff must be at least backoff")
+	}
+	return nil
+}
+
+// validateHealthCheckClientAuth checks the client certificate a health
+// check presents.
+func validateHealthCheckClientAuth(cert *CertificateConfig) error {
+	check := cert.HealthCheck
+	explicit := check.ClientCertificate != "" || check.ClientKey != ""
+	if explicit && !check.ClientAuth {
+		return fmt.Errorf("client_certificate and client_key require client_auth")
+	}
+	if explicit && (check.ClientCertificate == "" || check.ClientKey == "") {
+		return fmt.Errorf("client_certificate and client_key must be set together")
+	}
+	if check.ClientAuth && !explicit && cert.IsCABundleSource() {
+		return fmt.Errorf("client_auth requires client_certificate and client_key for source 'ca_bundle'")
+	}
+	return nil
+}
+
+// validateBackupConfig sets backup defaults.
+func validateBackupConfig(backup *BackupConfig) error {
+	if backup.Keep == 0 {
+		backup.Keep = 5
+	}
+	if backup.Keep < 0 {
+		return fmt.Errorf("keep must be positive")
+	}
+	if backup.VerifyTimeout == 0 {
+		backup.VerifyTimeout = 30 * time.Second
+	}
+	if backup.VerifyTimeout < 0 {
+		return fmt.Errorf("verify_timeout must be positive")
+	}
+	return nil
+}
+
+// validateVersionedConfig checks the versioned directory and sets the
+// default number of versions kept.
+func validateVersionedConfig(cert *CertificateConfig) error {
+	versioned := cert.Versioned
+	if versioned.Dir == "" {
+		return fmt.Errorf("dir is required")
+	}
+	if !filepath.IsAbs(versioned.Dir) {
+		return fmt.Errorf("dir must be an absolute path, got '%s'", versioned.Dir)
+	}
+	if versioned.Keep == 0 {
+		versioned.Keep = 10
+	}
+	if versioned.Keep < 0 {
+		return fmt.Errorf("keep must be positive")
+	}
+	if cert.IsDER() || cert.IsCABundleSource() {
+		return fmt.Errorf("dir cannot be used with encoding 'der' or source 'ca_bundle'")
+	}
+	return nil
+}
+
+// validateKeystoreConfig validates JKS output and sets the default alias.
+func validateKeystoreConfig(cert *CertificateConfig) error {
+	keystore := cert.Keystore
+	if keystore.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+	for _, path := range []string{keystore.Path, keystore.Truststore} {
+		if path != "" && (path == cert.Certificate || path == cert.Key || path == cert.CAFile) {
+			return fmt.Errorf("paths must differ from certificate, key, and ca_file")
+		}
+	}
+	if keystore.Truststore == keystore.Path {
+		return fmt.Errorf("truststore must differ from path")
+	}
+	if keystore.Password == "" && keystore.PasswordFile == "" {
+		return fmt.Errorf("password or password_file is required")
+	}
+	if keystore.Password != "" && keystore.PasswordFile != "" {
+		return fmt.Errorf("password and password_file are mutually exclusive")
+	}
+	if keystore.Alias == "" {
+		keystore.Alias = cert.Name
+	}
+	return nil
+}
+
+// validateFileModes validates cert_mode, key_mode, and dir_mode and sets
+// their defaults.
+func validateFileModes(cert *CertificateConfig) error {
+	for _, field := range []struct {
+		name  string
+		value *string
+		def   string
+	}{
+		{"cert_mode", &cert.CertMode, "0644"},
+		{"key_mode", &cert.KeyMode, "0600"},
+		{"dir_mode", &cert.DirMode, "0755"},
+	} {
+		if *field.value == "" {
+			*field.value = field.def
+		}
+		if !isFileMode(*field.value) {
+			return fmt.Errorf("%s must be an octal file mode, got '%s'", field.name, *field.value)
+		}
+	}
+	return nil
+}
+
+// validateOutputs validates additional output files and sets default
+// modes and ownership.
+func validateOutputs(cert *CertificateConfig) error {
+	paths := map[string]bool{cert.Certificate: true, cert.Key: true}
+	if cert.CAFile != "" {
+		paths[cert.CAFile] = true
+	}
+	if cert.Keystore != nil {
+		paths[cert.Keystore.Path] = true
+		if cert.Keystore.Truststore != "" {
+			paths[cert.Keystore.Truststore] = true
+		}
+	}
+
+	for i := range cert.Outputs {
+		output := &cert.Outputs[i]
+		switch output.Type {
+		case "leaf", "fullchain", "chain", "key", "combined":
+		default:
+			return fmt.Errorf("outputs[%d].type must be 'leaf', 'fullchain', 'chain', 'key', or 'combined', got '%s'", i, output.Type)
+		}
+		if output.Type == "combined" && cert.IsDER() {
+			return fmt.Errorf("outputs[%d].type 'combined' is not supported with encoding 'der'", i)
+		}
+		if output.Path == "" {
+			return fmt.Errorf("outputs[%d].path is required", i)
+		}
+		if paths[output.Path] {
+			return fmt.Errorf("outputs[%d].path %s is already written for this certificate", i, output.Path)
+		}
+		paths[output.Path] = true
+
+		if output.Mode == "" {
+			output.Mode = "0644"
+			if output.Type == "key" || output.Type == "combined" {
+				output.Mode = "0600"
+			}
+		}
+		if !isFileMode(output.Mode) {
+			return fmt.Errorf("outputs[%d].mode must be an octal file mode, got '%s'", i, output.Mode)
+		}
+		if output.Owner == "" {
+			output.Owner = cert.Owner
+		}
+		if output.Group == "" {
+			output.Group = cert.Group
+		}
+	}
+	return nil
+}
+
+// validateCertSource validates where a certificate's material comes from
+// and sets defaults.
+func validateCertSource(cert *CertificateConfig) error {
+	switch cert.Source {
+	case "":
+		cert.Source = "pki"
+	case "pki", "kv", "acme", "ca_bundle":
+	default:
+		return fmt.Errorf("source must be 'pki', 'kv', 'acme', or 'ca_bundle', got '%s'", cert.Source)
+	}
+
+	if cert.KV != nil && cert.Source != "kv" {
+		return fmt.Errorf("kv requires source 'kv'")
+	}
+	if cert.ACME != nil && cert.Source != "acme" {
+		return fmt.Errorf("acme requires source 'acme'")
+	}
+	if cert.CABundle != nil && cert.Source != "ca_bundle" {
+		return fmt.Errorf("ca_bundle requires source 'ca_bundle'")
+	}
+
+	if cert.Source == "pki" || cert.Source == "ca_bundle" {
+		if strings.ContainsAny(cert.IssuerRef, "/ ") {
+			return fmt.Errorf("issuer_ref must be an issuer name or ID, got '%s'", cert.IssuerRef)
+		}
+	}
+	if cert.Source == "pki" {
+		return nil
+	}
+	if cert.Source == "ca_bundle" {
+		return validateCABundleSource(cert)
+	}
+
+	if cert.IssuerRef != "" {
+		return fmt.Errorf("issuer_ref requires source 'pki'")
+	}
+
+	if cert.Source == "acme" {
+		if cert.Vault != nil {
+			return fmt.Errorf("vault cannot be set when source is 'acme'")
+		}
+		if cert.ACME == nil {
+			cert.ACME = &CertACMEConfig{}
+		}
+		switch cert.ACME.Challenge {
+		case "", "http-01", "dns-01":
+		default:
+			return fmt.Errorf("acme.challenge must be 'http-01' or 'dns-01', got '%s'", cert.ACME.Challenge)
+		}
+		return nil
+	}
+
+	if cert.KV == nil || cert.KV.Path == "" {
+		return fmt.Errorf("kv.path is required when source is 'kv'")
+	}
+	if cert.KV.Mount == "" {
+		cert.KV.Mount = "secret"
+	}
+	if cert.KV.Version < 0 {
+		return fmt.Errorf("kv.version must not be negative")
+	}
+	if cert.KV.RefreshInterval == 0 {
+		cert.KV.RefreshInterval = 5 * time.Minute
+	}
+	if cert.KV.RefreshInterval < 0 {
+		return fmt.Errorf("kv.refresh_interval must be positive")
+	}
+
+	return nil
+}
+
+// validateCABundleSource checks that a CA bundle entry sets none of the
+// settings that only apply to a certificate and key, and sets defaults.
+func validateCABundleSource(cert *CertificateConfig) error {
+	switch {
+	case cert.Key != "":
+		return fmt.Errorf("key cannot be set when source is 'ca_bundle'")
+	case cert.CAFile != "":
+		return fmt.Errorf("ca_file cannot be set when source is 'ca_bundle'")
+	case len(cert.Outputs) > 0:
+		return fmt.Errorf("outputs cannot be set when source is 'ca_bundle'")
+	case cert.Keystore != nil:
+		return fmt.Errorf("keystore cannot be set when source is 'ca_bundle'")
+	case cert.KeyEncryption != nil:
+		return fmt.Errorf("key_encryption cannot be set when source is 'ca_bundle'")
+	case cert.Encoding == "der":
+		return fmt.Errorf("encoding 'der' cannot hold a CA bundle")
+	}
+
+	if cert.CABundle == nil {
+		cert.CABundle = &CertCABundleSource{}
+	}
+	if cert.CABundle.RefreshInterval == 0 {
+		cert.CABundle.RefreshInterval = time.Hour
+	}
+	if cert.CABundle.RefreshInterval < 0 {
+		return fmt.Errorf("ca_bundle.refresh_interval must be positive")
+	}
+	return nil
+}
+
+// validateACMEConfig sets ACME account defaults and checks that at least
+// one challenge solver is configured.
+func validateACMEConfig(acme *ACMEConfig) error {
+	if acme.DirectoryURL == "" {
+		acme.DirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+	}
+	if acme.AccountKeyFile == "" {
+		return fmt.Errorf("account_key_file is required")
+	}
+	if acme.EAB != nil && (acme.EAB.KeyID == "" || acme.EAB.HMACKey == "") {
+		return fmt.Errorf("eab requires key_id and hmac_key")
+	}
+
+	if acme.HTTP01 == nil && acme.DNS01 == nil {
+		return fmt.Errorf("http01 or dns01 is required")
+	}
+	if acme.HTTP01 != nil {
+		if acme.HTTP01.Listen != "" && acme.HTTP01.Webroot != "" {
+			return fmt.Errorf("http01: only one of listen or webroot may be set")
+		}
+		if acme.HTTP01.Listen == "" && acme.HTTP01.Webroot == "" {
+			acme.HTTP01.Listen = ":80"
+		}
+	}
+	if acme.DNS01 != nil {
+		if acme.DNS01.Command == "" {
+			return fmt.Errorf("dns01.command is required")
+		}
+		if acme.DNS01.PropagationDelay == 0 {
+			acme.DNS01.PropagationDelay = 30 * time.Second
+		}
+		if acme.DNS01.PropagationDelay < 0 {
+			return fmt.Errorf("dns01.propagation_delay must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// validateACMECertificates checks that certificates with source "acme" have
+// an ACME account and a solver for their challenge, and sets the default
+// challenge type.
+func validateACMECertificates(certs []CertificateConfig, acme *ACMEConfig) error {
+	for i := range certs {
+		cert := &certs[i]
+		if !cert.IsACMESource() {
+			continue
+		}
+		if acme == nil {
+			return fmt.Errorf("certificates[%d].source 'acme' requires the acme section for %s", i, cert.Name)
+		}
+
+		if cert.ACME.Challenge == "" {
+			cert.ACME.Challenge = "http-01"
+			if acme.HTTP01 == nil {
+				cert.ACME.Challenge = "dns-01"
+			}
+		}
+		if cert.ACME.Challenge == "http-01" && acme.HTTP01 == nil {
+			return fmt.Errorf("certificates[%d].acme.challenge 'http-01' requires acme.http01 for %s", i, cert.Name)
+		}
+		if cert.ACME.Challenge == "dns-01" && acme.DNS01 == nil {
+			return fmt.Errorf("certificates[%d].acme.challenge 'dns-01' requires acme.dns01 for %s", i, cert.Name)
+		}
+		if len(cert.IPSans) > 0 || len(cert.URISans) > 0 || len(cert.OtherSans) > 0 {
+			return fmt.Errorf("certificates[%d]: only DNS alt_names are supported with source 'acme' for %s", i, cert.Name)
+		}
+	}
+
+	return nil
+}
+
+// validateSandboxConfig checks that sandbox paths are absolute.
+func validateSandboxConfig(sandbox *SandboxConfig) error {
+	if len(sandbox.Env) > 0 && !sandbox.MinimalEnv {
+		return fmt.Errorf("env requires minimal_env")
+	}
+
+	if sandbox.Landlock == nil {
+		return nil
+	}
+	if len(sandbox.Landlock.ReadOnly) == 0 && len(sandbox.Landlock.ReadWrite) == 0 {
+		return fmt.Errorf("landlock requires read_only or read_write paths")
+	}
+	for i, path := range sandbox.Landlock.ReadOnly {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("landlock.read_only[%d] must be an absolute path, got '%s'", i, path)
+		}
+	}
+	for i, path := range sandbox.Landlock.ReadWrite {
+		if !filepath.IsAbs(path) {
+			return fmt.Errorf("landlock.read_write[%d] must be an absolute path, got '%s'", i, path)
+		}
+	}
+
+	return nil
+}
+
+// validateKeyConfig validates the private key type, size, and format.
+func validateKeyConfig(cert *CertificateConfig) error {
+	validBits := map[string]map[int]bool{
+		"rsa":     {2048: true, 3072: true, 4096: true, 8192: true},
+		"ec":      {224: true, 256: true, 384: true, 521: true},
+		"ed25519": {},
+	}
+
+	if cert.KeyType != "" {
+		bits, ok := validBits[cert.KeyType]
+		if !ok {
+			return fmt.Errorf("key_type must be 'rsa', 'ec', or 'ed25519', got '%s'", cert.KeyType)
+		}
+		if cert.KeyBits != 0 && !bits[cert.KeyBits] {
+			return fmt.Errorf("key_bits %d is not valid for key_type '%s'", cert.KeyBits, cert.KeyType)
+		}
+	} else if cert.KeyBits != 0 {
+		return fmt.Errorf("key_bits requires key_type")
+	}
+
+	if cert.PrivateKeyFormat != "" && cert.PrivateKeyFormat != "pem" && cert.PrivateKeyFormat != "pkcs8" {
+		return fmt.Errorf("private_key_format must be 'pem' or 'pkcs8', got '%s'", cert.PrivateKeyFormat)
+	}
+
+	if cert.Encoding != "" && cert.Encoding != "pem" && cert.Encoding != "der" {
+		return fmt.Errorf("encoding must be 'pem' or 'der', got '%s'", cert.Encoding)
+	}
+	if cert.IsDER() && cert.IsCombinedFile() {
+		return fmt.Errorf("encoding 'der' requires separate certificate and key files")
+	}
+
+	if enc := cert.KeyEncryption; enc != nil {
+		if (enc.PassphraseFile == "") == (enc.PassphraseEnv == "") {
+			return fmt.Errorf("key_encryption requires exactly one of passphrase_file or passphrase_env")
+		}
+		if cert.IsDER() {
+			return fmt.Errorf("key_encryption requires encoding 'pem'")
+		}
+	}
+
+	return nil
+}
+
+// validateSubjectConfig checks URI and other SANs are well formed and that
+// subject values are not empty, since Vault takes them comma-separated.
+func validateSubjectConfig(cert *CertificateConfig) error {
+	for i, uri := range cert.URISans {
+		parsed, err := url.Parse(uri)
+		if err != nil || parsed.Scheme == "" || strings.Contains(uri, ",") {
+			return fmt.Errorf("uri_sans[%d] must be an absolute URI, got '%s'", i, uri)
+		}
+	}
+	for i, san := range cert.OtherSans {
+		if !otherSANPattern.MatchString(san) || strings.Contains(san, ",") {
+			return fmt.Errorf("other_sans[%d] must be '<oid>;UTF8:<value>', got '%s'", i, san)
+		}
+	}
+
+	fields := []struct {
+		name   string
+		values []string
+	}{
+		{"ou", cert.OU},
+		{"organization", cert.Organization},
+		{"country", cert.Country},
+		{"locality", cert.Locality},
+		{"postal_code", cert.PostalCode},
+	}
+	for _, field := range fields {
+		for i, value := range field.values {
+			if strings.TrimSpace(value) == "" || strings.Contains(value, ",") {
+				return fmt.Errorf("%s[%d] must be non-empty and must not contain commas", field.name, i)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateCertVaultConfig validates a per-certificate Vault override,
+// inheriting auth and retry settings from the global Vault config.
+func validateCertVaultConfig(override, global *VaultConfig) error {
+	if override.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+	for i, addr := range override.Addresses {
+		if addr == "" {
+			return fmt.Errorf("addresses[%d] must not be empty", i)
+		}
+	}
+
+	if !hasAuthConfig(&override.Auth) {
+		override.Auth = global.Auth
+	}
+	if err := validateAuthConfig(&override.Auth); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+
+	if override.Retry == (RetryConfig{}) {
+		override.Retry = global.Retry
+	}
+	if err := validateRetryConfig(&override.Retry); err != nil {
+		return fmt.Errorf("retry: %w", err)
+	}
+
+	return nil
+}
+
+// validateRetryConfig validates the Vault retry policy and sets defaults.
+func validateRetryConfig(retry *RetryConfig) error {
+	if retry.MaxAttempts < 0 {
+		return fmt.Errorf("max_attempts must not be negative, got %d", retry.MaxAttempts)
+	}
+	if retry.Jitter < 0 || retry.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1, got %v", retry.Jitter)
+	}
+
+	if retry.MaxAttempts == 0 {
+		retry.MaxAttempts = 3
+	}
+	if retry.BaseBackoff == 0 {
+		retry.BaseBackoff = time.Second
+	}
+	if retry.MaxBackoff == 0 {
+		retry.MaxBackoff = 30 * time.Second
+	}
+	if retry.Jitter == 0 {
+		retry.Jitter = 0.2
+	}
+
+	if retry.MaxBackoff < retry.BaseBackoff {
+		return fmt.Errorf("max_backoff (%s) must not be less than base_backoff (%s)", retry.MaxBackoff, retry.BaseBackoff)
+	}
+
+	return nil
+}
+
+// validateNodeConfig validates node identity settings.
+func validateNodeConfig(node *NodeConfig) error {
+	switch node.CloudMetadata {
+	case "":
+		return nil
+	case "auto", "gce", "ec2", "azure":
+	default:
+		return fmt.Errorf("cloud_metadata must be 'auto', 'gce', 'ec2', or 'azure', got '%s'", node.CloudMetadata)
+	}
+
+	if node.CloudMetadataTimeout == 0 {
+		node.CloudMetadataTimeout = 2 * time.Second
+	}
+	if node.CloudMetadataTimeout < 0 {
+		return fmt.Errorf("cloud_metadata_timeout must be positive")
+	}
+	return nil
+}
+
+// validateAdaptiveRenewalConfig sets degradation thresholds.
+func validateAdaptiveRenewalConfig(adaptive *AdaptiveRenewalConfig) error {
+	if adaptive.ErrorRate == 0 {
+		adaptive.ErrorRate = 0.2
+	}
+	if adaptive.ErrorRate < 0 || adaptive.ErrorRate > 1 {
+		return fmt.Errorf("error_rate must be between 0 and 1, got %v", adaptive.ErrorRate)
+	}
+	if adaptive.Latency == 0 {
+		adaptive.Latency = 2 * time.Second
+	}
+	if adaptive.Latency < 0 {
+		return fmt.Errorf("latency must be positive")
+	}
+	if adaptive.MinRequests == 0 {
+		adaptive.MinRequests = 3
+	}
+	if adaptive.MinRequests < 0 {
+		return fmt.Errorf("min_requests must be positive")
+	}
+	if adaptive.Backoff == 0 {
+		adaptive.Backoff = 10 * time.Minute
+	}
+	if adaptive.Backoff < 0 {
+		return fmt.Errorf("backoff must be positive")
+	}
+
+	return nil
+}
+
+// validateWatchConfig checks watch-only targets and sets defaults.
+func validateWatchConfig(watch *WatchConfig) error {
+	for i, pattern := range watch.Files {
+		if pattern == "" {
+			return fmt.Errorf("files[%d] must not be empty", i)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("files[%d] is not a valid glob: %w", i, err)
+		}
+	}
+	for i, endpoint := range watch.Endpoints {
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil || host == "" || port == "" {
+			return fmt.Errorf("endpoints[%d] must be host:port, got %q", i, endpoint)
+		}
+	}
+
+	if watch.Interval < 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if watch.Interval == 0 {
+		watch.Interval = 5 * time.Minute
+	}
+	if watch.Timeout < 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+	if watch.Timeout == 0 {
+		watch.Timeout = 5 * time.Second
+	}
+	return nil
+}
+
+// validateRateLimitConfig sets rate limit defaults.
+func validateRateLimitConfig(rateLimit *RateLimitConfig) error {
+	if rateLimit.RequestsPerMinute == 0 {
+		rateLimit.RequestsPerMinute = 10
+	}
+	if rateLimit.RequestsPerMinute < 0 {
+		return fmt.Errorf("requests_per_minute must be positive")
+	}
+	if rateLimit.Burst == 0 {
+		rateLimit.Burst = 5
+	}
+	if rateLimit.Burst < 0 {
+		return fmt.Errorf("burst must be positive")
+	}
+
+	return nil
+}
+
+// validateAPIAuthConfig checks API credentials are complete.
+func validateAPIAuthConfig(auth *APIAuthConfig) error {
+	if (auth.Username == "") != (auth.Password == "") {
+		return fmt.Errorf("username and password must be set together")
+	}
+	if auth.Username == "" && len(auth.Tokens) == 0 && auth.OIDC == nil {
+		return fmt.Errorf("username and password, tokens, or oidc are required")
+	}
+	for i, token := range auth.Tokens {
+		if token == "" {
+			return fmt.Errorf("tokens[%d] must not be empty", i)
+		}
+	}
+	if auth.OIDC != nil {
+		if err := validateOIDCConfig(auth.OIDC); err != nil {
+			return fmt.Errorf("oidc.%w", err)
+		}
+	}
+	return nil
+}
+
+// validateOIDCConfig checks single sign-on settings are complete.
+func validateOIDCConfig(oidc *OIDCConfig) error {
+	if oidc.IssuerURL == "" {
+		return fmt.Errorf("issuer_url is required")
+	}
+	if oidc.ClientID == "" {
+		return fmt.Errorf("client_id is required")
+	}
+	if oidc.ClientSecret == "" {
+		return fmt.Errorf("client_secret is required")
+	}
+	redirect, err := url.Parse(oidc.RedirectURL)
+	if err != nil || (redirect.Scheme != "http" && redirect.Scheme != "https") || redirect.Host == "" {
+		return fmt.Errorf("redirect_url must be an http or https URL, got '%s'", oidc.RedirectURL)
+	}
+	if !strings.HasSuffix(redirect.Path, "/auth/callback") {
+		return fmt.Errorf("redirect_url must end in /auth/callback, got '%s'", oidc.RedirectURL)
+	}
+	if oidc.SessionTTL < 0 {
+		return fmt.Errorf("session_ttl must not be negative")
+	}
+	return nil
+}
+
+// validateWebConfig validates HTTP server settings, defaulting the port to
+// the Prometheus port.
+func validateWebConfig(web *WebConfig, prometheusPort int) error {
+	if web.Port == 0 {
+		web.Port = prometheusPort
+	}
+	if web.Port < 0 || web.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", web.Port)
+	}
+	if (web.TLSCertFile == "") != (web.TLSKeyFile == "") {
+		return fmt.Errorf("tls_cert_file and tls_key_file must be set together")
+	}
+	if web.TLSClientCAFile != "" && web.TLSCertFile == "" {
+		return fmt.Errorf("tls_client_ca_file requires tls_cert_file")
+	}
+
+	return nil
+}
+
+// validatePushgatewayConfig validates the Pushgateway URL and sets
+// defaults.
+func validatePushgatewayConfig(push *PushgatewayConfig) error {
+	u, err := url.Parse(push.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http or https URL, got '%s'", push.URL)
+	}
+	if push.Job == "" {
+		push.Job = "vault-cert-manager"
+	}
+	if push.Instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("instance is required when the hostname is unknown: %w", err)
+		}
+		push.Instance = hostname
+	}
+	if push.Timeout == 0 {
+		push.Timeout = 10 * time.Second
+	}
+	if push.Timeout < 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	return nil
+}
+
+// validateStatsDConfig validates the StatsD address and tags and sets
+// defaults.
+func validateStatsDConfig(statsd *StatsDConfig) error {
+	if statsd.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if statsd.Port == 0 {
+		statsd.Port = 8125
+	}
+	if statsd.Port < 0 || statsd.Port > 65535 {
+		return fmt.Errorf("port must be between 1 and 65535, got %d", statsd.Port)
+	}
+	for i, tag := range statsd.Tags {
+		if tag == "" || strings.ContainsAny(tag, ",|#\n") {
+			return fmt.Errorf("tags[%d] must be a non-empty tag without ',', '|', or '#', got %q", i, tag)
+		}
+	}
+
+	return nil
+}
+
+// validateTracingConfig validates the OTLP endpoint and sets defaults when
+// tracing is enabled.
+func validateTracingConfig(tracing *TracingConfig) error {
+	if tracing.Endpoint == "" {
+		return nil
+	}
+	u, err := url.Parse(tracing.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("endpoint must be an http or https URL, got '%s'", tracing.Endpoint)
+	}
+	if tracing.ServiceName == "" {
+		tracing.ServiceName = "vault-cert-manager"
+	}
+	if tracing.Interval == 0 {
+		tracing.Interval = 5 * time.Second
+	}
+	if tracing.Interval < 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if tracing.Timeout == 0 {
+		tracing.Timeout = 10 * time.Second
+	}
+	if tracing.Timeout < 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	return nil
+}
+
+// validateChaosConfig validates fault-injection probabilities and sets defaults.
+func validateChaosConfig(chaos *ChaosConfig) error {
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{"issue_failure_rate", chaos.IssueFailureRate},
+		{"write_failure_rate", chaos.WriteFailureRate},
+		{"health_check_failure_rate", chaos.HealthCheckFailureRate},
+		{"delay_rate", chaos.DelayRate},
+	}
+	for _, r := range rates {
+		if r.rate < 0 || r.rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1, got %v", r.name, r.rate)
+		}
+	}
+
+	if chaos.DelayRate > 0 && chaos.MaxDelay == 0 {
+		chaos.MaxDelay = 5 * time.Second
+	}
+
+	return nil
+}
+
+// validateSourceConfig sets defaults for the central certificate source.
+func validateSourceConfig(source *SourceConfig) error {
+	if source.VaultKV != nil && source.ConsulKV != nil {
+		return fmt.Errorf("only one of vault_kv or consul_kv may be set")
+	}
+
+	if source.ConsulKV != nil {
+		return validateConsulKVSource(source.ConsulKV)
+	}
+
+	if source.VaultKV == nil {
+		return nil
+	}
+
+	kv := source.VaultKV
+	if kv.Path == "" {
+		return fmt.Errorf("vault_kv.path is required")
+	}
+	if kv.Mount == "" {
+		kv.Mount = "secret"
+	}
+	if kv.Version == 0 {
+		kv.Version = 2
+	}
+	if kv.Version != 1 && kv.Version != 2 {
+		return fmt.Errorf("vault_kv.version must be 1 or 2, got %d", kv.Version)
+	}
+	if kv.RefreshInterval == 0 {
+		kv.RefreshInterval = 5 * time.Minute
+	}
+	if kv.RefreshInterval < 0 {
+		return fmt.Errorf("vault_kv.refresh_interval must be positive")
+	}
+
+	return nil
+}
+
+// validateConsulKVSource sets defaults for the Consul KV source.
+func validateConsulKVSource(kv *ConsulKVSource) error {
+	if kv.Key == "" {
+		return fmt.Errorf("consul_kv.key is required")
+	}
+	if kv.Address == "" {
+		kv.Address = "http://127.0.0.1:8500"
+	}
+	if kv.Wait == 0 {
+		kv.Wait = 5 * time.Minute
+	}
+	if kv.Wait < 0 {
+		return fmt.Errorf("consul_kv.wait must be positive")
+	}
+
+	return nil
+}
+
+// validateNotificationsConfig sets defaults and checks every route has a
+// destination.
+func validateNotificationsConfig(notifications *NotificationsConfig) error {
+	if notifications.OwnerKey == "" {
+		notifications.OwnerKey = "team"
+	}
+
+	if notifications.Default != nil && !notifications.Default.hasDestination() {
+		return fmt.Errorf("default requires slack_webhook or pagerduty_routing_key")
+	}
+
+	owners := make([]string, 0, len(notifications.Routes))
+	for owner := range notifications.Routes {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+
+	for _, owner := range owners {
+		route := notifications.Routes[owner]
+		if !route.hasDestination() {
+			return fmt.Errorf("routes.%s requires slack_webhook or pagerduty_routing_key", owner)
+		}
+	}
+
+	return nil
+}
+
+// hasDestination reports whether the route sends anywhere.
+func (r *NotificationRoute) hasDestination() bool {
+	return r.SlackWebhook != "" || r.PagerDutyRoutingKey != ""
+}
+
+// validateAuthConfig validates the authentication configuration.
+func validateAuthConfig(auth *AuthConfig) error {
+	authMethods := 0
+
+	if auth.Token != nil {
+		authMethods++
+		if auth.Token.Value == "" {
+			return fmt.Errorf("token.value is required")
+		}
+	}
+
+	if auth.GCP != nil {
 		authMethods++
 		if auth.GCP.Role == "" {
 			return fmt.Errorf("gcp.role is required")
@@ -341,8 +2119,11 @@ func validateAuthConfig(auth *AuthConfig) error {
 		if auth.AppRole.RoleID == "" {
 			return fmt.Errorf("approle.role_id is required")
 		}
-		if auth.AppRole.SecretID == "" && auth.AppRole.SecretIDFile == "" {
-			return fmt.Errorf("approle.secret_id or approle.secret_id_file is required")
+		if auth.AppRole.SecretID == "" && auth.AppRole.SecretIDFile == "" && auth.AppRole.SecretIDWrappedFile == "" {
+			return fmt.Errorf("approle.secret_id, approle.secret_id_file, or approle.secret_id_wrapped_file is required")
+		}
+		if auth.AppRole.DeleteWrappedFile && auth.AppRole.SecretIDWrappedFile == "" {
+			return fmt.Errorf("approle.delete_wrapped_file requires approle.secret_id_wrapped_file")
 		}
 		if auth.AppRole.MountPath == "" {
 			auth.AppRole.MountPath = "approle"
@@ -364,6 +2145,21 @@ func hasAuthConfig(auth *AuthConfig) bool {
 	return auth.Token != nil || auth.GCP != nil || auth.TLS != nil || auth.AppRole != nil
 }
 
+// isFileMode reports whether s is an octal permission mode such as "0640".
+func isFileMode(s string) bool {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	return err == nil && mode <= 0777
+}
+
+// parseFileMode parses an octal mode, returning def when s is unset.
+func parseFileMode(s string, def os.FileMode) os.FileMode {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if s == "" || err != nil {
+		return def
+	}
+	return os.FileMode(mode)
+}
+
 // -------------------------------------------------------------------------
 // METHODS
 // -------------------------------------------------------------------------
@@ -372,3 +2168,199 @@ func hasAuthConfig(auth *AuthConfig) bool {
 func (c *CertificateConfig) IsCombinedFile() bool {
 	return c.Certificate == c.Key
 }
+
+// UnmarshalYAML accepts a fraction or a percentage string.
+func (p *Percent) UnmarshalYAML(value *yaml.Node) error {
+	text := strings.TrimSpace(value.Value)
+	if number, ok := strings.CutSuffix(text, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil {
+			return fmt.Errorf("invalid percentage %q", value.Value)
+		}
+		*p = Percent(percent / 100)
+		return nil
+	}
+
+	var fraction float64
+	if err := value.Decode(&fraction); err != nil {
+		return fmt.Errorf("invalid percentage %q", value.Value)
+	}
+	*p = Percent(fraction)
+	return nil
+}
+
+// CertificatePath returns the file the certificate is written to, which is
+// the configured path with a ShadowSuffix in shadow mode.
+func (c *CertificateConfig) CertificatePath() string {
+	return c.shadowPath(c.Certificate)
+}
+
+// KeyPath returns the file the private key is written to.
+func (c *CertificateConfig) KeyPath() string {
+	if c.Key == "" {
+		return ""
+	}
+	return c.shadowPath(c.Key)
+}
+
+// CAFilePath returns the file the CA chain is written to, or "" if unset.
+func (c *CertificateConfig) CAFilePath() string {
+	if c.CAFile == "" {
+		return ""
+	}
+	return c.shadowPath(c.CAFile)
+}
+
+// KeystorePath returns the file the JKS keystore is written to, or "" if
+// unset.
+func (c *CertificateConfig) KeystorePath() string {
+	if c.Keystore == nil {
+		return ""
+	}
+	return c.shadowPath(c.Keystore.Path)
+}
+
+// TruststorePath returns the file the JKS truststore is written to, or ""
+// if unset.
+func (c *CertificateConfig) TruststorePath() string {
+	if c.Keystore == nil || c.Keystore.Truststore == "" {
+		return ""
+	}
+	return c.shadowPath(c.Keystore.Truststore)
+}
+
+// OutputPath returns the file an additional output is written to.
+func (c *CertificateConfig) OutputPath(output OutputConfig) string {
+	return c.shadowPath(output.Path)
+}
+
+// shadowPath redirects path to its shadow file in shadow mode.
+func (c *CertificateConfig) shadowPath(path string) string {
+	if c.Shadow {
+		return path + ShadowSuffix
+	}
+	return path
+}
+
+// FileMode returns the output's permissions. Mode is validated on load.
+func (o OutputConfig) FileMode() os.FileMode {
+	return parseFileMode(o.Mode, 0)
+}
+
+// CertFileMode returns the mode of certificate, ca_file, and truststore
+// files.
+func (c *CertificateConfig) CertFileMode() os.FileMode {
+	return parseFileMode(c.CertMode, 0644)
+}
+
+// KeyFileMode returns the mode of key, combined, and keystore files.
+func (c *CertificateConfig) KeyFileMode() os.FileMode {
+	return parseFileMode(c.KeyMode, 0600)
+}
+
+// DirFileMode returns the mode of directories created for the
+// certificate's files.
+func (c *CertificateConfig) DirFileMode() os.FileMode {
+	return parseFileMode(c.DirMode, 0755)
+}
+
+// HasTargets reports whether any files or endpoints are watched.
+func (w *WatchConfig) HasTargets() bool {
+	return len(w.Files) > 0 || len(w.Endpoints) > 0
+}
+
+// Hash returns a short fingerprint of the configuration after defaults
+// were applied, so nodes running the same configuration report the same
+// value. It changes whenever any setting does.
+func (c *Config) Hash() string {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// IsWatchOnly reports whether the agent only watches certificates, so no
+// Vault is configured or needed.
+func (c *Config) IsWatchOnly() bool {
+	return c.Vault.Address == "" &&
+		len(c.Certificates) == 0 &&
+		c.Source.VaultKV == nil && c.Source.ConsulKV == nil &&
+		c.Watch.HasTargets()
+}
+
+// Target returns the address or command the health check probes.
+func (h *HealthCheck) Target() string {
+	if h.Command != "" {
+		return h.Command
+	}
+	return h.TCP
+}
+
+// DashboardEnabled reports whether the dashboard and its API are served.
+func (w *WebConfig) DashboardEnabled() bool {
+	return w.EnableDashboard == nil || *w.EnableDashboard
+}
+
+// MetricsEnabled reports whether /metrics is served.
+func (w *WebConfig) MetricsEnabled() bool {
+	return w.EnableMetrics == nil || *w.EnableMetrics
+}
+
+// HasOnChange returns true if a command or action runs after deployment.
+func (c *CertificateConfig) HasOnChange() bool {
+	return c.OnChange != "" || c.OnChangeAction != nil
+}
+
+// IsDER returns true if certificate, key, and CA files are written as DER.
+func (c *CertificateConfig) IsDER() bool {
+	return c.Encoding == "der"
+}
+
+// IsKVSource returns true if the certificate is pre-issued and read from KV.
+func (c *CertificateConfig) IsKVSource() bool {
+	return c.Source == "kv"
+}
+
+// IsACMESource returns true if the certificate is ordered over ACME.
+func (c *CertificateConfig) IsACMESource() bool {
+	return c.Source == "acme"
+}
+
+// IsCABundleSource returns true if the entry only syncs the PKI mount's CA
+// chain, with no key.
+func (c *CertificateConfig) IsCABundleSource() bool {
+	return c.Source == "ca_bundle"
+}
+
+// Refreshes reports whether the certificate is re-read from Vault every
+// RefreshInterval and deployed when it changed, rather than renewed before
+// it expires.
+func (c *CertificateConfig) Refreshes() bool {
+	return c.IsKVSource() || c.IsCABundleSource()
+}
+
+// RefreshInterval returns how often a refreshed certificate is re-read, or
+// zero for certificates that are renewed instead.
+func (c *CertificateConfig) RefreshInterval() time.Duration {
+	switch {
+	case c.IsKVSource() && c.KV != nil:
+		return c.KV.RefreshInterval
+	case c.IsCABundleSource() && c.CABundle != nil:
+		return c.CABundle.RefreshInterval
+	}
+	return 0
+}
+
+// IssuesMissing reports whether the manager creates the certificate's files
+// when they are missing or unreadable.
+func (c *CertificateConfig) IssuesMissing() bool {
+	return c.Policy != PolicyRenewOnly
+}
+
+// AutoRenews reports whether the manager renews the certificate on its own,
+// when it is due or its issuing CA rotated.
+func (c *CertificateConfig) AutoRenews() bool {
+	return c.Policy != PolicyIssueIfMissing
+}
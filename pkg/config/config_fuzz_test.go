@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Configuration Fuzz Tests
+//
+// Native Go fuzz tests for YAML configuration parsing and validation.
+// Run with: go test -fuzz=FuzzParseConfig ./pkg/config
+// -------------------------------------------------------------------------------
+
+package config
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// FUZZ TESTS
+// -------------------------------------------------------------------------
+
+// FuzzParseConfig verifies arbitrary input never panics parsing or validation.
+func FuzzParseConfig(f *testing.F) {
+	f.Add([]byte(`
+vault:
+  address: https://vault.example.com
+  auth:
+    token:
+      value: test-token
+certificates:
+  - name: test-cert
+    role: test-role
+    common_name: test.example.com
+    certificate: /tmp/test.crt
+    key: /tmp/test.key
+    ttl: 24h
+    key_type: ec
+    key_bits: 384
+    health_check:
+      tcp: 127.0.0.1:443
+`))
+	f.Add([]byte(`vault: {address: x, auth: {approle: {role_id: r, secret_id: s}, token: {value: t}}}`))
+	f.Add([]byte(`chaos: {enabled: true, delay_rate: 2}`))
+	f.Add([]byte(`certificates: [{name: a}, {name: a}]`))
+	f.Add([]byte("\x00\xff: ["))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cfg, err := parseConfig(data, "fuzz.yaml")
+		if err != nil {
+			return
+		}
+		_ = validateConfig(cfg)
+	})
+}
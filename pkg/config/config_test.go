@@ -14,6 +14,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // -------------------------------------------------------------------------
@@ -184,6 +187,21 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErr: false,
 		},
+		{
+			name: "negative ca check interval",
+			config: Config{
+				Vault: VaultConfig{
+					Address:         "https://vault.example.com",
+					CACheckInterval: -time.Minute,
+					Auth: AuthConfig{
+						Token: &TokenAuth{
+							Value: "test-token",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name: "missing vault address",
 			config: Config{
@@ -197,6 +215,73 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "empty failover address",
+			config: Config{
+				Vault: VaultConfig{
+					Address:   "https://vault.example.com",
+					Addresses: []string{""},
+					Auth: AuthConfig{
+						Token: &TokenAuth{
+							Value: "test-token",
+						},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "invalid metadata label",
+			config: Config{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com",
+					Auth: AuthConfig{
+						Token: &TokenAuth{
+							Value: "test-token",
+						},
+					},
+				},
+				Prometheus: PrometheusConfig{
+					MetadataLabels: []string{"cost-center"},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "notification route without destination",
+			config: Config{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com",
+					Auth: AuthConfig{
+						Token: &TokenAuth{
+							Value: "test-token",
+						},
+					},
+				},
+				Notifications: NotificationsConfig{
+					Routes: map[string]NotificationRoute{"database": {}},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "multiple certificate sources",
+			config: Config{
+				Vault: VaultConfig{
+					Address: "https://vault.example.com",
+					Auth: AuthConfig{
+						Token: &TokenAuth{
+							Value: "test-token",
+						},
+					},
+				},
+				Source: SourceConfig{
+					VaultKV:  &VaultKVSource{Path: "vault-cert-manager/web01"},
+					ConsulKV: &ConsulKVSource{Key: "vault-cert-manager/web01"},
+				},
+			},
+			expectErr: true,
+		},
 		{
 			name: "missing certificate name",
 			config: Config{
@@ -241,6 +326,830 @@ func TestValidateConfig(t *testing.T) {
 					t.Error("prometheus port should default to 9090")
 				}
 			}
+
+			if tt.config.Vault.CACheckInterval != time.Hour {
+				t.Errorf("vault.ca_check_interval should default to 1h, got %s", tt.config.Vault.CACheckInterval)
+			}
+
+			if rl := tt.config.API.RateLimit; rl.RequestsPerMinute != 10 || rl.Burst != 5 {
+				t.Errorf("api.rate_limit should default to 10/min burst 5, got %v/min burst %d", rl.RequestsPerMinute, rl.Burst)
+			}
+		})
+	}
+}
+
+// TestValidateAdaptiveRenewalConfig verifies adaptive renewal defaults and
+// threshold validation.
+func TestValidateAdaptiveRenewalConfig(t *testing.T) {
+	adaptive := &AdaptiveRenewalConfig{}
+	if err := validateAdaptiveRenewalConfig(adaptive); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if adaptive.ErrorRate != 0.2 || adaptive.Latency != 2*time.Second ||
+		adaptive.MinRequests != 3 || adaptive.Backoff != 10*time.Minute {
+		t.Errorf("unexpected defaults: %+v", adaptive)
+	}
+
+	if err := validateAdaptiveRenewalConfig(&AdaptiveRenewalConfig{ErrorRate: 1.5}); err == nil {
+		t.Error("expected error for error_rate above 1")
+	}
+	if err := validateAdaptiveRenewalConfig(&AdaptiveRenewalConfig{Backoff: -time.Minute}); err == nil {
+		t.Error("expected error for negative backoff")
+	}
+}
+
+// TestValidateConfig_Watch verifies watch defaults, target validation, and
+// that Vault is optional only for watch-only agents.
+func TestValidateConfig_Watch(t *testing.T) {
+	watchOnly := Config{Watch: WatchConfig{Files: []string{"/etc/ssl/*.pem"}, Endpoints: []string{"db.example.com:5432"}}}
+	if err := validateConfig(&watchOnly); err != nil {
+		t.Fatalf("unexpected error for watch-only config: %v", err)
+	}
+	if !watchOnly.IsWatchOnly() {
+		t.Error("expected config without Vault or certificates to be watch-only")
+	}
+	if watchOnly.Watch.Interval != 5*time.Minute || watchOnly.Watch.Timeout != 5*time.Second {
+		t.Errorf("unexpected watch defaults: %+v", watchOnly.Watch)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"no vault and no watch targets", Config{}},
+		{"certificates without vault", Config{
+			Watch:        WatchConfig{Files: []string{"/etc/ssl/*.pem"}},
+			Certificates: []CertificateConfig{{Name: "a", Role: "r", CommonName: "a.example.com", Certificate: "/tmp/a.crt", Key: "/tmp/a.key"}},
+		}},
+		{"invalid glob", Config{Watch: WatchConfig{Files: []string{"/etc/ssl/[.pem"}}}},
+		{"endpoint without port", Config{Watch: WatchConfig{Endpoints: []string{"db.example.com"}}}},
+		{"negative interval", Config{Watch: WatchConfig{Files: []string{"/a.pem"}, Interval: -time.Minute}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateConfig(&tt.config); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateBackupConfig verifies backup defaults and validation.
+func TestValidateBackupConfig(t *testing.T) {
+	backup := &BackupConfig{}
+	if err := validateBackupConfig(backup); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backup.Keep != 5 || backup.VerifyTimeout != 30*time.Second {
+		t.Errorf("unexpected defaults: %+v", backup)
+	}
+
+	if err := validateBackupConfig(&BackupConfig{Keep: -1}); err == nil {
+		t.Error("expected error for negative keep")
+	}
+}
+
+// TestValidateVersionedConfig verifies the versioned directory default and
+// validation.
+func TestValidateVersionedConfig(t *testing.T) {
+	cert := &CertificateConfig{Name: "web", Versioned: &VersionedConfig{Dir: "/etc/vault-cert-manager"}}
+	if err := validateVersionedConfig(cert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cert.Versioned.Keep != 10 {
+		t.Errorf("expected keep default 10, got %d", cert.Versioned.Keep)
+	}
+
+	tests := []struct {
+		name string
+		cert CertificateConfig
+	}{
+		{name: "missing dir", cert: CertificateConfig{Versioned: &VersionedConfig{}}},
+		{name: "relative dir", cert: CertificateConfig{Versioned: &VersionedConfig{Dir: "certs"}}},
+		{name: "negative keep", cert: CertificateConfig{Versioned: &VersionedConfig{Dir: "/certs", Keep: -1}}},
+		{name: "der", cert: CertificateConfig{Encoding: "der", Versioned: &VersionedConfig{Dir: "/certs"}}},
+		{name: "ca bundle", cert: CertificateConfig{Source: "ca_bundle", Versioned: &VersionedConfig{Dir: "/certs"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateVersionedConfig(&tt.cert); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateKeystoreConfig verifies keystore paths, password sources, and
+// the default alias.
+func TestValidateKeystoreConfig(t *testing.T) {
+	cert := &CertificateConfig{
+		Name:        "web",
+		Certificate: "/etc/ssl/web.crt",
+		Key:         "/etc/ssl/web.key",
+		Keystore:    &KeystoreConfig{Path: "/etc/ssl/web.jks", Password: "changeit"},
+	}
+	if err := validateKeystoreConfig(cert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cert.Keystore.Alias != "web" {
+		t.Errorf("expected default alias web, got %q", cert.Keystore.Alias)
+	}
+
+	tests := []struct {
+		name     string
+		keystore KeystoreConfig
+	}{
+		{name: "missing path", keystore: KeystoreConfig{Password: "changeit"}},
+		{name: "path is certificate", keystore: KeystoreConfig{Path: "/etc/ssl/web.crt", Password: "changeit"}},
+		{name: "truststore is path", keystore: KeystoreConfig{Path: "/etc/ssl/web.jks", Truststore: "/etc/ssl/web.jks", Password: "changeit"}},
+		{name: "missing password", keystore: KeystoreConfig{Path: "/etc/ssl/web.jks"}},
+		{name: "both passwords", keystore: KeystoreConfig{Path: "/etc/ssl/web.jks", Password: "changeit", PasswordFile: "/etc/ssl/pw"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &CertificateConfig{Name: "web", Certificate: "/etc/ssl/web.crt", Key: "/etc/ssl/web.key", Keystore: &tt.keystore}
+			if err := validateKeystoreConfig(cert); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateRenewalPolicy verifies renew_before and renew_at_percent
+// bounds.
+func TestValidateRenewalPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		cert    CertificateConfig
+		wantErr bool
+	}{
+		{name: "default", cert: CertificateConfig{Source: "pki", TTL: 24 * time.Hour}},
+		{name: "renew_before", cert: CertificateConfig{Source: "pki", TTL: 90 * 24 * time.Hour, RenewBefore: 14 * 24 * time.Hour}},
+		{name: "renew_at_percent", cert: CertificateConfig{Source: "pki", TTL: time.Hour, RenewAtPercent: 0.66}},
+		{name: "renew_before for kv", cert: CertificateConfig{Source: "kv", RenewBefore: 30 * 24 * time.Hour}},
+		{name: "negative renew_before", cert: CertificateConfig{Source: "pki", TTL: time.Hour, RenewBefore: -time.Minute}, wantErr: true},
+		{name: "renew_before exceeds ttl", cert: CertificateConfig{Source: "pki", TTL: time.Hour, RenewBefore: 2 * time.Hour}, wantErr: true},
+		{name: "renew_at_percent as percentage", cert: CertificateConfig{Source: "pki", TTL: time.Hour, RenewAtPercent: 66}, wantErr: true},
+		{name: "issue_if_missing policy", cert: CertificateConfig{Source: "pki", TTL: time.Hour, Policy: PolicyIssueIfMissing}},
+		{name: "renew_only policy", cert: CertificateConfig{Source: "pki", TTL: time.Hour, Policy: PolicyRenewOnly}},
+		{name: "unknown policy", cert: CertificateConfig{Source: "pki", TTL: time.Hour, Policy: "ignore"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRenewalPolicy(&tt.cert)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && tt.cert.Policy == "" {
+				t.Error("expected policy default to be set")
+			}
+		})
+	}
+}
+
+// TestValidateOutputs verifies output types, path conflicts, modes, and
+// inherited ownership.
+func TestValidateOutputs(t *testing.T) {
+	cert := &CertificateConfig{
+		Name:        "web",
+		Certificate: "/etc/ssl/web.crt",
+		Key:         "/etc/ssl/web.key",
+		Owner:       "nginx",
+		Outputs: []OutputConfig{
+			{Type: "fullchain", Path: "/etc/nginx/web.fullchain.pem"},
+			{Type: "key", Path: "/var/lib/postgresql/server.key", Owner: "postgres"},
+			{Type: "leaf", Path: "/var/lib/postgresql/server.crt", Mode: "0640"},
+		},
+	}
+	if err := validateOutputs(cert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode := cert.Outputs[0].FileMode(); mode != 0644 {
+		t.Errorf("expected default fullchain mode 0644, got %v", mode)
+	}
+	if mode := cert.Outputs[1].FileMode(); mode != 0600 {
+		t.Errorf("expected default key mode 0600, got %v", mode)
+	}
+	if mode := cert.Outputs[2].FileMode(); mode != 0640 {
+		t.Errorf("expected mode 0640, got %v", mode)
+	}
+	if cert.Outputs[0].Owner != "nginx" || cert.Outputs[1].Owner != "postgres" {
+		t.Errorf("unexpected owners %q, %q", cert.Outputs[0].Owner, cert.Outputs[1].Owner)
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		output   OutputConfig
+	}{
+		{name: "unknown type", output: OutputConfig{Type: "bundle", Path: "/etc/ssl/web.bundle"}},
+		{name: "missing path", output: OutputConfig{Type: "leaf"}},
+		{name: "path is key", output: OutputConfig{Type: "leaf", Path: "/etc/ssl/web.key"}},
+		{name: "invalid mode", output: OutputConfig{Type: "leaf", Path: "/etc/ssl/leaf.crt", Mode: "rw-r--r--"}},
+		{name: "combined with der", encoding: "der", output: OutputConfig{Type: "combined", Path: "/etc/ssl/web.pem"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := &CertificateConfig{
+				Name:        "web",
+				Certificate: "/etc/ssl/web.crt",
+				Key:         "/etc/ssl/web.key",
+				Encoding:    tt.encoding,
+				Outputs:     []OutputConfig{tt.output},
+			}
+			if err := validateOutputs(cert); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateActionConfig verifies reload action defaults and that
+// exactly one valid action is required.
+func TestValidateActionConfig(t *testing.T) {
+	action := &ActionConfig{Systemd: "nginx.service"}
+	if err := validateActionConfig(action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if action.SystemdMode != "reload" {
+		t.Errorf("expected default systemd_mode reload, got %q", action.SystemdMode)
+	}
+
+	action = &ActionConfig{Signal: &SignalAction{Pidfile: "/run/nginx.pid"}}
+	if err := validateActionConfig(action); err != nil || action.Signal.Signal != "HUP" {
+		t.Errorf("expected default signal HUP, got %q (%v)", action.Signal.Signal, err)
+	}
+
+	action = &ActionConfig{HTTP: &HTTPAction{URL: "http://localhost:8080/reload", Method: "put"}}
+	if err := validateActionConfig(action); err != nil || action.HTTP.Method != "PUT" {
+		t.Errorf("expected method PUT, got %q (%v)", action.HTTP.Method, err)
+	}
+
+	tests := []struct {
+		name   string
+		action ActionConfig
+	}{
+		{name: "none", action: ActionConfig{}},
+		{name: "two actions", action: ActionConfig{Systemd: "nginx.service", Docker: "web"}},
+		{name: "unknown systemd_mode", action: ActionConfig{Systemd: "nginx.service", SystemdMode: "stop"}},
+		{name: "systemd_mode without systemd", action: ActionConfig{Docker: "web", SystemdMode: "restart"}},
+		{name: "missing pidfile", action: ActionConfig{Signal: &SignalAction{Signal: "HUP"}}},
+		{name: "unknown signal", action: ActionConfig{Signal: &SignalAction{Pidfile: "/run/nginx.pid", Signal: "KILL"}}},
+		{name: "relative url", action: ActionConfig{HTTP: &HTTPAction{URL: "/reload"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateActionConfig(&tt.action); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateHookPolicy verifies on_change timeout and retry defaults and
+// rejection of invalid values.
+func TestValidateHookPolicy(t *testing.T) {
+	policy := &HookPolicy{Retries: 3}
+	if err := validateHookPolicy(policy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.Timeout != 5*time.Minute || policy.Backoff != 5*time.Second || policy.MaxBackoff != time.Minute {
+		t.Errorf("unexpected defaults %+v", policy)
+	}
+
+	for name, policy := range map[string]HookPolicy{
+		"negative timeout":          {Timeout: -time.Second},
+		"negative retries":          {Retries: -1},
+		"negative backoff":          {Backoff: -time.Second},
+		"max_backoff below backoff": {Backoff: time.Minute, MaxBackoff: time.Second},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := validateHookPolicy(&policy); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateFileModes verifies default and configured certificate file
+// modes and rejection of non-octal modes.
+func TestValidateFileModes(t *testing.T) {
+	cert := &CertificateConfig{Name: "web", KeyMode: "0640"}
+	if err := validateFileModes(cert); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode := cert.CertFileMode(); mode != 0644 {
+		t.Errorf("expected default cert mode 0644, got %v", mode)
+	}
+	if mode := cert.KeyFileMode(); mode != 0640 {
+		t.Errorf("expected key mode 0640, got %v", mode)
+	}
+	if mode := cert.DirFileMode(); mode != 0755 {
+		t.Errorf("expected default dir mode 0755, got %v", mode)
+	}
+
+	tests := []struct {
+		name string
+		cert CertificateConfig
+	}{
+		{name: "symbolic cert mode", cert: CertificateConfig{CertMode: "rw-r--r--"}},
+		{name: "non-octal key mode", cert: CertificateConfig{KeyMode: "0999"}},
+		{name: "special bits in dir mode", cert: CertificateConfig{DirMode: "4755"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateFileModes(&tt.cert); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// TestValidateNodeConfig verifies cloud metadata provider and timeout validation.
+func TestValidateNodeConfig(t *testing.T) {
+	node := &NodeConfig{CloudMetadata: "auto"}
+	if err := validateNodeConfig(node); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if node.CloudMetadataTimeout != 2*time.Second {
+		t.Errorf("expected default timeout 2s, got %v", node.CloudMetadataTimeout)
+	}
+
+	if err := validateNodeConfig(&NodeConfig{CloudMetadata: "openstack"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+	if err := validateNodeConfig(&NodeConfig{CloudMetadata: "ec2", CloudMetadataTimeout: -time.Second}); err == nil {
+		t.Error("expected error for negative timeout")
+	}
+}
+
+// TestValidateWebConfig verifies the port defaults to the Prometheus port,
+// TLS files are set together, and endpoints are enabled by default.
+func TestValidateWebConfig(t *testing.T) {
+	web := &WebConfig{}
+	if err := validateWebConfig(web, 9090); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if web.Port != 9090 {
+		t.Errorf("expected port to default to 9090, got %d", web.Port)
+	}
+	if !web.DashboardEnabled() || !web.MetricsEnabled() {
+		t.Error("expected dashboard and metrics enabled by default")
+	}
+
+	disabled := false
+	if (&WebConfig{EnableDashboard: &disabled}).DashboardEnabled() {
+		t.Error("expected enable_dashboard: false to disable the dashboard")
+	}
+
+	if err := validateWebConfig(&WebConfig{TLSCertFile: "/etc/ssl/web.crt"}, 9090); err == nil {
+		t.Error("expected error for tls_cert_file without tls_key_file")
+	}
+	if err := validateWebConfig(&WebConfig{TLSClientCAFile: "/etc/ssl/clients-ca.pem"}, 9090); err == nil {
+		t.Error("expected error for tls_client_ca_file without tls_cert_file")
+	}
+	if err := validateWebConfig(&WebConfig{Port: 70000}, 9090); err == nil {
+		t.Error("expected error for out-of-range port")
+	}
+}
+
+// TestValidatePushgatewayConfig verifies defaults and URL validation.
+func TestValidatePushgatewayConfig(t *testing.T) {
+	push := &PushgatewayConfig{URL: "http://pushgateway:9091"}
+	if err := validatePushgatewayConfig(push); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if push.Job != "vault-cert-manager" || push.Instance == "" || push.Timeout != 10*time.Second {
+		t.Errorf("unexpected pushgateway defaults: %+v", push)
+	}
+
+	if err := validatePushgatewayConfig(&PushgatewayConfig{URL: "pushgateway:9091"}); err == nil {
+		t.Error("expected error for a URL without a scheme")
+	}
+	if err := validatePushgatewayConfig(&PushgatewayConfig{URL: "http://pushgateway:9091", Timeout: -time.Second}); err == nil {
+		t.Error("expected error for negative timeout")
+	}
+}
+
+// TestValidateStatsDConfig verifies the host is required, the port
+// defaults to 8125, and tags cannot break the line format.
+func TestValidateStatsDConfig(t *testing.T) {
+	statsd := &StatsDConfig{Host: "localhost", Tags: []string{"env:prod"}}
+	if err := validateStatsDConfig(statsd); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if statsd.Port != 8125 {
+		t.Errorf("expected port to default to 8125, got %d", statsd.Port)
+	}
+
+	for name, statsd := range map[string]*StatsDConfig{
+		"no host":        {},
+		"invalid port":   {Host: "localhost", Port: 70000},
+		"tag with comma": {Host: "localhost", Tags: []string{"env:prod,team:db"}},
+	} {
+		if err := validateStatsDConfig(statsd); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestValidateTracingConfig verifies tracing is optional, defaults are set
+// once an endpoint is configured, and the endpoint must be an HTTP URL.
+func TestValidateTracingConfig(t *testing.T) {
+	if err := validateTracingConfig(&TracingConfig{}); err != nil {
+		t.Fatalf("unexpected error for disabled tracing: %v", err)
+	}
+
+	tracing := &TracingConfig{Endpoint: "http://otel-collector:4318/v1/traces"}
+	if err := validateTracingConfig(tracing); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracing.ServiceName != "vault-cert-manager" || tracing.Interval != 5*time.Second || tracing.Timeout != 10*time.Second {
+		t.Errorf("unexpected tracing defaults: %+v", tracing)
+	}
+
+	if err := validateTracingConfig(&TracingConfig{Endpoint: "otel-collector:4318"}); err == nil {
+		t.Error("expected error for an endpoint without a scheme")
+	}
+	if err := validateTracingConfig(&TracingConfig{Endpoint: "http://otel-collector:4318/v1/traces", Interval: -time.Second}); err == nil {
+		t.Error("expected error for negative interval")
+	}
+}
+
+// TestValidateKeyConfig verifies key type, size, and format validation.
+func TestValidateKeyConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		cert      CertificateConfig
+		expectErr bool
+	}{
+		{name: "defaults", cert: CertificateConfig{}},
+		{name: "ec p-384", cert: CertificateConfig{KeyType: "ec", KeyBits: 384}},
+		{name: "rsa 4096 pkcs8", cert: CertificateConfig{KeyType: "rsa", KeyBits: 4096, PrivateKeyFormat: "pkcs8"}},
+		{name: "ed25519", cert: CertificateConfig{KeyType: "ed25519"}},
+		{name: "unknown type", cert: CertificateConfig{KeyType: "dsa"}, expectErr: true},
+		{name: "bits mismatch", cert: CertificateConfig{KeyType: "ec", KeyBits: 2048}, expectErr: true},
+		{name: "bits without type", cert: CertificateConfig{KeyBits: 2048}, expectErr: true},
+		{name: "der format", cert: CertificateConfig{PrivateKeyFormat: "der"}, expectErr: true},
+		{name: "der encoding", cert: CertificateConfig{Certificate: "/a.der", Key: "/a.key", Encoding: "der"}},
+		{name: "unknown encoding", cert: CertificateConfig{Encoding: "p12"}, expectErr: true},
+		{name: "der combined file", cert: CertificateConfig{Certificate: "/a.der", Key: "/a.der", Encoding: "der"}, expectErr: true},
+		{name: "encrypted key", cert: CertificateConfig{KeyEncryption: &KeyEncryptionConfig{PassphraseEnv: "KEY_PASSPHRASE"}}},
+		{name: "encrypted key without passphrase", cert: CertificateConfig{KeyEncryption: &KeyEncryptionConfig{}}, expectErr: true},
+		{name: "encrypted key with both passphrases", cert: CertificateConfig{KeyEncryption: &KeyEncryptionConfig{PassphraseFile: "/p", PassphraseEnv: "P"}}, expectErr: true},
+		{name: "encrypted der key", cert: CertificateConfig{Encoding: "der", KeyEncryption: &KeyEncryptionConfig{PassphraseFile: "/p"}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeyConfig(&tt.cert)
+			if tt.expectErr && err == nil {
+				t.Error("expected error but got none")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_CertificateVaultOverride verifies per-certificate Vault
+// overrides inherit global auth and retry settings.
+func TestValidateConfig_CertificateVaultOverride(t *testing.T) {
+	cfg := Config{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    AuthConfig{Token: &TokenAuth{Value: "global-token"}},
+		},
+		Certificates: []CertificateConfig{
+			{
+				Name:        "dr-cert",
+				Role:        "web",
+				CommonName:  "dr.example.com",
+				Certificate: "/tmp/dr.crt",
+				Key:         "/tmp/dr.key",
+				Vault:       &VaultConfig{Address: "https://vault-dr.example.com", PKIMount: "pki_dr"},
+			},
+		},
+	}
+
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	override := cfg.Certificates[0].Vault
+	if override.Auth.Token == nil || override.Auth.Token.Value != "global-token" {
+		t.Error("expected override to inherit global token auth")
+	}
+	if override.Retry.MaxAttempts != cfg.Vault.Retry.MaxAttempts {
+		t.Error("expected override to inherit global retry policy")
+	}
+
+	cfg.Certificates[0].Vault = &VaultConfig{}
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for override without address")
+	}
+}
+
+// TestValidateConfig_HealthCheck verifies health check defaults, the
+// supported STARTTLS modes, and the verify and client_auth settings.
+func TestValidateConfig_HealthCheck(t *testing.T) {
+	cfg := Config{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    AuthConfig{Token: &TokenAuth{Value: "test-token"}},
+		},
+		Certificates: []CertificateConfig{
+			{
+				Name:        "mail",
+				Role:        "mail",
+				CommonName:  "mail.example.com",
+				Certificate: "/tmp/mail.crt",
+				Key:         "/tmp/mail.key",
+				HealthCheck: &HealthCheck{TCP: "mail.example.com:587", StartTLS: "smtp"},
+			},
+		},
+	}
+
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Certificates[0].HealthCheck.Timeout != 5*time.Second {
+		t.Errorf("expected default timeout, got %s", cfg.Certificates[0].HealthCheck.Timeout)
+	}
+
+	cfg.Certificates[0].HealthCheck.StartTLS = "imap"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for unsupported starttls mode")
+	}
+
+	cfg.Certificates[0].HealthCheck.StartTLS = ""
+	cfg.Certificates[0].HealthCheck.ConfirmTimeout = -time.Second
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for negative confirm_timeout")
+	}
+
+	cfg.Certificates[0].HealthCheck.ConfirmTimeout = 0
+	cfg.Certificates[0].HealthCheck.CAFile = "/etc/ssl/internal-ca.pem"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for ca_file without verify")
+	}
+
+	cfg.Certificates[0].HealthCheck.CAFile = ""
+	cfg.Certificates[0].HealthCheck.ClientAuth = true
+	if err := validateConfig(&cfg); err != nil {
+		t.Errorf("unexpected error for client_auth with the managed certificate: %v", err)
+	}
+
+	cfg.Certificates[0].HealthCheck.ClientCertificate = "/etc/ssl/client.crt"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for client_certificate without client_key")
+	}
+
+	cfg.Certificates[0].HealthCheck.ClientKey = "/etc/ssl/client.key"
+	cfg.Certificates[0].HealthCheck.ClientAuth = false
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for client_certificate without client_auth")
+	}
+
+	cfg.Certificates[0].HealthCheck.ClientCertificate = ""
+	cfg.Certificates[0].HealthCheck.ClientKey = ""
+	cfg.Certificates[0].HealthCheck.Remediate = &RemediateConfig{}
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for remediate without a command or on_change")
+	}
+
+	cfg.Certificates[0].OnChange = "systemctl reload postfix"
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Certificates[0].HealthCheck.Remediate.After != 5*time.Minute {
+		t.Errorf("expected default remediate.after, got %s", cfg.Certificates[0].HealthCheck.Remediate.After)
+	}
+
+	cfg.Certificates[0].HealthCheck.Command = "openssl s_client -connect mail.example.com:465 </dev/null"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for tcp with command")
+	}
+
+	cfg.Certificates[0].HealthCheck.TCP = ""
+	if err := validateConfig(&cfg); err != nil {
+		t.Errorf("unexpected error for a command health check: %v", err)
+	}
+
+	cfg.Certificates[0].HealthCheck.Verify = true
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for verify with command")
+	}
+}
+
+// TestValidateConfig_CertificateKVSource verifies KV-sourced certificates
+// need a path instead of a role and get defaults.
+func TestValidateConfig_CertificateKVSource(t *testing.T) {
+	cfg := Config{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    AuthConfig{Token: &TokenAuth{Value: "test-token"}},
+		},
+		Certificates: []CertificateConfig{
+			{
+				Name:        "wildcard",
+				CommonName:  "*.example.com",
+				Certificate: "/tmp/wildcard.crt",
+				Key:         "/tmp/wildcard.key",
+				Source:      "kv",
+				KV:          &CertKVSource{Path: "certs/wildcard", Version: 3},
+			},
+		},
+	}
+
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kv := cfg.Certificates[0].KV
+	if kv.Mount != "secret" || kv.RefreshInterval != 5*time.Minute {
+		t.Errorf("expected kv defaults, got mount %q refresh %s", kv.Mount, kv.RefreshInterval)
+	}
+
+	cfg.Certificates[0].IssuerRef = "root-2024"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for issuer_ref on a kv source")
+	}
+
+	cfg.Certificates[0].IssuerRef = ""
+	cfg.Certificates[0].ReusePrivateKey = true
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for reuse_private_key on a kv source")
+	}
+
+	cfg.Certificates[0].ReusePrivateKey = false
+	cfg.Certificates[0].KV = nil
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for kv source without kv.path")
+	}
+
+	cfg.Certificates[0].Source = "vault"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+// TestValidateConfig_CertificateCABundleSource verifies CA bundle entries
+// need neither a key nor a common name and refuse key-only settings.
+func TestValidateConfig_CertificateCABundleSource(t *testing.T) {
+	cfg := Config{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    AuthConfig{Token: &TokenAuth{Value: "test-token"}},
+		},
+		Certificates: []CertificateConfig{
+			{
+				Name:        "internal-ca",
+				Certificate: "/etc/ssl/internal-ca.pem",
+				Source:      "ca_bundle",
+				IssuerRef:   "int-2025",
+			},
+		},
+	}
+
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cert := cfg.Certificates[0]
+	if cert.RefreshInterval() != time.Hour {
+		t.Errorf("expected 1h refresh default, got %s", cert.RefreshInterval())
+	}
+	if cert.KeyPath() != "" || !cert.Refreshes() {
+		t.Errorf("unexpected key path %q or refresh %v", cert.KeyPath(), cert.Refreshes())
+	}
+
+	cfg.Certificates[0].Key = "/etc/ssl/internal-ca.key"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for key on a ca_bundle source")
+	}
+
+	cfg.Certificates[0].Key = ""
+	cfg.Certificates[0].Encoding = "der"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for der encoding on a ca_bundle source")
+	}
+
+	cfg.Certificates[0].Encoding = ""
+	cfg.Certificates[0].Source = "pki"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for ca_bundle settings without source 'ca_bundle'")
+	}
+}
+
+// TestValidateConfig_CertificateACMESource verifies ACME certificates need
+// the acme section and a solver for their challenge type.
+func TestValidateConfig_CertificateACMESource(t *testing.T) {
+	cfg := Config{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    AuthConfig{Token: &TokenAuth{Value: "test-token"}},
+		},
+		Certificates: []CertificateConfig{
+			{
+				Name:        "public",
+				CommonName:  "www.example.com",
+				Certificate: "/tmp/public.crt",
+				Key:         "/tmp/public.key",
+				Source:      "acme",
+			},
+		},
+	}
+
+	if err := validateConfig(&cfg); err == nil {
+		t.Fatal("expected error for acme source without acme section")
+	}
+
+	cfg.ACME = &ACMEConfig{
+		AccountKeyFile: "/var/lib/vault-cert-manager/acme.key",
+		DNS01:          &DNS01Config{Command: "/usr/local/bin/dns-hook"},
+	}
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.ACME.DirectoryURL != "https://acme-v02.api.letsencrypt.org/directory" {
+		t.Errorf("expected Let's Encrypt directory default, got %q", cfg.ACME.DirectoryURL)
+	}
+	if cfg.ACME.DNS01.PropagationDelay != 30*time.Second {
+		t.Errorf("expected 30s propagation delay, got %s", cfg.ACME.DNS01.PropagationDelay)
+	}
+	if cfg.Certificates[0].ACME.Challenge != "dns-01" {
+		t.Errorf("expected challenge to default to the only solver, got %q", cfg.Certificates[0].ACME.Challenge)
+	}
+
+	cfg.Certificates[0].ACME.Challenge = "http-01"
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for http-01 without acme.http01")
+	}
+
+	cfg.ACME.HTTP01 = &HTTP01Config{}
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ACME.HTTP01.Listen != ":80" {
+		t.Errorf("expected http01 listen default :80, got %q", cfg.ACME.HTTP01.Listen)
+	}
+
+	cfg.Certificates[0].IPSans = []string{"10.0.0.1"}
+	if err := validateConfig(&cfg); err == nil {
+		t.Error("expected error for ip_sans with acme source")
+	}
+}
+
+// TestValidateSubjectConfig verifies subject field and extra SAN validation.
+func TestValidateSubjectConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		cert      CertificateConfig
+		expectErr bool
+	}{
+		{name: "empty", cert: CertificateConfig{}},
+		{name: "subject fields", cert: CertificateConfig{OU: []string{"Platform"}, Organization: []string{"Example Inc"}, Country: []string{"US"}}},
+		{name: "uri san", cert: CertificateConfig{URISans: []string{"spiffe://example.com/web"}}},
+		{name: "relative uri san", cert: CertificateConfig{URISans: []string{"example.com/web"}}, expectErr: true},
+		{name: "other san", cert: CertificateConfig{OtherSans: []string{"1.3.6.1.4.1.311.20.2.3;UTF8:web@example.com"}}},
+		{name: "other san without type", cert: CertificateConfig{OtherSans: []string{"1.3.6.1.4.1.311.20.2.3:web@example.com"}}, expectErr: true},
+		{name: "comma in organization", cert: CertificateConfig{Organization: []string{"Example, Inc"}}, expectErr: true},
+		{name: "empty locality", cert: CertificateConfig{Locality: []string{" "}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSubjectConfig(&tt.cert)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error: %v, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateSandboxConfig verifies on_change sandbox validation.
+func TestValidateSandboxConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		sandbox   SandboxConfig
+		expectErr bool
+	}{
+		{name: "minimal env", sandbox: SandboxConfig{MinimalEnv: true, Env: []string{"HOME"}}},
+		{name: "env without minimal_env", sandbox: SandboxConfig{Env: []string{"HOME"}}, expectErr: true},
+		{name: "landlock", sandbox: SandboxConfig{Landlock: &LandlockConfig{ReadOnly: []string{"/usr"}}}},
+		{name: "landlock without paths", sandbox: SandboxConfig{Landlock: &LandlockConfig{}}, expectErr: true},
+		{name: "relative landlock path", sandbox: SandboxConfig{Landlock: &LandlockConfig{ReadWrite: []string{"certs"}}}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSandboxConfig(&tt.sandbox)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("expected error: %v, got %v", tt.expectErr, err)
+			}
 		})
 	}
 }
@@ -279,3 +1188,195 @@ func TestCertificateConfig_IsCombinedFile(t *testing.T) {
 		})
 	}
 }
+
+// TestParseCertificates verifies remote definitions are validated against
+// local certificates and returned with defaults applied.
+func TestParseCertificates(t *testing.T) {
+	cfg := &Config{
+		Vault: VaultConfig{
+			Address: "https://vault.example.com",
+			Auth:    AuthConfig{Token: &TokenAuth{Value: "test-token"}},
+		},
+		Certificates: []CertificateConfig{
+			{Name: "local", Role: "web", CommonName: "local.example.com", Certificate: "/tmp/l.crt", Key: "/tmp/l.key"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		doc       string
+		expectErr bool
+	}{
+		{
+			name: "yaml document",
+			doc: `
+certificates:
+  - name: remote
+    role: web
+    common_name: remote.example.com
+    certificate: /tmp/r.crt
+    key: /tmp/r.key
+`,
+		},
+		{
+			name: "json list",
+			doc:  `{"certificates":[{"name":"remote","role":"web","common_name":"remote.example.com","certificate":"/tmp/r.crt","key":"/tmp/r.key"}]}`,
+		},
+		{
+			name: "collides with local",
+			doc: `
+certificates:
+  - name: local
+    role: web
+    common_name: other.example.com
+    certificate: /tmp/o.crt
+    key: /tmp/o.key
+`,
+			expectErr: true,
+		},
+		{
+			name:      "missing role",
+			doc:       `{"certificates":[{"name":"remote","common_name":"remote.example.com","certificate":"/tmp/r.crt","key":"/tmp/r.key"}]}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certs, err := ParseCertificates([]byte(tt.doc), cfg)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(certs) != 1 || certs[0].Name != "remote" {
+				t.Fatalf("expected only the remote certificate, got %+v", certs)
+			}
+			if certs[0].TTL != 24*time.Hour {
+				t.Errorf("expected default TTL, got %v", certs[0].TTL)
+			}
+		})
+	}
+}
+
+// TestValidateConfig_Integrity verifies integrity watching defaults and
+// that reissue requires watching.
+func TestValidateConfig_Integrity(t *testing.T) {
+	watch := WatchConfig{Files: []string{"/etc/ssl/*.pem"}}
+
+	cfg := Config{Watch: watch, Integrity: IntegrityConfig{Watch: true, Reissue: true}}
+	if err := validateConfig(&cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Integrity.Debounce != 2*time.Second {
+		t.Errorf("expected default debounce of 2s, got %v", cfg.Integrity.Debounce)
+	}
+
+	for name, integrity := range map[string]IntegrityConfig{
+		"reissue without watch": {Reissue: true},
+		"negative debounce":     {Watch: true, Debounce: -time.Second},
+	} {
+		cfg := Config{Watch: watch, Integrity: integrity}
+		if err := validateConfig(&cfg); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// TestPercent_UnmarshalYAML verifies renew_at_percent accepts fractions and
+// percentage strings.
+func TestPercent_UnmarshalYAML(t *testing.T) {
+	tests := map[string]Percent{
+		"renew_at_percent: 0.66":  0.66,
+		"renew_at_percent: 66%":   0.66,
+		"renew_at_percent: '75%'": 0.75,
+	}
+	for doc, want := range tests {
+		var cert CertificateConfig
+		if err := yaml.Unmarshal([]byte(doc), &cert); err != nil {
+			t.Errorf("%s: unexpected error: %v", doc, err)
+			continue
+		}
+		if diff := float64(cert.RenewAtPercent - want); diff > 1e-9 || diff < -1e-9 {
+			t.Errorf("%s: expected %v, got %v", doc, want, cert.RenewAtPercent)
+		}
+	}
+
+	var cert CertificateConfig
+	if err := yaml.Unmarshal([]byte("renew_at_percent: many%"), &cert); err == nil {
+		t.Error("expected error for invalid percentage")
+	}
+}
+
+// TestConfig_Hash verifies the hash is stable for the same settings and
+// changes with any setting.
+func TestConfig_Hash(t *testing.T) {
+	cfg := &Config{Vault: VaultConfig{Address: "https://vault.example.com:8200"}}
+	hash := cfg.Hash()
+	if hash == "" || hash != cfg.Hash() {
+		t.Fatalf("expected a stable hash, got %q and %q", hash, cfg.Hash())
+	}
+
+	cfg.Certificates = append(cfg.Certificates, CertificateConfig{Name: "web"})
+	if cfg.Hash() == hash {
+		t.Error("expected the hash to change with the certificates")
+	}
+}
+
+// TestValidateCertLabels verifies certificate labels must be valid label
+// names that do not collide with existing metric labels.
+func TestValidateCertLabels(t *testing.T) {
+	tests := map[string]bool{
+		"team":         true,
+		"service_tier": true,
+		"cost-center":  false,
+		"__team":       false,
+		"name":         false,
+		"instance":     false,
+		"cloud_zone":   false,
+	}
+	for key, valid := range tests {
+		err := validateCertLabels(map[string]string{key: "value"})
+		if valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", key, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("%s: expected error", key)
+		}
+	}
+}
+
+// TestValidateAPIAuthConfig verifies API credentials must be complete.
+func TestValidateAPIAuthConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		auth      APIAuthConfig
+		expectErr bool
+	}{
+		{name: "basic auth", auth: APIAuthConfig{Username: "admin", Password: "hunter2"}},
+		{name: "tokens", auth: APIAuthConfig{Tokens: []string{"s3cret"}, ProtectStatus: true}},
+		{name: "no credentials", auth: APIAuthConfig{ProtectStatus: true}, expectErr: true},
+		{name: "username without password", auth: APIAuthConfig{Username: "admin"}, expectErr: true},
+		{name: "empty token", auth: APIAuthConfig{Tokens: []string{""}}, expectErr: true},
+		{name: "oidc", auth: APIAuthConfig{OIDC: &OIDCConfig{IssuerURL: "https://sso.example.com", ClientID: "vcm", ClientSecret: "s", RedirectURL: "https://node1:9101/auth/callback"}}},
+		{name: "oidc without client id", auth: APIAuthConfig{OIDC: &OIDCConfig{IssuerURL: "https://sso.example.com", ClientSecret: "s", RedirectURL: "https://node1:9101/auth/callback"}}, expectErr: true},
+		{name: "oidc wrong redirect path", auth: APIAuthConfig{OIDC: &OIDCConfig{IssuerURL: "https://sso.example.com", ClientID: "vcm", ClientSecret: "s", RedirectURL: "https://node1:9101/callback"}}, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAPIAuthConfig(&tt.auth)
+			if tt.expectErr && err == nil {
+				t.Error("expected error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Configuration Trust
+//
+// Detached signature verification for configuration files. The config
+// controls private key placement and on_change commands, so every file can
+// be required to carry a signature from a trusted key. Supports minisign
+// signatures (<file>.minisig) and base64 signatures made with a PEM key over
+// the file's SHA-256 digest (<file>.sig), matching report signing. The same
+// key verifies documents from the central certificate source.
+// -------------------------------------------------------------------------------
+
+package config
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// TrustKey verifies detached signatures over configuration files.
+type TrustKey struct {
+	pem      crypto.PublicKey
+	minisign *minisignKey
+}
+
+// SourceTrust holds how documents from the central certificate source are
+// verified. Without a key they are not checked; in strict mode an unsigned
+// or invalid document is refused, otherwise it is applied with a warning.
+type SourceTrust struct {
+	Key    *TrustKey
+	Strict bool
+}
+
+// minisignKey is a minisign Ed25519 public key and its key ID.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// errUnsigned is returned when a config file or source document has no
+// signature.
+var errUnsigned = errors.New("no signature found")
+
+// -------------------------------------------------------------------------
+// PUBLIC FUNCTIONS
+// -------------------------------------------------------------------------
+
+// LoadTrustKey reads a PEM-encoded RSA, ECDSA, or Ed25519 public key, or a
+// minisign public key.
+func LoadTrustKey(keyFile string) (*TrustKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust key %s: %w", keyFile, err)
+	}
+
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trust key %s: %w", keyFile, err)
+		}
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+			return &TrustKey{pem: key}, nil
+		default:
+			return nil, fmt.Errorf("unsupported trust key type %T", key)
+		}
+	}
+
+	key, err := parseMinisignKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse trust key %s: %w", keyFile, err)
+	}
+	return &TrustKey{minisign: key}, nil
+}
+
+// LoadTrustedConfig loads configuration like LoadConfig, checking each
+// file against the trusted key. The signature is verified over the bytes
+// that are parsed, so a file replaced between the two cannot slip through.
+// In strict mode any unsigned or invalid file is an error; otherwise the
+// problems are returned as warnings and the file is loaded.
+func LoadTrustedConfig(path string, key *TrustKey, strict bool) (*Config, []string, error) {
+	var warnings []string
+	cfg, err := loadConfig(path, func(file string, content []byte) error {
+		err := key.verifyFile(file, content)
+		if err == nil {
+			return nil
+		}
+		if strict {
+			return fmt.Errorf("refusing untrusted config file %s: %w", file, err)
+		}
+		warnings = append(warnings, fmt.Sprintf("%s: %v", file, err))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cfg, warnings, nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Verify checks sig, a minisign signature file or a base64 signature
+// depending on the key, over content. An empty sig is reported as
+// unsigned.
+func (k *TrustKey) Verify(content, sig []byte) error {
+	if len(bytes.TrimSpace(sig)) == 0 {
+		return errUnsigned
+	}
+	if k.minisign != nil {
+		return k.minisign.verify(content, sig)
+	}
+	return verifyPEMSignature(k.pem, content, sig)
+}
+
+// verifyFile verifies content, the contents of file, against the detached
+// signature stored next to file.
+func (k *TrustKey) verifyFile(file string, content []byte) error {
+	sigFile := file + ".sig"
+	if k.minisign != nil {
+		sigFile = file + ".minisig"
+	}
+
+	sig, err := os.ReadFile(sigFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errUnsigned
+		}
+		return fmt.Errorf("failed to read signature %s: %w", sigFile, err)
+	}
+
+	return k.Verify(content, sig)
+}
+
+// verify checks a minisign signature file over content, including the
+// global signature over the trusted comment.
+func (k *minisignKey) verify(content, sigFile []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(sigFile)), "\n")
+	if len(lines) < 4 {
+		return fmt.Errorf("malformed minisign signature")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 74 {
+		return fmt.Errorf("malformed minisign signature")
+	}
+	if !bytes.Equal(sig[2:10], k.id[:]) {
+		return fmt.Errorf("signature was made with a different key")
+	}
+
+	message := content
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		digest := blake2b.Sum512(content)
+		message = digest[:]
+	default:
+		return fmt.Errorf("unsupported minisign signature algorithm %q", sig[:2])
+	}
+
+	if !ed25519.Verify(k.key, message, sig[10:]) {
+		return fmt.Errorf("signature verification failed")
+	}
+
+	trusted, ok := strings.CutPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if !ok {
+		return fmt.Errorf("malformed minisign trusted comment")
+	}
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return fmt.Errorf("malformed minisign global signature")
+	}
+	if !ed25519.Verify(k.key, append(sig[10:], trusted...), global) {
+		return fmt.Errorf("trusted comment verification failed")
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// parseMinisignKey decodes a minisign public key file or bare key line.
+func parseMinisignKey(data []byte) (*minisignKey, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	line := strings.TrimSpace(lines[len(lines)-1])
+
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 42 || string(raw[:2]) != "Ed" {
+		return nil, fmt.Errorf("not a PEM or minisign public key")
+	}
+
+	key := &minisignKey{key: ed25519.PublicKey(raw[10:])}
+	copy(key.id[:], raw[2:10])
+	return key, nil
+}
+
+// verifyPEMSignature checks a base64 signature over the SHA-256 digest of
+// content, or over content itself for Ed25519 keys.
+func verifyPEMSignature(key crypto.PublicKey, content, sigFile []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigFile)))
+	if err != nil {
+		return fmt.Errorf("malformed signature: %w", err)
+	}
+
+	digest := sha256.Sum256(content)
+	valid := false
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		valid = ed25519.Verify(k, content, sig)
+	case *ecdsa.PublicKey:
+		valid = ecdsa.VerifyASN1(k, digest[:], sig)
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	}
+
+	if !valid {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
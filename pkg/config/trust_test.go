@@ -0,0 +1,163 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Configuration Trust Tests
+//
+// Unit tests for config file signature verification with PEM and minisign
+// keys.
+// -------------------------------------------------------------------------------
+
+package config
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestLoadTrustedConfig_PEM verifies ECDSA-signed configs are accepted
+// and unsigned or tampered configs are rejected in strict mode.
+func TestLoadTrustedConfig_PEM(t *testing.T) {
+	tmpDir := t.TempDir()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	keyFile := filepath.Join(tmpDir, "trust.pem")
+	writeTestFile(t, keyFile, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+
+	key, err := LoadTrustKey(keyFile)
+	if err != nil {
+		t.Fatalf("failed to load trust key: %v", err)
+	}
+
+	configDir := filepath.Join(tmpDir, "conf.d")
+	if err := os.Mkdir(configDir, 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	configFile := filepath.Join(configDir, "vault.yml")
+	content := []byte("vault:\n  address: https://vault.example.com\n  auth:\n    token:\n      value: test-token\n")
+	writeTestFile(t, configFile, string(content))
+
+	if _, _, err := LoadTrustedConfig(configDir, key, true); err == nil {
+		t.Error("expected unsigned config to be refused in strict mode")
+	}
+	cfg, warnings, err := LoadTrustedConfig(configDir, key, false)
+	if err != nil || len(warnings) != 1 || cfg.Vault.Address != "https://vault.example.com" {
+		t.Errorf("expected unsigned config loaded with one warning, got %v (err: %v)", warnings, err)
+	}
+
+	digest := sha256.Sum256(content)
+	sig, err := priv.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	writeTestFile(t, configFile+".sig", base64.StdEncoding.EncodeToString(sig)+"\n")
+
+	if _, warnings, err := LoadTrustedConfig(configDir, key, true); err != nil || len(warnings) != 0 {
+		t.Errorf("expected signed config to verify, got %v (err: %v)", warnings, err)
+	}
+	if err := key.Verify(content, []byte(base64.StdEncoding.EncodeToString(sig))); err != nil {
+		t.Errorf("expected the signature to verify a source document: %v", err)
+	}
+	if err := key.Verify(content, nil); err != errUnsigned {
+		t.Errorf("expected a document without a signature to be unsigned, got %v", err)
+	}
+
+	writeTestFile(t, configFile, string(content)+"    on_change: rm -rf /\n")
+	if _, _, err := LoadTrustedConfig(configDir, key, true); err == nil {
+		t.Error("expected tampered config to be refused in strict mode")
+	}
+}
+
+// TestLoadTrustedConfig_Minisign verifies legacy and prehashed
+// minisign signatures, including the trusted comment.
+func TestLoadTrustedConfig_Minisign(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyID := []byte("12345678")
+
+	tmpDir := t.TempDir()
+	keyFile := filepath.Join(tmpDir, "minisign.pub")
+	rawKey := append(append([]byte("Ed"), keyID...), pub...)
+	writeTestFile(t, keyFile, "untrusted comment: minisign public key\n"+base64.StdEncoding.EncodeToString(rawKey)+"\n")
+
+	key, err := LoadTrustKey(keyFile)
+	if err != nil {
+		t.Fatalf("failed to load trust key: %v", err)
+	}
+
+	configFile := filepath.Join(tmpDir, "config.yaml")
+	content := []byte("vault:\n  address: https://vault.example.com\n  auth:\n    token:\n      value: test-token\n")
+	writeTestFile(t, configFile, string(content))
+
+	for _, alg := range []string{"Ed", "ED"} {
+		t.Run(alg, func(t *testing.T) {
+			message := content
+			if alg == "ED" {
+				digest := blake2b.Sum512(content)
+				message = digest[:]
+			}
+			sig := ed25519.Sign(priv, message)
+			trusted := "timestamp:1700000000\tfile:config.yaml"
+			global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+			rawSig := append(append([]byte(alg), keyID...), sig...)
+			writeTestFile(t, configFile+".minisig",
+				"untrusted comment: signature\n"+
+					base64.StdEncoding.EncodeToString(rawSig)+"\n"+
+					"trusted comment: "+trusted+"\n"+
+					base64.StdEncoding.EncodeToString(global)+"\n")
+
+			if _, _, err := LoadTrustedConfig(configFile, key, true); err != nil {
+				t.Errorf("expected signature to verify: %v", err)
+			}
+
+			writeTestFile(t, configFile+".minisig",
+				"untrusted comment: signature\n"+
+					base64.StdEncoding.EncodeToString(rawSig)+"\n"+
+					"trusted comment: forged\n"+
+					base64.StdEncoding.EncodeToString(global)+"\n")
+
+			if _, _, err := LoadTrustedConfig(configFile, key, true); err == nil {
+				t.Error("expected forged trusted comment to be refused")
+			}
+		})
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// writeTestFile writes content to path or fails the test.
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
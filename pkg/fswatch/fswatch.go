@@ -0,0 +1,154 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Watcher
+//
+// Reports changes to a set of files. Parent directories are watched rather
+// than the files themselves, so files replaced by rename, or deleted and
+// recreated, keep being reported. Linux uses inotify; other platforms poll.
+// -------------------------------------------------------------------------------
+
+// Package fswatch reports changes to individual files.
+package fswatch
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Watcher reports changes to the files passed to Set.
+type Watcher struct {
+	mu      sync.Mutex
+	files   map[string]bool
+	dirs    map[string]bool
+	backend backend
+
+	events    chan string
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// backend watches directories and reports the paths of entries that
+// changed in them.
+type backend interface {
+	watch(dir string) error
+	unwatch(dir string)
+	close() error
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// New creates a watcher with no files.
+func New() (*Watcher, error) {
+	w := &Watcher{
+		files:  make(map[string]bool),
+		dirs:   make(map[string]bool),
+		events: make(chan string, 64),
+		done:   make(chan struct{}),
+	}
+	b, err := newBackend(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start file watcher: %w", err)
+	}
+	w.backend = b
+	return w, nil
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Set replaces the watched files. Directories that do not exist yet are
+// skipped; call Set again once they may have been created.
+func (w *Watcher) Set(paths []string) error {
+	files := make(map[string]bool, len(paths))
+	dirs := make(map[string]bool)
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		files[path] = true
+		dirs[filepath.Dir(path)] = true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.files = files
+	var errs []error
+	for dir := range dirs {
+		if w.dirs[dir] {
+			continue
+		}
+		if err := w.backend.watch(dir); err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("failed to watch %s: %w", dir, err))
+			}
+			continue
+		}
+		w.dirs[dir] = true
+	}
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.backend.unwatch(dir)
+			delete(w.dirs, dir)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Events returns the paths of watched files as they change. A change may
+// be reported more than once.
+func (w *Watcher) Events() <-chan string {
+	return w.events
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.backend.close()
+	})
+	return err
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// notify reports path if it is a watched file.
+func (w *Watcher) notify(path string) {
+	w.mu.Lock()
+	watched := w.files[path]
+	w.mu.Unlock()
+	if !watched {
+		return
+	}
+
+	select {
+	case w.events <- path:
+	case <-w.done:
+	}
+}
+
+// forget drops a directory the backend stopped watching, e.g. because it
+// was removed, so the next Set watches it again.
+func (w *Watcher) forget(dir string) {
+	w.mu.Lock()
+	delete(w.dirs, dir)
+	w.mu.Unlock()
+}
@@ -0,0 +1,139 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Watcher (Linux)
+//
+// inotify backend. The inotify descriptor is non-blocking and wrapped in an
+// os.File, so reads wait in the runtime poller and Close unblocks them.
+// -------------------------------------------------------------------------------
+
+//go:build linux
+
+package fswatch
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// inotifyMask selects entries written, created, removed, renamed, or
+// changed in mode or ownership.
+const inotifyMask = unix.IN_CLOSE_WRITE | unix.IN_CREATE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ATTRIB
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// inotifyBackend watches directories with inotify.
+type inotifyBackend struct {
+	fd      int
+	file    *os.File
+	watcher *Watcher
+
+	mu   sync.Mutex
+	wds  map[int]string
+	dirs map[string]int
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// newBackend starts an inotify instance reporting to w.
+func newBackend(w *Watcher) (backend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	b := &inotifyBackend{
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		watcher: w,
+		wds:     make(map[int]string),
+		dirs:    make(map[string]int),
+	}
+	go b.read()
+	return b, nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// watch adds an inotify watch on dir.
+func (b *inotifyBackend) watch(dir string) error {
+	wd, err := unix.InotifyAddWatch(b.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	b.mu.Lock()
+	b.wds[wd] = dir
+	b.dirs[dir] = wd
+	b.mu.Unlock()
+	return nil
+}
+
+// unwatch removes the inotify watch on dir.
+func (b *inotifyBackend) unwatch(dir string) {
+	b.mu.Lock()
+	wd, ok := b.dirs[dir]
+	delete(b.dirs, dir)
+	delete(b.wds, wd)
+	b.mu.Unlock()
+	if ok {
+		_, _ = unix.InotifyRmWatch(b.fd, uint32(wd))
+	}
+}
+
+// close closes the inotify instance, ending read.
+func (b *inotifyBackend) close() error {
+	return b.file.Close()
+}
+
+// read decodes inotify events until the instance is closed.
+func (b *inotifyBackend) read() {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:min(nameStart+nameLen, n)]), "\x00")
+			offset = nameStart + nameLen
+
+			b.mu.Lock()
+			dir, ok := b.wds[wd]
+			if ok && mask&unix.IN_IGNORED != 0 {
+				delete(b.wds, wd)
+				delete(b.dirs, dir)
+			}
+			b.mu.Unlock()
+
+			switch {
+			case !ok:
+			case mask&unix.IN_IGNORED != 0:
+				b.watcher.forget(dir)
+			case name != "":
+				b.watcher.notify(filepath.Join(dir, name))
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Watcher (non-Linux)
+//
+// Polling backend: watched directories are listed every PollInterval and
+// entries whose size, modification time, or mode changed are reported.
+// -------------------------------------------------------------------------------
+
+//go:build !linux
+
+package fswatch
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// PollInterval is how often watched directories are listed.
+const PollInterval = time.Second
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// entryState is what the poller compares between listings.
+type entryState struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// pollBackend watches directories by listing them.
+type pollBackend struct {
+	watcher *Watcher
+
+	mu   sync.Mutex
+	dirs map[string]map[string]entryState
+	done chan struct{}
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// newBackend starts a poller reporting to w.
+func newBackend(w *Watcher) (backend, error) {
+	b := &pollBackend{
+		watcher: w,
+		dirs:    make(map[string]map[string]entryState),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b, nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// watch records dir's current entries.
+func (b *pollBackend) watch(dir string) error {
+	entries, err := listDir(dir)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.dirs[dir] = entries
+	b.mu.Unlock()
+	return nil
+}
+
+// unwatch stops listing dir.
+func (b *pollBackend) unwatch(dir string) {
+	b.mu.Lock()
+	delete(b.dirs, dir)
+	b.mu.Unlock()
+}
+
+// close stops the poller.
+func (b *pollBackend) close() error {
+	close(b.done)
+	return nil
+}
+
+// run lists watched directories every PollInterval until closed.
+func (b *pollBackend) run() {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.poll()
+		}
+	}
+}
+
+// poll reports entries that changed since the previous listing.
+func (b *pollBackend) poll() {
+	b.mu.Lock()
+	dirs := make([]string, 0, len(b.dirs))
+	for dir := range b.dirs {
+		dirs = append(dirs, dir)
+	}
+	b.mu.Unlock()
+
+	for _, dir := range dirs {
+		current, err := listDir(dir)
+
+		b.mu.Lock()
+		previous, ok := b.dirs[dir]
+		switch {
+		case !ok:
+		case err != nil:
+			delete(b.dirs, dir)
+		default:
+			b.dirs[dir] = current
+		}
+		b.mu.Unlock()
+
+		if !ok {
+			continue
+		}
+		if err != nil {
+			for name := range previous {
+				b.watcher.notify(filepath.Join(dir, name))
+			}
+			b.watcher.forget(dir)
+			continue
+		}
+		for name, state := range current {
+			if prev, seen := previous[name]; !seen || prev != state {
+				b.watcher.notify(filepath.Join(dir, name))
+			}
+		}
+		for name := range previous {
+			if _, seen := current[name]; !seen {
+				b.watcher.notify(filepath.Join(dir, name))
+			}
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// listDir returns the state of each entry in dir.
+func listDir(dir string) (map[string]entryState, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	states := make(map[string]entryState, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		states[entry.Name()] = entryState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+	}
+	return states, nil
+}
@@ -0,0 +1,96 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - File Watcher Tests
+//
+// Unit tests for reporting changes to watched files.
+// -------------------------------------------------------------------------------
+
+package fswatch
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestWatcher verifies writes, replacement by rename, and removal of a
+// watched file are reported, and changes to other files are not.
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tls.crt")
+	writeFile(t, path, "one")
+
+	w, err := New()
+	if err != nil {
+		t.Fatalf("failed to create watcher: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	missing := filepath.Join(dir, "not-yet", "tls.key")
+	if err := w.Set([]string{path, missing}); err != nil {
+		t.Fatalf("failed to set files: %v", err)
+	}
+
+	writeFile(t, filepath.Join(dir, "other.crt"), "other")
+	writeFile(t, path, "two")
+	expectEvent(t, w, path)
+
+	replacement := filepath.Join(dir, ".tls.crt.tmp")
+	writeFile(t, replacement, "three")
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatalf("failed to replace file: %v", err)
+	}
+	expectEvent(t, w, path)
+
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove file: %v", err)
+	}
+	expectEvent(t, w, path)
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// writeFile writes content to path.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// expectEvent waits for an event for path, draining duplicates, and fails
+// on events for any other file.
+func expectEvent(t *testing.T, w *Watcher, path string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case got := <-w.Events():
+			if got != path {
+				t.Fatalf("unexpected event for %s", got)
+			}
+			for {
+				select {
+				case got := <-w.Events():
+					if got != path {
+						t.Fatalf("unexpected event for %s", got)
+					}
+				case <-time.After(100 * time.Millisecond):
+					return
+				}
+			}
+		case <-timeout:
+			t.Fatalf("no event for %s", path)
+		}
+	}
+}
@@ -2,8 +2,10 @@
 // vault-cert-manager - Health Checker
 //
 // TCP-based health checking for certificate deployments. Validates TLS
-// connections and retrieves remote certificate fingerprints to verify
-// successful certificate deployment to target services.
+// connections, negotiated directly or after a plaintext STARTTLS exchange
+// and optionally presenting a client certificate, and retrieves remote
+// certificate fingerprints to verify successful certificate deployment to
+// target services. Services it cannot reach are checked by a command.
 // -------------------------------------------------------------------------------
 
 // Package health provides TCP-based certificate health checking.
@@ -21,6 +23,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"os"
 	"time"
 )
 
@@ -42,6 +45,13 @@ type CheckResult struct {
 	Success           bool
 	Error             error
 	RemoteFingerprint string
+
+	// Untrusted is set when a certificate was served but its chain or
+	// hostname did not verify. RemoteFingerprint is still set.
+	Untrusted bool
+
+	// Chain is the served chain, leaf first, when the check saw one.
+	Chain []*x509.Certificate
 }
 
 // TCPChecker performs health checks via TCP/TLS connections.
@@ -60,9 +70,10 @@ func NewTCPChecker() *TCPChecker {
 // METHODS
 // -------------------------------------------------------------------------
 
-// Check performs a TLS health check and retrieves the remote certificate.
+// Check performs a TLS health check, or runs the health check command, and
+// retrieves the remote certificate.
 func (t *TCPChecker) Check(managed *cert.ManagedCertificate) (*CheckResult, error) {
-	if managed.Config.HealthCheck == nil || managed.Config.HealthCheck.TCP == "" {
+	if managed.Config.HealthCheck == nil {
 		return &CheckResult{Success: true}, nil
 	}
 
@@ -71,30 +82,64 @@ func (t *TCPChecker) Check(managed *cert.ManagedCertificate) (*CheckResult, erro
 		timeout = 5 * time.Second
 	}
 
-	conn, err := net.DialTimeout("tcp", managed.Config.HealthCheck.TCP, timeout)
+	if managed.Config.HealthCheck.Command != "" {
+		return checkCommand(managed, timeout), nil
+	}
+	if managed.Config.HealthCheck.TCP == "" {
+		return &CheckResult{Success: true}, nil
+	}
+
+	addr := managed.Config.HealthCheck.TCP
+	conn, err := net.DialTimeout("tcp", addr, timeout)
 	if err != nil {
 		return &CheckResult{
 			Success: false,
-			Error:   fmt.Errorf("failed to connect to %s: %w", managed.Config.HealthCheck.TCP, err),
+			Error:   fmt.Errorf("failed to connect to %s: %w", addr, err),
 		}, nil
 	}
 	defer func() { _ = conn.Close() }()
 
-	tlsConn, err := tls.Dial("tcp", managed.Config.HealthCheck.TCP, &tls.Config{
-		InsecureSkipVerify: true,
-	})
-	if err != nil {
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
 		return &CheckResult{
 			Success: false,
-			Error:   fmt.Errorf("failed to establish TLS connection to %s: %w", managed.Config.HealthCheck.TCP, err),
+			Error:   fmt.Errorf("failed to set deadline: %w", err),
 		}, nil
 	}
-	defer func() { _ = tlsConn.Close() }()
 
-	if err := tlsConn.SetDeadline(time.Now().Add(timeout)); err != nil {
+	if mode := managed.Config.HealthCheck.StartTLS; mode != "" {
+		if err := startTLS(conn, mode); err != nil {
+			return &CheckResult{
+				Success: false,
+				Error:   fmt.Errorf("failed to negotiate %s STARTTLS with %s: %w", mode, addr, err),
+			}, nil
+		}
+	}
+
+	serverName := managed.Config.HealthCheck.ServerName
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(addr)
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	}
+	if managed.Config.HealthCheck.ClientAuth {
+		clientCert, err := loadClientCertificate(managed)
+		if err != nil {
+			return &CheckResult{
+				Success: false,
+				Error:   fmt.Errorf("failed to load health check client certificate: %w", err),
+			}, nil
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return &clientCert, nil
+		}
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
 		return &CheckResult{
 			Success: false,
-			Error:   fmt.Errorf("failed to set deadline: %w", err),
+			Error:   fmt.Errorf("failed to establish TLS connection to %s: %w", addr, err),
 		}, nil
 	}
 
@@ -109,12 +154,52 @@ func (t *TCPChecker) Check(managed *cert.ManagedCertificate) (*CheckResult, erro
 	remoteCert := state.PeerCertificates[0]
 	remoteFingerprint := t.calculateFingerprint(remoteCert)
 
+	if managed.Config.HealthCheck.Verify {
+		roots, err := loadRoots(managed.Config.HealthCheck.CAFile)
+		if err != nil {
+			return &CheckResult{
+				Success:           false,
+				Error:             err,
+				RemoteFingerprint: remoteFingerprint,
+			}, nil
+		}
+		if err := verifyChain(state.PeerCertificates, roots, serverName); err != nil {
+			return &CheckResult{
+				Success:           false,
+				Error:             fmt.Errorf("%s served an untrusted certificate: %w", addr, err),
+				RemoteFingerprint: remoteFingerprint,
+				Untrusted:         true,
+				Chain:             state.PeerCertificates,
+			}, nil
+		}
+	}
+
 	return &CheckResult{
 		Success:           true,
 		RemoteFingerprint: remoteFingerprint,
+		Chain:             state.PeerCertificates,
 	}, nil
 }
 
+// ExpiringIntermediate returns the served intermediate that expires first
+// if it expires before the leaf, which clients then stop trusting early
+// even though the leaf is valid. It returns nil otherwise.
+func (r *CheckResult) ExpiringIntermediate() *x509.Certificate {
+	if len(r.Chain) < 2 {
+		return nil
+	}
+	var earliest *x509.Certificate
+	for _, intermediate := range r.Chain[1:] {
+		if earliest == nil || intermediate.NotAfter.Before(earliest.NotAfter) {
+			earliest = intermediate
+		}
+	}
+	if !earliest.NotAfter.Before(r.Chain[0].NotAfter) {
+		return nil
+	}
+	return earliest
+}
+
 // calculateFingerprint computes a SHA256 fingerprint of the certificate.
 func (t *TCPChecker) calculateFingerprint(cert *x509.Certificate) string {
 	if cert == nil {
@@ -123,3 +208,47 @@ func (t *TCPChecker) calculateFingerprint(cert *x509.Certificate) string {
 	hash := sha256.Sum256(cert.Raw)
 	return hex.EncodeToString(hash[:])
 }
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// loadClientCertificate returns the configured client certificate, or the
+// managed certificate and key.
+func loadClientCertificate(managed *cert.ManagedCertificate) (tls.Certificate, error) {
+	check := managed.Config.HealthCheck
+	if check.ClientCertificate != "" {
+		return tls.LoadX509KeyPair(check.ClientCertificate, check.ClientKey)
+	}
+	return cert.LoadKeyPair(managed.Config)
+}
+
+// loadRoots returns the roots in caFile, or nil for the system roots.
+func loadRoots(caFile string) (*x509.CertPool, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read health check ca_file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in health check ca_file %s", caFile)
+	}
+	return roots, nil
+}
+
+// verifyChain verifies the served chain against roots for serverName.
+func verifyChain(chain []*x509.Certificate, roots *x509.CertPool, serverName string) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	return err
+}
@@ -11,8 +11,15 @@ package health
 // -------------------------------------------------------------------------
 
 import (
+	"bytes"
 	"cert-manager/pkg/cert"
 	"cert-manager/pkg/config"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -75,6 +82,132 @@ func TestTCPChecker_Check_InvalidHost(t *testing.T) {
 	}
 }
 
+// TestTCPChecker_Check_Verify verifies strict checks pass against the
+// configured roots and report an untrusted chain or hostname distinctly.
+func TestTCPChecker_Check_Verify(t *testing.T) {
+	tlsCert, fingerprint := testServerCertificate(t)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsCert.Certificate[0]})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+	plainTLS := func(conn net.Conn) bool { return true }
+
+	tests := []struct {
+		name       string
+		caFile     string
+		serverName string
+		untrusted  bool
+	}{
+		{name: "trusted", caFile: caFile},
+		{name: "unknown authority", untrusted: true},
+		{name: "wrong hostname", caFile: caFile, serverName: "other.example.com", untrusted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			managed := &cert.ManagedCertificate{
+				Config: &config.CertificateConfig{
+					Name: "web",
+					HealthCheck: &config.HealthCheck{
+						TCP:        serveStartTLS(t, tlsConfig, plainTLS),
+						Timeout:    2 * time.Second,
+						Verify:     true,
+						CAFile:     tt.caFile,
+						ServerName: tt.serverName,
+					},
+				},
+			}
+
+			result, err := NewTCPChecker().Check(managed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Success == tt.untrusted || result.Untrusted != tt.untrusted {
+				t.Errorf("expected untrusted %v, got %+v", tt.untrusted, result)
+			}
+			if result.RemoteFingerprint != fingerprint {
+				t.Errorf("expected the served fingerprint even when untrusted, got %q", result.RemoteFingerprint)
+			}
+		})
+	}
+}
+
+// TestTCPChecker_Check_ClientAuth verifies the managed certificate, or
+// the configured one, is presented to services requiring mTLS.
+func TestTCPChecker_Check_ClientAuth(t *testing.T) {
+	serverCert, _ := testServerCertificate(t)
+	managedCert, _ := testServerCertificate(t)
+	otherCert, _ := testServerCertificate(t)
+
+	tmpDir := t.TempDir()
+	writeKeyPair := func(name string, pair tls.Certificate) (string, string) {
+		certFile := filepath.Join(tmpDir, name+".crt")
+		keyFile := filepath.Join(tmpDir, name+".key")
+		keyDER, err := x509.MarshalPKCS8PrivateKey(pair.PrivateKey)
+		if err != nil {
+			t.Fatalf("failed to encode key: %v", err)
+		}
+		_ = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: pair.Certificate[0]}), 0644)
+		_ = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600)
+		return certFile, keyFile
+	}
+	managedCertFile, managedKeyFile := writeKeyPair("managed", managedCert)
+	otherCertFile, otherKeyFile := writeKeyPair("other", otherCert)
+
+	tests := []struct {
+		name       string
+		clientCert string
+		clientKey  string
+		expected   []byte
+	}{
+		{name: "managed certificate", expected: managedCert.Certificate[0]},
+		{name: "configured certificate", clientCert: otherCertFile, clientKey: otherKeyFile, expected: otherCert.Certificate[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			presented := make(chan []byte, 1)
+			tlsConfig := &tls.Config{
+				Certificates: []tls.Certificate{serverCert},
+				ClientAuth:   tls.RequireAnyClientCert,
+				VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+					presented <- rawCerts[0]
+					return nil
+				},
+			}
+			managed := &cert.ManagedCertificate{
+				Config: &config.CertificateConfig{
+					Name:        "etcd",
+					Certificate: managedCertFile,
+					Key:         managedKeyFile,
+					HealthCheck: &config.HealthCheck{
+						TCP:               serveStartTLS(t, tlsConfig, func(conn net.Conn) bool { return true }),
+						Timeout:           2 * time.Second,
+						ClientAuth:        true,
+						ClientCertificate: tt.clientCert,
+						ClientKey:         tt.clientKey,
+					},
+				},
+			}
+
+			result, err := NewTCPChecker().Check(managed)
+			if err != nil || !result.Success {
+				t.Fatalf("expected success, got %+v, %v", result, err)
+			}
+			select {
+			case raw := <-presented:
+				if !bytes.Equal(raw, tt.expected) {
+					t.Error("unexpected client certificate presented")
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("expected a client certificate to be presented")
+			}
+		})
+	}
+}
+
 // TestTCPChecker_calculateFingerprint verifies fingerprint calculation.
 func TestTCPChecker_calculateFingerprint(t *testing.T) {
 	checker := NewTCPChecker()
@@ -84,3 +217,32 @@ func TestTCPChecker_calculateFingerprint(t *testing.T) {
 		t.Error("fingerprint should be empty for nil certificate")
 	}
 }
+
+// TestCheckResult_ExpiringIntermediate verifies an intermediate expiring
+// before the leaf is flagged, the earliest one if several do.
+func TestCheckResult_ExpiringIntermediate(t *testing.T) {
+	now := time.Now()
+	leaf := &x509.Certificate{NotAfter: now.Add(90 * 24 * time.Hour)}
+	early := &x509.Certificate{NotAfter: now.Add(30 * 24 * time.Hour)}
+	earliest := &x509.Certificate{NotAfter: now.Add(10 * 24 * time.Hour)}
+	late := &x509.Certificate{NotAfter: now.Add(365 * 24 * time.Hour)}
+
+	tests := []struct {
+		name  string
+		chain []*x509.Certificate
+		want  *x509.Certificate
+	}{
+		{"leaf only", []*x509.Certificate{leaf}, nil},
+		{"intermediate outlives leaf", []*x509.Certificate{leaf, late}, nil},
+		{"intermediate expires first", []*x509.Certificate{leaf, early, late}, early},
+		{"earliest of several", []*x509.Certificate{leaf, early, earliest}, earliest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &CheckResult{Success: true, Chain: tt.chain}
+			if got := result.ExpiringIntermediate(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Command Health Checks
+//
+// Runs a health_check command for services the TCP checker cannot reach,
+// e.g. exotic protocols through openssl s_client or services on a local
+// socket. The command is healthy when it exits 0. If it prints the served
+// certificate as PEM, or its SHA-256 fingerprint, the fingerprint is
+// compared with the disk like a TCP check's, and a printed PEM chain is
+// checked like a served one.
+// -------------------------------------------------------------------------------
+
+package health
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/cert"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// commandWaitDelay bounds the wait for a killed command's output to be
+// closed by processes it started.
+const commandWaitDelay = time.Second
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// checkCommand runs the health_check command with sh, killing it after
+// timeout, and reads the served fingerprint from its output.
+func checkCommand(managed *cert.ManagedCertificate, timeout time.Duration) *CheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", managed.Config.HealthCheck.Command)
+	cmd.Env = append(os.Environ(),
+		"CERT_NAME="+managed.Config.Name,
+		"CERT_PATH="+managed.Config.CertificatePath(),
+		"KEY_PATH="+managed.Config.KeyPath(),
+		"CERT_FINGERPRINT="+managed.Fingerprint,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = commandWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", timeout)
+		}
+		output := strings.TrimSpace(stderr.String())
+		if output == "" {
+			output = strings.TrimSpace(stdout.String())
+		}
+		return &CheckResult{
+			Success: false,
+			Error:   fmt.Errorf("health check command failed: %w: %s", err, output),
+		}
+	}
+
+	return &CheckResult{
+		Success:           true,
+		RemoteFingerprint: outputFingerprint(stdout.Bytes()),
+		Chain:             outputChain(stdout.Bytes()),
+	}
+}
+
+// outputChain returns the PEM certificates in output that parse, in order.
+func outputChain(output []byte) []*x509.Certificate {
+	var chain []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, output = pem.Decode(output)
+		if block == nil {
+			return chain
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if certificate, err := x509.ParseCertificate(block.Bytes); err == nil {
+			chain = append(chain, certificate)
+		}
+	}
+}
+
+// outputFingerprint returns the fingerprint of the first PEM certificate
+// in output, or the SHA-256 fingerprint printed on a line of its own, as
+// hex or as openssl x509 -fingerprint -sha256 prints it. It returns ""
+// when output holds neither.
+func outputFingerprint(output []byte) string {
+	for rest := output; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			hash := sha256.Sum256(block.Bytes)
+			return hex.EncodeToString(hash[:])
+		}
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if label, value, ok := strings.Cut(line, "="); ok && strings.HasPrefix(strings.ToLower(label), "sha256") {
+			line = value
+		}
+		fingerprint := strings.ToLower(strings.ReplaceAll(line, ":", ""))
+		if decoded, err := hex.DecodeString(fingerprint); err == nil && len(decoded) == sha256.Size {
+			return fingerprint
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Command Health Check Tests
+//
+// Unit tests for health checks that run a command.
+// -------------------------------------------------------------------------------
+
+package health
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestTCPChecker_Check_Command verifies the exit code decides success and
+// a printed certificate or fingerprint is reported as the served one.
+func TestTCPChecker_Check_Command(t *testing.T) {
+	serverCert, fingerprint := testServerCertificate(t)
+	pemFile := filepath.Join(t.TempDir(), "served.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: serverCert.Certificate[0]})
+	if err := os.WriteFile(pemFile, pemData, 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+
+	check := func(command string) *CheckResult {
+		t.Helper()
+		managed := &cert.ManagedCertificate{
+			Config: &config.CertificateConfig{
+				Name:        "socket",
+				HealthCheck: &config.HealthCheck{Command: command, Timeout: time.Second},
+			},
+		}
+		result, err := NewTCPChecker().Check(managed)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	if result := check("echo CONNECTED; cat " + pemFile); !result.Success || result.RemoteFingerprint != fingerprint || len(result.Chain) != 1 {
+		t.Errorf("expected the printed certificate's fingerprint and chain, got %+v", result)
+	}
+
+	colons := strings.ToUpper(fingerprint[:2])
+	for i := 2; i < len(fingerprint); i += 2 {
+		colons += ":" + strings.ToUpper(fingerprint[i:i+2])
+	}
+	if result := check("echo 'sha256 Fingerprint=" + colons + "'"); result.RemoteFingerprint != fingerprint {
+		t.Errorf("expected the printed fingerprint, got %q", result.RemoteFingerprint)
+	}
+
+	if result := check("test \"$CERT_NAME\" = socket"); !result.Success || result.RemoteFingerprint != "" {
+		t.Errorf("expected success without a fingerprint, got %+v", result)
+	}
+
+	result := check("echo 'connection refused' >&2; exit 1")
+	if result.Success || !strings.Contains(result.Error.Error(), "connection refused") {
+		t.Errorf("expected failure with the command's output, got %+v", result)
+	}
+
+	if result := check("sleep 5"); result.Success || !strings.Contains(result.Error.Error(), "timed out") {
+		t.Errorf("expected a timeout, got %+v", result)
+	}
+}
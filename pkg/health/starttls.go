@@ -0,0 +1,166 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - STARTTLS Negotiation
+//
+// Plaintext preludes for protocols that upgrade a connection to TLS in
+// band: SMTP STARTTLS (RFC 3207), the LDAP StartTLS extended operation
+// (RFC 4511), and the PostgreSQL SSLRequest message. After the server
+// agrees, the TLS handshake runs on the same connection.
+// -------------------------------------------------------------------------------
+
+package health
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// ldapStartTLSOID names the LDAP StartTLS extended operation.
+const ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// postgresSSLRequestCode is the protocol code of a PostgreSQL SSLRequest.
+const postgresSSLRequestCode = 80877103
+
+// ldapMaxMessage bounds the LDAP response read before the handshake.
+const ldapMaxMessage = 64 * 1024
+
+// -------------------------------------------------------------------------
+// FUNCTIONS
+// -------------------------------------------------------------------------
+
+// startTLS runs the plaintext exchange of mode on conn, returning once the
+// server is ready for the TLS handshake.
+func startTLS(conn net.Conn, mode string) error {
+	switch mode {
+	case "smtp":
+		return startTLSSMTP(conn)
+	case "ldap":
+		return startTLSLDAP(conn)
+	case "postgres":
+		return startTLSPostgres(conn)
+	default:
+		return fmt.Errorf("unsupported starttls mode %s", mode)
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// startTLSSMTP reads the greeting, sends EHLO, and issues STARTTLS.
+func startTLSSMTP(conn net.Conn) error {
+	text := textproto.NewConn(conn)
+	if _, _, err := text.ReadResponse(220); err != nil {
+		return fmt.Errorf("unexpected greeting: %w", err)
+	}
+	if err := text.PrintfLine("EHLO vault-cert-manager"); err != nil {
+		return err
+	}
+	if _, _, err := text.ReadResponse(250); err != nil {
+		return fmt.Errorf("EHLO refused: %w", err)
+	}
+	if err := text.PrintfLine("STARTTLS"); err != nil {
+		return err
+	}
+	if _, _, err := text.ReadResponse(220); err != nil {
+		return fmt.Errorf("STARTTLS refused: %w", err)
+	}
+	return nil
+}
+
+// startTLSLDAP sends a StartTLS extended request and checks the result
+// code of the extended response.
+func startTLSLDAP(conn net.Conn) error {
+	oid := []byte(ldapStartTLSOID)
+	op := append([]byte{0x77, byte(len(oid) + 2), 0x80, byte(len(oid))}, oid...)
+	request := append([]byte{0x30, byte(len(op) + 3), 0x02, 0x01, 0x01}, op...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	message, err := readBER(conn)
+	if err != nil {
+		return fmt.Errorf("failed to read StartTLS response: %w", err)
+	}
+	var response struct {
+		ID int
+		Op asn1.RawValue
+	}
+	if _, err := asn1.Unmarshal(message, &response); err != nil {
+		return fmt.Errorf("invalid StartTLS response: %w", err)
+	}
+	if response.Op.Class != asn1.ClassApplication || response.Op.Tag != 24 {
+		return fmt.Errorf("unexpected LDAP response [APPLICATION %d]", response.Op.Tag)
+	}
+	var resultCode asn1.Enumerated
+	if _, err := asn1.Unmarshal(response.Op.Bytes, &resultCode); err != nil {
+		return fmt.Errorf("invalid StartTLS result: %w", err)
+	}
+	if resultCode != 0 {
+		return fmt.Errorf("StartTLS refused with result code %d", resultCode)
+	}
+	return nil
+}
+
+// startTLSPostgres sends an SSLRequest and expects 'S' in reply.
+func startTLSPostgres(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], postgresSSLRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("failed to read SSLRequest reply: %w", err)
+	}
+	if reply[0] != 'S' {
+		return fmt.Errorf("server does not accept SSL (replied %q)", reply[0])
+	}
+	return nil
+}
+
+// readBER reads one BER element with a definite length from r.
+func readBER(r io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	length := int(header[1])
+	if length&0x80 != 0 {
+		n := length & 0x7f
+		if n == 0 || n > 3 {
+			return nil, fmt.Errorf("unsupported BER length encoding")
+		}
+		lengthBytes := make([]byte, n)
+		if _, err := io.ReadFull(r, lengthBytes); err != nil {
+			return nil, err
+		}
+		header = append(header, lengthBytes...)
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > ldapMaxMessage {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
@@ -0,0 +1,181 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - STARTTLS Negotiation Tests
+//
+// Unit tests for health checks over SMTP, LDAP, and PostgreSQL STARTTLS
+// against local fake servers.
+// -------------------------------------------------------------------------------
+
+package health
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestTCPChecker_Check_StartTLS verifies the served certificate is read
+// after each protocol's plaintext upgrade, and refusals fail the check.
+func TestTCPChecker_Check_StartTLS(t *testing.T) {
+	tlsCert, fingerprint := testServerCertificate(t)
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+
+	tests := []struct {
+		mode    string
+		prelude func(conn net.Conn) bool
+	}{
+		{mode: "smtp", prelude: func(conn net.Conn) bool {
+			r := bufio.NewReader(conn)
+			_, _ = io.WriteString(conn, "220-mail.example.com ESMTP\r\n220 ready\r\n")
+			if line, _ := r.ReadString('\n'); !strings.HasPrefix(line, "EHLO ") {
+				return false
+			}
+			_, _ = io.WriteString(conn, "250-mail.example.com\r\n250 STARTTLS\r\n")
+			if line, _ := r.ReadString('\n'); line != "STARTTLS\r\n" {
+				_, _ = io.WriteString(conn, "502 not implemented\r\n")
+				return false
+			}
+			_, _ = io.WriteString(conn, "220 go ahead\r\n")
+			return true
+		}},
+		{mode: "ldap", prelude: func(conn net.Conn) bool {
+			request, err := readBER(conn)
+			if err != nil || !strings.Contains(string(request), ldapStartTLSOID) {
+				return false
+			}
+			// ExtendedResponse with resultCode success and empty DN and message.
+			_, _ = conn.Write([]byte{0x30, 0x0c, 0x02, 0x01, 0x01, 0x78, 0x07, 0x0a, 0x01, 0x00, 0x04, 0x00, 0x04, 0x00})
+			return true
+		}},
+		{mode: "postgres", prelude: func(conn net.Conn) bool {
+			request := make([]byte, 8)
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return false
+			}
+			_, _ = conn.Write([]byte("S"))
+			return true
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			addr := serveStartTLS(t, tlsConfig, tt.prelude)
+			result, err := NewTCPChecker().Check(startTLSCertificate(addr, tt.mode))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.Success {
+				t.Fatalf("expected success, got %v", result.Error)
+			}
+			if result.RemoteFingerprint != fingerprint {
+				t.Errorf("expected fingerprint %s, got %s", fingerprint, result.RemoteFingerprint)
+			}
+		})
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		addr := serveStartTLS(t, tlsConfig, func(conn net.Conn) bool {
+			request := make([]byte, 8)
+			_, _ = io.ReadFull(conn, request)
+			_, _ = conn.Write([]byte("N"))
+			return false
+		})
+		result, err := NewTCPChecker().Check(startTLSCertificate(addr, "postgres"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Success || result.Error == nil || !strings.Contains(result.Error.Error(), "STARTTLS") {
+			t.Errorf("expected STARTTLS failure, got %+v", result)
+		}
+	})
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// testServerCertificate returns a self-signed server certificate and its
+// fingerprint.
+func testServerCertificate(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	hash := sha256.Sum256(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, hex.EncodeToString(hash[:])
+}
+
+// serveStartTLS accepts one connection, runs prelude, and upgrades to TLS
+// with tlsConfig if it succeeds. It returns the listener address.
+func serveStartTLS(t *testing.T, tlsConfig *tls.Config, prelude func(conn net.Conn) bool) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if !prelude(conn) {
+			return
+		}
+		tlsConn := tls.Server(conn, tlsConfig)
+		_ = tlsConn.Handshake()
+		_, _ = io.Copy(io.Discard, tlsConn)
+	}()
+	return listener.Addr().String()
+}
+
+// startTLSCertificate returns a managed certificate checked at addr with
+// the given STARTTLS mode.
+func startTLSCertificate(addr, mode string) *cert.ManagedCertificate {
+	return &cert.ManagedCertificate{
+		Config: &config.CertificateConfig{
+			Name: "mail",
+			HealthCheck: &config.HealthCheck{
+				TCP:      addr,
+				Timeout:  2 * time.Second,
+				StartTLS: mode,
+			},
+		},
+	}
+}
@@ -0,0 +1,91 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Deployment Verification
+//
+// Polls a certificate's health_check target after a rotation until it
+// serves the new certificate, so failed reloads are caught, failing the
+// rotation and rolling it back when backups are kept.
+// -------------------------------------------------------------------------------
+
+package health
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"fmt"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Verifier implements cert.DeploymentVerifier on top of a Checker.
+type Verifier struct {
+	checker  Checker
+	interval time.Duration
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewVerifier creates a deployment verifier using checker.
+func NewVerifier(checker Checker) *Verifier {
+	return &Verifier{
+		checker:  checker,
+		interval: time.Second,
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// VerifyDeployment checks the health_check target until it serves the
+// certificate on disk, returning the last failure once timeout passes.
+func (v *Verifier) VerifyDeployment(managed *cert.ManagedCertificate, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		err := v.check(managed)
+		if err == nil {
+			return nil
+		}
+		if time.Now().Add(v.interval).After(deadline) {
+			return err
+		}
+		time.Sleep(v.interval)
+	}
+}
+
+// check runs one health check and compares the served fingerprint, if
+// the check reports one.
+func (v *Verifier) check(managed *cert.ManagedCertificate) error {
+	result, err := v.checker.Check(managed)
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return result.Error
+	}
+	if result.RemoteFingerprint != "" && result.RemoteFingerprint != managed.Fingerprint {
+		return fmt.Errorf("%s is still serving certificate %s", managed.Config.HealthCheck.Target(), result.RemoteFingerprint)
+	}
+	return nil
+}
+
+// ServedFingerprint returns the fingerprint of the certificate the
+// health_check target serves, trusted or not.
+func (v *Verifier) ServedFingerprint(managed *cert.ManagedCertificate) (string, error) {
+	result, err := v.checker.Check(managed)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success && !result.Untrusted {
+		return "", result.Error
+	}
+	return result.RemoteFingerprint, nil
+}
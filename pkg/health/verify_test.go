@@ -0,0 +1,82 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Deployment Verification Tests
+//
+// Unit tests for polling health_check targets after a rotation.
+// -------------------------------------------------------------------------------
+
+package health
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestVerifier_VerifyDeployment verifies the target is polled until it
+// serves the deployed fingerprint, and fails once the timeout passes.
+func TestVerifier_VerifyDeployment(t *testing.T) {
+	managed := &cert.ManagedCertificate{
+		Config:      &config.CertificateConfig{Name: "web", HealthCheck: &config.HealthCheck{TCP: "localhost:443"}},
+		Fingerprint: "new",
+	}
+
+	checker := &sequenceChecker{fingerprints: []string{"old", "old", "new"}}
+	verifier := NewVerifier(checker)
+	verifier.interval = time.Millisecond
+
+	if err := verifier.VerifyDeployment(managed, time.Second); err != nil {
+		t.Errorf("expected verification to succeed after reload, got %v", err)
+	}
+	if checker.calls != 3 {
+		t.Errorf("expected 3 checks, got %d", checker.calls)
+	}
+
+	stale := &sequenceChecker{fingerprints: []string{"old"}}
+	verifier = NewVerifier(stale)
+	verifier.interval = time.Millisecond
+
+	if err := verifier.VerifyDeployment(managed, 20*time.Millisecond); err == nil {
+		t.Error("expected verification to fail while the old certificate is served")
+	}
+}
+
+// TestVerifier_ServedFingerprint verifies one check returns the served
+// fingerprint, whether or not it matches the disk.
+func TestVerifier_ServedFingerprint(t *testing.T) {
+	managed := &cert.ManagedCertificate{
+		Config:      &config.CertificateConfig{Name: "web", HealthCheck: &config.HealthCheck{TCP: "localhost:443"}},
+		Fingerprint: "new",
+	}
+
+	verifier := NewVerifier(&sequenceChecker{fingerprints: []string{"old"}})
+	served, err := verifier.ServedFingerprint(managed)
+	if err != nil || served != "old" {
+		t.Errorf("expected the served fingerprint, got %q, %v", served, err)
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// sequenceChecker serves the given fingerprints in turn, repeating the last.
+type sequenceChecker struct {
+	fingerprints []string
+	calls        int
+}
+
+// Check implements Checker.
+func (s *sequenceChecker) Check(*cert.ManagedCertificate) (*CheckResult, error) {
+	fingerprint := s.fingerprints[min(s.calls, len(s.fingerprints)-1)]
+	s.calls++
+	return &CheckResult{Success: true, RemoteFingerprint: fingerprint}, nil
+}
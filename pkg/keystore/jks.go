@@ -0,0 +1,248 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Java KeyStore Encoding
+//
+// Writes JKS keystores for JVM services that cannot read PEM: a private key
+// entry with its certificate chain, and trusted certificate entries for
+// truststores. Keys are protected with the JKS key protector and the store
+// is sealed with the JKS integrity digest, both keyed by the store password.
+// -------------------------------------------------------------------------------
+
+// Package keystore encodes Java KeyStore (JKS) files.
+package keystore
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	magic   = 0xFEEDFEED
+	version = 2
+
+	tagPrivateKey  = 1
+	tagTrustedCert = 2
+
+	certType = "X.509"
+
+	// digestWhitener is mixed into the integrity digest by the JDK.
+	digestWhitener = "Mighty Aphrodite"
+
+	saltLength = sha1.Size
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// keyProtectorOID identifies the JDK's proprietary key protection algorithm.
+var keyProtectorOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 42, 2, 17, 1, 1}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// KeyStore is an in-memory JKS keystore.
+type KeyStore struct {
+	entries []entry
+	aliases map[string]bool
+}
+
+// entry is one keystore entry. Private key entries carry the PKCS#8 key
+// and chain; trusted certificate entries carry a single certificate.
+type entry struct {
+	alias   string
+	created time.Time
+	key     []byte
+	chain   []*x509.Certificate
+}
+
+// encryptedPrivateKeyInfo is the PKCS#8 wrapper around a protected key.
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// AddPrivateKey adds a private key entry. chain starts with the key's
+// certificate, followed by its issuers.
+func (ks *KeyStore) AddPrivateKey(alias string, key crypto.PrivateKey, chain []*x509.Certificate, created time.Time) error {
+	if len(chain) == 0 {
+		return fmt.Errorf("private key entry %q needs a certificate", alias)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key for %q: %w", alias, err)
+	}
+	return ks.add(entry{alias: alias, created: created, key: der, chain: chain})
+}
+
+// AddTrustedCert adds a trusted certificate entry.
+func (ks *KeyStore) AddTrustedCert(alias string, cert *x509.Certificate, created time.Time) error {
+	return ks.add(entry{alias: alias, created: created, chain: []*x509.Certificate{cert}})
+}
+
+// Marshal encodes the keystore, protecting private keys and the store
+// with password.
+func (ks *KeyStore) Marshal(password string) ([]byte, error) {
+	var buf bytes.Buffer
+	writeUint32(&buf, magic)
+	writeUint32(&buf, version)
+	writeUint32(&buf, uint32(len(ks.entries)))
+
+	for _, e := range ks.entries {
+		if e.key == nil {
+			writeUint32(&buf, tagTrustedCert)
+			writeUTF(&buf, e.alias)
+			writeUint64(&buf, uint64(e.created.UnixMilli()))
+			writeCertificate(&buf, e.chain[0])
+			continue
+		}
+
+		protected, err := protectKey(e.key, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to protect private key %q: %w", e.alias, err)
+		}
+		writeUint32(&buf, tagPrivateKey)
+		writeUTF(&buf, e.alias)
+		writeUint64(&buf, uint64(e.created.UnixMilli()))
+		writeUint32(&buf, uint32(len(protected)))
+		buf.Write(protected)
+		writeUint32(&buf, uint32(len(e.chain)))
+		for _, cert := range e.chain {
+			writeCertificate(&buf, cert)
+		}
+	}
+
+	buf.Write(storeDigest(password, buf.Bytes()))
+	return buf.Bytes(), nil
+}
+
+// add appends an entry, rejecting duplicate aliases. Aliases are
+// lowercased, as the JDK does when it loads a JKS file.
+func (ks *KeyStore) add(e entry) error {
+	e.alias = strings.ToLower(e.alias)
+	if e.alias == "" {
+		return fmt.Errorf("keystore alias is required")
+	}
+	if ks.aliases == nil {
+		ks.aliases = make(map[string]bool)
+	}
+	if ks.aliases[e.alias] {
+		return fmt.Errorf("duplicate keystore alias %q", e.alias)
+	}
+	ks.aliases[e.alias] = true
+	ks.entries = append(ks.entries, e)
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// protectKey encrypts a PKCS#8 key with the JDK key protector: the key is
+// XORed with a SHA-1 keystream seeded by a random salt, and a SHA-1 check
+// over the plaintext is appended.
+func protectKey(plain []byte, password string) ([]byte, error) {
+	passwd := passwordBytes(password)
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, len(plain))
+	digest := salt
+	for offset := 0; offset < len(plain); offset += sha1.Size {
+		h := sha1.New()
+		h.Write(passwd)
+		h.Write(digest)
+		digest = h.Sum(nil)
+		for i := 0; i < sha1.Size && offset+i < len(plain); i++ {
+			encrypted[offset+i] = plain[offset+i] ^ digest[i]
+		}
+	}
+
+	check := sha1.New()
+	check.Write(passwd)
+	check.Write(plain)
+
+	data := append(append(salt, encrypted...), check.Sum(nil)...)
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: keyProtectorOID, Parameters: asn1.NullRawValue},
+		EncryptedData: data,
+	})
+}
+
+// storeDigest computes the integrity digest appended to a JKS file.
+func storeDigest(password string, data []byte) []byte {
+	h := sha1.New()
+	h.Write(passwordBytes(password))
+	h.Write([]byte(digestWhitener))
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// passwordBytes encodes a password as big-endian UTF-16, as the JDK does.
+func passwordBytes(password string) []byte {
+	units := utf16.Encode([]rune(password))
+	out := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		out = append(out, byte(u>>8), byte(u))
+	}
+	return out
+}
+
+// writeCertificate writes a certificate type and its DER encoding.
+func writeCertificate(buf *bytes.Buffer, cert *x509.Certificate) {
+	writeUTF(buf, certType)
+	writeUint32(buf, uint32(len(cert.Raw)))
+	buf.Write(cert.Raw)
+}
+
+// writeUTF writes a string in Java's modified UTF-8 with a length prefix.
+func writeUTF(buf *bytes.Buffer, s string) {
+	var encoded []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		switch {
+		case u >= 0x0001 && u <= 0x007F:
+			encoded = append(encoded, byte(u))
+		case u <= 0x07FF:
+			encoded = append(encoded, byte(0xC0|u>>6), byte(0x80|u&0x3F))
+		default:
+			encoded = append(encoded, byte(0xE0|u>>12), byte(0x80|(u>>6)&0x3F), byte(0x80|u&0x3F))
+		}
+	}
+	_ = binary.Write(buf, binary.BigEndian, uint16(len(encoded)))
+	buf.Write(encoded)
+}
+
+// writeUint32 writes a big-endian uint32.
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	_ = binary.Write(buf, binary.BigEndian, v)
+}
+
+// writeUint64 writes a big-endian uint64.
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	_ = binary.Write(buf, binary.BigEndian, v)
+}
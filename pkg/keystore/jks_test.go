@@ -0,0 +1,281 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Java KeyStore Encoding Tests
+//
+// Unit tests decoding generated keystores the way the JDK does: checking
+// the integrity digest, entry layout, and private key recovery.
+// -------------------------------------------------------------------------------
+
+package keystore
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestKeyStore_Marshal verifies a private key entry and a trusted
+// certificate entry round-trip through the JKS format.
+func TestKeyStore_Marshal(t *testing.T) {
+	key, cert := newTestCertificate(t, "web.example.com")
+	_, ca := newTestCertificate(t, "Example CA")
+	created := time.UnixMilli(1700000000000)
+
+	ks := &KeyStore{}
+	if err := ks.AddPrivateKey("Web", key, []*x509.Certificate{cert, ca}, created); err != nil {
+		t.Fatalf("AddPrivateKey failed: %v", err)
+	}
+	if err := ks.AddTrustedCert("ca-0", ca, created); err != nil {
+		t.Fatalf("AddTrustedCert failed: %v", err)
+	}
+
+	data, err := ks.Marshal("changeit")
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	entries := decodeKeyStore(t, data, "changeit")
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	private := entries[0]
+	if private.alias != "web" {
+		t.Errorf("expected lowercased alias web, got %q", private.alias)
+	}
+	if !private.created.Equal(created) {
+		t.Errorf("expected creation time %v, got %v", created, private.created)
+	}
+	if len(private.chain) != 2 || !private.chain[0].Equal(cert) || !private.chain[1].Equal(ca) {
+		t.Errorf("private key chain does not match")
+	}
+	wantKey, _ := x509.MarshalPKCS8PrivateKey(key)
+	if !bytes.Equal(private.key, wantKey) {
+		t.Error("recovered private key does not match")
+	}
+
+	trusted := entries[1]
+	if trusted.alias != "ca-0" || trusted.key != nil || !trusted.chain[0].Equal(ca) {
+		t.Errorf("unexpected trusted entry: %+v", trusted)
+	}
+}
+
+// TestKeyStore_WrongPassword verifies the integrity digest is keyed by the
+// store password.
+func TestKeyStore_WrongPassword(t *testing.T) {
+	_, cert := newTestCertificate(t, "web.example.com")
+
+	ks := &KeyStore{}
+	if err := ks.AddTrustedCert("web", cert, time.Now()); err != nil {
+		t.Fatalf("AddTrustedCert failed: %v", err)
+	}
+	data, err := ks.Marshal("changeit")
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	body := data[:len(data)-sha1.Size]
+	if bytes.Equal(storeDigest("wrong", body), data[len(data)-sha1.Size:]) {
+		t.Error("expected digest mismatch for the wrong password")
+	}
+}
+
+// TestKeyStore_Add verifies alias validation.
+func TestKeyStore_Add(t *testing.T) {
+	key, cert := newTestCertificate(t, "web.example.com")
+
+	ks := &KeyStore{}
+	if err := ks.AddPrivateKey("web", key, nil, time.Now()); err == nil {
+		t.Error("expected error for private key without certificate")
+	}
+	if err := ks.AddTrustedCert("", cert, time.Now()); err == nil {
+		t.Error("expected error for empty alias")
+	}
+	if err := ks.AddTrustedCert("web", cert, time.Now()); err != nil {
+		t.Fatalf("AddTrustedCert failed: %v", err)
+	}
+	if err := ks.AddTrustedCert("WEB", cert, time.Now()); err == nil {
+		t.Error("expected error for duplicate alias")
+	}
+}
+
+// TestWriteUTF verifies Java modified UTF-8 encoding.
+func TestWriteUTF(t *testing.T) {
+	var buf bytes.Buffer
+	writeUTF(&buf, "a\x00é")
+
+	expected := []byte{0x00, 0x05, 'a', 0xC0, 0x80, 0xC3, 0xA9}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("expected % x, got % x", expected, buf.Bytes())
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// newTestCertificate creates an ECDSA key and self-signed certificate.
+func newTestCertificate(t *testing.T, commonName string) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return key, cert
+}
+
+// decodeKeyStore parses a JKS file as the JDK does, failing the test on an
+// integrity or key check mismatch.
+func decodeKeyStore(t *testing.T, data []byte, password string) []entry {
+	t.Helper()
+
+	body, digest := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	if !bytes.Equal(storeDigest(password, body), digest) {
+		t.Fatal("keystore integrity digest mismatch")
+	}
+
+	r := bytes.NewReader(body)
+	var header struct{ Magic, Version, Count uint32 }
+	mustRead(t, r, &header)
+	if header.Magic != magic || header.Version != version {
+		t.Fatalf("unexpected header %+v", header)
+	}
+
+	var entries []entry
+	for i := uint32(0); i < header.Count; i++ {
+		var tag uint32
+		var created uint64
+		mustRead(t, r, &tag)
+		e := entry{alias: readUTF(t, r)}
+		mustRead(t, r, &created)
+		e.created = time.UnixMilli(int64(created))
+
+		switch tag {
+		case tagPrivateKey:
+			e.key = recoverKey(t, readBytes(t, r), password)
+			var n uint32
+			mustRead(t, r, &n)
+			for j := uint32(0); j < n; j++ {
+				e.chain = append(e.chain, readCertificate(t, r))
+			}
+		case tagTrustedCert:
+			e.chain = []*x509.Certificate{readCertificate(t, r)}
+		default:
+			t.Fatalf("unexpected entry tag %d", tag)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// recoverKey reverses the JDK key protector and checks the key digest.
+func recoverKey(t *testing.T, der []byte, password string) []byte {
+	t.Helper()
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatalf("failed to parse protected key: %v", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(keyProtectorOID) {
+		t.Fatalf("unexpected key protection algorithm %v", info.Algorithm.Algorithm)
+	}
+
+	data := info.EncryptedData
+	salt, encrypted, check := data[:saltLength], data[saltLength:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+	passwd := passwordBytes(password)
+
+	plain := make([]byte, len(encrypted))
+	digest := salt
+	for offset := 0; offset < len(encrypted); offset += sha1.Size {
+		digest = sha1Sum(passwd, digest)
+		for i := 0; i < sha1.Size && offset+i < len(encrypted); i++ {
+			plain[offset+i] = encrypted[offset+i] ^ digest[i]
+		}
+	}
+	if !bytes.Equal(sha1Sum(passwd, plain), check) {
+		t.Fatal("private key check digest mismatch")
+	}
+	return plain
+}
+
+// readCertificate reads a certificate type and DER certificate.
+func readCertificate(t *testing.T, r io.Reader) *x509.Certificate {
+	t.Helper()
+	if typ := readUTF(t, r); typ != certType {
+		t.Fatalf("unexpected certificate type %q", typ)
+	}
+	cert, err := x509.ParseCertificate(readBytes(t, r))
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+// readUTF reads a length-prefixed ASCII string.
+func readUTF(t *testing.T, r io.Reader) string {
+	t.Helper()
+	var n uint16
+	mustRead(t, r, &n)
+	buf := make([]byte, n)
+	mustRead(t, r, buf)
+	return string(buf)
+}
+
+// readBytes reads a uint32 length-prefixed byte slice.
+func readBytes(t *testing.T, r io.Reader) []byte {
+	t.Helper()
+	var n uint32
+	mustRead(t, r, &n)
+	buf := make([]byte, n)
+	mustRead(t, r, buf)
+	return buf
+}
+
+// mustRead reads big-endian data, failing the test on error.
+func mustRead(t *testing.T, r io.Reader, v any) {
+	t.Helper()
+	if err := binary.Read(r, binary.BigEndian, v); err != nil {
+		t.Fatalf("failed to read keystore: %v", err)
+	}
+}
+
+// sha1Sum hashes the concatenation of parts.
+func sha1Sum(parts ...[]byte) []byte {
+	h := sha1.New()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
@@ -15,11 +15,20 @@ package metrics
 
 import (
 	"cert-manager/pkg/cert"
+	"cert-manager/pkg/cloud"
 	"cert-manager/pkg/health"
+	"cert-manager/pkg/watch"
 	"cert-manager/pkg/web"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -29,6 +38,19 @@ import (
 // TYPES
 // -------------------------------------------------------------------------
 
+// ServerOptions selects what the HTTP server serves and whether it uses
+// HTTPS. The zero value serves everything over plain HTTP.
+type ServerOptions struct {
+	CertFile         string // serve HTTPS when set, with KeyFile
+	KeyFile          string
+	DisableDashboard bool
+	DisableMetrics   bool
+
+	// ClientCAFile requires a client certificate signed by these CAs for
+	// the dashboard and its API. The probes and /metrics stay open.
+	ClientCAFile string
+}
+
 // Collector gathers and exposes certificate metrics for Prometheus.
 type Collector struct {
 	certManager   *cert.Manager
@@ -39,9 +61,45 @@ type Collector struct {
 	notBeforeTimestamp   *prometheus.GaugeVec
 	notAfterTimestamp    *prometheus.GaugeVec
 	renewalsTotal        *prometheus.CounterVec
+	renewalFailures      *prometheus.CounterVec
+	renewalDuration      *prometheus.HistogramVec
 	fingerprintInfo      *prometheus.GaugeVec
-
+	servedUntrusted      *prometheus.GaugeVec
+	servedChainNotAfter  *prometheus.GaugeVec
+	servedEarlyExpiry    *prometheus.GaugeVec
+	destinationInSync    *prometheus.GaugeVec
+	renewalInterval      *prometheus.GaugeVec
+	certInfo             *prometheus.GaugeVec
+	metadataLabels       []string
+	rateLimiter          *web.RateLimiter
+	nodeIdentity         *cloud.Identity
+	watchScanner         *watch.Scanner
+	readiness            func() error
+
+	// Renewal counts last exported, by certificate. Its keys are the
+	// certificates with series, see RemoveCertificate.
+	countsMu      sync.Mutex
 	renewalCounts map[string]map[string]int
+
+	// HTTP server started by StartServer; stopped is set by Shutdown so a
+	// server not yet started never starts.
+	serverMu      sync.Mutex
+	server        *http.Server
+	stopped       bool
+	serverOptions ServerOptions
+
+	// Sinks other than Prometheus, see EmitToSinks. lastCounts holds the
+	// cumulative values last emitted, to send counters as increases.
+	sinks      []Sink
+	sinkMu     sync.Mutex
+	lastCounts map[string]float64
+
+	// Version, commit, and configuration hash, see SetBuildInfo.
+	buildMu sync.Mutex
+	build   web.BuildInfo
+
+	// Credentials the dashboard's API requires, see SetAuth.
+	auth *web.Auth
 }
 
 // -------------------------------------------------------------------------
@@ -90,6 +148,23 @@ func NewCollector(certManager *cert.Manager, healthChecker health.Checker) *Coll
 			[]string{"name", "status"},
 		),
 
+		renewalFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "managed_cert_renewal_failures_total",
+				Help: "The total number of failed issuance attempts since startup, by the stage that failed.",
+			},
+			[]string{"name", "reason"},
+		),
+
+		renewalDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "managed_cert_renewal_duration_seconds",
+				Help:    "How long issuance attempts took, from the request to the confirmed deployment, by status (success or error).",
+				Buckets: prometheus.ExponentialBuckets(0.1, 2, 12),
+			},
+			[]string{"name", "status"},
+		),
+
 		fingerprintInfo: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "managed_cert_fingerprint_info",
@@ -97,13 +172,63 @@ func NewCollector(certManager *cert.Manager, healthChecker health.Checker) *Coll
 			},
 			[]string{"name", "fingerprint", "location"},
 		),
+
+		servedUntrusted: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "managed_cert_health_check_untrusted",
+				Help: "1 if a verifying health check saw the certificate served but its chain or hostname did not verify, 0 otherwise.",
+			},
+			[]string{"name"},
+		),
+
+		servedChainNotAfter: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "managed_cert_served_chain_not_after_timestamp_seconds",
+				Help: "The earliest expiry of any certificate in the chain the health check target serves, as a Unix timestamp.",
+			},
+			[]string{"name"},
+		),
+
+		servedEarlyExpiry: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "managed_cert_served_intermediate_expires_first",
+				Help: "1 if an intermediate the health check target serves expires before the leaf, 0 otherwise.",
+			},
+			[]string{"name"},
+		),
+
+		destinationInSync: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "managed_cert_destination_in_sync",
+				Help: "1 if the deployed file holds what was last written for the latest deployment, 0 otherwise.",
+			},
+			[]string{"name", "destination", "path"},
+		),
+
+		renewalInterval: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "managed_cert_renewal_interval_seconds",
+				Help: "The time between the certificate's two most recent successful rotations, in seconds.",
+			},
+			[]string{"name"},
+		),
 	}
 
 	registry.MustRegister(c.lastRenewedTimestamp)
 	registry.MustRegister(c.notBeforeTimestamp)
 	registry.MustRegister(c.notAfterTimestamp)
 	registry.MustRegister(c.renewalsTotal)
+	registry.MustRegister(c.renewalFailures)
+	registry.MustRegister(c.renewalDuration)
 	registry.MustRegister(c.fingerprintInfo)
+	registry.MustRegister(c.servedUntrusted)
+	registry.MustRegister(c.servedChainNotAfter)
+	registry.MustRegister(c.servedEarlyExpiry)
+	registry.MustRegister(c.destinationInSync)
+	registry.MustRegister(c.renewalInterval)
+	registry.MustRegister(newHookCollector(certManager))
+	registry.MustRegister(newExpiryCollector(certManager))
+	registry.MustRegister(newBuildCollector(c))
 
 	return c
 }
@@ -112,43 +237,373 @@ func NewCollector(certManager *cert.Manager, healthChecker health.Checker) *Coll
 // PUBLIC METHODS
 // -------------------------------------------------------------------------
 
-// StartServer starts the HTTP server with Prometheus metrics and web dashboard.
-func (c *Collector) StartServer(port int) error {
-	mux := http.NewServeMux()
+// SetMetadataLabels registers managed_cert_info with the given certificate
+// metadata keys as labels. Certificates missing a key get an empty value.
+func (c *Collector) SetMetadataLabels(keys []string) {
+	c.metadataLabels = keys
+	c.certInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "managed_cert_info",
+			Help: "A static metric with value of 1, labeled with whitelisted certificate metadata.",
+		},
+		append([]string{"name"}, keys...),
+	)
+	c.registry.MustRegister(c.certInfo)
+}
 
-	// Prometheus metrics endpoint
-	mux.Handle("/metrics", promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{}))
+// SetRenewalThrottle exports whether renewals are being deferred while
+// Vault is degraded, and how many have been deferred.
+func (c *Collector) SetRenewalThrottle(throttle *cert.RenewalThrottle) {
+	c.registry.MustRegister(
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Name: "managed_cert_renewal_throttled",
+				Help: "1 while non-urgent renewals are deferred because Vault looks degraded, 0 otherwise.",
+			},
+			func() float64 {
+				if throttle.Throttled() {
+					return 1
+				}
+				return 0
+			},
+		),
+		prometheus.NewCounterFunc(
+			prometheus.CounterOpts{
+				Name: "managed_cert_renewals_deferred_total",
+				Help: "The total number of renewals deferred while Vault looked degraded.",
+			},
+			func() float64 { return float64(throttle.Deferred()) },
+		),
+	)
+}
 
-	// Web dashboard
+// SetNodeIdentity adds the node's cloud identity as static cloud_* labels
+// on every exported metric and shows it on the dashboard and /api/node.
+func (c *Collector) SetNodeIdentity(identity *cloud.Identity) {
+	c.nodeIdentity = identity
+}
+
+// SetRateLimiter limits the dashboard's mutating endpoints and exports the
+// limiter's metrics.
+func (c *Collector) SetRateLimiter(limiter *web.RateLimiter) {
+	c.rateLimiter = limiter
+	c.registry.MustRegister(limiter)
+}
+
+// SetAuth requires credentials for the dashboard's rotation endpoints, and
+// for its read-only endpoints when auth protects status. /metrics and the
+// probes stay open.
+func (c *Collector) SetAuth(auth *web.Auth) {
+	c.auth = auth
+}
+
+// SetReadiness makes /readyz fail with the error check returns until it
+// returns nil. Without it, /readyz always succeeds.
+func (c *Collector) SetReadiness(check func() error) {
+	c.readiness = check
+}
+
+// MetricsHandler returns the Prometheus /metrics handler for the
+// collector's own registry, labeled with the node identity when set.
+func (c *Collector) MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(c.gatherer(), promhttp.HandlerOpts{})
+}
+
+// NewDashboard returns a web dashboard configured like the one StartServer
+// serves, for mounting its handlers on an existing server.
+func (c *Collector) NewDashboard() *web.Dashboard {
 	dashboard := web.NewDashboard(c.certManager, c.healthChecker)
-	dashboard.RegisterHandlers(mux)
+	dashboard.SetRateLimiter(c.rateLimiter)
+	dashboard.SetAuth(c.auth)
+	dashboard.SetNodeIdentity(c.nodeIdentity)
+	dashboard.SetWatchScanner(c.watchScanner)
+	dashboard.SetBuildInfo(c.BuildInfo)
+	return dashboard
+}
 
+// SetServerOptions configures what StartServer and Handler serve.
+func (c *Collector) SetServerOptions(options ServerOptions) {
+	c.serverOptions = options
+}
+
+// Handler returns everything StartServer serves, the probes plus /metrics
+// and the web dashboard and its API unless disabled, as one http.Handler.
+func (c *Collector) Handler() http.Handler {
+	handler, _ := c.newHandler()
+	return handler
+}
+
+// StartServer starts the HTTP server with Prometheus metrics and web
+// dashboard, blocking until it fails or Shutdown is called. It returns nil
+// after Shutdown.
+func (c *Collector) StartServer(port int) error {
 	addr := fmt.Sprintf(":%d", port)
-	slog.Info("Starting HTTP server", "address", addr, "endpoints", []string{"/", "/metrics", "/api/status", "/api/rotate/*"})
 
-	return http.ListenAndServe(addr, mux)
+	c.serverMu.Lock()
+	if c.stopped {
+		c.serverMu.Unlock()
+		return nil
+	}
+	handler, dashboard := c.newHandler()
+	server := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	server.RegisterOnShutdown(dashboard.CloseStreams)
+	options := c.serverOptions
+	if options.CertFile != "" {
+		// Fail at startup on unreadable files rather than on every handshake.
+		if _, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile); err != nil {
+			c.serverMu.Unlock()
+			return fmt.Errorf("failed to load server certificate: %w", err)
+		}
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: options.getCertificate,
+		}
+		if options.ClientCAFile != "" {
+			clientCAs, err := loadClientCAs(options.ClientCAFile)
+			if err != nil {
+				c.serverMu.Unlock()
+				return err
+			}
+			// Verified when given, and required by the dashboard's handlers.
+			server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+			server.TLSConfig.ClientCAs = clientCAs
+		}
+	}
+	c.server = server
+	c.serverMu.Unlock()
+
+	slog.Info("Starting HTTP server",
+		"address", addr,
+		"tls", options.CertFile != "",
+		"endpoints", options.endpoints())
+
+	var err error
+	if options.CertFile != "" {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the HTTP server, waiting until ctx is done for in-flight
+// requests to finish.
+func (c *Collector) Shutdown(ctx context.Context) error {
+	c.serverMu.Lock()
+	c.stopped = true
+	server := c.server
+	c.serverMu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
 
-// UpdateMetrics refreshes all certificate and health check metrics.
+// UpdateMetrics refreshes all certificate and health check metrics, and
+// removes the series of certificates no longer managed.
 func (c *Collector) UpdateMetrics() {
-	managedCerts := c.certManager.GetManagedCertificates()
+	snapshot := c.certManager.Snapshot()
+	current := make(map[string]bool, len(snapshot))
+	for _, managed := range snapshot {
+		current[managed.Config.Name] = true
+	}
+	c.removeStale(current)
 
-	for name, managed := range managedCerts {
+	for _, managed := range snapshot {
+		name := managed.Config.Name
 		c.updateCertificateMetrics(name, managed)
 		c.updateHealthCheckMetrics(name, managed)
 	}
 }
 
+// RemoveCertificate deletes every series labeled with the certificate's
+// name, so a certificate no longer managed stops being reported.
+// Scrape-time metrics such as managed_cert_expiry_seconds follow the
+// manager and need no cleanup.
+func (c *Collector) RemoveCertificate(name string) {
+	c.countsMu.Lock()
+	delete(c.renewalCounts, name)
+	c.countsMu.Unlock()
+
+	labels := prometheus.Labels{"name": name}
+	for _, vec := range c.certificateVecs() {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
 // -------------------------------------------------------------------------
 // PRIVATE METHODS
 // -------------------------------------------------------------------------
 
+// newHandler builds Handler, returning the dashboard it serves, nil when
+// disabled.
+func (c *Collector) newHandler() (http.Handler, *web.Dashboard) {
+	mux := http.NewServeMux()
+	if !c.serverOptions.DisableMetrics {
+		mux.Handle("/metrics", c.MetricsHandler())
+	}
+	mux.HandleFunc("/healthz", c.handleHealthz)
+	mux.HandleFunc("/readyz", c.handleReadyz)
+
+	var dashboard *web.Dashboard
+	if !c.serverOptions.DisableDashboard {
+		dashboard = c.NewDashboard()
+		if c.serverOptions.ClientCAFile != "" {
+			dashboardMux := http.NewServeMux()
+			dashboard.RegisterHandlers(dashboardMux)
+			mux.Handle("/", requireClientCertificate(dashboardMux))
+		} else {
+			dashboard.RegisterHandlers(mux)
+		}
+	}
+	return mux, dashboard
+}
+
+// requireClientCertificate rejects requests without a verified client
+// certificate with 403 Forbidden.
+func requireClientCertificate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			http.Error(w, "Client certificate required", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// loadClientCAs reads the CAs trusted to sign client certificates.
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in client CA file %s", path)
+	}
+	return pool, nil
+}
+
+// gatherer returns the collector's registry, with certificate labels on
+// each certificate's metrics and the node identity on all of them when set.
+func (c *Collector) gatherer() prometheus.Gatherer {
+	var gatherer prometheus.Gatherer = &certLabelGatherer{inner: c.registry, certManager: c.certManager}
+	if c.nodeIdentity != nil {
+		gatherer = newLabeledGatherer(gatherer, c.nodeIdentity.Labels())
+	}
+	return gatherer
+}
+
+// removeStale removes the series of certificates that have series but are
+// not in current, e.g. after a reload or source refresh removed them.
+func (c *Collector) removeStale(current map[string]bool) {
+	c.countsMu.Lock()
+	var stale []string
+	for name := range c.renewalCounts {
+		if !current[name] {
+			stale = append(stale, name)
+		}
+	}
+	c.countsMu.Unlock()
+
+	for _, name := range stale {
+		slog.Debug("Removing metrics of certificate no longer managed", "certificate", name)
+		c.RemoveCertificate(name)
+	}
+}
+
+// certificateVecs returns the metric vectors labeled by certificate name.
+func (c *Collector) certificateVecs() []interface {
+	DeletePartialMatch(labels prometheus.Labels) int
+} {
+	vecs := []interface {
+		DeletePartialMatch(labels prometheus.Labels) int
+	}{
+		c.lastRenewedTimestamp,
+		c.notBeforeTimestamp,
+		c.notAfterTimestamp,
+		c.renewalsTotal,
+		c.renewalFailures,
+		c.renewalDuration,
+		c.fingerprintInfo,
+		c.servedUntrusted,
+		c.servedChainNotAfter,
+		c.servedEarlyExpiry,
+		c.destinationInSync,
+		c.renewalInterval,
+	}
+	if c.certInfo != nil {
+		vecs = append(vecs, c.certInfo)
+	}
+	return vecs
+}
+
+// handleHealthz reports the process as alive whenever it can serve HTTP.
+func (c *Collector) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz reports 503 with the reason until the readiness check
+// passes.
+func (c *Collector) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if c.readiness != nil {
+		if err := c.readiness(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, "ok")
+}
+
+// getCertificate loads the server certificate on every handshake, so one
+// renewed by this agent is served without a restart.
+func (o ServerOptions) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certificate, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+// endpoints lists the paths served with these options, for logging.
+func (o ServerOptions) endpoints() []string {
+	endpoints := []string{"/healthz", "/readyz"}
+	if !o.DisableMetrics {
+		endpoints = append(endpoints, "/metrics")
+	}
+	if !o.DisableDashboard {
+		endpoints = append(endpoints, "/", "/api/status", "/api/node", "/api/watch", "/api/rotate/*")
+	}
+	return endpoints
+}
+
 // updateCertificateMetrics updates metrics for a single certificate.
 func (c *Collector) updateCertificateMetrics(name string, managed *cert.ManagedCertificate) {
+	if c.certInfo != nil {
+		values := []string{name}
+		for _, key := range c.metadataLabels {
+			values = append(values, managed.Config.Metadata[key])
+		}
+		c.certInfo.WithLabelValues(values...).Set(1)
+	}
+
 	if !managed.LastRenewed.IsZero() {
 		c.lastRenewedTimestamp.WithLabelValues(name).Set(float64(managed.LastRenewed.Unix()))
 	}
 
+	c.updateRenewalCounter(name, "success", managed.Renewals.Success)
+	c.updateRenewalCounter(name, "error", managed.Renewals.Failure)
+
+	if interval := managed.RenewalInterval(); interval > 0 {
+		c.renewalInterval.WithLabelValues(name).Set(interval.Seconds())
+	}
+
 	if managed.Certificate != nil {
 		c.notBeforeTimestamp.WithLabelValues(name).Set(float64(managed.Certificate.NotBefore.Unix()))
 		c.notAfterTimestamp.WithLabelValues(name).Set(float64(managed.Certificate.NotAfter.Unix()))
@@ -157,6 +612,31 @@ func (c *Collector) updateCertificateMetrics(name string, managed *cert.ManagedC
 			c.fingerprintInfo.WithLabelValues(name, managed.Fingerprint, "disk").Set(1)
 		}
 	}
+
+	for destination, status := range managed.Destinations {
+		inSync := 0.0
+		if status.InSync {
+			inSync = 1
+		}
+		c.destinationInSync.WithLabelValues(name, destination, status.Path).Set(inSync)
+	}
+}
+
+// updateRenewalCounter raises the renewal counter to the manager's count,
+// which includes renewals restored from the state file.
+func (c *Collector) updateRenewalCounter(name, status string, count int) {
+	c.countsMu.Lock()
+	defer c.countsMu.Unlock()
+
+	counts, ok := c.renewalCounts[name]
+	if !ok {
+		counts = make(map[string]int)
+		c.renewalCounts[name] = counts
+	}
+	if delta := count - counts[status]; delta > 0 {
+		c.renewalsTotal.WithLabelValues(name, status).Add(float64(delta))
+		counts[status] = count
+	}
 }
 
 // updateHealthCheckMetrics performs health check and updates fingerprint metrics.
@@ -171,7 +651,17 @@ func (c *Collector) updateHealthCheckMetrics(name string, managed *cert.ManagedC
 		return
 	}
 
-	if !result.Success {
+	if managed.Config.HealthCheck.Verify {
+		untrusted := 0.0
+		if result.Untrusted {
+			untrusted = 1
+		}
+		c.servedUntrusted.WithLabelValues(name).Set(untrusted)
+	}
+
+	if result.Untrusted {
+		slog.Warn("Health check found the certificate served but untrusted", "certificate", name, "error", result.Error)
+	} else if !result.Success {
 		slog.Warn("Health check failed", "certificate", name, "error", result.Error)
 		return
 	}
@@ -179,9 +669,48 @@ func (c *Collector) updateHealthCheckMetrics(name string, managed *cert.ManagedC
 	if result.RemoteFingerprint != "" {
 		c.fingerprintInfo.WithLabelValues(name, result.RemoteFingerprint, "memory").Set(1)
 	}
+
+	if len(result.Chain) > 0 {
+		notAfter, earlyExpiry := result.Chain[0].NotAfter, 0.0
+		if intermediate := result.ExpiringIntermediate(); intermediate != nil {
+			notAfter, earlyExpiry = intermediate.NotAfter, 1
+			slog.Warn("Served intermediate expires before the certificate",
+				"certificate", name,
+				"intermediate", intermediate.Subject.CommonName,
+				"intermediate_not_after", intermediate.NotAfter,
+				"not_after", result.Chain[0].NotAfter)
+		}
+		c.servedChainNotAfter.WithLabelValues(name).Set(float64(notAfter.Unix()))
+		c.servedEarlyExpiry.WithLabelValues(name).Set(earlyExpiry)
+	}
+}
+
+// ObserveRenewal implements cert.RenewalObserver, recording the duration
+// of each issuance attempt and the stage failed attempts failed in.
+func (c *Collector) ObserveRenewal(name string, event cert.RotationEvent, duration time.Duration) {
+	c.track(name)
+
+	status := "success"
+	if !event.Success {
+		status = "error"
+		c.renewalFailures.WithLabelValues(name, event.Reason).Inc()
+	}
+	c.renewalDuration.WithLabelValues(name, status).Observe(duration.Seconds())
 }
 
 // IncrementRenewalCounter increments the renewal counter for a certificate.
 func (c *Collector) IncrementRenewalCounter(name, status string) {
+	c.track(name)
 	c.renewalsTotal.WithLabelValues(name, status).Inc()
 }
+
+// track records that name has series, so removeStale finds them once the
+// certificate is no longer managed.
+func (c *Collector) track(name string) {
+	c.countsMu.Lock()
+	defer c.countsMu.Unlock()
+
+	if _, ok := c.renewalCounts[name]; !ok {
+		c.renewalCounts[name] = make(map[string]int)
+	}
+}
@@ -15,9 +15,20 @@ import (
 	"cert-manager/pkg/config"
 	"cert-manager/pkg/health"
 	"cert-manager/pkg/vault"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/mock/gomock"
 )
 
@@ -53,6 +64,210 @@ func TestNewCollector(t *testing.T) {
 	}
 }
 
+// TestCollector_Handler verifies the metrics and dashboard handlers serve
+// each collector's own registry, so several can share a process.
+func TestCollector_Handler(t *testing.T) {
+	first := NewCollector(cert.NewManager(nil), nil)
+	second := NewCollector(cert.NewManager(nil), nil)
+	first.IncrementRenewalCounter("web", "success")
+
+	get := func(handler http.Handler, path string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 for %s, got %d", path, rec.Code)
+		}
+		return rec.Body.String()
+	}
+
+	if !strings.Contains(get(first.MetricsHandler(), "/metrics"), `managed_cert_renewals_total{name="web",status="success"} 1`) {
+		t.Error("expected renewal counter in first collector's metrics")
+	}
+	if strings.Contains(get(second.MetricsHandler(), "/metrics"), `name="web"`) {
+		t.Error("expected second collector's metrics to be independent")
+	}
+	get(first.Handler(), "/api/status")
+	get(first.Handler(), "/metrics")
+}
+
+// TestCollector_Probes verifies /healthz always succeeds and /readyz
+// reports the readiness check's error until it passes.
+func TestCollector_Probes(t *testing.T) {
+	collector := NewCollector(cert.NewManager(nil), nil)
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		collector.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("expected ready without a readiness check, got %d", rec.Code)
+	}
+
+	var notReady error = errors.New("initial certificate processing has not completed")
+	collector.SetReadiness(func() error { return notReady })
+	if rec := get("/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("expected healthz 200, got %d", rec.Code)
+	}
+	rec := get("/readyz")
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "initial certificate processing") {
+		t.Errorf("expected 503 with the reason, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	notReady = nil
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("expected ready once the check passes, got %d", rec.Code)
+	}
+}
+
+// TestCollector_Shutdown verifies Shutdown stops a running server and
+// keeps one not yet started from starting.
+func TestCollector_Shutdown(t *testing.T) {
+	collector := NewCollector(cert.NewManager(nil), nil)
+	done := make(chan error, 1)
+	go func() { done <- collector.StartServer(0) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		collector.serverMu.Lock()
+		started := collector.server != nil
+		collector.serverMu.Unlock()
+		if started {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := collector.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil after shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartServer did not return after shutdown")
+	}
+
+	if err := collector.StartServer(0); err != nil {
+		t.Errorf("expected a stopped collector not to start, got %v", err)
+	}
+}
+
+// TestCollector_ServerOptions verifies disabled endpoints are not served
+// while the probes always are, and the TLS certificate is read from disk.
+func TestCollector_ServerOptions(t *testing.T) {
+	collector := NewCollector(cert.NewManager(nil), nil)
+	collector.SetServerOptions(ServerOptions{DisableDashboard: true, DisableMetrics: true})
+	for path, want := range map[string]int{
+		"/metrics":    http.StatusNotFound,
+		"/api/status": http.StatusNotFound,
+		"/healthz":    http.StatusOK,
+		"/readyz":     http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		collector.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+
+	tmpDir := t.TempDir()
+	data := vault.CreateTestCertificateData()
+	options := ServerOptions{CertFile: filepath.Join(tmpDir, "web.crt"), KeyFile: filepath.Join(tmpDir, "web.key")}
+	if err := os.WriteFile(options.CertFile, []byte(data.Certificate), 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(options.KeyFile, []byte(data.PrivateKey), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if _, err := options.getCertificate(nil); err != nil {
+		t.Errorf("unexpected error loading the server certificate: %v", err)
+	}
+
+	collector.SetServerOptions(ServerOptions{CertFile: filepath.Join(tmpDir, "missing.crt"), KeyFile: options.KeyFile})
+	if err := collector.StartServer(0); err == nil {
+		t.Error("expected an error for a missing server certificate")
+	}
+}
+
+// TestCollector_ClientCertificate verifies that with a client CA the
+// dashboard and its API require a verified client certificate, while the
+// probes and /metrics do not.
+func TestCollector_ClientCertificate(t *testing.T) {
+	collector := NewCollector(cert.NewManager(nil), nil)
+	collector.SetServerOptions(ServerOptions{CertFile: "web.crt", KeyFile: "web.key", ClientCAFile: "clients.pem"})
+	handler := collector.Handler()
+
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	tests := []struct {
+		path  string
+		state *tls.ConnectionState
+		want  int
+	}{
+		{"/api/status", nil, http.StatusForbidden},
+		{"/", &tls.ConnectionState{}, http.StatusForbidden},
+		{"/api/status", verified, http.StatusOK},
+		{"/healthz", nil, http.StatusOK},
+		{"/metrics", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.TLS = tt.state
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s with %v: expected %d, got %d", tt.path, tt.state != nil, tt.want, rec.Code)
+		}
+	}
+
+	tmpDir := t.TempDir()
+	if _, err := loadClientCAs(filepath.Join(tmpDir, "missing.pem")); err == nil {
+		t.Error("expected an error for a missing client CA file")
+	}
+	caFile := filepath.Join(tmpDir, "clients.pem")
+	if err := os.WriteFile(caFile, []byte(vault.CreateTestCertificateData().CertificateChain), 0644); err != nil {
+		t.Fatalf("failed to write client CA: %v", err)
+	}
+	if _, err := loadClientCAs(caFile); err != nil {
+		t.Errorf("unexpected error loading the client CA: %v", err)
+	}
+}
+
+// TestCollector_Push verifies metrics are pushed to the job and instance
+// group on the Pushgateway.
+func TestCollector_Push(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	collector := NewCollector(cert.NewManager(nil), nil)
+	collector.renewalsTotal.WithLabelValues("web", "success").Inc()
+	if err := collector.Push(PushOptions{URL: server.URL, Job: "vault-cert-manager", Instance: "node1", Timeout: time.Second}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPut || path != "/metrics/job/vault-cert-manager/instance/node1" {
+		t.Errorf("expected a PUT to the job and instance group, got %s %s", method, path)
+	}
+	if !strings.Contains(body, "managed_cert_renewals_total") {
+		t.Error("expected the renewal counter in the pushed metrics")
+	}
+
+	server.Close()
+	if err := collector.Push(PushOptions{URL: server.URL, Job: "vault-cert-manager", Timeout: time.Second}); err == nil {
+		t.Error("expected an error when the Pushgateway is unreachable")
+	}
+}
+
 // TestCollector_UpdateMetrics verifies metrics refresh functionality.
 func TestCollector_UpdateMetrics(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -84,6 +299,57 @@ func TestCollector_UpdateMetrics(t *testing.T) {
 	}
 }
 
+// TestCollector_RemovesStaleSeries verifies the series of a certificate
+// are deleted once it is no longer managed, and others are kept.
+func TestCollector_RemovesStaleSeries(t *testing.T) {
+	certManager := cert.NewManager(nil)
+	collector := NewCollector(certManager, nil)
+	collector.SetMetadataLabels([]string{"team"})
+
+	for _, name := range []string{"web", "db"} {
+		if err := certManager.AddCertificate(&config.CertificateConfig{Name: name, Role: "test-role", CommonName: name + ".example.com"}); err != nil {
+			t.Fatalf("failed to add certificate: %v", err)
+		}
+		collector.ObserveRenewal(name, cert.RotationEvent{Success: false, Reason: "issue"}, time.Second)
+	}
+	collector.UpdateMetrics()
+
+	names := func() map[string]bool {
+		t.Helper()
+		families, err := collector.registry.Gather()
+		if err != nil {
+			t.Fatalf("failed to gather metrics: %v", err)
+		}
+		seen := make(map[string]bool)
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				if name, ok := metricTags(metric)["name"]; ok {
+					seen[family.GetName()+"/"+name] = true
+				}
+			}
+		}
+		return seen
+	}
+	if seen := names(); !seen["managed_cert_info/db"] || !seen["managed_cert_renewal_failures_total/db"] {
+		t.Fatalf("expected series for db, got %v", seen)
+	}
+
+	if err := certManager.RemoveCertificate("db"); err != nil {
+		t.Fatalf("failed to remove certificate: %v", err)
+	}
+	collector.UpdateMetrics()
+
+	seen := names()
+	for series := range seen {
+		if strings.HasSuffix(series, "/db") {
+			t.Errorf("expected no series for db, found %s", series)
+		}
+	}
+	if !seen["managed_cert_info/web"] || !seen["managed_cert_renewal_failures_total/web"] {
+		t.Errorf("expected series for web to be kept, got %v", seen)
+	}
+}
+
 // TestCollector_IncrementRenewalCounter verifies renewal counter increments.
 func TestCollector_IncrementRenewalCounter(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -97,3 +363,388 @@ func TestCollector_IncrementRenewalCounter(t *testing.T) {
 	collector.IncrementRenewalCounter("test-cert", "success")
 	collector.IncrementRenewalCounter("test-cert", "error")
 }
+
+// TestCollector_RenewalsFromStateFile verifies the renewal counter picks up
+// counts restored from the state file and only counts them once.
+func TestCollector_RenewalsFromStateFile(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	_ = os.WriteFile(statePath, []byte(`{"version": 1, "certificates": {"web": {"renewals": {"success": 3, "failure": 1}}}}`), 0600)
+
+	certManager := cert.NewManager(nil)
+	if err := certManager.SetStateFile(statePath); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := certManager.AddCertificate(&config.CertificateConfig{
+		Name:        "web",
+		Certificate: filepath.Join(dir, "web.crt"),
+		Key:         filepath.Join(dir, "web.key"),
+	}); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	collector := NewCollector(certManager, nil)
+	collector.UpdateMetrics()
+	collector.UpdateMetrics()
+
+	rec := httptest.NewRecorder()
+	collector.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`managed_cert_renewals_total{name="web",status="success"} 3`,
+		`managed_cert_renewals_total{name="web",status="error"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected %s in metrics", want)
+		}
+	}
+}
+
+// fakeVaultStats returns fixed Vault counters.
+type fakeVaultStats struct{}
+
+// Stats returns fixed retry, request, and auth counters.
+func (fakeVaultStats) Stats() vault.Stats {
+	return vault.Stats{
+		Retries: map[string]uint64{"issue": 2},
+		Requests: map[string]vault.RequestStats{
+			"pki/issue/web": {Count: 3, Errors: 1, DurationSum: 0.3, Buckets: map[float64]uint64{0.1: 2, 1: 3}},
+		},
+		AuthSuccesses:    1,
+		ClockSkew:        -45 * time.Second,
+		ClockSkewSampled: true,
+		LastAuth:         time.Unix(1700000000, 0),
+		TokenExpiry:      time.Now().Add(time.Hour),
+	}
+}
+
+// TestCollector_SetVaultStats verifies Vault retry counters are exported.
+func TestCollector_SetVaultStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	certManager := cert.NewManager(vault.NewMockClient(ctrl))
+	collector := NewCollector(certManager, health.NewTCPChecker())
+	collector.SetVaultStats(fakeVaultStats{})
+
+	families, err := collector.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, mf := range families {
+		found[mf.GetName()] = true
+		switch mf.GetName() {
+		case "managed_cert_vault_retries_total":
+			if v := mf.GetMetric()[0].GetCounter().GetValue(); v != 2 {
+				t.Errorf("expected 2 retries, got %v", v)
+			}
+		case "managed_cert_vault_request_errors_total":
+			if v := mf.GetMetric()[0].GetCounter().GetValue(); v != 1 {
+				t.Errorf("expected 1 request error, got %v", v)
+			}
+		case "managed_cert_vault_request_duration_seconds":
+			if v := mf.GetMetric()[0].GetHistogram().GetSampleCount(); v != 3 {
+				t.Errorf("expected 3 latency samples, got %v", v)
+			}
+		case "managed_cert_vault_clock_skew_seconds":
+			if v := mf.GetMetric()[0].GetGauge().GetValue(); v != -45 {
+				t.Errorf("expected -45s clock skew, got %v", v)
+			}
+		case "managed_cert_vault_last_auth_timestamp_seconds":
+			if v := mf.GetMetric()[0].GetGauge().GetValue(); v != 1700000000 {
+				t.Errorf("expected the last auth timestamp, got %v", v)
+			}
+		case "managed_cert_vault_token_ttl_seconds":
+			if v := mf.GetMetric()[0].GetGauge().GetValue(); v <= 3500 || v > 3600 {
+				t.Errorf("expected about an hour of token TTL, got %v", v)
+			}
+		}
+	}
+
+	for _, name := range []string{
+		"managed_cert_vault_retries_total",
+		"managed_cert_vault_requests_total",
+		"managed_cert_vault_request_errors_total",
+		"managed_cert_vault_request_duration_seconds",
+		"managed_cert_vault_auth_total",
+		"managed_cert_vault_clock_skew_seconds",
+		"managed_cert_vault_last_auth_timestamp_seconds",
+		"managed_cert_vault_token_ttl_seconds",
+	} {
+		if !found[name] {
+			t.Errorf("%s not found", name)
+		}
+	}
+}
+
+// TestCollector_HookFailures verifies failed on_change attempts are
+// exported by reason.
+func TestCollector_HookFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	certManager := cert.NewManager(mockClient)
+	collector := NewCollector(certManager, health.NewTCPChecker())
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: tmpDir + "/web.crt",
+		Key:         tmpDir + "/web.key",
+		TTL:         24 * time.Hour,
+		OnChange:    "exit 1",
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	if err := certManager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	_ = certManager.ProcessCertificates()
+
+	families, err := collector.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	counts := make(map[string]float64)
+	for _, mf := range families {
+		if mf.GetName() != "managed_cert_hook_failures_total" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "reason" {
+					counts[label.GetValue()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	if counts["error"] != 1 || counts["timeout"] != 0 {
+		t.Errorf("unexpected hook failure counts %v", counts)
+	}
+}
+
+// TestCollector_ObserveRenewal verifies attempt durations are recorded by
+// status and failures counted by reason.
+func TestCollector_ObserveRenewal(t *testing.T) {
+	collector := NewCollector(cert.NewManager(nil), nil)
+	collector.ObserveRenewal("web", cert.RotationEvent{Success: true}, 2*time.Second)
+	collector.ObserveRenewal("web", cert.RotationEvent{Reason: "issue"}, time.Second)
+
+	rec := httptest.NewRecorder()
+	collector.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`managed_cert_renewal_failures_total{name="web",reason="issue"} 1`,
+		`managed_cert_renewal_duration_seconds_count{name="web",status="success"} 1`,
+		`managed_cert_renewal_duration_seconds_sum{name="web",status="error"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("expected %s in metrics", want)
+		}
+	}
+}
+
+// TestCollector_Expiry verifies the time left is exported and the days
+// left match the dashboard's.
+func TestCollector_Expiry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tmpDir := t.TempDir()
+	mockClient := vault.NewMockClient(ctrl)
+	certManager := cert.NewManager(mockClient)
+	collector := NewCollector(certManager, nil)
+
+	certConfig := &config.CertificateConfig{
+		Name:        "web",
+		Role:        "test-role",
+		CommonName:  "web.example.com",
+		Certificate: tmpDir + "/web.crt",
+		Key:         tmpDir + "/web.key",
+		TTL:         24 * time.Hour,
+	}
+	mockClient.EXPECT().IssueCertificate(certConfig).Return(vault.CreateTestCertificateData(), nil)
+	if err := certManager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	if err := certManager.ProcessCertificates(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notAfter := certManager.Snapshot()[0].Certificate.NotAfter
+	left := time.Until(notAfter).Seconds()
+
+	families, err := collector.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	values := make(map[string]float64)
+	for _, mf := range families {
+		for _, metric := range mf.GetMetric() {
+			values[mf.GetName()] = metric.GetGauge().GetValue()
+		}
+	}
+	if seconds := values["managed_cert_expiry_seconds"]; seconds <= 0 || seconds > left {
+		t.Errorf("unexpected expiry seconds %v", seconds)
+	}
+	if days := values["managed_cert_days_left"]; days != float64(cert.DaysLeft(notAfter)) {
+		t.Errorf("expected %d days left, got %v", cert.DaysLeft(notAfter), days)
+	}
+}
+
+// TestCollector_SetMetadataLabels verifies only whitelisted metadata keys
+// become labels on managed_cert_info.
+func TestCollector_SetMetadataLabels(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	certManager := cert.NewManager(vault.NewMockClient(ctrl))
+	collector := NewCollector(certManager, health.NewTCPChecker())
+	collector.SetMetadataLabels([]string{"team", "service"})
+
+	err := certManager.AddCertificate(&config.CertificateConfig{
+		Name:     "db-cert",
+		Metadata: map[string]string{"team": "database", "ticket": "OPS-1"},
+	})
+	if err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	collector.UpdateMetrics()
+
+	families, err := collector.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	for _, mf := range families {
+		if mf.GetName() != "managed_cert_info" {
+			continue
+		}
+
+		labels := make(map[string]string)
+		for _, lp := range mf.GetMetric()[0].GetLabel() {
+			labels[lp.GetName()] = lp.GetValue()
+		}
+
+		expected := map[string]string{"name": "db-cert", "team": "database", "service": ""}
+		if len(labels) != len(expected) {
+			t.Fatalf("expected labels %v, got %v", expected, labels)
+		}
+		for k, v := range expected {
+			if labels[k] != v {
+				t.Errorf("expected label %s=%q, got %q", k, v, labels[k])
+			}
+		}
+		return
+	}
+	t.Error("managed_cert_info not found")
+}
+
+// TestLabeledGatherer verifies node labels are added to every gathered metric.
+func TestLabeledGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"name"})
+	registry.MustRegister(counter)
+	counter.WithLabelValues("a").Inc()
+	counter.WithLabelValues("b").Inc()
+
+	gatherer := newLabeledGatherer(registry, map[string]string{"cloud_provider": "gce", "cloud_zone": "us-east1-b"})
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	metrics := families[0].GetMetric()
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(metrics))
+	}
+	for _, metric := range metrics {
+		var names []string
+		for _, lp := range metric.GetLabel() {
+			names = append(names, lp.GetName())
+		}
+		if got := strings.Join(names, ","); got != "cloud_provider,cloud_zone,name" {
+			t.Errorf("expected sorted labels cloud_provider,cloud_zone,name, got %s", got)
+		}
+	}
+}
+
+// TestCollector_BuildInfo verifies the build and configuration info metrics
+// carry the values set on the collector.
+func TestCollector_BuildInfo(t *testing.T) {
+	collector := NewCollector(cert.NewManager(nil), nil)
+	collector.SetBuildInfo("1.4.0", "abc123")
+
+	labels := func(name string) map[string]string {
+		t.Helper()
+		families, err := collector.registry.Gather()
+		if err != nil {
+			t.Fatalf("failed to gather metrics: %v", err)
+		}
+		for _, family := range families {
+			if family.GetName() == name {
+				return metricTags(family.GetMetric()[0])
+			}
+		}
+		return nil
+	}
+
+	if got := labels("vault_cert_manager_build_info"); got["version"] != "1.4.0" || got["commit"] != "abc123" {
+		t.Errorf("expected version and commit labels, got %v", got)
+	}
+	if got := labels("vault_cert_manager_config_info"); got != nil {
+		t.Errorf("expected no config info before a hash is set, got %v", got)
+	}
+
+	collector.SetConfigHash("0f1e2d3c4b5a")
+	if got := labels("vault_cert_manager_config_info"); got["hash"] != "0f1e2d3c4b5a" {
+		t.Errorf("expected the config hash label, got %v", got)
+	}
+}
+
+// TestCertLabelGatherer verifies certificate labels are added to the
+// certificate's metrics only, without replacing labels a metric has.
+func TestCertLabelGatherer(t *testing.T) {
+	certManager := cert.NewManager(nil)
+	if err := certManager.AddCertificate(&config.CertificateConfig{
+		Name:   "web",
+		Labels: map[string]string{"team": "storefront", "environment": "prod"},
+	}); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	info := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_info"}, []string{"name", "team"})
+	registry.MustRegister(info)
+	info.WithLabelValues("web", "web-platform").Set(1)
+	info.WithLabelValues("db", "").Set(1)
+
+	gatherer := &certLabelGatherer{inner: registry, certManager: certManager}
+	families, err := gatherer.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	for _, metric := range families[0].GetMetric() {
+		labels := metricTags(metric)
+		switch labels["name"] {
+		case "web":
+			if labels["environment"] != "prod" || labels["team"] != "web-platform" {
+				t.Errorf("expected environment added and team kept, got %v", labels)
+			}
+			var names []string
+			for _, lp := range metric.GetLabel() {
+				names = append(names, lp.GetName())
+			}
+			if got := strings.Join(names, ","); got != "environment,name,team" {
+				t.Errorf("expected sorted labels environment,name,team, got %s", got)
+			}
+		case "db":
+			if _, ok := labels["environment"]; ok {
+				t.Errorf("expected no labels added to db, got %v", labels)
+			}
+		}
+	}
+}
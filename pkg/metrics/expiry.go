@@ -0,0 +1,75 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Expiry Metrics
+//
+// Prometheus collector for the time left on each managed certificate.
+// Values are computed at scrape time, so alert rules need not compute
+// not_after - time() themselves and agree with the dashboard's days left.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// expiryCollector converts certificate expiry into time-left metrics.
+type expiryCollector struct {
+	certManager   *cert.Manager
+	expirySeconds *prometheus.Desc
+	daysLeft      *prometheus.Desc
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// newExpiryCollector creates a collector for certManager's certificates.
+func newExpiryCollector(certManager *cert.Manager) *expiryCollector {
+	return &expiryCollector{
+		certManager: certManager,
+		expirySeconds: prometheus.NewDesc(
+			"managed_cert_expiry_seconds",
+			"The time left until the certificate expires, in seconds, negative once expired.",
+			[]string{"name"}, nil,
+		),
+		daysLeft: prometheus.NewDesc(
+			"managed_cert_days_left",
+			"The whole days left until the certificate expires, as shown on the dashboard.",
+			[]string{"name"}, nil,
+		),
+	}
+}
+
+// -------------------------------------------------------------------------
+// PROMETHEUS COLLECTOR
+// -------------------------------------------------------------------------
+
+// Describe sends the metric descriptors to Prometheus.
+func (e *expiryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- e.expirySeconds
+	ch <- e.daysLeft
+}
+
+// Collect emits the time left on certificates loaded from disk.
+func (e *expiryCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, managed := range e.certManager.Snapshot() {
+		if managed.Certificate == nil {
+			continue
+		}
+		name := managed.Config.Name
+		notAfter := managed.Certificate.NotAfter
+		ch <- prometheus.MustNewConstMetric(e.expirySeconds, prometheus.GaugeValue, time.Until(notAfter).Seconds(), name)
+		ch <- prometheus.MustNewConstMetric(e.daysLeft, prometheus.GaugeValue, float64(cert.DaysLeft(notAfter)), name)
+	}
+}
@@ -0,0 +1,101 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Hook Metrics
+//
+// Prometheus collector for failed on_change attempts, deployments rejected
+// by pre_change, rotations the service never confirmed, and remediations of
+// services found serving an old certificate. Counts are kept
+// by the certificate manager and read at scrape time, so failures before the
+// metrics server starts are still counted.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// hookCollector converts on_change failure counts into Prometheus metrics.
+type hookCollector struct {
+	certManager            *cert.Manager
+	failuresTotal          *prometheus.Desc
+	preChangeFailuresTotal *prometheus.Desc
+	unconfirmedTotal       *prometheus.Desc
+	remediationsTotal      *prometheus.Desc
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// newHookCollector creates a collector for certManager's hook failures.
+func newHookCollector(certManager *cert.Manager) *hookCollector {
+	return &hookCollector{
+		certManager: certManager,
+		failuresTotal: prometheus.NewDesc(
+			"managed_cert_hook_failures_total",
+			"The total number of failed on_change attempts, retries included, by reason (timeout or error).",
+			[]string{"name", "reason"}, nil,
+		),
+		preChangeFailuresTotal: prometheus.NewDesc(
+			"managed_cert_pre_change_failures_total",
+			"The total number of deployments rejected by pre_change, keeping the current certificate.",
+			[]string{"name"}, nil,
+		),
+		unconfirmedTotal: prometheus.NewDesc(
+			"managed_cert_rotations_unconfirmed_total",
+			"The total number of rotations whose health_check target never served the new certificate.",
+			[]string{"name"}, nil,
+		),
+		remediationsTotal: prometheus.NewDesc(
+			"managed_cert_remediations_total",
+			"The total number of times a service out of sync for longer than remediate.after was reloaded, by result (success or error).",
+			[]string{"name", "result"}, nil,
+		),
+	}
+}
+
+// -------------------------------------------------------------------------
+// PROMETHEUS COLLECTOR
+// -------------------------------------------------------------------------
+
+// Describe sends the metric descriptors to Prometheus.
+func (h *hookCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- h.failuresTotal
+	ch <- h.preChangeFailuresTotal
+	ch <- h.unconfirmedTotal
+	ch <- h.remediationsTotal
+}
+
+// Collect emits the failure counts of certificates with hooks.
+func (h *hookCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, managed := range h.certManager.Snapshot() {
+		name := managed.Config.Name
+		if managed.Config.PreChange != "" {
+			ch <- prometheus.MustNewConstMetric(h.preChangeFailuresTotal, prometheus.CounterValue, float64(managed.PreChangeFailures), name)
+		}
+		if managed.Config.HealthCheck != nil {
+			ch <- prometheus.MustNewConstMetric(h.unconfirmedTotal, prometheus.CounterValue, float64(managed.UnconfirmedRotations), name)
+			if managed.Config.HealthCheck.Remediate != nil {
+				remediations := managed.Remediations
+				ch <- prometheus.MustNewConstMetric(h.remediationsTotal, prometheus.CounterValue, float64(remediations.Success), name, "success")
+				ch <- prometheus.MustNewConstMetric(h.remediationsTotal, prometheus.CounterValue, float64(remediations.Failure), name, "error")
+			}
+		}
+		if !managed.Config.HasOnChange() {
+			continue
+		}
+		failures := managed.HookFailures
+		ch <- prometheus.MustNewConstMetric(h.failuresTotal, prometheus.CounterValue, float64(failures.Timeouts), name, "timeout")
+		ch <- prometheus.MustNewConstMetric(h.failuresTotal, prometheus.CounterValue, float64(failures.Errors), name, "error")
+	}
+}
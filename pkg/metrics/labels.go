@@ -0,0 +1,113 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Node and Certificate Labels
+//
+// Adds static node labels, such as the cloud instance identity, to every
+// exported metric so fleet-wide queries can group by real infrastructure,
+// and each certificate's configured labels to its metrics so alerts can be
+// routed by owner.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// labeledGatherer adds constant labels to every metric gathered from inner.
+type labeledGatherer struct {
+	inner  prometheus.Gatherer
+	labels []*dto.LabelPair
+}
+
+// certLabelGatherer adds each certificate's configured labels to the
+// metrics gathered from inner that carry the certificate's name.
+type certLabelGatherer struct {
+	inner       prometheus.Gatherer
+	certManager *cert.Manager
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// newLabeledGatherer wraps inner so its metrics carry labels.
+func newLabeledGatherer(inner prometheus.Gatherer, labels map[string]string) *labeledGatherer {
+	g := &labeledGatherer{inner: inner}
+	for name, value := range labels {
+		g.labels = append(g.labels, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	return g
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Gather implements prometheus.Gatherer.
+func (g *labeledGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.inner.Gather()
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			metric.Label = append(metric.Label, g.labels...)
+			sortLabels(metric)
+		}
+	}
+	return families, err
+}
+
+// Gather implements prometheus.Gatherer. Labels a metric already has, such
+// as metadata labels on managed_cert_info, are kept.
+func (g *certLabelGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.inner.Gather()
+
+	labels := make(map[string]map[string]string)
+	for _, managed := range g.certManager.Snapshot() {
+		if len(managed.Config.Labels) > 0 {
+			labels[managed.Config.Name] = managed.Config.Labels
+		}
+	}
+	if len(labels) == 0 {
+		return families, err
+	}
+
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			existing := metricTags(metric)
+			extra := labels[existing["name"]]
+			if extra == nil {
+				continue
+			}
+			for name, value := range extra {
+				if _, ok := existing[name]; !ok {
+					metric.Label = append(metric.Label, &dto.LabelPair{Name: &name, Value: &value})
+				}
+			}
+			sortLabels(metric)
+		}
+	}
+	return families, err
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// sortLabels restores the label order Prometheus expects after labels were
+// appended.
+func sortLabels(metric *dto.Metric) {
+	sort.Slice(metric.Label, func(i, j int) bool {
+		return metric.Label[i].GetName() < metric.Label[j].GetName()
+	})
+}
@@ -0,0 +1,53 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Pushgateway Support
+//
+// Pushes the collector's metrics to a Prometheus Pushgateway. One-shot runs
+// (--rotate from cron) exit before any scrape, so they push their renewal
+// results instead.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// PushOptions identifies the Pushgateway and the group pushed to.
+type PushOptions struct {
+	URL      string
+	Job      string
+	Instance string // grouping label, so nodes do not overwrite each other
+	Timeout  time.Duration
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Push replaces the metrics of the job and instance group on the
+// Pushgateway with the collector's current metrics.
+func (c *Collector) Push(options PushOptions) error {
+	pusher := push.New(options.URL, options.Job).
+		Gatherer(c.gatherer()).
+		Client(&http.Client{Timeout: options.Timeout})
+	if options.Instance != "" {
+		pusher = pusher.Grouping("instance", options.Instance)
+	}
+
+	if err := pusher.Push(); err != nil {
+		return fmt.Errorf("failed to push metrics to %s: %w", options.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Metric Sinks
+//
+// Forwards the collector's metrics to sinks other than Prometheus, for
+// fleets that are not scraped. Every refresh, the registry is gathered and
+// each sample handed to the sinks: gauges as gauges, and counters,
+// histogram counts, and histogram sums as the increase since the previous
+// refresh, so the sink sees the same values a scrape would.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// Sink receives metric samples alongside the Prometheus registry. Tags are
+// the sample's Prometheus labels.
+type Sink interface {
+	Gauge(name string, value float64, tags map[string]string)
+	Count(name string, delta float64, tags map[string]string)
+	Flush() error
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// AddSink forwards metrics to sink on every EmitToSinks.
+func (c *Collector) AddSink(sink Sink) {
+	c.sinks = append(c.sinks, sink)
+}
+
+// EmitToSinks gathers the current metrics and hands them to every sink.
+func (c *Collector) EmitToSinks() {
+	if len(c.sinks) == 0 {
+		return
+	}
+
+	families, err := c.gatherer().Gather()
+	if err != nil {
+		slog.Warn("Failed to gather metrics for sinks", "error", err)
+	}
+
+	c.sinkMu.Lock()
+	defer c.sinkMu.Unlock()
+	if c.lastCounts == nil {
+		c.lastCounts = make(map[string]float64)
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			tags := metricTags(metric)
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				c.emitCount(name, metric.GetCounter().GetValue(), tags)
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				c.emitCount(name+"_count", float64(histogram.GetSampleCount()), tags)
+				c.emitCount(name+"_sum", histogram.GetSampleSum(), tags)
+			case dto.MetricType_GAUGE:
+				c.emitGauge(name, metric.GetGauge().GetValue(), tags)
+			case dto.MetricType_UNTYPED:
+				c.emitGauge(name, metric.GetUntyped().GetValue(), tags)
+			}
+		}
+	}
+
+	for _, sink := range c.sinks {
+		if err := sink.Flush(); err != nil {
+			slog.Warn("Failed to flush metric sink", "error", err)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// emitGauge hands a gauge sample to every sink.
+func (c *Collector) emitGauge(name string, value float64, tags map[string]string) {
+	for _, sink := range c.sinks {
+		sink.Gauge(name, value, tags)
+	}
+}
+
+// emitCount hands the increase of a cumulative value since the previous
+// emit to every sink. A value that went down was reset, e.g. after a
+// certificate was removed and added again, and is sent in full.
+func (c *Collector) emitCount(name string, value float64, tags map[string]string) {
+	key := seriesKey(name, tags)
+	delta := value - c.lastCounts[key]
+	if delta < 0 {
+		delta = value
+	}
+	c.lastCounts[key] = value
+	if delta == 0 {
+		return
+	}
+
+	for _, sink := range c.sinks {
+		sink.Count(name, delta, tags)
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// metricTags returns a sample's labels as tags.
+func metricTags(metric *dto.Metric) map[string]string {
+	tags := make(map[string]string, len(metric.GetLabel()))
+	for _, label := range metric.GetLabel() {
+		tags[label.GetName()] = label.GetValue()
+	}
+	return tags
+}
+
+// seriesKey identifies a series by name and tags.
+func seriesKey(name string, tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, key := range keys {
+		b.WriteString("\x00" + key + "=" + tags[key])
+	}
+	return b.String()
+}
@@ -0,0 +1,151 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - StatsD Sink
+//
+// Sends metrics to a StatsD or Datadog agent over UDP. Samples are written
+// in the DogStatsD format, with the Prometheus labels and the configured
+// tags as "key:value" tags, and batched into packets that fit a typical
+// MTU. Plain StatsD servers that do not understand tags ignore them or
+// must be run with tag support.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// maxStatsDPacket keeps packets below a 1500-byte MTU after IP and UDP
+// headers.
+const maxStatsDPacket = 1432
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// tagReplacer replaces characters that delimit DogStatsD fields in tags.
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// StatsDSink is a Sink sending DogStatsD lines over UDP.
+type StatsDSink struct {
+	conn   net.Conn
+	prefix string
+	tags   []string // constant tags, "key:value"
+
+	mu      sync.Mutex
+	packet  bytes.Buffer
+	sendErr error // first send failure since the last Flush
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewStatsDSink creates a sink sending to address ("host:port"), prefixing
+// every metric name with prefix and adding tags to every sample.
+func NewStatsDSink(address, prefix string, tags []string) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", address, err)
+	}
+	return &StatsDSink{conn: conn, prefix: prefix, tags: tags}, nil
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Gauge sends a gauge sample.
+func (s *StatsDSink) Gauge(name string, value float64, tags map[string]string) {
+	s.write(name, value, "g", tags)
+}
+
+// Count sends a counter increment.
+func (s *StatsDSink) Count(name string, delta float64, tags map[string]string) {
+	s.write(name, delta, "c", tags)
+}
+
+// Flush sends the partial packet and reports the first send failure since
+// the previous Flush.
+func (s *StatsDSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.send()
+	err := s.sendErr
+	s.sendErr = nil
+	return err
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// write appends a sample to the current packet, sending the packet first
+// when the sample would not fit. Values StatsD cannot represent are
+// skipped.
+func (s *StatsDSink) write(name string, value float64, kind string, tags map[string]string) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	line := s.format(name, value, kind, tags)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.packet.Len() > 0 && s.packet.Len()+1+len(line) > maxStatsDPacket {
+		s.send()
+	}
+	if s.packet.Len() > 0 {
+		s.packet.WriteByte('\n')
+	}
+	s.packet.WriteString(line)
+}
+
+// send writes the current packet and starts a new one. Callers hold mu.
+func (s *StatsDSink) send() {
+	if s.packet.Len() == 0 {
+		return
+	}
+	if _, err := s.conn.Write(s.packet.Bytes()); err != nil && s.sendErr == nil {
+		s.sendErr = fmt.Errorf("failed to send to statsd: %w", err)
+	}
+	s.packet.Reset()
+}
+
+// format renders a DogStatsD line: <prefix><name>:<value>|<kind>|#<tags>.
+func (s *StatsDSink) format(name string, value float64, kind string, tags map[string]string) string {
+	all := append([]string(nil), s.tags...)
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		all = append(all, tagReplacer.Replace(key+":"+tags[key]))
+	}
+
+	line := s.prefix + name + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind
+	if len(all) > 0 {
+		line += "|#" + strings.Join(all, ",")
+	}
+	return line
+}
@@ -0,0 +1,83 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - StatsD Sink Tests
+//
+// Unit tests for forwarding metrics to sinks and the StatsD line format.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestCollector_EmitToSinks verifies counters are sent as increases since
+// the previous emit, with labels and constant tags, in DogStatsD format.
+func TestCollector_EmitToSinks(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	sink, err := NewStatsDSink(listener.LocalAddr().String(), "vcm.", []string{"env:test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	collector := NewCollector(cert.NewManager(nil), nil)
+	collector.AddSink(sink)
+
+	receive := func() string {
+		t.Helper()
+		var lines []string
+		buf := make([]byte, 65536)
+		for {
+			_ = listener.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := listener.ReadFrom(buf)
+			if err != nil {
+				return strings.Join(lines, "\n")
+			}
+			lines = append(lines, string(buf[:n]))
+		}
+	}
+
+	counter := collector.renewalsTotal.WithLabelValues("web", "success")
+	counter.Add(2)
+	collector.EmitToSinks()
+	if got := receive(); !strings.Contains(got, "vcm.managed_cert_renewals_total:2|c|#env:test,name:web,status:success") {
+		t.Errorf("expected the renewal counter, got %q", got)
+	}
+
+	counter.Inc()
+	collector.EmitToSinks()
+	if got := receive(); !strings.Contains(got, "vcm.managed_cert_renewals_total:1|c|") {
+		t.Errorf("expected the counter's increase, got %q", got)
+	}
+
+	collector.EmitToSinks()
+	if got := receive(); strings.Contains(got, "managed_cert_renewals_total") {
+		t.Errorf("expected no sample for an unchanged counter, got %q", got)
+	}
+}
+
+// TestStatsDSink_Format verifies tags are sorted and delimiter characters
+// in label values are replaced.
+func TestStatsDSink_Format(t *testing.T) {
+	sink := &StatsDSink{prefix: "vcm.", tags: []string{"env:prod"}}
+	line := sink.format("managed_cert_days_left", 12.5, "g", map[string]string{"name": "a|b,c", "cloud_zone": "us-east1-b"})
+	want := "vcm.managed_cert_days_left:12.5|g|#env:prod,cloud_zone:us-east1-b,name:a_b_c"
+	if line != want {
+		t.Errorf("expected %q, got %q", want, line)
+	}
+}
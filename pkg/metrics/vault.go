@@ -0,0 +1,150 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Vault Metrics
+//
+// Prometheus collector that exposes cumulative Vault client counters. Values
+// are pulled from the Vault client at scrape time so operations performed
+// before the metrics server starts (such as initial authentication) are
+// still counted.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/vault"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// VaultStatsSource provides cumulative Vault operation counters.
+type VaultStatsSource interface {
+	Stats() vault.Stats
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// vaultCollector converts Vault client stats into Prometheus metrics.
+type vaultCollector struct {
+	source          VaultStatsSource
+	retriesTotal    *prometheus.Desc
+	failoversTotal  *prometheus.Desc
+	requestsTotal   *prometheus.Desc
+	errorsTotal     *prometheus.Desc
+	requestDuration *prometheus.Desc
+	authTotal       *prometheus.Desc
+	clockSkew       *prometheus.Desc
+	lastAuth        *prometheus.Desc
+	tokenTTL        *prometheus.Desc
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// SetVaultStats registers metrics backed by the given Vault client.
+func (c *Collector) SetVaultStats(source VaultStatsSource) {
+	c.registry.MustRegister(&vaultCollector{
+		source: source,
+		retriesTotal: prometheus.NewDesc(
+			"managed_cert_vault_retries_total",
+			"The total number of retried Vault operations.",
+			[]string{"operation"}, nil,
+		),
+		failoversTotal: prometheus.NewDesc(
+			"managed_cert_vault_failovers_total",
+			"The total number of switches to another Vault HA address.",
+			nil, nil,
+		),
+		requestsTotal: prometheus.NewDesc(
+			"managed_cert_vault_requests_total",
+			"The total number of Vault API requests.",
+			[]string{"path"}, nil,
+		),
+		errorsTotal: prometheus.NewDesc(
+			"managed_cert_vault_request_errors_total",
+			"The total number of Vault API requests that failed or returned an error status.",
+			[]string{"path"}, nil,
+		),
+		requestDuration: prometheus.NewDesc(
+			"managed_cert_vault_request_duration_seconds",
+			"Latency of Vault API requests, in seconds.",
+			[]string{"path"}, nil,
+		),
+		authTotal: prometheus.NewDesc(
+			"managed_cert_vault_auth_total",
+			"The total number of Vault authentication attempts.",
+			[]string{"result"}, nil,
+		),
+		clockSkew: prometheus.NewDesc(
+			"managed_cert_vault_clock_skew_seconds",
+			"Local clock minus Vault's clock from the last response Date header, in seconds.",
+			nil, nil,
+		),
+		lastAuth: prometheus.NewDesc(
+			"managed_cert_vault_last_auth_timestamp_seconds",
+			"Unix timestamp of the last successful Vault authentication.",
+			nil, nil,
+		),
+		tokenTTL: prometheus.NewDesc(
+			"managed_cert_vault_token_ttl_seconds",
+			"Seconds until the Vault token expires, when known.",
+			nil, nil,
+		),
+	})
+}
+
+// -------------------------------------------------------------------------
+// PROMETHEUS COLLECTOR
+// -------------------------------------------------------------------------
+
+// Describe sends the metric descriptors to Prometheus.
+func (v *vaultCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- v.retriesTotal
+	ch <- v.failoversTotal
+	ch <- v.requestsTotal
+	ch <- v.errorsTotal
+	ch <- v.requestDuration
+	ch <- v.authTotal
+	ch <- v.clockSkew
+	ch <- v.lastAuth
+	ch <- v.tokenTTL
+}
+
+// Collect reads the current Vault stats and emits them as metrics.
+func (v *vaultCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := v.source.Stats()
+	for op, n := range stats.Retries {
+		ch <- prometheus.MustNewConstMetric(v.retriesTotal, prometheus.CounterValue, float64(n), op)
+	}
+	ch <- prometheus.MustNewConstMetric(v.failoversTotal, prometheus.CounterValue, float64(stats.Failovers))
+
+	for path, req := range stats.Requests {
+		ch <- prometheus.MustNewConstMetric(v.requestsTotal, prometheus.CounterValue, float64(req.Count), path)
+		ch <- prometheus.MustNewConstMetric(v.errorsTotal, prometheus.CounterValue, float64(req.Errors), path)
+		ch <- prometheus.MustNewConstHistogram(v.requestDuration, req.Count, req.DurationSum, req.Buckets, path)
+	}
+
+	ch <- prometheus.MustNewConstMetric(v.authTotal, prometheus.CounterValue, float64(stats.AuthSuccesses), "success")
+	ch <- prometheus.MustNewConstMetric(v.authTotal, prometheus.CounterValue, float64(stats.AuthFailures), "failure")
+
+	if stats.ClockSkewSampled {
+		ch <- prometheus.MustNewConstMetric(v.clockSkew, prometheus.GaugeValue, stats.ClockSkew.Seconds())
+	}
+
+	if !stats.LastAuth.IsZero() {
+		ch <- prometheus.MustNewConstMetric(v.lastAuth, prometheus.GaugeValue, float64(stats.LastAuth.Unix()))
+	}
+	if !stats.TokenExpiry.IsZero() {
+		ch <- prometheus.MustNewConstMetric(v.tokenTTL, prometheus.GaugeValue, time.Until(stats.TokenExpiry).Seconds())
+	}
+}
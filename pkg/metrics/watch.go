@@ -0,0 +1,85 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Watch-Only Metrics
+//
+// Prometheus collector for certificates that are watched but not managed.
+// Values come from the scanner's most recent scan at scrape time; targets
+// that could not be read only report managed_cert_watch_up 0.
+// -------------------------------------------------------------------------------
+
+package metrics
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/watch"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// watchCollector converts watched targets into Prometheus metrics.
+type watchCollector struct {
+	scanner            *watch.Scanner
+	up                 *prometheus.Desc
+	notBeforeTimestamp *prometheus.Desc
+	notAfterTimestamp  *prometheus.Desc
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// SetWatchScanner exports the expiry of watched certificates and shows
+// them on the dashboard and /api/watch.
+func (c *Collector) SetWatchScanner(scanner *watch.Scanner) {
+	c.watchScanner = scanner
+	c.registry.MustRegister(&watchCollector{
+		scanner: scanner,
+		up: prometheus.NewDesc(
+			"managed_cert_watch_up",
+			"1 if the watched file or endpoint was read on the last scan, 0 otherwise.",
+			[]string{"source", "location"}, nil,
+		),
+		notBeforeTimestamp: prometheus.NewDesc(
+			"managed_cert_watch_not_before_timestamp_seconds",
+			"The timestamp of the watched certificate not before date, in seconds since the Unix epoch.",
+			[]string{"source", "location", "subject"}, nil,
+		),
+		notAfterTimestamp: prometheus.NewDesc(
+			"managed_cert_watch_not_after_timestamp_seconds",
+			"The timestamp of the watched certificate not after date, in seconds since the Unix epoch.",
+			[]string{"source", "location", "subject"}, nil,
+		),
+	})
+}
+
+// -------------------------------------------------------------------------
+// PROMETHEUS COLLECTOR
+// -------------------------------------------------------------------------
+
+// Describe sends the metric descriptors to Prometheus.
+func (w *watchCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- w.up
+	ch <- w.notBeforeTimestamp
+	ch <- w.notAfterTimestamp
+}
+
+// Collect emits metrics for the targets of the most recent scan.
+func (w *watchCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, target := range w.scanner.Targets() {
+		if target.Error != "" {
+			ch <- prometheus.MustNewConstMetric(w.up, prometheus.GaugeValue, 0, target.Source, target.Location)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(w.up, prometheus.GaugeValue, 1, target.Source, target.Location)
+		ch <- prometheus.MustNewConstMetric(w.notBeforeTimestamp, prometheus.GaugeValue,
+			float64(target.NotBefore.Unix()), target.Source, target.Location, target.Subject)
+		ch <- prometheus.MustNewConstMetric(w.notAfterTimestamp, prometheus.GaugeValue,
+			float64(target.NotAfter.Unix()), target.Source, target.Location, target.Subject)
+	}
+}
@@ -0,0 +1,126 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - acme.sh Migration
+//
+// Reads acme.sh domain directories (<domain>/ or <domain>_ecc/) and their
+// <domain>.conf. Certificates installed with --install-cert are taken from
+// their installed paths, and the reload command becomes on_change.
+// -------------------------------------------------------------------------------
+
+package migrate
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// acme.sh stores some values base64 encoded between these markers.
+const (
+	acmeShBase64Start = "__ACME_BASE64__START_"
+	acmeShBase64End   = "__ACME_BASE64__END_"
+)
+
+// -------------------------------------------------------------------------
+// FUNCTIONS
+// -------------------------------------------------------------------------
+
+// scanAcmeSh returns a candidate for every domain directory under dir.
+func scanAcmeSh(dir string) ([]Candidate, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read acme.sh directory: %w", err)
+	}
+
+	var found []Candidate
+	var skipped []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		lineage := entry.Name()
+		domain := strings.TrimSuffix(lineage, "_ecc")
+		domainDir := filepath.Join(dir, lineage)
+
+		conf, err := readAcmeShConf(filepath.Join(domainDir, domain+".conf"))
+		if os.IsNotExist(err) {
+			continue // not a domain directory (ca/, deploy/, dnsapi/, ...)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("acme.sh %s: %v", lineage, err))
+			continue
+		}
+
+		cert, err := parseCertificateFile(filepath.Join(domainDir, domain+".cer"))
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("acme.sh %s: %v", lineage, err))
+			continue
+		}
+
+		candidate := Candidate{
+			Client:   "acme.sh",
+			Lineage:  lineage,
+			CertPath: filepath.Join(domainDir, "fullchain.cer"),
+			KeyPath:  filepath.Join(domainDir, domain+".key"),
+			Hook:     conf["Le_ReloadCmd"],
+			Cert:     cert,
+		}
+		if path := conf["Le_RealFullChainPath"]; path != "" {
+			candidate.CertPath = path
+		}
+		if path := conf["Le_RealKeyPath"]; path != "" {
+			candidate.KeyPath = path
+		}
+		found = append(found, candidate)
+	}
+
+	return found, skipped, nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// readAcmeShConf parses a domain config of KEY='value' lines, decoding
+// base64-wrapped values.
+func readAcmeShConf(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	conf := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		value = strings.Trim(value, `'"`)
+
+		if encoded, ok := strings.CutPrefix(value, acmeShBase64Start); ok {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(encoded, acmeShBase64End))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+			}
+			value = string(decoded)
+		}
+		conf[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return conf, nil
+}
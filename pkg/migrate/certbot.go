@@ -0,0 +1,106 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - certbot Migration
+//
+// Reads certbot lineages from live/<name>/ and the matching
+// renewal/<name>.conf, whose renew_hook (deploy hook) or post_hook becomes
+// the certificate's on_change command.
+// -------------------------------------------------------------------------------
+
+package migrate
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// FUNCTIONS
+// -------------------------------------------------------------------------
+
+// scanCertbot returns a candidate for every lineage under dir/live.
+func scanCertbot(dir string) ([]Candidate, []string, error) {
+	liveDir := filepath.Join(dir, "live")
+	entries, err := os.ReadDir(liveDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read certbot live directory: %w", err)
+	}
+
+	var found []Candidate
+	var skipped []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		lineage := entry.Name()
+		lineageDir := filepath.Join(liveDir, lineage)
+
+		cert, err := parseCertificateFile(filepath.Join(lineageDir, "cert.pem"))
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("certbot %s: %v", lineage, err))
+			continue
+		}
+
+		hook, err := certbotHook(filepath.Join(dir, "renewal", lineage+".conf"))
+		if err != nil && !os.IsNotExist(err) {
+			skipped = append(skipped, fmt.Sprintf("certbot %s: %v", lineage, err))
+			continue
+		}
+
+		found = append(found, Candidate{
+			Client:   "certbot",
+			Lineage:  lineage,
+			CertPath: filepath.Join(lineageDir, "fullchain.pem"),
+			KeyPath:  filepath.Join(lineageDir, "privkey.pem"),
+			Hook:     hook,
+			Cert:     cert,
+		})
+	}
+
+	return found, skipped, nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// certbotHook returns the reload command from a renewal config's
+// [renewalparams] section, preferring renew_hook over post_hook.
+func certbotHook(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	params := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.Trim(line, "[]")
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && section == "renewalparams" {
+			params[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if hook := params["renew_hook"]; hook != "" {
+		return hook, nil
+	}
+	return params["post_hook"], nil
+}
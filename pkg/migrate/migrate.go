@@ -0,0 +1,342 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - ACME Client Migration
+//
+// Scans hosts managed by certbot or acme.sh and generates equivalent
+// certificate definitions issued from a Vault PKI role. Current certificates
+// can be copied into the managed paths so services keep working until the
+// first Vault issuance, and a report lists what was found and what needs
+// review before cutting over.
+// -------------------------------------------------------------------------------
+
+// Package migrate generates vault-cert-manager config from ACME client state.
+package migrate
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cert-manager/pkg/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Options controls a migration scan.
+type Options struct {
+	CertbotDir string        // certbot config directory, e.g. /etc/letsencrypt ("" skips)
+	AcmeShDir  string        // acme.sh home, e.g. ~/.acme.sh ("" skips)
+	Role       string        // Vault PKI role for generated entries
+	CertDir    string        // directory for managed files ("" keeps the current paths)
+	TTL        time.Duration // requested TTL (default: 24h)
+}
+
+// Candidate is a certificate found in an ACME client's state.
+type Candidate struct {
+	Client   string // "certbot" or "acme.sh"
+	Lineage  string // certbot lineage or acme.sh domain directory
+	CertPath string // full chain the service currently reads
+	KeyPath  string // private key the service currently reads
+	Hook     string // reload command configured in the ACME client
+	Cert     *x509.Certificate
+}
+
+// Result pairs a candidate with its generated certificate definition.
+type Result struct {
+	Candidate
+	Config   config.CertificateConfig
+	Copied   bool
+	Warnings []string
+}
+
+// Plan is the outcome of a migration scan.
+type Plan struct {
+	Results []*Result
+	Skipped []string // lineages that could not be read, with the reason
+}
+
+// -------------------------------------------------------------------------
+// FUNCTIONS
+// -------------------------------------------------------------------------
+
+// Scan reads the configured ACME client directories and builds a
+// certificate definition for every certificate found. The generated
+// definitions are validated as a config file would be.
+func Scan(opts Options) (*Plan, error) {
+	if opts.Role == "" {
+		return nil, fmt.Errorf("a Vault PKI role is required")
+	}
+	if opts.TTL == 0 {
+		opts.TTL = 24 * time.Hour
+	}
+
+	plan := &Plan{}
+	var candidates []Candidate
+
+	if opts.CertbotDir != "" {
+		found, skipped, err := scanCertbot(opts.CertbotDir)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, found...)
+		plan.Skipped = append(plan.Skipped, skipped...)
+	}
+	if opts.AcmeShDir != "" {
+		found, skipped, err := scanAcmeSh(opts.AcmeShDir)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, found...)
+		plan.Skipped = append(plan.Skipped, skipped...)
+	}
+
+	names := make(map[string]bool)
+	for _, candidate := range candidates {
+		plan.Results = append(plan.Results, newResult(candidate, opts, names))
+	}
+
+	data, err := plan.marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := config.ParseCertificates(data, &config.Config{}); err != nil {
+		return nil, fmt.Errorf("generated config is invalid: %w", err)
+	}
+
+	return plan, nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Copy writes each current certificate and key to its managed path, so the
+// service can be pointed at the new paths before the first Vault issuance.
+// Existing files at the managed paths are left alone.
+func (p *Plan) Copy() error {
+	for _, result := range p.Results {
+		if result.Config.Certificate == result.CertPath {
+			continue
+		}
+		if fileExists(result.Config.Certificate) || fileExists(result.Config.Key) {
+			result.Warnings = append(result.Warnings, "managed files already exist, not copied")
+			continue
+		}
+
+		if err := copyFile(result.CertPath, result.Config.Certificate, 0644); err != nil {
+			return err
+		}
+		if err := copyFile(result.KeyPath, result.Config.Key, 0600); err != nil {
+			return err
+		}
+		result.Copied = true
+	}
+	return nil
+}
+
+// WriteConfig writes the generated certificate definitions as a YAML
+// document suitable for a config directory.
+func (p *Plan) WriteConfig(w io.Writer) error {
+	data, err := p.marshal()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// WriteReport writes a human-readable summary of the scan.
+func (p *Plan) WriteReport(w io.Writer) {
+	counts := make(map[string]int)
+	for _, result := range p.Results {
+		counts[result.Client]++
+	}
+	fmt.Fprintf(w, "Found %d certificate(s) (certbot: %d, acme.sh: %d)\n",
+		len(p.Results), counts["certbot"], counts["acme.sh"])
+
+	for _, result := range p.Results {
+		cfg := result.Config
+		fmt.Fprintf(w, "\n%s (%s %s)\n", cfg.Name, result.Client, result.Lineage)
+		fmt.Fprintf(w, "  domains:   %s\n", strings.Join(append([]string{cfg.CommonName}, cfg.AltNames...), ", "))
+		fmt.Fprintf(w, "  expires:   %s (%d days)\n",
+			result.Cert.NotAfter.Format("2006-01-02"), int(time.Until(result.Cert.NotAfter).Hours()/24))
+		fmt.Fprintf(w, "  current:   %s\n", result.CertPath)
+
+		managed := cfg.Certificate
+		if result.Copied {
+			managed += " (copied)"
+		}
+		fmt.Fprintf(w, "  managed:   %s\n", managed)
+		if cfg.OnChange != "" {
+			fmt.Fprintf(w, "  on_change: %s\n", cfg.OnChange)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(w, "  warning:   %s\n", warning)
+		}
+	}
+
+	if len(p.Skipped) > 0 {
+		fmt.Fprintf(w, "\nSkipped:\n")
+		for _, skipped := range p.Skipped {
+			fmt.Fprintf(w, "  %s\n", skipped)
+		}
+	}
+}
+
+// marshal renders the generated definitions as a certificates document.
+func (p *Plan) marshal() ([]byte, error) {
+	doc := struct {
+		Certificates []config.CertificateConfig `yaml:"certificates"`
+	}{Certificates: []config.CertificateConfig{}}
+	for _, result := range p.Results {
+		doc.Certificates = append(doc.Certificates, result.Config)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to marshal certificates: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// newResult builds the certificate definition for a candidate, choosing a
+// name not already in names.
+func newResult(candidate Candidate, opts Options, names map[string]bool) *Result {
+	cert := candidate.Cert
+	result := &Result{Candidate: candidate}
+
+	base := strings.Replace(strings.TrimSuffix(candidate.Lineage, "_ecc"), "*", "wildcard", 1)
+	name := base
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	names[name] = true
+
+	commonName := cert.Subject.CommonName
+	if commonName == "" && len(cert.DNSNames) > 0 {
+		commonName = cert.DNSNames[0]
+	}
+
+	cfg := config.CertificateConfig{
+		Name:        name,
+		Role:        opts.Role,
+		CommonName:  commonName,
+		Certificate: candidate.CertPath,
+		Key:         candidate.KeyPath,
+		TTL:         opts.TTL,
+		OnChange:    candidate.Hook,
+	}
+	if opts.CertDir != "" {
+		cfg.Certificate = filepath.Join(opts.CertDir, name+".crt")
+		cfg.Key = filepath.Join(opts.CertDir, name+".key")
+	}
+
+	for _, dnsName := range cert.DNSNames {
+		if dnsName != commonName {
+			cfg.AltNames = append(cfg.AltNames, dnsName)
+		}
+	}
+	for _, ip := range cert.IPAddresses {
+		cfg.IPSans = append(cfg.IPSans, ip.String())
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		cfg.KeyType, cfg.KeyBits = "rsa", key.N.BitLen()
+	case *ecdsa.PublicKey:
+		cfg.KeyType, cfg.KeyBits = "ec", key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		cfg.KeyType = "ed25519"
+	}
+
+	result.Config = cfg
+	result.Warnings = warnings(result)
+	return result
+}
+
+// warnings lists what an operator should review for a result.
+func warnings(result *Result) []string {
+	var out []string
+
+	if time.Now().After(result.Cert.NotAfter) {
+		out = append(out, "current certificate has expired")
+	}
+	for _, name := range append([]string{result.Config.CommonName}, result.Config.AltNames...) {
+		if strings.HasPrefix(name, "*.") {
+			out = append(out, "wildcard names require allow_wildcard_certificates on the Vault role")
+			break
+		}
+	}
+	if result.Config.OnChange == "" {
+		out = append(out, "no reload hook found, set on_change so services pick up renewals")
+	}
+	if result.Config.Certificate == result.CertPath {
+		out = append(out, fmt.Sprintf("managed paths are %s's files, disable its renewal before cutting over", result.Client))
+	} else {
+		out = append(out, "point services at the managed paths, then disable "+result.Client+" renewal")
+	}
+
+	return out
+}
+
+// parseCertificateFile reads the first certificate in a PEM file.
+func parseCertificateFile(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no certificate found in %s", path)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
+
+// copyFile copies src to dst with mode, creating dst's directory.
+func copyFile(src, dst string, mode os.FileMode) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dst, err)
+	}
+	if err := os.WriteFile(dst, data, mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	return nil
+}
+
+// fileExists checks if a file exists at the given path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,206 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - ACME Client Migration Tests
+//
+// Unit tests for scanning certbot and acme.sh layouts and generating config.
+// -------------------------------------------------------------------------------
+
+package migrate
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/config"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestScan verifies certbot and acme.sh certificates become definitions
+// with their domains, key parameters, paths, and reload hooks.
+func TestScan(t *testing.T) {
+	tmpDir := t.TempDir()
+	certbotDir := filepath.Join(tmpDir, "letsencrypt")
+	acmeShDir := filepath.Join(tmpDir, "acme.sh")
+
+	live := filepath.Join(certbotDir, "live", "example.com")
+	writeCertificate(t, live, "cert.pem", "fullchain.pem", "privkey.pem", "example.com", "www.example.com")
+	writeFile(t, filepath.Join(certbotDir, "live", "README"), "")
+	writeFile(t, filepath.Join(certbotDir, "renewal", "example.com.conf"),
+		"version = 2.0.0\n[renewalparams]\nauthenticator = nginx\nrenew_hook = systemctl reload nginx\n")
+
+	domainDir := filepath.Join(acmeShDir, "example.com_ecc")
+	writeCertificate(t, domainDir, "example.com.cer", "fullchain.cer", "example.com.key", "example.com", "api.example.com")
+	reload := base64.StdEncoding.EncodeToString([]byte("systemctl restart haproxy"))
+	writeFile(t, filepath.Join(domainDir, "example.com.conf"),
+		"Le_Domain='example.com'\nLe_RealKeyPath='/etc/haproxy/api.key'\n"+
+			"Le_ReloadCmd='"+acmeShBase64Start+reload+acmeShBase64End+"'\n")
+	writeFile(t, filepath.Join(acmeShDir, "ca", "placeholder"), "")
+
+	plan, err := Scan(Options{CertbotDir: certbotDir, AcmeShDir: acmeShDir, Role: "web", TTL: 720 * time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(plan.Results))
+	}
+
+	certbot := plan.Results[0].Config
+	if certbot.Name != "example.com" || certbot.Role != "web" || certbot.TTL != 720*time.Hour {
+		t.Errorf("unexpected certbot entry: %+v", certbot)
+	}
+	if certbot.CommonName != "example.com" || len(certbot.AltNames) != 1 || certbot.AltNames[0] != "www.example.com" {
+		t.Errorf("unexpected certbot names: %s %v", certbot.CommonName, certbot.AltNames)
+	}
+	if certbot.Certificate != filepath.Join(live, "fullchain.pem") || certbot.Key != filepath.Join(live, "privkey.pem") {
+		t.Errorf("unexpected certbot paths: %s %s", certbot.Certificate, certbot.Key)
+	}
+	if certbot.OnChange != "systemctl reload nginx" {
+		t.Errorf("expected renew_hook as on_change, got %q", certbot.OnChange)
+	}
+	if certbot.KeyType != "ec" || certbot.KeyBits != 256 {
+		t.Errorf("expected ec 256 key, got %s %d", certbot.KeyType, certbot.KeyBits)
+	}
+
+	acmeSh := plan.Results[1].Config
+	if acmeSh.Name != "example.com-2" {
+		t.Errorf("expected deduplicated name, got %q", acmeSh.Name)
+	}
+	if acmeSh.Key != "/etc/haproxy/api.key" {
+		t.Errorf("expected installed key path, got %q", acmeSh.Key)
+	}
+	if acmeSh.OnChange != "systemctl restart haproxy" {
+		t.Errorf("expected decoded reload command, got %q", acmeSh.OnChange)
+	}
+
+	var out bytes.Buffer
+	if err := plan.WriteConfig(&out); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := config.ParseCertificates(out.Bytes(), &config.Config{}); err != nil {
+		t.Errorf("generated config does not parse: %v", err)
+	}
+	if !strings.Contains(out.String(), "ttl: 720h0m0s") {
+		t.Errorf("expected readable ttl in config, got:\n%s", out.String())
+	}
+}
+
+// TestPlan_Copy verifies current certificates are copied to the managed
+// paths and existing managed files are not overwritten.
+func TestPlan_Copy(t *testing.T) {
+	tmpDir := t.TempDir()
+	certbotDir := filepath.Join(tmpDir, "letsencrypt")
+	certDir := filepath.Join(tmpDir, "managed")
+
+	live := filepath.Join(certbotDir, "live", "example.com")
+	writeCertificate(t, live, "cert.pem", "fullchain.pem", "privkey.pem", "example.com")
+	writeCertificate(t, filepath.Join(certbotDir, "live", "other.com"), "cert.pem", "fullchain.pem", "privkey.pem", "other.com")
+	writeFile(t, filepath.Join(certDir, "other.com.crt"), "existing")
+
+	plan, err := Scan(Options{CertbotDir: certbotDir, Role: "web", CertDir: certDir})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := plan.Copy(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	copied, err := os.ReadFile(filepath.Join(certDir, "example.com.crt"))
+	if err != nil {
+		t.Fatalf("failed to read copied certificate: %v", err)
+	}
+	original, _ := os.ReadFile(filepath.Join(live, "fullchain.pem"))
+	if !bytes.Equal(copied, original) {
+		t.Error("expected copied certificate to match the full chain")
+	}
+	if info, err := os.Stat(filepath.Join(certDir, "example.com.key")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected key copied with mode 0600: %v", err)
+	}
+
+	if !plan.Results[0].Copied || plan.Results[1].Copied {
+		t.Error("expected only the first certificate to be copied")
+	}
+	existing, _ := os.ReadFile(filepath.Join(certDir, "other.com.crt"))
+	if string(existing) != "existing" {
+		t.Error("expected existing managed file to be left alone")
+	}
+
+	var report bytes.Buffer
+	plan.WriteReport(&report)
+	for _, want := range []string{"Found 2 certificate(s)", "(copied)", "not copied", "no reload hook found"} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, report.String())
+		}
+	}
+}
+
+// TestScan_RequiresRole verifies a role must be given.
+func TestScan_RequiresRole(t *testing.T) {
+	if _, err := Scan(Options{}); err == nil {
+		t.Error("expected error without role")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// writeCertificate writes a self-signed certificate for names into dir as
+// certFile and chainFile, and its key as keyFile.
+func writeCertificate(t *testing.T, dir, certFile, chainFile, keyFile string, names ...string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	writeFile(t, filepath.Join(dir, certFile), certPEM)
+	writeFile(t, filepath.Join(dir, chainFile), certPEM+certPEM)
+	writeFile(t, filepath.Join(dir, keyFile), string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})))
+}
+
+// writeFile writes content to path, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
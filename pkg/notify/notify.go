@@ -0,0 +1,207 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Notification Routing
+//
+// Sends rotation failure and recovery alerts to the team that owns each
+// certificate. The owner is read from a configurable metadata key and
+// mapped to Slack webhooks and PagerDuty services, falling back to a
+// default route, so an expiring database cert pages the database team
+// rather than a catch-all channel.
+// -------------------------------------------------------------------------------
+
+// Package notify routes certificate alerts to owning teams.
+package notify
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Notifier routes rotation outcomes to owner-specific destinations.
+// It satisfies cert.Notifier.
+type Notifier struct {
+	config       *config.NotificationsConfig
+	httpClient   *http.Client
+	pagerDutyURL string
+	hostname     string
+
+	mu      sync.Mutex
+	failing map[string]bool
+	wg      sync.WaitGroup
+}
+
+// alert describes a single notification.
+type alert struct {
+	certificate string
+	owner       string
+	resolved    bool
+	summary     string
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// New creates a notifier from the notifications configuration.
+func New(cfg *config.NotificationsConfig) *Notifier {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &Notifier{
+		config:       cfg,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		pagerDutyURL: pagerDutyEventsURL,
+		hostname:     hostname,
+		failing:      make(map[string]bool),
+	}
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// NotifyRotation alerts the owner when a rotation fails, and again when a
+// previously failing certificate recovers. Alerts are sent asynchronously
+// so a slow webhook never stalls certificate processing.
+func (n *Notifier) NotifyRotation(certConfig *config.CertificateConfig, event cert.RotationEvent, notAfter time.Time) {
+	n.mu.Lock()
+	wasFailing := n.failing[certConfig.Name]
+	n.failing[certConfig.Name] = !event.Success
+	n.mu.Unlock()
+
+	if event.Success && !wasFailing {
+		return
+	}
+
+	a := alert{
+		certificate: certConfig.Name,
+		owner:       certConfig.Metadata[n.config.OwnerKey],
+		resolved:    event.Success,
+	}
+	if event.Success {
+		a.summary = fmt.Sprintf("Certificate %s on %s renewed successfully after earlier failures", certConfig.Name, n.hostname)
+	} else {
+		a.summary = fmt.Sprintf("Certificate %s on %s failed to renew: %s", certConfig.Name, n.hostname, event.Error)
+		if !notAfter.IsZero() {
+			a.summary += fmt.Sprintf(" (current certificate expires %s)", notAfter.UTC().Format(time.RFC3339))
+		}
+	}
+
+	route := n.route(a.owner)
+	if route == nil {
+		slog.Debug("No notification route for certificate", "certificate", a.certificate, "owner", a.owner)
+		return
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.send(route, a)
+	}()
+}
+
+// Wait blocks until all in-flight notifications have been sent.
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// route returns the destinations for an owner, or the default route.
+func (n *Notifier) route(owner string) *config.NotificationRoute {
+	if owner != "" {
+		if route, ok := n.config.Routes[owner]; ok {
+			return &route
+		}
+	}
+	return n.config.Default
+}
+
+// send delivers an alert to every destination on the route.
+func (n *Notifier) send(route *config.NotificationRoute, a alert) {
+	if route.SlackWebhook != "" {
+		if err := n.sendSlack(route.SlackWebhook, a); err != nil {
+			slog.Error("Failed to send Slack notification", "certificate", a.certificate, "owner", a.owner, "error", err)
+		}
+	}
+
+	if route.PagerDutyRoutingKey != "" {
+		if err := n.sendPagerDuty(route.PagerDutyRoutingKey, a); err != nil {
+			slog.Error("Failed to send PagerDuty event", "certificate", a.certificate, "owner", a.owner, "error", err)
+		}
+	}
+}
+
+// sendSlack posts the alert to a Slack incoming webhook.
+func (n *Notifier) sendSlack(webhook string, a alert) error {
+	prefix := ":rotating_light:"
+	if a.resolved {
+		prefix = ":white_check_mark:"
+	}
+	return n.postJSON(webhook, map[string]string{"text": prefix + " " + a.summary})
+}
+
+// sendPagerDuty triggers or resolves a PagerDuty incident. The dedup key is
+// stable per host and certificate so a recovery resolves the original page.
+func (n *Notifier) sendPagerDuty(routingKey string, a alert) error {
+	action := "trigger"
+	if a.resolved {
+		action = "resolve"
+	}
+
+	return n.postJSON(n.pagerDutyURL, map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": action,
+		"dedup_key":    fmt.Sprintf("vault-cert-manager/%s/%s", n.hostname, a.certificate),
+		"payload": map[string]string{
+			"summary":  a.summary,
+			"source":   n.hostname,
+			"severity": "error",
+		},
+	})
+}
+
+// postJSON sends a JSON body and treats non-2xx responses as errors.
+func (n *Notifier) postJSON(url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	resp, err := n.httpClient.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Notification Routing Tests
+//
+// Unit tests for owner-based routing and failure/recovery alerts.
+// -------------------------------------------------------------------------------
+
+package notify
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// recorder is a fake webhook endpoint that records received payloads.
+type recorder struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	bodies []map[string]interface{}
+}
+
+// newRecorder starts a fake webhook endpoint.
+func newRecorder(t *testing.T) *recorder {
+	t.Helper()
+
+	r := &recorder{}
+	r.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, body)
+		r.mu.Unlock()
+	}))
+	t.Cleanup(r.server.Close)
+
+	return r
+}
+
+// received returns the payloads recorded so far.
+func (r *recorder) received() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]interface{}(nil), r.bodies...)
+}
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestNotifier_Routing verifies alerts reach the owning team's route, or
+// the default route when the owner has none.
+func TestNotifier_Routing(t *testing.T) {
+	database := newRecorder(t)
+	fallback := newRecorder(t)
+
+	notifier := New(&config.NotificationsConfig{
+		OwnerKey: "team",
+		Default:  &config.NotificationRoute{SlackWebhook: fallback.server.URL},
+		Routes: map[string]config.NotificationRoute{
+			"database": {SlackWebhook: database.server.URL},
+		},
+	})
+
+	failure := cert.RotationEvent{Time: time.Now(), Error: "permission denied"}
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		expected *recorder
+	}{
+		{name: "owner route", metadata: map[string]string{"team": "database"}, expected: database},
+		{name: "unknown owner", metadata: map[string]string{"team": "web"}, expected: fallback},
+		{name: "no owner", expected: fallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(tt.expected.received())
+
+			notifier.NotifyRotation(&config.CertificateConfig{Name: tt.name, Metadata: tt.metadata}, failure, time.Time{})
+			notifier.Wait()
+
+			if got := len(tt.expected.received()); got != before+1 {
+				t.Errorf("expected one alert on the routed webhook, got %d", got-before)
+			}
+		})
+	}
+
+	if total := len(database.received()) + len(fallback.received()); total != len(tests) {
+		t.Errorf("expected %d alerts in total, got %d", len(tests), total)
+	}
+}
+
+// TestNotifier_PagerDutyResolve verifies a recovery resolves the page
+// opened by the earlier failure, and repeated successes stay quiet.
+func TestNotifier_PagerDutyResolve(t *testing.T) {
+	pagerDuty := newRecorder(t)
+
+	notifier := New(&config.NotificationsConfig{
+		OwnerKey: "team",
+		Routes: map[string]config.NotificationRoute{
+			"database": {PagerDutyRoutingKey: "routing-key"},
+		},
+	})
+	notifier.pagerDutyURL = pagerDuty.server.URL
+
+	certConfig := &config.CertificateConfig{Name: "db", Metadata: map[string]string{"team": "database"}}
+	events := []cert.RotationEvent{
+		{Success: true},
+		{Success: false, Error: "vault sealed"},
+		{Success: true},
+		{Success: true},
+	}
+	for _, event := range events {
+		notifier.NotifyRotation(certConfig, event, time.Time{})
+		notifier.Wait()
+	}
+
+	received := pagerDuty.received()
+	if len(received) != 2 {
+		t.Fatalf("expected trigger and resolve events, got %d", len(received))
+	}
+	if received[0]["event_action"] != "trigger" || received[1]["event_action"] != "resolve" {
+		t.Errorf("expected trigger then resolve, got %v then %v", received[0]["event_action"], received[1]["event_action"])
+	}
+	if received[0]["dedup_key"] != received[1]["dedup_key"] {
+		t.Error("expected resolve to reuse the trigger dedup key")
+	}
+}
@@ -0,0 +1,63 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Docker Reload Action
+//
+// Restarts a container through the Docker Engine API on its unix socket,
+// without needing the docker CLI on the host.
+// -------------------------------------------------------------------------------
+
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// DockerSocket is the Docker Engine API socket.
+var DockerSocket = "/var/run/docker.sock"
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// restartContainer restarts the named container.
+func restartContainer(ctx context.Context, container string) error {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", DockerSocket)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	endpoint := "http://docker/containers/" + url.PathEscape(container) + "/restart"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create docker request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach docker at %s: %w", DockerSocket, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("docker restart of %s returned %s: %s", container, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
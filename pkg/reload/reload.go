@@ -0,0 +1,108 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Reload Actions
+//
+// Built-in on_change actions that reload a service without a shell: a
+// systemd unit over D-Bus, a signal to the process in a pidfile, a call to
+// an HTTP reload endpoint, or a Docker container restart. Each honors the
+// context deadline set by the hook policy.
+// -------------------------------------------------------------------------------
+
+// Package reload runs built-in service reload actions.
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// PUBLIC FUNCTIONS
+// -------------------------------------------------------------------------
+
+// Run performs action, giving up when ctx is done.
+func Run(ctx context.Context, action *config.ActionConfig) error {
+	switch {
+	case action.Systemd != "":
+		return systemdUnit(ctx, action.Systemd, action.SystemdMode)
+	case action.Signal != nil:
+		return signalPidfile(action.Signal)
+	case action.HTTP != nil:
+		return callHTTP(ctx, action.HTTP)
+	case action.Docker != "":
+		return restartContainer(ctx, action.Docker)
+	default:
+		return fmt.Errorf("no reload action configured")
+	}
+}
+
+// Describe returns a short description of action for logs.
+func Describe(action *config.ActionConfig) string {
+	switch {
+	case action.Systemd != "":
+		return fmt.Sprintf("systemd %s %s", action.SystemdMode, action.Systemd)
+	case action.Signal != nil:
+		return fmt.Sprintf("signal %s to %s", action.Signal.Signal, action.Signal.Pidfile)
+	case action.HTTP != nil:
+		return fmt.Sprintf("http %s %s", action.HTTP.Method, action.HTTP.URL)
+	case action.Docker != "":
+		return "docker restart " + action.Docker
+	default:
+		return "none"
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// signalPidfile sends the configured signal to the process in the pidfile.
+func signalPidfile(action *config.SignalAction) error {
+	name := strings.TrimPrefix(strings.ToUpper(action.Signal), "SIG")
+	sig, ok := signals[name]
+	if !ok {
+		return fmt.Errorf("unsupported signal %s", action.Signal)
+	}
+
+	data, err := os.ReadFile(action.Pidfile)
+	if err != nil {
+		return fmt.Errorf("failed to read pidfile: %w", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return fmt.Errorf("invalid pid in %s: %q", action.Pidfile, strings.TrimSpace(string(data)))
+	}
+
+	if err := sendSignal(pid, sig); err != nil {
+		return fmt.Errorf("failed to send SIG%s to pid %d: %w", name, pid, err)
+	}
+	return nil
+}
+
+// callHTTP calls the reload endpoint, failing on a non-2xx status.
+func callHTTP(ctx context.Context, action *config.HTTPAction) error {
+	req, err := http.NewRequestWithContext(ctx, action.Method, action.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create reload request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("reload request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("reload endpoint returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
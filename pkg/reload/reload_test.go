@@ -0,0 +1,181 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Reload Action Tests
+//
+// Unit tests for the signal, HTTP, Docker, and systemd reload actions
+// against local fakes.
+// -------------------------------------------------------------------------------
+
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"cert-manager/pkg/config"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRun_HTTP verifies the endpoint is called with the configured method
+// and non-2xx statuses fail.
+func TestRun_HTTP(t *testing.T) {
+	var method string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	action := &config.ActionConfig{HTTP: &config.HTTPAction{URL: server.URL + "/reload", Method: "PUT"}}
+	if err := Run(context.Background(), action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != "PUT" {
+		t.Errorf("expected PUT, got %s", method)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := Run(context.Background(), action); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected 503 error, got %v", err)
+	}
+}
+
+// TestRun_Docker verifies the container is restarted through the Engine
+// API socket.
+func TestRun_Docker(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	var path string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.Method + " " + r.URL.Path
+		if strings.Contains(r.URL.Path, "missing") {
+			http.Error(w, `{"message":"No such container"}`, http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	previous := DockerSocket
+	DockerSocket = socket
+	defer func() { DockerSocket = previous }()
+
+	if err := Run(context.Background(), &config.ActionConfig{Docker: "web"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "POST /containers/web/restart" {
+		t.Errorf("unexpected request %s", path)
+	}
+	if err := Run(context.Background(), &config.ActionConfig{Docker: "missing"}); err == nil {
+		t.Error("expected error for missing container")
+	}
+}
+
+// TestRun_Systemd verifies the unit is reloaded with a systemd manager
+// call over D-Bus, and a D-Bus error fails the action.
+func TestRun_Systemd(t *testing.T) {
+	calls := fakeSystemBus(t)
+
+	action := &config.ActionConfig{Systemd: "nginx.service", SystemdMode: "reload-or-restart"}
+	if err := Run(context.Background(), action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	call := <-calls
+	if call.Member != "ReloadOrRestartUnit" || call.Destination != "org.freedesktop.systemd1" {
+		t.Errorf("unexpected call %+v", call)
+	}
+	if len(call.Args) != 2 || call.Args[0] != "nginx.service" || call.Args[1] != "replace" {
+		t.Errorf("unexpected arguments %v", call.Args)
+	}
+
+	action.Systemd = "missing.service"
+	err := Run(context.Background(), action)
+	if err == nil || !strings.Contains(err.Error(), "NoSuchUnit") {
+		t.Errorf("expected NoSuchUnit error, got %v", err)
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeSystemBus serves a minimal system bus that answers Hello and sends
+// systemd manager calls to the returned channel. Units named missing.*
+// get an error reply.
+func fakeSystemBus(t *testing.T) <-chan *dbusMessage {
+	socket := filepath.Join(t.TempDir(), "bus.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	previous := SystemBusSocket
+	SystemBusSocket = socket
+	t.Cleanup(func() { SystemBusSocket = previous })
+
+	calls := make(chan *dbusMessage, 4)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			serveSystemBus(conn, calls)
+		}
+	}()
+	return calls
+}
+
+// serveSystemBus handles one client connection.
+func serveSystemBus(conn net.Conn, calls chan<- *dbusMessage) {
+	defer func() { _ = conn.Close() }()
+	r := bufio.NewReader(conn)
+
+	if _, err := r.ReadString('\n'); err != nil {
+		return
+	}
+	_, _ = conn.Write([]byte("OK 0123456789abcdef\r\n"))
+	if _, err := r.ReadString('\n'); err != nil {
+		return
+	}
+
+	for serial := uint32(1); ; serial++ {
+		call, err := readDBusMessage(r)
+		if err != nil {
+			return
+		}
+		reply := &dbusMessage{Type: dbusMethodReturn, Serial: serial, ReplySerial: call.Serial}
+		switch {
+		case call.Member == "Hello":
+			// A signal before the reply must be skipped by the client.
+			signal := &dbusMessage{Type: 4, Serial: 100, Path: "/org/freedesktop/DBus", Interface: "org.freedesktop.DBus", Member: "NameAcquired", Args: []string{":1.1"}}
+			_, _ = conn.Write(signal.encode())
+			reply.Args = []string{":1.1"}
+		case len(call.Args) > 0 && strings.HasPrefix(call.Args[0], "missing."):
+			reply.Type = dbusError
+			reply.ErrorName = "org.freedesktop.systemd1.NoSuchUnit"
+			reply.Args = []string{"Unit " + call.Args[0] + " not found."}
+		default:
+			calls <- call
+		}
+		_, _ = conn.Write(reply.encode())
+	}
+}
@@ -0,0 +1,39 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Signal Action (Unix)
+//
+// Sends POSIX signals to the process named by a signal action's pidfile.
+// -------------------------------------------------------------------------------
+
+//go:build !windows
+
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import "syscall"
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// signals maps signal names, without the SIG prefix, to signals.
+var signals = map[string]syscall.Signal{
+	"HUP":   syscall.SIGHUP,
+	"INT":   syscall.SIGINT,
+	"QUIT":  syscall.SIGQUIT,
+	"TERM":  syscall.SIGTERM,
+	"USR1":  syscall.SIGUSR1,
+	"USR2":  syscall.SIGUSR2,
+	"WINCH": syscall.SIGWINCH,
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// sendSignal sends sig to the process pid.
+func sendSignal(pid int, sig syscall.Signal) error {
+	return syscall.Kill(pid, sig)
+}
@@ -0,0 +1,54 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Signal Action Tests (Unix)
+//
+// Unit tests for sending signals to the process in a pidfile.
+// -------------------------------------------------------------------------------
+
+//go:build !windows
+
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRun_Signal verifies the signal is sent to the pid in the pidfile.
+func TestRun_Signal(t *testing.T) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, syscall.SIGUSR1)
+	defer signal.Stop(received)
+
+	pidfile := filepath.Join(t.TempDir(), "app.pid")
+	_ = os.WriteFile(pidfile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+
+	action := &config.ActionConfig{Signal: &config.SignalAction{Pidfile: pidfile, Signal: "SIGUSR1"}}
+	if err := Run(context.Background(), action); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected SIGUSR1 to be delivered")
+	}
+
+	_ = os.WriteFile(pidfile, []byte("nginx"), 0644)
+	if err := Run(context.Background(), action); err == nil {
+		t.Error("expected error for invalid pidfile")
+	}
+}
@@ -0,0 +1,50 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Signal Action (Windows)
+//
+// Windows processes cannot be sent POSIX signals. TERM and INT terminate
+// the process in the pidfile, the closest equivalent; the other signals,
+// which ask a service to reload, are refused. Reload Windows services with
+// an http action or an on_change command instead.
+// -------------------------------------------------------------------------------
+
+//go:build windows
+
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// signals maps signal names, without the SIG prefix, to signals.
+var signals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"QUIT": syscall.SIGQUIT,
+	"TERM": syscall.SIGTERM,
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// sendSignal terminates the process pid for TERM and INT.
+func sendSignal(pid int, sig syscall.Signal) error {
+	if sig != syscall.SIGTERM && sig != syscall.SIGINT {
+		return fmt.Errorf("signal %s is not supported on windows", sig)
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
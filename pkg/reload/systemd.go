@@ -0,0 +1,411 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - systemd Reload Action
+//
+// Reloads or restarts a systemd unit by calling the systemd manager over
+// the D-Bus system bus. Only the small part of the D-Bus wire protocol
+// needed for one method call with string arguments is implemented: EXTERNAL
+// authentication, Hello, and the call itself. systemd queues the job and
+// replies right away; the action does not wait for the job to finish.
+// -------------------------------------------------------------------------------
+
+package reload
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// D-Bus message types.
+const (
+	dbusMethodCall   = 1
+	dbusMethodReturn = 2
+	dbusError        = 3
+)
+
+// D-Bus header field codes.
+const (
+	dbusFieldPath        = 1
+	dbusFieldInterface   = 2
+	dbusFieldMember      = 3
+	dbusFieldErrorName   = 4
+	dbusFieldReplySerial = 5
+	dbusFieldDestination = 6
+	dbusFieldSignature   = 8
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// SystemBusSocket is the D-Bus system bus socket.
+var SystemBusSocket = "/run/dbus/system_bus_socket"
+
+// systemdMethods maps systemd_mode to the systemd manager method.
+var systemdMethods = map[string]string{
+	"reload":            "ReloadUnit",
+	"restart":           "RestartUnit",
+	"reload-or-restart": "ReloadOrRestartUnit",
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// dbusMessage is a D-Bus message whose body holds only strings.
+type dbusMessage struct {
+	Type        byte
+	Serial      uint32
+	Path        string
+	Interface   string
+	Member      string
+	ErrorName   string
+	Destination string
+	ReplySerial uint32
+	Args        []string
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// systemdUnit reloads or restarts unit as mode says.
+func systemdUnit(ctx context.Context, unit, mode string) error {
+	method, ok := systemdMethods[mode]
+	if !ok {
+		return fmt.Errorf("unsupported systemd_mode %s", mode)
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", SystemBusSocket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the system bus: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	r := bufio.NewReader(conn)
+	if err := dbusAuth(conn, r); err != nil {
+		return err
+	}
+	if _, err := dbusCall(conn, r, &dbusMessage{
+		Serial:      1,
+		Path:        "/org/freedesktop/DBus",
+		Interface:   "org.freedesktop.DBus",
+		Member:      "Hello",
+		Destination: "org.freedesktop.DBus",
+	}); err != nil {
+		return err
+	}
+	if _, err := dbusCall(conn, r, &dbusMessage{
+		Serial:      2,
+		Path:        "/org/freedesktop/systemd1",
+		Interface:   "org.freedesktop.systemd1.Manager",
+		Member:      method,
+		Destination: "org.freedesktop.systemd1",
+		Args:        []string{unit, "replace"},
+	}); err != nil {
+		return fmt.Errorf("systemd %s %s: %w", mode, unit, err)
+	}
+	return nil
+}
+
+// dbusAuth authenticates as the process's user with EXTERNAL.
+func dbusAuth(w io.Writer, r *bufio.Reader) error {
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := fmt.Fprintf(w, "\x00AUTH EXTERNAL %s\r\n", uid); err != nil {
+		return fmt.Errorf("failed to authenticate to the system bus: %w", err)
+	}
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to authenticate to the system bus: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("system bus rejected authentication: %s", strings.TrimSpace(line))
+	}
+	if _, err := io.WriteString(w, "BEGIN\r\n"); err != nil {
+		return fmt.Errorf("failed to authenticate to the system bus: %w", err)
+	}
+	return nil
+}
+
+// dbusCall sends a method call and waits for its reply, skipping signals
+// and other messages in between. A D-Bus error reply is returned as an
+// error.
+func dbusCall(w io.Writer, r *bufio.Reader, call *dbusMessage) (*dbusMessage, error) {
+	call.Type = dbusMethodCall
+	if _, err := w.Write(call.encode()); err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", call.Member, err)
+	}
+	for {
+		reply, err := readDBusMessage(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reply to %s: %w", call.Member, err)
+		}
+		if reply.ReplySerial != call.Serial {
+			continue
+		}
+		switch reply.Type {
+		case dbusMethodReturn:
+			return reply, nil
+		case dbusError:
+			detail := ""
+			if len(reply.Args) > 0 {
+				detail = ": " + reply.Args[0]
+			}
+			return nil, fmt.Errorf("%s%s", reply.ErrorName, detail)
+		}
+	}
+}
+
+// encode marshals m in little-endian byte order.
+func (m *dbusMessage) encode() []byte {
+	body := &dbusWriter{}
+	for _, arg := range m.Args {
+		body.string(arg)
+	}
+
+	msg := &dbusWriter{}
+	msg.buf.Write([]byte{'l', m.Type, 0, 1})
+	msg.uint32(uint32(body.buf.Len()))
+	msg.uint32(m.Serial)
+
+	fields := &dbusWriter{}
+	field := func(code byte, sig, value string) {
+		if value == "" {
+			return
+		}
+		fields.align(8)
+		fields.buf.WriteByte(code)
+		fields.signature(sig)
+		if sig == "g" {
+			fields.signature(value)
+		} else {
+			fields.string(value)
+		}
+	}
+	field(dbusFieldPath, "o", m.Path)
+	field(dbusFieldInterface, "s", m.Interface)
+	field(dbusFieldMember, "s", m.Member)
+	field(dbusFieldErrorName, "s", m.ErrorName)
+	field(dbusFieldDestination, "s", m.Destination)
+	if m.ReplySerial != 0 {
+		fields.align(8)
+		fields.buf.WriteByte(dbusFieldReplySerial)
+		fields.signature("u")
+		fields.uint32(m.ReplySerial)
+	}
+	if len(m.Args) > 0 {
+		field(dbusFieldSignature, "g", strings.Repeat("s", len(m.Args)))
+	}
+
+	// Fields start at offset 16, which is 8-aligned, so their alignment
+	// within the message matches that within fields.
+	msg.uint32(uint32(fields.buf.Len()))
+	msg.buf.Write(fields.buf.Bytes())
+	msg.align(8)
+	msg.buf.Write(body.buf.Bytes())
+	return msg.buf.Bytes()
+}
+
+// readDBusMessage reads one message with string, object path, signature,
+// and uint32 header fields and a body of strings.
+func readDBusMessage(r io.Reader) (*dbusMessage, error) {
+	fixed := make([]byte, 16)
+	if _, err := io.ReadFull(r, fixed); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch fixed[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("invalid D-Bus endianness %q", fixed[0])
+	}
+	bodyLen := order.Uint32(fixed[4:8])
+	fieldsLen := order.Uint32(fixed[12:16])
+	if bodyLen > 1<<20 || fieldsLen > 1<<16 {
+		return nil, fmt.Errorf("D-Bus message too large")
+	}
+
+	padded := (16 + fieldsLen + 7) &^ 7
+	rest := make([]byte, padded-16+bodyLen)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, err
+	}
+
+	m := &dbusMessage{Type: fixed[1], Serial: order.Uint32(fixed[8:12])}
+	fields := &dbusReader{data: append(fixed, rest[:fieldsLen]...), pos: 16, order: order}
+	signature := ""
+	for fields.pos < len(fields.data) {
+		fields.align(8)
+		code, err := fields.byte()
+		if err != nil {
+			return nil, err
+		}
+		sig, err := fields.signature()
+		if err != nil {
+			return nil, err
+		}
+		var text string
+		var number uint32
+		switch sig {
+		case "s", "o":
+			text, err = fields.string()
+		case "g":
+			text, err = fields.signature()
+		case "u":
+			number, err = fields.uint32()
+		default:
+			return nil, fmt.Errorf("unsupported D-Bus header field type %q", sig)
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch code {
+		case dbusFieldPath:
+			m.Path = text
+		case dbusFieldInterface:
+			m.Interface = text
+		case dbusFieldMember:
+			m.Member = text
+		case dbusFieldErrorName:
+			m.ErrorName = text
+		case dbusFieldDestination:
+			m.Destination = text
+		case dbusFieldReplySerial:
+			m.ReplySerial = number
+		case dbusFieldSignature:
+			signature = text
+		}
+	}
+
+	// Only string arguments are decoded; a reply carrying other types,
+	// such as systemd's job path, is read but its body ignored.
+	if strings.Trim(signature, "s") == "" {
+		body := &dbusReader{data: rest[padded-16:], order: order}
+		for range signature {
+			arg, err := body.string()
+			if err != nil {
+				return nil, err
+			}
+			m.Args = append(m.Args, arg)
+		}
+	}
+	return m, nil
+}
+
+// -------------------------------------------------------------------------
+// WIRE FORMAT
+// -------------------------------------------------------------------------
+
+// dbusWriter marshals D-Bus values, aligned from the start of the buffer.
+type dbusWriter struct {
+	buf bytes.Buffer
+}
+
+// align pads with zeros to a multiple of n.
+func (w *dbusWriter) align(n int) {
+	for w.buf.Len()%n != 0 {
+		w.buf.WriteByte(0)
+	}
+}
+
+// uint32 writes an aligned little-endian uint32.
+func (w *dbusWriter) uint32(v uint32) {
+	w.align(4)
+	w.buf.Write(binary.LittleEndian.AppendUint32(nil, v))
+}
+
+// string writes a length-prefixed, nul-terminated string.
+func (w *dbusWriter) string(s string) {
+	w.uint32(uint32(len(s)))
+	w.buf.WriteString(s)
+	w.buf.WriteByte(0)
+}
+
+// signature writes a signature, whose length is a single byte.
+func (w *dbusWriter) signature(s string) {
+	w.buf.WriteByte(byte(len(s)))
+	w.buf.WriteString(s)
+	w.buf.WriteByte(0)
+}
+
+// dbusReader unmarshals D-Bus values, aligned from the start of data.
+type dbusReader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+// align skips padding to a multiple of n.
+func (r *dbusReader) align(n int) {
+	r.pos = (r.pos + n - 1) / n * n
+}
+
+// byte reads one byte.
+func (r *dbusReader) byte() (byte, error) {
+	if r.pos >= len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos++
+	return r.data[r.pos-1], nil
+}
+
+// uint32 reads an aligned uint32.
+func (r *dbusReader) uint32() (uint32, error) {
+	r.align(4)
+	if r.pos+4 > len(r.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	r.pos += 4
+	return r.order.Uint32(r.data[r.pos-4:]), nil
+}
+
+// string reads a string or object path.
+func (r *dbusReader) string() (string, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return "", err
+	}
+	return r.text(int(n))
+}
+
+// signature reads a signature.
+func (r *dbusReader) signature() (string, error) {
+	n, err := r.byte()
+	if err != nil {
+		return "", err
+	}
+	return r.text(int(n))
+}
+
+// text reads n bytes followed by a nul terminator.
+func (r *dbusReader) text(n int) (string, error) {
+	if n < 0 || r.pos+n+1 > len(r.data) {
+		return "", io.ErrUnexpectedEOF
+	}
+	s := string(r.data[r.pos : r.pos+n])
+	r.pos += n + 1
+	return s, nil
+}
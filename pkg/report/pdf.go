@@ -0,0 +1,305 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Report PDF Rendering
+//
+// Renders reports as PDF without external dependencies. Pages are A4
+// landscape, set in the standard Helvetica fonts every PDF reader provides,
+// with the same inventory and rotation history tables as the HTML report.
+// -------------------------------------------------------------------------------
+
+package report
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	pdfPageWidth  = 842.0
+	pdfPageHeight = 595.0
+	pdfMargin     = 36.0
+	pdfFontSize   = 8.0
+	pdfLineHeight = 11.0
+
+	// pdfCharWidth approximates the average Helvetica glyph width as a
+	// fraction of the font size, used to fit text into columns.
+	pdfCharWidth = 0.5
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// pdfColumn is a table column and its width in points.
+type pdfColumn struct {
+	title string
+	width float64
+}
+
+// pdfDocument accumulates page content streams.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64
+}
+
+var (
+	inventoryColumns = []pdfColumn{
+		{"Node", 80}, {"Name", 80}, {"Common Name", 100}, {"Source", 85}, {"Key", 55},
+		{"Expires", 95}, {"Posture", 65}, {"Last Renewed", 95}, {"Violations", 115},
+	}
+	historyColumns = []pdfColumn{
+		{"Time", 95}, {"Node", 80}, {"Name", 80}, {"Result", 50}, {"Correlation ID", 170}, {"Error", 295},
+	}
+)
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// RenderPDF writes the report as a PDF document.
+func (r *Report) RenderPDF(w io.Writer) error {
+	doc := &pdfDocument{}
+	doc.newPage()
+
+	doc.heading("Certificate Compliance Report", 16)
+	doc.line(fmt.Sprintf("Generated %s - Period %s to %s (%d days)",
+		formatTime(r.GeneratedAt), formatTime(r.PeriodStart), formatTime(r.GeneratedAt), int(r.Period.Hours()/24)))
+	doc.line(fmt.Sprintf("Certificates: %d    Policy violations: %d", len(r.Entries), r.Violations))
+
+	var inventory, history [][]string
+	for _, e := range r.Entries {
+		source := "-"
+		if e.Source != "" {
+			source = e.Source
+		}
+		if e.Role != "" {
+			source += " (role " + e.Role + ")"
+		}
+		if e.Issuer != "" {
+			source += "\nissued by " + e.Issuer
+		}
+		key := "-"
+		if e.KeyAlgorithm != "" {
+			key = fmt.Sprintf("%s %d", e.KeyAlgorithm, e.KeyBits)
+		}
+		posture := e.Status
+		if !e.NotAfter.IsZero() {
+			posture += fmt.Sprintf(" (%dd)", e.DaysLeft)
+		}
+		violations := "none"
+		if len(e.Violations) > 0 {
+			violations = strings.Join(e.Violations, "\n")
+		}
+		inventory = append(inventory, []string{
+			e.Node, e.Name, e.CommonName, source, key,
+			formatTime(e.NotAfter), posture, formatTime(e.LastRenewed), violations,
+		})
+
+		for _, rot := range e.Rotations {
+			result := "success"
+			if !rot.Success {
+				result = "failed"
+			}
+			history = append(history, []string{formatTime(rot.Time), e.Node, e.Name, result, rot.CorrelationID, rot.Error})
+		}
+	}
+
+	doc.heading("Inventory", 12)
+	if len(inventory) == 0 {
+		doc.line("No certificates found.")
+	} else {
+		doc.table(inventoryColumns, inventory)
+	}
+
+	doc.heading("Rotation History", 12)
+	if len(history) == 0 {
+		doc.line("No rotations in period.")
+	} else {
+		doc.table(historyColumns, history)
+	}
+
+	_, err := w.Write(doc.bytes("Certificate Compliance Report - " + formatTime(r.GeneratedAt)))
+	return err
+}
+
+// newPage starts a new page at the top margin.
+func (d *pdfDocument) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// reserve starts a new page unless height points fit above the bottom margin.
+func (d *pdfDocument) reserve(height float64) {
+	if d.y-height < pdfMargin {
+		d.newPage()
+	}
+}
+
+// text places s with its baseline at x and the current position.
+func (d *pdfDocument) text(x float64, font string, size float64, s string) {
+	_, _ = fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, pdfEscape(s))
+}
+
+// heading writes a bold heading with space around it.
+func (d *pdfDocument) heading(s string, size float64) {
+	d.reserve(size + 2*pdfLineHeight)
+	d.y -= size + 6
+	d.text(pdfMargin, "F2", size, s)
+	d.y -= 6
+}
+
+// line writes a line of body text.
+func (d *pdfDocument) line(s string) {
+	d.reserve(pdfLineHeight)
+	d.y -= pdfLineHeight
+	d.text(pdfMargin, "F1", pdfFontSize, s)
+}
+
+// table writes rows under a bold header, wrapping cells to their column
+// and repeating the header on every page the table spans.
+func (d *pdfDocument) table(columns []pdfColumn, rows [][]string) {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.title
+	}
+	d.reserve(3 * pdfLineHeight)
+	d.row(columns, header, "F2")
+
+	for _, row := range rows {
+		lines := 1
+		for i, cell := range row {
+			lines = max(lines, len(pdfWrap(cell, columns[i].width)))
+		}
+		if d.y-float64(lines)*pdfLineHeight < pdfMargin {
+			d.newPage()
+			d.row(columns, header, "F2")
+		}
+		d.row(columns, row, "F1")
+	}
+}
+
+// row writes one table row and the rule beneath it.
+func (d *pdfDocument) row(columns []pdfColumn, cells []string, font string) {
+	top := d.y
+	bottom := d.y
+	x := pdfMargin
+	for i, cell := range cells {
+		d.y = top
+		for _, l := range pdfWrap(cell, columns[i].width) {
+			d.y -= pdfLineHeight
+			d.text(x, font, pdfFontSize, l)
+		}
+		bottom = min(bottom, d.y)
+		x += columns[i].width
+	}
+	d.y = bottom - 3
+	_, _ = fmt.Fprintf(d.page, "0.8 0.82 0.85 RG 0.5 w %.2f %.2f m %.2f %.2f l S\n",
+		pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+}
+
+// bytes assembles the document with a page number footer on every page.
+func (d *pdfDocument) bytes(title string) []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(format string, args ...interface{}) {
+		offsets = append(offsets, out.Len())
+		_, _ = fmt.Fprintf(&out, "%d 0 obj\n", len(offsets))
+		_, _ = fmt.Fprintf(&out, format, args...)
+		out.WriteString("\nendobj\n")
+	}
+
+	// Objects 1-5 are the catalog, page tree, fonts, and info; each page
+	// is followed by its content stream.
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 6+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object("<< /Title (%s) /Producer (vault-cert-manager) >>", pdfEscape(title))
+	for i, page := range d.pages {
+		d.page = page
+		d.y = pdfMargin / 2
+		d.text(pdfPageWidth-pdfMargin-60, "F1", pdfFontSize, fmt.Sprintf("Page %d of %d", i+1, len(d.pages)))
+
+		object("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 7+2*i)
+		object("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.Bytes())
+	}
+
+	xref := out.Len()
+	_, _ = fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		_, _ = fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	_, _ = fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE FUNCTIONS
+// -------------------------------------------------------------------------
+
+// pdfWrap splits s into lines fitting width points, breaking at spaces
+// where possible and at explicit newlines.
+func pdfWrap(s string, width float64) []string {
+	limit := max(int(width/(pdfFontSize*pdfCharWidth))-1, 1)
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			for len(word) > limit {
+				if current != "" {
+					lines = append(lines, current)
+					current = ""
+				}
+				lines = append(lines, word[:limit])
+				word = word[limit:]
+			}
+			switch {
+			case current == "":
+				current = word
+			case len(current)+1+len(word) <= limit:
+				current += " " + word
+			default:
+				lines = append(lines, current)
+				current = word
+			}
+		}
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// pdfEscape makes s safe inside a PDF string literal. Characters outside
+// printable ASCII are replaced, since the fonts use a single-byte encoding.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
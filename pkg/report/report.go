@@ -0,0 +1,211 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Compliance Report
+//
+// Builds compliance reports covering every managed certificate: issuance
+// source, key strength, expiry posture, policy violations, and rotation
+// history over a reporting period. Reports render to self-contained HTML
+// or PDF and can be signed with a PEM private key
+// so auditors can verify they were not altered after generation.
+// -------------------------------------------------------------------------------
+
+// Package report provides compliance report generation and signing.
+package report
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	// DefaultPeriod is the reporting window used when none is specified.
+	DefaultPeriod = 90 * 24 * time.Hour
+
+	// criticalDays is the remaining lifetime below which a certificate is flagged.
+	criticalDays = 7
+
+	minRSABits   = 2048
+	minECDSABits = 256
+
+	// FormatHTML and FormatPDF are the formats accepted by Render.
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Rotation records a single issuance attempt for a certificate.
+type Rotation struct {
+	Time          time.Time
+	Success       bool
+	Error         string
+	CorrelationID string
+}
+
+// Entry describes one certificate in the report.
+type Entry struct {
+	Node         string
+	Name         string
+	CommonName   string
+	Source       string // backend that issued it: pki, kv, acme, or ca_bundle
+	Role         string
+	Issuer       string
+	KeyAlgorithm string
+	KeyBits      int
+	NotAfter     time.Time
+	DaysLeft     int
+	Status       string
+	OutOfSync    bool
+	LastRenewed  time.Time
+	Rotations    []Rotation
+	Violations   []string
+}
+
+// Report is a point-in-time compliance report.
+type Report struct {
+	GeneratedAt time.Time
+	PeriodStart time.Time
+	Period      time.Duration
+	Entries     []Entry
+	Violations  int
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC FUNCTIONS
+// -------------------------------------------------------------------------
+
+// New builds a report from the given entries, evaluating policy violations
+// and filtering rotation history to the reporting period.
+func New(entries []Entry, period time.Duration, now time.Time) *Report {
+	if period <= 0 {
+		period = DefaultPeriod
+	}
+
+	r := &Report{
+		GeneratedAt: now,
+		PeriodStart: now.Add(-period),
+		Period:      period,
+	}
+
+	for _, entry := range entries {
+		var rotations []Rotation
+		for _, rot := range entry.Rotations {
+			if !rot.Time.Before(r.PeriodStart) {
+				rotations = append(rotations, rot)
+			}
+		}
+		entry.Rotations = rotations
+		entry.Violations = evaluate(entry, now)
+		r.Violations += len(entry.Violations)
+		r.Entries = append(r.Entries, entry)
+	}
+
+	sort.Slice(r.Entries, func(i, j int) bool {
+		if r.Entries[i].Node != r.Entries[j].Node {
+			return r.Entries[i].Node < r.Entries[j].Node
+		}
+		return r.Entries[i].Name < r.Entries[j].Name
+	})
+
+	return r
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Render writes the report in the given format, FormatHTML or FormatPDF.
+func (r *Report) Render(w io.Writer, format string) error {
+	switch format {
+	case FormatHTML:
+		return r.RenderHTML(w)
+	case FormatPDF:
+		return r.RenderPDF(w)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// RenderHTML writes the report as a self-contained HTML document.
+func (r *Report) RenderHTML(w io.Writer) error {
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"formatTime": formatTime,
+		"days": func(d time.Duration) int {
+			return int(d.Hours() / 24)
+		},
+	}).ParseFS(templateFS, "templates/*.html")
+	if err != nil {
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	return tmpl.ExecuteTemplate(w, "report.html", r)
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE FUNCTIONS
+// -------------------------------------------------------------------------
+
+// formatTime formats a report timestamp in UTC.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "Never"
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// evaluate returns the policy violations for a single entry.
+func evaluate(entry Entry, now time.Time) []string {
+	var violations []string
+
+	switch {
+	case entry.NotAfter.IsZero():
+		violations = append(violations, "no certificate deployed")
+	case !now.Before(entry.NotAfter):
+		violations = append(violations, "certificate expired")
+	case entry.DaysLeft <= criticalDays:
+		violations = append(violations, fmt.Sprintf("expires within %d days", criticalDays))
+	}
+
+	switch entry.KeyAlgorithm {
+	case "RSA":
+		if entry.KeyBits < minRSABits {
+			violations = append(violations, fmt.Sprintf("RSA key size %d below minimum %d", entry.KeyBits, minRSABits))
+		}
+	case "ECDSA":
+		if entry.KeyBits < minECDSABits {
+			violations = append(violations, fmt.Sprintf("ECDSA key size %d below minimum %d", entry.KeyBits, minECDSABits))
+		}
+	}
+
+	if entry.OutOfSync {
+		violations = append(violations, "served certificate does not match disk")
+	}
+
+	failures := 0
+	for _, rot := range entry.Rotations {
+		if !rot.Success {
+			failures++
+		}
+	}
+	if failures > 0 {
+		violations = append(violations, fmt.Sprintf("%d failed rotation(s) in period", failures))
+	}
+
+	return violations
+}
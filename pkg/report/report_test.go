@@ -0,0 +1,195 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Compliance Report Tests
+//
+// Unit tests for report building, policy evaluation, rendering, and signing.
+// -------------------------------------------------------------------------------
+
+package report
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestNew_Violations verifies policy evaluation and history filtering.
+func TestNew_Violations(t *testing.T) {
+	now := time.Now()
+
+	entries := []Entry{
+		{
+			Name:         "weak",
+			KeyAlgorithm: "RSA",
+			KeyBits:      1024,
+			NotAfter:     now.Add(60 * 24 * time.Hour),
+			DaysLeft:     60,
+			Rotations: []Rotation{
+				{Time: now.Add(-200 * 24 * time.Hour), Success: false},
+				{Time: now.Add(-time.Hour), Success: false, Error: "vault error"},
+			},
+		},
+		{
+			Name:         "good",
+			KeyAlgorithm: "ECDSA",
+			KeyBits:      384,
+			NotAfter:     now.Add(60 * 24 * time.Hour),
+			DaysLeft:     60,
+		},
+		{
+			Name:         "expired",
+			KeyAlgorithm: "ECDSA",
+			KeyBits:      256,
+			NotAfter:     now.Add(-time.Hour),
+		},
+	}
+
+	r := New(entries, 0, now)
+
+	if r.Period != DefaultPeriod {
+		t.Errorf("expected default period, got %v", r.Period)
+	}
+
+	if r.Entries[0].Name != "expired" || r.Entries[2].Name != "weak" {
+		t.Errorf("entries not sorted by name: %v, %v", r.Entries[0].Name, r.Entries[2].Name)
+	}
+
+	weak := r.Entries[2]
+	if len(weak.Rotations) != 1 {
+		t.Errorf("expected rotations outside the period to be dropped, got %d", len(weak.Rotations))
+	}
+	if len(weak.Violations) != 2 {
+		t.Errorf("expected key size and failed rotation violations, got %v", weak.Violations)
+	}
+
+	if len(r.Entries[1].Violations) != 0 {
+		t.Errorf("expected no violations for good cert, got %v", r.Entries[1].Violations)
+	}
+
+	if len(r.Entries[0].Violations) != 1 || r.Entries[0].Violations[0] != "certificate expired" {
+		t.Errorf("expected expired violation, got %v", r.Entries[0].Violations)
+	}
+
+	if r.Violations != 3 {
+		t.Errorf("expected 3 total violations, got %d", r.Violations)
+	}
+}
+
+// TestReport_RenderHTML verifies the report renders certificate details.
+func TestReport_RenderHTML(t *testing.T) {
+	r := New([]Entry{{Node: "node1", Name: "web-cert", CommonName: "www.example.com", Source: "acme", Issuer: "R3"}}, time.Hour, time.Now())
+
+	var buf bytes.Buffer
+	if err := r.RenderHTML(&buf); err != nil {
+		t.Fatalf("failed to render report: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"web-cert", "www.example.com", "node1", "acme", "issued by R3", "no certificate deployed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered report missing %q", want)
+		}
+	}
+}
+
+// TestReport_RenderPDF verifies the PDF carries certificate details and
+// rotation history, spans pages, and has a valid cross-reference table.
+func TestReport_RenderPDF(t *testing.T) {
+	now := time.Now()
+	var entries []Entry
+	for i := 0; i < 60; i++ {
+		entries = append(entries, Entry{
+			Node:       "node1",
+			Name:       fmt.Sprintf("cert-%02d", i),
+			CommonName: "www.example.com",
+			Source:     "kv",
+			Rotations:  []Rotation{{Time: now.Add(-time.Minute), Error: "vault (sealed)", CorrelationID: "corr-1"}},
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := New(entries, time.Hour, now).RenderPDF(&buf); err != nil {
+		t.Fatalf("failed to render report: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "%PDF-1.4") || !strings.HasSuffix(out, "%%EOF\n") {
+		t.Fatal("expected a PDF header and trailer")
+	}
+	for _, want := range []string{"(cert-59)", "(www.example.com)", "(kv)", "(no certificate deployed)", "(vault \\(sealed\\))", "(corr-1)", "Page 2 of"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("rendered report missing %q", want)
+		}
+	}
+
+	xref, err := strconv.Atoi(strings.Fields(out[strings.LastIndex(out, "startxref"):])[1])
+	if err != nil {
+		t.Fatalf("failed to read startxref: %v", err)
+	}
+	lines := strings.Split(out[xref:], "\n")
+	var count int
+	if _, err := fmt.Sscanf(lines[1], "0 %d", &count); err != nil {
+		t.Fatalf("failed to read xref size: %v", err)
+	}
+	for i := 1; i < count; i++ {
+		var offset int
+		if _, err := fmt.Sscanf(lines[2+i], "%d", &offset); err != nil {
+			t.Fatalf("failed to read xref entry %d: %v", i, err)
+		}
+		if !strings.HasPrefix(out[offset:], fmt.Sprintf("%d 0 obj", i)) {
+			t.Errorf("xref entry %d does not point at its object", i)
+		}
+	}
+}
+
+// TestSign verifies signatures produced from a PEM key can be verified.
+func TestSign(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "signing.key")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	signer, err := LoadSigner(keyFile)
+	if err != nil {
+		t.Fatalf("failed to load signer: %v", err)
+	}
+
+	content := []byte("<html>report</html>")
+	sig, err := Sign(signer, content)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	digest := sha256.Sum256(content)
+	if !ecdsa.VerifyASN1(&key.PublicKey, digest[:], sig) {
+		t.Error("signature did not verify")
+	}
+}
@@ -0,0 +1,67 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Report Signing
+//
+// Detached signatures for generated reports. Loads a PEM-encoded RSA, ECDSA,
+// or Ed25519 private key and signs the SHA-256 digest of the report so the
+// output can be verified with standard tooling (openssl dgst -verify).
+// -------------------------------------------------------------------------------
+
+package report
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// -------------------------------------------------------------------------
+// PUBLIC FUNCTIONS
+// -------------------------------------------------------------------------
+
+// LoadSigner reads a PEM-encoded private key usable for report signing.
+func LoadSigner(keyFile string) (crypto.Signer, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key %s: %w", keyFile, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode PEM signing key %s", keyFile)
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported signing key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unsupported signing key format in %s", keyFile)
+}
+
+// Sign returns a detached signature over the given report content.
+func Sign(signer crypto.Signer, content []byte) ([]byte, error) {
+	if _, ok := signer.(ed25519.PrivateKey); ok {
+		return signer.Sign(rand.Reader, content, crypto.Hash(0))
+	}
+
+	digest := sha256.Sum256(content)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
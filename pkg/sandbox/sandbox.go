@@ -0,0 +1,174 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Hook Sandbox
+//
+// Builds restricted commands for on_change hooks: a minimal environment, an
+// empty network namespace, and Landlock filesystem rules plus a seccomp
+// syscall denylist. Landlock and seccomp must be applied in the hook process
+// itself, so the binary re-executes itself as a small helper that restricts
+// its own thread and then execs the shell.
+// -------------------------------------------------------------------------------
+
+// Package sandbox runs hook commands with a limited blast radius.
+package sandbox
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// helperEnv carries the helper spec to the re-executed binary.
+const helperEnv = "VAULT_CERT_MANAGER_SANDBOX"
+
+// defaultPath is the PATH given to hooks run with a minimal environment.
+const defaultPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// helperSpec tells the helper which restrictions to apply before exec.
+type helperSpec struct {
+	Shell    string                 `json:"shell"`
+	Script   string                 `json:"script"`
+	Seccomp  bool                   `json:"seccomp,omitempty"`
+	Landlock *config.LandlockConfig `json:"landlock,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC FUNCTIONS
+// -------------------------------------------------------------------------
+
+// Command returns a command running script with sh, restricted by cfg. A
+// nil cfg runs the script unrestricted with the daemon's environment.
+func Command(script string, cfg *config.SandboxConfig) (*exec.Cmd, error) {
+	return CommandContext(context.Background(), script, cfg)
+}
+
+// CommandContext is like Command, but the command is killed when ctx is
+// done, as with exec.CommandContext.
+func CommandContext(ctx context.Context, script string, cfg *config.SandboxConfig) (*exec.Cmd, error) {
+	if cfg == nil {
+		return exec.CommandContext(ctx, "sh", "-c", script), nil
+	}
+
+	env := os.Environ()
+	if cfg.MinimalEnv {
+		env = minimalEnv(cfg.Env)
+	}
+
+	attr, err := sysProcAttr(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Seccomp && cfg.Landlock == nil {
+		cmd := exec.CommandContext(ctx, "sh", "-c", script)
+		cmd.Env = env
+		cmd.SysProcAttr = attr
+		return cmd, nil
+	}
+
+	if runtime.GOOS != "linux" {
+		return nil, fmt.Errorf("landlock and seccomp sandboxing require linux")
+	}
+
+	shell, err := exec.LookPath("sh")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find sh: %w", err)
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find own executable: %w", err)
+	}
+
+	spec, err := json.Marshal(helperSpec{
+		Shell:    shell,
+		Script:   script,
+		Seccomp:  cfg.Seccomp,
+		Landlock: cfg.Landlock,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sandbox spec: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, self)
+	cmd.Env = append(env, helperEnv+"="+string(spec))
+	cmd.SysProcAttr = attr
+	return cmd, nil
+}
+
+// RunHelper applies the restrictions requested by Command and replaces the
+// process with the hook shell. It returns immediately unless the process
+// was started as a sandbox helper, so call it first thing in main.
+func RunHelper() {
+	raw, ok := os.LookupEnv(helperEnv)
+	if !ok {
+		return
+	}
+
+	if err := runHelper(raw); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// runHelper restricts the current thread and execs the hook on it, so the
+// restrictions carry over to the new program.
+func runHelper(raw string) error {
+	var spec helperSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		return fmt.Errorf("invalid sandbox spec: %w", err)
+	}
+
+	runtime.LockOSThread()
+
+	if err := restrictSelf(&spec); err != nil {
+		return err
+	}
+
+	env := make([]string, 0, len(os.Environ()))
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, helperEnv+"=") {
+			env = append(env, kv)
+		}
+	}
+
+	if err := syscall.Exec(spec.Shell, []string{"sh", "-c", spec.Script}, env); err != nil {
+		return fmt.Errorf("failed to exec %s: %w", spec.Shell, err)
+	}
+	return nil
+}
+
+// minimalEnv returns PATH plus the named variables from the environment.
+func minimalEnv(keep []string) []string {
+	env := []string{"PATH=" + defaultPath}
+	for _, name := range keep {
+		if name == "PATH" {
+			env[0] = "PATH=" + os.Getenv("PATH")
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
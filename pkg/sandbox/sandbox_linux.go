@@ -0,0 +1,294 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Hook Sandbox (Linux)
+//
+// Linux restrictions for hook commands: network namespaces via clone flags,
+// Landlock filesystem rulesets, and a seccomp-bpf filter returning EPERM for
+// privileged syscalls and namespace creation.
+// -------------------------------------------------------------------------------
+
+//go:build linux
+
+package sandbox
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// Landlock ABI v1 access rights.
+const (
+	landlockFileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE
+	landlockReadAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR
+	landlockAllAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+)
+
+// cloneNamespaceFlags are the clone flags that create namespaces, refused
+// under the seccomp profile like unshare.
+const cloneNamespaceFlags = unix.CLONE_NEWNS | unix.CLONE_NEWCGROUP | unix.CLONE_NEWUTS |
+	unix.CLONE_NEWIPC | unix.CLONE_NEWUSER | unix.CLONE_NEWPID | unix.CLONE_NEWNET
+
+// x32SyscallBit marks x32 ABI syscall numbers on amd64.
+const x32SyscallBit = 0x40000000
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// deniedSyscalls are refused with EPERM under the seccomp profile.
+var deniedSyscalls = []uintptr{
+	unix.SYS_PTRACE,
+	unix.SYS_MOUNT,
+	unix.SYS_UMOUNT2,
+	unix.SYS_PIVOT_ROOT,
+	unix.SYS_SETNS,
+	unix.SYS_UNSHARE,
+	unix.SYS_REBOOT,
+	unix.SYS_KEXEC_LOAD,
+	unix.SYS_INIT_MODULE,
+	unix.SYS_FINIT_MODULE,
+	unix.SYS_DELETE_MODULE,
+	unix.SYS_BPF,
+	unix.SYS_PERF_EVENT_OPEN,
+	unix.SYS_SWAPON,
+	unix.SYS_SWAPOFF,
+}
+
+// auditArches maps GOARCH to the seccomp audit architecture.
+var auditArches = map[string]uint32{
+	"amd64":   unix.AUDIT_ARCH_X86_64,
+	"arm64":   unix.AUDIT_ARCH_AARCH64,
+	"386":     unix.AUDIT_ARCH_I386,
+	"riscv64": unix.AUDIT_ARCH_RISCV64,
+	"ppc64le": unix.AUDIT_ARCH_PPC64LE,
+	"s390x":   unix.AUDIT_ARCH_S390X,
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// sysProcAttr returns process attributes for the hook, placing it in a new
+// network namespace when no_network is set. Non-root daemons also get a
+// user namespace so the network namespace can be created unprivileged.
+func sysProcAttr(cfg *config.SandboxConfig) (*syscall.SysProcAttr, error) {
+	if !cfg.NoNetwork {
+		return nil, nil
+	}
+
+	if os.Geteuid() == 0 {
+		return &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNET}, nil
+	}
+
+	return &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWNET | syscall.CLONE_NEWUSER,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Geteuid(), HostID: os.Geteuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getegid(), HostID: os.Getegid(), Size: 1}},
+	}, nil
+}
+
+// restrictSelf applies no_new_privs, Landlock, and seccomp to the calling
+// thread, which must be locked.
+func restrictSelf(spec *helperSpec) error {
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+
+	if spec.Landlock != nil {
+		if err := applyLandlock(spec.Landlock); err != nil {
+			return err
+		}
+	}
+
+	if spec.Seccomp {
+		if err := applySeccomp(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyLandlock restricts filesystem access to the configured paths.
+func applyLandlock(cfg *config.LandlockConfig) error {
+	attr := unix.LandlockRulesetAttr{Access_fs: landlockAllAccess}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("landlock is not available: %w", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	for _, path := range cfg.ReadOnly {
+		if err := addLandlockRule(ruleset, path, landlockReadAccess); err != nil {
+			return err
+		}
+	}
+	for _, path := range cfg.ReadWrite {
+		if err := addLandlockRule(ruleset, path, landlockAllAccess); err != nil {
+			return err
+		}
+	}
+
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockRule allows access beneath path. Rights that only apply to
+// directories are dropped for regular files. Missing paths are skipped.
+func addLandlockRule(ruleset int, path string, access uint64) error {
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		if err == unix.ENOENT {
+			return nil
+		}
+		return fmt.Errorf("failed to open landlock path %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		return fmt.Errorf("failed to stat landlock path %s: %w", path, err)
+	}
+	if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+		access &= landlockFileAccess
+	}
+
+	rule := unix.LandlockPathBeneathAttr{Allowed_access: access, Parent_fd: int32(fd)}
+	_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset),
+		unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to add landlock rule for %s: %w", path, errno)
+	}
+	return nil
+}
+
+// applySeccomp installs a filter that fails denied syscalls with EPERM and
+// kills the process on a foreign syscall ABI.
+func applySeccomp() error {
+	arch, ok := auditArches[runtime.GOARCH]
+	if !ok {
+		return fmt.Errorf("seccomp is not supported on %s", runtime.GOARCH)
+	}
+
+	filter := seccompFilter(arch)
+	prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %w", err)
+	}
+	return nil
+}
+
+// seccompFilter assembles the BPF program for the denylist. clone3 fails
+// with ENOSYS, since its flags live in memory the filter cannot read, so
+// libc and the Go runtime fall back to clone, whose flags are checked for
+// namespace creation. On amd64, x32 syscalls share the audit architecture
+// and are told apart by a bit in the syscall number, so they are killed
+// like any other foreign ABI.
+func seccompFilter(arch uint32) []unix.SockFilter {
+	const (
+		archOffset = 4  // offsetof(struct seccomp_data, arch)
+		nrOffset   = 0  // offsetof(struct seccomp_data, nr)
+		argsOffset = 16 // offsetof(struct seccomp_data, args)
+	)
+
+	// Jump targets, resolved once the return instructions are placed.
+	const (
+		next = iota
+		allow
+		deny
+		noSys
+		kill
+	)
+
+	type insn struct {
+		unix.SockFilter
+		jt, jf int
+	}
+	prog := []insn{
+		{SockFilter: unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: archOffset}},
+		{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: arch}, jf: kill},
+		{SockFilter: unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: nrOffset}},
+	}
+	if arch == unix.AUDIT_ARCH_X86_64 {
+		prog = append(prog, insn{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, K: x32SyscallBit}, jt: kill})
+	}
+	for _, nr := range deniedSyscalls {
+		prog = append(prog, insn{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: uint32(nr)}, jt: deny})
+	}
+	prog = append(prog,
+		insn{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: unix.SYS_CLONE3}, jt: noSys},
+		insn{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, K: unix.SYS_CLONE}, jf: allow},
+		insn{SockFilter: unix.SockFilter{Code: unix.BPF_LD | unix.BPF_W | unix.BPF_ABS, K: argsOffset + cloneFlagsOffset(arch)}},
+		insn{SockFilter: unix.SockFilter{Code: unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K, K: cloneNamespaceFlags}, jt: deny, jf: allow},
+	)
+
+	targets := map[int]int{}
+	for _, ret := range []struct {
+		label  int
+		action uint32
+	}{
+		{allow, unix.SECCOMP_RET_ALLOW},
+		{deny, unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)},
+		{noSys, unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)},
+		{kill, unix.SECCOMP_RET_KILL_PROCESS},
+	} {
+		targets[ret.label] = len(prog)
+		prog = append(prog, insn{SockFilter: unix.SockFilter{Code: unix.BPF_RET | unix.BPF_K, K: ret.action}})
+	}
+
+	filter := make([]unix.SockFilter, len(prog))
+	for i, in := range prog {
+		filter[i] = in.SockFilter
+		if in.jt != next {
+			filter[i].Jt = uint8(targets[in.jt] - i - 1)
+		}
+		if in.jf != next {
+			filter[i].Jf = uint8(targets[in.jf] - i - 1)
+		}
+	}
+	return filter
+}
+
+// cloneFlagsOffset returns the offset within seccomp_data.args of the low
+// 32 bits of clone's flags argument, which s390x passes second.
+func cloneFlagsOffset(arch uint32) uint32 {
+	switch arch {
+	case unix.AUDIT_ARCH_S390X:
+		return 8 + 4 // second argument, big-endian
+	default:
+		return 0
+	}
+}
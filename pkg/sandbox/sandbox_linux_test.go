@@ -0,0 +1,125 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Hook Sandbox Tests (Linux)
+//
+// Unit tests for the seccomp filter, run through a small interpreter for the
+// BPF instructions it uses.
+// -------------------------------------------------------------------------------
+
+//go:build linux
+
+package sandbox
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/binary"
+	"runtime"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestSeccompFilter verifies denied syscalls, clone3, namespace-creating
+// clone flags, and foreign ABIs are refused while ordinary calls pass.
+// Syscall numbers are the host's; s390x's argument order and byte order
+// are checked with the s390x program.
+func TestSeccompFilter(t *testing.T) {
+	host, ok := auditArches[runtime.GOARCH]
+	if !ok {
+		t.Skipf("seccomp is not supported on %s", runtime.GOARCH)
+	}
+	eperm := unix.SECCOMP_RET_ERRNO | uint32(unix.EPERM)
+	enosys := unix.SECCOMP_RET_ERRNO | uint32(unix.ENOSYS)
+	threadFlags := uint64(unix.CLONE_VM | unix.CLONE_FS | unix.CLONE_FILES | unix.CLONE_SIGHAND | unix.CLONE_THREAD)
+	foreign := uint32(unix.AUDIT_ARCH_I386)
+	if host == foreign {
+		foreign = unix.AUDIT_ARCH_X86_64
+	}
+	x32 := uint32(unix.SECCOMP_RET_ALLOW) // an unknown syscall elsewhere
+	if host == unix.AUDIT_ARCH_X86_64 {
+		x32 = unix.SECCOMP_RET_KILL_PROCESS
+	}
+
+	tests := []struct {
+		name string
+		arch uint32
+		nr   uint32
+		args [6]uint64
+		want uint32
+	}{
+		{"getpid", host, unix.SYS_GETPID, [6]uint64{}, unix.SECCOMP_RET_ALLOW},
+		{"ptrace", host, unix.SYS_PTRACE, [6]uint64{}, eperm},
+		{"thread clone", host, unix.SYS_CLONE, [6]uint64{threadFlags}, unix.SECCOMP_RET_ALLOW},
+		{"clone new user", host, unix.SYS_CLONE, [6]uint64{unix.CLONE_NEWUSER | uint64(unix.SIGCHLD)}, eperm},
+		{"clone new net", host, unix.SYS_CLONE, [6]uint64{unix.CLONE_NEWNET}, eperm},
+		{"clone3", host, unix.SYS_CLONE3, [6]uint64{}, enosys},
+		{"foreign arch", foreign, unix.SYS_GETPID, [6]uint64{}, unix.SECCOMP_RET_KILL_PROCESS},
+		{"s390x clone new pid", unix.AUDIT_ARCH_S390X, unix.SYS_CLONE, [6]uint64{0, unix.CLONE_NEWPID}, eperm},
+		{"s390x clone", unix.AUDIT_ARCH_S390X, unix.SYS_CLONE, [6]uint64{unix.CLONE_NEWPID, threadFlags}, unix.SECCOMP_RET_ALLOW},
+		{"x32 getpid", host, x32SyscallBit | unix.SYS_GETPID, [6]uint64{}, x32},
+	}
+
+	for _, tt := range tests {
+		filterArch, order := host, binary.ByteOrder(binary.LittleEndian)
+		if tt.arch == unix.AUDIT_ARCH_S390X {
+			filterArch, order = tt.arch, binary.BigEndian
+		}
+		if got := runFilter(t, seccompFilter(filterArch), order, seccompData(order, tt.nr, tt.arch, tt.args)); got != tt.want {
+			t.Errorf("%s: expected %#x, got %#x", tt.name, tt.want, got)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// seccompData encodes struct seccomp_data in the given byte order.
+func seccompData(order binary.ByteOrder, nr, arch uint32, args [6]uint64) []byte {
+	data := make([]byte, 64)
+	order.PutUint32(data[0:], nr)
+	order.PutUint32(data[4:], arch)
+	for i, arg := range args {
+		order.PutUint64(data[16+8*i:], arg)
+	}
+	return data
+}
+
+// runFilter interprets the BPF instructions seccompFilter emits, loading
+// words in the given byte order as the kernel does.
+func runFilter(t *testing.T, filter []unix.SockFilter, order binary.ByteOrder, data []byte) uint32 {
+	t.Helper()
+
+	var acc uint32
+	for pc := 0; pc < len(filter); pc++ {
+		in := filter[pc]
+		switch in.Code {
+		case unix.BPF_LD | unix.BPF_W | unix.BPF_ABS:
+			acc = order.Uint32(data[in.K:])
+		case unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K:
+			if acc == in.K {
+				pc += int(in.Jt)
+			} else {
+				pc += int(in.Jf)
+			}
+		case unix.BPF_JMP | unix.BPF_JSET | unix.BPF_K:
+			if acc&in.K != 0 {
+				pc += int(in.Jt)
+			} else {
+				pc += int(in.Jf)
+			}
+		case unix.BPF_RET | unix.BPF_K:
+			return in.K
+		default:
+			t.Fatalf("unexpected instruction %#x", in.Code)
+		}
+	}
+	t.Fatal("filter fell off the end")
+	return 0
+}
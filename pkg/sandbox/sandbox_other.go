@@ -0,0 +1,37 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Hook Sandbox (non-Linux)
+//
+// Network namespaces, Landlock, and seccomp are Linux-only; other platforms
+// refuse configurations that request them.
+// -------------------------------------------------------------------------------
+
+//go:build !linux
+
+package sandbox
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"fmt"
+	"syscall"
+)
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// sysProcAttr refuses no_network, which needs Linux network namespaces.
+func sysProcAttr(cfg *config.SandboxConfig) (*syscall.SysProcAttr, error) {
+	if cfg.NoNetwork {
+		return nil, fmt.Errorf("no_network sandboxing requires linux")
+	}
+	return nil, nil
+}
+
+// restrictSelf is never reached: Command refuses landlock and seccomp.
+func restrictSelf(spec *helperSpec) error {
+	return fmt.Errorf("landlock and seccomp sandboxing require linux")
+}
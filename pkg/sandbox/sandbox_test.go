@@ -0,0 +1,130 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Hook Sandbox Tests
+//
+// Unit tests for sandboxed hook commands. The test binary acts as the
+// sandbox helper when re-executed by Command.
+// -------------------------------------------------------------------------------
+
+package sandbox
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestMain runs the sandbox helper when the test binary is re-executed.
+func TestMain(m *testing.M) {
+	RunHelper()
+	os.Exit(m.Run())
+}
+
+// TestCommand_MinimalEnv verifies only PATH and allowlisted variables reach
+// the hook.
+func TestCommand_MinimalEnv(t *testing.T) {
+	t.Setenv("SANDBOX_KEEP", "kept")
+	t.Setenv("SANDBOX_SECRET", "leaked")
+
+	cmd, err := Command("env", &config.SandboxConfig{MinimalEnv: true, Env: []string{"SANDBOX_KEEP"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("hook failed: %v: %s", err, output)
+	}
+
+	env := string(output)
+	if !strings.Contains(env, "SANDBOX_KEEP=kept") || !strings.Contains(env, "PATH="+defaultPath) {
+		t.Errorf("expected PATH and SANDBOX_KEEP, got:\n%s", env)
+	}
+	if strings.Contains(env, "SANDBOX_SECRET") {
+		t.Errorf("expected SANDBOX_SECRET to be dropped, got:\n%s", env)
+	}
+}
+
+// TestCommand_Landlock verifies a sandboxed hook can only write beneath its
+// read_write paths.
+func TestCommand_Landlock(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("landlock requires linux")
+	}
+
+	allowed := t.TempDir()
+	denied := t.TempDir()
+	cfg := &config.SandboxConfig{
+		Landlock: &config.LandlockConfig{
+			ReadOnly:  []string{"/bin", "/usr", "/lib", "/lib64", "/etc"},
+			ReadWrite: []string{allowed, "/dev/null"},
+		},
+	}
+
+	cmd, err := Command("echo ok > "+filepath.Join(allowed, "out")+" && echo no > "+filepath.Join(denied, "out"), cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := cmd.CombinedOutput()
+	if strings.Contains(string(output), "landlock is not available") {
+		t.Skip("landlock is not supported by this kernel")
+	}
+	if err == nil {
+		t.Fatal("expected write outside read_write paths to fail")
+	}
+
+	if _, err := os.Stat(filepath.Join(allowed, "out")); err != nil {
+		t.Errorf("expected write to allowed path to succeed: %v (output: %s)", err, output)
+	}
+	if _, err := os.Stat(filepath.Join(denied, "out")); err == nil {
+		t.Error("expected write to denied path to be blocked")
+	}
+}
+
+// TestCommand_Seccomp verifies privileged syscalls fail under the seccomp
+// profile.
+func TestCommand_Seccomp(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("seccomp requires linux")
+	}
+	if _, err := exec.LookPath("unshare"); err != nil {
+		t.Skip("unshare is not installed")
+	}
+	if err := exec.Command("unshare", "-U", "true").Run(); err != nil {
+		t.Skip("unshare is not permitted outside the sandbox")
+	}
+
+	cmd, err := Command("unshare -U true", &config.SandboxConfig{Seccomp: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatal("expected unshare to be denied")
+	}
+	if !strings.Contains(string(output), "Operation not permitted") {
+		t.Errorf("expected EPERM, got: %s", output)
+	}
+}
+
+// TestCommand_Unrestricted verifies a nil sandbox runs the script as before.
+func TestCommand_Unrestricted(t *testing.T) {
+	cmd, err := Command("echo hello", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(output)) != "hello" {
+		t.Errorf("expected hello, got %q (err: %v)", output, err)
+	}
+}
@@ -0,0 +1,202 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Consul KV Source
+//
+// Reads certificate definitions from a Consul KV key and watches it with
+// blocking queries, so additions and removals apply as soon as the key is
+// written, matching consul-template driven workflows. The document's
+// signature, if any, is read from the key with a .sig suffix.
+// -------------------------------------------------------------------------------
+
+package source
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	// consulErrorBackoff is the delay before retrying a failed watch query.
+	consulErrorBackoff = 5 * time.Second
+
+	// consulMinWait is the least time between watch queries that did not
+	// advance the index, so a server that never blocks is not polled in a
+	// tight loop.
+	consulMinWait = time.Second
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// errKeyNotFound is returned for a key that does not exist.
+var errKeyNotFound = errors.New("not found")
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// ConsulKV loads certificate definitions from a Consul KV key.
+type ConsulKV struct {
+	config     *config.ConsulKVSource
+	httpClient *http.Client
+	backoff    time.Duration
+	minWait    time.Duration
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewConsulKV creates a Consul KV source.
+func NewConsulKV(cfg *config.ConsulKVSource) *ConsulKV {
+	return &ConsulKV{
+		config: cfg,
+		// Leave headroom over the blocking wait so the server answers first.
+		httpClient: &http.Client{Timeout: cfg.Wait + 30*time.Second},
+		backoff:    consulErrorBackoff,
+		minWait:    consulMinWait,
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Name describes the source for logging.
+func (s *ConsulKV) Name() string {
+	return "consul-kv:" + s.config.Key
+}
+
+// Load reads the current value of the key and its signature.
+func (s *ConsulKV) Load(ctx context.Context) (Document, error) {
+	data, _, err := s.get(ctx, s.config.Key, 0)
+	if err != nil {
+		return Document{}, err
+	}
+	return s.document(ctx, data)
+}
+
+// Watch runs blocking queries against the key and reports each change.
+// A signature written after its document is picked up when the blocking
+// query next returns, at the latest after the wait time. Queries that do
+// not advance the index are spaced at least minWait apart.
+func (s *ConsulKV) Watch(ctx context.Context, onChange func(Document)) {
+	var index uint64
+	var last *Document
+
+	for {
+		data, newIndex, err := s.get(ctx, s.config.Key, index)
+		var doc Document
+		if err == nil {
+			doc, err = s.document(ctx, data)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to watch certificate source", "source", s.Name(), "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.backoff):
+			}
+			continue
+		}
+
+		// Consul may reset its index (e.g. after a snapshot restore), and a
+		// proxy may drop the header; start over rather than blocking on an
+		// index that will never be reached.
+		previous := index
+		if newIndex == 0 || newIndex < index {
+			index = 0
+		} else {
+			index = newIndex
+		}
+
+		if last == nil || !doc.Equal(*last) {
+			last = &doc
+			onChange(doc)
+		}
+
+		if index == 0 || index == previous {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.minWait):
+			}
+		}
+	}
+}
+
+// document pairs data, the key's value, with the signature stored under
+// the key's .sig sibling, if there is one.
+func (s *ConsulKV) document(ctx context.Context, data []byte) (Document, error) {
+	signature, _, err := s.get(ctx, s.config.Key+".sig", 0)
+	if errors.Is(err, errKeyNotFound) {
+		return Document{Data: data}, nil
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to read signature: %w", err)
+	}
+	return Document{Data: data, Signature: signature}, nil
+}
+
+// get fetches the raw value of key, blocking until the index moves past
+// waitIndex when it is non-zero. It returns the value and the new index.
+func (s *ConsulKV) get(ctx context.Context, key string, waitIndex uint64) ([]byte, uint64, error) {
+	query := url.Values{"raw": {""}}
+	if waitIndex > 0 {
+		query.Set("index", strconv.FormatUint(waitIndex, 10))
+		query.Set("wait", s.config.Wait.String())
+	}
+	endpoint := fmt.Sprintf("%s/v1/kv/%s?%s",
+		strings.TrimSuffix(s.config.Address, "/"),
+		strings.TrimPrefix(key, "/"),
+		query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create Consul request: %w", err)
+	}
+	if s.config.Token != "" {
+		req.Header.Set("X-Consul-Token", s.config.Token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query Consul: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read Consul response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, 0, fmt.Errorf("consul key %s %w", key, errKeyNotFound)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("consul returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	index, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	return body, index, nil
+}
@@ -0,0 +1,237 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Consul KV Source Tests
+//
+// Unit tests for loading and watching certificate definitions in Consul KV.
+// -------------------------------------------------------------------------------
+
+package source
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeConsul serves a single KV key with blocking query support, and its
+// .sig sibling when signature is set.
+type fakeConsul struct {
+	mu        sync.Mutex
+	value     string
+	signature string
+	index     uint64
+	blocking  int
+	tokens    []string
+}
+
+// ServeHTTP answers /v1/kv requests, holding blocking queries until the
+// index moves or a short wait elapses.
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	waitIndex, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+
+	f.mu.Lock()
+	f.tokens = append(f.tokens, r.Header.Get("X-Consul-Token"))
+	if strings.HasSuffix(r.URL.Path, ".sig") {
+		signature := f.signature
+		f.mu.Unlock()
+		if signature == "" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(signature))
+		return
+	}
+	if waitIndex > 0 {
+		f.blocking++
+	}
+	f.mu.Unlock()
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for {
+		f.mu.Lock()
+		value, index := f.value, f.index
+		f.mu.Unlock()
+
+		if waitIndex == 0 || index > waitIndex || time.Now().After(deadline) {
+			w.Header().Set("X-Consul-Index", strconv.FormatUint(index, 10))
+			_, _ = w.Write([]byte(value))
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// set writes a new value and bumps the index.
+func (f *fakeConsul) set(value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.value = value
+	f.index++
+}
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestConsulKV_Watch verifies changes are delivered through blocking queries
+// and unchanged responses are not reported.
+func TestConsulKV_Watch(t *testing.T) {
+	consul := &fakeConsul{}
+	consul.set("v1")
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	src := NewConsulKV(&config.ConsulKVSource{
+		Address: server.URL,
+		Key:     "vault-cert-manager/web01",
+		Token:   "acl-token",
+		Wait:    time.Second,
+	})
+	src.minWait = 10 * time.Millisecond
+
+	doc, err := src.Load(context.Background())
+	if err != nil || string(doc.Data) != "v1" || doc.Signature != nil {
+		t.Fatalf("expected initial unsigned load of v1, got %+v (err %v)", doc, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string, 10)
+	go src.Watch(ctx, func(doc Document) { changes <- string(doc.Data) + string(doc.Signature) })
+
+	if got := <-changes; got != "v1" {
+		t.Fatalf("expected first document v1, got %s", got)
+	}
+
+	consul.set("v2")
+
+	select {
+	case got := <-changes:
+		if got != "v2" {
+			t.Errorf("expected changed document v2, got %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	select {
+	case got := <-changes:
+		t.Errorf("unexpected repeat notification: %s", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// A signature written after the document is picked up once the
+	// blocking query returns.
+	consul.mu.Lock()
+	consul.signature = "+sig"
+	consul.mu.Unlock()
+	select {
+	case got := <-changes:
+		if got != "v2+sig" {
+			t.Errorf("expected signed document v2+sig, got %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the signature")
+	}
+
+	consul.mu.Lock()
+	defer consul.mu.Unlock()
+	if consul.blocking == 0 {
+		t.Error("expected blocking queries with an index")
+	}
+	for _, token := range consul.tokens {
+		if token != "acl-token" {
+			t.Errorf("expected ACL token on every request, got %q", token)
+		}
+	}
+}
+
+// TestConsulKV_Watch_IndexReset verifies a missing, zero, or decreasing
+// X-Consul-Index restarts the watch from index zero, and queries that do
+// not advance the index are rate limited.
+func TestConsulKV_Watch_IndexReset(t *testing.T) {
+	var mu sync.Mutex
+	var queried []string
+	var times []time.Time
+	headers := []string{"5", "3", "0"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sig") {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		queried = append(queried, r.URL.Query().Get("index"))
+		times = append(times, time.Now())
+		if len(headers) > 0 {
+			w.Header().Set("X-Consul-Index", headers[0])
+			headers = headers[1:]
+		}
+		_, _ = w.Write([]byte("v1"))
+	}))
+	defer server.Close()
+
+	src := NewConsulKV(&config.ConsulKVSource{Address: server.URL, Key: "web01", Wait: time.Second})
+	src.minWait = 20 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		src.Watch(ctx, func(Document) {})
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(queried)
+		mu.Unlock()
+		if n >= 6 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(queried) < 6 {
+		t.Fatalf("expected the watch to keep querying, got %v", queried)
+	}
+	// 5 is waited on; 3 went backwards, and a zero or missing index is
+	// never waited on.
+	for i, want := range []string{"", "5", "", "", "", ""} {
+		if queried[i] != want {
+			t.Errorf("query %d: expected index %q, got %q (all %v)", i, want, queried[i], queried)
+		}
+	}
+	if elapsed := times[5].Sub(times[1]); elapsed < 4*src.minWait {
+		t.Errorf("expected queries without a new index to be %v apart, four took %v", src.minWait, elapsed)
+	}
+}
+
+// TestConsulKV_LoadMissing verifies a missing key is an error.
+func TestConsulKV_LoadMissing(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	src := NewConsulKV(&config.ConsulKVSource{Address: server.URL, Key: "missing", Wait: time.Second})
+	if _, err := src.Load(context.Background()); err == nil {
+		t.Error("expected error for missing key")
+	}
+}
@@ -0,0 +1,55 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Central Certificate Sources
+//
+// Loads certificate definitions from a central store so a fleet's
+// certificates can be managed in one place. Sources return a raw document
+// containing a "certificates" list and its detached signature, if any;
+// verification, parsing, and validation against the local configuration are
+// left to the caller.
+// -------------------------------------------------------------------------------
+
+// Package source provides central stores for certificate definitions.
+package source
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"context"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Document is a certificate definition document as stored in the source,
+// with the detached signature stored alongside it, nil when there is none.
+type Document struct {
+	Data      []byte
+	Signature []byte
+}
+
+// Equal reports whether d and other hold the same data and signature.
+func (d Document) Equal(other Document) bool {
+	return bytes.Equal(d.Data, other.Data) && bytes.Equal(d.Signature, other.Signature)
+}
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// Source supplies certificate definition documents.
+type Source interface {
+	// Name describes the source for logging.
+	Name() string
+
+	// Load fetches the current document.
+	Load(ctx context.Context) (Document, error)
+
+	// Watch calls onChange with each new document until ctx is cancelled.
+	// Fetch errors are logged and retried; the last good document stays
+	// in effect.
+	Watch(ctx context.Context, onChange func(Document))
+}
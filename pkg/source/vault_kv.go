@@ -0,0 +1,125 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Vault KV Source
+//
+// Reads certificate definitions from the "certificates" field of a Vault KV
+// secret and polls it for changes. The field may hold a YAML document or a
+// structured list written with `vault kv put ... certificates=@certs.json`.
+// A "signature" field in the same secret signs the YAML document, so both
+// are read together from one version.
+// -------------------------------------------------------------------------------
+
+package source
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// INTERFACES
+// -------------------------------------------------------------------------
+
+// KVReader reads the data of a Vault KV secret.
+type KVReader interface {
+	ReadKV(ctx context.Context, mount, path string, version int) (map[string]interface{}, error)
+}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// VaultKV loads certificate definitions from a Vault KV secret.
+type VaultKV struct {
+	reader KVReader
+	config *config.VaultKVSource
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewVaultKV creates a Vault KV source.
+func NewVaultKV(reader KVReader, cfg *config.VaultKVSource) *VaultKV {
+	return &VaultKV{reader: reader, config: cfg}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Name describes the source for logging.
+func (s *VaultKV) Name() string {
+	return fmt.Sprintf("vault-kv:%s/%s", s.config.Mount, s.config.Path)
+}
+
+// Load reads the secret and returns its certificates as a document, signed
+// by the secret's signature field when set.
+func (s *VaultKV) Load(ctx context.Context) (Document, error) {
+	data, err := s.reader.ReadKV(ctx, s.config.Mount, s.config.Path, s.config.Version)
+	if err != nil {
+		return Document{}, err
+	}
+
+	value, ok := data["certificates"]
+	if !ok {
+		return Document{}, fmt.Errorf("secret %s has no certificates field", s.Name())
+	}
+
+	var signature []byte
+	if sig, ok := data["signature"]; ok {
+		text, ok := sig.(string)
+		if !ok {
+			return Document{}, fmt.Errorf("secret %s has a signature field that is not a string", s.Name())
+		}
+		signature = []byte(text)
+	}
+
+	// A string holds a YAML document; anything else is a structured list
+	// that is re-encoded as JSON, which the YAML parser also accepts.
+	if text, ok := value.(string); ok {
+		return Document{Data: []byte(text), Signature: signature}, nil
+	}
+	if signature != nil {
+		// The re-encoded list is not the document that was signed.
+		return Document{}, fmt.Errorf("secret %s is signed, so its certificates field must be a YAML string", s.Name())
+	}
+
+	doc, err := json.Marshal(map[string]interface{}{"certificates": value})
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to encode certificates from %s: %w", s.Name(), err)
+	}
+	return Document{Data: doc}, nil
+}
+
+// Watch polls the secret every refresh interval and reports changes.
+func (s *VaultKV) Watch(ctx context.Context, onChange func(Document)) {
+	ticker := time.NewTicker(s.config.RefreshInterval)
+	defer ticker.Stop()
+
+	var last *Document
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			doc, err := s.Load(ctx)
+			if err != nil {
+				slog.Error("Failed to refresh certificate source", "source", s.Name(), "error", err)
+				continue
+			}
+			if last != nil && doc.Equal(*last) {
+				continue
+			}
+			last = &doc
+			onChange(doc)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Vault KV Source Tests
+//
+// Unit tests for loading and watching certificate definitions in Vault KV.
+// -------------------------------------------------------------------------------
+
+package source
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// fakeKV is an in-memory KVReader.
+type fakeKV struct {
+	mu   sync.Mutex
+	data map[string]interface{}
+	err  error
+}
+
+// ReadKV returns the stored secret data.
+func (f *fakeKV) ReadKV(ctx context.Context, mount, path string, version int) (map[string]interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.data, f.err
+}
+
+// set replaces the stored secret data.
+func (f *fakeKV) set(data map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data = data
+}
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestVaultKV_Load verifies string and structured certificate fields.
+func TestVaultKV_Load(t *testing.T) {
+	tests := []struct {
+		name      string
+		kv        *fakeKV
+		contains  string
+		signature string
+		expectErr bool
+	}{
+		{
+			name:     "yaml string",
+			kv:       &fakeKV{data: map[string]interface{}{"certificates": "certificates:\n  - name: web\n"}},
+			contains: "name: web",
+		},
+		{
+			name: "structured list",
+			kv: &fakeKV{data: map[string]interface{}{
+				"certificates": []interface{}{map[string]interface{}{"name": "web"}},
+			}},
+			contains: `"name":"web"`,
+		},
+		{
+			name: "signed yaml string",
+			kv: &fakeKV{data: map[string]interface{}{
+				"certificates": "certificates:\n  - name: web\n",
+				"signature":    "c2lnbmF0dXJl",
+			}},
+			contains:  "name: web",
+			signature: "c2lnbmF0dXJl",
+		},
+		{
+			name: "signed structured list",
+			kv: &fakeKV{data: map[string]interface{}{
+				"certificates": []interface{}{map[string]interface{}{"name": "web"}},
+				"signature":    "c2lnbmF0dXJl",
+			}},
+			expectErr: true,
+		},
+		{
+			name:      "missing field",
+			kv:        &fakeKV{data: map[string]interface{}{"other": "value"}},
+			expectErr: true,
+		},
+		{
+			name:      "read error",
+			kv:        &fakeKV{err: fmt.Errorf("permission denied")},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := NewVaultKV(tt.kv, &config.VaultKVSource{Mount: "secret", Path: "hosts/web01", Version: 2})
+
+			doc, err := src.Load(context.Background())
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(string(doc.Data), tt.contains) {
+				t.Errorf("expected document to contain %q, got %s", tt.contains, doc.Data)
+			}
+			if string(doc.Signature) != tt.signature {
+				t.Errorf("expected signature %q, got %q", tt.signature, doc.Signature)
+			}
+		})
+	}
+}
+
+// TestVaultKV_Watch verifies only changed documents are reported.
+func TestVaultKV_Watch(t *testing.T) {
+	kv := &fakeKV{data: map[string]interface{}{"certificates": "v1"}}
+	src := NewVaultKV(kv, &config.VaultKVSource{Mount: "secret", Path: "hosts/web01", RefreshInterval: 5 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan string, 10)
+	go src.Watch(ctx, func(doc Document) { changes <- string(doc.Data) })
+
+	if got := <-changes; got != "v1" {
+		t.Fatalf("expected first document v1, got %s", got)
+	}
+
+	kv.set(map[string]interface{}{"certificates": "v2"})
+
+	select {
+	case got := <-changes:
+		if got != "v2" {
+			t.Errorf("expected changed document v2, got %s", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	select {
+	case got := <-changes:
+		t.Errorf("unexpected repeat notification: %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,164 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Versioned On-Disk State
+//
+// Directories the manager keeps state in record the schema version of their
+// layout in a small marker file. On startup, older layouts are upgraded in
+// place one migration at a time, recording each step so an interrupted
+// upgrade resumes where it stopped. A layout written by a newer release is
+// refused, so downgrading an agent never rewrites state it does not
+// understand.
+// -------------------------------------------------------------------------------
+
+// Package state versions and migrates the manager's on-disk state.
+package state
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// VersionFile is the marker holding a directory's schema version.
+const VersionFile = ".vcm-schema"
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// ErrNewerVersion marks state written by a newer release.
+var ErrNewerVersion = errors.New("state was written by a newer version of vault-cert-manager")
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Migration upgrades a directory from the previous schema version to
+// Version.
+type Migration struct {
+	Version     int
+	Description string
+	Apply       func(dir string) error
+}
+
+// Schema is the versioned layout of one kind of state directory. Version 0
+// is state from before versioning; each migration raises it by one, so the
+// current version is the number of migrations.
+type Schema struct {
+	Name       string
+	Migrations []Migration
+}
+
+// marker is the content of VersionFile.
+type marker struct {
+	Schema  string `json:"schema"`
+	Version int    `json:"version"`
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Current returns the schema version this release writes.
+func (s Schema) Current() int {
+	return len(s.Migrations)
+}
+
+// Version returns dir's schema version: the recorded one, 0 for existing
+// state from before versioning, or Current for a missing or empty
+// directory, which has nothing to migrate.
+func (s Schema) Version(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, VersionFile))
+	if err == nil {
+		var m marker
+		if err := json.Unmarshal(data, &m); err != nil {
+			return 0, fmt.Errorf("failed to parse %s schema version in %s: %w", s.Name, dir, err)
+		}
+		if m.Schema != s.Name {
+			return 0, fmt.Errorf("%s holds %s state, not %s", dir, m.Schema, s.Name)
+		}
+		return m.Version, nil
+	}
+	if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("failed to read %s schema version in %s: %w", s.Name, dir, err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return s.Current(), nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	if len(entries) == 0 {
+		return s.Current(), nil
+	}
+	return 0, nil
+}
+
+// Upgrade migrates dir to the current version and records it, creating
+// dir if needed. It returns ErrNewerVersion, leaving dir untouched, when
+// dir is ahead of this release.
+func (s Schema) Upgrade(dir string) error {
+	version, err := s.Version(dir)
+	if err != nil {
+		return err
+	}
+	if version > s.Current() {
+		return fmt.Errorf("%w: %s in %s is at version %d, this release supports up to %d",
+			ErrNewerVersion, s.Name, dir, version, s.Current())
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	for _, migration := range s.Migrations[version:] {
+		slog.Info("Migrating on-disk state",
+			"schema", s.Name,
+			"dir", dir,
+			"version", migration.Version,
+			"migration", migration.Description)
+		if err := migration.Apply(dir); err != nil {
+			return fmt.Errorf("failed to migrate %s in %s to version %d: %w", s.Name, dir, migration.Version, err)
+		}
+		if err := s.record(dir, migration.Version); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, VersionFile)); os.IsNotExist(err) {
+		return s.record(dir, s.Current())
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// record atomically writes dir's schema version.
+func (s Schema) record(dir string, version int) error {
+	data, err := json.Marshal(marker{Schema: s.Name, Version: version})
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, VersionFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to record %s schema version: %w", s.Name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to record %s schema version: %w", s.Name, err)
+	}
+	return nil
+}
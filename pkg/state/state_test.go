@@ -0,0 +1,130 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Versioned On-Disk State Tests
+//
+// Unit tests for schema version detection, upgrades, and downgrade refusal.
+// -------------------------------------------------------------------------------
+
+package state
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestSchema_Upgrade verifies legacy state runs every migration in order,
+// new directories are stamped without migrating, and upgraded state is
+// left alone.
+func TestSchema_Upgrade(t *testing.T) {
+	var applied []int
+	schema := testSchema(&applied, nil)
+
+	legacy := t.TempDir()
+	_ = os.WriteFile(filepath.Join(legacy, "data"), []byte("x"), 0600)
+	if err := schema.Upgrade(legacy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 2 || applied[0] != 1 || applied[1] != 2 {
+		t.Errorf("expected migrations 1 and 2, got %v", applied)
+	}
+	if v, _ := schema.Version(legacy); v != 2 {
+		t.Errorf("expected version 2, got %d", v)
+	}
+
+	applied = nil
+	if err := schema.Upgrade(legacy); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fresh := filepath.Join(t.TempDir(), "new")
+	if err := schema.Upgrade(fresh); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("expected no migrations, got %v", applied)
+	}
+	if v, _ := schema.Version(fresh); v != 2 {
+		t.Errorf("expected new directory at version 2, got %d", v)
+	}
+}
+
+// TestSchema_Upgrade_Resume verifies a failed migration keeps the steps
+// before it and is retried by the next upgrade.
+func TestSchema_Upgrade_Resume(t *testing.T) {
+	var applied []int
+	failure := errors.New("disk full")
+	schema := testSchema(&applied, failure)
+
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "data"), []byte("x"), 0600)
+	if err := schema.Upgrade(dir); !errors.Is(err, failure) {
+		t.Fatalf("expected migration error, got %v", err)
+	}
+	if v, _ := schema.Version(dir); v != 1 {
+		t.Errorf("expected version 1 after failed migration, got %d", v)
+	}
+
+	schema = testSchema(&applied, nil)
+	if err := schema.Upgrade(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(applied) != 3 || applied[2] != 2 {
+		t.Errorf("expected only migration 2 to be retried, got %v", applied)
+	}
+}
+
+// TestSchema_Upgrade_Newer verifies state from a newer release is refused
+// and left untouched.
+func TestSchema_Upgrade_Newer(t *testing.T) {
+	var applied []int
+	schema := testSchema(&applied, nil)
+
+	dir := t.TempDir()
+	marker := `{"schema":"test","version":3}`
+	_ = os.WriteFile(filepath.Join(dir, VersionFile), []byte(marker), 0600)
+	if err := schema.Upgrade(dir); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("expected newer version error, got %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, VersionFile))
+	if string(data) != marker {
+		t.Errorf("expected version marker to be untouched, got %s", data)
+	}
+
+	_ = os.WriteFile(filepath.Join(dir, VersionFile), []byte(`{"schema":"other","version":1}`), 0600)
+	if err := schema.Upgrade(dir); err == nil {
+		t.Error("expected error for another schema's directory")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// testSchema returns a two-version schema recording applied migrations.
+// Migration 2 returns fail when set.
+func testSchema(applied *[]int, fail error) Schema {
+	migration := func(version int) func(string) error {
+		return func(string) error {
+			*applied = append(*applied, version)
+			if version == 2 {
+				return fail
+			}
+			return nil
+		}
+	}
+	return Schema{
+		Name: "test",
+		Migrations: []Migration{
+			{Version: 1, Description: "first", Apply: migration(1)},
+			{Version: 2, Description: "second", Apply: migration(2)},
+		},
+	}
+}
@@ -0,0 +1,328 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - OpenTelemetry Tracing
+//
+// Exports spans around issuance attempts to an OpenTelemetry collector
+// using OTLP/HTTP with JSON encoding, so slow rotations can be broken down
+// in an existing tracing backend. Finished spans are buffered and sent in
+// batches; when the collector is unreachable spans beyond the buffer are
+// dropped rather than slowing down certificate work.
+// -------------------------------------------------------------------------------
+
+// Package tracing exports certificate rotation spans over OTLP/HTTP.
+package tracing
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	// maxPendingSpans bounds the spans buffered between exports.
+	maxPendingSpans = 2048
+
+	// scopeName identifies the instrumentation in exported spans.
+	scopeName = "cert-manager/pkg/cert"
+
+	// OTLP span kind and status codes.
+	spanKindInternal = 1
+	statusCodeOK     = 1
+	statusCodeError  = 2
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Tracer starts spans and exports them in batches. It satisfies
+// cert.Tracer.
+type Tracer struct {
+	config     *config.TracingConfig
+	httpClient *http.Client
+	resource   []keyValue
+
+	mu      sync.Mutex
+	pending []otlpSpan
+	dropped int
+}
+
+// span is an in-progress span started by a Tracer.
+type span struct {
+	tracer     *Tracer
+	traceID    string
+	spanID     string
+	parentID   string
+	name       string
+	start      time.Time
+	attributes map[string]string
+}
+
+// exportRequest is the OTLP ExportTraceServiceRequest JSON encoding.
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+// resourceSpans groups spans by the process that produced them.
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+// resource describes the process that produced the spans.
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+// scopeSpans groups spans by instrumentation scope.
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+// scope names the instrumentation that produced the spans.
+type scope struct {
+	Name string `json:"name"`
+}
+
+// otlpSpan is a finished span in OTLP JSON encoding. IDs are hex and
+// timestamps decimal strings, as the OTLP JSON mapping requires.
+type otlpSpan struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+// keyValue is an OTLP attribute with a string value.
+type keyValue struct {
+	Key   string      `json:"key"`
+	Value stringValue `json:"value"`
+}
+
+// stringValue is an OTLP AnyValue holding a string.
+type stringValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// status is an OTLP span status.
+type status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// New creates a tracer exporting to the configured OTLP endpoint.
+func New(cfg *config.TracingConfig) *Tracer {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return &Tracer{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+		resource: []keyValue{
+			attribute("service.name", cfg.ServiceName),
+			attribute("host.name", hostname),
+		},
+	}
+}
+
+// -------------------------------------------------------------------------
+// PUBLIC METHODS
+// -------------------------------------------------------------------------
+
+// Start starts a span, as a child of parent when it is a span of this
+// tracer and as the root of a new trace otherwise.
+func (t *Tracer) Start(parent cert.Span, name string, attributes map[string]string) cert.Span {
+	s := &span{
+		tracer:     t,
+		spanID:     randomID(8),
+		name:       name,
+		start:      time.Now(),
+		attributes: attributes,
+	}
+	if p, ok := parent.(*span); ok {
+		s.traceID, s.parentID = p.traceID, p.spanID
+	} else {
+		s.traceID = randomID(16)
+	}
+	return s
+}
+
+// Run exports buffered spans every interval until ctx is done, then
+// exports what is left.
+func (t *Tracer) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.Flush()
+			return
+		case <-ticker.C:
+			t.Flush()
+		}
+	}
+}
+
+// Flush exports the buffered spans. Failures are logged and the spans
+// discarded.
+func (t *Tracer) Flush() {
+	t.mu.Lock()
+	spans, dropped := t.pending, t.dropped
+	t.pending, t.dropped = nil, 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		slog.Warn("Dropped trace spans, export buffer full", "dropped", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+	if err := t.export(spans); err != nil {
+		slog.Warn("Failed to export trace spans",
+			"endpoint", t.config.Endpoint,
+			"spans", len(spans),
+			"error", err)
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// End finishes the span, marking it failed with err when set, and
+// buffers it for export.
+func (s *span) End(err error) {
+	end := time.Now()
+	finished := otlpSpan{
+		TraceID:           s.traceID,
+		SpanID:            s.spanID,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Attributes:        attributes(s.attributes),
+		Status:            status{Code: statusCodeOK},
+	}
+	if err != nil {
+		finished.Status = status{Code: statusCodeError, Message: err.Error()}
+	}
+	s.tracer.enqueue(finished)
+}
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// enqueue buffers a finished span, counting it as dropped when the buffer
+// is full.
+func (t *Tracer) enqueue(finished otlpSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.pending) >= maxPendingSpans {
+		t.dropped++
+		return
+	}
+	t.pending = append(t.pending, finished)
+}
+
+// export posts spans to the OTLP endpoint.
+func (t *Tracer) export(spans []otlpSpan) error {
+	body, err := json.Marshal(exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{Attributes: t.resource},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: scopeName},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// attribute returns a string attribute.
+func attribute(key, value string) keyValue {
+	return keyValue{Key: key, Value: stringValue{StringValue: value}}
+}
+
+// attributes converts a map to attributes sorted by key.
+func attributes(values map[string]string) []keyValue {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]keyValue, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, attribute(key, values[key]))
+	}
+	return result
+}
+
+// randomID returns n random bytes as hex, the OTLP JSON encoding of trace
+// and span IDs.
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,94 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - OpenTelemetry Tracing Tests
+//
+// Unit tests for span export over OTLP/HTTP.
+// -------------------------------------------------------------------------------
+
+package tracing
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestTracer_Flush verifies finished spans are exported with their parent,
+// status, attributes, resource, and the configured headers.
+func TestTracer_Flush(t *testing.T) {
+	var received exportRequest
+	var apiKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("X-Api-Key")
+		_ = json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	tracer := New(&config.TracingConfig{
+		Endpoint:    server.URL,
+		ServiceName: "vault-cert-manager",
+		Headers:     map[string]string{"X-Api-Key": "secret"},
+		Interval:    time.Second,
+		Timeout:     time.Second,
+	})
+
+	root := tracer.Start(nil, "certificate.rotate", map[string]string{"certificate.name": "web"})
+	child := tracer.Start(root, "vault.issue", nil)
+	child.End(errors.New("permission denied"))
+	root.End(nil)
+	tracer.Flush()
+
+	if apiKey != "secret" {
+		t.Errorf("expected the configured header, got %q", apiKey)
+	}
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected export %+v", received)
+	}
+	if attrs := received.ResourceSpans[0].Resource.Attributes; len(attrs) == 0 || attrs[0].Value.StringValue != "vault-cert-manager" {
+		t.Errorf("expected the service name on the resource, got %+v", attrs)
+	}
+
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	issue, rotate := spans[0], spans[1]
+	if issue.TraceID != rotate.TraceID || issue.ParentSpanID != rotate.SpanID || rotate.ParentSpanID != "" {
+		t.Errorf("expected vault.issue to be a child of certificate.rotate, got %+v and %+v", issue, rotate)
+	}
+	if len(rotate.TraceID) != 32 || len(rotate.SpanID) != 16 {
+		t.Errorf("expected hex trace and span IDs, got %q and %q", rotate.TraceID, rotate.SpanID)
+	}
+	if issue.Status.Code != statusCodeError || issue.Status.Message != "permission denied" {
+		t.Errorf("expected an error status, got %+v", issue.Status)
+	}
+	if rotate.Status.Code != statusCodeOK || len(rotate.Attributes) != 1 || rotate.Attributes[0].Key != "certificate.name" {
+		t.Errorf("unexpected root span %+v", rotate)
+	}
+}
+
+// TestTracer_Enqueue verifies spans beyond the buffer are dropped rather
+// than growing it without bound.
+func TestTracer_Enqueue(t *testing.T) {
+	tracer := New(&config.TracingConfig{Endpoint: "http://127.0.0.1:0", Interval: time.Second, Timeout: time.Second})
+	for i := 0; i < maxPendingSpans+10; i++ {
+		tracer.Start(nil, "disk.write", nil).End(nil)
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	if len(tracer.pending) != maxPendingSpans || tracer.dropped != 10 {
+		t.Errorf("expected %d pending and 10 dropped, got %d and %d", maxPendingSpans, len(tracer.pending), tracer.dropped)
+	}
+}
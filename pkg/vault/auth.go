@@ -14,6 +14,7 @@ package vault
 import (
 	"cert-manager/pkg/config"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 )
@@ -27,6 +28,13 @@ type Authenticator interface {
 	Authenticate(client *api.Client) error
 }
 
+// TokenLeaser is implemented by authenticators that log in for a new token
+// and can report the TTL Vault granted it on the last login. Zero means
+// the token does not expire.
+type TokenLeaser interface {
+	TokenTTL() time.Duration
+}
+
 // -------------------------------------------------------------------------
 // PUBLIC FUNCTIONS
 // -------------------------------------------------------------------------
@@ -3,7 +3,8 @@
 //
 // AppRole-based authentication for Vault. Authenticates using role_id and
 // secret_id, which is the recommended method for machine/service authentication.
-// Supports reading secret_id from a file for improved security.
+// Supports reading secret_id from a file for improved security, or unwrapping
+// a response-wrapped secret_id for single-use secure introduction.
 // -------------------------------------------------------------------------------
 
 package vault
@@ -18,10 +19,24 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 )
 
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// unwrappedSecretIDs caches secret_ids obtained from wrapped files. Wrapping
+// tokens are single-use, so re-authentication and per-certificate clients
+// sharing the same file must reuse the first unwrap result.
+var (
+	unwrappedMu        sync.Mutex
+	unwrappedSecretIDs = make(map[string]string)
+)
+
 // -------------------------------------------------------------------------
 // TYPES
 // -------------------------------------------------------------------------
@@ -29,6 +44,7 @@ import (
 // AppRoleAuthenticator implements AppRole-based Vault authentication.
 type AppRoleAuthenticator struct {
 	config *config.AppRoleAuth
+	ttl    time.Duration // TTL granted on the last login
 }
 
 // -------------------------------------------------------------------------
@@ -48,7 +64,7 @@ func NewAppRoleAuthenticator(config *config.AppRoleAuth) *AppRoleAuthenticator {
 
 // Authenticate performs AppRole authentication with Vault.
 func (a *AppRoleAuthenticator) Authenticate(client *api.Client) error {
-	secretID, err := a.getSecretID()
+	secretID, err := a.getSecretID(client)
 	if err != nil {
 		return fmt.Errorf("failed to get secret_id: %w", err)
 	}
@@ -78,17 +94,27 @@ func (a *AppRoleAuthenticator) Authenticate(client *api.Client) error {
 	}
 
 	client.SetToken(resp.Auth.ClientToken)
+	a.ttl = time.Duration(resp.Auth.LeaseDuration) * time.Second
 	slog.Info("Successfully authenticated with AppRole")
 
 	return nil
 }
 
+// TokenTTL returns the TTL Vault granted the token on the last login.
+func (a *AppRoleAuthenticator) TokenTTL() time.Duration {
+	return a.ttl
+}
+
 // -------------------------------------------------------------------------
 // PRIVATE METHODS
 // -------------------------------------------------------------------------
 
-// getSecretID retrieves the secret_id from config or file.
-func (a *AppRoleAuthenticator) getSecretID() (string, error) {
+// getSecretID retrieves the secret_id from a wrapped file, plain file, or config.
+func (a *AppRoleAuthenticator) getSecretID(client *api.Client) (string, error) {
+	if a.config.SecretIDWrappedFile != "" {
+		return a.unwrapSecretID(client)
+	}
+
 	// Prefer secret_id_file over inline secret_id
 	if a.config.SecretIDFile != "" {
 		data, err := os.ReadFile(a.config.SecretIDFile)
@@ -104,3 +130,53 @@ func (a *AppRoleAuthenticator) getSecretID() (string, error) {
 
 	return "", fmt.Errorf("either secret_id or secret_id_file must be specified")
 }
+
+// unwrapSecretID exchanges the wrapping token in secret_id_wrapped_file for
+// the secret_id via sys/wrapping/unwrap, optionally deleting the file.
+func (a *AppRoleAuthenticator) unwrapSecretID(client *api.Client) (string, error) {
+	path := a.config.SecretIDWrappedFile
+
+	unwrappedMu.Lock()
+	defer unwrappedMu.Unlock()
+
+	if secretID, ok := unwrappedSecretIDs[path]; ok {
+		return secretID, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret_id wrapped file %s: %w", path, err)
+	}
+
+	// Unwrap on a clone authenticated with the wrapping token itself so the
+	// main client's token is left untouched.
+	unwrapClient, err := client.Clone()
+	if err != nil {
+		return "", fmt.Errorf("failed to clone client for unwrap: %w", err)
+	}
+	unwrapClient.SetToken(strings.TrimSpace(string(data)))
+
+	resp, err := unwrapClient.Logical().Unwrap("")
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap secret_id: %w", err)
+	}
+	if resp == nil || resp.Data == nil {
+		return "", fmt.Errorf("no data returned when unwrapping secret_id")
+	}
+
+	secretID, _ := resp.Data["secret_id"].(string)
+	if secretID == "" {
+		return "", fmt.Errorf("unwrapped response does not contain secret_id")
+	}
+	unwrappedSecretIDs[path] = secretID
+
+	slog.Info("Unwrapped AppRole secret_id", "file", path)
+
+	if a.config.DeleteWrappedFile {
+		if err := os.Remove(path); err != nil {
+			slog.Warn("Failed to delete secret_id wrapped file", "file", path, "error", err)
+		}
+	}
+
+	return secretID, nil
+}
@@ -34,6 +34,7 @@ import (
 // GCPAuthenticator implements GCP-based Vault authentication.
 type GCPAuthenticator struct {
 	config *config.GCPAuth
+	ttl    time.Duration // TTL granted on the last login
 }
 
 // -------------------------------------------------------------------------
@@ -90,11 +91,17 @@ func (g *GCPAuthenticator) Authenticate(client *api.Client) error {
 	}
 
 	client.SetToken(resp.Auth.ClientToken)
+	g.ttl = time.Duration(resp.Auth.LeaseDuration) * time.Second
 	slog.Info("Successfully authenticated with GCP", "auth_type", g.config.Type)
 
 	return nil
 }
 
+// TokenTTL returns the TTL Vault granted the token on the last login.
+func (g *GCPAuthenticator) TokenTTL() time.Duration {
+	return g.ttl
+}
+
 // -------------------------------------------------------------------------
 // PRIVATE METHODS
 // -------------------------------------------------------------------------
@@ -12,8 +12,15 @@ package vault
 
 import (
 	"cert-manager/pkg/config"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/hashicorp/vault/api"
 )
 
 // -------------------------------------------------------------------------
@@ -112,3 +119,71 @@ func TestCreateAuthenticator(t *testing.T) {
 		})
 	}
 }
+
+// TestAppRoleAuthenticator_WrappedSecretID verifies the secret_id is
+// unwrapped once, used for login, and the wrapped file is deleted.
+func TestAppRoleAuthenticator_WrappedSecretID(t *testing.T) {
+	unwraps := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/sys/wrapping/unwrap":
+			unwraps++
+			if r.Header.Get("X-Vault-Token") != "wrapping-token" || unwraps > 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"wrapping token is not valid or does not exist"}})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"secret_id": "unwrapped-secret"},
+			})
+		case "/v1/auth/approle/login":
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["secret_id"] != "unwrapped-secret" {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"invalid secret id"}})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth": map[string]interface{}{"client_token": "login-token"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	wrappedFile := filepath.Join(t.TempDir(), "wrapped")
+	if err := os.WriteFile(wrappedFile, []byte("wrapping-token\n"), 0600); err != nil {
+		t.Fatalf("failed to write wrapped file: %v", err)
+	}
+
+	client, err := api.NewClient(&api.Config{Address: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	authenticator := NewAppRoleAuthenticator(&config.AppRoleAuth{
+		RoleID:              "role",
+		SecretIDWrappedFile: wrappedFile,
+		DeleteWrappedFile:   true,
+	})
+
+	// Authenticate twice to simulate re-authentication after token expiry.
+	for i := 0; i < 2; i++ {
+		if err := authenticator.Authenticate(client); err != nil {
+			t.Fatalf("authentication %d failed: %v", i+1, err)
+		}
+	}
+
+	if client.Token() != "login-token" {
+		t.Errorf("expected login token, got %q", client.Token())
+	}
+	if unwraps != 1 {
+		t.Errorf("expected 1 unwrap call, got %d", unwraps)
+	}
+	if _, err := os.Stat(wrappedFile); !os.IsNotExist(err) {
+		t.Errorf("expected wrapped file to be deleted, stat error: %v", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 )
@@ -28,6 +29,7 @@ import (
 // TLSAuthenticator implements TLS certificate-based Vault authentication.
 type TLSAuthenticator struct {
 	config *config.TLSAuth
+	ttl    time.Duration // TTL granted on the last login
 }
 
 // -------------------------------------------------------------------------
@@ -64,7 +66,12 @@ func (t *TLSAuthenticator) Authenticate(client *api.Client) error {
 		config.HttpClient.Transport = &http.Transport{}
 	}
 
-	transport, ok := config.HttpClient.Transport.(*http.Transport)
+	roundTripper := config.HttpClient.Transport
+	if wrapped, ok := roundTripper.(interface{ Unwrap() http.RoundTripper }); ok {
+		roundTripper = wrapped.Unwrap()
+	}
+
+	transport, ok := roundTripper.(*http.Transport)
 	if !ok {
 		return fmt.Errorf("unable to configure TLS transport")
 	}
@@ -108,7 +115,13 @@ func (t *TLSAuthenticator) Authenticate(client *api.Client) error {
 
 	// Set the token on the original client
 	client.SetToken(resp.Auth.ClientToken)
+	t.ttl = time.Duration(resp.Auth.LeaseDuration) * time.Second
 	slog.Info("Successfully authenticated with TLS certificate")
 
 	return nil
 }
+
+// TokenTTL returns the TTL Vault granted the token on the last login.
+func (t *TLSAuthenticator) TokenTTL() time.Duration {
+	return t.ttl
+}
@@ -15,11 +15,18 @@ package vault
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"log/slog"
 	"net"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"cert-manager/pkg/config"
@@ -27,6 +34,16 @@ import (
 	"github.com/hashicorp/vault/api"
 )
 
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// CorrelationHeader carries the agent-side correlation ID on issuance
+// requests. Enable it in Vault audit logs with:
+//
+//	vault write sys/config/auditing/request-headers/X-Correlation-ID hmac=false
+const CorrelationHeader = "X-Correlation-ID"
+
 // -------------------------------------------------------------------------
 // INTERFACES
 // -------------------------------------------------------------------------
@@ -36,6 +53,18 @@ type Client interface {
 	IssueCertificate(certConfig *config.CertificateConfig) (*CertificateData, error)
 }
 
+// CAChainFetcher is implemented by clients that can read the PKI mount's
+// current CA chain, used to detect issuing CA rotation.
+type CAChainFetcher interface {
+	FetchCAChain(certConfig *config.CertificateConfig) (string, error)
+}
+
+// CSRSigner is implemented by clients that can have the PKI mount sign a
+// CSR, used to renew certificates with reuse_private_key.
+type CSRSigner interface {
+	SignCertificate(certConfig *config.CertificateConfig, csrPEM string) (*CertificateData, error)
+}
+
 // -------------------------------------------------------------------------
 // TYPES
 // -------------------------------------------------------------------------
@@ -46,6 +75,16 @@ type VaultClient struct {
 	pkiMount      string
 	authenticator Authenticator
 	authConfig    *config.AuthConfig
+	retry         *RetryPolicy
+	addresses     []string
+	addrIndex     int
+	failovers     uint64
+	addrMu        sync.Mutex
+	recorder      *requestRecorder
+	authSuccesses atomic.Uint64
+	authFailures  atomic.Uint64
+	lastAuth      atomic.Int64 // unix nanoseconds of the last successful login
+	tokenExpiry   atomic.Int64 // unix nanoseconds; zero when unknown or non-expiring
 	mu            sync.RWMutex
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -58,6 +97,30 @@ type CertificateData struct {
 	CertificateChain string
 	SerialNumber     string
 	Expiration       time.Time
+	CorrelationID    string
+	VaultRequestID   string
+}
+
+// RequestError is a failed certificate request, carrying the correlation
+// ID sent with it and Vault's request ID when Vault responded, so failed
+// attempts can be found in the Vault audit log.
+type RequestError struct {
+	CorrelationID  string
+	VaultRequestID string
+	Err            error
+}
+
+// Stats holds cumulative Vault operation counters for metrics.
+type Stats struct {
+	Retries          map[string]uint64
+	Failovers        uint64
+	Requests         map[string]RequestStats // keyed by API path, e.g. "pki/issue/web"
+	AuthSuccesses    uint64
+	AuthFailures     uint64
+	ClockSkew        time.Duration // local clock minus Vault's; valid when ClockSkewSampled
+	ClockSkewSampled bool
+	LastAuth         time.Time // last successful authentication; zero before any
+	TokenExpiry      time.Time // when the token expires; zero when unknown or non-expiring
 }
 
 // -------------------------------------------------------------------------
@@ -75,21 +138,29 @@ func NewClient(vaultConfig *config.VaultConfig) (*VaultClient, error) {
 		return nil, fmt.Errorf("failed to create vault client: %w", err)
 	}
 
-	// Create and execute the appropriate authenticator
+	// NewClient fills in the default HTTP client; wrap its transport so
+	// every request, including authentication, is instrumented.
+	recorder := newRequestRecorder(cfg.HttpClient.Transport)
+	cfg.HttpClient.Transport = recorder
+
+	// Create the appropriate authenticator
 	authenticator, err := CreateAuthenticator(&vaultConfig.Auth)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create authenticator: %w", err)
 	}
 
-	if err := authenticator.Authenticate(client); err != nil {
-		return nil, fmt.Errorf("failed to authenticate with vault: %w", err)
-	}
-
 	pkiMount := vaultConfig.PKIMount
 	if pkiMount == "" {
 		pkiMount = "pki"
 	}
 
+	addresses := append([]string{vaultConfig.Address}, vaultConfig.Addresses...)
+	retry := NewRetryPolicy(&vaultConfig.Retry)
+	if retry.maxAttempts < len(addresses) {
+		// Allow at least one attempt against every configured address
+		retry.maxAttempts = len(addresses)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	vc := &VaultClient{
@@ -97,16 +168,44 @@ func NewClient(vaultConfig *config.VaultConfig) (*VaultClient, error) {
 		pkiMount:      pkiMount,
 		authenticator: authenticator,
 		authConfig:    &vaultConfig.Auth,
+		retry:         retry,
+		addresses:     addresses,
+		recorder:      recorder,
 		ctx:           ctx,
 		cancel:        cancel,
 	}
 
+	if err := vc.do("auth", vc.authenticate); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to authenticate with vault: %w", err)
+	}
+
 	// Start token renewal goroutine
 	go vc.tokenRenewalLoop()
 
 	return vc, nil
 }
 
+// Stats returns cumulative operation counters.
+func (v *VaultClient) Stats() Stats {
+	v.addrMu.Lock()
+	failovers := v.failovers
+	v.addrMu.Unlock()
+
+	skew, sampled := v.recorder.clockSkew()
+	return Stats{
+		Retries:          v.retry.Retries(),
+		Failovers:        failovers,
+		Requests:         v.recorder.snapshot(),
+		AuthSuccesses:    v.authSuccesses.Load(),
+		AuthFailures:     v.authFailures.Load(),
+		ClockSkew:        skew,
+		ClockSkewSampled: sampled,
+		LastAuth:         unixNanoTime(v.lastAuth.Load()),
+		TokenExpiry:      unixNanoTime(v.tokenExpiry.Load()),
+	}
+}
+
 // Close stops the token renewal goroutine.
 func (v *VaultClient) Close() {
 	v.cancel()
@@ -147,16 +246,43 @@ func (v *VaultClient) renewToken() error {
 		return fmt.Errorf("empty response from token renewal")
 	}
 
+	v.setTokenTTL(time.Duration(secret.Auth.LeaseDuration) * time.Second)
 	slog.Info("Successfully renewed Vault token", "ttl", secret.Auth.LeaseDuration)
 	return nil
 }
 
+// authenticate runs a single authentication attempt and counts its outcome.
+func (v *VaultClient) authenticate() error {
+	if err := v.authenticator.Authenticate(v.client); err != nil {
+		v.authFailures.Add(1)
+		return err
+	}
+	v.authSuccesses.Add(1)
+	v.lastAuth.Store(time.Now().UnixNano())
+
+	// A static token's TTL is not known until it is first renewed.
+	if leaser, ok := v.authenticator.(TokenLeaser); ok {
+		v.setTokenTTL(leaser.TokenTTL())
+	}
+	return nil
+}
+
+// setTokenTTL records when the current token expires given its TTL. Zero
+// marks a token that does not expire.
+func (v *VaultClient) setTokenTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		v.tokenExpiry.Store(0)
+		return
+	}
+	v.tokenExpiry.Store(time.Now().Add(ttl).UnixNano())
+}
+
 // reAuthenticate performs a fresh authentication with Vault.
 func (v *VaultClient) reAuthenticate() error {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
-	if err := v.authenticator.Authenticate(v.client); err != nil {
+	if err := v.do("auth", v.authenticate); err != nil {
 		return fmt.Errorf("re-authentication failed: %w", err)
 	}
 
@@ -168,13 +294,258 @@ func (v *VaultClient) reAuthenticate() error {
 // METHODS
 // -------------------------------------------------------------------------
 
-// IssueCertificate requests a new certificate from Vault PKI.
+// Error returns the underlying error, which names the correlation ID.
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// IssueCertificate requests a new certificate from Vault PKI, reads the
+// pre-issued certificate from KV for certificates with source "kv", or
+// reads the CA chain for entries with source "ca_bundle".
 func (v *VaultClient) IssueCertificate(certConfig *config.CertificateConfig) (*CertificateData, error) {
 	v.mu.RLock()
 	defer v.mu.RUnlock()
 
-	path := fmt.Sprintf("%s/issue/%s", v.pkiMount, certConfig.Role)
+	if certConfig.IsKVSource() {
+		return v.readKVCertificate(certConfig)
+	}
+	if certConfig.IsCABundleSource() {
+		return v.readCABundle(certConfig)
+	}
+
+	return v.requestCertificate("issue", certConfig, issuePath(v.pkiMount, certConfig), buildIssueRequest(certConfig), parseIssueResponse)
+}
+
+// SignCertificate has the PKI role sign csrPEM, for certificates that keep
+// their private key across renewals. The returned data holds no private
+// key.
+func (v *VaultClient) SignCertificate(certConfig *config.CertificateConfig, csrPEM string) (*CertificateData, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	data := buildIssueRequest(certConfig)
+	delete(data, "key_type")
+	delete(data, "key_bits")
+	delete(data, "private_key_format")
+	data["csr"] = csrPEM
+	return v.requestCertificate("sign", certConfig, signPath(v.pkiMount, certConfig), data, parseSignResponse)
+}
+
+// FetchCAChain reads the PKI mount's current CA chain as PEM, issuing CA
+// first. When the certificate pins an issuer_ref, that issuer's chain is
+// read instead of the mount default's.
+func (v *VaultClient) FetchCAChain(certConfig *config.CertificateConfig) (string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.fetchCAChain(certConfig)
+}
+
+// fetchCAChain reads the CA chain for FetchCAChain. Callers hold v.mu.
+func (v *VaultClient) fetchCAChain(certConfig *config.CertificateConfig) (string, error) {
+	path := v.pkiMount + "/cert/ca_chain"
+	if certConfig.IssuerRef != "" {
+		path = fmt.Sprintf("%s/issuer/%s/json", v.pkiMount, certConfig.IssuerRef)
+	}
+
+	var resp *api.Secret
+	err := v.do("ca_chain", func() error {
+		var err error
+		resp, err = v.client.Logical().Read(path)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to read CA chain from %s: %w", path, err)
+	}
+
+	if resp == nil || resp.Data == nil {
+		return "", fmt.Errorf("empty CA chain response from %s", path)
+	}
+
+	var chain string
+	switch c := resp.Data["ca_chain"].(type) {
+	case []interface{}:
+		var parts []string
+		for _, part := range c {
+			if s, ok := part.(string); ok {
+				parts = append(parts, strings.TrimSpace(s))
+			}
+		}
+		chain = strings.Join(parts, "\n")
+	default:
+		chain, _ = resp.Data["certificate"].(string)
+	}
+	if chain == "" {
+		return "", fmt.Errorf("no CA chain configured at %s", path)
+	}
+	return strings.TrimSpace(chain), nil
+}
+
+// requestCertificate writes an issue or sign request to path and parses
+// the response with parse. Callers hold v.mu.
+func (v *VaultClient) requestCertificate(op string, certConfig *config.CertificateConfig, path string, data map[string]interface{}, parse func(map[string]interface{}) (*CertificateData, error)) (*CertificateData, error) {
+	correlationID := newCorrelationID()
+
+	slog.Info("Requesting certificate from Vault",
+		"certificate", certConfig.Name,
+		"path", path,
+		"correlation_id", correlationID)
+
+	var resp *api.Secret
+	err := v.do(op, func() error {
+		// Clone per attempt so a failover's address change is picked up.
+		client := v.client.WithRequestCallbacks(func(req *api.Request) {
+			req.Headers.Set(CorrelationHeader, correlationID)
+		})
+		var err error
+		resp, err = client.Logical().Write(path, data)
+		return err
+	})
+	if err != nil {
+		return nil, &RequestError{
+			CorrelationID: correlationID,
+			Err:           fmt.Errorf("failed to %s certificate from vault (correlation_id=%s): %w", op, correlationID, err),
+		}
+	}
+
+	if resp == nil || resp.Data == nil {
+		return nil, &RequestError{
+			CorrelationID: correlationID,
+			Err:           fmt.Errorf("empty response from vault (correlation_id=%s)", correlationID),
+		}
+	}
+
+	slog.Debug("Vault issued certificate",
+		"certificate", certConfig.Name,
+		"correlation_id", correlationID,
+		"vault_request_id", resp.RequestID)
+
+	certData, err := parse(resp.Data)
+	if err != nil {
+		return nil, &RequestError{CorrelationID: correlationID, VaultRequestID: resp.RequestID, Err: err}
+	}
+
+	certData.CorrelationID = correlationID
+	certData.VaultRequestID = resp.RequestID
+	warnSubjectMismatch(certConfig, certData)
+	return certData, nil
+}
+
+// readKVCertificate reads pre-issued certificate material from a KV v2
+// secret, pinned to the configured version when set. Callers hold v.mu.
+func (v *VaultClient) readKVCertificate(certConfig *config.CertificateConfig) (*CertificateData, error) {
+	kv := certConfig.KV
+	correlationID := newCorrelationID()
+
+	slog.Info("Reading certificate from Vault KV",
+		"certificate", certConfig.Name,
+		"path", kv.Mount+"/"+kv.Path,
+		"version", kv.Version,
+		"correlation_id", correlationID)
+
+	var secret *api.KVSecret
+	err := v.do("kv", func() error {
+		client := v.client.WithRequestCallbacks(func(req *api.Request) {
+			req.Headers.Set(CorrelationHeader, correlationID)
+		})
+		var err error
+		if kv.Version > 0 {
+			secret, err = client.KVv2(kv.Mount).GetVersion(v.ctx, kv.Path, kv.Version)
+		} else {
+			secret, err = client.KVv2(kv.Mount).Get(v.ctx, kv.Path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, &RequestError{
+			CorrelationID: correlationID,
+			Err:           fmt.Errorf("failed to read certificate from %s/%s (correlation_id=%s): %w", kv.Mount, kv.Path, correlationID, err),
+		}
+	}
+
+	certData, err := parseKVCertificate(secret.Data)
+	if err != nil {
+		reqErr := &RequestError{CorrelationID: correlationID, Err: fmt.Errorf("%s/%s: %w", kv.Mount, kv.Path, err)}
+		if secret.Raw != nil {
+			reqErr.VaultRequestID = secret.Raw.RequestID
+		}
+		return nil, reqErr
+	}
+
+	certData.CorrelationID = correlationID
+	if secret.Raw != nil {
+		certData.VaultRequestID = secret.Raw.RequestID
+	}
+	return certData, nil
+}
+
+// readCABundle reads the CA chain as the certificate material of a CA
+// bundle entry. Callers hold v.mu.
+func (v *VaultClient) readCABundle(certConfig *config.CertificateConfig) (*CertificateData, error) {
+	correlationID := newCorrelationID()
+
+	slog.Debug("Reading CA bundle from Vault",
+		"certificate", certConfig.Name,
+		"issuer_ref", certConfig.IssuerRef,
+		"correlation_id", correlationID)
+
+	chain, err := v.fetchCAChain(certConfig)
+	if err != nil {
+		return nil, &RequestError{CorrelationID: correlationID, Err: fmt.Errorf("%w (correlation_id=%s)", err, correlationID)}
+	}
+	return &CertificateData{Certificate: chain + "\n", CorrelationID: correlationID}, nil
+}
+
+// ReadKV reads the data of a KV secret. For version 2 mounts the latest
+// secret version is returned.
+func (v *VaultClient) ReadKV(ctx context.Context, mount, path string, version int) (map[string]interface{}, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	var secret *api.KVSecret
+	err := v.do("kv", func() error {
+		var err error
+		if version == 1 {
+			secret, err = v.client.KVv1(mount).Get(ctx, path)
+		} else {
+			secret, err = v.client.KVv2(mount).Get(ctx, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s/%s from vault: %w", mount, path, err)
+	}
+
+	return secret.Data, nil
+}
 
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// issuePath returns the PKI issue endpoint for a certificate, scoped to its
+// issuer_ref when one is set.
+func issuePath(pkiMount string, certConfig *config.CertificateConfig) string {
+	if certConfig.IssuerRef != "" {
+		return fmt.Sprintf("%s/issuer/%s/issue/%s", pkiMount, certConfig.IssuerRef, certConfig.Role)
+	}
+	return fmt.Sprintf("%s/issue/%s", pkiMount, certConfig.Role)
+}
+
+// signPath returns the PKI sign endpoint for a certificate.
+func signPath(pkiMount string, certConfig *config.CertificateConfig) string {
+	if certConfig.IssuerRef != "" {
+		return fmt.Sprintf("%s/issuer/%s/sign/%s", pkiMount, certConfig.IssuerRef, certConfig.Role)
+	}
+	return fmt.Sprintf("%s/sign/%s", pkiMount, certConfig.Role)
+}
+
+// buildIssueRequest assembles the PKI issue request body for a certificate.
+func buildIssueRequest(certConfig *config.CertificateConfig) map[string]interface{} {
 	data := map[string]interface{}{
 		"common_name": certConfig.CommonName,
 		"format":      "pem",
@@ -200,27 +571,64 @@ func (v *VaultClient) IssueCertificate(certConfig *config.CertificateConfig) (*C
 		}
 	}
 
-	resp, err := v.client.Logical().Write(path, data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to issue certificate from vault: %w", err)
+	if len(certConfig.URISans) > 0 {
+		data["uri_sans"] = strings.Join(certConfig.URISans, ",")
+	}
+	if len(certConfig.OtherSans) > 0 {
+		data["other_sans"] = strings.Join(certConfig.OtherSans, ",")
 	}
 
-	if resp == nil || resp.Data == nil {
-		return nil, fmt.Errorf("empty response from vault")
+	subject := map[string][]string{
+		"ou":           certConfig.OU,
+		"organization": certConfig.Organization,
+		"country":      certConfig.Country,
+		"locality":     certConfig.Locality,
+		"postal_code":  certConfig.PostalCode,
+	}
+	for field, values := range subject {
+		if len(values) > 0 {
+			data[field] = strings.Join(values, ",")
+		}
 	}
 
-	certificate, ok := resp.Data["certificate"].(string)
-	if !ok || certificate == "" {
-		return nil, fmt.Errorf("certificate not found in vault response")
+	if certConfig.KeyType != "" {
+		data["key_type"] = certConfig.KeyType
+	}
+	if certConfig.KeyBits > 0 {
+		data["key_bits"] = certConfig.KeyBits
+	}
+	if certConfig.PrivateKeyFormat != "" {
+		data["private_key_format"] = certConfig.PrivateKeyFormat
 	}
 
-	privateKey, ok := resp.Data["private_key"].(string)
+	return data
+}
+
+// parseIssueResponse extracts certificate material from a PKI issue response.
+func parseIssueResponse(data map[string]interface{}) (*CertificateData, error) {
+	privateKey, ok := data["private_key"].(string)
 	if !ok || privateKey == "" {
 		return nil, fmt.Errorf("private_key not found in vault response")
 	}
 
+	certData, err := parseSignResponse(data)
+	if err != nil {
+		return nil, err
+	}
+	certData.PrivateKey = privateKey
+	return certData, nil
+}
+
+// parseSignResponse extracts the certificate, chain, serial, and expiry
+// from a PKI issue or sign response.
+func parseSignResponse(data map[string]interface{}) (*CertificateData, error) {
+	certificate, ok := data["certificate"].(string)
+	if !ok || certificate == "" {
+		return nil, fmt.Errorf("certificate not found in vault response")
+	}
+
 	var certificateChain string
-	if chain, ok := resp.Data["ca_chain"]; ok {
+	if chain, ok := data["ca_chain"]; ok {
 		if chainSlice, ok := chain.([]interface{}); ok {
 			var chainParts []string
 			for _, part := range chainSlice {
@@ -235,23 +643,136 @@ func (v *VaultClient) IssueCertificate(certConfig *config.CertificateConfig) (*C
 	}
 
 	if certificateChain == "" {
-		if issuingCA, ok := resp.Data["issuing_ca"].(string); ok && issuingCA != "" {
+		if issuingCA, ok := data["issuing_ca"].(string); ok && issuingCA != "" {
 			certificateChain = issuingCA
 		}
 	}
 
-	serialNumber, _ := resp.Data["serial_number"].(string)
+	serialNumber, _ := data["serial_number"].(string)
 
 	var expiration time.Time
-	if exp, ok := resp.Data["expiration"].(int64); ok {
+	switch exp := data["expiration"].(type) {
+	case int64:
 		expiration = time.Unix(exp, 0)
+	case json.Number:
+		if n, err := exp.Int64(); err == nil {
+			expiration = time.Unix(n, 0)
+		}
 	}
 
 	return &CertificateData{
 		Certificate:      certificate,
-		PrivateKey:       privateKey,
 		CertificateChain: certificateChain,
 		SerialNumber:     serialNumber,
 		Expiration:       expiration,
 	}, nil
 }
+
+// parseKVCertificate extracts pre-issued certificate material from KV
+// secret data. The serial and expiration are taken from the certificate.
+func parseKVCertificate(data map[string]interface{}) (*CertificateData, error) {
+	certificate, _ := data["certificate"].(string)
+	if certificate == "" {
+		return nil, fmt.Errorf("certificate not found in kv secret")
+	}
+
+	privateKey, _ := data["private_key"].(string)
+	if privateKey == "" {
+		return nil, fmt.Errorf("private_key not found in kv secret")
+	}
+
+	block, _ := pem.Decode([]byte(certificate))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("certificate in kv secret is not PEM encoded")
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate in kv secret: %w", err)
+	}
+
+	var chain string
+	switch c := data["ca_chain"].(type) {
+	case string:
+		chain = strings.TrimSpace(c)
+	case []interface{}:
+		var parts []string
+		for _, part := range c {
+			if s, ok := part.(string); ok {
+				parts = append(parts, strings.TrimSpace(s))
+			}
+		}
+		chain = strings.Join(parts, "\n")
+	}
+
+	return &CertificateData{
+		Certificate:      strings.TrimSpace(certificate),
+		PrivateKey:       strings.TrimSpace(privateKey),
+		CertificateChain: chain,
+		SerialNumber:     FormatSerial(leaf.SerialNumber.Bytes()),
+		Expiration:       leaf.NotAfter,
+	}, nil
+}
+
+// warnSubjectMismatch logs requested subject fields that are missing from
+// the issued certificate, which happens when the role does not allow them.
+func warnSubjectMismatch(certConfig *config.CertificateConfig, certData *CertificateData) {
+	block, _ := pem.Decode([]byte(certData.Certificate))
+	if block == nil {
+		return
+	}
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return
+	}
+
+	fields := []struct {
+		name      string
+		requested []string
+		issued    []string
+	}{
+		{"ou", certConfig.OU, leaf.Subject.OrganizationalUnit},
+		{"organization", certConfig.Organization, leaf.Subject.Organization},
+		{"country", certConfig.Country, leaf.Subject.Country},
+		{"locality", certConfig.Locality, leaf.Subject.Locality},
+		{"postal_code", certConfig.PostalCode, leaf.Subject.PostalCode},
+	}
+	for _, field := range fields {
+		for _, value := range field.requested {
+			if !slices.Contains(field.issued, value) {
+				slog.Warn("Issued certificate is missing a requested subject field; check the role allows it",
+					"certificate", certConfig.Name,
+					"field", field.name,
+					"requested", field.requested,
+					"issued", field.issued)
+				break
+			}
+		}
+	}
+}
+
+// FormatSerial renders a serial number the way Vault does (colon-separated hex).
+func FormatSerial(serial []byte) string {
+	parts := make([]string, len(serial))
+	for i, b := range serial {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// newCorrelationID returns a random identifier for joining agent and Vault audit events.
+func newCorrelationID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// unixNanoTime converts unix nanoseconds to a time, keeping zero as the
+// zero time.
+func unixNanoTime(nanos int64) time.Time {
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos)
+}
@@ -0,0 +1,44 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Issue Response Fuzz Tests
+//
+// Native Go fuzz tests for parsing PKI issue responses and CA chains.
+// Run with: go test -fuzz=FuzzParseIssueResponse ./pkg/vault
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// -------------------------------------------------------------------------
+// FUZZ TESTS
+// -------------------------------------------------------------------------
+
+// FuzzParseIssueResponse verifies arbitrary response bodies never panic the parser.
+func FuzzParseIssueResponse(f *testing.F) {
+	f.Add([]byte(`{"certificate":"c","private_key":"k","ca_chain":["a","b"],"serial_number":"01","expiration":1700000000}`))
+	f.Add([]byte(`{"certificate":"c","private_key":"k","issuing_ca":"ca"}`))
+	f.Add([]byte(`{"certificate":1,"private_key":null,"ca_chain":[1,{"x":2}]}`))
+	f.Add([]byte(`{"ca_chain":"not-a-list","expiration":"soon"}`))
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		var data map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return
+		}
+
+		certData, err := parseIssueResponse(data)
+		if err == nil && (certData.Certificate == "" || certData.PrivateKey == "") {
+			t.Fatal("parsed response missing certificate or key without error")
+		}
+	})
+}
@@ -12,6 +12,17 @@ package vault
 
 import (
 	"cert-manager/pkg/config"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 )
@@ -120,3 +131,559 @@ func TestCertificateDataValidation(t *testing.T) {
 		t.Error("expiration should be in the future")
 	}
 }
+
+// TestBuildIssueRequest verifies key parameters are passed through to Vault.
+func TestBuildIssueRequest(t *testing.T) {
+	data := buildIssueRequest(&config.CertificateConfig{
+		CommonName:       "test.example.com",
+		TTL:              time.Hour,
+		IPSans:           []string{"127.0.0.1", "not-an-ip"},
+		KeyType:          "ec",
+		KeyBits:          384,
+		PrivateKeyFormat: "pkcs8",
+	})
+
+	if data["key_type"] != "ec" {
+		t.Errorf("expected key_type ec, got %v", data["key_type"])
+	}
+	if data["key_bits"] != 384 {
+		t.Errorf("expected key_bits 384, got %v", data["key_bits"])
+	}
+	if data["private_key_format"] != "pkcs8" {
+		t.Errorf("expected private_key_format pkcs8, got %v", data["private_key_format"])
+	}
+	if data["ip_sans"] != "127.0.0.1" {
+		t.Errorf("expected invalid IP SANs to be dropped, got %v", data["ip_sans"])
+	}
+
+	data = buildIssueRequest(&config.CertificateConfig{CommonName: "test.example.com"})
+	if _, ok := data["key_type"]; ok {
+		t.Error("key_type should be omitted when not configured")
+	}
+	if _, ok := data["organization"]; ok {
+		t.Error("organization should be omitted when not configured")
+	}
+}
+
+// TestBuildIssueRequest_Subject verifies subject fields and extra SANs are
+// passed through comma-separated.
+func TestBuildIssueRequest_Subject(t *testing.T) {
+	data := buildIssueRequest(&config.CertificateConfig{
+		CommonName:   "test.example.com",
+		URISans:      []string{"spiffe://example.com/web", "https://example.com"},
+		OtherSans:    []string{"1.3.6.1.4.1.311.20.2.3;UTF8:web@example.com"},
+		OU:           []string{"Platform", "Security"},
+		Organization: []string{"Example Inc"},
+		Country:      []string{"US"},
+		Locality:     []string{"Portland"},
+		PostalCode:   []string{"97201"},
+	})
+
+	expected := map[string]string{
+		"uri_sans":     "spiffe://example.com/web,https://example.com",
+		"other_sans":   "1.3.6.1.4.1.311.20.2.3;UTF8:web@example.com",
+		"ou":           "Platform,Security",
+		"organization": "Example Inc",
+		"country":      "US",
+		"locality":     "Portland",
+		"postal_code":  "97201",
+	}
+	for field, want := range expected {
+		if data[field] != want {
+			t.Errorf("expected %s %q, got %v", field, want, data[field])
+		}
+	}
+}
+
+// TestIssuePath verifies issuance is scoped to the pinned issuer.
+func TestIssuePath(t *testing.T) {
+	certConfig := &config.CertificateConfig{Role: "web"}
+	if path := issuePath("pki", certConfig); path != "pki/issue/web" {
+		t.Errorf("unexpected default path %s", path)
+	}
+
+	certConfig.IssuerRef = "root-2024"
+	if path := issuePath("pki", certConfig); path != "pki/issuer/root-2024/issue/web" {
+		t.Errorf("unexpected issuer path %s", path)
+	}
+}
+
+// TestIssueCertificate_CorrelationID verifies each issuance carries a correlation header.
+func TestIssueCertificate_CorrelationID(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(CorrelationHeader)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"request_id": "vault-req-1",
+			"data": map[string]interface{}{
+				"certificate":   "cert",
+				"private_key":   "key",
+				"serial_number": "01:02",
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	data, err := client.IssueCertificate(&config.CertificateConfig{Name: "test", Role: "web", CommonName: "test.example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received == "" || received != data.CorrelationID {
+		t.Errorf("expected header %q to match correlation ID %q", received, data.CorrelationID)
+	}
+	if data.VaultRequestID != "vault-req-1" {
+		t.Errorf("expected vault request ID, got %q", data.VaultRequestID)
+	}
+}
+
+// TestIssueCertificate_CorrelationIDOnFailure verifies failed issuances
+// return the correlation ID sent, and Vault's request ID once it responded.
+func TestIssueCertificate_CorrelationIDOnFailure(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(CorrelationHeader)
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/pki/issue/denied" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"errors": []string{"common name not allowed"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"request_id": "vault-req-2",
+			"data":       map[string]interface{}{"serial_number": "01:02"},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	tests := []struct {
+		role           string
+		vaultRequestID string
+	}{
+		{"denied", ""},
+		{"malformed", "vault-req-2"},
+	}
+	for _, tt := range tests {
+		_, err := client.IssueCertificate(&config.CertificateConfig{Name: "test", Role: tt.role, CommonName: "test.example.com"})
+		var reqErr *RequestError
+		if !errors.As(err, &reqErr) {
+			t.Fatalf("%s: expected a RequestError, got %v", tt.role, err)
+		}
+		if received == "" || reqErr.CorrelationID != received {
+			t.Errorf("%s: expected correlation ID %q, got %q", tt.role, received, reqErr.CorrelationID)
+		}
+		if reqErr.VaultRequestID != tt.vaultRequestID {
+			t.Errorf("%s: expected vault request ID %q, got %q", tt.role, tt.vaultRequestID, reqErr.VaultRequestID)
+		}
+	}
+}
+
+// TestIssueCertificate_Failover verifies requests move to the next HA address
+// when the current one is unreachable.
+func TestIssueCertificate_Failover(t *testing.T) {
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadURL := dead.URL
+	dead.Close()
+
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"certificate": "cert", "private_key": "key"},
+		})
+	}))
+	defer live.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address:   deadURL,
+		Addresses: []string{live.URL},
+		Auth:      config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+	client.client.SetMaxRetries(0)
+
+	if _, err := client.IssueCertificate(&config.CertificateConfig{Name: "test", Role: "web", CommonName: "test.example.com"}); err != nil {
+		t.Fatalf("expected failover to succeed: %v", err)
+	}
+
+	if client.Stats().Failovers != 1 {
+		t.Errorf("expected 1 failover, got %d", client.Stats().Failovers)
+	}
+	if client.client.Address() != live.URL {
+		t.Errorf("expected client to stay on live address, got %s", client.client.Address())
+	}
+}
+
+// TestVaultClient_RequestStats verifies requests and authentication attempts
+// are counted per path.
+func TestVaultClient_RequestStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/pki/issue/denied" {
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {"permission denied"}})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"certificate": "cert", "private_key": "key"},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	for _, role := range []string{"web", "web", "denied"} {
+		_, _ = client.IssueCertificate(&config.CertificateConfig{Name: role, Role: role, CommonName: "test.example.com"})
+	}
+
+	stats := client.Stats()
+	if stats.AuthSuccesses != 1 || stats.AuthFailures != 0 {
+		t.Errorf("expected 1 successful auth, got %d successes and %d failures", stats.AuthSuccesses, stats.AuthFailures)
+	}
+
+	web := stats.Requests["pki/issue/web"]
+	if web.Count != 2 || web.Errors != 0 {
+		t.Errorf("expected 2 successful web requests, got %+v", web)
+	}
+	if web.Buckets[RequestDurationBuckets[len(RequestDurationBuckets)-1]] != 2 {
+		t.Errorf("expected both requests in the largest bucket, got %v", web.Buckets)
+	}
+
+	denied := stats.Requests["pki/issue/denied"]
+	if denied.Count != 1 || denied.Errors != 1 {
+		t.Errorf("expected 1 failed denied request, got %+v", denied)
+	}
+}
+
+// TestVaultClient_FetchCAChain verifies the CA chain is read from the PKI mount.
+func TestVaultClient_FetchCAChain(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"certificate": "intermediate\nroot\n"},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address:  server.URL,
+		PKIMount: "pki_int",
+		Auth:     config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	chain, err := client.FetchCAChain(&config.CertificateConfig{Name: "test"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/v1/pki_int/cert/ca_chain" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if chain != "intermediate\nroot" {
+		t.Errorf("unexpected chain %q", chain)
+	}
+}
+
+// TestIssueCertificate_CABundleSource verifies CA bundle entries read the
+// CA chain as their certificate, with no key.
+func TestIssueCertificate_CABundleSource(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"certificate": "intermediate\nroot\n"},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	data, err := client.IssueCertificate(&config.CertificateConfig{Name: "internal-ca", Source: "ca_bundle"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/v1/pki/cert/ca_chain" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if data.Certificate != "intermediate\nroot\n" || data.PrivateKey != "" {
+		t.Errorf("unexpected certificate data %+v", data)
+	}
+}
+
+// TestVaultClient_FetchCAChain_IssuerRef verifies a pinned issuer's chain is
+// read from its issuer endpoint.
+func TestVaultClient_FetchCAChain_IssuerRef(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate": "intermediate",
+				"ca_chain":    []string{"intermediate\n", "root\n"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	chain, err := client.FetchCAChain(&config.CertificateConfig{Name: "test", IssuerRef: "int-2025"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/v1/pki/issuer/int-2025/json" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if chain != "intermediate\nroot" {
+		t.Errorf("unexpected chain %q", chain)
+	}
+}
+
+// TestVaultClient_Stats_ClockSkew verifies skew is measured from Vault's
+// response Date header.
+func TestVaultClient_Stats_ClockSkew(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(-2*time.Minute).UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"certificate": "ca", "ca_chain": []string{"ca"}},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	if stats := client.Stats(); stats.ClockSkewSampled {
+		t.Fatal("expected no skew sample before any request")
+	}
+	if _, err := client.FetchCAChain(&config.CertificateConfig{Name: "test"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stats := client.Stats()
+	if !stats.ClockSkewSampled {
+		t.Fatal("expected a skew sample")
+	}
+	if stats.ClockSkew < 119*time.Second || stats.ClockSkew > 121*time.Second {
+		t.Errorf("expected about 2m of skew, got %v", stats.ClockSkew)
+	}
+}
+
+// TestIssueCertificate_KVSource verifies pre-issued certificates are read
+// from the pinned KV v2 version instead of the PKI mount.
+func TestIssueCertificate_KVSource(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x0a0b),
+		Subject:      pkix.Name{CommonName: "*.example.com"},
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path + "?" + r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"request_id": "kv-req-1",
+			"data": map[string]interface{}{
+				"data": map[string]interface{}{
+					"certificate": certPEM,
+					"private_key": "key",
+					"ca_chain":    "chain\n",
+				},
+				"metadata": map[string]interface{}{"version": 3},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	data, err := client.IssueCertificate(&config.CertificateConfig{
+		Name:   "wildcard",
+		Source: "kv",
+		KV:     &config.CertKVSource{Mount: "secret", Path: "certs/wildcard", Version: 3},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requested != "/v1/secret/data/certs/wildcard?version=3" {
+		t.Errorf("unexpected request %s", requested)
+	}
+	if data.SerialNumber != "0a:0b" {
+		t.Errorf("expected serial from certificate, got %q", data.SerialNumber)
+	}
+	if data.CertificateChain != "chain" || data.VaultRequestID != "kv-req-1" {
+		t.Errorf("unexpected certificate data %+v", data)
+	}
+}
+
+// TestSignCertificate verifies CSRs are posted to the role's sign endpoint
+// without key parameters and the response needs no private key.
+func TestSignCertificate(t *testing.T) {
+	var path string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"certificate":   "cert",
+				"issuing_ca":    "ca",
+				"serial_number": "01:02",
+				"expiration":    time.Now().Add(time.Hour).Unix(),
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{Token: &config.TokenAuth{Value: "test-token"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	data, err := client.SignCertificate(&config.CertificateConfig{
+		Name:       "pinned",
+		Role:       "web",
+		CommonName: "example.com",
+		KeyType:    "rsa",
+		KeyBits:    4096,
+	}, "csr-pem")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if path != "/v1/pki/sign/web" {
+		t.Errorf("unexpected path %s", path)
+	}
+	if body["csr"] != "csr-pem" || body["common_name"] != "example.com" {
+		t.Errorf("unexpected request %v", body)
+	}
+	if _, ok := body["key_type"]; ok {
+		t.Error("expected no key_type in a sign request")
+	}
+	if data.Certificate != "cert" || data.PrivateKey != "" || data.SerialNumber != "01:02" {
+		t.Errorf("unexpected certificate data %+v", data)
+	}
+}
+
+// TestVaultClient_Stats_TokenLifetime verifies the login time and token
+// expiry are tracked from the login response and updated on renewal.
+func TestVaultClient_Stats_TokenLifetime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		lease := 3600
+		if r.URL.Path == "/v1/auth/token/renew-self" {
+			lease = 7200
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": "login-token", "lease_duration": lease},
+		})
+	}))
+	defer server.Close()
+
+	before := time.Now()
+	client, err := NewClient(&config.VaultConfig{
+		Address: server.URL,
+		Auth:    config.AuthConfig{AppRole: &config.AppRoleAuth{RoleID: "role", SecretID: "secret"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	stats := client.Stats()
+	if stats.LastAuth.Before(before) {
+		t.Errorf("expected the login to be recorded, got %v", stats.LastAuth)
+	}
+	if ttl := time.Until(stats.TokenExpiry); ttl < 59*time.Minute || ttl > time.Hour {
+		t.Errorf("expected the token to expire in about an hour, got %v", ttl)
+	}
+
+	if err := client.renewToken(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl := time.Until(client.Stats().TokenExpiry); ttl < 119*time.Minute || ttl > 2*time.Hour {
+		t.Errorf("expected the renewed token to expire in about two hours, got %v", ttl)
+	}
+}
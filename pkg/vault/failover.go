@@ -0,0 +1,56 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - HA Failover
+//
+// Rotates the Vault client between configured cluster addresses when a
+// request fails with a transient error, so a single unreachable or sealed
+// node does not stall issuance. The token is cluster-wide and survives
+// the switch.
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"log/slog"
+)
+
+// -------------------------------------------------------------------------
+// PRIVATE METHODS
+// -------------------------------------------------------------------------
+
+// do runs fn under the retry policy, failing over to the next configured
+// address after each transient failure.
+func (v *VaultClient) do(op string, fn func() error) error {
+	return v.retry.Do(op, func() error {
+		err := fn()
+		if err != nil && isRetryable(err) {
+			v.failover()
+		}
+		return err
+	})
+}
+
+// failover switches the client to the next configured address.
+func (v *VaultClient) failover() {
+	v.addrMu.Lock()
+	defer v.addrMu.Unlock()
+
+	if len(v.addresses) < 2 {
+		return
+	}
+
+	from := v.addresses[v.addrIndex]
+	v.addrIndex = (v.addrIndex + 1) % len(v.addresses)
+	to := v.addresses[v.addrIndex]
+
+	if err := v.client.SetAddress(to); err != nil {
+		slog.Error("Failed to switch Vault address", "address", to, "error", err)
+		return
+	}
+
+	v.failovers++
+	slog.Warn("Failing over to next Vault address", "from", from, "to", to)
+}
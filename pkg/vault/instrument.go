@@ -0,0 +1,215 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Vault Request Instrumentation
+//
+// HTTP transport wrapper that records per-path request counts, errors, and
+// latency for every call the Vault client makes, including logins, token
+// renewals, and unwraps. Counters are cumulative and read at scrape time
+// through Stats. Response Date headers are compared against the local
+// clock to detect skew, which silently shifts renewal thresholds.
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+// ClockSkewThreshold is the local/Vault clock difference that is logged as
+// an error.
+const ClockSkewThreshold = 30 * time.Second
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+// RequestDurationBuckets are the latency histogram upper bounds, in seconds.
+var RequestDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// RequestStats holds cumulative counters for one Vault API path.
+type RequestStats struct {
+	Count       uint64
+	Errors      uint64
+	DurationSum float64            // seconds
+	Buckets     map[float64]uint64 // cumulative counts keyed by RequestDurationBuckets
+}
+
+// requestRecorder is an http.RoundTripper that records request stats.
+type requestRecorder struct {
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	requests map[string]*RequestStats
+	skew     time.Duration // local clock minus Vault's, from the last response
+	sampled  bool
+	skewed   bool // skew exceeded ClockSkewThreshold at the last sample
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// newRequestRecorder wraps the given transport.
+func newRequestRecorder(next http.RoundTripper) *requestRecorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &requestRecorder{
+		next:     next,
+		requests: make(map[string]*RequestStats),
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// RoundTrip performs the request and records its outcome. Transport errors
+// and 4xx/5xx responses count as errors.
+func (r *requestRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := r.next.RoundTrip(req)
+	failed := err != nil || resp.StatusCode >= 400
+
+	end := time.Now()
+	r.observe(strings.TrimPrefix(req.URL.Path, "/v1/"), end.Sub(start), failed)
+	if err == nil {
+		r.observeDate(resp.Header.Get("Date"), start, end, req.URL.Host)
+	}
+	return resp, err
+}
+
+// Unwrap returns the underlying transport so callers that need to adjust
+// TLS settings can reach it.
+func (r *requestRecorder) Unwrap() http.RoundTripper {
+	return r.next
+}
+
+// snapshot returns a deep copy of the per-path stats. A nil recorder has
+// no stats.
+func (r *requestRecorder) snapshot() map[string]RequestStats {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string]RequestStats, len(r.requests))
+	for path, stats := range r.requests {
+		cp := *stats
+		cp.Buckets = make(map[float64]uint64, len(stats.Buckets))
+		for bound, n := range stats.Buckets {
+			cp.Buckets[bound] = n
+		}
+		out[path] = cp
+	}
+	return out
+}
+
+// clockSkew returns the last measured skew, and whether any response has
+// carried a usable Date header. A nil recorder has no measurement.
+func (r *requestRecorder) clockSkew() (time.Duration, bool) {
+	if r == nil {
+		return 0, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.skew, r.sampled
+}
+
+// observeDate measures clock skew from a response Date header. The header
+// has one-second resolution, so the server time is taken as the middle of
+// its second and compared with the middle of the request. Crossing the
+// threshold is logged once in each direction.
+func (r *requestRecorder) observeDate(header string, start, end time.Time, host string) {
+	if header == "" {
+		return
+	}
+	server, err := http.ParseTime(header)
+	if err != nil {
+		return
+	}
+
+	local := start.Add(end.Sub(start) / 2)
+	skew := local.Sub(server.Add(500 * time.Millisecond)).Round(time.Second)
+	exceeded := skew.Abs() >= ClockSkewThreshold
+
+	r.mu.Lock()
+	changed := exceeded != r.skewed
+	r.skew, r.sampled, r.skewed = skew, true, exceeded
+	r.mu.Unlock()
+
+	switch {
+	case changed && exceeded:
+		slog.Error("Local clock differs from Vault; renewal timing is unreliable until the clock is fixed",
+			"vault", host,
+			"skew", skew,
+			"threshold", ClockSkewThreshold)
+	case changed:
+		slog.Info("Local clock is back in sync with Vault",
+			"vault", host,
+			"skew", skew)
+	}
+}
+
+// observe records one request against a path.
+func (r *requestRecorder) observe(path string, duration time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.requests[path]
+	if !ok {
+		stats = &RequestStats{Buckets: make(map[float64]uint64, len(RequestDurationBuckets))}
+		r.requests[path] = stats
+	}
+
+	seconds := duration.Seconds()
+	stats.Count++
+	stats.DurationSum += seconds
+	if failed {
+		stats.Errors++
+	}
+	for _, bound := range RequestDurationBuckets {
+		if seconds <= bound {
+			stats.Buckets[bound]++
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// mergeRequestStats adds src into dst.
+func mergeRequestStats(dst map[string]RequestStats, src map[string]RequestStats) {
+	for path, stats := range src {
+		merged := dst[path]
+		merged.Count += stats.Count
+		merged.Errors += stats.Errors
+		merged.DurationSum += stats.DurationSum
+		if merged.Buckets == nil {
+			merged.Buckets = make(map[float64]uint64, len(stats.Buckets))
+		}
+		for bound, n := range stats.Buckets {
+			merged.Buckets[bound] += n
+		}
+		dst[path] = merged
+	}
+}
@@ -0,0 +1,150 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Retry Policy
+//
+// Exponential backoff with jitter for Vault operations. Retries transient
+// failures (network errors, 5xx, 429) and gives up immediately on client
+// errors such as permission denied or unknown roles. Keeps per-operation
+// retry counters for metrics.
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// RetryPolicy retries operations with exponential backoff and jitter.
+type RetryPolicy struct {
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	jitter      float64
+	sleep       func(time.Duration)
+
+	mu      sync.Mutex
+	retries map[string]uint64
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewRetryPolicy creates a retry policy from configuration. A zero
+// max_attempts disables retries.
+func NewRetryPolicy(cfg *config.RetryConfig) *RetryPolicy {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	return &RetryPolicy{
+		maxAttempts: maxAttempts,
+		baseBackoff: cfg.BaseBackoff,
+		maxBackoff:  cfg.MaxBackoff,
+		jitter:      cfg.Jitter,
+		sleep:       time.Sleep,
+		retries:     make(map[string]uint64),
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Do runs fn until it succeeds, returns a non-retryable error, or the
+// attempt budget is exhausted.
+func (p *RetryPolicy) Do(op string, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= p.maxAttempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		if attempt == p.maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		backoff := p.backoff(attempt)
+		slog.Warn("Vault operation failed, retrying",
+			"operation", op,
+			"attempt", attempt,
+			"max_attempts", p.maxAttempts,
+			"backoff", backoff,
+			"error", err)
+
+		p.mu.Lock()
+		p.retries[op]++
+		p.mu.Unlock()
+
+		p.sleep(backoff)
+	}
+	return err
+}
+
+// Retries returns a copy of the per-operation retry counters.
+func (p *RetryPolicy) Retries() map[string]uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make(map[string]uint64, len(p.retries))
+	for op, n := range p.retries {
+		out[op] = n
+	}
+	return out
+}
+
+// backoff returns the delay before the next attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.baseBackoff << (attempt - 1)
+	if d <= 0 || (p.maxBackoff > 0 && d > p.maxBackoff) {
+		d = p.maxBackoff
+	}
+
+	if p.jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.jitter * float64(d))
+	}
+	return d
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// IsUnreachable reports whether err means the request never reached Vault,
+// such as a refused connection, a DNS failure, or a timeout, as opposed to
+// an error Vault returned.
+func IsUnreachable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// isRetryable reports whether an error is likely transient.
+func isRetryable(err error) bool {
+	if errors.Is(err, api.ErrSecretNotFound) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode >= 500 || respErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
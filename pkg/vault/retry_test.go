@@ -0,0 +1,135 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Retry Policy Tests
+//
+// Unit tests for exponential backoff retries of Vault operations.
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRetryPolicy_Do verifies transient errors are retried with growing backoff.
+func TestRetryPolicy_Do(t *testing.T) {
+	policy := NewRetryPolicy(&config.RetryConfig{
+		MaxAttempts: 4,
+		BaseBackoff: time.Second,
+		MaxBackoff:  3 * time.Second,
+	})
+
+	var sleeps []time.Duration
+	policy.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	calls := 0
+	err := policy.Do("issue", func() error {
+		calls++
+		if calls < 4 {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 4 {
+		t.Errorf("expected 4 calls, got %d", calls)
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, d := range expected {
+		if sleeps[i] != d {
+			t.Errorf("backoff %d: expected %v, got %v", i, d, sleeps[i])
+		}
+	}
+
+	if n := policy.Retries()["issue"]; n != 3 {
+		t.Errorf("expected 3 retries recorded, got %d", n)
+	}
+}
+
+// TestRetryPolicy_NonRetryable verifies client errors are not retried.
+func TestRetryPolicy_NonRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "permission denied", err: fmt.Errorf("wrapped: %w", &api.ResponseError{StatusCode: 403})},
+		{name: "secret not found", err: fmt.Errorf("wrapped: %w", api.ErrSecretNotFound)},
+		{name: "context canceled", err: fmt.Errorf("wrapped: %w", context.Canceled)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewRetryPolicy(&config.RetryConfig{MaxAttempts: 5, BaseBackoff: time.Millisecond})
+			policy.sleep = func(time.Duration) {}
+
+			calls := 0
+			err := policy.Do("issue", func() error {
+				calls++
+				return tt.err
+			})
+
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if calls != 1 {
+				t.Errorf("expected 1 call, got %d", calls)
+			}
+		})
+	}
+}
+
+// TestRetryPolicy_Disabled verifies a zero attempt budget runs once.
+func TestRetryPolicy_Disabled(t *testing.T) {
+	policy := NewRetryPolicy(&config.RetryConfig{})
+
+	calls := 0
+	_ = policy.Do("auth", func() error {
+		calls++
+		return fmt.Errorf("fail")
+	})
+
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
+
+// TestIsUnreachable verifies network errors are told apart from errors
+// Vault returned.
+func TestIsUnreachable(t *testing.T) {
+	dialErr := &url.Error{Op: "Put", URL: "https://vault:8200", Err: &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")}}
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{dialErr, true},
+		{fmt.Errorf("failed to issue: %w", dialErr), true},
+		{&api.ResponseError{StatusCode: 403}, false},
+		{fmt.Errorf("vault error"), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsUnreachable(tt.err); got != tt.want {
+			t.Errorf("IsUnreachable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
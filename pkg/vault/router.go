@@ -0,0 +1,169 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Client Router
+//
+// Routes issuance requests to per-certificate Vault clients so individual
+// certificates can be issued from a different Vault cluster or PKI mount
+// than the global default. Aggregates stats and lifecycle across clients.
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"fmt"
+	"sync"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Router dispatches issuance to a per-certificate client or a default.
+type Router struct {
+	fallback Client
+
+	mu      sync.RWMutex
+	clients map[string]Client
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewRouter creates a router that uses fallback for unrouted certificates.
+func NewRouter(fallback Client) *Router {
+	return &Router{
+		fallback: fallback,
+		clients:  make(map[string]Client),
+	}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Route assigns a dedicated client to the named certificate.
+func (r *Router) Route(certName string, client Client) {
+	r.mu.Lock()
+	previous := r.clients[certName]
+	r.clients[certName] = client
+	r.mu.Unlock()
+
+	if previous != nil && previous != client {
+		closeClient(previous)
+	}
+}
+
+// Remove drops the named certificate's dedicated client, closing it, so the
+// certificate falls back to the default client.
+func (r *Router) Remove(certName string) {
+	r.mu.Lock()
+	client, ok := r.clients[certName]
+	delete(r.clients, certName)
+	r.mu.Unlock()
+
+	if ok {
+		closeClient(client)
+	}
+}
+
+// IssueCertificate issues the certificate using its routed client.
+func (r *Router) IssueCertificate(certConfig *config.CertificateConfig) (*CertificateData, error) {
+	return r.clientFor(certConfig.Name).IssueCertificate(certConfig)
+}
+
+// FetchCAChain reads the CA chain using the certificate's routed client.
+func (r *Router) FetchCAChain(certConfig *config.CertificateConfig) (string, error) {
+	client := r.clientFor(certConfig.Name)
+	fetcher, ok := client.(CAChainFetcher)
+	if !ok {
+		return "", fmt.Errorf("vault client for %s cannot read CA chains", certConfig.Name)
+	}
+	return fetcher.FetchCAChain(certConfig)
+}
+
+// SignCertificate signs a CSR using the certificate's routed client.
+func (r *Router) SignCertificate(certConfig *config.CertificateConfig, csrPEM string) (*CertificateData, error) {
+	client := r.clientFor(certConfig.Name)
+	signer, ok := client.(CSRSigner)
+	if !ok {
+		return nil, fmt.Errorf("vault client for %s cannot sign CSRs", certConfig.Name)
+	}
+	return signer.SignCertificate(certConfig, csrPEM)
+}
+
+// Stats returns operation counters summed across all clients. ClockSkew is
+// the largest skew measured by any client.
+func (r *Router) Stats() Stats {
+	total := Stats{
+		Retries:  make(map[string]uint64),
+		Requests: make(map[string]RequestStats),
+	}
+	for _, client := range r.all() {
+		source, ok := client.(interface{ Stats() Stats })
+		if !ok {
+			continue
+		}
+		stats := source.Stats()
+		for op, n := range stats.Retries {
+			total.Retries[op] += n
+		}
+		total.Failovers += stats.Failovers
+		total.AuthSuccesses += stats.AuthSuccesses
+		total.AuthFailures += stats.AuthFailures
+		mergeRequestStats(total.Requests, stats.Requests)
+		if stats.ClockSkewSampled && (!total.ClockSkewSampled || stats.ClockSkew.Abs() > total.ClockSkew.Abs()) {
+			total.ClockSkew, total.ClockSkewSampled = stats.ClockSkew, true
+		}
+		// Report the client closest to trouble: the stalest login and the
+		// token that expires first.
+		if !stats.LastAuth.IsZero() && (total.LastAuth.IsZero() || stats.LastAuth.Before(total.LastAuth)) {
+			total.LastAuth = stats.LastAuth
+		}
+		if !stats.TokenExpiry.IsZero() && (total.TokenExpiry.IsZero() || stats.TokenExpiry.Before(total.TokenExpiry)) {
+			total.TokenExpiry = stats.TokenExpiry
+		}
+	}
+	return total
+}
+
+// Close stops background work for all clients.
+func (r *Router) Close() {
+	for _, client := range r.all() {
+		closeClient(client)
+	}
+}
+
+// clientFor returns the client for the named certificate.
+func (r *Router) clientFor(certName string) Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if client, ok := r.clients[certName]; ok {
+		return client
+	}
+	return r.fallback
+}
+
+// all returns the fallback and every routed client.
+func (r *Router) all() []Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	clients := []Client{r.fallback}
+	for _, client := range r.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// closeClient stops background work for clients that support it.
+func closeClient(client Client) {
+	if closer, ok := client.(interface{ Close() }); ok {
+		closer.Close()
+	}
+}
@@ -0,0 +1,66 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Client Router Tests
+//
+// Unit tests for routing issuance to per-certificate Vault clients.
+// -------------------------------------------------------------------------------
+
+package vault
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRouter_IssueCertificate verifies routed and fallback dispatch.
+func TestRouter_IssueCertificate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fallback := NewMockClient(ctrl)
+	dedicated := NewMockClient(ctrl)
+
+	router := NewRouter(fallback)
+	router.Route("db-cert", dedicated)
+
+	dbCert := &config.CertificateConfig{Name: "db-cert"}
+	webCert := &config.CertificateConfig{Name: "web-cert"}
+
+	dedicated.EXPECT().IssueCertificate(dbCert).Return(CreateTestCertificateData(), nil)
+	fallback.EXPECT().IssueCertificate(webCert).Return(CreateTestCertificateData(), nil)
+
+	if _, err := router.IssueCertificate(dbCert); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := router.IssueCertificate(webCert); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestRouter_Stats verifies stats are summed across clients that expose them.
+func TestRouter_Stats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	primary := &VaultClient{retry: NewRetryPolicy(&config.RetryConfig{})}
+	primary.retry.retries["issue"] = 2
+	secondary := &VaultClient{retry: NewRetryPolicy(&config.RetryConfig{})}
+	secondary.retry.retries["issue"] = 3
+
+	router := NewRouter(primary)
+	router.Route("a", secondary)
+	router.Route("b", NewMockClient(ctrl))
+
+	if n := router.Stats().Retries["issue"]; n != 5 {
+		t.Errorf("expected 5 issue retries, got %d", n)
+	}
+}
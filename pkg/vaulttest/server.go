@@ -0,0 +1,292 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fake Vault Server
+//
+// In-process fake of the Vault PKI issue, sign, and CA chain endpoints for
+// soak and integration testing. Issues real ECDSA certificates signed by an ephemeral
+// CA so the full parse/write/fingerprint path is exercised without a Vault
+// cluster. The CA can be rotated to exercise CA rotation handling.
+// -------------------------------------------------------------------------------
+
+// Package vaulttest provides a fake Vault PKI server for testing.
+package vaulttest
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Server is a fake Vault server that serves PKI issue requests.
+type Server struct {
+	URL string
+
+	server *httptest.Server
+	caMu   sync.RWMutex
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	caPEM  string
+	serial atomic.Int64
+	issued atomic.Int64
+	failed atomic.Int64
+
+	// FailureRate is the probability (0-1) that an issue request returns a 500.
+	FailureRate float64
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewServer starts a fake Vault server with an ephemeral CA.
+func NewServer() (*Server, error) {
+	s := &Server{}
+	if err := s.RotateCA(); err != nil {
+		return nil, err
+	}
+	s.serial.Store(1)
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.URL = s.server.URL
+
+	return s, nil
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Close shuts down the fake server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Issued returns the number of certificates issued.
+func (s *Server) Issued() int64 {
+	return s.issued.Load()
+}
+
+// Failed returns the number of injected failures.
+func (s *Server) Failed() int64 {
+	return s.failed.Load()
+}
+
+// CAPEM returns the PEM-encoded CA certificate.
+func (s *Server) CAPEM() string {
+	s.caMu.RLock()
+	defer s.caMu.RUnlock()
+	return s.caPEM
+}
+
+// RotateCA replaces the issuing CA with a freshly generated one. Later
+// issue and CA chain requests use the new CA.
+func (s *Server) RotateCA() error {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate CA key: %w", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "vaulttest CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &caKey.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+
+	s.caMu.Lock()
+	s.caCert = caCert
+	s.caKey = caKey
+	s.caPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	s.caMu.Unlock()
+
+	return nil
+}
+
+// handle serves Vault API requests.
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/cert/ca_chain") {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{"certificate": s.CAPEM()},
+		})
+		return
+	}
+
+	if r.Method != http.MethodPut && r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "unsupported method")
+		return
+	}
+
+	if !strings.Contains(r.URL.Path, "/issue/") && !strings.Contains(r.URL.Path, "/sign/") {
+		writeError(w, http.StatusNotFound, "unsupported path "+r.URL.Path)
+		return
+	}
+
+	if s.FailureRate > 0 && randFloat() < s.FailureRate {
+		s.failed.Add(1)
+		writeError(w, http.StatusInternalServerError, "injected failure")
+		return
+	}
+
+	var req map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	certPEM, keyPEM, caPEM, serial, notAfter, err := s.issue(req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.issued.Add(1)
+
+	data := map[string]interface{}{
+		"certificate":   certPEM,
+		"issuing_ca":    caPEM,
+		"ca_chain":      []string{caPEM},
+		"serial_number": fmt.Sprintf("%x", serial),
+		"expiration":    notAfter.Unix(),
+	}
+	if keyPEM != "" {
+		data["private_key"] = keyPEM
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"request_id": fmt.Sprintf("fake-%d", serial),
+		"data":       data,
+	})
+}
+
+// issue signs a new leaf certificate for the request with the current CA.
+// Requests carrying a csr are signed for the CSR's key and return no key.
+func (s *Server) issue(req map[string]interface{}) (string, string, string, int64, time.Time, error) {
+	cn, _ := req["common_name"].(string)
+	if cn == "" {
+		return "", "", "", 0, time.Time{}, fmt.Errorf("common_name is required")
+	}
+
+	ttl := time.Hour
+	if v, ok := req["ttl"].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			ttl = d
+		}
+	}
+
+	var publicKey interface{}
+	var key *ecdsa.PrivateKey
+	if csrPEM, ok := req["csr"].(string); ok {
+		block, _ := pem.Decode([]byte(csrPEM))
+		if block == nil {
+			return "", "", "", 0, time.Time{}, fmt.Errorf("csr is not PEM encoded")
+		}
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return "", "", "", 0, time.Time{}, err
+		}
+		if err := csr.CheckSignature(); err != nil {
+			return "", "", "", 0, time.Time{}, err
+		}
+		publicKey = csr.PublicKey
+	} else {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return "", "", "", 0, time.Time{}, err
+		}
+		publicKey = &key.PublicKey
+	}
+
+	serial := s.serial.Add(1)
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(ttl),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{cn},
+	}
+	if alt, ok := req["alt_names"].(string); ok && alt != "" {
+		template.DNSNames = append(template.DNSNames, strings.Split(alt, ",")...)
+	}
+	if ips, ok := req["ip_sans"].(string); ok && ips != "" {
+		for _, ip := range strings.Split(ips, ",") {
+			if parsed := net.ParseIP(ip); parsed != nil {
+				template.IPAddresses = append(template.IPAddresses, parsed)
+			}
+		}
+	}
+
+	s.caMu.RLock()
+	caCert, caKey, caPEM := s.caCert, s.caKey, s.caPEM
+	s.caMu.RUnlock()
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, publicKey, caKey)
+	if err != nil {
+		return "", "", "", 0, time.Time{}, err
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	if key == nil {
+		return certPEM, "", caPEM, serial, template.NotAfter, nil
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", "", 0, time.Time{}, err
+	}
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM, caPEM, serial, template.NotAfter, nil
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// writeError writes a Vault-style error response.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string][]string{"errors": {msg}})
+}
+
+// randFloat returns a cryptographically random float in [0, 1).
+func randFloat() float64 {
+	n, err := rand.Int(rand.Reader, big.NewInt(1<<53))
+	if err != nil {
+		return 1
+	}
+	return float64(n.Int64()) / (1 << 53)
+}
@@ -0,0 +1,289 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Watch-Only Certificates
+//
+// Periodically reads certificates the agent does not manage, from files
+// matched by glob patterns and from TLS endpoints, so their expiry can be
+// exported and shown next to managed certificates. Nothing is verified or
+// written; a target that cannot be read is reported with its error.
+// -------------------------------------------------------------------------------
+
+// Package watch scans files and TLS endpoints for certificates to monitor.
+package watch
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	// SourceFile marks targets read from a file.
+	SourceFile = "file"
+
+	// SourceEndpoint marks targets read from a TLS endpoint.
+	SourceEndpoint = "endpoint"
+)
+
+// -------------------------------------------------------------------------
+// TYPES
+// -------------------------------------------------------------------------
+
+// Target is the certificate last read from one file or endpoint. Files
+// and endpoints report their leaf certificate, the first one found.
+type Target struct {
+	Source      string    `json:"source"`   // SourceFile or SourceEndpoint
+	Location    string    `json:"location"` // file path or host:port
+	Subject     string    `json:"subject,omitempty"`
+	Issuer      string    `json:"issuer,omitempty"`
+	Serial      string    `json:"serial,omitempty"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Checked     time.Time `json:"checked"`
+}
+
+// Scanner reads the configured watch targets.
+type Scanner struct {
+	config *config.WatchConfig
+
+	mu      sync.RWMutex
+	targets []Target
+}
+
+// -------------------------------------------------------------------------
+// CONSTRUCTOR
+// -------------------------------------------------------------------------
+
+// NewScanner creates a scanner for the given watch configuration.
+func NewScanner(cfg *config.WatchConfig) *Scanner {
+	return &Scanner{config: cfg}
+}
+
+// -------------------------------------------------------------------------
+// METHODS
+// -------------------------------------------------------------------------
+
+// Targets returns the results of the most recent scan, ordered by source
+// and location.
+func (s *Scanner) Targets() []Target {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.targets
+}
+
+// Run scans immediately and then every interval until ctx is cancelled.
+func (s *Scanner) Run(ctx context.Context) {
+	s.Scan(ctx)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Scan(ctx)
+		}
+	}
+}
+
+// Scan reads every file and endpoint once and stores the results.
+// Endpoints are dialed concurrently.
+func (s *Scanner) Scan(ctx context.Context) []Target {
+	targets := s.scanFiles()
+
+	results := make([]Target, len(s.config.Endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range s.config.Endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = s.scanEndpoint(ctx, endpoint)
+		}()
+	}
+	wg.Wait()
+	targets = append(targets, results...)
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Source != targets[j].Source {
+			return targets[i].Source < targets[j].Source
+		}
+		return targets[i].Location < targets[j].Location
+	})
+
+	failed := 0
+	for _, target := range targets {
+		if target.Error != "" {
+			failed++
+		}
+	}
+	slog.Debug("Scanned watched certificates", "targets", len(targets), "failed", failed)
+
+	s.mu.Lock()
+	s.targets = targets
+	s.mu.Unlock()
+	return targets
+}
+
+// scanFiles reads every file matched by the configured patterns. Matched
+// files without a certificate, such as private keys, are skipped.
+func (s *Scanner) scanFiles() []Target {
+	seen := make(map[string]bool)
+	var targets []Target
+
+	for _, pattern := range s.config.Files {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			targets = append(targets, Target{Source: SourceFile, Location: pattern, Error: err.Error(), Checked: time.Now()})
+			continue
+		}
+		if len(matches) == 0 {
+			slog.Warn("Watch pattern matched no files", "pattern", pattern)
+			continue
+		}
+
+		for _, path := range matches {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			target, ok := scanFile(path)
+			if !ok {
+				slog.Debug("Skipping watched file without a certificate", "path", path)
+				continue
+			}
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// scanEndpoint connects to endpoint and reads the certificate it serves.
+// The chain is not verified: expired or untrusted certificates are exactly
+// what watching is meant to surface.
+func (s *Scanner) scanEndpoint(ctx context.Context, endpoint string) Target {
+	target := Target{Source: SourceEndpoint, Location: endpoint, Checked: time.Now()}
+
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		target.Error = err.Error()
+		return target
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	dialer := &tls.Dialer{Config: &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	}}
+	conn, err := dialer.DialContext(ctx, "tcp", endpoint)
+	if err != nil {
+		target.Error = fmt.Sprintf("failed to connect: %v", err)
+		return target
+	}
+	defer func() { _ = conn.Close() }()
+
+	peers := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		target.Error = "no certificate presented"
+		return target
+	}
+	describe(&target, peers[0])
+	return target
+}
+
+// -------------------------------------------------------------------------
+// HELPERS
+// -------------------------------------------------------------------------
+
+// scanFile reads the first certificate in a PEM or DER file. It returns
+// false for readable files that hold no certificate.
+func scanFile(path string) (Target, bool) {
+	target := Target{Source: SourceFile, Location: path, Checked: time.Now()}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		target.Error = err.Error()
+		return target, true
+	}
+
+	cert, err := firstCertificate(data)
+	if err != nil {
+		target.Error = err.Error()
+		return target, true
+	}
+	if cert == nil {
+		return target, false
+	}
+	describe(&target, cert)
+	return target, true
+}
+
+// firstCertificate parses the first certificate in PEM data, or DER data
+// if there is no PEM. It returns nil if data holds no certificate.
+func firstCertificate(data []byte) (*x509.Certificate, error) {
+	rest := data
+	sawPEM := false
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		sawPEM = true
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		return cert, nil
+	}
+	if sawPEM {
+		return nil, nil
+	}
+
+	if certs, err := x509.ParseCertificates(data); err == nil && len(certs) > 0 {
+		return certs[0], nil
+	}
+	return nil, nil
+}
+
+// describe copies cert's identity and validity into target.
+func describe(target *Target, cert *x509.Certificate) {
+	hash := sha256.Sum256(cert.Raw)
+
+	target.Subject = cert.Subject.CommonName
+	target.Issuer = cert.Issuer.CommonName
+	target.Serial = cert.SerialNumber.Text(16)
+	target.DNSNames = cert.DNSNames
+	target.NotBefore = cert.NotBefore
+	target.NotAfter = cert.NotAfter
+	target.Fingerprint = hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,173 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Watch-Only Certificate Tests
+//
+// Unit tests for scanning watched files and TLS endpoints.
+// -------------------------------------------------------------------------------
+
+package watch
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"cert-manager/pkg/config"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// newCertificateDER creates a self-signed certificate for cn expiring at
+// notAfter.
+func newCertificateDER(t *testing.T, cn string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	return der
+}
+
+// writeFile writes data to name in dir.
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestScanner_Files verifies PEM and DER files are read through globs,
+// files without a certificate are skipped, and unparsable certificates
+// are reported.
+func TestScanner_Files(t *testing.T) {
+	dir := t.TempDir()
+	notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second).UTC()
+
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: newCertificateDER(t, "pem.example.com", notAfter)})
+	writeFile(t, dir, "a.pem", pemCert)
+	writeFile(t, dir, "b.der", newCertificateDER(t, "der.example.com", notAfter))
+	writeFile(t, dir, "c.key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}))
+	writeFile(t, dir, "d.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("garbage")}))
+
+	scanner := NewScanner(&config.WatchConfig{
+		Files: []string{
+			filepath.Join(dir, "*.pem"),
+			filepath.Join(dir, "*"), // overlaps *.pem
+			filepath.Join(dir, "missing", "*.pem"),
+		},
+		Interval: time.Minute,
+		Timeout:  time.Second,
+	})
+
+	targets := scanner.Scan(context.Background())
+	if len(targets) != 3 {
+		t.Fatalf("expected 3 targets, got %d: %+v", len(targets), targets)
+	}
+
+	want := []struct {
+		name    string
+		subject string
+		failed  bool
+	}{
+		{"a.pem", "pem.example.com", false},
+		{"b.der", "der.example.com", false},
+		{"d.pem", "", true},
+	}
+	for i, w := range want {
+		target := targets[i]
+		if target.Source != SourceFile || target.Location != filepath.Join(dir, w.name) {
+			t.Errorf("target %d: expected file %s, got %s %s", i, w.name, target.Source, target.Location)
+		}
+		if (target.Error != "") != w.failed {
+			t.Errorf("%s: unexpected error %q", w.name, target.Error)
+		}
+		if target.Subject != w.subject {
+			t.Errorf("%s: expected subject %q, got %q", w.name, w.subject, target.Subject)
+		}
+		if !w.failed && !target.NotAfter.Equal(notAfter) {
+			t.Errorf("%s: expected not after %v, got %v", w.name, notAfter, target.NotAfter)
+		}
+	}
+
+	if got := scanner.Targets(); len(got) != len(targets) {
+		t.Errorf("expected Targets to return the last scan, got %d targets", len(got))
+	}
+}
+
+// TestScanner_Endpoints verifies the served certificate is read without
+// verification and unreachable endpoints are reported.
+func TestScanner_Endpoints(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	served := server.Certificate()
+
+	// Reserve a port with nothing listening on it.
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closedAddr := closed.Listener.Addr().String()
+	closed.Close()
+
+	live := strings.TrimPrefix(server.URL, "https://")
+	scanner := NewScanner(&config.WatchConfig{
+		Endpoints: []string{live, closedAddr},
+		Interval:  time.Minute,
+		Timeout:   2 * time.Second,
+	})
+
+	targets := scanner.Scan(context.Background())
+	if len(targets) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(targets))
+	}
+
+	byLocation := make(map[string]Target)
+	for _, target := range targets {
+		if target.Source != SourceEndpoint {
+			t.Errorf("expected endpoint source, got %q", target.Source)
+		}
+		byLocation[target.Location] = target
+	}
+
+	got := byLocation[live]
+	if got.Error != "" {
+		t.Fatalf("unexpected error for %s: %s", live, got.Error)
+	}
+	if !got.NotAfter.Equal(served.NotAfter) || got.Serial != served.SerialNumber.Text(16) {
+		t.Errorf("expected served certificate, got serial %s not after %v", got.Serial, got.NotAfter)
+	}
+
+	if byLocation[closedAddr].Error == "" {
+		t.Errorf("expected an error for unreachable endpoint %s", closedAddr)
+	}
+}
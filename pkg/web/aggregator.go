@@ -2,23 +2,39 @@
 // vault-cert-manager - Aggregator Dashboard
 //
 // Centralized dashboard that discovers all vault-cert-manager instances via
-// Consul and displays their certificate status in a unified view.
+// Consul, DNS SRV records, or a static list, and displays their certificate
+// status in a unified view.
 // -------------------------------------------------------------------------------
 
 package web
 
 import (
+	"context"
+	"crypto"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"log/slog"
+	"mime"
+	"net"
 	"net/http"
+	"net/url"
 	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"cert-manager/pkg/cloud"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// ndjsonContentType is the media type for newline-delimited JSON streams.
+const ndjsonContentType = "application/x-ndjson"
+
 // ConsulService represents a service instance from Consul.
 type ConsulService struct {
 	Node           string `json:"Node"`
@@ -27,12 +43,36 @@ type ConsulService struct {
 	ServicePort    int    `json:"ServicePort"`
 }
 
+// ConsulDiscovery selects the Consul service instances the aggregator
+// queries.
+type ConsulDiscovery struct {
+	PassingOnly bool     // only instances whose health checks all pass
+	Datacenter  string   // datacenter to query, the agent's own when empty
+	Tags        []string // instances must have every tag
+}
+
+// consulHealthEntry is an instance from Consul's /v1/health/service.
+type consulHealthEntry struct {
+	Node struct {
+		Node    string `json:"Node"`
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
 // NodeStatus represents the status of all certs on a single node.
 type NodeStatus struct {
-	Node    string       `json:"node"`
-	Address string       `json:"address"`
-	Certs   []CertStatus `json:"certs"`
-	Error   string       `json:"error,omitempty"`
+	Node    string          `json:"node"`
+	Address string          `json:"address"`
+	Cloud   *cloud.Identity `json:"cloud,omitempty"`
+	Certs   []CertStatus    `json:"certs"`
+	Error   string          `json:"error,omitempty"`
+
+	// Build and configuration the node runs, from /api/node.
+	BuildInfo
 }
 
 // Aggregator provides a centralized dashboard for all vault-cert-manager instances.
@@ -42,6 +82,58 @@ type Aggregator struct {
 	templates    *template.Template
 	httpClient   *http.Client
 	rotateClient *http.Client
+	reportSigner crypto.Signer
+	statusCache  conditionalJSON
+	queryCache   conditionalJSON // filtered or sorted status, apart from statusCache for its pollers
+	rateLimiter  *RateLimiter
+	registry     *prometheus.Registry
+	slo          SLOConfig
+	nodeErrors   *prometheus.CounterVec
+	auth         *Auth
+	nodeToken    string
+
+	// eventInterval is how often /api/events polls the nodes.
+	eventInterval time.Duration
+
+	// closing is closed by CloseStreams to end the /api/events streams.
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// HTTP server started by StartServer; stopped is set by Shutdown so a
+	// server not yet started never starts.
+	serverMu sync.Mutex
+	server   *http.Server
+	stopped  bool
+
+	nodesMu sync.Mutex
+	nodes   map[string]*nodeSnapshot // keyed by node base URL
+
+	// Static nodes queried instead of Consul, see SetStaticNodes.
+	staticNodes []ConsulService
+	nodesFile   string
+
+	// DNS SRV discovery, see SetSRVDiscovery. srvLookup is
+	// net.DefaultResolver.LookupSRV outside tests.
+	srvName    string
+	srvRefresh time.Duration
+	srvLookup  func(ctx context.Context, name string) (string, []*net.SRV, error)
+	srvMu      sync.Mutex
+	srvNodes   []ConsulService
+	srvFetched time.Time
+
+	// consul selects the instances discovered through Consul.
+	consul ConsulDiscovery
+
+	// Consul client and ACL token, see SetConsulClient.
+	consulClient *http.Client
+	consulToken  string
+
+	// nodeScheme is "https" once SetNodeTLS is called.
+	nodeScheme string
+
+	// Fleet rotations kept for polling, oldest first.
+	fleetMu        sync.Mutex
+	fleetRotations []*FleetRotation
 }
 
 // NewAggregator creates a new aggregator dashboard.
@@ -53,9 +145,12 @@ func NewAggregator(consulAddr, serviceName string, rotateTimeout time.Duration)
 			}
 			return t.Format("2006-01-02 15:04:05")
 		},
+		"days": func(d time.Duration) int {
+			return int(d.Hours() / 24)
+		},
 	}).ParseFS(templateFS, "templates/*.html"))
 
-	return &Aggregator{
+	a := &Aggregator{
 		consulAddr:  consulAddr,
 		serviceName: serviceName,
 		templates:   tmpl,
@@ -65,21 +160,113 @@ func NewAggregator(consulAddr, serviceName string, rotateTimeout time.Duration)
 		rotateClient: &http.Client{
 			Timeout: rotateTimeout,
 		},
+		registry:      prometheus.NewRegistry(),
+		slo:           SLOConfig{Lead: DefaultSLOLead, Window: DefaultSLOWindow, Target: DefaultSLOTarget},
+		eventInterval: aggregatorEventInterval,
+		closing:       make(chan struct{}),
+		nodeErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "managed_cert_fleet_node_errors_total",
+				Help: "Failed fetches of a node's status, from scrapes, dashboard loads, and API requests.",
+			},
+			[]string{"node"},
+		),
+		nodes: make(map[string]*nodeSnapshot),
+		srvLookup: func(ctx context.Context, name string) (string, []*net.SRV, error) {
+			return net.DefaultResolver.LookupSRV(ctx, "", "", name)
+		},
+		consul:       ConsulDiscovery{PassingOnly: true},
+		consulClient: &http.Client{Timeout: 10 * time.Second},
+		nodeScheme:   "http",
 	}
+	a.registry.MustRegister(newFleetCollector(a), a.nodeErrors)
+	return a
 }
 
 // RegisterHandlers registers the aggregator HTTP handlers.
 func (a *Aggregator) RegisterHandlers(mux *http.ServeMux) {
-	mux.HandleFunc("/", a.handleDashboard)
-	mux.HandleFunc("/api/status", a.handleAPIStatus)
-	mux.HandleFunc("/api/rotate/", a.handleAPIRotate)
+	mux.HandleFunc("/", a.auth.RequireForStatus(a.handleDashboard))
+	mux.HandleFunc("/api/status", a.auth.RequireForStatus(a.handleAPIStatus))
+	mux.HandleFunc("/api/rotate/", a.auth.Require(a.rateLimiter.Wrap("rotate", a.handleAPIRotate)))
+	mux.HandleFunc("/api/rotate-fleet", a.auth.Require(a.rateLimiter.Wrap("rotate_fleet", a.handleAPIRotateFleet)))
+	mux.HandleFunc("/api/rotate-fleet/", a.auth.RequireForStatus(a.handleAPIFleetRotation))
+	mux.HandleFunc("/api/report", a.auth.RequireForStatus(a.handleAPIReport))
+	mux.HandleFunc("/api/events", a.auth.RequireForStatus(a.handleAPIEvents))
+	a.auth.RegisterHandlers(mux)
+	mux.Handle("/metrics", promhttp.HandlerFor(a.registry, promhttp.HandlerOpts{}))
+}
+
+// SetRateLimiter limits proxied rotate requests and exports the limiter's
+// metrics on /metrics.
+func (a *Aggregator) SetRateLimiter(limiter *RateLimiter) {
+	a.rateLimiter = limiter
+	a.registry.MustRegister(limiter)
+}
+
+// SetAuth requires credentials for proxied rotate requests, and for the
+// dashboard, status, and report when auth protects status.
+func (a *Aggregator) SetAuth(auth *Auth) {
+	a.auth = auth
+}
+
+// SetNodeToken sends token as a bearer token on every request to a node,
+// for nodes that require authentication. Without it, proxied rotate
+// requests forward the caller's Authorization header.
+func (a *Aggregator) SetNodeToken(token string) {
+	a.nodeToken = token
+}
+
+// SetSLO configures the fleet renewal objective.
+func (a *Aggregator) SetSLO(cfg SLOConfig) {
+	a.slo = cfg
+}
+
+// SetConsulDiscovery selects the instances discovered through Consul. By
+// default, only instances passing their health checks are queried.
+func (a *Aggregator) SetConsulDiscovery(cfg ConsulDiscovery) {
+	a.consul = cfg
+}
+
+// SetReportSigner configures the key used to sign compliance reports.
+func (a *Aggregator) SetReportSigner(signer crypto.Signer) {
+	a.reportSigner = signer
 }
 
-// discoverServices queries Consul for all vault-cert-manager instances.
+// discoverServices queries Consul's health endpoint for the
+// vault-cert-manager instances selected by the Consul discovery settings,
+// or returns the static or SRV nodes when they are set.
 func (a *Aggregator) discoverServices() ([]ConsulService, error) {
-	url := fmt.Sprintf("%s/v1/catalog/service/%s", a.consulAddr, a.serviceName)
+	if a.staticDiscovery() {
+		return a.staticServices()
+	}
+	if a.srvName != "" {
+		return a.srvServices()
+	}
+
+	params := url.Values{}
+	if a.consul.PassingOnly {
+		params.Set("passing", "true")
+	}
+	if a.consul.Datacenter != "" {
+		params.Set("dc", a.consul.Datacenter)
+	}
+	for _, tag := range a.consul.Tags {
+		params.Add("tag", tag)
+	}
+	endpoint := fmt.Sprintf("%s/v1/health/service/%s", a.consulAddr, url.PathEscape(a.serviceName))
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Consul request: %w", err)
+	}
+	if a.consulToken != "" {
+		req.Header.Set("X-Consul-Token", a.consulToken)
+	}
 
-	resp, err := a.httpClient.Get(url)
+	resp, err := a.consulClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query Consul: %w", err)
 	}
@@ -90,11 +277,20 @@ func (a *Aggregator) discoverServices() ([]ConsulService, error) {
 		return nil, fmt.Errorf("consul returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	var services []ConsulService
-	if err := json.NewDecoder(resp.Body).Decode(&services); err != nil {
+	var entries []consulHealthEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
 		return nil, fmt.Errorf("failed to decode Consul response: %w", err)
 	}
 
+	services := make([]ConsulService, 0, len(entries))
+	for _, entry := range entries {
+		services = append(services, ConsulService{
+			Node:           entry.Node.Node,
+			Address:        entry.Node.Address,
+			ServiceAddress: entry.Service.Address,
+			ServicePort:    entry.Service.Port,
+		})
+	}
 	return services, nil
 }
 
@@ -105,32 +301,53 @@ func (a *Aggregator) fetchNodeStatus(svc ConsulService) NodeStatus {
 		addr = svc.Address
 	}
 
-	url := fmt.Sprintf("http://%s:%d/api/status", addr, svc.ServicePort)
-
 	status := NodeStatus{
 		Node:    svc.Node,
 		Address: fmt.Sprintf("%s:%d", addr, svc.ServicePort),
 	}
 
-	resp, err := a.httpClient.Get(url)
+	certs, err := a.syncNodeCerts(a.nodeURL(status.Address, ""))
 	if err != nil {
 		status.Error = err.Error()
+		a.nodeErrors.WithLabelValues(svc.Node).Inc()
 		return status
 	}
-	defer func() { _ = resp.Body.Close() }()
+	status.Certs = certs
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		status.Error = fmt.Sprintf("status %d: %s", resp.StatusCode, string(body))
-		return status
+	if info := a.fetchNodeInfo(addr, svc.ServicePort); info != nil {
+		status.Cloud = info.Cloud
+		status.BuildInfo = info.BuildInfo
 	}
+	return status
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&status.Certs); err != nil {
-		status.Error = fmt.Sprintf("decode error: %v", err)
-		return status
+// fetchNodeInfo queries a node's cloud identity and build information.
+// Nodes running versions without /api/node return nil; older versions
+// leave the build information empty.
+func (a *Aggregator) fetchNodeInfo(addr string, port int) *NodeInfo {
+	resp, err := a.nodeGet(a.nodeURL(fmt.Sprintf("%s:%d", addr, port), "/api/node"))
+	if err != nil {
+		return nil
 	}
+	defer func() { _ = resp.Body.Close() }()
 
-	return status
+	var info NodeInfo
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&info) != nil {
+		return nil
+	}
+	return &info
+}
+
+// nodeGet sends a GET request to a node, with the node token if set.
+func (a *Aggregator) nodeGet(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.nodeToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.nodeToken)
+	}
+	return a.httpClient.Do(req)
 }
 
 // fetchAllStatuses queries all discovered nodes in parallel.
@@ -153,14 +370,64 @@ func (a *Aggregator) fetchAllStatuses() ([]NodeStatus, error) {
 
 	wg.Wait()
 
-	// Sort by node name
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Node < results[j].Node
-	})
+	sortNodeStatuses(results)
+
+	bases := make(map[string]bool, len(results))
+	for _, node := range results {
+		bases[a.nodeURL(node.Address, "")] = true
+	}
+	a.pruneNodes(bases)
 
 	return results, nil
 }
 
+// streamAllStatuses queries all discovered nodes in parallel and returns a
+// channel that yields each node's status as soon as it responds. The
+// channel is closed once every node has answered.
+func (a *Aggregator) streamAllStatuses() (<-chan NodeStatus, error) {
+	services, err := a.discoverServices()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(chan NodeStatus, len(services))
+	var wg sync.WaitGroup
+
+	for _, svc := range services {
+		wg.Add(1)
+		go func(s ConsulService) {
+			defer wg.Done()
+			status := a.fetchNodeStatus(s)
+			sortNodeStatuses([]NodeStatus{status})
+			results <- status
+		}(svc)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results, nil
+}
+
+// sortNodeStatuses orders nodes by name and address, and each node's
+// certificates by name, so repeated requests produce identical output.
+func sortNodeStatuses(nodes []NodeStatus) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Node != nodes[j].Node {
+			return nodes[i].Node < nodes[j].Node
+		}
+		return nodes[i].Address < nodes[j].Address
+	})
+
+	for _, node := range nodes {
+		sort.SliceStable(node.Certs, func(i, j int) bool {
+			return node.Certs[i].Name < node.Certs[j].Name
+		})
+	}
+}
+
 // handleDashboard serves the aggregated dashboard page.
 func (a *Aggregator) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -168,6 +435,12 @@ func (a *Aggregator) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query, err := parseStatusQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	statuses, err := a.fetchAllStatuses()
 	if err != nil {
 		slog.Error("Failed to fetch statuses", "error", err)
@@ -175,10 +448,21 @@ func (a *Aggregator) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The SLO covers the whole fleet, whatever is shown.
 	data := struct {
 		Nodes []NodeStatus
+		Total int
+		Query statusQuery
+		SLO   SLO
+		SSO   bool
+		User  string
 	}{
-		Nodes: statuses,
+		Nodes: query.applyNodes(statuses),
+		Total: len(statuses),
+		Query: query,
+		SLO:   computeSLO(statuses, a.slo, time.Now()),
+		SSO:   a.auth.SSO(),
+		User:  a.auth.User(r),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -188,21 +472,89 @@ func (a *Aggregator) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleAPIStatus returns aggregated status as JSON.
+// handleAPIStatus returns aggregated status as JSON, narrowed and ordered
+// by the status, search, and sort parameters.
 func (a *Aggregator) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	query, err := parseStatusQuery(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if wantsNDJSON(r) {
+		a.streamAPIStatus(w, query)
+		return
+	}
+
 	statuses, err := a.fetchAllStatuses()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(statuses)
+	if query.empty() {
+		a.statusCache.serve(w, r, statuses)
+	} else {
+		a.queryCache.serve(w, r, query.applyNodes(statuses))
+	}
+}
+
+// streamAPIStatus writes one JSON line per node as each node responds,
+// flushing after every line so clients can render before the slowest node
+// answers. Lines arrive in completion order, not sorted order, and nodes
+// whose certificates the query filters out entirely are skipped.
+func (a *Aggregator) streamAPIStatus(w http.ResponseWriter, query statusQuery) {
+	statuses, err := a.streamAllStatuses()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	for status := range statuses {
+		matched := query.applyNodes([]NodeStatus{status})
+		if len(matched) == 0 {
+			continue
+		}
+		if err := encoder.Encode(matched[0]); err != nil {
+			// The channel is buffered, so abandoning it does not block
+			// the remaining node fetches.
+			slog.Debug("Status stream client disconnected", "error", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// wantsNDJSON reports whether the client asked for a streamed NDJSON
+// response, either via ?stream=ndjson or the Accept header.
+func wantsNDJSON(r *http.Request) bool {
+	if r.URL.Query().Get("stream") == "ndjson" {
+		return true
+	}
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == ndjsonContentType {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // handleAPIRotate proxies rotate requests to the appropriate node.
@@ -254,26 +606,14 @@ func (a *Aggregator) handleAPIRotate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Proxy the request
-	addr := targetSvc.ServiceAddress
-	if addr == "" {
-		addr = targetSvc.Address
-	}
-
-	var targetURL string
-	if certName == "all" {
-		targetURL = fmt.Sprintf("http://%s:%d/api/rotate/all", addr, targetSvc.ServicePort)
-	} else {
-		targetURL = fmt.Sprintf("http://%s:%d/api/rotate/%s", addr, targetSvc.ServicePort, certName)
-	}
-
-	slog.Info("Proxying rotate request", "node", nodeName, "cert", certName, "url", targetURL)
-
-	proxyReq, err := http.NewRequest(http.MethodPost, targetURL, nil)
+	proxyReq, err := a.nodeRotateRequest(context.Background(), *targetSvc, certName, r.URL.RawQuery, r.Header.Get("Authorization"))
 	if err != nil {
 		http.Error(w, "Failed to create request: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	slog.Info("Proxying rotate request", "node", nodeName, "cert", certName, "url", proxyReq.URL.String(), "user", RequestUser(r))
+
 	resp, err := a.rotateClient.Do(proxyReq)
 	if err != nil {
 		http.Error(w, "Failed to proxy request: "+err.Error(), http.StatusBadGateway)
@@ -287,13 +627,81 @@ func (a *Aggregator) handleAPIRotate(w http.ResponseWriter, r *http.Request) {
 	_, _ = io.Copy(w, resp.Body)
 }
 
-// StartServer starts the aggregator HTTP server.
-func (a *Aggregator) StartServer(port int) error {
+// nodeRotateRequest builds the request rotating certName, or every
+// certificate when it is "all", on svc. It carries the node token, or
+// without one the caller's authorization.
+func (a *Aggregator) nodeRotateRequest(ctx context.Context, svc ConsulService, certName, rawQuery, authorization string) (*http.Request, error) {
+	addr := svc.ServiceAddress
+	if addr == "" {
+		addr = svc.Address
+	}
+
+	targetURL := a.nodeURL(fmt.Sprintf("%s:%d", addr, svc.ServicePort), "/api/rotate/"+url.PathEscape(certName))
+	if rawQuery != "" {
+		targetURL += "?" + rawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if a.nodeToken != "" {
+		req.Header.Set("Authorization", "Bearer "+a.nodeToken)
+	} else if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req, nil
+}
+
+// Handler returns the aggregator dashboard, API, and metrics as one
+// http.Handler for mounting on an existing server.
+func (a *Aggregator) Handler() http.Handler {
 	mux := http.NewServeMux()
 	a.RegisterHandlers(mux)
+	return mux
+}
 
+// StartServer starts the aggregator HTTP server, blocking until it fails
+// or Shutdown is called. It returns nil after Shutdown.
+func (a *Aggregator) StartServer(port int) error {
 	addr := fmt.Sprintf(":%d", port)
-	slog.Info("Starting aggregator dashboard", "address", addr, "consul", a.consulAddr, "service", a.serviceName)
 
-	return http.ListenAndServe(addr, mux)
+	a.serverMu.Lock()
+	if a.stopped {
+		a.serverMu.Unlock()
+		return nil
+	}
+	server := &http.Server{Addr: addr, Handler: a.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	server.RegisterOnShutdown(a.CloseStreams)
+	a.server = server
+	a.serverMu.Unlock()
+
+	switch {
+	case a.staticDiscovery():
+		slog.Info("Starting aggregator dashboard", "address", addr, "nodes", len(a.staticNodes), "nodes_file", a.nodesFile)
+	case a.srvName != "":
+		slog.Info("Starting aggregator dashboard", "address", addr, "srv", a.srvName, "refresh", a.srvRefresh)
+	default:
+		slog.Info("Starting aggregator dashboard", "address", addr, "consul", a.consulAddr, "service", a.serviceName,
+			"passing_only", a.consul.PassingOnly, "datacenter", a.consul.Datacenter, "tags", a.consul.Tags)
+	}
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops the HTTP server, waiting until ctx is done for in-flight
+// requests, such as rotations being proxied to nodes, to finish.
+func (a *Aggregator) Shutdown(ctx context.Context) error {
+	a.serverMu.Lock()
+	a.stopped = true
+	server := a.server
+	a.serverMu.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	return server.Shutdown(ctx)
 }
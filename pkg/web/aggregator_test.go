@@ -0,0 +1,373 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Aggregator Tests
+//
+// Unit tests for node discovery, status aggregation, and output ordering.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/cloud"
+)
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// newTestNode starts a fake node serving the given certificate statuses.
+func newTestNode(t *testing.T, certs []CertStatus) ConsulService {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(certs)
+	}))
+	t.Cleanup(server.Close)
+
+	host, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to parse test server address: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+
+	return ConsulService{Address: host, ServicePort: port}
+}
+
+// newTestConsul starts a fake Consul health endpoint returning the given
+// services.
+func newTestConsul(t *testing.T, services []ConsulService) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(consulHealthEntries(services))
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// consulHealthEntries converts services to Consul health endpoint entries.
+func consulHealthEntries(services []ConsulService) []consulHealthEntry {
+	entries := make([]consulHealthEntry, len(services))
+	for i, svc := range services {
+		entries[i].Node.Node = svc.Node
+		entries[i].Node.Address = svc.Address
+		entries[i].Service.Address = svc.ServiceAddress
+		entries[i].Service.Port = svc.ServicePort
+	}
+	return entries
+}
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestAggregator_FetchAllStatuses_Ordering verifies nodes and certificates
+// are returned in a stable order regardless of discovery order.
+func TestAggregator_FetchAllStatuses_Ordering(t *testing.T) {
+	nodeB := newTestNode(t, []CertStatus{{Name: "zeta"}, {Name: "alpha"}})
+	nodeB.Node = "node-b"
+	nodeA := newTestNode(t, []CertStatus{{Name: "web"}})
+	nodeA.Node = "node-a"
+
+	consulAddr := newTestConsul(t, []ConsulService{nodeB, nodeA})
+	aggregator := NewAggregator(consulAddr, "vault-cert-manager", time.Second)
+
+	statuses, err := aggregator.fetchAllStatuses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(statuses) != 2 || statuses[0].Node != "node-a" || statuses[1].Node != "node-b" {
+		t.Fatalf("expected nodes sorted by name, got %+v", statuses)
+	}
+
+	certs := statuses[1].Certs
+	if len(certs) != 2 || certs[0].Name != "alpha" || certs[1].Name != "zeta" {
+		t.Errorf("expected certificates sorted by name, got %+v", certs)
+	}
+}
+
+// TestAggregator_HandleAPIReport verifies the fleet report is served as
+// HTML or PDF with failed rotations from the nodes' history.
+func TestAggregator_HandleAPIReport(t *testing.T) {
+	node := newTestNode(t, []CertStatus{{
+		Name:    "web",
+		Source:  "acme",
+		History: []cert.RotationEvent{{Time: time.Now(), Error: "vault sealed", CorrelationID: "corr-1"}},
+	}})
+	node.Node = "node-a"
+	aggregator := NewAggregator(newTestConsul(t, []ConsulService{node}), "vault-cert-manager", time.Second)
+
+	tests := []struct {
+		query       string
+		code        int
+		contentType string
+		want        []string
+	}{
+		{"", http.StatusOK, "text/html; charset=utf-8", []string{"acme", "corr-1", "1 failed rotation(s) in period"}},
+		{"?format=pdf", http.StatusOK, "application/pdf", []string{"(acme)", "(corr-1)", "(failed)", "(vault sealed)"}},
+		{"?format=docx", http.StatusBadRequest, "", nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		aggregator.handleAPIReport(rec, httptest.NewRequest(http.MethodGet, "/api/report"+tt.query, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%q: expected %d, got %d", tt.query, tt.code, rec.Code)
+			continue
+		}
+		if tt.code != http.StatusOK {
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%q: expected content type %q, got %q", tt.query, tt.contentType, ct)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(rec.Body.String(), want) {
+				t.Errorf("%q: report missing %q", tt.query, want)
+			}
+		}
+	}
+}
+
+// TestAggregator_HandleAPIStatus_Stream verifies NDJSON streaming emits one
+// line per node.
+func TestAggregator_HandleAPIStatus_Stream(t *testing.T) {
+	nodeA := newTestNode(t, []CertStatus{{Name: "zeta"}, {Name: "alpha"}})
+	nodeA.Node = "node-a"
+	nodeB := newTestNode(t, []CertStatus{{Name: "web"}})
+	nodeB.Node = "node-b"
+
+	consulAddr := newTestConsul(t, []ConsulService{nodeA, nodeB})
+	aggregator := NewAggregator(consulAddr, "vault-cert-manager", time.Second)
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+	}{
+		{name: "query parameter", target: "/api/status?stream=ndjson"},
+		{name: "accept header", target: "/api/status", accept: "text/plain, application/x-ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			aggregator.handleAPIStatus(rec, req)
+
+			if ct := rec.Header().Get("Content-Type"); ct != ndjsonContentType {
+				t.Fatalf("expected content type %s, got %s", ndjsonContentType, ct)
+			}
+
+			nodes := make(map[string]NodeStatus)
+			scanner := bufio.NewScanner(rec.Body)
+			for scanner.Scan() {
+				var status NodeStatus
+				if err := json.Unmarshal(scanner.Bytes(), &status); err != nil {
+					t.Fatalf("failed to decode line %q: %v", scanner.Text(), err)
+				}
+				nodes[status.Node] = status
+			}
+
+			if len(nodes) != 2 {
+				t.Fatalf("expected 2 node lines, got %d", len(nodes))
+			}
+			if certs := nodes["node-a"].Certs; len(certs) != 2 || certs[0].Name != "alpha" {
+				t.Errorf("expected node certificates sorted by name, got %+v", certs)
+			}
+		})
+	}
+}
+
+// TestAggregator_HandleAPIStatus_Conditional verifies unchanged status
+// returns 304 Not Modified for matching ETag and Last-Modified validators.
+func TestAggregator_HandleAPIStatus_Conditional(t *testing.T) {
+	node := newTestNode(t, []CertStatus{{Name: "web"}})
+	node.Node = "node-a"
+
+	consulAddr := newTestConsul(t, []ConsulService{node})
+	aggregator := NewAggregator(consulAddr, "vault-cert-manager", time.Second)
+
+	rec := httptest.NewRecorder()
+	aggregator.handleAPIStatus(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+	etag := rec.Header().Get("ETag")
+	lastModified := rec.Header().Get("Last-Modified")
+	if rec.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("expected 200 with validators, got %d etag=%q last-modified=%q", rec.Code, etag, lastModified)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{name: "if-none-match", header: "If-None-Match", value: etag},
+		{name: "if-modified-since", header: "If-Modified-Since", value: lastModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			req.Header.Set(tt.header, tt.value)
+			rec := httptest.NewRecorder()
+
+			aggregator.handleAPIStatus(rec, req)
+
+			if rec.Code != http.StatusNotModified {
+				t.Errorf("expected 304, got %d", rec.Code)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("expected empty body, got %q", rec.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	aggregator.handleAPIStatus(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for stale ETag, got %d", rec.Code)
+	}
+}
+
+// TestAggregator_FetchNodeStatus_Cloud verifies the node's cloud identity and
+// build information are read from /api/node.
+func TestAggregator_FetchNodeStatus_Cloud(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/node" {
+			_ = json.NewEncoder(w).Encode(NodeInfo{
+				Hostname:  "web-1",
+				Cloud:     &cloud.Identity{Provider: "ec2", InstanceID: "i-0abc", Zone: "us-east-1b"},
+				BuildInfo: BuildInfo{Version: "1.4.0", Commit: "abc123", ConfigHash: "0f1e2d3c4b5a"},
+			})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]CertStatus{{Name: "web"}})
+	}))
+	defer server.Close()
+
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	aggregator := NewAggregator("", "vault-cert-manager", time.Second)
+	status := aggregator.fetchNodeStatus(ConsulService{Node: "web-1", Address: host, ServicePort: port})
+
+	if status.Error != "" {
+		t.Fatalf("unexpected error: %s", status.Error)
+	}
+	if status.Cloud == nil || status.Cloud.InstanceID != "i-0abc" {
+		t.Errorf("expected cloud identity from /api/node, got %+v", status.Cloud)
+	}
+	if status.Version != "1.4.0" || status.ConfigHash != "0f1e2d3c4b5a" {
+		t.Errorf("expected build information from /api/node, got %+v", status.BuildInfo)
+	}
+
+	// Older nodes without /api/node still report their certificates.
+	legacy := newTestNode(t, []CertStatus{{Name: "db"}})
+	status = aggregator.fetchNodeStatus(legacy)
+	if status.Error != "" || status.Cloud != nil || len(status.Certs) != 1 {
+		t.Errorf("expected certificates without identity, got %+v", status)
+	}
+}
+
+// TestAggregator_Shutdown verifies Shutdown stops a running server and
+// keeps one not yet started from starting.
+func TestAggregator_Shutdown(t *testing.T) {
+	aggregator := NewAggregator("127.0.0.1:1", "vault-cert-manager", time.Second)
+	done := make(chan error, 1)
+	go func() { done <- aggregator.StartServer(0) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		aggregator.serverMu.Lock()
+		started := aggregator.server != nil
+		aggregator.serverMu.Unlock()
+		if started {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := aggregator.Shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil after shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartServer did not return after shutdown")
+	}
+
+	if err := aggregator.StartServer(0); err != nil {
+		t.Errorf("expected a stopped aggregator not to start, got %v", err)
+	}
+}
+
+// TestAggregator_ConsulDiscovery verifies the health endpoint is queried
+// with the passing, datacenter, and tag filters, and instances are read
+// from its entries.
+func TestAggregator_ConsulDiscovery(t *testing.T) {
+	var query url.Values
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(consulHealthEntries([]ConsulService{
+			{Node: "node-a", Address: "10.0.0.5", ServiceAddress: "10.0.1.5", ServicePort: 9101},
+		}))
+	}))
+	t.Cleanup(server.Close)
+
+	aggregator := NewAggregator(server.URL, "vault-cert-manager", time.Second)
+	services, err := aggregator.discoverServices()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/v1/health/service/vault-cert-manager" || query.Get("passing") != "true" {
+		t.Errorf("expected passing instances from the health endpoint by default, got %s?%s", path, query.Encode())
+	}
+	want := ConsulService{Node: "node-a", Address: "10.0.0.5", ServiceAddress: "10.0.1.5", ServicePort: 9101}
+	if len(services) != 1 || services[0] != want {
+		t.Errorf("expected %+v, got %+v", want, services)
+	}
+
+	aggregator.SetConsulDiscovery(ConsulDiscovery{Datacenter: "dc2", Tags: []string{"prod", "web"}})
+	if _, err := aggregator.discoverServices(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query.Has("passing") || query.Get("dc") != "dc2" || !reflect.DeepEqual(query["tag"], []string{"prod", "web"}) {
+		t.Errorf("expected all dc2 instances tagged prod and web, got %s", query.Encode())
+	}
+}
@@ -0,0 +1,167 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - API Authentication
+//
+// HTTP basic auth, static bearer tokens, and OIDC single sign-on for the
+// dashboard and its API. Rotation endpoints always require credentials once
+// authentication is configured, since anyone who can reach them can force
+// rotations; the dashboard and status endpoints only when status is
+// protected too.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// authRealm is the basic auth realm browsers show when prompting.
+const authRealm = "vault-cert-manager"
+
+// userKey is the request context key of the authenticated user.
+type userKey struct{}
+
+// Auth checks requests for basic auth credentials or a bearer token.
+type Auth struct {
+	username      string
+	password      string
+	tokens        []string
+	protectStatus bool
+	oidc          *OIDC
+}
+
+// NewAuth accepts basic auth as username and password, when username is
+// set, and any of tokens as "Authorization: Bearer <token>". With
+// protectStatus, read-only endpoints require credentials as well.
+func NewAuth(username, password string, tokens []string, protectStatus bool) *Auth {
+	return &Auth{
+		username:      username,
+		password:      password,
+		tokens:        tokens,
+		protectStatus: protectStatus,
+	}
+}
+
+// SetOIDC accepts sessions from single sign-on as well. Unauthenticated
+// browsers are sent to the provider's login instead of answered 401.
+func (a *Auth) SetOIDC(oidc *OIDC) {
+	a.oidc = oidc
+}
+
+// RegisterHandlers registers the single sign-on endpoints, if configured.
+func (a *Auth) RegisterHandlers(mux *http.ServeMux) {
+	if a == nil || a.oidc == nil {
+		return
+	}
+	a.oidc.RegisterHandlers(mux)
+}
+
+// SSO reports whether single sign-on is configured.
+func (a *Auth) SSO() bool {
+	return a != nil && a.oidc != nil
+}
+
+// Require wraps handler so it only runs for authenticated requests,
+// answering 401 otherwise. The user is available to handler through
+// RequestUser. A nil Auth returns handler as is.
+func (a *Auth) Require(handler http.HandlerFunc) http.HandlerFunc {
+	if a == nil {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := a.User(r)
+		if user == "" {
+			if a.oidc != nil && r.Method == http.MethodGet && strings.Contains(r.Header.Get("Accept"), "text/html") {
+				http.Redirect(w, r, a.oidc.LoginURL(r), http.StatusFound)
+				return
+			}
+			slog.Warn("API request without valid credentials", "path", r.URL.Path, "remote", remoteHost(r))
+			if a.username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="`+authRealm+`", charset="UTF-8"`)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "Authentication required"})
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+	}
+}
+
+// RequireForStatus wraps a read-only handler, requiring credentials only
+// when status is protected.
+func (a *Auth) RequireForStatus(handler http.HandlerFunc) http.HandlerFunc {
+	if a == nil || !a.protectStatus {
+		return handler
+	}
+	return a.Require(handler)
+}
+
+// User returns who r is authenticated as, or "" when it carries no valid
+// credentials: the basic auth username, "token:" and a prefix of the
+// token's hash for bearer tokens, or the single sign-on user.
+func (a *Auth) User(r *http.Request) string {
+	if a == nil {
+		return ""
+	}
+
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		for _, want := range a.tokens {
+			if secretEqual(token, want) {
+				sum := sha256.Sum256([]byte(want))
+				return "token:" + hex.EncodeToString(sum[:4])
+			}
+		}
+		return ""
+	}
+
+	if username, password, ok := r.BasicAuth(); ok {
+		if a.username == "" {
+			return ""
+		}
+		// Both comparisons run, so the time taken does not reveal which failed.
+		userOK := secretEqual(username, a.username)
+		passwordOK := secretEqual(password, a.password)
+		if userOK && passwordOK {
+			return a.username
+		}
+		return ""
+	}
+
+	if a.oidc != nil {
+		return a.oidc.User(r)
+	}
+	return ""
+}
+
+// RequestUser returns the user a handler wrapped by Require was called
+// for, or "" outside Require.
+func RequestUser(r *http.Request) string {
+	user, _ := r.Context().Value(userKey{}).(string)
+	return user
+}
+
+// secretEqual compares secrets in constant time. Hashing first keeps the
+// comparison from revealing the expected length.
+func secretEqual(got, want string) bool {
+	gotSum, wantSum := sha256.Sum256([]byte(got)), sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(gotSum[:], wantSum[:]) == 1
+}
+
+// remoteHost returns the request's remote IP, for logging and rate
+// limiting.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,128 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - API Authentication Tests
+//
+// Unit tests for basic auth and bearer tokens on the dashboard and
+// aggregator endpoints.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestAuth_Dashboard verifies rotation requires credentials while status
+// stays open, and both require them when status is protected.
+func TestAuth_Dashboard(t *testing.T) {
+	tests := []struct {
+		name          string
+		protectStatus bool
+		method        string
+		path          string
+		credentials   func(*http.Request)
+		want          int
+	}{
+		{"open status", false, http.MethodGet, "/api/status", nil, http.StatusOK},
+		{"rotate without credentials", false, http.MethodPost, "/api/rotate/all", nil, http.StatusUnauthorized},
+		{"rotate with wrong token", false, http.MethodPost, "/api/rotate/all", bearer("wrong"), http.StatusUnauthorized},
+		{"rotate with token", false, http.MethodPost, "/api/rotate/all?dry_run=true", bearer("s3cret"), http.StatusOK},
+		{"rotate with basic auth", false, http.MethodPost, "/api/rotate/all?dry_run=true", basic("admin", "hunter2"), http.StatusOK},
+		{"rotate with wrong password", false, http.MethodPost, "/api/rotate/all", basic("admin", "wrong"), http.StatusUnauthorized},
+		{"protected status", true, http.MethodGet, "/api/status", nil, http.StatusUnauthorized},
+		{"protected dashboard", true, http.MethodGet, "/", nil, http.StatusUnauthorized},
+		{"protected status with token", true, http.MethodGet, "/api/status", bearer("s3cret"), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dashboard := NewDashboard(cert.NewManager(nil), nil)
+			dashboard.SetAuth(NewAuth("admin", "hunter2", []string{"s3cret"}, tt.protectStatus))
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.credentials != nil {
+				tt.credentials(req)
+			}
+			rec := httptest.NewRecorder()
+			dashboard.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("expected a WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+// TestAggregator_NodeToken verifies the aggregator sends its node token on
+// status fetches and proxied rotate requests.
+func TestAggregator_NodeToken(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.URL.Path+" "+r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	node := ConsulService{Node: "web-1", Address: host, ServicePort: port}
+
+	aggregator := NewAggregator(newTestConsul(t, []ConsulService{node}), "vault-cert-manager", time.Second)
+	aggregator.SetAuth(NewAuth("", "", []string{"operator"}, false))
+	aggregator.SetNodeToken("node-secret")
+
+	aggregator.fetchNodeStatus(node)
+	req := httptest.NewRequest(http.MethodPost, "/api/rotate/web-1/all", nil)
+	bearer("operator")(req)
+	rec := httptest.NewRecorder()
+	aggregator.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, request := range []string{"/api/status Bearer node-secret", "/api/rotate/all Bearer node-secret"} {
+		found := false
+		for _, s := range seen {
+			found = found || s == request
+		}
+		if !found {
+			t.Errorf("expected request %q, got %v", request, seen)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// bearer returns a function adding a bearer token to a request.
+func bearer(token string) func(*http.Request) {
+	return func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// basic returns a function adding basic auth credentials to a request.
+func basic(username, password string) func(*http.Request) {
+	return func(r *http.Request) {
+		r.SetBasicAuth(username, password)
+	}
+}
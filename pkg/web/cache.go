@@ -0,0 +1,56 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Conditional Responses
+//
+// ETag and Last-Modified support for the JSON status APIs. Frequent pollers
+// such as wall dashboards get 304 Not Modified while the payload is
+// unchanged, instead of re-downloading the full status on every poll.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// conditionalJSON tracks the last payload served by an endpoint so it can
+// report when that payload last changed.
+type conditionalJSON struct {
+	mu       sync.Mutex
+	etag     string
+	modified time.Time
+}
+
+// serve encodes v and writes it with ETag, Last-Modified, and Cache-Control
+// headers, answering 304 Not Modified when the client's validators match.
+func (c *conditionalJSON) serve(w http.ResponseWriter, r *http.Request, v interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, "Failed to encode response: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.mu.Lock()
+	if etag != c.etag {
+		c.etag = etag
+		// HTTP dates have second precision; truncate so If-Modified-Since
+		// comparisons match.
+		c.modified = time.Now().UTC().Truncate(time.Second)
+	}
+	modified := c.modified
+	c.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+
+	http.ServeContent(w, r, "", modified, bytes.NewReader(buf.Bytes()))
+}
@@ -0,0 +1,78 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Consul Client
+//
+// How the aggregator connects to Consul: the ACL token sent with discovery
+// queries and the TLS settings for Consul agents serving HTTPS, following
+// the CONSUL_* environment variables the consul CLI reads.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ConsulClientConfig holds the aggregator's Consul credentials.
+type ConsulClientConfig struct {
+	Token         string // ACL token sent as X-Consul-Token
+	CAFile        string // CA bundle verifying Consul's certificate, the system roots when empty
+	CertFile      string // client certificate, for agents with verify_incoming
+	KeyFile       string
+	TLSServerName string // name verified in Consul's certificate instead of the address's host
+}
+
+// ConsulAddrFromEnv returns the Consul address from CONSUL_HTTP_ADDR, with
+// an https scheme when CONSUL_HTTP_SSL is true and the address has none. It
+// returns "" when the variable is unset.
+func ConsulAddrFromEnv() string {
+	addr := os.Getenv("CONSUL_HTTP_ADDR")
+	if addr == "" || strings.Contains(addr, "://") {
+		return addr
+	}
+	if os.Getenv("CONSUL_HTTP_SSL") == "true" {
+		return "https://" + addr
+	}
+	return "http://" + addr
+}
+
+// ConsulClientConfigFromEnv reads CONSUL_HTTP_TOKEN, or the token in
+// CONSUL_HTTP_TOKEN_FILE, and CONSUL_CACERT, CONSUL_CLIENT_CERT,
+// CONSUL_CLIENT_KEY, and CONSUL_TLS_SERVER_NAME.
+func ConsulClientConfigFromEnv() (ConsulClientConfig, error) {
+	cfg := ConsulClientConfig{
+		Token:         os.Getenv("CONSUL_HTTP_TOKEN"),
+		CAFile:        os.Getenv("CONSUL_CACERT"),
+		CertFile:      os.Getenv("CONSUL_CLIENT_CERT"),
+		KeyFile:       os.Getenv("CONSUL_CLIENT_KEY"),
+		TLSServerName: os.Getenv("CONSUL_TLS_SERVER_NAME"),
+	}
+	if path := os.Getenv("CONSUL_HTTP_TOKEN_FILE"); cfg.Token == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return ConsulClientConfig{}, fmt.Errorf("failed to read CONSUL_HTTP_TOKEN_FILE: %w", err)
+		}
+		cfg.Token = strings.TrimSpace(string(data))
+	}
+	return cfg, nil
+}
+
+// SetConsulClient sends cfg's token with every Consul query and connects
+// to Consul with its TLS settings. The client certificate is read again on
+// every handshake, so one renewed on disk is used without a restart.
+func (a *Aggregator) SetConsulClient(cfg ConsulClientConfig) error {
+	tlsConfig, err := clientTLSConfig(cfg.CAFile, cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to configure Consul TLS: %w", err)
+	}
+	tlsConfig.ServerName = cfg.TLSServerName
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	a.consulClient = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	a.consulToken = cfg.Token
+	return nil
+}
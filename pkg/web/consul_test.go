@@ -0,0 +1,109 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Consul Client Tests
+//
+// Unit tests for the aggregator's Consul token and TLS settings.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestAggregator_SetConsulClient verifies discovery queries carry the ACL
+// token and verify Consul's certificate against the configured CA.
+func TestAggregator_SetConsulClient(t *testing.T) {
+	var token string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Consul-Token")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(consulHealthEntries([]ConsulService{{Node: "node-a", Address: "10.0.0.5", ServicePort: 9101}}))
+	}))
+	t.Cleanup(server.Close)
+
+	aggregator := NewAggregator(server.URL, "vault-cert-manager", time.Second)
+	if _, err := aggregator.discoverServices(); err == nil {
+		t.Fatal("expected Consul's certificate to be untrusted without its CA")
+	}
+
+	caFile := filepath.Join(t.TempDir(), "consul-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatalf("failed to write CA file: %v", err)
+	}
+	if err := aggregator.SetConsulClient(ConsulClientConfig{Token: "s3cret", CAFile: caFile}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	services, err := aggregator.discoverServices()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(services) != 1 || token != "s3cret" {
+		t.Errorf("expected one node queried with the token, got %+v and token %q", services, token)
+	}
+
+	for _, cfg := range []ConsulClientConfig{
+		{CAFile: filepath.Join(t.TempDir(), "missing.pem")},
+		{CertFile: caFile},
+	} {
+		if err := aggregator.SetConsulClient(cfg); err == nil {
+			t.Errorf("%+v: expected an error", cfg)
+		}
+	}
+}
+
+// TestConsulClientConfigFromEnv verifies the consul CLI's environment
+// variables are honored, the token taking precedence over the token file.
+func TestConsulClientConfigFromEnv(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	t.Setenv("CONSUL_HTTP_TOKEN", "")
+	t.Setenv("CONSUL_HTTP_TOKEN_FILE", tokenFile)
+	t.Setenv("CONSUL_CACERT", "/etc/consul/ca.pem")
+	t.Setenv("CONSUL_CLIENT_CERT", "/etc/consul/client.pem")
+	t.Setenv("CONSUL_CLIENT_KEY", "/etc/consul/client-key.pem")
+	t.Setenv("CONSUL_TLS_SERVER_NAME", "consul.service.consul")
+	t.Setenv("CONSUL_HTTP_ADDR", "consul:8501")
+	t.Setenv("CONSUL_HTTP_SSL", "true")
+
+	cfg, err := ConsulClientConfigFromEnv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := ConsulClientConfig{
+		Token:         "from-file",
+		CAFile:        "/etc/consul/ca.pem",
+		CertFile:      "/etc/consul/client.pem",
+		KeyFile:       "/etc/consul/client-key.pem",
+		TLSServerName: "consul.service.consul",
+	}
+	if cfg != want {
+		t.Errorf("expected %+v, got %+v", want, cfg)
+	}
+	if addr := ConsulAddrFromEnv(); addr != "https://consul:8501" {
+		t.Errorf("expected https://consul:8501, got %s", addr)
+	}
+
+	t.Setenv("CONSUL_HTTP_TOKEN", "from-env")
+	if cfg, _ := ConsulClientConfigFromEnv(); cfg.Token != "from-env" {
+		t.Errorf("expected CONSUL_HTTP_TOKEN to take precedence, got %q", cfg.Token)
+	}
+}
@@ -11,15 +11,20 @@ package web
 import (
 	"embed"
 	"encoding/json"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"cert-manager/pkg/cert"
+	"cert-manager/pkg/cloud"
 	"cert-manager/pkg/health"
+	"cert-manager/pkg/watch"
 )
 
 //go:embed templates/*.html
@@ -30,19 +35,84 @@ type Dashboard struct {
 	certManager   *cert.Manager
 	healthChecker health.Checker
 	templates     *template.Template
+	statusCache   conditionalJSON
+	queryCache    conditionalJSON // filtered or sorted status, apart from statusCache for its pollers
+	revisions     *statusRevisions
+	rateLimiter   *RateLimiter
+	identity      *cloud.Identity
+	watchScanner  *watch.Scanner
+	buildInfo     func() BuildInfo
+	auth          *Auth
+	eventInterval time.Duration
+
+	// closing is closed by CloseStreams to end the /api/events streams.
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NodeInfo describes the node serving the dashboard.
+type NodeInfo struct {
+	Hostname string          `json:"hostname"`
+	Cloud    *cloud.Identity `json:"cloud,omitempty"`
+
+	BuildInfo
+}
+
+// BuildInfo identifies the build and configuration a node runs.
+type BuildInfo struct {
+	Version    string `json:"version,omitempty"`
+	Commit     string `json:"commit,omitempty"`
+	ConfigHash string `json:"config_hash,omitempty"`
 }
 
 // CertStatus represents certificate status for the dashboard.
 type CertStatus struct {
-	Name              string    `json:"name"`
-	CommonName        string    `json:"common_name"`
-	NotAfter          time.Time `json:"not_after"`
-	DaysLeft          int       `json:"days_left"`
-	Fingerprint       string    `json:"fingerprint"`
-	MemoryFingerprint string    `json:"memory_fingerprint,omitempty"`
-	OutOfSync         bool      `json:"out_of_sync"`
-	LastRenewed       time.Time `json:"last_renewed"`
-	Status            string    `json:"status"` // "healthy", "expiring", "critical", "out_of_sync"
+	Name              string               `json:"name"`
+	CommonName        string               `json:"common_name"`
+	Source            string               `json:"source,omitempty"` // pki, kv, acme, or ca_bundle
+	Role              string               `json:"role,omitempty"`
+	Issuer            string               `json:"issuer,omitempty"`
+	KeyAlgorithm      string               `json:"key_algorithm,omitempty"`
+	KeyBits           int                  `json:"key_bits,omitempty"`
+	NotAfter          time.Time            `json:"not_after"`
+	DaysLeft          int                  `json:"days_left"`
+	Fingerprint       string               `json:"fingerprint"`
+	MemoryFingerprint string               `json:"memory_fingerprint,omitempty"`
+	OutOfSync         bool                 `json:"out_of_sync"`
+	Untrusted         bool                 `json:"untrusted,omitempty"`
+	Shadow            bool                 `json:"shadow,omitempty"`
+	Renewing          bool                 `json:"renewing,omitempty"` // an issuance attempt is in progress
+	LastRenewed       time.Time            `json:"last_renewed"`
+	Renewals          cert.RenewalCounts   `json:"renewals"`
+	LastFailure       time.Time            `json:"last_failure,omitzero"`
+	LastError         string               `json:"last_error,omitempty"`
+	Status            string               `json:"status"` // "healthy", "expiring", "critical", "out_of_sync"
+	History           []cert.RotationEvent `json:"history,omitempty"`
+	Metadata          map[string]string    `json:"metadata,omitempty"`
+	Labels            map[string]string    `json:"labels,omitempty"`
+
+	// ExpiringIntermediate and ChainNotAfter name the served intermediate
+	// that expires before the certificate, and when.
+	ExpiringIntermediate string    `json:"expiring_intermediate,omitempty"`
+	ChainNotAfter        time.Time `json:"chain_not_after,omitzero"`
+
+	// LastAttempt, ConsecutiveFailures, and VaultReachable tell a valid
+	// certificate whose renewals keep failing from a healthy one.
+	LastAttempt         time.Time `json:"last_attempt,omitzero"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	VaultReachable      *bool     `json:"vault_reachable,omitempty"` // whether the last Vault request reached Vault
+}
+
+// VaultUnreachable reports whether the certificate's last Vault request
+// did not reach Vault.
+func (s CertStatus) VaultUnreachable() bool {
+	return s.VaultReachable != nil && !*s.VaultReachable
+}
+
+// PlanResponse is returned by dry-run rotation requests and /api/plan.
+type PlanResponse struct {
+	DryRun bool                   `json:"dry_run"`
+	Plan   []cert.PlannedRotation `json:"plan"`
 }
 
 // NewDashboard creates a new dashboard instance.
@@ -54,21 +124,71 @@ func NewDashboard(certManager *cert.Manager, healthChecker health.Checker) *Dash
 			}
 			return t.Format("2006-01-02 15:04:05")
 		},
-	}).ParseFS(templateFS, "templates/*.html"))
+	}).ParseFS(templateFS, "templates/dashboard.html", "templates/cert.html"))
 
 	return &Dashboard{
 		certManager:   certManager,
 		healthChecker: healthChecker,
 		templates:     tmpl,
+		revisions:     newStatusRevisions(),
+		eventInterval: nodeEventInterval,
+		closing:       make(chan struct{}),
 	}
 }
 
+// SetRateLimiter limits mutating requests to the rotation endpoints.
+func (d *Dashboard) SetRateLimiter(limiter *RateLimiter) {
+	d.rateLimiter = limiter
+}
+
+// SetNodeIdentity shows the node's cloud identity on the dashboard and
+// /api/node.
+func (d *Dashboard) SetNodeIdentity(identity *cloud.Identity) {
+	d.identity = identity
+}
+
+// SetAuth requires credentials for the rotation endpoints, and for the
+// dashboard and read-only endpoints when auth protects status.
+func (d *Dashboard) SetAuth(auth *Auth) {
+	d.auth = auth
+}
+
+// SetBuildInfo shows the build and configuration the node runs on the
+// dashboard and /api/node. info is called on every request, so a reloaded
+// configuration is reported without rebuilding the dashboard.
+func (d *Dashboard) SetBuildInfo(info func() BuildInfo) {
+	d.buildInfo = info
+}
+
+// Handler returns the dashboard page and its API as one http.Handler for
+// mounting on an existing server. Under a path prefix, wrap it in
+// http.StripPrefix; the page calls the API with relative URLs.
+func (d *Dashboard) Handler() http.Handler {
+	mux := http.NewServeMux()
+	d.RegisterHandlers(mux)
+	return mux
+}
+
+// StatusHandler returns the /api/status handler on its own, for servers
+// that expose the status API without the dashboard.
+func (d *Dashboard) StatusHandler() http.Handler {
+	return d.auth.RequireForStatus(d.handleAPIStatus)
+}
+
 // RegisterHandlers registers the dashboard HTTP handlers.
 func (d *Dashboard) RegisterHandlers(mux *http.ServeMux) {
-	mux.HandleFunc("/", d.handleDashboard)
-	mux.HandleFunc("/api/status", d.handleAPIStatus)
-	mux.HandleFunc("/api/rotate/all", d.handleAPIRotateAll)
-	mux.HandleFunc("/api/rotate/", d.handleAPIRotateCert)
+	mux.HandleFunc("/", d.auth.RequireForStatus(d.handleDashboard))
+	mux.HandleFunc("/api/status", d.auth.RequireForStatus(d.handleAPIStatus))
+	mux.HandleFunc("/api/node", d.auth.RequireForStatus(d.handleAPINode))
+	mux.HandleFunc("/api/watch", d.auth.RequireForStatus(d.handleAPIWatch))
+	mux.HandleFunc("/api/events", d.auth.RequireForStatus(d.handleAPIEvents))
+	mux.HandleFunc("/api/certs/", d.auth.RequireForStatus(d.handleAPICert))
+	mux.HandleFunc("/api/ca", d.auth.RequireForStatus(d.handleAPICA))
+	mux.HandleFunc("/certs/", d.auth.RequireForStatus(d.handleCertPage))
+	mux.HandleFunc("/api/plan", d.auth.RequireForStatus(d.handleAPIPlan))
+	mux.HandleFunc("/api/rotate/all", d.auth.Require(d.rateLimiter.Wrap("rotate_all", d.handleAPIRotateAll)))
+	mux.HandleFunc("/api/rotate/", d.auth.Require(d.rateLimiter.Wrap("rotate", d.handleAPIRotateCert)))
+	d.auth.RegisterHandlers(mux)
 }
 
 // handleDashboard serves the main dashboard page.
@@ -78,14 +198,33 @@ func (d *Dashboard) handleDashboard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query, err := parseStatusQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	statuses := d.getCertStatuses()
 
 	data := struct {
 		Hostname string
+		Cloud    *cloud.Identity
+		Build    BuildInfo
 		Certs    []CertStatus
+		Total    int
+		Query    statusQuery
+		Watched  []WatchStatus
+		SSO      bool
+		User     string
 	}{
 		Hostname: getHostname(),
-		Certs:    statuses,
+		Cloud:    d.identity,
+		Build:    d.currentBuildInfo(),
+		Certs:    query.apply(statuses),
+		Total:    len(statuses),
+		Query:    query,
+		Watched:  d.getWatchStatuses(),
+		SSO:      d.auth.SSO(),
+		User:     d.auth.User(r),
 	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -95,27 +234,105 @@ func (d *Dashboard) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleAPIStatus returns certificate status as JSON.
+// handleAPIStatus returns certificate status as JSON, or with ?since=
+// only the certificates that changed after a revision or time, narrowed
+// and ordered by the status, search, and sort parameters.
 func (d *Dashboard) handleAPIStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	query, err := parseStatusQuery(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
 	statuses := d.getCertStatuses()
+	w.Header().Set(epochHeader, d.revisions.epoch)
+
+	since := r.URL.Query().Get("since")
+	if since == "" {
+		w.Header().Set(revisionHeader, strconv.FormatUint(d.revisions.observe(statuses), 10))
+		if query.empty() {
+			d.statusCache.serve(w, r, statuses)
+		} else {
+			d.queryCache.serve(w, r, query.apply(statuses))
+		}
+		return
+	}
+
+	delta, err := d.revisions.delta(statuses, since)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if !query.empty() {
+		delta = query.applyDelta(delta)
+	}
+	w.Header().Set(revisionHeader, strconv.FormatUint(delta.Revision, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	_ = json.NewEncoder(w).Encode(delta)
+}
+
+// handleAPINode returns the node's hostname, cloud identity, and build
+// information as JSON.
+func (d *Dashboard) handleAPINode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(statuses)
+	_ = json.NewEncoder(w).Encode(NodeInfo{Hostname: getHostname(), Cloud: d.identity, BuildInfo: d.currentBuildInfo()})
+}
+
+// currentBuildInfo returns the node's build information, empty when none
+// was set.
+func (d *Dashboard) currentBuildInfo() BuildInfo {
+	if d.buildInfo == nil {
+		return BuildInfo{}
+	}
+	return d.buildInfo()
 }
 
-// handleAPIRotateAll forces rotation of all certificates.
+// handleAPIPlan returns what the next processing pass would do.
+func (d *Dashboard) handleAPIPlan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(PlanResponse{DryRun: true, Plan: d.certManager.Plan()})
+}
+
+// handleAPIRotateAll forces rotation of all certificates, or with
+// ?dry_run=true returns what would be rotated.
 func (d *Dashboard) handleAPIRotateAll(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	slog.Info("API request to rotate all certificates")
+	dryRun, err := dryRunParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dryRun {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(PlanResponse{DryRun: true, Plan: d.certManager.PlanForceRotateAll()})
+		return
+	}
+
+	slog.Info("API request to rotate all certificates", "user", RequestUser(r))
 	if err := d.certManager.ForceRotateAll(); err != nil {
 		slog.Error("Failed to rotate certificates", "error", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -128,7 +345,8 @@ func (d *Dashboard) handleAPIRotateAll(w http.ResponseWriter, r *http.Request) {
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok", "message": "All certificates rotated"})
 }
 
-// handleAPIRotateCert forces rotation of a specific certificate.
+// handleAPIRotateCert forces rotation of a specific certificate, or with
+// ?dry_run=true returns what would be rotated.
 func (d *Dashboard) handleAPIRotateCert(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -144,7 +362,24 @@ func (d *Dashboard) handleAPIRotateCert(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	slog.Info("API request to rotate certificate", "certificate", certName)
+	dryRun, err := dryRunParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if dryRun {
+		plan, err := d.certManager.PlanForceRotate(certName)
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(PlanResponse{DryRun: true, Plan: []cert.PlannedRotation{plan}})
+		return
+	}
+
+	slog.Info("API request to rotate certificate", "certificate", certName, "user", RequestUser(r))
 	if err := d.certManager.ForceRotate(certName); err != nil {
 		slog.Error("Failed to rotate certificate", "certificate", certName, "error", err)
 		w.Header().Set("Content-Type", "application/json")
@@ -157,49 +392,80 @@ func (d *Dashboard) handleAPIRotateCert(w http.ResponseWriter, r *http.Request)
 	_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok", "message": "Certificate rotated", "name": certName})
 }
 
+// dryRunParam parses the optional dry_run query parameter.
+func dryRunParam(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("dry_run")
+	if value == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid dry_run %q", value)
+	}
+	return dryRun, nil
+}
+
 // getCertStatuses builds status info for all managed certificates.
 func (d *Dashboard) getCertStatuses() []CertStatus {
 	var statuses []CertStatus
+	for _, managed := range d.certManager.Snapshot() {
+		statuses = append(statuses, d.certStatus(managed))
+	}
+	return statuses
+}
 
-	for name, managed := range d.certManager.GetManagedCertificates() {
-		status := CertStatus{
-			Name:        name,
-			CommonName:  managed.Config.CommonName,
-			Fingerprint: managed.Fingerprint,
-			LastRenewed: managed.LastRenewed,
-		}
+// certStatus builds the status of one managed certificate, checking its
+// health_check target when it has one.
+func (d *Dashboard) certStatus(managed *cert.ManagedCertificate) CertStatus {
+	status := CertStatus{
+		Name:        managed.Config.Name,
+		CommonName:  managed.Config.CommonName,
+		Source:      managed.Config.Source,
+		Role:        managed.Config.Role,
+		Fingerprint: managed.Fingerprint,
+		LastRenewed: managed.LastRenewed,
+		Renewals:    managed.Renewals,
+		LastFailure: managed.LastFailure,
+		LastError:   managed.LastError,
+		History:     managed.History,
+		Metadata:    managed.Config.Metadata,
+		Labels:      managed.Config.Labels,
+		Shadow:      managed.Config.Shadow,
+		Renewing:    managed.Renewing,
 
-		if managed.Certificate != nil {
-			status.NotAfter = managed.Certificate.NotAfter
-			status.DaysLeft = int(time.Until(managed.Certificate.NotAfter).Hours() / 24)
-
-			switch {
-			case status.DaysLeft <= 7:
-				status.Status = "critical"
-			case status.DaysLeft <= 30:
-				status.Status = "expiring"
-			default:
-				status.Status = "healthy"
-			}
-		} else {
-			status.Status = "unknown"
-		}
+		LastAttempt:         managed.LastAttempt,
+		ConsecutiveFailures: managed.ConsecutiveFailures,
+		VaultReachable:      managed.VaultReachable,
+	}
 
-		// Check if certificate is out of sync (disk != memory)
-		if d.healthChecker != nil && managed.Config.HealthCheck != nil {
-			result, err := d.healthChecker.Check(managed)
-			if err == nil && result.Success && result.RemoteFingerprint != "" {
-				status.MemoryFingerprint = result.RemoteFingerprint
-				if managed.Fingerprint != "" && result.RemoteFingerprint != managed.Fingerprint {
-					status.OutOfSync = true
-				}
+	if managed.Certificate != nil {
+		status.Issuer = managed.Certificate.Issuer.CommonName
+		status.KeyAlgorithm, status.KeyBits = cert.PublicKeyInfo(managed.Certificate)
+		status.NotAfter = managed.Certificate.NotAfter
+		status.DaysLeft = cert.DaysLeft(managed.Certificate.NotAfter)
+		status.Status = expiryStatus(status.DaysLeft)
+	} else {
+		status.Status = "unknown"
+	}
+
+	// Check if certificate is out of sync (disk != memory). Shadow
+	// certificates are never served, so only the served one is shown.
+	if d.healthChecker != nil && managed.Config.HealthCheck != nil {
+		result, err := d.healthChecker.Check(managed)
+		if err == nil && (result.Success || result.Untrusted) && result.RemoteFingerprint != "" {
+			status.MemoryFingerprint = result.RemoteFingerprint
+			status.Untrusted = result.Untrusted
+			if intermediate := result.ExpiringIntermediate(); intermediate != nil {
+				status.ExpiringIntermediate = intermediate.Subject.CommonName
+				status.ChainNotAfter = intermediate.NotAfter
+			}
+			if !status.Shadow && managed.Fingerprint != "" && result.RemoteFingerprint != managed.Fingerprint {
+				status.OutOfSync = true
 			}
 		}
-
-		statuses = append(statuses, status)
 	}
 
-	return statuses
+	return status
 }
 
 func getHostname() string {
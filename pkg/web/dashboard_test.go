@@ -0,0 +1,103 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Dashboard Tests
+//
+// Unit tests for the per-node dashboard.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestDashboard_HandleDashboard verifies the dashboard page renders.
+func TestDashboard_HandleDashboard(t *testing.T) {
+	dashboard := NewDashboard(cert.NewManager(nil), nil)
+	mux := http.NewServeMux()
+	dashboard.RegisterHandlers(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestDashboard_Handler verifies the dashboard can be mounted under a path
+// prefix on another server's mux.
+func TestDashboard_Handler(t *testing.T) {
+	dashboard := NewDashboard(cert.NewManager(nil), nil)
+	mux := http.NewServeMux()
+	mux.Handle("/certs/", http.StripPrefix("/certs", dashboard.Handler()))
+	mux.Handle("/status", dashboard.StatusHandler())
+
+	for _, path := range []string{"/certs/", "/certs/api/status", "/certs/api/node", "/status"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("expected 200 for %s, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// TestDashboard_RotateDryRun verifies dry-run rotation requests return the
+// plan without rotating anything.
+func TestDashboard_RotateDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := cert.NewManager(nil)
+	if err := manager.AddCertificate(&config.CertificateConfig{
+		Name:        "web",
+		Certificate: filepath.Join(tmpDir, "web.crt"),
+		Key:         filepath.Join(tmpDir, "web.key"),
+	}); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	handler := NewDashboard(manager, nil).Handler()
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		reason string
+	}{
+		{http.MethodPost, "/api/rotate/all?dry_run=true", http.StatusOK, cert.PlanForced},
+		{http.MethodPost, "/api/rotate/web?dry_run=1", http.StatusOK, cert.PlanForced},
+		{http.MethodGet, "/api/plan", http.StatusOK, cert.PlanMissing},
+		{http.MethodPost, "/api/rotate/unknown?dry_run=true", http.StatusNotFound, ""},
+		{http.MethodPost, "/api/rotate/all?dry_run=maybe", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("%s: expected %d, got %d: %s", tt.path, tt.code, rec.Code, rec.Body.String())
+			continue
+		}
+		if tt.reason == "" {
+			continue
+		}
+		var resp PlanResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s: invalid JSON: %v", tt.path, err)
+		}
+		if !resp.DryRun || len(resp.Plan) != 1 || resp.Plan[0].Reason != tt.reason {
+			t.Errorf("%s: unexpected plan %+v", tt.path, resp)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Certificate Details
+//
+// Everything known about one certificate, for /api/certs/{name} and the
+// dashboard's detail page: the parsed certificate with its subject and
+// alternative names, the CA chain on disk, the rotation history, and the
+// output of the last on_change run. The deployed certificates and CA chains
+// can be downloaded as PEM; private keys never leave the host.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/vault"
+)
+
+// CertDetail is the /api/certs/{name} response.
+type CertDetail struct {
+	CertStatus
+
+	Subject            string            `json:"subject,omitempty"`
+	IssuerSubject      string            `json:"issuer_subject,omitempty"`
+	Serial             string            `json:"serial,omitempty"`
+	NotBefore          time.Time         `json:"not_before,omitzero"`
+	SignatureAlgorithm string            `json:"signature_algorithm,omitempty"`
+	DNSNames           []string          `json:"dns_names,omitempty"`
+	IPAddresses        []string          `json:"ip_addresses,omitempty"`
+	URIs               []string          `json:"uris,omitempty"`
+	EmailAddresses     []string          `json:"email_addresses,omitempty"`
+	NextRenewal        time.Time         `json:"next_renewal,omitzero"`
+	Chain              []ChainCert       `json:"chain,omitempty"`
+	ChainError         string            `json:"chain_error,omitempty"`
+	LastHook           *cert.HookRun     `json:"last_hook,omitempty"`
+	HookFailures       cert.HookFailures `json:"hook_failures"`
+}
+
+// ChainCert describes a CA certificate of a certificate's chain.
+type ChainCert struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Serial      string    `json:"serial"`
+	NotAfter    time.Time `json:"not_after"`
+	Fingerprint string    `json:"fingerprint"` // SHA-256 of the DER, as for the certificate
+}
+
+// pemContentType is the media type of PEM downloads.
+const pemContentType = "application/x-pem-file"
+
+// handleAPICert returns the details of one certificate as JSON, or its
+// PEM.
+// Path format: /api/certs/{name} or /api/certs/{name}/pem
+func (d *Dashboard) handleAPICert(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/api/certs/")
+	if name, ok := strings.CutSuffix(name, "/pem"); ok {
+		d.serveCertPEM(w, name, r.URL.Query().Get("chain") != "false")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "Certificate name required"})
+		return
+	}
+
+	detail, ok := d.certDetail(name)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("certificate %s not found", name)})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(detail)
+}
+
+// serveCertPEM writes the named certificate as deployed, followed by its
+// chain on disk unless withChain is false. The private key is never
+// served.
+func (d *Dashboard) serveCertPEM(w http.ResponseWriter, name string, withChain bool) {
+	var managed *cert.ManagedCertificate
+	for _, m := range d.certManager.Snapshot() {
+		if m.Config.Name == name {
+			managed = m
+		}
+	}
+	if managed == nil || managed.Certificate == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("certificate %s not found", name)})
+		return
+	}
+
+	var chain []*x509.Certificate
+	if withChain {
+		var err error
+		if chain, err = d.certManager.ChainOnDisk(name); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, c := range append([]*x509.Certificate{managed.Certificate}, chain...) {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	w.Header().Set("Content-Type", pemContentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleAPICA returns every CA certificate in the chains on disk as one
+// PEM bundle, each once.
+func (d *Dashboard) handleAPICA(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var buf bytes.Buffer
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, managed := range d.certManager.Snapshot() {
+		chain, err := d.certManager.ChainOnDisk(managed.Config.Name)
+		if err != nil {
+			slog.Warn("Failed to read CA chain for /api/ca", "certificate", managed.Config.Name, "error", err)
+			continue
+		}
+		for _, ca := range chain {
+			sum := sha256.Sum256(ca.Raw)
+			if seen[sum] {
+				continue
+			}
+			seen[sum] = true
+			_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw})
+		}
+	}
+
+	if buf.Len() == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "no CA certificates on disk"})
+		return
+	}
+	w.Header().Set("Content-Type", pemContentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleCertPage serves the detail page of one certificate.
+// Path format: /certs/{name}
+func (d *Dashboard) handleCertPage(w http.ResponseWriter, r *http.Request) {
+	detail, ok := d.certDetail(strings.TrimPrefix(r.URL.Path, "/certs/"))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	history := slices.Clone(detail.History)
+	slices.Reverse(history)
+
+	data := struct {
+		Hostname string
+		Cert     CertDetail
+		History  []cert.RotationEvent // newest first
+		SSO      bool
+		User     string
+	}{
+		Hostname: getHostname(),
+		Cert:     detail,
+		History:  history,
+		SSO:      d.auth.SSO(),
+		User:     d.auth.User(r),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := d.templates.ExecuteTemplate(w, "cert.html", data); err != nil {
+		slog.Error("Failed to render certificate page", "certificate", detail.Name, "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// certDetail builds the details of the named certificate.
+func (d *Dashboard) certDetail(name string) (CertDetail, bool) {
+	for _, managed := range d.certManager.Snapshot() {
+		if managed.Config.Name != name {
+			continue
+		}
+
+		detail := CertDetail{
+			CertStatus:   d.certStatus(managed),
+			NextRenewal:  managed.NextRenewal,
+			LastHook:     managed.LastHook,
+			HookFailures: managed.HookFailures,
+		}
+		if c := managed.Certificate; c != nil {
+			detail.Subject = c.Subject.String()
+			detail.IssuerSubject = c.Issuer.String()
+			detail.Serial = vault.FormatSerial(c.SerialNumber.Bytes())
+			detail.NotBefore = c.NotBefore
+			detail.SignatureAlgorithm = c.SignatureAlgorithm.String()
+			detail.DNSNames = c.DNSNames
+			detail.EmailAddresses = c.EmailAddresses
+			for _, ip := range c.IPAddresses {
+				detail.IPAddresses = append(detail.IPAddresses, ip.String())
+			}
+			for _, uri := range c.URIs {
+				detail.URIs = append(detail.URIs, uri.String())
+			}
+		}
+
+		chain, err := d.certManager.ChainOnDisk(name)
+		if err != nil {
+			detail.ChainError = err.Error()
+		}
+		for _, ca := range chain {
+			detail.Chain = append(detail.Chain, chainCert(ca))
+		}
+		return detail, true
+	}
+	return CertDetail{}, false
+}
+
+// chainCert describes a CA certificate.
+func chainCert(ca *x509.Certificate) ChainCert {
+	sum := sha256.Sum256(ca.Raw)
+	return ChainCert{
+		Subject:     ca.Subject.String(),
+		Issuer:      ca.Issuer.String(),
+		Serial:      vault.FormatSerial(ca.SerialNumber.Bytes()),
+		NotAfter:    ca.NotAfter,
+		Fingerprint: hex.EncodeToString(sum[:]),
+	}
+}
@@ -0,0 +1,136 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Certificate Detail Tests
+//
+// Unit tests for the certificate detail API and page.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/vault"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestDashboard_CertDetail verifies the API describes the certificate on
+// disk and its chain, the page renders, and unknown names are not found.
+func TestDashboard_CertDetail(t *testing.T) {
+	mux, _ := newDetailDashboard(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/certs/web", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var detail CertDetail
+	if err := json.Unmarshal(rec.Body.Bytes(), &detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if detail.Subject != "CN=example.com" || detail.Serial != "30:39" {
+		t.Errorf("expected subject CN=example.com and serial 30:39, got %q and %q", detail.Subject, detail.Serial)
+	}
+	if len(detail.DNSNames) != 1 || detail.DNSNames[0] != "example.com" {
+		t.Errorf("expected DNS name example.com, got %v", detail.DNSNames)
+	}
+	if len(detail.Chain) != 1 || detail.Chain[0].Serial != "01" || len(detail.Chain[0].Fingerprint) != 64 {
+		t.Errorf("expected the CA in the chain, got %+v", detail.Chain)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/certs/web", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), detail.Chain[0].Fingerprint) {
+		t.Errorf("expected the page to show the chain, got %d", rec.Code)
+	}
+
+	for _, path := range []string{"/api/certs/missing", "/certs/missing"} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("expected 404 for %s, got %d", path, rec.Code)
+		}
+	}
+}
+
+// TestDashboard_CertPEM verifies the certificate is served as deployed,
+// with or without its chain, and the CA bundle holds the chain.
+func TestDashboard_CertPEM(t *testing.T) {
+	mux, certData := newDetailDashboard(t)
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/api/certs/web/pem", certData.Certificate + certData.CertificateChain},
+		{"/api/certs/web/pem?chain=false", certData.Certificate},
+		{"/api/ca", certData.CertificateChain},
+	}
+	for _, tt := range tests {
+		rec := get(tt.url)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != pemContentType {
+			t.Fatalf("%s: expected PEM, got %d %s", tt.url, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tt.url, tt.want, rec.Body.String())
+		}
+		if strings.Contains(rec.Body.String(), "PRIVATE KEY") {
+			t.Errorf("%s: served a private key", tt.url)
+		}
+	}
+
+	if rec := get("/api/certs/missing/pem"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown certificate, got %d", rec.Code)
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// newDetailDashboard serves a dashboard managing "web", deployed with its
+// chain and key from the returned certificate data.
+func newDetailDashboard(t *testing.T) (*http.ServeMux, *vault.CertificateData) {
+	t.Helper()
+
+	dir := t.TempDir()
+	certData := vault.CreateTestCertificateData()
+	certPath := filepath.Join(dir, "web.crt")
+	keyPath := filepath.Join(dir, "web.key")
+	if err := os.WriteFile(certPath, []byte(certData.Certificate+certData.CertificateChain), 0644); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyPath, []byte(certData.PrivateKey), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+
+	manager := cert.NewManager(nil)
+	manager.SyncCertificates([]*config.CertificateConfig{{
+		Name:        "web",
+		CommonName:  "example.com",
+		Certificate: certPath,
+		Key:         keyPath,
+	}})
+	mux := http.NewServeMux()
+	NewDashboard(manager, nil).RegisterHandlers(mux)
+	return mux, certData
+}
@@ -0,0 +1,113 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Static Node Discovery
+//
+// A fixed list of node addresses for aggregators without Consul, given on
+// the command line or in a file re-read on every refresh. Static nodes are
+// queried and rotated exactly like nodes discovered through Consul.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseNodeAddress parses a static node address of the form
+// [name=]host:port. The name defaults to the host.
+func ParseNodeAddress(s string) (ConsulService, error) {
+	s = strings.TrimSpace(s)
+	name, hostPort, named := strings.Cut(s, "=")
+	if !named {
+		hostPort = name
+	}
+
+	host, portStr, err := net.SplitHostPort(strings.TrimSpace(hostPort))
+	if err != nil {
+		return ConsulService{}, fmt.Errorf("invalid node address %q, expected [name=]host:port: %w", s, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 1 || port > 65535 {
+		return ConsulService{}, fmt.Errorf("invalid port in node address %q", s)
+	}
+	if host == "" {
+		return ConsulService{}, fmt.Errorf("missing host in node address %q", s)
+	}
+
+	name = strings.TrimSpace(name)
+	if !named {
+		name = host
+	} else if name == "" {
+		return ConsulService{}, fmt.Errorf("empty name in node address %q", s)
+	}
+	return ConsulService{Node: name, Address: host, ServicePort: port}, nil
+}
+
+// ReadNodesFile reads static node addresses from path, one per line.
+// Blank lines and lines starting with # are skipped.
+func ReadNodesFile(path string) ([]ConsulService, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read nodes file: %w", err)
+	}
+
+	var nodes []ConsulService
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		node, err := ParseNodeAddress(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, scanner.Err()
+}
+
+// SetStaticNodes queries nodes, and the nodes listed in nodesFile when it
+// is set, instead of discovering nodes through Consul. The file is re-read
+// on every refresh, so nodes can be added and removed without a restart;
+// it must be readable, and node names unique, when SetStaticNodes is
+// called.
+func (a *Aggregator) SetStaticNodes(nodes []ConsulService, nodesFile string) error {
+	a.staticNodes = nodes
+	a.nodesFile = nodesFile
+	_, err := a.staticServices()
+	return err
+}
+
+// staticDiscovery reports whether nodes are listed rather than discovered
+// through Consul.
+func (a *Aggregator) staticDiscovery() bool {
+	return len(a.staticNodes) > 0 || a.nodesFile != ""
+}
+
+// staticServices returns the static nodes followed by those in the nodes
+// file. Node names must be unique, since rotations address nodes by name.
+func (a *Aggregator) staticServices() ([]ConsulService, error) {
+	nodes := append([]ConsulService(nil), a.staticNodes...)
+	if a.nodesFile != "" {
+		listed, err := ReadNodesFile(a.nodesFile)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, listed...)
+	}
+
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		if seen[node.Node] {
+			return nil, fmt.Errorf("duplicate node name %q in static nodes", node.Node)
+		}
+		seen[node.Node] = true
+	}
+	return nodes, nil
+}
@@ -0,0 +1,97 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Static Node Discovery Tests
+//
+// Unit tests for static node addresses and nodes files.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestParseNodeAddress verifies node names default to the host and
+// malformed addresses are rejected.
+func TestParseNodeAddress(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ConsulService
+		wantErr bool
+	}{
+		{"10.0.0.5:9101", ConsulService{Node: "10.0.0.5", Address: "10.0.0.5", ServicePort: 9101}, false},
+		{"web-1=web-1.internal:9101", ConsulService{Node: "web-1", Address: "web-1.internal", ServicePort: 9101}, false},
+		{" db = 10.0.0.6:9101 ", ConsulService{Node: "db", Address: "10.0.0.6", ServicePort: 9101}, false},
+		{"web-1.internal", ConsulService{}, true},
+		{"web-1.internal:http", ConsulService{}, true},
+		{":9101", ConsulService{}, true},
+		{"=web-1.internal:9101", ConsulService{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNodeAddress(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: expected error %v, got %v", tt.input, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: expected %+v, got %+v", tt.input, tt.want, got)
+		}
+	}
+}
+
+// TestAggregator_StaticNodes verifies nodes from the command line and the
+// nodes file are queried without Consul, and file changes are picked up on
+// the next refresh.
+func TestAggregator_StaticNodes(t *testing.T) {
+	nodeA := newTestNode(t, []CertStatus{{Name: "web"}})
+	nodeB := newTestNode(t, []CertStatus{{Name: "db"}})
+
+	nodesFile := filepath.Join(t.TempDir(), "nodes")
+	writeNodes := func(content string) {
+		if err := os.WriteFile(nodesFile, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write nodes file: %v", err)
+		}
+	}
+	writeNodes("# fleet\n\n")
+
+	// Consul is unreachable; static discovery must not query it.
+	aggregator := NewAggregator("http://127.0.0.1:1", "vault-cert-manager", time.Second)
+	nodeA.Node = "node-a"
+	if err := aggregator.SetStaticNodes([]ConsulService{nodeA}, nodesFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	statuses, err := aggregator.fetchAllStatuses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Node != "node-a" || len(statuses[0].Certs) != 1 {
+		t.Fatalf("expected node-a with its certificate, got %+v", statuses)
+	}
+
+	writeNodes(fmt.Sprintf("node-b=%s:%d\n", nodeB.Address, nodeB.ServicePort))
+	statuses, err = aggregator.fetchAllStatuses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statuses) != 2 || statuses[1].Node != "node-b" || statuses[1].Certs[0].Name != "db" {
+		t.Errorf("expected node-b from the nodes file, got %+v", statuses)
+	}
+
+	writeNodes(fmt.Sprintf("node-a=%s:%d\n", nodeB.Address, nodeB.ServicePort))
+	if _, err := aggregator.fetchAllStatuses(); err == nil {
+		t.Error("expected an error for a duplicate node name")
+	}
+}
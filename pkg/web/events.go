@@ -0,0 +1,261 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Live Updates
+//
+// Server-Sent Events streams that keep the dashboards current without
+// reloading. A node pushes status deltas as soon as a renewal starts or
+// finishes, and re-checks on an interval for expiry and health check
+// changes. The aggregator polls its nodes differentially while a client is
+// connected and pushes the nodes whose status changed.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// eventStreamContentType is the media type of Server-Sent Events.
+	eventStreamContentType = "text/event-stream"
+
+	// nodeEventInterval is how often a node re-checks status between
+	// renewal signals, picking up health check and expiry changes.
+	nodeEventInterval = 10 * time.Second
+
+	// aggregatorEventInterval is how often the aggregator polls its nodes
+	// for a connected client.
+	aggregatorEventInterval = 5 * time.Second
+)
+
+// eventStream writes Server-Sent Events, flushing after each.
+type eventStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+// newEventStream starts an event stream response, or returns false when w
+// cannot flush.
+func newEventStream(w http.ResponseWriter) (*eventStream, bool) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, false
+	}
+
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	// Keep reverse proxies such as nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &eventStream{w: w, flusher: flusher}, true
+}
+
+// send writes an event of type event with data as JSON. A non-empty id is
+// sent back by browsers as Last-Event-ID when they reconnect.
+func (s *eventStream) send(event, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	if id != "" {
+		b.WriteString("id: " + id + "\n")
+	}
+	b.WriteString("event: " + event + "\n")
+	b.WriteString("data: " + string(payload) + "\n\n")
+	if _, err := s.w.Write([]byte(b.String())); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// keepalive writes a comment, so idle connections are not closed by
+// proxies and disconnected clients are noticed.
+func (s *eventStream) keepalive() error {
+	if _, err := s.w.Write([]byte(": keepalive\n\n")); err != nil {
+		return err
+	}
+	s.flusher.Flush()
+	return nil
+}
+
+// handleAPIEvents streams status deltas as "status" events, the first one
+// holding every certificate. Event IDs are the epoch and revision, so a
+// reconnecting browser only receives what it missed.
+func (d *Dashboard) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := parseStatusQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	changes, unsubscribe := d.certManager.Subscribe()
+	defer unsubscribe()
+
+	stream, ok := newEventStream(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	since, full := "0", true
+	if epoch, revision, ok := strings.Cut(r.Header.Get("Last-Event-ID"), "/"); ok && epoch == d.revisions.epoch {
+		if _, err := strconv.ParseUint(revision, 10, 64); err == nil {
+			since, full = revision, false
+		}
+	}
+
+	ticker := time.NewTicker(d.eventInterval)
+	defer ticker.Stop()
+
+	for {
+		// since is always a revision, which delta accepts.
+		delta, _ := d.revisions.delta(d.getCertStatuses(), since)
+		if full {
+			delta.Full, delta.Removed = true, nil
+		}
+		if !query.empty() {
+			delta = query.applyDelta(delta)
+		}
+
+		var err error
+		if delta.Full || len(delta.Changed) > 0 || len(delta.Removed) > 0 {
+			err = stream.send("status", fmt.Sprintf("%s/%d", delta.Epoch, delta.Revision), delta)
+		} else {
+			err = stream.keepalive()
+		}
+		if err != nil {
+			slog.Debug("Event stream client disconnected", "error", err)
+			return
+		}
+		since, full = strconv.FormatUint(delta.Revision, 10), false
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-d.closing:
+			return
+		case <-changes:
+		case <-ticker.C:
+		}
+	}
+}
+
+// handleAPIEvents streams each node's status as a "node" event whenever it
+// changes, starting with every node, and "node_removed" events for nodes
+// that left Consul.
+func (a *Aggregator) handleAPIEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query, err := parseStatusQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stream, ok := newEventStream(w)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ticker := time.NewTicker(a.eventInterval)
+	defer ticker.Stop()
+
+	sent := make(map[string]string) // node -> hash of the status last sent
+	for {
+		if err := a.sendNodeEvents(stream, query, sent); err != nil {
+			slog.Debug("Event stream client disconnected", "error", err)
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-a.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseStreams ends the open /api/events streams and any opened later.
+// Streams never go idle, so a server must call it when shutting down, or
+// Shutdown waits for them until its context is done; browsers reconnect
+// to the next instance.
+func (d *Dashboard) CloseStreams() {
+	if d == nil {
+		return
+	}
+	d.closeOnce.Do(func() { close(d.closing) })
+}
+
+// CloseStreams ends the open /api/events streams and any opened later,
+// see Dashboard.CloseStreams.
+func (a *Aggregator) CloseStreams() {
+	a.closeOnce.Do(func() { close(a.closing) })
+}
+
+// sendNodeEvents fetches every node query matches and sends those whose
+// status differs from sent, updating it, or a keepalive when none do. Only
+// write errors are returned.
+func (a *Aggregator) sendNodeEvents(stream *eventStream, query statusQuery, sent map[string]string) error {
+	statuses, err := a.fetchAllStatuses()
+	if err != nil {
+		slog.Warn("Failed to fetch statuses for event stream", "error", err)
+		return stream.keepalive()
+	}
+	statuses = query.applyNodes(statuses)
+
+	changed := false
+	seen := make(map[string]bool, len(statuses))
+	for _, node := range statuses {
+		seen[node.Node] = true
+		data, _ := json.Marshal(node)
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		if sent[node.Node] == hash {
+			continue
+		}
+		if err := stream.send("node", "", node); err != nil {
+			return err
+		}
+		sent[node.Node] = hash
+		changed = true
+	}
+
+	for name := range sent {
+		if seen[name] {
+			continue
+		}
+		if err := stream.send("node_removed", "", map[string]string{"node": name}); err != nil {
+			return err
+		}
+		delete(sent, name)
+		changed = true
+	}
+
+	if !changed {
+		return stream.keepalive()
+	}
+	return nil
+}
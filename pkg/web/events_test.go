@@ -0,0 +1,174 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Live Update Tests
+//
+// Unit tests for the Server-Sent Events streams of the node dashboard and
+// the aggregator.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestDashboard_Events verifies the stream starts with every certificate,
+// pushes only the changes afterwards, and resumes from Last-Event-ID.
+func TestDashboard_Events(t *testing.T) {
+	dir := t.TempDir()
+	certConfig := func(name string) *config.CertificateConfig {
+		return &config.CertificateConfig{
+			Name:        name,
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(dir, name+".crt"),
+			Key:         filepath.Join(dir, name+".key"),
+		}
+	}
+
+	manager := cert.NewManager(nil)
+	manager.SyncCertificates([]*config.CertificateConfig{certConfig("web")})
+	dashboard := NewDashboard(manager, nil)
+	dashboard.eventInterval = time.Hour
+	// Cleanups run last first, so the streams close before Close waits
+	// for their handlers.
+	server := httptest.NewServer(dashboard.Handler())
+	t.Cleanup(server.Close)
+
+	events := openEventStream(t, server.URL+"/api/events", "")
+	id, first := events.next(t)
+	var delta StatusDelta
+	_ = json.Unmarshal([]byte(first["status"]), &delta)
+	if !delta.Full || len(delta.Changed) != 1 || delta.Changed[0].Name != "web" {
+		t.Fatalf("expected the full status first, got %s", first["status"])
+	}
+
+	manager.SyncCertificates([]*config.CertificateConfig{certConfig("web"), certConfig("api")})
+	_, second := events.next(t)
+	delta = StatusDelta{}
+	_ = json.Unmarshal([]byte(second["status"]), &delta)
+	if delta.Full || len(delta.Changed) != 1 || delta.Changed[0].Name != "api" {
+		t.Fatalf("expected only the added certificate, got %s", second["status"])
+	}
+
+	resumed := openEventStream(t, server.URL+"/api/events", id)
+	_, missed := resumed.next(t)
+	delta = StatusDelta{}
+	_ = json.Unmarshal([]byte(missed["status"]), &delta)
+	if delta.Full || len(delta.Changed) != 1 || delta.Changed[0].Name != "api" {
+		t.Fatalf("expected only the missed change on resume, got %s", missed["status"])
+	}
+}
+
+// TestAggregator_Events verifies every node is sent once and unchanged
+// nodes are not sent again.
+func TestAggregator_Events(t *testing.T) {
+	node := newTestNode(t, []CertStatus{{Name: "web", Status: "healthy"}})
+	node.Node = "node-a"
+
+	aggregator := NewAggregator(newTestConsul(t, []ConsulService{node}), "vault-cert-manager", time.Second)
+	aggregator.eventInterval = 10 * time.Millisecond
+	server := httptest.NewServer(aggregator.Handler())
+	t.Cleanup(server.Close)
+
+	events := openEventStream(t, server.URL+"/api/events", "")
+	_, first := events.next(t)
+	var status NodeStatus
+	_ = json.Unmarshal([]byte(first["node"]), &status)
+	if status.Node != "node-a" || len(status.Certs) != 1 {
+		t.Fatalf("expected node-a's status, got %v", first)
+	}
+
+	_, second := events.next(t)
+	if _, ok := second[""]; !ok {
+		t.Errorf("expected a keepalive for the unchanged node, got %v", second)
+	}
+}
+
+// TestDashboard_CloseStreams verifies open streams end, so a server
+// shutting down does not wait for them.
+func TestDashboard_CloseStreams(t *testing.T) {
+	dashboard := NewDashboard(cert.NewManager(nil), nil)
+	dashboard.eventInterval = time.Hour
+	server := httptest.NewServer(dashboard.Handler())
+	t.Cleanup(server.Close)
+
+	events := openEventStream(t, server.URL+"/api/events", "")
+	events.next(t)
+
+	dashboard.CloseStreams()
+	if _, err := io.ReadAll(events.reader); err != nil {
+		t.Errorf("expected the stream to end, got %v", err)
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// testEventStream reads a Server-Sent Events response.
+type testEventStream struct {
+	reader *bufio.Reader
+}
+
+// openEventStream connects to url, resuming after lastEventID if set.
+func openEventStream(t *testing.T, url, lastEventID string) *testEventStream {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != eventStreamContentType {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return &testEventStream{reader: bufio.NewReader(resp.Body)}
+}
+
+// next reads the next event, returning its ID and its data keyed by event
+// type, or "" for a comment.
+func (s *testEventStream) next(t *testing.T) (string, map[string]string) {
+	t.Helper()
+
+	id, event, data := "", "", ""
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return id, map[string]string{event: data}
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
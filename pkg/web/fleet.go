@@ -0,0 +1,119 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fleet Metrics
+//
+// Summarizes the fleet on the aggregator's /metrics: certificates and how
+// many are close to expiry, nodes and which could not be reached, and the
+// renewal SLO, so one scrape target covers every node. Nodes are fetched
+// once per scrape.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fleetExpiryWindows are the days-left thresholds certificates are counted
+// under, as the within label.
+var fleetExpiryWindows = []struct {
+	label string
+	days  int
+}{
+	{"7d", 7},
+	{"30d", 30},
+}
+
+// fleetCollector exports fleet-wide metrics, evaluated on every scrape.
+type fleetCollector struct {
+	aggregator  *Aggregator
+	nodes       *prometheus.Desc
+	unreachable *prometheus.Desc
+	nodeUp      *prometheus.Desc
+	certs       *prometheus.Desc
+	expiring    *prometheus.Desc
+	ratio       *prometheus.Desc
+	onTime      *prometheus.Desc
+	late        *prometheus.Desc
+}
+
+// Describe implements prometheus.Collector.
+func (c *fleetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.nodes
+	ch <- c.unreachable
+	ch <- c.nodeUp
+	ch <- c.certs
+	ch <- c.expiring
+	ch <- c.ratio
+	ch <- c.onTime
+	ch <- c.late
+}
+
+// Collect implements prometheus.Collector.
+func (c *fleetCollector) Collect(ch chan<- prometheus.Metric) {
+	statuses, err := c.aggregator.fetchAllStatuses()
+	if err != nil {
+		slog.Warn("Failed to fetch statuses for fleet metrics", "error", err)
+		return
+	}
+
+	unreachable, certs := 0, 0
+	expiring := make([]int, len(fleetExpiryWindows))
+	for _, node := range statuses {
+		up := 1.0
+		if node.Error != "" {
+			up = 0
+			unreachable++
+		}
+		ch <- prometheus.MustNewConstMetric(c.nodeUp, prometheus.GaugeValue, up, node.Node, node.Address)
+
+		for _, status := range node.Certs {
+			certs++
+			if status.NotAfter.IsZero() {
+				continue
+			}
+			for i, window := range fleetExpiryWindows {
+				if status.DaysLeft < window.days {
+					expiring[i]++
+				}
+			}
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.nodes, prometheus.GaugeValue, float64(len(statuses)))
+	ch <- prometheus.MustNewConstMetric(c.unreachable, prometheus.GaugeValue, float64(unreachable))
+	ch <- prometheus.MustNewConstMetric(c.certs, prometheus.GaugeValue, float64(certs))
+	for i, window := range fleetExpiryWindows {
+		ch <- prometheus.MustNewConstMetric(c.expiring, prometheus.GaugeValue, float64(expiring[i]), window.label)
+	}
+
+	slo := computeSLO(statuses, c.aggregator.slo, time.Now())
+	ch <- prometheus.MustNewConstMetric(c.ratio, prometheus.GaugeValue, slo.Percent/100)
+	ch <- prometheus.MustNewConstMetric(c.onTime, prometheus.GaugeValue, float64(slo.OnTime))
+	ch <- prometheus.MustNewConstMetric(c.late, prometheus.GaugeValue, float64(slo.Late))
+}
+
+// newFleetCollector creates the fleet metrics for an aggregator.
+func newFleetCollector(a *Aggregator) *fleetCollector {
+	return &fleetCollector{
+		aggregator: a,
+		nodes: prometheus.NewDesc("managed_cert_fleet_nodes",
+			"Nodes discovered in Consul", nil, nil),
+		unreachable: prometheus.NewDesc("managed_cert_fleet_nodes_unreachable",
+			"Discovered nodes whose status could not be fetched", nil, nil),
+		nodeUp: prometheus.NewDesc("managed_cert_fleet_node_up",
+			"1 if the node's status was fetched on this scrape, 0 otherwise", []string{"node", "address"}, nil),
+		certs: prometheus.NewDesc("managed_cert_fleet_certificates",
+			"Certificates managed across reachable nodes", nil, nil),
+		expiring: prometheus.NewDesc("managed_cert_fleet_certificates_expiring",
+			"Certificates across reachable nodes with fewer days left than the window, expired ones included", []string{"within"}, nil),
+		ratio: prometheus.NewDesc("managed_cert_fleet_renewal_slo_ratio",
+			"Fraction of renewals in the SLO window that happened at least the SLO lead time before expiry", nil, nil),
+		onTime: prometheus.NewDesc("managed_cert_fleet_renewals_on_time",
+			"Renewals in the SLO window that happened at least the SLO lead time before expiry", nil, nil),
+		late: prometheus.NewDesc("managed_cert_fleet_renewals_late",
+			"Renewals in the SLO window that happened within the SLO lead time of expiry, plus certificates that expired", nil, nil),
+	}
+}
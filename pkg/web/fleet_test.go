@@ -0,0 +1,80 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fleet Metrics Tests
+//
+// Unit tests for the aggregator's fleet summary metrics.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestAggregator_FleetMetrics verifies certificates, expiring certificates,
+// and unreachable nodes are counted across the fleet.
+func TestAggregator_FleetMetrics(t *testing.T) {
+	now := time.Now()
+	healthy := newTestNode(t, []CertStatus{
+		{Name: "web", NotAfter: now.Add(60 * 24 * time.Hour), DaysLeft: 60},
+		{Name: "api", NotAfter: now.Add(20 * 24 * time.Hour), DaysLeft: 20},
+		{Name: "db", NotAfter: now.Add(3 * 24 * time.Hour), DaysLeft: 3},
+		{Name: "pending"},
+	})
+	healthy.Node = "node-a"
+	down := ConsulService{Node: "node-b", Address: "127.0.0.1", ServicePort: 1}
+
+	aggregator := NewAggregator(newTestConsul(t, []ConsulService{healthy, down}), "vault-cert-manager", time.Second)
+
+	// Collectors are gathered concurrently, so the error counter is only
+	// certain to include the first scrape's failure on the second.
+	if _, err := aggregator.registry.Gather(); err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	families, err := aggregator.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "within" || label.GetName() == "node" {
+					key += "/" + label.GetValue()
+				}
+			}
+			if gauge := metric.GetGauge(); gauge != nil {
+				values[key] = gauge.GetValue()
+			} else if counter := metric.GetCounter(); counter != nil {
+				values[key] = counter.GetValue()
+			}
+		}
+	}
+
+	for key, want := range map[string]float64{
+		"managed_cert_fleet_nodes":                     2,
+		"managed_cert_fleet_nodes_unreachable":         1,
+		"managed_cert_fleet_node_up/node-a":            1,
+		"managed_cert_fleet_node_up/node-b":            0,
+		"managed_cert_fleet_certificates":              4,
+		"managed_cert_fleet_certificates_expiring/7d":  1,
+		"managed_cert_fleet_certificates_expiring/30d": 2,
+	} {
+		if got, ok := values[key]; !ok || got != want {
+			t.Errorf("expected %s = %v, got %v (present: %v)", key, want, got, ok)
+		}
+	}
+	if got := values["managed_cert_fleet_node_errors_total/node-b"]; got < 1 {
+		t.Errorf("expected node-b's fetch errors to be counted, got %v", got)
+	}
+}
@@ -0,0 +1,382 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fleet Rotation
+//
+// Rotates one certificate, or every certificate, across all nodes as a
+// background job: POST /api/rotate-fleet starts it with bounded concurrency
+// and a per-node timeout, and GET /api/rotate-fleet/{id} reports its
+// progress and each node's result, for the dashboard and for scripts.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFleetConcurrency is how many nodes a fleet rotation rotates at
+// once unless the request sets concurrency.
+const defaultFleetConcurrency = 4
+
+// maxFleetRotations is how many fleet rotations are kept for polling. The
+// oldest finished rotation is dropped when another starts, and no more
+// start while this many are unfinished.
+const maxFleetRotations = 20
+
+// maxFleetResponseBytes bounds how much of a node's error response is
+// kept.
+const maxFleetResponseBytes = 4096
+
+// FleetRotation is a fleet-wide rotation started by POST /api/rotate-fleet.
+type FleetRotation struct {
+	ID             string              `json:"id"`
+	Cert           string              `json:"cert"` // certificate rotated, or "all"
+	Concurrency    int                 `json:"concurrency"`
+	TimeoutSeconds float64             `json:"timeout_seconds"` // per node
+	User           string              `json:"user,omitempty"`
+	State          string              `json:"state"` // discovering, running, completed, failed, or canceled
+	Error          string              `json:"error,omitempty"`
+	Started        time.Time           `json:"started"`
+	Finished       time.Time           `json:"finished,omitzero"`
+	Total          int                 `json:"total"`
+	Done           int                 `json:"done"`
+	Succeeded      int                 `json:"succeeded"`
+	Failed         int                 `json:"failed"`
+	Nodes          []FleetRotationNode `json:"nodes"`
+
+	timeout time.Duration
+}
+
+// FleetRotationNode is one node's part of a fleet rotation.
+type FleetRotationNode struct {
+	Node       string    `json:"node"`
+	Address    string    `json:"address"`
+	State      string    `json:"state"` // pending, running, succeeded, or failed
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Started    time.Time `json:"started,omitzero"`
+	Finished   time.Time `json:"finished,omitzero"`
+}
+
+// handleAPIRotateFleet starts a fleet rotation and returns it with 202
+// Accepted. Query parameters: cert (default all), concurrency (default 4),
+// and timeout, a per-node duration defaulting to the rotate timeout.
+func (a *Aggregator) handleAPIRotateFleet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	params := r.URL.Query()
+	job := &FleetRotation{
+		ID:          randomString(),
+		Cert:        params.Get("cert"),
+		Concurrency: defaultFleetConcurrency,
+		User:        RequestUser(r),
+		State:       "discovering",
+		Started:     time.Now(),
+		Nodes:       []FleetRotationNode{},
+		timeout:     a.rotateClient.Timeout,
+	}
+	if job.Cert == "" {
+		job.Cert = "all"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	badRequest := func(message string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+	}
+	if value := params.Get("concurrency"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil || concurrency < 1 {
+			badRequest(fmt.Sprintf("concurrency must be a positive integer, got %q", value))
+			return
+		}
+		job.Concurrency = concurrency
+	}
+	if value := params.Get("timeout"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			badRequest(fmt.Sprintf("timeout must be a positive duration, got %q", value))
+			return
+		}
+		job.timeout = timeout
+	}
+	job.TimeoutSeconds = job.timeout.Seconds()
+
+	if !a.addFleetRotation(job) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("%d fleet rotations are already in progress", maxFleetRotations),
+		})
+		return
+	}
+	slog.Info("Starting fleet rotation",
+		"id", job.ID,
+		"cert", job.Cert,
+		"concurrency", job.Concurrency,
+		"timeout", job.timeout,
+		"user", job.User)
+	go a.runFleetRotation(job, r.Header.Get("Authorization"))
+
+	w.Header().Set("Location", "rotate-fleet/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(a.fleetRotation(job.ID))
+}
+
+// handleAPIFleetRotation returns the progress of a fleet rotation.
+// Path format: /api/rotate-fleet/{id}
+func (a *Aggregator) handleAPIFleetRotation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/rotate-fleet/")
+	w.Header().Set("Content-Type", "application/json")
+	job := a.fleetRotation(id)
+	if job == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("fleet rotation %s not found", id)})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(job)
+}
+
+// addFleetRotation records job, dropping the oldest finished rotation
+// once more than maxFleetRotations are kept. It returns false without
+// recording job when maxFleetRotations are unfinished.
+func (a *Aggregator) addFleetRotation(job *FleetRotation) bool {
+	a.fleetMu.Lock()
+	defer a.fleetMu.Unlock()
+
+	unfinished := 0
+	for _, old := range a.fleetRotations {
+		if old.Finished.IsZero() {
+			unfinished++
+		}
+	}
+	if unfinished >= maxFleetRotations {
+		return false
+	}
+
+	a.fleetRotations = append(a.fleetRotations, job)
+	if len(a.fleetRotations) <= maxFleetRotations {
+		return true
+	}
+	for i, old := range a.fleetRotations {
+		if !old.Finished.IsZero() {
+			a.fleetRotations = slices.Delete(a.fleetRotations, i, i+1)
+			break
+		}
+	}
+	return true
+}
+
+// fleetRotation returns a copy of the fleet rotation with the given ID, or
+// nil when there is none.
+func (a *Aggregator) fleetRotation(id string) *FleetRotation {
+	a.fleetMu.Lock()
+	defer a.fleetMu.Unlock()
+
+	for _, job := range a.fleetRotations {
+		if job.ID == id {
+			snapshot := *job
+			snapshot.Nodes = slices.Clone(job.Nodes)
+			return &snapshot
+		}
+	}
+	return nil
+}
+
+// runFleetRotation rotates job's certificate on every target node, at most
+// job.Concurrency at a time. When the aggregator shuts down, rotations in
+// progress are aborted, nodes not yet started are left pending, and the
+// rotation is canceled.
+func (a *Aggregator) runFleetRotation(job *FleetRotation, authorization string) {
+	services, nodes, err := a.fleetRotationTargets(job.Cert)
+	a.fleetMu.Lock()
+	if err != nil {
+		job.State = "failed"
+		job.Error = err.Error()
+		job.Finished = time.Now()
+		a.fleetMu.Unlock()
+		slog.Error("Fleet rotation failed", "id", job.ID, "error", err)
+		return
+	}
+	job.State = "running"
+	job.Nodes = nodes
+	job.Total = len(nodes)
+	for _, node := range nodes {
+		if node.State == "failed" {
+			job.Done++
+			job.Failed++
+		}
+	}
+	a.fleetMu.Unlock()
+
+	// The per-node timeout replaces the rotate client's own.
+	client := *a.rotateClient
+	client.Timeout = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-a.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	sem := make(chan struct{}, job.Concurrency)
+	var wg sync.WaitGroup
+dispatch:
+	for i, svc := range services {
+		if nodes[i].State == "failed" {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			a.rotateFleetNode(ctx, &client, job, i, svc, authorization)
+		}()
+	}
+	wg.Wait()
+
+	a.fleetMu.Lock()
+	job.State = "completed"
+	if ctx.Err() != nil {
+		job.State = "canceled"
+	}
+	job.Finished = time.Now()
+	succeeded, failed := job.Succeeded, job.Failed
+	a.fleetMu.Unlock()
+
+	slog.Info("Fleet rotation finished",
+		"id", job.ID,
+		"cert", job.Cert,
+		"state", job.State,
+		"succeeded", succeeded,
+		"failed", failed)
+}
+
+// rotateFleetNode rotates job's certificate on svc until ctx is done,
+// recording the result as job's i-th node.
+func (a *Aggregator) rotateFleetNode(ctx context.Context, client *http.Client, job *FleetRotation, i int, svc ConsulService, authorization string) {
+	a.fleetMu.Lock()
+	job.Nodes[i].State = "running"
+	job.Nodes[i].Started = time.Now()
+	a.fleetMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, job.timeout)
+	defer cancel()
+
+	var statusCode int
+	req, err := a.nodeRotateRequest(ctx, svc, job.Cert, "", authorization)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = client.Do(req); err == nil {
+			statusCode = resp.StatusCode
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, maxFleetResponseBytes))
+			_ = resp.Body.Close()
+			if statusCode >= http.StatusMultipleChoices {
+				err = nodeResponseError(statusCode, body)
+			}
+		}
+	}
+
+	a.fleetMu.Lock()
+	defer a.fleetMu.Unlock()
+	node := &job.Nodes[i]
+	node.StatusCode = statusCode
+	node.Finished = time.Now()
+	job.Done++
+	if err != nil {
+		node.State = "failed"
+		node.Error = err.Error()
+		job.Failed++
+		slog.Warn("Fleet rotation failed on node", "id", job.ID, "node", svc.Node, "error", err)
+		return
+	}
+	node.State = "succeeded"
+	job.Succeeded++
+}
+
+// fleetRotationTargets returns the nodes to rotate certName on, with their
+// initial progress: every discovered node for "all", otherwise the nodes
+// managing certName. Nodes whose status cannot be fetched are included as
+// failed, since they may manage it.
+func (a *Aggregator) fleetRotationTargets(certName string) ([]ConsulService, []FleetRotationNode, error) {
+	services, err := a.discoverServices()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to discover nodes: %w", err)
+	}
+
+	statuses := make([]NodeStatus, len(services))
+	if certName != "all" {
+		var wg sync.WaitGroup
+		for i, svc := range services {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				statuses[i] = a.fetchNodeStatus(svc)
+			}()
+		}
+		wg.Wait()
+	}
+
+	var targets []ConsulService
+	nodes := []FleetRotationNode{}
+	for i, svc := range services {
+		addr := svc.ServiceAddress
+		if addr == "" {
+			addr = svc.Address
+		}
+		node := FleetRotationNode{Node: svc.Node, Address: fmt.Sprintf("%s:%d", addr, svc.ServicePort), State: "pending"}
+
+		status := statuses[i]
+		switch {
+		case certName == "all":
+		case status.Error != "":
+			node.State = "failed"
+			node.Error = "failed to fetch status: " + status.Error
+		case !slices.ContainsFunc(status.Certs, func(c CertStatus) bool { return c.Name == certName }):
+			continue
+		}
+		targets = append(targets, svc)
+		nodes = append(nodes, node)
+	}
+	return targets, nodes, nil
+}
+
+// nodeResponseError describes a node's error response, using the error
+// field of a JSON body when there is one.
+func nodeResponseError(statusCode int, body []byte) error {
+	var decoded struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &decoded) == nil && decoded.Error != "" {
+		message = decoded.Error
+	}
+	if message == "" {
+		return fmt.Errorf("node returned status %d", statusCode)
+	}
+	return fmt.Errorf("node returned status %d: %s", statusCode, message)
+}
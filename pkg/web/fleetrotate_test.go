@@ -0,0 +1,223 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fleet Rotation Tests
+//
+// Unit tests for fleet-wide rotation jobs and their progress.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// newRotateNode starts a fake node serving the given certificate statuses
+// and handling rotate requests with rotate.
+func newRotateNode(t *testing.T, name string, certs []CertStatus, rotate http.HandlerFunc) ConsulService {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/rotate/") {
+			rotate(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(certs)
+	}))
+	t.Cleanup(server.Close)
+
+	host, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to parse test server address: %v", err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	return ConsulService{Node: name, Address: host, ServicePort: port}
+}
+
+// startFleetRotation starts a fleet rotation and polls it until it
+// finishes.
+func startFleetRotation(t *testing.T, handler http.Handler, query string) FleetRotation {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rotate-fleet?"+query, nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var job FleetRotation
+	if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rec.Header().Get("Location") != "rotate-fleet/"+job.ID {
+		t.Errorf("expected the job's location, got %q", rec.Header().Get("Location"))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for job.Finished.IsZero() {
+		if time.Now().After(deadline) {
+			t.Fatalf("fleet rotation did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rotate-fleet/"+job.ID, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 polling the job, got %d", rec.Code)
+		}
+		job = FleetRotation{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &job)
+	}
+	return job
+}
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestAggregator_RotateFleet verifies a named certificate is rotated only
+// on the nodes managing it, and each node's result is reported.
+func TestAggregator_RotateFleet(t *testing.T) {
+	var rotated atomic.Value
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		rotated.Store(r.URL.Path)
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	}
+	sealed := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "vault sealed"})
+	}
+	nodes := []ConsulService{
+		newRotateNode(t, "node-a", []CertStatus{{Name: "web"}}, ok),
+		newRotateNode(t, "node-b", []CertStatus{{Name: "web"}, {Name: "db"}}, sealed),
+		newRotateNode(t, "node-c", []CertStatus{{Name: "db"}}, ok),
+	}
+	handler := NewAggregator(newTestConsul(t, nodes), "vault-cert-manager", time.Second).Handler()
+
+	job := startFleetRotation(t, handler, "cert=web&concurrency=1")
+	if job.State != "completed" || job.Total != 2 || job.Done != 2 || job.Succeeded != 1 || job.Failed != 1 {
+		t.Fatalf("expected one success and one failure among two nodes, got %+v", job)
+	}
+	results := make(map[string]FleetRotationNode)
+	for _, node := range job.Nodes {
+		results[node.Node] = node
+	}
+	if results["node-a"].State != "succeeded" || rotated.Load() != "/api/rotate/web" {
+		t.Errorf("expected web rotated on node-a, got %+v", results["node-a"])
+	}
+	if b := results["node-b"]; b.State != "failed" || b.StatusCode != http.StatusInternalServerError || !strings.Contains(b.Error, "vault sealed") {
+		t.Errorf("expected node-b to fail with its error, got %+v", b)
+	}
+	if _, ok := results["node-c"]; ok {
+		t.Error("expected node-c, which does not manage web, to be skipped")
+	}
+
+	for _, query := range []string{"concurrency=0", "timeout=soon"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rotate-fleet?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/rotate-fleet/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown job, got %d", rec.Code)
+	}
+}
+
+// TestAggregator_RotateFleet_Concurrency verifies no more than the
+// requested number of nodes rotate at once, and slow nodes time out.
+func TestAggregator_RotateFleet_Concurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	slow := func(delay time.Duration) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+			}
+		}
+	}
+
+	var nodes []ConsulService
+	for _, name := range []string{"node-a", "node-b", "node-c", "node-d"} {
+		nodes = append(nodes, newRotateNode(t, name, nil, slow(50*time.Millisecond)))
+	}
+	nodes = append(nodes, newRotateNode(t, "node-e", nil, slow(time.Minute)))
+	handler := NewAggregator(newTestConsul(t, nodes), "vault-cert-manager", time.Second).Handler()
+
+	job := startFleetRotation(t, handler, "concurrency=2&timeout=500ms")
+	if job.Total != 5 || job.Succeeded != 4 || job.Failed != 1 {
+		t.Errorf("expected four successes and one timeout, got %+v", job)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("expected at most 2 concurrent rotations, got %d", p)
+	}
+}
+
+// TestAggregator_RotateFleet_Limit verifies no fleet rotation starts while
+// maxFleetRotations are unfinished.
+func TestAggregator_RotateFleet_Limit(t *testing.T) {
+	aggregator := NewAggregator(newTestConsul(t, nil), "vault-cert-manager", time.Second)
+	for i := 0; i < maxFleetRotations; i++ {
+		aggregator.fleetRotations = append(aggregator.fleetRotations, &FleetRotation{ID: strconv.Itoa(i), State: "running"})
+	}
+
+	rec := httptest.NewRecorder()
+	aggregator.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/rotate-fleet", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(aggregator.fleetRotations) != maxFleetRotations {
+		t.Errorf("expected the rejected job not to be recorded, got %d jobs", len(aggregator.fleetRotations))
+	}
+
+	aggregator.fleetRotations[0].Finished = time.Now()
+	startFleetRotation(t, aggregator.Handler(), "")
+	if len(aggregator.fleetRotations) != maxFleetRotations || aggregator.fleetRotations[0].ID != "1" {
+		t.Errorf("expected the finished job replaced, got %d jobs", len(aggregator.fleetRotations))
+	}
+}
+
+// TestAggregator_RotateFleet_Close verifies closing the aggregator aborts
+// rotations in progress and cancels the job.
+func TestAggregator_RotateFleet_Close(t *testing.T) {
+	started := make(chan struct{})
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}
+	nodes := []ConsulService{newRotateNode(t, "node-a", nil, hang)}
+	aggregator := NewAggregator(newTestConsul(t, nodes), "vault-cert-manager", time.Minute)
+	handler := aggregator.Handler()
+
+	go func() {
+		<-started
+		aggregator.CloseStreams()
+	}()
+	job := startFleetRotation(t, handler, "timeout=1m")
+	if job.State != "canceled" || job.Failed != 1 || job.Nodes[0].State != "failed" {
+		t.Errorf("expected the rotation aborted and the job canceled, got %+v", job)
+	}
+}
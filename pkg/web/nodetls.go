@@ -0,0 +1,83 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Node TLS
+//
+// HTTPS between the aggregator and the nodes: the aggregator verifies each
+// node's certificate against a CA and presents a client certificate that
+// nodes with web.tls_client_ca_file require, so status and rotate traffic
+// across the fleet is encrypted and authenticated in both directions.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// NodeTLSConfig holds how the aggregator connects to nodes over HTTPS.
+type NodeTLSConfig struct {
+	CAFile   string // CA bundle verifying the nodes' certificates, the system roots when empty
+	CertFile string // client certificate presented to nodes
+	KeyFile  string
+}
+
+// SetNodeTLS queries and rotates nodes over HTTPS with cfg's CA and client
+// certificate. The client certificate is read again on every handshake, so
+// one renewed on disk is used without a restart.
+func (a *Aggregator) SetNodeTLS(cfg NodeTLSConfig) error {
+	tlsConfig, err := clientTLSConfig(cfg.CAFile, cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to configure node TLS: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	a.httpClient.Transport = transport
+	a.rotateClient.Transport = transport
+	a.nodeScheme = "https"
+	return nil
+}
+
+// nodeURL returns the URL of path on the node at address, a host:port.
+func (a *Aggregator) nodeURL(address, path string) string {
+	return a.nodeScheme + "://" + address + path
+}
+
+// clientTLSConfig builds a client TLS configuration trusting the CAs in
+// caFile, or the system roots without one, and presenting the client
+// certificate in certFile and keyFile when set.
+func clientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("a client certificate requires both a certificate and a key file")
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		roots := x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = roots
+	}
+	if certFile != "" {
+		// Fail at startup on unreadable files rather than on every request.
+		if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &certificate, nil
+		}
+	}
+	return tlsConfig, nil
+}
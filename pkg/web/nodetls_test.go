@@ -0,0 +1,104 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Node TLS Tests
+//
+// Unit tests for HTTPS and client certificates between the aggregator and
+// the nodes.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/vault"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestAggregator_SetNodeTLS verifies nodes requiring a client certificate
+// are queried and rotated over HTTPS once the aggregator presents one.
+func TestAggregator_SetNodeTLS(t *testing.T) {
+	dir := t.TempDir()
+	client := vault.CreateTestCertificateData()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(client.CertificateChain))
+	rotated := false
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			rotated = true
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]CertStatus{{Name: "web"}})
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	node := ConsulService{Node: "node-a", Address: host, ServicePort: port}
+	aggregator := NewAggregator(newTestConsul(t, []ConsulService{node}), "vault-cert-manager", time.Second)
+
+	statuses, _ := aggregator.fetchAllStatuses()
+	if len(statuses) != 1 || statuses[0].Error == "" {
+		t.Fatalf("expected plain HTTP to an HTTPS node to fail, got %+v", statuses)
+	}
+
+	caFile := write("nodes-ca.pem", string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})))
+	if err := aggregator.SetNodeTLS(NodeTLSConfig{CAFile: caFile}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if statuses, _ = aggregator.fetchAllStatuses(); statuses[0].Error == "" {
+		t.Error("expected the node to reject the aggregator without a client certificate")
+	}
+
+	tlsConfig := NodeTLSConfig{
+		CAFile:   caFile,
+		CertFile: write("client.crt", client.Certificate),
+		KeyFile:  write("client.key", client.PrivateKey),
+	}
+	if err := aggregator.SetNodeTLS(tlsConfig); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	statuses, _ = aggregator.fetchAllStatuses()
+	if statuses[0].Error != "" || len(statuses[0].Certs) != 1 {
+		t.Fatalf("expected the node's certificates over mTLS, got %+v", statuses[0])
+	}
+
+	rec := httptest.NewRecorder()
+	aggregator.handleAPIRotate(rec, httptest.NewRequest(http.MethodPost, "/api/rotate/node-a/web", nil))
+	if rec.Code != http.StatusOK || !rotated {
+		t.Errorf("expected the rotation proxied over mTLS, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if err := aggregator.SetNodeTLS(NodeTLSConfig{CertFile: tlsConfig.CertFile}); err == nil {
+		t.Error("expected an error for a client certificate without a key")
+	}
+}
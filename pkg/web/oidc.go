@@ -0,0 +1,588 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - OIDC Single Sign-On
+//
+// Logs dashboard users in through an OpenID Connect provider with the
+// authorization code flow. The provider is discovered on first use, ID
+// tokens are verified against its published keys, and the user's groups
+// are checked against the allowed groups. Logged-in users get a signed
+// session cookie, so rotations are attributed to them.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// sessionCookie holds the signed session of a logged-in user.
+	sessionCookie = "vcm_session"
+
+	// loginCookie holds the state of a login in progress.
+	loginCookie = "vcm_login"
+
+	// loginTimeout is how long a user has to complete a login at the
+	// provider.
+	loginTimeout = 10 * time.Minute
+
+	// keyRefreshInterval limits how often unknown key IDs refetch the
+	// provider's keys.
+	keyRefreshInterval = time.Minute
+
+	// DefaultSessionTTL is how long a login lasts.
+	DefaultSessionTTL = 8 * time.Hour
+)
+
+// OIDCConfig configures single sign-on with an OpenID Connect provider.
+type OIDCConfig struct {
+	IssuerURL     string
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string   // this server's .../auth/callback, as registered with the provider
+	Scopes        []string // default: openid, profile, email
+	AllowedGroups []string // empty allows every user the provider authenticates
+	GroupsClaim   string   // default: groups
+	SessionTTL    time.Duration
+}
+
+// OIDC logs users in through an OpenID Connect provider.
+type OIDC struct {
+	cfg        OIDCConfig
+	basePath   string // path the auth/ endpoints are served under
+	secure     bool   // cookies only over HTTPS
+	sessionKey []byte
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu          sync.Mutex
+	provider    *oidcProvider
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// oidcProvider is the part of the provider's discovery document used.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcSession is the payload of the session and login cookies.
+type oidcSession struct {
+	User   string `json:"u,omitempty"`
+	State  string `json:"s,omitempty"`
+	Nonce  string `json:"n,omitempty"`
+	Return string `json:"r,omitempty"`
+	Expiry int64  `json:"e"`
+}
+
+// jsonWebKey is a public key from the provider's key set.
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewOIDC creates single sign-on for cfg. The auth/ endpoints are served
+// next to the redirect URL's auth/callback. Sessions are signed with a key
+// generated at startup, so users log in again after a restart.
+func NewOIDC(cfg OIDCConfig) (*OIDC, error) {
+	redirect, err := url.Parse(cfg.RedirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redirect URL: %w", err)
+	}
+	basePath, ok := strings.CutSuffix(redirect.Path, "auth/callback")
+	if !ok || !strings.HasSuffix(basePath, "/") {
+		return nil, fmt.Errorf("redirect URL must end in /auth/callback, got %s", cfg.RedirectURL)
+	}
+
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	if cfg.SessionTTL == 0 {
+		cfg.SessionTTL = DefaultSessionTTL
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %w", err)
+	}
+
+	return &OIDC{
+		cfg:        cfg,
+		basePath:   basePath,
+		secure:     redirect.Scheme == "https",
+		sessionKey: key,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+	}, nil
+}
+
+// RegisterHandlers registers the login, callback, and logout endpoints.
+func (o *OIDC) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/auth/login", o.handleLogin)
+	mux.HandleFunc("/auth/callback", o.handleCallback)
+	mux.HandleFunc("/auth/logout", o.handleLogout)
+}
+
+// User returns the user of r's session, or "" when r has no valid one.
+func (o *OIDC) User(r *http.Request) string {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return ""
+	}
+	session, ok := o.decode(cookie.Value)
+	if !ok {
+		return ""
+	}
+	return session.User
+}
+
+// LoginURL returns the path starting a login that returns to r's page.
+func (o *OIDC) LoginURL(r *http.Request) string {
+	return o.basePath + "auth/login?return=" + url.QueryEscape(r.RequestURI)
+}
+
+// handleLogin redirects the user to the provider, remembering the state,
+// nonce, and page to return to in a short-lived cookie.
+func (o *OIDC) handleLogin(w http.ResponseWriter, r *http.Request) {
+	provider, err := o.discover(r.Context())
+	if err != nil {
+		slog.Error("OIDC discovery failed", "issuer", o.cfg.IssuerURL, "error", err)
+		http.Error(w, "Single sign-on is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	returnTo := r.URL.Query().Get("return")
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.HasPrefix(returnTo, "/\\") {
+		returnTo = o.basePath
+	}
+	login := oidcSession{
+		State:  randomString(),
+		Nonce:  randomString(),
+		Return: returnTo,
+		Expiry: o.now().Add(loginTimeout).Unix(),
+	}
+	o.setCookie(w, loginCookie, o.encode(login), loginTimeout)
+
+	authURL := o.oauthConfig(provider).AuthCodeURL(login.State, oauth2.SetAuthURLParam("nonce", login.Nonce))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// handleCallback completes a login: it exchanges the code for tokens,
+// verifies the ID token and the user's groups, and starts a session.
+func (o *OIDC) handleCallback(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(loginCookie)
+	if err != nil {
+		http.Error(w, "Login expired, start again", http.StatusBadRequest)
+		return
+	}
+	login, ok := o.decode(cookie.Value)
+	if !ok || !hmac.Equal([]byte(login.State), []byte(r.URL.Query().Get("state"))) {
+		http.Error(w, "Login expired, start again", http.StatusBadRequest)
+		return
+	}
+	o.setCookie(w, loginCookie, "", -1)
+
+	if reason := r.URL.Query().Get("error"); reason != "" {
+		slog.Warn("OIDC login rejected by provider", "error", reason, "description", r.URL.Query().Get("error_description"))
+		http.Error(w, "Login failed: "+reason, http.StatusUnauthorized)
+		return
+	}
+
+	user, err := o.exchange(r.Context(), r.URL.Query().Get("code"), login.Nonce)
+	if err != nil {
+		slog.Warn("OIDC login failed", "error", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+
+	slog.Info("User logged in", "user", user)
+	session := oidcSession{User: user, Expiry: o.now().Add(o.cfg.SessionTTL).Unix()}
+	o.setCookie(w, sessionCookie, o.encode(session), o.cfg.SessionTTL)
+	http.Redirect(w, r, login.Return, http.StatusFound)
+}
+
+// handleLogout ends the session.
+func (o *OIDC) handleLogout(w http.ResponseWriter, r *http.Request) {
+	o.setCookie(w, sessionCookie, "", -1)
+	http.Redirect(w, r, o.basePath, http.StatusFound)
+}
+
+// exchange redeems code and returns the user named by the verified ID
+// token, if they are in an allowed group.
+func (o *OIDC) exchange(ctx context.Context, code, nonce string) (string, error) {
+	provider, err := o.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, o.httpClient)
+	token, err := o.oauthConfig(provider).Exchange(ctx, code)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange code: %w", err)
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+
+	claims, err := o.verifyIDToken(ctx, provider, rawIDToken, nonce)
+	if err != nil {
+		return "", err
+	}
+
+	user := claimString(claims, "email")
+	if user == "" {
+		user = claimString(claims, "preferred_username")
+	}
+	if user == "" {
+		user = claimString(claims, "sub")
+	}
+
+	if len(o.cfg.AllowedGroups) > 0 {
+		groups, _ := claims[o.cfg.GroupsClaim].([]interface{})
+		allowed := false
+		for _, group := range groups {
+			name, _ := group.(string)
+			allowed = allowed || slices.Contains(o.cfg.AllowedGroups, name)
+		}
+		if !allowed {
+			return "", fmt.Errorf("user %s is not in an allowed group", user)
+		}
+	}
+	return user, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry,
+// and nonce, returning its claims.
+func (o *OIDC) verifyIDToken(ctx context.Context, provider *oidcProvider, raw, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed id_token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed id_token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed id_token signature: %w", err)
+	}
+	key, err := o.key(ctx, provider, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed id_token claims: %w", err)
+	}
+	if claimString(claims, "iss") != provider.Issuer {
+		return nil, fmt.Errorf("id_token issued by %q, expected %q", claimString(claims, "iss"), provider.Issuer)
+	}
+	if !audienceContains(claims["aud"], o.cfg.ClientID) {
+		return nil, fmt.Errorf("id_token is not for client %s", o.cfg.ClientID)
+	}
+	if exp, _ := claims["exp"].(float64); o.now().Unix() >= int64(exp) {
+		return nil, fmt.Errorf("id_token expired")
+	}
+	if !hmac.Equal([]byte(claimString(claims, "nonce")), []byte(nonce)) {
+		return nil, fmt.Errorf("id_token nonce does not match")
+	}
+	return claims, nil
+}
+
+// discover fetches the provider's discovery document once.
+func (o *OIDC) discover(ctx context.Context) (*oidcProvider, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.provider != nil {
+		return o.provider, nil
+	}
+
+	var provider oidcProvider
+	if err := o.getJSON(ctx, strings.TrimSuffix(o.cfg.IssuerURL, "/")+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, err
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s is incomplete", o.cfg.IssuerURL)
+	}
+	o.provider = &provider
+	return o.provider, nil
+}
+
+// key returns the provider's signing key kid, refetching the key set when
+// kid is unknown, e.g. after the provider rotated its keys.
+func (o *OIDC) key(ctx context.Context, provider *oidcProvider, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	if o.now().Sub(o.keysFetched) < keyRefreshInterval {
+		return nil, fmt.Errorf("unknown id_token signing key %q", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := o.getJSON(ctx, provider.JWKSURI, &set); err != nil {
+		return nil, err
+	}
+	o.keysFetched = o.now()
+	o.keys = make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			o.keys[jwk.Kid] = key
+		}
+	}
+
+	if key, ok := o.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown id_token signing key %q", kid)
+}
+
+// getJSON fetches url and decodes the JSON response into v.
+func (o *OIDC) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch %s: status %d", url, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", url, err)
+	}
+	return nil
+}
+
+// oauthConfig returns the OAuth2 client for provider.
+func (o *OIDC) oauthConfig(provider *oidcProvider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     o.cfg.ClientID,
+		ClientSecret: o.cfg.ClientSecret,
+		RedirectURL:  o.cfg.RedirectURL,
+		Scopes:       o.cfg.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  provider.AuthorizationEndpoint,
+			TokenURL: provider.TokenEndpoint,
+		},
+	}
+}
+
+// encode signs session as a cookie value.
+func (o *OIDC) encode(session oidcSession) string {
+	payload, _ := json.Marshal(session)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(o.sign(encoded))
+}
+
+// decode verifies and decodes a cookie value, rejecting expired sessions.
+func (o *OIDC) decode(value string) (oidcSession, bool) {
+	var session oidcSession
+	encoded, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return session, false
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, o.sign(encoded)) {
+		return session, false
+	}
+	if err := decodeSegment(encoded, &session); err != nil {
+		return session, false
+	}
+	return session, o.now().Unix() < session.Expiry
+}
+
+// sign returns the HMAC of a cookie payload.
+func (o *OIDC) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, o.sessionKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// setCookie sets an HttpOnly cookie on the auth base path. A negative
+// maxAge deletes it.
+func (o *OIDC) setCookie(w http.ResponseWriter, name, value string, maxAge time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     o.basePath,
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// publicKey converts an RSA or EC key to a crypto.PublicKey.
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+// verifySignature checks a JWS signature made with alg.
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	var h hash.Hash
+	var hashID crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		h, hashID = sha256.New(), crypto.SHA256
+	case "384":
+		h, hashID = sha512.New384(), crypto.SHA384
+	case "512":
+		h, hashID = sha512.New(), crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported id_token algorithm %q", alg)
+	}
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch {
+	case strings.HasPrefix(alg, "RS"):
+		if key, ok := key.(*rsa.PublicKey); ok {
+			return rsa.VerifyPKCS1v15(key, hashID, digest, signature)
+		}
+	case strings.HasPrefix(alg, "PS"):
+		if key, ok := key.(*rsa.PublicKey); ok {
+			return rsa.VerifyPSS(key, hashID, digest, signature, nil)
+		}
+	case strings.HasPrefix(alg, "ES"):
+		if key, ok := key.(*ecdsa.PublicKey); ok {
+			size := (key.Curve.Params().BitSize + 7) / 8
+			if len(signature) != 2*size {
+				return fmt.Errorf("invalid id_token signature")
+			}
+			r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+			if !ecdsa.Verify(key, digest, r, s) {
+				return fmt.Errorf("invalid id_token signature")
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported id_token algorithm %q", alg)
+	}
+	return fmt.Errorf("id_token algorithm %s does not match the signing key", alg)
+}
+
+// decodeSegment decodes a base64url JSON segment into v.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// audienceContains reports whether an aud claim, a string or a list of
+// strings, contains clientID.
+func audienceContains(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, entry := range aud {
+			if entry == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// claimString returns a string claim, or "".
+func claimString(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// randomString returns 16 random bytes, base64url encoded.
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
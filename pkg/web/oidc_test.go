@@ -0,0 +1,252 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - OIDC Single Sign-On Tests
+//
+// Unit tests for the single sign-on login flow against a fake OpenID
+// Connect provider.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestOIDC_Login verifies a browser is sent to the provider, logged in on
+// the callback when in an allowed group, and rejected otherwise.
+func TestOIDC_Login(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []string
+		want   int
+	}{
+		{"allowed group", []string{"dev", "ops"}, http.StatusFound},
+		{"other group", []string{"dev"}, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestProvider(t, tt.groups)
+			dashboard := newSSODashboard(t, provider.URL)
+			handler := dashboard.Handler()
+
+			// An unauthenticated browser is sent to log in.
+			req := httptest.NewRequest(http.MethodGet, "/?tab=watched", nil)
+			req.Header.Set("Accept", "text/html")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/auth/login?return=%2F%3Ftab%3Dwatched" {
+				t.Fatalf("expected a redirect to log in, got %d %s", rec.Code, rec.Header().Get("Location"))
+			}
+
+			// Login redirects to the provider.
+			login := rec.Header().Get("Location")
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, login, nil))
+			authURL, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil || !strings.HasPrefix(authURL.String(), provider.URL+"/authorize") {
+				t.Fatalf("expected a redirect to the provider, got %d %s", rec.Code, rec.Header().Get("Location"))
+			}
+			provider.nonce = authURL.Query().Get("nonce")
+
+			// The provider redirects back with a code.
+			req = httptest.NewRequest(http.MethodGet, "/auth/callback?code=abc&state="+url.QueryEscape(authURL.Query().Get("state")), nil)
+			addCookies(req, rec)
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("expected %d from the callback, got %d: %s", tt.want, rec.Code, rec.Body.String())
+			}
+			if tt.want != http.StatusFound {
+				return
+			}
+			if rec.Header().Get("Location") != "/?tab=watched" {
+				t.Errorf("expected a redirect back to the page, got %s", rec.Header().Get("Location"))
+			}
+
+			// The session authenticates the dashboard and rotations.
+			req = httptest.NewRequest(http.MethodGet, "/", nil)
+			addCookies(req, rec)
+			page := httptest.NewRecorder()
+			handler.ServeHTTP(page, req)
+			if page.Code != http.StatusOK || !strings.Contains(page.Body.String(), "Signed in as alice@example.com") {
+				t.Errorf("expected the dashboard for alice, got %d", page.Code)
+			}
+
+			req = httptest.NewRequest(http.MethodPost, "/api/rotate/all?dry_run=true", nil)
+			addCookies(req, rec)
+			rotate := httptest.NewRecorder()
+			handler.ServeHTTP(rotate, req)
+			if rotate.Code != http.StatusOK {
+				t.Errorf("expected rotation to be allowed, got %d", rotate.Code)
+			}
+		})
+	}
+}
+
+// TestOIDC_Session verifies tampered sessions and off-site return paths
+// are rejected.
+func TestOIDC_Session(t *testing.T) {
+	oidc, err := NewOIDC(OIDCConfig{IssuerURL: "http://idp.example", ClientID: "vcm", RedirectURL: "http://dash.example/auth/callback"})
+	if err != nil {
+		t.Fatalf("NewOIDC failed: %v", err)
+	}
+
+	valid := oidc.encode(oidcSession{User: "alice", Expiry: time.Now().Add(time.Hour).Unix()})
+	expired := oidc.encode(oidcSession{User: "alice", Expiry: time.Now().Add(-time.Hour).Unix()})
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"u":"mallory","e":9999999999}`)) + valid[strings.Index(valid, "."):]
+
+	for value, want := range map[string]string{valid: "alice", expired: "", forged: ""} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookie, Value: value})
+		if got := oidc.User(req); got != want {
+			t.Errorf("expected user %q, got %q", want, got)
+		}
+	}
+
+	for _, returnTo := range []string{"//evil.example", "https://evil.example", "/\\evil.example"} {
+		rec := httptest.NewRecorder()
+		oidc.provider = &oidcProvider{AuthorizationEndpoint: "http://idp.example/authorize", TokenEndpoint: "t", JWKSURI: "k"}
+		oidc.handleLogin(rec, httptest.NewRequest(http.MethodGet, "/auth/login?return="+url.QueryEscape(returnTo), nil))
+		cookie := rec.Result().Cookies()[0]
+		login, _ := oidc.decode(cookie.Value)
+		if login.Return != "/" {
+			t.Errorf("expected return path %q to be replaced, got %q", returnTo, login.Return)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// testProvider is a fake OpenID Connect provider issuing ID tokens for
+// alice@example.com.
+type testProvider struct {
+	*httptest.Server
+	nonce string
+}
+
+// newTestProvider starts a provider whose ID tokens carry groups.
+func newTestProvider(t *testing.T, groups []string) *testProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	provider := &testProvider{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 provider.URL,
+			"authorization_endpoint": provider.URL + "/authorize",
+			"token_endpoint":         provider.URL + "/token",
+			"jwks_uri":               provider.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "abc" {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		idToken := signTestToken(t, key, map[string]interface{}{
+			"iss":    provider.URL,
+			"aud":    "vcm",
+			"sub":    "1234",
+			"email":  "alice@example.com",
+			"groups": groups,
+			"nonce":  provider.nonce,
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	})
+	provider.Server = httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+	return provider
+}
+
+// newSSODashboard creates a dashboard protected by single sign-on with
+// issuer, allowing the ops group.
+func newSSODashboard(t *testing.T, issuer string) *Dashboard {
+	t.Helper()
+
+	oidc, err := NewOIDC(OIDCConfig{
+		IssuerURL:     issuer,
+		ClientID:      "vcm",
+		ClientSecret:  "secret",
+		RedirectURL:   "http://dash.example/auth/callback",
+		AllowedGroups: []string{"ops"},
+	})
+	if err != nil {
+		t.Fatalf("NewOIDC failed: %v", err)
+	}
+	auth := NewAuth("", "", nil, true)
+	auth.SetOIDC(oidc)
+
+	dashboard := NewDashboard(cert.NewManager(nil), nil)
+	dashboard.SetAuth(auth)
+	return dashboard
+}
+
+// signTestToken returns claims as an RS256 JWT signed by key.
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// addCookies adds the cookies set on rec to req.
+func addCookies(req *http.Request, rec *httptest.ResponseRecorder) {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.MaxAge >= 0 {
+			req.AddCookie(cookie)
+		}
+	}
+}
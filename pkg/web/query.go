@@ -0,0 +1,219 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Status Queries
+//
+// Server-side filtering and sorting for nodes with dozens of certificates
+// and fleets with hundreds of nodes. The status, search, and sort query
+// parameters narrow and order /api/status, /api/events, and the dashboard
+// pages alike, so a filtered page keeps updating live.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// statusValues are the accepted values of the status parameter.
+var statusValues = []string{"healthy", "expiring", "critical", "unknown", "out_of_sync"}
+
+// sortKeys are the accepted values of the sort parameter, without the "-"
+// prefix that reverses the order.
+var sortKeys = []string{"name", "status", "days_left", "last_renewed"}
+
+// statusQuery selects and orders certificate statuses.
+type statusQuery struct {
+	Statuses []string // certificates with any of these statuses, all when empty
+	Search   string   // case-insensitive substring, see matches
+	Sort     string   // one of sortKeys, "-" prefixed for descending
+}
+
+// parseStatusQuery reads the status, search, and sort parameters of r.
+// status takes a comma-separated list.
+func parseStatusQuery(r *http.Request) (statusQuery, error) {
+	params := r.URL.Query()
+	q := statusQuery{
+		Search: strings.TrimSpace(params.Get("search")),
+		Sort:   params.Get("sort"),
+	}
+
+	for _, status := range strings.Split(params.Get("status"), ",") {
+		if status = strings.TrimSpace(status); status == "" {
+			continue
+		}
+		if !slices.Contains(statusValues, status) {
+			return statusQuery{}, fmt.Errorf("status must be one of %s, got %q", strings.Join(statusValues, ", "), status)
+		}
+		q.Statuses = append(q.Statuses, status)
+	}
+
+	if key := strings.TrimPrefix(q.Sort, "-"); q.Sort != "" && !slices.Contains(sortKeys, key) {
+		return statusQuery{}, fmt.Errorf("sort must be one of %s, optionally prefixed with -, got %q", strings.Join(sortKeys, ", "), q.Sort)
+	}
+	return q, nil
+}
+
+// Status returns the status parameter, for the dashboards' controls.
+func (q statusQuery) Status() string {
+	return strings.Join(q.Statuses, ",")
+}
+
+// filtering reports whether q drops any certificates.
+func (q statusQuery) filtering() bool {
+	return len(q.Statuses) > 0 || q.Search != ""
+}
+
+// empty reports whether q leaves statuses as they are.
+func (q statusQuery) empty() bool {
+	return !q.filtering() && q.Sort == ""
+}
+
+// matches reports whether status has one of q's statuses and contains
+// q's search term in its name, common name, role, issuer, or a label or
+// metadata value.
+func (q statusQuery) matches(status CertStatus) bool {
+	if len(q.Statuses) > 0 && !slices.ContainsFunc(q.Statuses, func(want string) bool {
+		return status.Status == want || (want == "out_of_sync" && status.OutOfSync)
+	}) {
+		return false
+	}
+	if q.Search == "" {
+		return true
+	}
+
+	fields := []string{status.Name, status.CommonName, status.Role, status.Issuer}
+	for _, value := range status.Labels {
+		fields = append(fields, value)
+	}
+	for _, value := range status.Metadata {
+		fields = append(fields, value)
+	}
+	return slices.ContainsFunc(fields, q.contains)
+}
+
+// contains reports whether s contains q's search term, ignoring case.
+func (q statusQuery) contains(s string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(q.Search))
+}
+
+// apply returns the statuses q matches, in q's order.
+func (q statusQuery) apply(statuses []CertStatus) []CertStatus {
+	matched := make([]CertStatus, 0, len(statuses))
+	for _, status := range statuses {
+		if q.matches(status) {
+			matched = append(matched, status)
+		}
+	}
+	q.sort(matched)
+	return matched
+}
+
+// applyDelta narrows delta to the certificates q matches. Certificates
+// that changed and no longer match are reported as removed, so clients
+// drop them from a filtered view.
+func (q statusQuery) applyDelta(delta StatusDelta) StatusDelta {
+	changed := make([]CertStatus, 0, len(delta.Changed))
+	for _, status := range delta.Changed {
+		if q.matches(status) {
+			changed = append(changed, status)
+		} else if !delta.Full {
+			delta.Removed = append(delta.Removed, status.Name)
+		}
+	}
+	q.sort(changed)
+	delta.Changed = changed
+	sort.Strings(delta.Removed)
+	return delta
+}
+
+// applyNodes filters and orders each node's certificates. Nodes left
+// without certificates are dropped, except unreachable ones whose name
+// matches the search. With a sort other than name, nodes are ordered by
+// their first certificate, so the most urgent node comes first.
+func (q statusQuery) applyNodes(nodes []NodeStatus) []NodeStatus {
+	if q.empty() {
+		return nodes
+	}
+
+	result := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Error != "" {
+			if q.Search == "" || q.contains(node.Node) {
+				result = append(result, node)
+			}
+			continue
+		}
+		if q.Search != "" && q.contains(node.Node) {
+			// Every certificate of a matching node is shown.
+			node.Certs = statusQuery{Statuses: q.Statuses, Sort: q.Sort}.apply(node.Certs)
+		} else {
+			node.Certs = q.apply(node.Certs)
+		}
+		if len(node.Certs) > 0 || !q.filtering() {
+			result = append(result, node)
+		}
+	}
+
+	if key := strings.TrimPrefix(q.Sort, "-"); key != "" && key != "name" {
+		sort.SliceStable(result, func(i, j int) bool {
+			a, b := result[i].Certs, result[j].Certs
+			if len(a) == 0 || len(b) == 0 {
+				return len(b) == 0 && len(a) > 0
+			}
+			return q.less(a[0], b[0])
+		})
+	}
+	return result
+}
+
+// sort orders statuses by q's sort key, leaving them as they are without
+// one.
+func (q statusQuery) sort(statuses []CertStatus) {
+	if q.Sort == "" {
+		return
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return q.less(statuses[i], statuses[j])
+	})
+}
+
+// less reports whether a sorts before b, ties broken by name.
+func (q statusQuery) less(a, b CertStatus) bool {
+	var cmp int
+	switch strings.TrimPrefix(q.Sort, "-") {
+	case "status":
+		cmp = severity(a) - severity(b)
+	case "days_left":
+		cmp = a.NotAfter.Compare(b.NotAfter)
+	case "last_renewed":
+		cmp = a.LastRenewed.Compare(b.LastRenewed)
+	case "name":
+		cmp = strings.Compare(a.Name, b.Name)
+	}
+	if strings.HasPrefix(q.Sort, "-") {
+		cmp = -cmp
+	}
+	if cmp != 0 {
+		return cmp < 0
+	}
+	return a.Name < b.Name
+}
+
+// severity ranks a status for sort=status, most urgent first.
+func severity(status CertStatus) int {
+	switch {
+	case status.Status == "critical":
+		return 0
+	case status.OutOfSync:
+		return 1
+	case status.Status == "unknown":
+		return 2
+	case status.Status == "expiring":
+		return 3
+	default:
+		return 4
+	}
+}
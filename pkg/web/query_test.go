@@ -0,0 +1,160 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Status Query Tests
+//
+// Unit tests for filtering and sorting certificate statuses.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestStatusQuery_Apply verifies filtering by status and search term and
+// each sort order.
+func TestStatusQuery_Apply(t *testing.T) {
+	now := time.Now()
+	statuses := []CertStatus{
+		{Name: "api", CommonName: "api.example.com", Status: "expiring", NotAfter: now.Add(20 * 24 * time.Hour), LastRenewed: now.Add(-time.Hour)},
+		{Name: "db", CommonName: "db.internal", Status: "critical", NotAfter: now.Add(2 * 24 * time.Hour), Labels: map[string]string{"team": "payments"}},
+		{Name: "web", CommonName: "www.example.com", Status: "healthy", OutOfSync: true, NotAfter: now.Add(60 * 24 * time.Hour), LastRenewed: now},
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"api", "db", "web"}},
+		{"status=critical", []string{"db"}},
+		{"status=critical,expiring", []string{"api", "db"}},
+		{"status=out_of_sync", []string{"web"}},
+		{"search=EXAMPLE", []string{"api", "web"}},
+		{"search=payments", []string{"db"}},
+		{"sort=days_left", []string{"db", "api", "web"}},
+		{"sort=-days_left", []string{"web", "api", "db"}},
+		{"sort=status", []string{"db", "web", "api"}},
+		{"sort=-last_renewed", []string{"web", "api", "db"}},
+		{"search=example&sort=-name", []string{"web", "api"}},
+	}
+
+	for _, tt := range tests {
+		q, err := parseStatusQuery(httptest.NewRequest(http.MethodGet, "/api/status?"+tt.query, nil))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.query, err)
+		}
+		var got []string
+		for _, status := range q.apply(statuses) {
+			got = append(got, status.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+
+	for _, query := range []string{"status=broken", "sort=size"} {
+		if _, err := parseStatusQuery(httptest.NewRequest(http.MethodGet, "/api/status?"+query, nil)); err == nil {
+			t.Errorf("%q: expected an error", query)
+		}
+	}
+}
+
+// TestStatusQuery_ApplyNodes verifies nodes without matching certificates
+// are dropped, a matching node name keeps all of its certificates, and
+// nodes follow their most urgent certificate.
+func TestStatusQuery_ApplyNodes(t *testing.T) {
+	now := time.Now()
+	nodes := []NodeStatus{
+		{Node: "node-a", Certs: []CertStatus{{Name: "web", Status: "healthy", NotAfter: now.Add(60 * 24 * time.Hour)}}},
+		{Node: "node-b", Certs: []CertStatus{{Name: "web", Status: "healthy", NotAfter: now.Add(50 * 24 * time.Hour)}, {Name: "db", Status: "critical", NotAfter: now.Add(24 * time.Hour)}}},
+		{Node: "node-c", Error: "connection refused"},
+	}
+
+	tests := []struct {
+		q    statusQuery
+		want map[string][]string
+	}{
+		{statusQuery{Statuses: []string{"critical"}}, map[string][]string{"node-b": {"db"}, "node-c": nil}},
+		{statusQuery{Search: "node-a"}, map[string][]string{"node-a": {"web"}}},
+		{statusQuery{Search: "db"}, map[string][]string{"node-b": {"db"}}},
+	}
+	for _, tt := range tests {
+		got := make(map[string][]string)
+		for _, node := range tt.q.applyNodes(nodes) {
+			var names []string
+			for _, status := range node.Certs {
+				names = append(names, status.Name)
+			}
+			got[node.Node] = names
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v: expected %v, got %v", tt.q, tt.want, got)
+		}
+	}
+
+	sorted := statusQuery{Sort: "days_left"}.applyNodes(nodes)
+	if len(sorted) != 3 || sorted[0].Node != "node-b" || sorted[0].Certs[0].Name != "db" || sorted[2].Node != "node-c" {
+		t.Errorf("expected node-b with db first and the unreachable node last, got %+v", sorted)
+	}
+}
+
+// TestDashboard_HandleAPIStatus_Query verifies /api/status is filtered, and
+// a filtered delta reports certificates that stopped matching as removed.
+func TestDashboard_HandleAPIStatus_Query(t *testing.T) {
+	dir := t.TempDir()
+	var configs []*config.CertificateConfig
+	for _, name := range []string{"api", "web"} {
+		configs = append(configs, &config.CertificateConfig{
+			Name:        name,
+			CommonName:  name + ".example.com",
+			Certificate: filepath.Join(dir, name+".crt"),
+			Key:         filepath.Join(dir, name+".key"),
+		})
+	}
+	manager := cert.NewManager(nil)
+	manager.SyncCertificates(configs)
+	handler := NewDashboard(manager, nil).Handler()
+
+	get := func(url string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		return rec
+	}
+
+	rec := get("/api/status?search=web")
+	var statuses []CertStatus
+	_ = json.Unmarshal(rec.Body.Bytes(), &statuses)
+	if rec.Code != http.StatusOK || len(statuses) != 1 || statuses[0].Name != "web" {
+		t.Fatalf("expected only web, got %d %s", rec.Code, rec.Body.String())
+	}
+	revision := rec.Header().Get(revisionHeader)
+
+	configs[1].CommonName = "www.example.org"
+	manager.SyncCertificates(configs)
+	rec = get("/api/status?search=web.example&since=" + revision)
+	var delta StatusDelta
+	_ = json.Unmarshal(rec.Body.Bytes(), &delta)
+	if len(delta.Changed) != 0 || !reflect.DeepEqual(delta.Removed, []string{"web"}) {
+		t.Errorf("expected web to be removed from the filtered view, got %s", rec.Body.String())
+	}
+
+	if rec := get("/api/status?status=broken"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown status, got %d", rec.Code)
+	}
+}
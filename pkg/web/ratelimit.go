@@ -0,0 +1,187 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - API Rate Limiting
+//
+// Token bucket rate limiting for mutating API endpoints on nodes and the
+// aggregator. Each client, identified by the user it authenticated as or
+// its remote IP, gets its own bucket per endpoint so a runaway script cannot
+// hammer Vault and reload target services, while other clients and
+// endpoints are unaffected.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// sweepInterval is how often idle, fully refilled buckets are dropped.
+const sweepInterval = time.Minute
+
+// RateLimiter enforces a per-client, per-endpoint token bucket on wrapped
+// handlers.
+type RateLimiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket // keyed by endpoint and client
+	lastSweep time.Time
+	now       func() time.Time
+
+	limited *prometheus.CounterVec
+	allowed *prometheus.CounterVec
+	clients prometheus.GaugeFunc
+}
+
+// bucket holds a client's remaining tokens as of last.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter allowing requestsPerMinute sustained
+// mutating requests per client, with bursts of up to burst requests.
+func NewRateLimiter(requestsPerMinute float64, burst int) *RateLimiter {
+	l := &RateLimiter{
+		rate:    requestsPerMinute / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+
+		limited: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "managed_cert_api_rate_limited_total",
+				Help: "The total number of mutating API requests rejected with 429 Too Many Requests.",
+			},
+			[]string{"endpoint"},
+		),
+		allowed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "managed_cert_api_requests_allowed_total",
+				Help: "The total number of mutating API requests admitted by the rate limiter.",
+			},
+			[]string{"endpoint"},
+		),
+	}
+
+	l.clients = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "managed_cert_api_rate_limit_clients",
+			Help: "The number of client buckets currently tracked by the API rate limiter, one per endpoint used.",
+		},
+		func() float64 {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			return float64(len(l.buckets))
+		},
+	)
+
+	return l
+}
+
+// Describe implements prometheus.Collector.
+func (l *RateLimiter) Describe(ch chan<- *prometheus.Desc) {
+	l.limited.Describe(ch)
+	l.allowed.Describe(ch)
+	l.clients.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (l *RateLimiter) Collect(ch chan<- prometheus.Metric) {
+	l.limited.Collect(ch)
+	l.allowed.Collect(ch)
+	l.clients.Collect(ch)
+}
+
+// Wrap limits non-GET requests to handler, answering 429 with Retry-After
+// when the client's bucket for endpoint is empty. Wrap inside Auth.Require,
+// so clients are keyed on their verified identity and unauthenticated
+// requests are rejected before they get a bucket. A nil limiter returns
+// handler as is.
+func (l *RateLimiter) Wrap(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	if l == nil {
+		return handler
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			handler(w, r)
+			return
+		}
+
+		client := clientKey(r)
+		ok, retryAfter := l.allow(endpoint + " " + client)
+		if !ok {
+			l.limited.WithLabelValues(endpoint).Inc()
+			slog.Warn("API rate limit exceeded", "endpoint", endpoint, "client", client, "retry_after", retryAfter)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "Rate limit exceeded"})
+			return
+		}
+
+		l.allowed.WithLabelValues(endpoint).Inc()
+		handler(w, r)
+	}
+}
+
+// allow takes a token from the bucket for key, returning how long until
+// the next token when none is left.
+func (l *RateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled completely, since a fresh bucket
+// is equivalent. It requires l.mu to be locked.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// clientKey identifies the caller by the user Auth.Require verified, or
+// by its remote IP without authentication. Unverified credentials are never
+// used, since a client could present a new one with every request.
+func clientKey(r *http.Request) string {
+	if user := RequestUser(r); user != "" {
+		return "user:" + user
+	}
+	return "ip:" + remoteHost(r)
+}
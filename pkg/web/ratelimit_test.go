@@ -0,0 +1,177 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - API Rate Limiting Tests
+//
+// Unit tests for per-client token buckets on mutating endpoints.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestRateLimiter_Wrap verifies clients get their own bucket per endpoint,
+// exhausted buckets answer 429 with Retry-After, and tokens refill over
+// time.
+func TestRateLimiter_Wrap(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(60, 2)
+	limiter.now = func() time.Time { return now }
+
+	auth := NewAuth("", "", []string{"automation"}, false)
+	handler := auth.Require(limiter.Wrap("rotate", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	anonymous := limiter.Wrap("rotate", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	post := func(remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/rotate/web", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		if token == "" {
+			anonymous(rec, req)
+			return rec
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		handler(rec, req)
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := post("10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200 within burst, got %d", i, rec.Code)
+		}
+	}
+
+	rec := post("10.0.0.1:5678", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after burst, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected Retry-After 1, got %q", rec.Header().Get("Retry-After"))
+	}
+
+	if rec := post("10.0.0.2:1234", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected another IP to have its own bucket, got %d", rec.Code)
+	}
+	fleet := limiter.Wrap("rotate_fleet", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	fleetReq := httptest.NewRequest(http.MethodPost, "/api/rotate-fleet", nil)
+	fleetReq.RemoteAddr = "10.0.0.1:1234"
+	fleetRec := httptest.NewRecorder()
+	fleet(fleetRec, fleetReq)
+	if fleetRec.Code != http.StatusOK {
+		t.Errorf("expected another endpoint to have its own bucket, got %d", fleetRec.Code)
+	}
+	if rec := post("10.0.0.1:1234", "automation"); rec.Code != http.StatusOK {
+		t.Errorf("expected an authenticated user to have its own bucket, got %d", rec.Code)
+	}
+
+	// Unverified tokens are rejected before reaching the limiter, so
+	// rotating them neither evades the limit nor grows the bucket map.
+	for i := 0; i < 10; i++ {
+		if rec := post("10.0.0.3:1234", fmt.Sprintf("random-%d", i)); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("expected 401 for an invalid token, got %d", rec.Code)
+		}
+	}
+	limiter.mu.Lock()
+	clients := len(limiter.buckets)
+	limiter.mu.Unlock()
+	if clients != 4 {
+		t.Errorf("expected 4 tracked buckets, got %d", clients)
+	}
+
+	get := httptest.NewRequest(http.MethodGet, "/api/rotate/web", nil)
+	get.RemoteAddr = "10.0.0.1:1234"
+	getRec := httptest.NewRecorder()
+	anonymous(getRec, get)
+	if getRec.Code != http.StatusOK {
+		t.Errorf("expected GET to bypass the limiter, got %d", getRec.Code)
+	}
+
+	now = now.Add(time.Second)
+	if rec := post("10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected a token after refill, got %d", rec.Code)
+	}
+
+	if got := counterValue(t, limiter, "managed_cert_api_rate_limited_total"); got != 1 {
+		t.Errorf("expected 1 limited request, got %v", got)
+	}
+	if got := counterValue(t, limiter, "managed_cert_api_requests_allowed_total"); got != 6 {
+		t.Errorf("expected 6 allowed requests, got %v", got)
+	}
+}
+
+// TestRateLimiter_Sweep verifies fully refilled buckets are dropped.
+func TestRateLimiter_Sweep(t *testing.T) {
+	now := time.Now()
+	limiter := NewRateLimiter(60, 1)
+	limiter.now = func() time.Time { return now }
+
+	limiter.allow("ip:10.0.0.1")
+	now = now.Add(2 * sweepInterval)
+	limiter.allow("ip:10.0.0.2")
+
+	if _, ok := limiter.buckets["ip:10.0.0.1"]; ok {
+		t.Error("expected idle bucket to be swept")
+	}
+	if len(limiter.buckets) != 1 {
+		t.Errorf("expected 1 tracked client, got %d", len(limiter.buckets))
+	}
+}
+
+// TestRateLimiter_Nil verifies a nil limiter leaves handlers unwrapped.
+func TestRateLimiter_Nil(t *testing.T) {
+	var limiter *RateLimiter
+	called := false
+	limiter.Wrap("rotate", func(http.ResponseWriter, *http.Request) { called = true })(
+		httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/rotate/all", nil))
+
+	if !called {
+		t.Error("expected handler to be called")
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// counterValue sums a counter family gathered from collector.
+func counterValue(t *testing.T, collector prometheus.Collector, name string) float64 {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	var total float64
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			total += m.GetCounter().GetValue()
+		}
+	}
+	return total
+}
@@ -0,0 +1,119 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Compliance Report Handlers
+//
+// Bridges certificate status into the report package. Builds per-node
+// reports for the CLI and fleet-wide reports for the aggregator, which
+// serves them as signed HTML or PDF.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"cert-manager/pkg/report"
+)
+
+// BuildReport creates a compliance report for the local node.
+func (d *Dashboard) BuildReport(period time.Duration) *report.Report {
+	return report.New(reportEntries(getHostname(), d.getCertStatuses()), period, time.Now())
+}
+
+// handleAPIReport renders a fleet-wide compliance report. The reporting
+// window is taken from the "period" query parameter, and "format=pdf"
+// selects PDF instead of HTML.
+func (a *Aggregator) handleAPIReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	period := report.DefaultPeriod
+	if p := r.URL.Query().Get("period"); p != "" {
+		parsed, err := time.ParseDuration(p)
+		if err != nil {
+			http.Error(w, "Invalid period: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		period = parsed
+	}
+
+	format := report.FormatHTML
+	contentType := "text/html; charset=utf-8"
+	switch f := r.URL.Query().Get("format"); f {
+	case "", report.FormatHTML:
+	case report.FormatPDF:
+		format = report.FormatPDF
+		contentType = "application/pdf"
+	default:
+		http.Error(w, "Invalid format: "+f, http.StatusBadRequest)
+		return
+	}
+
+	statuses, err := a.fetchAllStatuses()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var entries []report.Entry
+	for _, node := range statuses {
+		entries = append(entries, reportEntries(node.Node, node.Certs)...)
+	}
+
+	var buf bytes.Buffer
+	if err := report.New(entries, period, time.Now()).Render(&buf, format); err != nil {
+		slog.Error("Failed to render report", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if a.reportSigner != nil {
+		sig, err := report.Sign(a.reportSigner, buf.Bytes())
+		if err != nil {
+			slog.Error("Failed to sign report", "error", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Report-Signature", base64.StdEncoding.EncodeToString(sig))
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// reportEntries converts certificate statuses into report entries.
+func reportEntries(node string, certs []CertStatus) []report.Entry {
+	entries := make([]report.Entry, 0, len(certs))
+	for _, c := range certs {
+		entry := report.Entry{
+			Node:         node,
+			Name:         c.Name,
+			CommonName:   c.CommonName,
+			Source:       c.Source,
+			Role:         c.Role,
+			Issuer:       c.Issuer,
+			KeyAlgorithm: c.KeyAlgorithm,
+			KeyBits:      c.KeyBits,
+			NotAfter:     c.NotAfter,
+			DaysLeft:     c.DaysLeft,
+			Status:       c.Status,
+			OutOfSync:    c.OutOfSync,
+			LastRenewed:  c.LastRenewed,
+		}
+		for _, event := range c.History {
+			entry.Rotations = append(entry.Rotations, report.Rotation{
+				Time:          event.Time,
+				Success:       event.Success,
+				Error:         event.Error,
+				CorrelationID: event.CorrelationID,
+			})
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,290 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Status Revisions
+//
+// Differential /api/status for high-frequency pollers. Each time the status
+// is served, certificates whose status changed get a new revision from a
+// counter that only increases while the process runs. Pollers pass the
+// revision they last saw as ?since= and receive only what changed after
+// it. The epoch identifies the process, so pollers notice restarts, which
+// reset the counter. The aggregator syncs nodes this way.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// revisionHeader carries the status revision on every /api/status
+	// response.
+	revisionHeader = "X-Status-Revision"
+
+	// epochHeader carries the status epoch on every /api/status response.
+	epochHeader = "X-Status-Epoch"
+
+	// maxRemovedCerts bounds how many removed certificates are remembered.
+	// Pollers asking for changes since before the oldest forgotten removal
+	// get the full status.
+	maxRemovedCerts = 1000
+)
+
+// StatusDelta is the /api/status?since= response.
+type StatusDelta struct {
+	Epoch    string       `json:"epoch"`
+	Revision uint64       `json:"revision"`
+	Full     bool         `json:"full,omitempty"` // changed holds every certificate
+	Changed  []CertStatus `json:"changed"`
+	Removed  []string     `json:"removed,omitempty"`
+}
+
+// statusRevisions tracks when each certificate's status last changed.
+type statusRevisions struct {
+	mu       sync.Mutex
+	epoch    string
+	revision uint64
+	certs    map[string]certRevision
+	removed  map[string]certRevision
+
+	// forgotten is the newest removal dropped to stay within
+	// maxRemovedCerts.
+	forgotten certRevision
+}
+
+// certRevision is when a certificate's status last changed.
+type certRevision struct {
+	hash     string
+	revision uint64
+	changed  time.Time
+}
+
+// nodeSnapshot is the aggregator's last synced status of one node.
+type nodeSnapshot struct {
+	epoch    string
+	revision uint64
+	certs    map[string]CertStatus
+}
+
+// newStatusRevisions starts revision tracking with a new epoch.
+func newStatusRevisions() *statusRevisions {
+	return &statusRevisions{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		certs:   make(map[string]certRevision),
+		removed: make(map[string]certRevision),
+	}
+}
+
+// observe records changes in statuses and returns the current revision.
+func (s *statusRevisions) observe(statuses []CertStatus) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observeLocked(statuses, time.Now())
+	return s.revision
+}
+
+// delta records changes in statuses and returns those after since, which
+// is a revision or an RFC 3339 time. A revision ahead of the counter comes
+// from before a restart, and a since before a forgotten removal could miss
+// it, so both get the full status.
+func (s *statusRevisions) delta(statuses []CertStatus, since string) (StatusDelta, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.observeLocked(statuses, time.Now())
+
+	var after func(certRevision) bool
+	full := false
+	if revision, err := strconv.ParseUint(since, 10, 64); err == nil {
+		full = revision > s.revision || revision < s.forgotten.revision
+		after = func(c certRevision) bool { return full || c.revision > revision }
+	} else if t, err := time.Parse(time.RFC3339, since); err == nil {
+		full = s.forgotten.changed.After(t)
+		after = func(c certRevision) bool { return full || c.changed.After(t) }
+	} else {
+		return StatusDelta{}, fmt.Errorf("since must be a revision or an RFC 3339 time, got %q", since)
+	}
+
+	delta := StatusDelta{Epoch: s.epoch, Revision: s.revision, Full: full, Changed: []CertStatus{}}
+	for _, status := range statuses {
+		if after(s.certs[status.Name]) {
+			delta.Changed = append(delta.Changed, status)
+		}
+	}
+	if !full {
+		for name, removed := range s.removed {
+			if after(removed) {
+				delta.Removed = append(delta.Removed, name)
+			}
+		}
+		sort.Strings(delta.Removed)
+	}
+	return delta, nil
+}
+
+// observeLocked bumps the revision for every certificate added, changed,
+// or removed since the last call, forgetting the oldest removals beyond
+// maxRemovedCerts. The caller must hold s.mu.
+func (s *statusRevisions) observeLocked(statuses []CertStatus, now time.Time) {
+	seen := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		seen[status.Name] = true
+
+		data, _ := json.Marshal(status)
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+
+		if current, ok := s.certs[status.Name]; ok && current.hash == hash {
+			continue
+		}
+		s.revision++
+		s.certs[status.Name] = certRevision{hash: hash, revision: s.revision, changed: now}
+		delete(s.removed, status.Name)
+	}
+
+	for name := range s.certs {
+		if seen[name] {
+			continue
+		}
+		s.revision++
+		s.removed[name] = certRevision{revision: s.revision, changed: now}
+		delete(s.certs, name)
+	}
+
+	if len(s.removed) <= maxRemovedCerts {
+		return
+	}
+	names := make([]string, 0, len(s.removed))
+	for name := range s.removed {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return s.removed[names[i]].revision < s.removed[names[j]].revision })
+	for _, name := range names[:len(names)-maxRemovedCerts] {
+		s.forgotten = s.removed[name]
+		delete(s.removed, name)
+	}
+}
+
+// syncNodeCerts returns a node's certificates, fetching only the changes
+// since the last sync when the node supports it and has not restarted.
+func (a *Aggregator) syncNodeCerts(base string) ([]CertStatus, error) {
+	a.nodesMu.Lock()
+	cached := a.nodes[base]
+	a.nodesMu.Unlock()
+
+	var snapshot *nodeSnapshot
+	var err error
+	if cached != nil {
+		snapshot, err = a.fetchNodeDelta(base, cached)
+	}
+	if snapshot == nil || err != nil {
+		if snapshot, err = a.fetchNodeFull(base); err != nil {
+			return nil, err
+		}
+	}
+
+	a.nodesMu.Lock()
+	if snapshot.epoch != "" {
+		a.nodes[base] = snapshot
+	} else {
+		delete(a.nodes, base)
+	}
+	a.nodesMu.Unlock()
+
+	certs := make([]CertStatus, 0, len(snapshot.certs))
+	for _, status := range snapshot.certs {
+		certs = append(certs, status)
+	}
+	return certs, nil
+}
+
+// fetchNodeFull fetches a node's full status. Nodes that do not send an
+// epoch are returned without one and are never synced differentially.
+func (a *Aggregator) fetchNodeFull(base string) (*nodeSnapshot, error) {
+	resp, err := a.nodeGet(base + "/api/status")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var certs []CertStatus
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, fmt.Errorf("decode error: %v", err)
+	}
+
+	snapshot := &nodeSnapshot{certs: make(map[string]CertStatus, len(certs))}
+	for _, status := range certs {
+		snapshot.certs[status.Name] = status
+	}
+	revision, err := strconv.ParseUint(resp.Header.Get(revisionHeader), 10, 64)
+	if err == nil {
+		snapshot.epoch, snapshot.revision = resp.Header.Get(epochHeader), revision
+	}
+	return snapshot, nil
+}
+
+// fetchNodeDelta applies a node's changes since cached. It returns nil
+// when the node restarted since cached was taken.
+func (a *Aggregator) fetchNodeDelta(base string, cached *nodeSnapshot) (*nodeSnapshot, error) {
+	resp, err := a.nodeGet(base + "/api/status?since=" + url.QueryEscape(strconv.FormatUint(cached.revision, 10)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		return nil, fmt.Errorf("node does not support differential status")
+	}
+
+	var delta StatusDelta
+	if err := json.Unmarshal(body, &delta); err != nil {
+		return nil, fmt.Errorf("decode error: %v", err)
+	}
+
+	snapshot := &nodeSnapshot{epoch: delta.Epoch, revision: delta.Revision, certs: make(map[string]CertStatus)}
+	switch {
+	case delta.Full:
+	case delta.Epoch != cached.epoch:
+		return nil, nil
+	default:
+		for name, status := range cached.certs {
+			snapshot.certs[name] = status
+		}
+		for _, name := range delta.Removed {
+			delete(snapshot.certs, name)
+		}
+	}
+	for _, status := range delta.Changed {
+		snapshot.certs[status.Name] = status
+	}
+	return snapshot, nil
+}
+
+// pruneNodes forgets synced nodes that are no longer discovered.
+func (a *Aggregator) pruneNodes(bases map[string]bool) {
+	a.nodesMu.Lock()
+	defer a.nodesMu.Unlock()
+	for base := range a.nodes {
+		if !bases[base] {
+			delete(a.nodes, base)
+		}
+	}
+}
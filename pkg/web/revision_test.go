@@ -0,0 +1,182 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Status Revision Tests
+//
+// Unit tests for differential /api/status and the aggregator's
+// differential node sync.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/config"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestStatusRevisions_Delta verifies only changed and removed certificates
+// are returned after a revision or time, and stale revisions get everything.
+func TestStatusRevisions_Delta(t *testing.T) {
+	revisions := newStatusRevisions()
+
+	initial := revisions.observe([]CertStatus{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	if initial != 3 {
+		t.Fatalf("expected revision 3 after adding three certificates, got %d", initial)
+	}
+	if again := revisions.observe([]CertStatus{{Name: "a"}, {Name: "b"}, {Name: "c"}}); again != initial {
+		t.Errorf("expected unchanged statuses to keep revision %d, got %d", initial, again)
+	}
+	checkpoint := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	current := []CertStatus{{Name: "a", Status: "expiring"}, {Name: "b"}}
+	delta, err := revisions.delta(current, strconv.FormatUint(initial, 10))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if delta.Revision != 5 || delta.Full || delta.Epoch != revisions.epoch {
+		t.Errorf("unexpected delta header: %+v", delta)
+	}
+	if len(delta.Changed) != 1 || delta.Changed[0].Name != "a" {
+		t.Errorf("expected only a changed, got %+v", delta.Changed)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0] != "c" {
+		t.Errorf("expected c removed, got %v", delta.Removed)
+	}
+
+	byTime, err := revisions.delta(current, checkpoint.Format(time.RFC3339Nano))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(byTime.Changed) != 1 || len(byTime.Removed) != 1 {
+		t.Errorf("expected the same changes since the checkpoint time, got %+v", byTime)
+	}
+
+	upToDate, _ := revisions.delta(current, strconv.FormatUint(delta.Revision, 10))
+	if len(upToDate.Changed) != 0 || len(upToDate.Removed) != 0 {
+		t.Errorf("expected no changes at the current revision, got %+v", upToDate)
+	}
+
+	stale, _ := revisions.delta(current, "1000")
+	if !stale.Full || len(stale.Changed) != 2 {
+		t.Errorf("expected a full status for a revision from before a restart, got %+v", stale)
+	}
+
+	if _, err := revisions.delta(current, "yesterday"); err == nil {
+		t.Error("expected error for an invalid since")
+	}
+}
+
+// TestStatusRevisions_ForgetRemoved verifies only the newest removals are
+// remembered, and pollers that could have missed a forgotten one get the
+// full status.
+func TestStatusRevisions_ForgetRemoved(t *testing.T) {
+	revisions := newStatusRevisions()
+
+	var statuses []CertStatus
+	for i := 0; i < maxRemovedCerts+10; i++ {
+		statuses = append(statuses, CertStatus{Name: fmt.Sprintf("cert-%04d", i)})
+	}
+	before := revisions.observe(statuses)
+	revisions.observe(statuses[10:])
+	revisions.observe(statuses[len(statuses)-1:])
+	revisions.observe(nil)
+	if len(revisions.removed) != maxRemovedCerts {
+		t.Fatalf("expected %d removals remembered, got %d", maxRemovedCerts, len(revisions.removed))
+	}
+	if _, ok := revisions.removed["cert-0000"]; ok {
+		t.Error("expected the oldest removal forgotten")
+	}
+
+	stale, _ := revisions.delta(nil, strconv.FormatUint(before, 10))
+	if !stale.Full || len(stale.Removed) != 0 {
+		t.Errorf("expected a full status for a revision before a forgotten removal, got full=%v removed=%d", stale.Full, len(stale.Removed))
+	}
+	recent, _ := revisions.delta(nil, strconv.FormatUint(revisions.revision-1, 10))
+	if recent.Full || len(recent.Removed) != 1 || recent.Removed[0] != statuses[len(statuses)-1].Name {
+		t.Errorf("expected only the last removal, got full=%v removed=%v", recent.Full, recent.Removed)
+	}
+}
+
+// TestAggregator_SyncNodeCerts verifies the aggregator syncs a node
+// differentially after the first fetch and resyncs when it restarts.
+func TestAggregator_SyncNodeCerts(t *testing.T) {
+	manager := cert.NewManager(nil)
+	certConfig := func(name string) *config.CertificateConfig {
+		dir := t.TempDir()
+		return &config.CertificateConfig{Name: name, Certificate: dir + "/c.crt", Key: dir + "/c.key"}
+	}
+	web, db := certConfig("web"), certConfig("db")
+	manager.SyncCertificates([]*config.CertificateConfig{web})
+
+	var mu sync.Mutex
+	var queries []string
+	dashboard := NewDashboard(manager, nil)
+	mux := http.NewServeMux()
+	dashboard.RegisterHandlers(mux)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/status" {
+			mu.Lock()
+			queries = append(queries, r.URL.RawQuery)
+			mu.Unlock()
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	node := ConsulService{Node: "node-1", Address: host, ServicePort: port}
+	aggregator := NewAggregator("", "vault-cert-manager", time.Second)
+
+	names := func() []string {
+		status := aggregator.fetchNodeStatus(node)
+		if status.Error != "" {
+			t.Fatalf("unexpected error: %s", status.Error)
+		}
+		sortNodeStatuses([]NodeStatus{status})
+		var names []string
+		for _, c := range status.Certs {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	if got := names(); len(got) != 1 || got[0] != "web" {
+		t.Fatalf("expected web, got %v", got)
+	}
+
+	manager.SyncCertificates([]*config.CertificateConfig{db})
+	if got := names(); len(got) != 1 || got[0] != "db" {
+		t.Fatalf("expected db after web was replaced, got %v", got)
+	}
+
+	mu.Lock()
+	if len(queries) != 2 || queries[0] != "" || queries[1] != "since=1" {
+		t.Errorf("expected a full fetch then a differential one, got %q", queries)
+	}
+	mu.Unlock()
+
+	// A restarted node has a new epoch, and the aggregator resyncs fully.
+	dashboard.revisions = newStatusRevisions()
+	dashboard.revisions.epoch = "restarted"
+	manager.SyncCertificates([]*config.CertificateConfig{web, db})
+	if got := names(); len(got) != 2 || got[0] != "db" || got[1] != "web" {
+		t.Fatalf("expected db and web after restart, got %v", got)
+	}
+}
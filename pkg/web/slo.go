@@ -0,0 +1,88 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fleet Renewal SLO
+//
+// Measures renewal reliability across the fleet: the share of renewals in a
+// rolling window that happened at least a lead time before the replaced
+// certificate expired. Certificates that expired in the window without
+// being renewed count against the SLO. Exposed on the aggregator's
+// dashboard and /metrics.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"time"
+)
+
+const (
+	// DefaultSLOLead is how long before expiry a renewal must happen.
+	DefaultSLOLead = 7 * 24 * time.Hour
+
+	// DefaultSLOWindow is the rolling window renewals are counted over.
+	DefaultSLOWindow = 30 * 24 * time.Hour
+
+	// DefaultSLOTarget is the on-time percentage the fleet should meet.
+	DefaultSLOTarget = 99.0
+)
+
+// SLOConfig defines the renewal objective.
+type SLOConfig struct {
+	Lead   time.Duration
+	Window time.Duration
+	Target float64 // percent
+}
+
+// SLO is the renewal objective evaluated over the fleet.
+type SLO struct {
+	SLOConfig
+	OnTime  int
+	Late    int     // renewed within the lead time, or expired
+	Expired int     // expired in the window without renewal
+	Percent float64 // 100 when nothing was due
+}
+
+// Met reports whether the fleet meets the target.
+func (s SLO) Met() bool {
+	return s.Percent >= s.Target
+}
+
+// Total returns the number of renewals and expiries counted.
+func (s SLO) Total() int {
+	return s.OnTime + s.Late
+}
+
+// computeSLO evaluates the renewal objective over the fleet's rotation
+// history. Nodes that could not be reached are skipped.
+func computeSLO(nodes []NodeStatus, cfg SLOConfig, now time.Time) SLO {
+	slo := SLO{SLOConfig: cfg}
+	start := now.Add(-cfg.Window)
+
+	for _, node := range nodes {
+		if node.Error != "" {
+			continue
+		}
+		for _, c := range node.Certs {
+			for _, event := range c.History {
+				if !event.Success || event.PreviousNotAfter.IsZero() || event.Time.Before(start) {
+					continue
+				}
+				if event.PreviousNotAfter.Sub(event.Time) >= cfg.Lead {
+					slo.OnTime++
+				} else {
+					slo.Late++
+				}
+			}
+
+			if !c.NotAfter.IsZero() && c.NotAfter.After(start) && !c.NotAfter.After(now) {
+				slo.Expired++
+				slo.Late++
+			}
+		}
+	}
+
+	slo.Percent = 100
+	if total := slo.Total(); total > 0 {
+		slo.Percent = float64(slo.OnTime) / float64(total) * 100
+	}
+	return slo
+}
@@ -0,0 +1,95 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Fleet Renewal SLO Tests
+//
+// Unit tests for renewal SLO computation and its aggregator metrics.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"testing"
+	"time"
+
+	"cert-manager/pkg/cert"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestComputeSLO verifies on-time, late, and expired certificates are
+// counted within the window only.
+func TestComputeSLO(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	cfg := SLOConfig{Lead: 7 * day, Window: 30 * day, Target: 99}
+
+	nodes := []NodeStatus{
+		{
+			Node: "node-a",
+			Certs: []CertStatus{
+				{
+					Name:     "web",
+					NotAfter: now.Add(60 * day),
+					History: []cert.RotationEvent{
+						{Time: now.Add(-10 * day), Success: true, PreviousNotAfter: now.Add(10 * day)}, // on time
+						{Time: now.Add(-5 * day), Success: true, PreviousNotAfter: now.Add(-3 * day)},  // late
+						{Time: now.Add(-4 * day), Success: false},                                      // failures are not renewals
+						{Time: now.Add(-40 * day), Success: true, PreviousNotAfter: now},               // outside window
+						{Time: now.Add(-2 * day), Success: true},                                       // first issuance
+					},
+				},
+				{Name: "db", NotAfter: now.Add(-day)}, // expired
+			},
+		},
+		{Node: "node-b", Error: "connection refused", Certs: []CertStatus{{Name: "x", NotAfter: now.Add(-day)}}},
+	}
+
+	slo := computeSLO(nodes, cfg, now)
+	if slo.OnTime != 1 || slo.Late != 2 || slo.Expired != 1 {
+		t.Fatalf("expected 1 on time, 2 late, 1 expired, got %+v", slo)
+	}
+	if slo.Percent < 33.3 || slo.Percent > 33.4 {
+		t.Errorf("expected 33.3%%, got %.2f", slo.Percent)
+	}
+	if slo.Met() {
+		t.Error("expected SLO not to be met")
+	}
+
+	empty := computeSLO(nil, cfg, now)
+	if empty.Percent != 100 || !empty.Met() {
+		t.Errorf("expected an empty fleet to meet the SLO, got %+v", empty)
+	}
+}
+
+// TestAggregator_SLOMetrics verifies the SLO is exported on /metrics.
+func TestAggregator_SLOMetrics(t *testing.T) {
+	now := time.Now()
+	node := newTestNode(t, []CertStatus{{
+		Name:     "web",
+		NotAfter: now.Add(30 * 24 * time.Hour),
+		History: []cert.RotationEvent{
+			{Time: now.Add(-time.Hour), Success: true, PreviousNotAfter: now.Add(20 * 24 * time.Hour)},
+		},
+	}})
+	node.Node = "node-a"
+
+	aggregator := NewAggregator(newTestConsul(t, []ConsulService{node}), "vault-cert-manager", time.Second)
+
+	families, err := aggregator.registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	values := make(map[string]float64)
+	for _, mf := range families {
+		values[mf.GetName()] = mf.GetMetric()[0].GetGauge().GetValue()
+	}
+	if values["managed_cert_fleet_renewal_slo_ratio"] != 1 || values["managed_cert_fleet_renewals_on_time"] != 1 {
+		t.Errorf("unexpected SLO metrics: %v", values)
+	}
+}
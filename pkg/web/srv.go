@@ -0,0 +1,84 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - DNS SRV Node Discovery
+//
+// Discovers nodes from the targets of a DNS SRV record, such as
+// _vault-cert-manager._tcp.example.com, for fleets that publish their
+// nodes in DNS without running Consul. Lookups are cached for the refresh
+// interval, and the last good answer is kept while DNS fails.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultSRVRefresh is how long SRV lookups are cached by default.
+const DefaultSRVRefresh = 30 * time.Second
+
+// srvLookupTimeout bounds a single SRV lookup.
+const srvLookupTimeout = 10 * time.Second
+
+// SetSRVDiscovery discovers nodes from the SRV record name instead of
+// Consul, looking it up again at most every refresh.
+func (a *Aggregator) SetSRVDiscovery(name string, refresh time.Duration) {
+	a.srvName = strings.TrimSpace(name)
+	a.srvRefresh = refresh
+}
+
+// srvServices returns the nodes the SRV record points to, looking it up
+// again once the cached answer is older than the refresh interval. When a
+// lookup fails after an earlier one succeeded, the cached nodes are
+// returned.
+func (a *Aggregator) srvServices() ([]ConsulService, error) {
+	a.srvMu.Lock()
+	defer a.srvMu.Unlock()
+
+	if a.srvNodes != nil && time.Since(a.srvFetched) < a.srvRefresh {
+		return a.srvNodes, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), srvLookupTimeout)
+	defer cancel()
+	_, records, err := a.srvLookup(ctx, a.srvName)
+	if err != nil {
+		if a.srvNodes != nil {
+			slog.Warn("SRV lookup failed, using previous nodes", "name", a.srvName, "error", err)
+			return a.srvNodes, nil
+		}
+		return nil, fmt.Errorf("failed to look up SRV record %s: %w", a.srvName, err)
+	}
+
+	a.srvNodes = srvNodes(records)
+	a.srvFetched = time.Now()
+	return a.srvNodes, nil
+}
+
+// srvNodes converts SRV records to nodes named after their target host.
+// Targets listed with several ports are named host:port, so node names
+// stay unique. Priority and weight are ignored, since every node is
+// queried.
+func srvNodes(records []*net.SRV) []ConsulService {
+	ports := make(map[string]int, len(records))
+	for _, record := range records {
+		ports[strings.TrimSuffix(record.Target, ".")]++
+	}
+
+	nodes := make([]ConsulService, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		name := host
+		if ports[host] > 1 {
+			name = net.JoinHostPort(host, fmt.Sprint(record.Port))
+		}
+		nodes = append(nodes, ConsulService{Node: name, Address: host, ServicePort: int(record.Port)})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Node < nodes[j].Node })
+	return nodes
+}
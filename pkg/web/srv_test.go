@@ -0,0 +1,91 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - DNS SRV Node Discovery Tests
+//
+// Unit tests for discovering nodes from SRV records.
+// -------------------------------------------------------------------------------
+
+package web
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestSRVNodes verifies nodes are named after their target, with the port
+// added only for targets listed more than once.
+func TestSRVNodes(t *testing.T) {
+	nodes := srvNodes([]*net.SRV{
+		{Target: "web-2.example.com.", Port: 9101},
+		{Target: "web-1.example.com.", Port: 9101},
+		{Target: "db.example.com.", Port: 9101},
+		{Target: "db.example.com.", Port: 9201},
+	})
+
+	want := []ConsulService{
+		{Node: "db.example.com:9101", Address: "db.example.com", ServicePort: 9101},
+		{Node: "db.example.com:9201", Address: "db.example.com", ServicePort: 9201},
+		{Node: "web-1.example.com", Address: "web-1.example.com", ServicePort: 9101},
+		{Node: "web-2.example.com", Address: "web-2.example.com", ServicePort: 9101},
+	}
+	if !reflect.DeepEqual(nodes, want) {
+		t.Errorf("expected %+v, got %+v", want, nodes)
+	}
+}
+
+// TestAggregator_SRVDiscovery verifies SRV targets are queried as nodes,
+// lookups are cached for the refresh interval, and the previous nodes are
+// kept while lookups fail.
+func TestAggregator_SRVDiscovery(t *testing.T) {
+	node := newTestNode(t, []CertStatus{{Name: "web"}})
+
+	aggregator := NewAggregator("http://127.0.0.1:1", "vault-cert-manager", time.Second)
+	aggregator.SetSRVDiscovery("_vault-cert-manager._tcp.example.com", time.Hour)
+
+	lookups := 0
+	var lookupErr error
+	aggregator.srvLookup = func(ctx context.Context, name string) (string, []*net.SRV, error) {
+		lookups++
+		if name != "_vault-cert-manager._tcp.example.com" {
+			t.Errorf("unexpected SRV name %q", name)
+		}
+		return name, []*net.SRV{{Target: node.Address + ".", Port: uint16(node.ServicePort)}}, lookupErr
+	}
+
+	statuses, err := aggregator.fetchAllStatuses()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].Node != node.Address || len(statuses[0].Certs) != 1 {
+		t.Fatalf("expected the SRV target with its certificate, got %+v", statuses)
+	}
+
+	if _, err := aggregator.discoverServices(); err != nil || lookups != 1 {
+		t.Errorf("expected the cached answer, got %d lookups and error %v", lookups, err)
+	}
+
+	aggregator.srvRefresh = 0
+	lookupErr = errors.New("no such host")
+	services, err := aggregator.discoverServices()
+	if err != nil || len(services) != 1 || lookups != 2 {
+		t.Errorf("expected the previous nodes after a failed lookup, got %+v, %d lookups, and error %v", services, lookups, err)
+	}
+
+	fresh := NewAggregator("http://127.0.0.1:1", "vault-cert-manager", time.Second)
+	fresh.SetSRVDiscovery("_vault-cert-manager._tcp.example.com", time.Hour)
+	fresh.srvLookup = aggregator.srvLookup
+	if _, err := fresh.discoverServices(); err == nil {
+		t.Error("expected an error when the first lookup fails")
+	}
+}
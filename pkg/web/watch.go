@@ -0,0 +1,75 @@
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Watched Certificates
+//
+// Shows certificates that are watched but not managed, from files and TLS
+// endpoints, below the managed certificates on the dashboard and as JSON
+// on /api/watch. They cannot be rotated from here.
+// -------------------------------------------------------------------------------
+
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/watch"
+)
+
+// WatchStatus represents a watched certificate for the dashboard.
+type WatchStatus struct {
+	watch.Target
+	DaysLeft int    `json:"days_left"`
+	Status   string `json:"status"` // "healthy", "expiring", "critical", "unknown"
+}
+
+// SetWatchScanner shows the scanner's watched certificates on the
+// dashboard and /api/watch.
+func (d *Dashboard) SetWatchScanner(scanner *watch.Scanner) {
+	d.watchScanner = scanner
+}
+
+// handleAPIWatch returns watched certificate status as JSON.
+func (d *Dashboard) handleAPIWatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses := d.getWatchStatuses()
+	if statuses == nil {
+		statuses = []WatchStatus{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(statuses)
+}
+
+// getWatchStatuses builds status info for all watched certificates.
+func (d *Dashboard) getWatchStatuses() []WatchStatus {
+	if d.watchScanner == nil {
+		return nil
+	}
+
+	var statuses []WatchStatus
+	for _, target := range d.watchScanner.Targets() {
+		status := WatchStatus{Target: target, Status: "unknown"}
+		if target.Error == "" {
+			status.DaysLeft = cert.DaysLeft(target.NotAfter)
+			status.Status = expiryStatus(status.DaysLeft)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// expiryStatus classifies a certificate by the days left before it expires.
+func expiryStatus(daysLeft int) string {
+	switch {
+	case daysLeft <= 7:
+		return "critical"
+	case daysLeft <= 30:
+		return "expiring"
+	default:
+		return "healthy"
+	}
+}
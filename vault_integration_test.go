@@ -0,0 +1,412 @@
+//go:build integration
+
+// -------------------------------------------------------------------------------
+// vault-cert-manager - Vault Integration Tests
+//
+// End-to-end tests against a real Vault server. Runs against VAULT_ADDR and
+// VAULT_TOKEN when set, otherwise starts a Vault dev server in Docker. Each
+// run mounts a fresh PKI engine with a root CA and role, then exercises
+// issuance, renewal thresholds, the rotation API, and output files.
+//
+//	go test -tags=integration -run TestVault ./...
+// -------------------------------------------------------------------------------
+
+package main
+
+// -------------------------------------------------------------------------
+// IMPORTS
+// -------------------------------------------------------------------------
+
+import (
+	"bytes"
+	"cert-manager/pkg/cert"
+	"cert-manager/pkg/clock"
+	"cert-manager/pkg/config"
+	"cert-manager/pkg/health"
+	"cert-manager/pkg/vault"
+	"cert-manager/pkg/web"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"gopkg.in/yaml.v3"
+)
+
+// -------------------------------------------------------------------------
+// CONSTANTS
+// -------------------------------------------------------------------------
+
+const (
+	// defaultVaultImage is the image started when VAULT_ADDR is unset,
+	// overridable with VAULT_IMAGE.
+	defaultVaultImage = "hashicorp/vault:1.17"
+
+	// devRootToken is the root token of the dev server started in Docker.
+	devRootToken = "integration-root"
+
+	integrationRole = "integration"
+)
+
+// -------------------------------------------------------------------------
+// VARIABLES
+// -------------------------------------------------------------------------
+
+var (
+	// integrationVault is the Vault under test, set up by TestMain.
+	integrationVault struct {
+		addr     string
+		token    string
+		pkiMount string
+		caPEM    string
+	}
+
+	// integrationSkip is why Vault is unavailable, if it is.
+	integrationSkip string
+)
+
+// -------------------------------------------------------------------------
+// TESTS
+// -------------------------------------------------------------------------
+
+// TestMain prepares Vault before the integration tests run and removes the
+// Docker container afterwards.
+func TestMain(m *testing.M) {
+	stop, err := setupVault()
+	if err != nil {
+		integrationSkip = err.Error()
+	}
+	code := m.Run()
+	if stop != nil {
+		stop()
+	}
+	os.Exit(code)
+}
+
+// TestVault_Issue verifies a certificate is issued by the PKI mount and
+// written to the certificate, key, CA, and output files.
+func TestVault_Issue(t *testing.T) {
+	manager, cfg := newIntegrationManager(t, func(c *config.CertificateConfig) {
+		c.CAFile = filepath.Join(filepath.Dir(c.Certificate), "ca.crt")
+		c.ExcludeChain = true
+		c.Outputs = []config.OutputConfig{
+			{Type: "fullchain", Path: filepath.Join(filepath.Dir(c.Certificate), "fullchain.pem")},
+			{Type: "combined", Path: filepath.Join(filepath.Dir(c.Certificate), "combined.pem")},
+		}
+	})
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("ProcessCertificates failed: %v", err)
+	}
+
+	leaf := readCertificate(t, cfg.Certificate)
+	if leaf.Subject.CommonName != cfg.CommonName {
+		t.Errorf("expected common name %s, got %s", cfg.CommonName, leaf.Subject.CommonName)
+	}
+	ca := readCertificate(t, cfg.CAFile)
+	if err := leaf.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("certificate is not signed by the mount's CA: %v", err)
+	}
+	if got := strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}))); got != strings.TrimSpace(integrationVault.caPEM) {
+		t.Error("ca_file does not hold the mount's CA")
+	}
+
+	key, err := os.ReadFile(cfg.Key)
+	if err != nil || !bytes.Contains(key, []byte("PRIVATE KEY")) {
+		t.Errorf("expected a PEM private key in %s: %v", cfg.Key, err)
+	}
+	fullchain, _ := os.ReadFile(cfg.Outputs[0].Path)
+	if n := bytes.Count(fullchain, []byte("BEGIN CERTIFICATE")); n != 2 {
+		t.Errorf("expected fullchain output with 2 certificates, got %d", n)
+	}
+	combined, _ := os.ReadFile(cfg.Outputs[1].Path)
+	if !bytes.Contains(combined, []byte("PRIVATE KEY")) {
+		t.Error("expected combined output to hold the private key")
+	}
+}
+
+// TestVault_RenewalThreshold verifies a certificate is reissued only once
+// the clock enters its renewal window.
+func TestVault_RenewalThreshold(t *testing.T) {
+	fake := clock.NewFake(time.Now())
+	manager, cfg := newIntegrationManager(t, func(c *config.CertificateConfig) {
+		c.TTL = time.Hour
+		c.RenewBefore = 10 * time.Minute
+	})
+	manager.SetClock(fake)
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("ProcessCertificates failed: %v", err)
+	}
+	first := readCertificate(t, cfg.Certificate)
+
+	fake.Advance(30 * time.Minute)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("ProcessCertificates failed: %v", err)
+	}
+	if !readCertificate(t, cfg.Certificate).Equal(first) {
+		t.Fatal("expected no renewal outside the renewal window")
+	}
+
+	// Past renew_before plus the maximum jitter of half the window.
+	fake.Set(first.NotAfter.Add(-4 * time.Minute))
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("ProcessCertificates failed: %v", err)
+	}
+	second := readCertificate(t, cfg.Certificate)
+	if second.Equal(first) {
+		t.Fatal("expected renewal inside the renewal window")
+	}
+	if second.SerialNumber.Cmp(first.SerialNumber) == 0 {
+		t.Error("expected a new serial number")
+	}
+}
+
+// TestVault_RotateAPI verifies POST /api/rotate/{name} reissues the
+// certificate through Vault.
+func TestVault_RotateAPI(t *testing.T) {
+	manager, cfg := newIntegrationManager(t, nil)
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("ProcessCertificates failed: %v", err)
+	}
+	before := readCertificate(t, cfg.Certificate)
+
+	mux := http.NewServeMux()
+	web.NewDashboard(manager, health.NewTCPChecker()).RegisterHandlers(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/rotate/"+cfg.Name, "application/json", nil)
+	if err != nil {
+		t.Fatalf("rotate request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	after := readCertificate(t, cfg.Certificate)
+	if after.SerialNumber.Cmp(before.SerialNumber) == 0 {
+		t.Error("expected rotation to issue a new certificate")
+	}
+	if history := manager.Snapshot()[0].History; len(history) != 2 || !history[1].Success {
+		t.Errorf("expected two successful rotations in history, got %+v", history)
+	}
+}
+
+// TestVault_Keystore verifies the issued certificate and chain are written
+// as a JKS keystore and truststore.
+func TestVault_Keystore(t *testing.T) {
+	manager, cfg := newIntegrationManager(t, func(c *config.CertificateConfig) {
+		dir := filepath.Dir(c.Certificate)
+		c.Keystore = &config.KeystoreConfig{
+			Path:       filepath.Join(dir, "web.jks"),
+			Alias:      c.Name,
+			Password:   "changeit",
+			Truststore: filepath.Join(dir, "trust.jks"),
+		}
+	})
+
+	if err := manager.ProcessCertificates(); err != nil {
+		t.Fatalf("ProcessCertificates failed: %v", err)
+	}
+
+	for _, path := range []string{cfg.Keystore.Path, cfg.Keystore.Truststore} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("expected %s to be written: %v", path, err)
+		}
+		if !bytes.HasPrefix(data, []byte{0xFE, 0xED, 0xFE, 0xED}) {
+			t.Errorf("expected %s to be a JKS file", path)
+		}
+	}
+}
+
+// -------------------------------------------------------------------------
+// TEST HELPERS
+// -------------------------------------------------------------------------
+
+// setupVault connects to VAULT_ADDR or starts a dev server in Docker, then
+// mounts a PKI engine with a root CA and role. The returned function stops
+// the container, if one was started.
+func setupVault() (func(), error) {
+	addr, token := os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN")
+	var stop func()
+	if addr == "" {
+		var err error
+		if addr, stop, err = startVaultContainer(); err != nil {
+			return nil, err
+		}
+		token = devRootToken
+	}
+
+	client, err := vaultapi.NewClient(&vaultapi.Config{Address: addr})
+	if err != nil {
+		return stop, fmt.Errorf("failed to create Vault client: %w", err)
+	}
+	client.SetToken(token)
+
+	if err := waitForVault(client, 30*time.Second); err != nil {
+		return stop, err
+	}
+
+	mount := fmt.Sprintf("pki-integration-%d", time.Now().UnixNano())
+	if err := client.Sys().Mount(mount, &vaultapi.MountInput{
+		Type:   "pki",
+		Config: vaultapi.MountConfigInput{MaxLeaseTTL: "87600h"},
+	}); err != nil {
+		return stop, fmt.Errorf("failed to mount PKI engine: %w", err)
+	}
+
+	root, err := client.Logical().Write(mount+"/root/generate/internal", map[string]interface{}{
+		"common_name": "Integration Root CA",
+		"ttl":         "87600h",
+		"key_type":    "ec",
+		"key_bits":    256,
+	})
+	if err != nil {
+		return stop, fmt.Errorf("failed to generate root CA: %w", err)
+	}
+	caPEM, _ := root.Data["certificate"].(string)
+
+	if _, err := client.Logical().Write(mount+"/roles/"+integrationRole, map[string]interface{}{
+		"allowed_domains":  "example.com",
+		"allow_subdomains": true,
+		"max_ttl":          "720h",
+		"key_type":         "ec",
+		"key_bits":         256,
+	}); err != nil {
+		return stop, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	integrationVault.addr = addr
+	integrationVault.token = token
+	integrationVault.pkiMount = mount
+	integrationVault.caPEM = caPEM
+	return stop, nil
+}
+
+// startVaultContainer runs a Vault dev server on a random local port.
+func startVaultContainer() (string, func(), error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return "", nil, fmt.Errorf("VAULT_ADDR is unset and docker is not available")
+	}
+
+	image := os.Getenv("VAULT_IMAGE")
+	if image == "" {
+		image = defaultVaultImage
+	}
+
+	out, err := exec.Command("docker", "run", "-d", "--rm",
+		"--cap-add=IPC_LOCK",
+		"-e", "VAULT_DEV_ROOT_TOKEN_ID="+devRootToken,
+		"-p", "127.0.0.1::8200",
+		image).Output()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start Vault container: %w", err)
+	}
+	id := strings.TrimSpace(string(out))
+	stop := func() { _ = exec.Command("docker", "rm", "-f", id).Run() }
+
+	out, err = exec.Command("docker", "port", id, "8200/tcp").Output()
+	if err != nil {
+		stop()
+		return "", nil, fmt.Errorf("failed to read Vault container port: %w", err)
+	}
+	hostPort := strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
+	return "http://" + hostPort, stop, nil
+}
+
+// waitForVault polls until Vault is unsealed and answering.
+func waitForVault(client *vaultapi.Client, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		health, err := client.Sys().Health()
+		if err == nil && health.Initialized && !health.Sealed {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("vault at %s not ready after %s: %v", client.Address(), timeout, err)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// newIntegrationManager returns a manager with one certificate issued from
+// the integration PKI mount, after applying modify and validating the
+// certificate as a config file would be.
+func newIntegrationManager(t *testing.T, modify func(*config.CertificateConfig)) (*cert.Manager, *config.CertificateConfig) {
+	t.Helper()
+	if integrationSkip != "" {
+		t.Skip("Vault unavailable: " + integrationSkip)
+	}
+
+	dir := t.TempDir()
+	name := strings.ToLower(strings.ReplaceAll(t.Name(), "_", "-"))
+	certs := []config.CertificateConfig{{
+		Name:        name,
+		Role:        integrationRole,
+		CommonName:  name + ".example.com",
+		Certificate: filepath.Join(dir, "tls.crt"),
+		Key:         filepath.Join(dir, "tls.key"),
+		TTL:         24 * time.Hour,
+	}}
+	if modify != nil {
+		modify(&certs[0])
+	}
+
+	cfg := &config.Config{
+		Vault: config.VaultConfig{
+			Address:  integrationVault.addr,
+			PKIMount: integrationVault.pkiMount,
+			Auth:     config.AuthConfig{Token: &config.TokenAuth{Value: integrationVault.token}},
+		},
+	}
+	data, err := yaml.Marshal(map[string]interface{}{"certificates": certs})
+	if err != nil {
+		t.Fatalf("failed to encode certificate config: %v", err)
+	}
+	if certs, err = config.ParseCertificates(data, cfg); err != nil {
+		t.Fatalf("invalid certificate config: %v", err)
+	}
+
+	client, err := vault.NewClient(&cfg.Vault)
+	if err != nil {
+		t.Fatalf("failed to create Vault client: %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	manager := cert.NewManager(client)
+	certConfig := &certs[0]
+	if err := manager.AddCertificate(certConfig); err != nil {
+		t.Fatalf("failed to add certificate: %v", err)
+	}
+	return manager, certConfig
+}
+
+// readCertificate parses the first certificate in a PEM file.
+func readCertificate(t *testing.T, path string) *x509.Certificate {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("no PEM block in %s", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse %s: %v", path, err)
+	}
+	return cert
+}